@@ -6,18 +6,25 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	_ "github.com/ai-fitness-planner/backend/docs"
 	"github.com/ai-fitness-planner/backend/internal/config"
 	"github.com/ai-fitness-planner/backend/internal/middleware"
+	"github.com/ai-fitness-planner/backend/internal/migration"
+	"github.com/ai-fitness-planner/backend/internal/pkg/analyticssink"
 	"github.com/ai-fitness-planner/backend/internal/pkg/crypto"
 	"github.com/ai-fitness-planner/backend/internal/pkg/database"
+	"github.com/ai-fitness-planner/backend/internal/pkg/events"
+	"github.com/ai-fitness-planner/backend/internal/pkg/jobqueue"
 	"github.com/ai-fitness-planner/backend/internal/pkg/jwt"
 	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/pkg/mailer"
 	"github.com/ai-fitness-planner/backend/internal/pkg/redis"
 	"github.com/ai-fitness-planner/backend/internal/pkg/session"
+	"github.com/ai-fitness-planner/backend/internal/pkg/taskqueue"
 	"github.com/ai-fitness-planner/backend/internal/repository"
 	"github.com/ai-fitness-planner/backend/internal/router"
 	"github.com/ai-fitness-planner/backend/internal/service"
@@ -63,35 +70,55 @@ func main() {
 	}
 	logger.Info("Custom validators registered")
 
-	// Initialize database
-	if err := database.InitDatabase(); err != nil {
-		logger.Fatal("Failed to initialize database", zap.Error(err))
+	startupCfg := config.GlobalConfig.Startup
+
+	// Initialize database, retrying while it comes up
+	dbReady := true
+	if err := connectWithRetry("database", database.InitDatabase, startupCfg); err != nil {
+		if !startupCfg.DegradedMode {
+			logger.Fatal("Failed to initialize database", zap.Error(err))
+		}
+		logger.Warn("Database unavailable, starting in degraded mode", zap.Error(err))
+		dbReady = false
+	} else {
+		logger.Info("Database connection established")
+		autoMigrate()
 	}
 	defer database.Close()
-	logger.Info("Database connection established")
 
-	// Initialize Redis
-	if err := redis.InitRedis(); err != nil {
-		logger.Fatal("Failed to initialize Redis", zap.Error(err))
+	// Initialize Redis, retrying while it comes up
+	redisReady := true
+	if err := connectWithRetry("redis", redis.InitRedis, startupCfg); err != nil {
+		if !startupCfg.DegradedMode {
+			logger.Fatal("Failed to initialize Redis", zap.Error(err))
+		}
+		logger.Warn("Redis unavailable, starting in degraded mode", zap.Error(err))
+		redisReady = false
+	} else {
+		logger.Info("Redis connection established")
 	}
 	defer redis.Close()
-	logger.Info("Redis connection established")
 
-	// Setup dependencies
-	deps, err := setupDependencies()
-	if err != nil {
-		logger.Fatal("Failed to setup dependencies", zap.Error(err))
+	// Serve the full API once every dependency is up; until then only
+	// /health answers and everything else returns 503.
+	handler := &switchableHandler{}
+	if dbReady && redisReady {
+		ginRouter, err := buildRouter()
+		if err != nil {
+			logger.Fatal("Failed to setup dependencies", zap.Error(err))
+		}
+		handler.Store(ginRouter)
+	} else {
+		handler.Store(router.SetupDegradedRouter())
+		go recoverDependencies(handler, dbReady, redisReady, startupCfg)
 	}
 
-	// Initialize router with dependencies
-	ginRouter := router.SetupRouter(deps)
-
 	// Create HTTP server
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", config.GlobalConfig.App.Port),
-		Handler:      ginRouter,
+		Handler:      handler,
 		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: writeTimeout(config.GlobalConfig.Timeout),
 		IdleTimeout:  60 * time.Second,
 	}
 
@@ -118,13 +145,263 @@ func main() {
 		logger.Error("Server forced to shutdown", zap.Error(err))
 	}
 
+	// Let running generations finish; the rest are picked up after restart
+	if queue := backgroundQueue.Load(); queue != nil {
+		queueCtx, queueCancel := context.WithTimeout(context.Background(), config.GlobalConfig.Queue.ShutdownTimeout)
+		if err := queue.Shutdown(queueCtx); err != nil {
+			logger.Warn("Generation queue stopped with tasks still running", zap.Error(err))
+		}
+		queueCancel()
+	}
+
+	// Deliver the domain events still buffered for the broker
+	if streamer := eventStreamer.Load(); streamer != nil {
+		streamCtx, streamCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := streamer.Shutdown(streamCtx); err != nil {
+			logger.Warn("Event stream stopped with events undelivered", zap.Error(err))
+		}
+		streamCancel()
+	}
+
 	logger.Info("Server exited")
 }
 
+// backgroundQueue is the generation task queue, set once the dependencies
+// are wired so it can be drained on shutdown
+var backgroundQueue atomic.Pointer[taskqueue.Queue]
+
+// eventStreamer forwards domain events to the configured broker, set when
+// event streaming is enabled so its buffer can be flushed on shutdown
+var eventStreamer atomic.Pointer[events.Streamer]
+
+// logStreamError logs domain events that could not be streamed
+func logStreamError(event events.Event, err error) {
+	logger.Warn("Failed to stream domain event",
+		zap.String("event_id", event.ID),
+		zap.String("type", event.Type),
+		zap.Int64("user_id", event.UserID),
+		zap.Error(err),
+	)
+}
+
+// logQueueError logs failed generation attempts and queue errors
+func logQueueError(task *taskqueue.Task, err error) {
+	if task == nil {
+		logger.Error("Generation queue error", zap.Error(err))
+		return
+	}
+	logger.Warn("Generation task attempt failed",
+		zap.String("task_id", task.ID),
+		zap.String("type", task.Type),
+		zap.Int("attempt", task.Attempt),
+		zap.Int("max_attempts", task.MaxAttempts),
+		zap.Error(err),
+	)
+}
+
+// buildRouter wires all dependencies and returns the full API router
+func buildRouter() (http.Handler, error) {
+	deps, err := setupDependencies()
+	if err != nil {
+		return nil, err
+	}
+	return router.SetupRouter(deps), nil
+}
+
+// switchableHandler lets the degraded router be replaced by the full router
+// without restarting the HTTP server
+type switchableHandler struct {
+	current atomic.Value
+}
+
+// Store replaces the handler used for subsequent requests
+func (s *switchableHandler) Store(h http.Handler) {
+	s.current.Store(h)
+}
+
+// ServeHTTP dispatches to the current handler
+func (s *switchableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.current.Load().(http.Handler).ServeHTTP(w, r)
+}
+
+// writeTimeout outlasts the longest route timeout, so a request that ran
+// out of time can still be answered with 504
+func writeTimeout(cfg config.TimeoutConfig) time.Duration {
+	longest := 15 * time.Second
+	for _, t := range []time.Duration{cfg.Default, cfg.AI, cfg.Bulk} {
+		if t+5*time.Second > longest {
+			longest = t + 5*time.Second
+		}
+	}
+	return longest
+}
+
+// connectWithRetry runs initFn with bounded exponential backoff
+func connectWithRetry(name string, initFn func() error, cfg config.StartupConfig) error {
+	attempts := cfg.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = initFn(); err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		delay := startupBackoff(attempt, cfg.InitialDelay, cfg.MaxDelay)
+		logger.Warn("Dependency not ready, retrying",
+			zap.String("dependency", name),
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", delay),
+			zap.Error(err),
+		)
+		time.Sleep(delay)
+	}
+
+	return fmt.Errorf("%s unavailable after %d attempts: %w", name, attempts, err)
+}
+
+// startupBackoff returns the delay before the next attempt, doubling from
+// initial and capped at max
+func startupBackoff(attempt int, initial, max time.Duration) time.Duration {
+	if initial <= 0 {
+		initial = time.Second
+	}
+	delay := initial
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if max > 0 && delay >= max {
+			return max
+		}
+	}
+	if max > 0 && delay > max {
+		return max
+	}
+	return delay
+}
+
+// recoverDependencies keeps reconnecting the dependencies that failed at
+// startup and swaps in the full router once all of them are reachable. The
+// init functions publish each connection atomically, so the degraded /health
+// handler can read them meanwhile; the full router is only built from them
+// after that.
+func recoverDependencies(handler *switchableHandler, dbReady, redisReady bool, cfg config.StartupConfig) {
+	for attempt := 1; ; attempt++ {
+		time.Sleep(startupBackoff(attempt, cfg.InitialDelay, cfg.MaxDelay))
+
+		if !dbReady {
+			if err := database.InitDatabase(); err != nil {
+				logger.Warn("Database still unavailable", zap.Error(err))
+			} else {
+				logger.Info("Database connection established")
+				autoMigrate()
+				dbReady = true
+			}
+		}
+		if !redisReady {
+			if err := redis.InitRedis(); err != nil {
+				logger.Warn("Redis still unavailable", zap.Error(err))
+			} else {
+				logger.Info("Redis connection established")
+				redisReady = true
+			}
+		}
+		if !dbReady || !redisReady {
+			continue
+		}
+
+		ginRouter, err := buildRouter()
+		if err != nil {
+			logger.Error("Failed to setup dependencies", zap.Error(err))
+			continue
+		}
+		handler.Store(ginRouter)
+		logger.Info("All dependencies available, leaving degraded mode")
+		return
+	}
+}
+
+// runPeriodically runs a background job on a fixed interval. fn returns the
+// number of items it acted on, which is logged when non-zero.
+func runPeriodically(name string, interval time.Duration, fn func(ctx context.Context) (int, error)) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		count, err := fn(ctx)
+		cancel()
+		if err != nil {
+			logger.Error("Background job failed", zap.String("job", name), zap.Error(err))
+			continue
+		}
+		if count > 0 {
+			logger.Info("Background job completed", zap.String("job", name), zap.Int("count", count))
+		}
+	}
+}
+
+// autoMigrate applies the embedded schema migrations and default prompt
+// templates when database.mysql.auto_migrate is enabled
+func autoMigrate() {
+	if !config.GlobalConfig.Database.MySQL.AutoMigrate {
+		return
+	}
+
+	applied, err := migration.Migrate(database.GetDB())
+	if err != nil {
+		logger.Fatal("Failed to execute schema migration", zap.Error(err))
+	}
+	if err := migration.SeedPromptTemplates(database.GetDB()); err != nil {
+		logger.Fatal("Failed to insert initial data", zap.Error(err))
+	}
+	logger.Info("Database migration completed", zap.Strings("applied", applied))
+}
+
+// newSessionManager selects the session manager for the configured mode
+func newSessionManager(cfg *config.Config) session.SessionManager {
+	if cfg.Session.Mode == "stateless" {
+		logger.Info("Running in stateless session mode; logouts are kept in memory only")
+		// Denylist entries must outlive every token a logout can revoke
+		tokenTTL := cfg.JWT.AccessTokenExpire
+		for _, ttl := range []time.Duration{cfg.JWT.RefreshTokenExpire, cfg.JWT.ImpersonationExpire} {
+			if ttl > tokenTTL {
+				tokenTTL = ttl
+			}
+		}
+		return session.NewStatelessSessionManager(tokenTTL)
+	}
+
+	store := session.NewSessionManager(redis.Client())
+	if cfg.Session.SlidingExpiration {
+		store = session.NewSlidingSessionManager(store, session.SlidingConfig{
+			IdleTimeout:     cfg.Session.IdleTimeout,
+			RefreshInterval: cfg.Session.RefreshInterval,
+			MaxLifetime:     cfg.Session.MaxLifetime,
+		})
+	}
+
+	return session.NewFallbackSessionManager(
+		store,
+		session.FallbackConfig{
+			CacheTTL:     cfg.Session.LocalCacheTTL,
+			DegradedAuth: cfg.Session.DegradedAuth,
+		},
+	)
+}
+
 // setupDependencies initializes all dependencies for dependency injection
 func setupDependencies() (*router.Dependencies, error) {
 	db := database.GetDB()
-	redisClient := redis.Rdb
+	redisClient := redis.Client()
 
 	// Initialize utilities
 	encryptor, err := crypto.NewEncryptor(config.GlobalConfig.App.SecretKey)
@@ -132,12 +409,18 @@ func setupDependencies() (*router.Dependencies, error) {
 		return nil, fmt.Errorf("failed to create encryptor: %w", err)
 	}
 
+	serviceSecret := config.GlobalConfig.JWT.ServiceSecret
+	if serviceSecret != "" && serviceSecret == config.GlobalConfig.JWT.Secret {
+		logger.Error("Service tokens disabled, jwt.service_secret must differ from jwt.secret")
+		serviceSecret = ""
+	}
 	jwtManager := jwt.NewJWTManager(
 		config.GlobalConfig.JWT.Secret,
+		serviceSecret,
 		config.GlobalConfig.JWT.AccessTokenExpire,
 		config.GlobalConfig.JWT.RefreshTokenExpire,
 	)
-	sessionManager := session.NewSessionManager(redisClient)
+	sessionManager := newSessionManager(config.GlobalConfig)
 
 	// Initialize rate limiter
 	rateLimitConfig := &middleware.RateLimitConfig{
@@ -145,6 +428,8 @@ func setupDependencies() (*router.Dependencies, error) {
 		UserRequestsPerHour:   config.GlobalConfig.RateLimit.APICallsPerHour,
 		IPRequestsPerMinute:   100,
 		AIGenerationPerMinute: 2,
+		AIGenerationPerDay:    config.GlobalConfig.RateLimit.AIGenerationsPerDay,
+		AIGenerationPerMonth:  config.GlobalConfig.RateLimit.AIGenerationsPerMonth,
 	}
 	rateLimiter := middleware.NewRateLimiter(redisClient, rateLimitConfig)
 
@@ -158,17 +443,99 @@ func setupDependencies() (*router.Dependencies, error) {
 	assessmentRepo := repository.NewAssessmentRepository(db)
 	bodyDataRepo := repository.NewBodyDataRepository(db)
 	fitnessGoalRepo := repository.NewFitnessGoalRepository(db)
+	impersonationAuditRepo := repository.NewImpersonationAuditRepository(db)
+	serviceAuditRepo := repository.NewServiceAuditRepository(db)
+	serviceTokenRevocationRepo := repository.NewServiceTokenRevocationRepository(db)
+	abuseFlagRepo := repository.NewAbuseFlagRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	checkInRepo := repository.NewCheckInRepository(db)
+	strengthRepo := repository.NewStrengthProfileRepository(db)
+	equipmentRepo := repository.NewEquipmentProfileRepository(db)
+	constraintRepo := repository.NewTrainingConstraintRepository(db)
+	organizationRepo := repository.NewOrganizationRepository(db)
+	aiUsageRepo := repository.NewAIUsageRepository(db)
+	aiCallLogRepo := repository.NewAICallLogRepository(db)
+	generationTaskRepo := repository.NewGenerationTaskRepository(db)
+	responseArchiveRepo := repository.NewAIResponseArchiveRepository(db)
+	accountMergeRepo := repository.NewAccountMergeRepository(db)
+	promptTemplateRepo := repository.NewPromptTemplateRepository(db)
+	coachMessageRepo := repository.NewCoachMessageRepository(db)
+	macrocycleRepo := repository.NewMacrocycleRepository(db)
+
+	providerPolicy, err := service.NewProviderPolicy(config.GlobalConfig.AI.AllowedProviders)
+	if err != nil {
+		return nil, err
+	}
 
 	// Initialize services
 	authService := service.NewAuthService(userRepo, jwtManager, sessionManager)
 	userService := service.NewUserService(userRepo, bodyDataRepo, fitnessGoalRepo)
+	abuseDetector := service.NewAbuseDetector(redisClient, abuseFlagRepo, config.GlobalConfig.Abuse)
+	retryTuner := service.NewProviderRetryTuner(service.RetryTunerConfig{
+		BaseDelay: config.GlobalConfig.AI.RetryDelay,
+		MinDelay:  config.GlobalConfig.AI.RetryDelayMin,
+		MaxDelay:  config.GlobalConfig.AI.RetryDelayMax,
+		Window:    config.GlobalConfig.AI.RetryWindow,
+	})
+	circuitBreaker := service.NewProviderCircuitBreaker(service.CircuitBreakerConfig{
+		FailureThreshold: config.GlobalConfig.AI.CircuitFailureThreshold,
+		Cooldown:         config.GlobalConfig.AI.CircuitCooldown,
+	})
+	parseMetrics := service.NewParseFailureMetrics(redisClient)
 	aiService := service.NewAIService(
 		aiAPIRepo,
 		encryptor,
+		abuseDetector,
 		config.GlobalConfig.AI.RetryAttempts,
-		config.GlobalConfig.AI.RetryDelay,
+		config.GlobalConfig.AI.Timeout,
+		retryTuner,
+		circuitBreaker,
+		config.GlobalConfig.AI.MaxConcurrentRequests,
+		aiUsageRepo,
+		service.NewAIResponseCache(redisClient, config.GlobalConfig.AI.ResponseCacheTTL),
+		promptTemplateRepo,
+		aiCallLogRepo,
+		parseMetrics,
+		providerPolicy,
+	)
+	aiAPIService := service.NewAIAPIService(
+		aiAPIRepo,
+		encryptor,
+		circuitBreaker,
+		aiUsageRepo,
+		aiCallLogRepo,
+		config.GlobalConfig.AI.Timeout,
+		config.GlobalConfig.AI.Pricing,
+		providerPolicy,
+	)
+	strengthService := service.NewStrengthProfileService(strengthRepo)
+	queueCfg := config.GlobalConfig.Queue
+	generationQueue := taskqueue.New(redisClient, taskqueue.Config{
+		Namespace:     "queue:generation",
+		Workers:       queueCfg.Workers,
+		MaxAttempts:   queueCfg.MaxAttempts,
+		RetryDelay:    queueCfg.RetryDelay,
+		MaxRetryDelay: queueCfg.MaxRetryDelay,
+		Lease:         queueCfg.Lease,
+		PollInterval:  queueCfg.PollInterval,
+		OnError:       logQueueError,
+	})
+	equipmentService := service.NewEquipmentService(equipmentRepo)
+	constraintService := service.NewTrainingConstraintService(constraintRepo, trainingPlanRepo, notificationRepo)
+	generationLock := service.NewGenerationLock(redisClient, queueCfg.GenerationLockTTL)
+	archiveCfg := config.GlobalConfig.ResponseArchive
+	responseArchiveService := service.NewResponseArchiveService(
+		responseArchiveRepo,
+		userRepo,
+		aiAPIRepo,
+		archiveCfg.Enabled,
+		archiveCfg.RetentionDays,
 	)
-	aiAPIService := service.NewAIAPIService(aiAPIRepo, encryptor)
+	todayCfg := config.GlobalConfig.TodayCache
+	var todayCache service.TodayCache
+	if todayCfg.Enabled {
+		todayCache = service.NewTodayCache(redisClient)
+	}
 	trainingService := service.NewTrainingService(
 		trainingPlanRepo,
 		trainingRecordRepo,
@@ -176,7 +543,18 @@ func setupDependencies() (*router.Dependencies, error) {
 		assessmentRepo,
 		bodyDataRepo,
 		fitnessGoalRepo,
+		checkInRepo,
+		strengthService,
+		equipmentRepo,
+		constraintRepo,
+		userRepo,
 		aiService,
+		generationQueue,
+		generationLock,
+		generationTaskRepo,
+		queueCfg.TaskRetention,
+		todayCache,
+		responseArchiveService,
 	)
 	nutritionService := service.NewNutritionService(
 		nutritionPlanRepo,
@@ -184,26 +562,261 @@ func setupDependencies() (*router.Dependencies, error) {
 		aiAPIRepo,
 		bodyDataRepo,
 		fitnessGoalRepo,
+		checkInRepo,
 		aiService,
+		generationQueue,
+		generationLock,
+		generationTaskRepo,
+		queueCfg.TaskRetention,
+		todayCache,
+		responseArchiveService,
 	)
+	if todayCache != nil {
+		todayWarmupService := service.NewTodayWarmupService(redisClient, todayCache, trainingPlanRepo, nutritionPlanRepo)
+		go runPeriodically("today cache warmup", todayCfg.WarmupInterval, todayWarmupService.WarmToday)
+	}
+	if queueCfg.TaskRetention > 0 {
+		go runPeriodically("training task cleanup", queueCfg.TaskSweepInterval, trainingService.SweepExpiredTasks)
+		go runPeriodically("nutrition task cleanup", queueCfg.TaskSweepInterval, nutritionService.SweepExpiredTasks)
+	}
+	// Archives made while enabled are purged on schedule even once disabled
+	go runPeriodically("AI response archive purge", archiveCfg.PurgeInterval, responseArchiveService.Purge)
 	statisticsService := service.NewStatisticsService(
 		trainingRecordRepo,
 		bodyDataRepo,
+		strengthRepo,
+		userRepo,
+	)
+	exportCfg := config.GlobalConfig.Export
+	exportQueue := jobqueue.New(
+		map[jobqueue.Class]jobqueue.PoolConfig{
+			jobqueue.ClassLow: {Workers: exportCfg.Workers, QueueSize: exportCfg.QueueSize},
+		},
+		exportCfg.ResultTTL,
+		exportCfg.JobTimeout,
+	)
+	exportService := service.NewExportService(
+		trainingRecordRepo,
+		nutritionRecordRepo,
+		exportQueue,
+		exportCfg.SyncRowLimit,
+	)
+	cleanupService := service.NewCleanupService(
+		trainingPlanRepo,
+		nutritionPlanRepo,
+		nutritionRecordRepo,
+		aiCallLogRepo,
+		generationTaskRepo,
+		responseArchiveRepo,
+		coachMessageRepo,
+		exportQueue,
+		redisClient,
+	)
+	adminService := service.NewAdminService(
+		userRepo,
+		aiAPIRepo,
+		impersonationAuditRepo,
+		serviceAuditRepo,
+		serviceTokenRevocationRepo,
+		abuseFlagRepo,
+		generationTaskRepo,
+		accountMergeRepo,
+		jwtManager,
+		sessionManager,
+		config.GlobalConfig.JWT.ImpersonationExpire,
+		config.GlobalConfig.JWT.ServiceTokenExpire,
+		parseMetrics,
+	)
+	notificationService := service.NewNotificationService(notificationRepo)
+	mailCfg := config.GlobalConfig.Mail
+	provisioningService := service.NewProvisioningService(
+		organizationRepo,
+		userRepo,
+		sessionManager,
+		mailer.New(mailer.Config{
+			Host:     mailCfg.Host,
+			Port:     mailCfg.Port,
+			Username: mailCfg.Username,
+			Password: mailCfg.Password,
+			From:     mailCfg.From,
+		}),
+		config.GlobalConfig.Invite.TTL,
+		config.GlobalConfig.Invite.AcceptURL,
+	)
+	runtimeService := service.NewRuntimeService(db, aiAPIRepo, config.GlobalConfig, providerPolicy)
+	syncCfg := config.GlobalConfig.Sync
+	syncService := service.NewSyncService(
+		trainingRecordRepo,
+		nutritionRecordRepo,
+		trainingPlanRepo,
+		strengthService,
+		syncCfg.TrainingRecordPolicy,
+		syncCfg.NutritionRecordPolicy,
+		syncCfg.MaxBatchSize,
+	)
+
+	checkInCfg := config.GlobalConfig.CheckIn
+	checkInService := service.NewCheckInService(checkInRepo, notificationRepo, time.Weekday(checkInCfg.ReminderWeekday))
+
+	goalsCfg := config.GlobalConfig.Goals
+	if goalsCfg.EvaluationEnabled {
+		goalEvaluator := service.NewGoalEvaluator(fitnessGoalRepo, bodyDataRepo, notificationRepo, goalsCfg.SustainDays)
+		go runPeriodically("goal evaluation", goalsCfg.EvaluationInterval, goalEvaluator.EvaluateActiveGoals)
+	}
+	if checkInCfg.ReminderEnabled {
+		go runPeriodically("check-in reminders", checkInCfg.ReminderInterval, func(ctx context.Context) (int, error) {
+			return checkInService.SendReminders(ctx, time.Now())
+		})
+	}
+
+	rolloverCfg := config.GlobalConfig.Rollover
+	if rolloverCfg.Enabled {
+		rolloverService := service.NewRolloverService(
+			trainingPlanRepo,
+			nutritionPlanRepo,
+			trainingService,
+			nutritionService,
+			rolloverCfg.LookbackDays,
+		)
+		go runPeriodically("plan rollover", rolloverCfg.CheckInterval, rolloverService.RollOverEndedPlans)
+	}
+
+	promptTemplateService := service.NewPromptTemplateService(promptTemplateRepo)
+
+	macrocycleService := service.NewMacrocycleService(
+		macrocycleRepo,
+		trainingPlanRepo,
+		trainingRecordRepo,
+		strengthService,
+		trainingService,
+	)
+
+	planShareService := service.NewPlanShareService(trainingPlanRepo, jwtManager, config.GlobalConfig.JWT.ShareTokenExpire)
+
+	coachCfg := config.GlobalConfig.Coach
+	coachService := service.NewCoachService(
+		coachMessageRepo,
+		service.NewCoachHistoryCache(redisClient, coachCfg.HistoryMessages, coachCfg.HistoryTTL),
+		aiAPIRepo,
+		trainingPlanRepo,
+		nutritionPlanRepo,
+		bodyDataRepo,
+		fitnessGoalRepo,
+		constraintRepo,
+		aiService,
+		coachCfg.HistoryMessages,
 	)
 
+	taskOpsService := service.NewTaskOpsService(generationQueue, aiAPIRepo, trainingService, nutritionService)
+
+	integrityCfg := config.GlobalConfig.Integrity
+	integrityService := service.NewIntegrityService(repository.NewIntegrityRepository(db), integrityCfg.AutoRepair)
+	if integrityCfg.CheckEnabled {
+		go runPeriodically("data integrity check", integrityCfg.CheckInterval, integrityService.RunScheduled)
+	}
+
+	analyticsCfg := config.GlobalConfig.Analytics
+	if analyticsCfg.Enabled {
+		analyticsSink, err := analyticssink.New(analyticssink.Config{
+			Type: analyticsCfg.Sink,
+			Dir:  analyticsCfg.Dir,
+			S3: analyticssink.S3Config{
+				Endpoint:        analyticsCfg.S3.Endpoint,
+				Region:          analyticsCfg.S3.Region,
+				Bucket:          analyticsCfg.S3.Bucket,
+				Prefix:          analyticsCfg.S3.Prefix,
+				AccessKeyID:     analyticsCfg.S3.AccessKeyID,
+				SecretAccessKey: analyticsCfg.S3.SecretAccessKey,
+			},
+			Kafka: analyticssink.KafkaConfig{
+				RESTProxyURL: analyticsCfg.Kafka.RESTProxyURL,
+				Topic:        analyticsCfg.Kafka.Topic,
+			},
+		})
+		switch {
+		case err != nil:
+			logger.Error("Analytics export disabled, invalid sink configuration", zap.Error(err))
+		case analyticsCfg.PseudonymKey == "":
+			// Without a secret key the pseudonyms could be recomputed from user IDs
+			logger.Error("Analytics export disabled, analytics.pseudonym_key is not set")
+		default:
+			analyticsExporter := service.NewAnalyticsExporter(
+				repository.NewAnalyticsRepository(db),
+				analyticsSink,
+				analyticsCfg.PseudonymKey,
+				analyticsCfg.K,
+				analyticsCfg.LookbackDays,
+			)
+			go runPeriodically("analytics export", analyticsCfg.ExportInterval, analyticsExporter.ExportEvents)
+		}
+	}
+
+	eventsCfg := config.GlobalConfig.Events
+	if eventsCfg.StreamEnabled {
+		streamCfg := events.StreamConfig{
+			Transport:         eventsCfg.Transport,
+			TopicPrefix:       eventsCfg.TopicPrefix,
+			BufferSize:        eventsCfg.BufferSize,
+			KafkaRESTProxyURL: eventsCfg.KafkaRESTProxyURL,
+			NATSURL:           eventsCfg.NATSURL,
+			NATSUser:          eventsCfg.NATSUser,
+			NATSPassword:      eventsCfg.NATSPassword,
+			NATSToken:         eventsCfg.NATSToken,
+			OnError:           logStreamError,
+		}
+		transport, err := events.NewTransport(streamCfg)
+		if err != nil {
+			logger.Error("Event streaming disabled, invalid transport configuration", zap.Error(err))
+		} else {
+			streamer := events.NewStreamer(transport, streamCfg)
+			events.Subscribe(streamer.Handle)
+			streamer.Start()
+			eventStreamer.Store(streamer)
+		}
+	}
+
+	// Handlers are registered by the services above
+	generationQueue.Start()
+	backgroundQueue.Store(generationQueue)
+
 	return &router.Dependencies{
-		DB:                db,
-		RedisClient:       redisClient,
-		JWTManager:        jwtManager,
-		SessionManager:    sessionManager,
-		RateLimiter:       rateLimiter,
-		AuthService:       authService,
-		UserService:       userService,
-		AIAPIService:      aiAPIService,
-		TrainingService:   trainingService,
-		NutritionService:  nutritionService,
-		StatisticsService: statisticsService,
-		AssessmentRepo:    assessmentRepo,
+		DB:                        db,
+		RedisClient:               redisClient,
+		JWTManager:                jwtManager,
+		SessionManager:            sessionManager,
+		RateLimiter:               rateLimiter,
+		AuthService:               authService,
+		UserService:               userService,
+		AIAPIService:              aiAPIService,
+		TrainingService:           trainingService,
+		NutritionService:          nutritionService,
+		StatisticsService:         statisticsService,
+		AdminService:              adminService,
+		ExportService:             exportService,
+		CleanupService:            cleanupService,
+		NotificationService:       notificationService,
+		CheckInService:            checkInService,
+		StrengthService:           strengthService,
+		EquipmentService:          equipmentService,
+		TrainingConstraintService: constraintService,
+		SyncService:               syncService,
+		ProvisioningService:       provisioningService,
+		RuntimeService:            runtimeService,
+		IntegrityService:          integrityService,
+		PromptTemplateService:     promptTemplateService,
+		CoachService:              coachService,
+		MacrocycleService:         macrocycleService,
+		PlanShareService:          planShareService,
+		TaskOpsService:            taskOpsService,
+		ResponseArchiveService:    responseArchiveService,
+
+		AssessmentRepo:             assessmentRepo,
+		UserRepo:                   userRepo,
+		ImpersonationAuditRepo:     impersonationAuditRepo,
+		ServiceAuditRepo:           serviceAuditRepo,
+		ServiceTokenRevocationRepo: serviceTokenRevocationRepo,
+
+		ParseFailureMetrics: parseMetrics,
 	}, nil
 }
 
@@ -0,0 +1,108 @@
+package request
+
+// 管理员代登录请求
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"required,min=5,max=500"`
+}
+
+// 代登录审计日志查询
+type ImpersonationLogQuery struct {
+	UserID int64 `form:"user_id" binding:"omitempty,min=1"`
+}
+
+// 签发服务令牌请求
+type IssueServiceTokenRequest struct {
+	Service string `json:"service" binding:"required,min=2,max=100"`
+	UserID  int64  `json:"user_id" binding:"required,min=1"`
+	// TTLHours defaults to, and is capped at, the configured maximum
+	TTLHours int `json:"ttl_hours" binding:"omitempty,min=1"`
+}
+
+// 服务令牌审计日志查询
+type ServiceAuditLogQuery struct {
+	Service string `form:"service" binding:"omitempty,max=100"`
+}
+
+// 异常使用记录查询
+type AbuseFlagQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending dismissed confirmed"`
+}
+
+// 生成任务记录查询
+type GenerationTaskQuery struct {
+	UserID   int64  `form:"user_id" binding:"omitempty,min=1"`
+	Status   string `form:"status" binding:"omitempty,oneof=completed failed"`
+	TaskType string `form:"task_type" binding:"omitempty,oneof=training:generate training:adjust nutrition:generate nutrition:adjust"`
+}
+
+// 异常使用审核请求
+type ReviewAbuseFlagRequest struct {
+	Action string `json:"action" binding:"required,oneof=dismiss confirm"`
+	Note   string `json:"note" binding:"omitempty,max=500"`
+}
+
+// 创建组织请求
+type CreateOrganizationRequest struct {
+	Name      string `json:"name" binding:"required,min=1,max=100"`
+	SeatLimit int    `json:"seat_limit" binding:"required,min=1,max=100000"`
+}
+
+// SCIM风格的用户资源
+type SCIMUser struct {
+	UserName    string      `json:"userName" binding:"omitempty,max=20"`
+	DisplayName string      `json:"displayName" binding:"omitempty,max=50"`
+	Emails      []SCIMEmail `json:"emails" binding:"required,min=1,dive"`
+}
+
+// SCIM风格的邮箱，取primary或第一个
+type SCIMEmail struct {
+	Value   string `json:"value" binding:"required,max=100"`
+	Primary bool   `json:"primary"`
+}
+
+// 批量导入组织用户请求（JSON格式；也可上传CSV）
+type ProvisionUsersRequest struct {
+	Schemas   []string   `json:"schemas"`
+	Resources []SCIMUser `json:"Resources" binding:"required,min=1,max=500,dive"`
+}
+
+// 批量停用组织用户请求（JSON格式；也可上传CSV）
+type DeactivateUsersRequest struct {
+	Emails []string `json:"emails" binding:"required,min=1,max=500,dive,max=100"`
+}
+
+// 接受组织邀请并设置密码
+type AcceptInvitationRequest struct {
+	Token           string `json:"token" binding:"required,len=64,hexadecimal"`
+	Password        string `json:"password" binding:"required,min=8,max=20,password_strength"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=Password"`
+}
+
+// 数据一致性检查请求参数，repair为true时执行自动修复
+type IntegrityCheckQuery struct {
+	Repair bool `form:"repair"`
+}
+
+// 账号合并请求，dry_run时只预览迁移内容和冲突处理
+type MergeAccountsRequest struct {
+	SourceUserID int64  `json:"source_user_id" binding:"required,min=1"`
+	TargetUserID int64  `json:"target_user_id" binding:"required,min=1"`
+	Reason       string `json:"reason" binding:"required,min=5,max=500"`
+	DryRun       bool   `json:"dry_run"`
+}
+
+// 账号合并记录查询
+type AccountMergeQuery struct {
+	UserID int64 `form:"user_id" binding:"omitempty,min=1"`
+}
+
+// 卡住任务查询
+type StuckTaskQuery struct {
+	// OlderThanMinutes defaults to 10
+	OlderThanMinutes int `form:"older_than_minutes" binding:"omitempty,min=1,max=1440"`
+}
+
+// 终止队列任务请求
+type FailTaskRequest struct {
+	Reason string `json:"reason" binding:"required,min=2,max=500"`
+}
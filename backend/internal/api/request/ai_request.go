@@ -2,25 +2,43 @@ package request
 
 // AI API配置请求
 type AddAIAPIRequest struct {
-	Provider    string   `json:"provider" binding:"required,oneof=openai wenxin tongyi"`
-	Name        string   `json:"name" binding:"required,min=1,max=100"`
-	APIEndpoint string   `json:"api_endpoint" binding:"required,url,max=500"`
-	APIKey      string   `json:"api_key" binding:"required,min=1,max=500"`
-	Model       string   `json:"model" binding:"required,min=1,max=100"`
-	MaxTokens   *int     `json:"max_tokens" binding:"omitempty,min=1,max=100000"`
-	Temperature *float64 `json:"temperature" binding:"omitempty,min=0,max=2"`
-	IsDefault   *bool    `json:"is_default"`
+	Provider       string   `json:"provider" binding:"required,oneof=openai wenxin tongyi anthropic deepseek ollama"`
+	Name           string   `json:"name" binding:"required,min=1,max=100"`
+	APIEndpoint    string   `json:"api_endpoint" binding:"required,url,max=500"`
+	APIKey         string   `json:"api_key" binding:"required_unless=Provider ollama,max=500"` // 本地模型（ollama）无需API Key
+	Model          string   `json:"model" binding:"required,min=1,max=100"`
+	MaxTokens      *int     `json:"max_tokens" binding:"omitempty,min=1,max=100000"`
+	Temperature    *float64 `json:"temperature" binding:"omitempty,min=0,max=2"`
+	TimeoutSeconds *int     `json:"timeout_seconds" binding:"omitempty,min=5,max=600"` // 单次调用超时，未设置时使用全局配置
+	IsDefault      *bool    `json:"is_default"`
 }
 
 type UpdateAIAPIRequest struct {
-	Name        string   `json:"name" binding:"omitempty,min=1,max=100"`
-	APIEndpoint string   `json:"api_endpoint" binding:"omitempty,url,max=500"`
-	APIKey      string   `json:"api_key" binding:"omitempty,min=1,max=500"`
-	Model       string   `json:"model" binding:"omitempty,min=1,max=100"`
-	MaxTokens   *int     `json:"max_tokens" binding:"omitempty,min=1,max=100000"`
-	Temperature *float64 `json:"temperature" binding:"omitempty,min=0,max=2"`
-	Status      *bool    `json:"status"`
-	IsDefault   *bool    `json:"is_default"`
+	Name           string   `json:"name" binding:"omitempty,min=1,max=100"`
+	APIEndpoint    string   `json:"api_endpoint" binding:"omitempty,url,max=500"`
+	APIKey         string   `json:"api_key" binding:"omitempty,min=1,max=500"`
+	Model          string   `json:"model" binding:"omitempty,min=1,max=100"`
+	MaxTokens      *int     `json:"max_tokens" binding:"omitempty,min=1,max=100000"`
+	Temperature    *float64 `json:"temperature" binding:"omitempty,min=0,max=2"`
+	TimeoutSeconds *int     `json:"timeout_seconds" binding:"omitempty,min=5,max=600"`
+	Status         *bool    `json:"status"`
+	IsDefault      *bool    `json:"is_default"`
+}
+
+// 设置备用链顺序请求，按尝试顺序排列；空列表清空备用链
+type SetFallbackOrderRequest struct {
+	APIIDs []int64 `json:"api_ids" binding:"max=10,dive,min=1"`
+}
+
+// AI用量查询参数，统计最近days天，默认30天
+type AIUsageQuery struct {
+	Days int `form:"days" binding:"omitempty,min=1,max=365"`
+}
+
+// AI调用记录查询参数
+type AICallLogQuery struct {
+	APIID  int64  `form:"api_id" binding:"omitempty,min=1"`
+	Status string `form:"status" binding:"omitempty,oneof=success error"`
 }
 
 type AIAPIIDParam struct {
@@ -25,6 +25,26 @@ type UpdateUserRequest struct {
 	Nickname string `json:"nickname" binding:"omitempty,min=1,max=50"`
 	Phone    string `json:"phone" binding:"omitempty,e164"`
 	Avatar   string `json:"avatar" binding:"omitempty,avatar"`
+	// 每周起始日，影响按周统计的周边界
+	WeekStart string `json:"week_start" binding:"omitempty,oneof=monday sunday"`
+	// 计划结束后是否自动生成下一周期
+	AutoRollover *bool `json:"auto_rollover"`
+	// 是否退出匿名分析数据导出
+	AnalyticsOptOut *bool `json:"analytics_opt_out"`
+	// 是否不存档生成计划的AI原始响应（供客服处理投诉）；开启后已有存档会在下次清理时删除
+	ResponseArchiveOptOut *bool `json:"response_archive_opt_out"`
+	// 无法训练的日子：每周固定的星期（0为周日）和具体日期，生成计划时安排为休息日；传空数组清空
+	BusyWeekdays  []int    `json:"busy_weekdays" binding:"omitempty,max=7,dive,min=0,max=6"`
+	BlackoutDates []string `json:"blackout_dates" binding:"omitempty,max=60,dive,datetime=2006-01-02"`
+	// 默认组间休息（秒），替换计划中对应类型动作的休息时间；整体替换已保存的设置，0表示沿用计划
+	RestIntervals *RestIntervalsRequest `json:"rest_intervals"`
+}
+
+// 组间休息偏好（秒）：力量（每组不超过6次）、增肌和有氧
+type RestIntervalsRequest struct {
+	Strength    int `json:"strength" binding:"omitempty,min=10,max=600"`
+	Hypertrophy int `json:"hypertrophy" binding:"omitempty,min=10,max=600"`
+	Cardio      int `json:"cardio" binding:"omitempty,min=10,max=600"`
 }
 
 // 更新密码请求
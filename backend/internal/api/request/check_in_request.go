@@ -0,0 +1,12 @@
+package request
+
+// 每周签到请求
+type CheckInRequest struct {
+	WeekOf          *string  `json:"week_of" binding:"omitempty,datetime=2006-01-02"`
+	Weight          *float64 `json:"weight" binding:"omitempty,min=20,max=500"`
+	AdherenceRating int      `json:"adherence_rating" binding:"required,min=1,max=5"`
+	EnergyLevel     int      `json:"energy_level" binding:"required,min=1,max=5"`
+	HungerLevel     int      `json:"hunger_level" binding:"required,min=1,max=5"`
+	Notes           *string  `json:"notes" binding:"omitempty,max=1000"`
+	Photos          []string `json:"photos" binding:"omitempty,max=6,dive,url,max=500"`
+}
@@ -0,0 +1,9 @@
+package request
+
+// CleanupRequest represents a bulk deletion. Without a confirmation token
+// the deletion is only previewed; the dates apply to nutrition records.
+type CleanupRequest struct {
+	StartDate         string `json:"start_date" binding:"omitempty,datetime=2006-01-02"`
+	EndDate           string `json:"end_date" binding:"omitempty,datetime=2006-01-02"`
+	ConfirmationToken string `json:"confirmation_token"`
+}
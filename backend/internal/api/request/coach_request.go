@@ -0,0 +1,12 @@
+package request
+
+// AI教练提问请求
+type CoachChatRequest struct {
+	Message string `json:"message" binding:"required,max=2000"`
+	AIAPIID *int64 `json:"ai_api_id" binding:"omitempty,min=1"`
+}
+
+// AI教练对话记录查询请求
+type CoachHistoryRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=200"`
+}
@@ -0,0 +1,26 @@
+package request
+
+// 创建训练限制请求
+type CreateTrainingConstraintRequest struct {
+	Source              *string  `json:"source" binding:"omitempty,oneof=doctor physio other"`
+	Description         string   `json:"description" binding:"required,min=1,max=500"`
+	RestrictedMovements []string `json:"restricted_movements" binding:"omitempty,max=50,dive,min=1,max=100"`
+	Attachments         []string `json:"attachments" binding:"omitempty,max=10,dive,url,max=500"`
+	StartsOn            *string  `json:"starts_on" binding:"omitempty,datetime=2006-01-02"`
+	ExpiresOn           *string  `json:"expires_on" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// 更新训练限制请求
+type UpdateTrainingConstraintRequest struct {
+	Source              *string  `json:"source" binding:"omitempty,oneof=doctor physio other"`
+	Description         *string  `json:"description" binding:"omitempty,min=1,max=500"`
+	RestrictedMovements []string `json:"restricted_movements" binding:"omitempty,max=50,dive,min=1,max=100"`
+	Attachments         []string `json:"attachments" binding:"omitempty,max=10,dive,url,max=500"`
+	StartsOn            *string  `json:"starts_on" binding:"omitempty,datetime=2006-01-02"`
+	ExpiresOn           *string  `json:"expires_on" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// 训练限制列表查询
+type TrainingConstraintQuery struct {
+	ActiveOnly bool `form:"active_only"`
+}
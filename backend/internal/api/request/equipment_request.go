@@ -0,0 +1,19 @@
+package request
+
+// 创建器材清单请求
+type CreateEquipmentProfileRequest struct {
+	Name         string   `json:"name" binding:"required,min=1,max=50"`
+	LocationType *string  `json:"location_type" binding:"omitempty,oneof=home gym other"`
+	Equipment    []string `json:"equipment" binding:"omitempty,max=100,dive,min=1,max=100"`
+	Weekdays     []int    `json:"weekdays" binding:"omitempty,max=7,dive,min=0,max=6"` // 0=周日
+	IsDefault    *bool    `json:"is_default"`
+}
+
+// 更新器材清单请求
+type UpdateEquipmentProfileRequest struct {
+	Name         *string  `json:"name" binding:"omitempty,min=1,max=50"`
+	LocationType *string  `json:"location_type" binding:"omitempty,oneof=home gym other"`
+	Equipment    []string `json:"equipment" binding:"omitempty,max=100,dive,min=1,max=100"`
+	Weekdays     []int    `json:"weekdays" binding:"omitempty,max=7,dive,min=0,max=6"` // 0=周日
+	IsDefault    *bool    `json:"is_default"`
+}
@@ -0,0 +1,7 @@
+package request
+
+// ExportParams represents query parameters for data exports
+type ExportParams struct {
+	StartDate string `form:"start_date" binding:"omitempty,datetime=2006-01-02"`
+	EndDate   string `form:"end_date" binding:"omitempty,datetime=2006-01-02"`
+}
@@ -0,0 +1,19 @@
+package request
+
+// CreateMacrocycleRequest represents the request to create a macrocycle
+type CreateMacrocycleRequest struct {
+	Name          string   `json:"name" binding:"required,min=1,max=100"`
+	Goal          string   `json:"goal" binding:"required,min=1,max=100"`
+	Phases        []string `json:"phases" binding:"required,min=1,max=12,dive,oneof=hypertrophy strength power peak endurance"` // 各训练块的计划阶段，按顺序排列
+	InitialPlanID *int64   `json:"initial_plan_id" binding:"omitempty,min=1"`                                                   // 作为第1训练块的已有计划
+}
+
+// GenerateNextBlockRequest represents the request to generate the next block
+// of a macrocycle
+type GenerateNextBlockRequest struct {
+	PlanName        string `json:"plan_name" binding:"omitempty,max=200"`
+	DurationWeeks   int    `json:"duration_weeks" binding:"required,min=1,max=52"`
+	DifficultyLevel string `json:"difficulty_level" binding:"required,oneof=easy medium hard extreme"`
+	Phase           string `json:"phase" binding:"omitempty,oneof=hypertrophy strength power peak endurance"` // 默认为计划中的下一阶段
+	AIAPIID         *int64 `json:"ai_api_id" binding:"omitempty,min=1"`
+}
@@ -0,0 +1,6 @@
+package request
+
+// 通知列表查询
+type NotificationQuery struct {
+	UnreadOnly bool `form:"unread_only"`
+}
@@ -8,11 +8,30 @@ type GenerateNutritionPlanRequest struct {
 	ProteinRatio        float64  `json:"protein_ratio" binding:"required,min=0,max=1,macro_ratio"`
 	CarbRatio           float64  `json:"carb_ratio" binding:"required,min=0,max=1,macro_ratio"`
 	FatRatio            float64  `json:"fat_ratio" binding:"required,min=0,max=1,macro_ratio"`
-	DietaryRestrictions []string `json:"dietary_restrictions" binding:"omitempty,dive,min=1,max=100"`
-	Preferences         []string `json:"preferences" binding:"omitempty,dive,min=1,max=100"`
+	DietaryRestrictions []string `json:"dietary_restrictions" binding:"omitempty,dive,min=1,max=100"` // 取值见 GET /meta/nutrition-options
+	Preferences         []string `json:"preferences" binding:"omitempty,dive,min=1,max=100"`          // 菜系，取值见 GET /meta/nutrition-options
+	LeftoverLunch       bool     `json:"leftover_lunch"`                                              // 晚餐剩菜作为次日午餐
+	BudgetLevel         string   `json:"budget_level" binding:"omitempty,oneof=low medium high"`
+	WeeklyBudget        *float64 `json:"weekly_budget" binding:"omitempty,gt=0,max=100000,excluded_with=BudgetLevel"` // 每周食物预算（元），与budget_level二选一
 	AIAPIID             *int64   `json:"ai_api_id" binding:"omitempty,min=1"`
 }
 
+// AdjustNutritionPlanRequest represents the request to adjust a nutrition
+// plan from the user's feedback
+type AdjustNutritionPlanRequest struct {
+	Feedback           string   `json:"feedback" binding:"omitempty,max=1000"`
+	SatisfactionRating *int     `json:"satisfaction_rating" binding:"omitempty,min=1,max=5"`
+	WeightChange       *float64 `json:"weight_change" binding:"omitempty,min=-50,max=50"` // 计划开始以来的体重变化（kg），不提供时根据身体数据计算
+	AIAPIID            *int64   `json:"ai_api_id" binding:"omitempty,min=1"`
+}
+
+// RegenerateNutritionDayRequest represents the request to regenerate one
+// day of a nutrition plan
+type RegenerateNutritionDayRequest struct {
+	Feedback string `json:"feedback" binding:"omitempty,max=1000"` // 对当天餐食的不满之处
+	AIAPIID  *int64 `json:"ai_api_id" binding:"omitempty,min=1"`
+}
+
 // RecordMealRequest represents the request to record a meal
 type RecordMealRequest struct {
 	PlanID   *int64                 `json:"plan_id" binding:"omitempty,min=1"`
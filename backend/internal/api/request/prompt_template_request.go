@@ -0,0 +1,43 @@
+package request
+
+// 提示词模板列表查询
+type PromptTemplateQuery struct {
+	Category    string `form:"category" binding:"omitempty,oneof=training nutrition assessment safety coach"`
+	Subcategory string `form:"subcategory" binding:"omitempty,max=50"`
+}
+
+// 创建提示词模板请求，新建的版本需单独设为默认
+type CreatePromptTemplateRequest struct {
+	Category    string   `json:"category" binding:"required,oneof=training nutrition assessment safety coach"`
+	Subcategory *string  `json:"subcategory" binding:"omitempty,max=50"`
+	Name        string   `json:"name" binding:"required,min=1,max=200"`
+	Template    string   `json:"template" binding:"required"`
+	Variables   []string `json:"variables" binding:"omitempty,dive,max=100"`
+	Description *string  `json:"description" binding:"omitempty,max=1000"`
+}
+
+// 更新提示词模板请求，未提供的字段保持不变
+type UpdatePromptTemplateRequest struct {
+	Name        *string  `json:"name" binding:"omitempty,min=1,max=200"`
+	Template    *string  `json:"template" binding:"omitempty,min=1"`
+	Variables   []string `json:"variables" binding:"omitempty,dive,max=100"`
+	Description *string  `json:"description" binding:"omitempty,max=1000"`
+}
+
+// 提示词模板预览请求，template为空时预览已保存的内容
+type PreviewPromptTemplateRequest struct {
+	Template  *string                `json:"template" binding:"omitempty,min=1"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// 设置提示词模板灰度比例请求，0表示结束灰度
+type PromptTemplateRolloutRequest struct {
+	Percent *int `json:"percent" binding:"required,min=0,max=100"`
+}
+
+// 提示词模板实验查询参数，统计最近days天生成的计划，默认30天
+type PromptExperimentQuery struct {
+	Category    string `form:"category" binding:"required,oneof=training nutrition"`
+	Subcategory string `form:"subcategory" binding:"required,max=50"`
+	Days        int    `form:"days" binding:"omitempty,min=1,max=365"`
+}
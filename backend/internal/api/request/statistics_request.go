@@ -14,3 +14,14 @@ type TrendsParams struct {
 	StartDate string `form:"start_date" binding:"omitempty,datetime=2006-01-02"`
 	EndDate   string `form:"end_date" binding:"omitempty,datetime=2006-01-02"`
 }
+
+// ProgressionParams represents query parameters for exercise progression
+type ProgressionParams struct {
+	Exercise string `form:"exercise" binding:"required,max=100"`
+	Days     int    `form:"days" binding:"omitempty,min=7,max=365"`
+}
+
+// OneRepMaxParams represents query parameters for 1RM estimates
+type OneRepMaxParams struct {
+	Days int `form:"days" binding:"omitempty,min=7,max=365"`
+}
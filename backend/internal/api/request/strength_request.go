@@ -0,0 +1,7 @@
+package request
+
+// 手动设置1RM请求
+type SetOneRepMaxRequest struct {
+	OneRepMax  float64 `json:"one_rep_max" binding:"required,gt=0,max=1000"`
+	AchievedAt *string `json:"achieved_at" binding:"omitempty,datetime=2006-01-02"`
+}
@@ -0,0 +1,40 @@
+package request
+
+// SyncRequest represents an offline sync push from a mobile client
+type SyncRequest struct {
+	LastSyncedAt     *string               `json:"last_synced_at" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"` // 上次同步返回的server_time，首次同步留空
+	TrainingRecords  []SyncTrainingRecord  `json:"training_records" binding:"omitempty,dive"`
+	NutritionRecords []SyncNutritionRecord `json:"nutrition_records" binding:"omitempty,dive"`
+}
+
+// SyncTrainingRecord represents a training record changed offline; only
+// client_id and deleted are needed for a deletion
+type SyncTrainingRecord struct {
+	ClientID        string                 `json:"client_id" binding:"required,uuid"`
+	Deleted         bool                   `json:"deleted"`
+	PlanID          *int64                 `json:"plan_id" binding:"omitempty,min=1"`
+	WorkoutDate     string                 `json:"workout_date" binding:"omitempty,datetime=2006-01-02"`
+	WorkoutType     string                 `json:"workout_type" binding:"omitempty,max=100"`
+	DurationMinutes *int                   `json:"duration_minutes" binding:"omitempty,min=0,max=1440"`
+	StartedAt       *string                `json:"started_at" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	EndedAt         *string                `json:"ended_at" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Exercises       map[string]interface{} `json:"exercises"`
+	PerformanceData map[string]interface{} `json:"performance_data"`
+	Notes           *string                `json:"notes" binding:"omitempty,max=1000"`
+	Rating          *int                   `json:"rating" binding:"omitempty,min=1,max=5"`
+	InjuryReport    *string                `json:"injury_report" binding:"omitempty,max=1000"`
+}
+
+// SyncNutritionRecord represents a meal changed offline
+type SyncNutritionRecord struct {
+	ClientID string                 `json:"client_id" binding:"required,uuid"`
+	Deleted  bool                   `json:"deleted"`
+	MealDate string                 `json:"meal_date" binding:"omitempty,datetime=2006-01-02"`
+	MealType string                 `json:"meal_type" binding:"omitempty,oneof=breakfast lunch dinner snack"`
+	Calories float64                `json:"calories" binding:"omitempty,min=0,max=10000"`
+	Protein  float64                `json:"protein" binding:"omitempty,min=0,max=1000"`
+	Carbs    float64                `json:"carbs" binding:"omitempty,min=0,max=1000"`
+	Fat      float64                `json:"fat" binding:"omitempty,min=0,max=1000"`
+	Fiber    float64                `json:"fiber" binding:"omitempty,min=0,max=500"`
+	Foods    map[string]interface{} `json:"foods"`
+}
@@ -7,6 +7,71 @@ type GenerateTrainingPlanRequest struct {
 	Goal            string `json:"goal" binding:"required,min=1,max=100"`
 	DifficultyLevel string `json:"difficulty_level" binding:"required,oneof=easy medium hard extreme"`
 	AIAPIID         *int64 `json:"ai_api_id" binding:"omitempty,min=1"`
+	// IncludeDeload makes every deload_every-th week (default 4) a lighter
+	// deload week
+	IncludeDeload      bool   `json:"include_deload"`
+	DeloadEvery        int    `json:"deload_every" binding:"omitempty,min=2,max=12"`
+	PeriodizationStyle string `json:"periodization_style" binding:"omitempty,oneof=linear undulating block"`
+}
+
+// AdjustTrainingPlanRequest represents the request to adjust a training plan
+// from the user's feedback
+type AdjustTrainingPlanRequest struct {
+	Feedback         string `json:"feedback" binding:"omitempty,max=1000"`
+	DifficultyRating *int   `json:"difficulty_rating" binding:"omitempty,min=1,max=5"` // 计划难度感受，1（太简单）到5（太难）
+	InjuryReport     string `json:"injury_report" binding:"omitempty,max=1000"`
+	AIAPIID          *int64 `json:"ai_api_id" binding:"omitempty,min=1"`
+}
+
+// UpdateTrainingPlanRequest represents the request to rename a training plan
+// or change its status; omitted fields are left unchanged
+type UpdateTrainingPlanRequest struct {
+	PlanName *string `json:"plan_name" binding:"omitempty,min=1,max=200"`
+	Status   *string `json:"status" binding:"omitempty,oneof=active paused inactive completed"`
+}
+
+// ClonePlanRequest represents the request to clone a training plan; the end
+// date follows from the start date and the original's length
+type ClonePlanRequest struct {
+	StartDate string  `json:"start_date" binding:"required,datetime=2006-01-02"`
+	PlanName  *string `json:"plan_name" binding:"omitempty,min=1,max=200"`
+}
+
+// CompletePlanDayRequest represents the request to mark a plan day as
+// completed; RecordID links the training record logged for the day
+type CompletePlanDayRequest struct {
+	RecordID *int64 `json:"record_id" binding:"omitempty,min=1"`
+}
+
+// SubstituteExerciseRequest represents the request to substitute an
+// exercise of a plan day; equipment and joints take the codes listed by the
+// exercise options
+type SubstituteExerciseRequest struct {
+	UnavailableEquipment []string `json:"unavailable_equipment" binding:"omitempty,max=10,dive,min=1,max=50"`
+	AvoidJoints          []string `json:"avoid_joints" binding:"omitempty,max=6,dive,min=1,max=50"`
+	Notes                string   `json:"notes" binding:"omitempty,max=500"` // 伤病或其他情况说明，填写后由AI推荐
+	AIAPIID              *int64   `json:"ai_api_id" binding:"omitempty,min=1"`
+}
+
+// RegenerateTrainingWeekRequest represents the request to regenerate one
+// week of a training plan
+type RegenerateTrainingWeekRequest struct {
+	Feedback     string `json:"feedback" binding:"omitempty,max=1000"`      // 对这一周安排的不满之处
+	InjuryReport string `json:"injury_report" binding:"omitempty,max=1000"` // 新出现的伤病或不适
+	AIAPIID      *int64 `json:"ai_api_id" binding:"omitempty,min=1"`
+}
+
+// ShareTrainingPlanRequest represents the request to make a public link to a
+// training plan
+type ShareTrainingPlanRequest struct {
+	// TTLHours defaults to, and is capped at, the configured maximum
+	TTLHours int `json:"ttl_hours" binding:"omitempty,min=1"`
+}
+
+// DeleteTrainingPlanParams represents query parameters for deleting a
+// training plan
+type DeleteTrainingPlanParams struct {
+	DeleteRecords bool `form:"delete_records"` // 同时删除关联的训练记录，默认保留并解除关联
 }
 
 // RecordTrainingRequest represents the request to record a training session
@@ -15,11 +80,14 @@ type RecordTrainingRequest struct {
 	WorkoutDate     string                 `json:"workout_date" binding:"required,datetime=2006-01-02,future_date"`
 	WorkoutType     string                 `json:"workout_type" binding:"required,min=1,max=100"`
 	DurationMinutes *int                   `json:"duration_minutes" binding:"omitempty,min=0,max=1440"`
+	StartedAt       *string                `json:"started_at" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"` // 训练开始时间（RFC3339），与结束时间一起提供时自动计算时长
+	EndedAt         *string                `json:"ended_at" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
 	Exercises       map[string]interface{} `json:"exercises" binding:"required"`
 	PerformanceData map[string]interface{} `json:"performance_data"`
 	Notes           *string                `json:"notes" binding:"omitempty,max=1000"`
 	Rating          *int                   `json:"rating" binding:"omitempty,min=1,max=5"`
 	InjuryReport    *string                `json:"injury_report" binding:"omitempty,max=1000"`
+	AllowDuplicate  bool                   `json:"allow_duplicate"` // 确认保存与已有记录相似的训练
 }
 
 // TrainingPlanListParams represents query parameters for listing training plans
@@ -29,6 +97,15 @@ type TrainingPlanListParams struct {
 	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
 }
 
+// IncludePlanData asks a plan detail fetch for the plan's full schedule
+const IncludePlanData = "plan_data"
+
+// PlanDetailParams represents query parameters for fetching a training or
+// nutrition plan; its plan data is only returned with include=plan_data
+type PlanDetailParams struct {
+	Include string `form:"include" binding:"omitempty,oneof=plan_data"`
+}
+
 // TrainingRecordListParams represents query parameters for listing training records
 type TrainingRecordListParams struct {
 	StartDate string `form:"start_date" binding:"omitempty,datetime=2006-01-02"`
@@ -36,3 +113,15 @@ type TrainingRecordListParams struct {
 	Page      int    `form:"page" binding:"omitempty,min=1"`
 	Limit     int    `form:"limit" binding:"omitempty,min=1,max=100"`
 }
+
+// PlanScheduleParams represents query parameters for a plan's schedule over a date range
+type PlanScheduleParams struct {
+	Start string `form:"start" binding:"required,datetime=2006-01-02"`
+	End   string `form:"end" binding:"required,datetime=2006-01-02"`
+}
+
+// ComparePlansParams represents query parameters for comparing two training plans
+type ComparePlansParams struct {
+	A int64 `form:"a" binding:"required,min=1"`
+	B int64 `form:"b" binding:"required,min=1,nefield=A"`
+}
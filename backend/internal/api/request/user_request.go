@@ -16,6 +16,7 @@ type AddGoalRequest struct {
 	GoalType        string   `json:"goal_type" binding:"required,min=1,max=100"`
 	GoalDescription string   `json:"goal_description" binding:"omitempty,min=1,max=500"`
 	TargetWeight    *float64 `json:"target_weight" binding:"omitempty,min=20,max=500"`
+	TargetBodyFat   *float64 `json:"target_body_fat" binding:"omitempty,min=0,max=100"`
 	Deadline        *string  `json:"deadline" binding:"omitempty,datetime=2006-01-02"`
 	TargetDate      *string  `json:"target_date" binding:"omitempty,datetime=2006-01-02"`
 	Notes           *string  `json:"notes" binding:"omitempty,min=1,max=500"`
@@ -27,6 +28,7 @@ type UpdateGoalRequest struct {
 	GoalType        string   `json:"goal_type" binding:"omitempty,min=1,max=100"`
 	GoalDescription string   `json:"goal_description" binding:"omitempty,min=1,max=500"`
 	TargetWeight    *float64 `json:"target_weight" binding:"omitempty,min=20,max=500"`
+	TargetBodyFat   *float64 `json:"target_body_fat" binding:"omitempty,min=0,max=100"`
 	Deadline        *string  `json:"deadline" binding:"omitempty,datetime=2006-01-02"`
 	TargetDate      *string  `json:"target_date" binding:"omitempty,datetime=2006-01-02"`
 	Notes           *string  `json:"notes" binding:"omitempty,min=1,max=500"`
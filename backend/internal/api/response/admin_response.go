@@ -0,0 +1,243 @@
+package response
+
+type ImpersonationResponse struct {
+	AccessToken string   `json:"access_token"`
+	ExpiresAt   string   `json:"expires_at"`
+	User        UserInfo `json:"user"`
+}
+
+type ImpersonationLogInfo struct {
+	ID         int64  `json:"id"`
+	AdminID    int64  `json:"admin_id"`
+	UserID     int64  `json:"user_id"`
+	SessionID  string `json:"session_id"`
+	Method     string `json:"method"`
+	Path       string `json:"path,omitempty"`
+	StatusCode int    `json:"status_code"`
+	Reason     string `json:"reason,omitempty"`
+	IPAddress  string `json:"ip_address,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type ImpersonationLogListResponse struct {
+	Logs       []ImpersonationLogInfo `json:"logs"`
+	Pagination PaginationInfo         `json:"pagination"`
+}
+
+type ServiceTokenResponse struct {
+	Token     string `json:"token"`
+	TokenID   string `json:"token_id"`
+	Service   string `json:"service"`
+	UserID    int64  `json:"user_id"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+type ServiceTokenRevocationResponse struct {
+	TokenID   string `json:"token_id"`
+	Service   string `json:"service"`
+	UserID    int64  `json:"user_id"`
+	RevokedBy int64  `json:"revoked_by"`
+	RevokedAt string `json:"revoked_at"`
+}
+
+type ServiceAuditLogInfo struct {
+	ID         int64  `json:"id"`
+	Service    string `json:"service"`
+	TokenID    string `json:"token_id"`
+	UserID     int64  `json:"user_id"`
+	AdminID    *int64 `json:"admin_id,omitempty"`
+	Method     string `json:"method"`
+	Path       string `json:"path,omitempty"`
+	StatusCode int    `json:"status_code"`
+	IPAddress  string `json:"ip_address,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type ServiceAuditLogListResponse struct {
+	Logs       []ServiceAuditLogInfo `json:"logs"`
+	Pagination PaginationInfo        `json:"pagination"`
+}
+
+type AbuseFlagInfo struct {
+	ID             int64                  `json:"id"`
+	UserID         int64                  `json:"user_id"`
+	AIAPIID        int64                  `json:"ai_api_id"`
+	Reason         string                 `json:"reason"`
+	Details        map[string]interface{} `json:"details,omitempty"`
+	Status         string                 `json:"status"`
+	SuspendedUntil string                 `json:"suspended_until,omitempty"`
+	ReviewedBy     int64                  `json:"reviewed_by,omitempty"`
+	ReviewNote     string                 `json:"review_note,omitempty"`
+	ReviewedAt     string                 `json:"reviewed_at,omitempty"`
+	CreatedAt      string                 `json:"created_at"`
+}
+
+type GenerationTaskListResponse struct {
+	Tasks      []GenerationTaskInfo `json:"tasks"`
+	Pagination PaginationInfo       `json:"pagination"`
+}
+
+type AbuseFlagListResponse struct {
+	Flags      []AbuseFlagInfo `json:"flags"`
+	Pagination PaginationInfo  `json:"pagination"`
+}
+
+// ParseFailureInfo is one plan parse failure counter
+type ParseFailureInfo struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Purpose  string `json:"purpose"`
+	Kind     string `json:"kind"`
+	Count    int64  `json:"count"`
+}
+
+// ParseFailureStatsResponse reports plan parse failures by provider, model
+// and cause, with totals per cause
+type ParseFailureStatsResponse struct {
+	Total    int64              `json:"total"`
+	ByKind   map[string]int64   `json:"by_kind"`
+	Counters []ParseFailureInfo `json:"counters"`
+}
+
+type OrganizationInfo struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	SeatLimit int    `json:"seat_limit"`
+	SeatsUsed int64  `json:"seats_used"`
+	CreatedAt string `json:"created_at"`
+}
+
+type OrganizationListResponse struct {
+	Organizations []OrganizationInfo `json:"organizations"`
+}
+
+type ProvisionedUserInfo struct {
+	Email          string `json:"email"`
+	UserID         int64  `json:"user_id"`
+	Reactivated    bool   `json:"reactivated"`
+	InvitationSent bool   `json:"invitation_sent"`
+}
+
+type ProvisionIssueInfo struct {
+	Email  string `json:"email"`
+	Reason string `json:"reason"`
+}
+
+type ProvisionUsersResponse struct {
+	Provisioned []ProvisionedUserInfo `json:"provisioned"`
+	Skipped     []ProvisionIssueInfo  `json:"skipped"`
+	Rejected    []ProvisionIssueInfo  `json:"rejected"`
+	SeatsUsed   int64                 `json:"seats_used"`
+	SeatLimit   int                   `json:"seat_limit"`
+}
+
+type DeactivateUsersResponse struct {
+	Deactivated []string             `json:"deactivated"`
+	Rejected    []ProvisionIssueInfo `json:"rejected"`
+	SeatsUsed   int64                `json:"seats_used"`
+}
+
+type IntegrityIssueInfo struct {
+	Check       string `json:"check"`
+	Table       string `json:"table"`
+	RecordID    int64  `json:"record_id"`
+	UserID      int64  `json:"user_id"`
+	Detail      string `json:"detail"`
+	Repairable  bool   `json:"repairable"`
+	Repaired    bool   `json:"repaired"`
+	RepairError string `json:"repair_error,omitempty"`
+}
+
+type IntegrityReportResponse struct {
+	StartedAt  string               `json:"started_at"`
+	FinishedAt string               `json:"finished_at"`
+	Repair     bool                 `json:"repair"`
+	Counts     map[string]int       `json:"counts"`
+	Repaired   int                  `json:"repaired"`
+	Truncated  []string             `json:"truncated,omitempty"`
+	Issues     []IntegrityIssueInfo `json:"issues"`
+}
+
+// AccountMergeConflictInfo is a value of a moved row that a merge changed
+// to resolve a conflict with the target account
+type AccountMergeConflictInfo struct {
+	Table  string      `json:"table"`
+	ID     int64       `json:"id"`
+	Column string      `json:"column"`
+	Old    interface{} `json:"old"`
+	New    interface{} `json:"new"`
+	Rule   string      `json:"rule"`
+}
+
+// AccountMergeInfo is an account merge audit record. Moved counts the rows
+// moved to the target account per table.
+type AccountMergeInfo struct {
+	ID           int64                      `json:"id,omitempty"`
+	AdminID      int64                      `json:"admin_id"`
+	SourceUserID int64                      `json:"source_user_id"`
+	TargetUserID int64                      `json:"target_user_id"`
+	Reason       string                     `json:"reason"`
+	Status       string                     `json:"status"`
+	DryRun       bool                       `json:"dry_run,omitempty"`
+	Moved        map[string]int             `json:"moved"`
+	Conflicts    []AccountMergeConflictInfo `json:"conflicts"`
+	RevertedBy   int64                      `json:"reverted_by,omitempty"`
+	RevertedAt   string                     `json:"reverted_at,omitempty"`
+	CreatedAt    string                     `json:"created_at"`
+}
+
+type AccountMergeListResponse struct {
+	Merges     []AccountMergeInfo `json:"merges"`
+	Pagination PaginationInfo     `json:"pagination"`
+}
+
+// QueuedTaskInfo is a generation task held by the task queue
+type QueuedTaskInfo struct {
+	TaskID      string `json:"task_id"`
+	TaskType    string `json:"task_type"`
+	UserID      int64  `json:"user_id"`
+	AIAPIID     int64  `json:"ai_api_id"`
+	Provider    string `json:"provider"`
+	State       string `json:"state"`
+	Attempt     int    `json:"attempt"`
+	MaxAttempts int    `json:"max_attempts"`
+	LastError   string `json:"last_error,omitempty"`
+	EnqueuedAt  string `json:"enqueued_at"`
+	ClaimedAt   string `json:"claimed_at,omitempty"`
+	Deadline    string `json:"deadline,omitempty"`
+	// ProcessingSeconds is how long the current attempt has been running
+	ProcessingSeconds int64 `json:"processing_seconds,omitempty"`
+}
+
+type StuckTaskListResponse struct {
+	Tasks []QueuedTaskInfo `json:"tasks"`
+}
+
+// ProviderQueueDepthInfo counts the queued tasks bound for one AI provider
+type ProviderQueueDepthInfo struct {
+	Provider  string `json:"provider"`
+	Pending   int    `json:"pending"`
+	Scheduled int    `json:"scheduled"`
+	Active    int    `json:"active"`
+}
+
+type QueueDepthResponse struct {
+	Providers []ProviderQueueDepthInfo `json:"providers"`
+	Total     int                      `json:"total"`
+}
+
+// AIResponseArchiveInfo is the redacted AI response a generated plan was
+// parsed from
+type AIResponseArchiveInfo struct {
+	ID        int64  `json:"id"`
+	TaskID    string `json:"task_id"`
+	UserID    int64  `json:"user_id"`
+	TaskType  string `json:"task_type"`
+	PlanID    int64  `json:"plan_id"`
+	AIAPIID   int64  `json:"ai_api_id"`
+	Provider  string `json:"provider"`
+	Model     string `json:"model,omitempty"`
+	Response  string `json:"response"`
+	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at"`
+}
@@ -7,16 +7,21 @@ type AIAPIListResponse struct {
 }
 
 type AIAPIInfo struct {
-	ID          int64   `json:"id"`
-	Provider    string  `json:"provider"`
-	Name        string  `json:"name"`
-	APIEndpoint string  `json:"api_endpoint"`
-	Model       string  `json:"model"`
-	MaxTokens   int     `json:"max_tokens,omitempty"`
-	Temperature float64 `json:"temperature,omitempty"`
-	IsDefault   bool    `json:"is_default"`
-	Status      bool    `json:"status"`
-	CreatedAt   string  `json:"created_at"`
+	ID               int64   `json:"id"`
+	Provider         string  `json:"provider"`
+	Name             string  `json:"name"`
+	APIEndpoint      string  `json:"api_endpoint"`
+	Model            string  `json:"model"`
+	MaxTokens        int     `json:"max_tokens,omitempty"`
+	Temperature      float64 `json:"temperature,omitempty"`
+	TimeoutSeconds   *int    `json:"timeout_seconds,omitempty"`
+	IsDefault        bool    `json:"is_default"`
+	FallbackPriority *int    `json:"fallback_priority,omitempty"`
+	Status           bool    `json:"status"`
+	// BlockedByPolicy marks an API whose provider the deployment no longer
+	// allows; it cannot be tested or used for generation
+	BlockedByPolicy bool   `json:"blocked_by_policy,omitempty"`
+	CreatedAt       string `json:"created_at"`
 }
 
 type AIAPIDetailResponse struct {
@@ -28,10 +33,61 @@ type TestAPIResponse struct {
 }
 
 type APITestResult struct {
-	Status       string    `json:"status"`
-	ResponseTime int       `json:"response_time"`
-	ModelInfo    ModelInfo `json:"model_info"`
-	Message      string    `json:"message,omitempty"`
+	Status       string       `json:"status"`
+	ResponseTime int          `json:"response_time"`
+	ModelInfo    ModelInfo    `json:"model_info"`
+	Message      string       `json:"message,omitempty"`
+	Circuit      *CircuitInfo `json:"circuit,omitempty"`
+}
+
+type CircuitInfo struct {
+	State               string  `json:"state"`
+	ConsecutiveFailures int     `json:"consecutive_failures"`
+	OpenUntil           *string `json:"open_until,omitempty"`
+	LastError           string  `json:"last_error,omitempty"`
+}
+
+type AIUsageResponse struct {
+	APIID            int64              `json:"api_id"`
+	Provider         string             `json:"provider"`
+	Since            string             `json:"since"`
+	Calls            int64              `json:"calls"`
+	PromptTokens     int64              `json:"prompt_tokens"`
+	CompletionTokens int64              `json:"completion_tokens"`
+	TotalTokens      int64              `json:"total_tokens"`
+	EstimatedCostUSD float64            `json:"estimated_cost_usd"`
+	UnpricedTokens   int64              `json:"unpriced_tokens"`
+	ByModel          []AIUsageModelInfo `json:"by_model"`
+}
+
+type AIUsageModelInfo struct {
+	Model            string   `json:"model"`
+	Calls            int64    `json:"calls"`
+	PromptTokens     int64    `json:"prompt_tokens"`
+	CompletionTokens int64    `json:"completion_tokens"`
+	TotalTokens      int64    `json:"total_tokens"`
+	EstimatedCostUSD *float64 `json:"estimated_cost_usd"`
+}
+
+type AICallLogInfo struct {
+	ID               int64   `json:"id"`
+	APIID            int64   `json:"api_id"`
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	Purpose          string  `json:"purpose"`
+	Status           string  `json:"status"`
+	Error            *string `json:"error,omitempty"`
+	LatencyMs        int64   `json:"latency_ms"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	PromptHash       string  `json:"prompt_hash"`
+	CreatedAt        string  `json:"created_at"`
+}
+
+type AICallLogListResponse struct {
+	Calls      []AICallLogInfo `json:"calls"`
+	Pagination PaginationInfo  `json:"pagination"`
 }
 
 type ModelInfo struct {
@@ -8,13 +8,33 @@ type AuthResponse struct {
 }
 
 type UserInfo struct {
-	ID        int64  `json:"id"`
-	Username  string `json:"username"`
-	Nickname  string `json:"nickname,omitempty"`
-	Email     string `json:"email"`
-	Phone     string `json:"phone,omitempty"`
-	Avatar    string `json:"avatar,omitempty"`
-	CreatedAt string `json:"created_at"`
+	ID           int64  `json:"id"`
+	Username     string `json:"username"`
+	Nickname     string `json:"nickname,omitempty"`
+	Email        string `json:"email"`
+	Phone        string `json:"phone,omitempty"`
+	Avatar       string `json:"avatar,omitempty"`
+	WeekStart    string `json:"week_start,omitempty"`
+	AutoRollover bool   `json:"auto_rollover"`
+	// AnalyticsOptOut keeps the user's data out of anonymized analytics exports
+	AnalyticsOptOut bool `json:"analytics_opt_out"`
+	// ResponseArchiveOptOut keeps the AI responses behind the user's plans
+	// out of the support archive
+	ResponseArchiveOptOut bool   `json:"response_archive_opt_out"`
+	CreatedAt             string `json:"created_at"`
+	// BusyWeekdays (0 = Sunday) and BlackoutDates are days the user cannot train
+	BusyWeekdays  []int    `json:"busy_weekdays,omitempty"`
+	BlackoutDates []string `json:"blackout_dates,omitempty"`
+	// RestIntervals are the user's default rests, omitted when none is set
+	RestIntervals *RestIntervalInfo `json:"rest_intervals,omitempty"`
+}
+
+// RestIntervalInfo holds default rests between sets in seconds; kinds left
+// out keep the plan's rests
+type RestIntervalInfo struct {
+	Strength    int `json:"strength,omitempty"`
+	Hypertrophy int `json:"hypertrophy,omitempty"`
+	Cardio      int `json:"cardio,omitempty"`
 }
 
 type LoginResponse struct {
@@ -8,9 +8,18 @@ type BaseResponse struct {
 	Code      int         `json:"code"`
 	Message   string      `json:"message"`
 	Data      interface{} `json:"data,omitempty"`
+	Warnings  []Warning   `json:"warnings,omitempty"`
 	Timestamp int64       `json:"timestamp"`
 }
 
+// Warning is a non-fatal problem returned alongside a successful response,
+// such as a value that was clamped or estimated; Code is stable for clients
+// to match on
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
 func Success(data interface{}) *BaseResponse {
 	return &BaseResponse{
 		Code:      200,
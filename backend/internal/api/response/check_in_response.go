@@ -0,0 +1,19 @@
+package response
+
+type CheckInInfo struct {
+	ID              int64    `json:"id"`
+	WeekStart       string   `json:"week_start"`
+	Weight          float64  `json:"weight,omitempty"`
+	AdherenceRating int      `json:"adherence_rating"`
+	EnergyLevel     int      `json:"energy_level"`
+	HungerLevel     int      `json:"hunger_level"`
+	Notes           string   `json:"notes,omitempty"`
+	Photos          []string `json:"photos"`
+	CreatedAt       string   `json:"created_at"`
+	UpdatedAt       string   `json:"updated_at"`
+}
+
+type CheckInListResponse struct {
+	CheckIns   []CheckInInfo  `json:"check_ins"`
+	Pagination PaginationInfo `json:"pagination"`
+}
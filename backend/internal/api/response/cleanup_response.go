@@ -0,0 +1,10 @@
+package response
+
+// CleanupPreviewResponse reports what a bulk deletion would delete. Sending
+// the same request again with ConfirmationToken before ExpiresAt starts it.
+type CleanupPreviewResponse struct {
+	Kind              string `json:"kind"`
+	Count             int64  `json:"count"`
+	ConfirmationToken string `json:"confirmation_token"`
+	ExpiresAt         string `json:"expires_at"`
+}
@@ -0,0 +1,18 @@
+package response
+
+type CoachMessageInfo struct {
+	ID        int64  `json:"id"`
+	Role      string `json:"role"`
+	Content   string `json:"content"`
+	AIAPIID   *int64 `json:"ai_api_id,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type CoachChatResponse struct {
+	Question CoachMessageInfo `json:"question"`
+	Reply    CoachMessageInfo `json:"reply"`
+}
+
+type CoachHistoryResponse struct {
+	Messages []CoachMessageInfo `json:"messages"`
+}
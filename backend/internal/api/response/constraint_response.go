@@ -0,0 +1,17 @@
+package response
+
+type TrainingConstraintInfo struct {
+	ID                  int64    `json:"id"`
+	Source              string   `json:"source"`
+	Description         string   `json:"description"`
+	RestrictedMovements []string `json:"restricted_movements"`
+	Attachments         []string `json:"attachments"`
+	StartsOn            string   `json:"starts_on"`
+	ExpiresOn           *string  `json:"expires_on"`
+	IsActive            bool     `json:"is_active"`
+	UpdatedAt           string   `json:"updated_at"`
+}
+
+type TrainingConstraintListResponse struct {
+	Constraints []TrainingConstraintInfo `json:"constraints"`
+}
@@ -0,0 +1,15 @@
+package response
+
+type EquipmentProfileInfo struct {
+	ID           int64    `json:"id"`
+	Name         string   `json:"name"`
+	LocationType string   `json:"location_type"`
+	Equipment    []string `json:"equipment"`
+	Weekdays     []int    `json:"weekdays"`
+	IsDefault    bool     `json:"is_default"`
+	UpdatedAt    string   `json:"updated_at"`
+}
+
+type EquipmentProfileListResponse struct {
+	Profiles []EquipmentProfileInfo `json:"profiles"`
+}
@@ -0,0 +1,37 @@
+package response
+
+import "time"
+
+// DateLayout is the format of calendar dates in responses
+const DateLayout = "2006-01-02"
+
+// FormatDate formats a calendar date such as a workout or plan date. Dates
+// are stored without a time of day and read in the server's zone, so they
+// are formatted as they are, not converted.
+func FormatDate(t time.Time) string {
+	return t.Format(DateLayout)
+}
+
+// FormatTime formats an instant such as a creation time as RFC 3339 in UTC,
+// so every timestamp in a response ends in Z whatever the server's zone
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// FormatDatePtr formats an optional date, returning "" for nil so the field
+// can be omitted
+func FormatDatePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return FormatDate(*t)
+}
+
+// FormatTimePtr formats an optional instant, returning "" for nil so the
+// field can be omitted
+func FormatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return FormatTime(*t)
+}
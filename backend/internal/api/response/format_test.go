@@ -0,0 +1,33 @@
+package response
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var shanghai = time.FixedZone("CST", 8*3600)
+
+func TestFormatDate_KeepsCalendarDay(t *testing.T) {
+	// Just after midnight in UTC+8 is still the previous day in UTC
+	date := time.Date(2024, 3, 4, 0, 30, 0, 0, shanghai)
+
+	assert.Equal(t, "2024-03-04", FormatDate(date))
+}
+
+func TestFormatTime_ConvertsToUTC(t *testing.T) {
+	instant := time.Date(2024, 3, 4, 0, 30, 15, 123, shanghai)
+
+	assert.Equal(t, "2024-03-03T16:30:15Z", FormatTime(instant))
+	assert.Equal(t, "2024-03-03T16:30:15Z", FormatTime(instant.UTC()))
+}
+
+func TestFormatPtr_EmptyForNil(t *testing.T) {
+	instant := time.Date(2024, 3, 4, 9, 0, 0, 0, shanghai)
+
+	assert.Equal(t, "", FormatDatePtr(nil))
+	assert.Equal(t, "", FormatTimePtr(nil))
+	assert.Equal(t, "2024-03-04", FormatDatePtr(&instant))
+	assert.Equal(t, "2024-03-04T01:00:00Z", FormatTimePtr(&instant))
+}
@@ -0,0 +1,31 @@
+package response
+
+type MacrocycleInfo struct {
+	ID        int64    `json:"id"`
+	Name      string   `json:"name"`
+	Goal      string   `json:"goal"`
+	Phases    []string `json:"phases"`
+	CreatedAt string   `json:"created_at"`
+}
+
+type MacrocycleBlockInfo struct {
+	BlockNumber    int     `json:"block_number"`
+	Phase          string  `json:"phase"`
+	PlanID         int64   `json:"plan_id"`
+	PlanName       string  `json:"plan_name"`
+	StartDate      string  `json:"start_date"`
+	EndDate        string  `json:"end_date"`
+	TotalWeeks     int     `json:"total_weeks"`
+	Status         string  `json:"status"`
+	CompletionRate float64 `json:"completion_rate"` // 截至目前已记录的训练日占比（%）
+}
+
+type MacrocycleDetailResponse struct {
+	MacrocycleInfo
+	Blocks    []MacrocycleBlockInfo `json:"blocks"`
+	NextPhase string                `json:"next_phase,omitempty"`
+}
+
+type MacrocycleListResponse struct {
+	Macrocycles []MacrocycleInfo `json:"macrocycles"`
+}
@@ -0,0 +1,75 @@
+package response
+
+type RuntimeInfoResponse struct {
+	App       RuntimeAppInfo       `json:"app"`
+	RateLimit RuntimeRateLimitInfo `json:"rate_limit"`
+	AI        RuntimeAIInfo        `json:"ai"`
+	Features  map[string]bool      `json:"features"`
+	Sync      RuntimeSyncInfo      `json:"sync"`
+	Schema    RuntimeSchemaInfo    `json:"schema"`
+}
+
+type RuntimeAppInfo struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Mode      string `json:"mode"`
+	GoVersion string `json:"go_version"`
+	StartedAt string `json:"started_at"`
+}
+
+type RuntimeRateLimitInfo struct {
+	APICallsPerMinute     int64 `json:"api_calls_per_minute"`
+	APICallsPerHour       int64 `json:"api_calls_per_hour"`
+	APICallsPerDay        int64 `json:"api_calls_per_day"`
+	AIGenerationsPerDay   int64 `json:"ai_generations_per_day"`
+	AIGenerationsPerMonth int64 `json:"ai_generations_per_month"`
+}
+
+type RuntimeAIInfo struct {
+	MaxConcurrentRequests int              `json:"max_concurrent_requests"`
+	TimeoutSeconds        int              `json:"timeout_seconds"`
+	RetryAttempts         int              `json:"retry_attempts"`
+	SupportedProviders    []string         `json:"supported_providers"`
+	AllowedProviders      []string         `json:"allowed_providers"`
+	ConfiguredProviders   map[string]int64 `json:"configured_providers"`
+}
+
+type RuntimeSyncInfo struct {
+	TrainingRecordPolicy  string `json:"training_record_policy"`
+	NutritionRecordPolicy string `json:"nutrition_record_policy"`
+}
+
+type RuntimeSchemaInfo struct {
+	Tracked bool     `json:"tracked"`
+	Current string   `json:"current"`
+	Latest  string   `json:"latest"`
+	Pending []string `json:"pending"`
+}
+
+// NutritionOptionsResponse lists the values nutrition plan generation
+// accepts for preferences and dietary_restrictions
+type NutritionOptionsResponse struct {
+	Cuisines            []DietTermInfo `json:"cuisines"`
+	DietaryRestrictions []DietTermInfo `json:"dietary_restrictions"`
+}
+
+// DietTermInfo is one accepted value; requests send Code, which plans store
+type DietTermInfo struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	EnglishName string `json:"english_name"`
+}
+
+// ExerciseOptionsResponse lists the values exercise substitution accepts for
+// unavailable_equipment and avoid_joints
+type ExerciseOptionsResponse struct {
+	Equipment []ExerciseTermInfo `json:"equipment"`
+	Joints    []ExerciseTermInfo `json:"joints"`
+}
+
+// ExerciseTermInfo is one accepted value of the exercise library
+type ExerciseTermInfo struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	EnglishName string `json:"english_name"`
+}
@@ -0,0 +1,17 @@
+package response
+
+type NotificationInfo struct {
+	ID        int64                  `json:"id"`
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Content   string                 `json:"content,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Read      bool                   `json:"read"`
+	ReadAt    string                 `json:"read_at,omitempty"`
+	CreatedAt string                 `json:"created_at"`
+}
+
+type NotificationListResponse struct {
+	Notifications []NotificationInfo `json:"notifications"`
+	Pagination    PaginationInfo     `json:"pagination"`
+}
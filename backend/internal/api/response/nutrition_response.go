@@ -13,13 +13,63 @@ type NutritionPlanInfo struct {
 	StartDate           string   `json:"start_date"`
 	EndDate             string   `json:"end_date"`
 	DailyCalories       float64  `json:"daily_calories"`
+	CalorieBasis        string   `json:"calorie_basis,omitempty"` // provided, calculated or default
 	ProteinRatio        float64  `json:"protein_ratio"`
 	CarbRatio           float64  `json:"carb_ratio"`
 	FatRatio            float64  `json:"fat_ratio"`
 	DietaryRestrictions []string `json:"dietary_restrictions,omitempty"`
 	Preferences         []string `json:"preferences,omitempty"`
+	LeftoverLunch       bool     `json:"leftover_lunch"`
+	BudgetLevel         string   `json:"budget_level,omitempty"`
+	WeeklyBudget        *float64 `json:"weekly_budget,omitempty"`
+	ParentPlanID        *int64   `json:"parent_plan_id,omitempty"`
 	Status              string   `json:"status"`
 	CreatedAt           string   `json:"created_at"`
+	// Warnings point out missing data the user can add to improve the plan
+	Warnings []PlanWarningInfo `json:"warnings,omitempty"`
+}
+
+// NutritionPlanDetailResponse represents a nutrition plan with its days
+type NutritionPlanDetailResponse struct {
+	Plan NutritionPlanDetailInfo `json:"plan"`
+}
+
+// NutritionPlanDetailInfo is a nutrition plan; PlanData is only set when
+// requested with include=plan_data
+type NutritionPlanDetailInfo struct {
+	NutritionPlanInfo
+	PlanData  *NutritionPlanDataInfo `json:"plan_data,omitempty"`
+	UpdatedAt string                 `json:"updated_at"`
+}
+
+// NutritionPlanDataInfo is the daily meal plan of a nutrition plan
+type NutritionPlanDataInfo struct {
+	Days []NutritionPlanDayInfo `json:"days"`
+}
+
+// NutritionPlanDayInfo is a day of a nutrition plan; Meals is keyed by
+// breakfast, lunch, dinner and snacks
+type NutritionPlanDayInfo struct {
+	Day         int                  `json:"day"`
+	Date        string               `json:"date"`
+	Meals       map[string]MealInfo  `json:"meals"`
+	DailyTotals *NutritionTotalsInfo `json:"daily_totals,omitempty"`
+}
+
+// NutritionTotalsInfo is the planned calories and macros of a day
+type NutritionTotalsInfo struct {
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein"`
+	Carbs    float64 `json:"carbs"`
+	Fat      float64 `json:"fat"`
+}
+
+// NutritionDayResponse is a regenerated day of a nutrition plan; Day has the
+// same structure as an entry of the plan's plan_data.days
+type NutritionDayResponse struct {
+	PlanID int64                  `json:"plan_id"`
+	Date   string                 `json:"date"`
+	Day    map[string]interface{} `json:"day"`
 }
 
 // NutritionRecordInfo represents a nutrition record in responses
@@ -52,3 +102,26 @@ type DailySummaryResponse struct {
 	TotalFiber    float64 `json:"total_fiber"`
 	MealCount     int     `json:"meal_count"`
 }
+
+type ShoppingListResponse struct {
+	PlanID     int64  `json:"plan_id"`
+	Week       int    `json:"week"`
+	TotalWeeks int    `json:"total_weeks"`
+	StartDate  string `json:"start_date"`
+	EndDate    string `json:"end_date"`
+	// EstimatedCost is in CNY and leaves out unpriced servings
+	EstimatedCost *float64           `json:"estimated_cost,omitempty"`
+	UnpricedCount int                `json:"unpriced_count"`
+	BudgetLevel   string             `json:"budget_level,omitempty"`
+	WeeklyBudget  *float64           `json:"weekly_budget,omitempty"`
+	OverBudget    bool               `json:"over_budget"`
+	Items         []ShoppingListItem `json:"items"`
+}
+
+type ShoppingListItem struct {
+	Name          string   `json:"name"`
+	Quantity      string   `json:"quantity,omitempty"`
+	Servings      int      `json:"servings"`
+	EstimatedCost *float64 `json:"estimated_cost,omitempty"`
+	UnpricedCount int      `json:"unpriced_count"`
+}
@@ -4,12 +4,73 @@ import "github.com/ai-fitness-planner/backend/internal/model"
 
 // 通用响应类型
 type TaskResponse struct {
-	TaskID        string      `json:"task_id"`
-	Status        string      `json:"status"`
-	Progress      int         `json:"progress"`
-	EstimatedTime int         `json:"estimated_time"`
-	Result        interface{} `json:"result,omitempty"`
-	ErrorMessage  string      `json:"error_message,omitempty"`
+	TaskID        string                `json:"task_id"`
+	Status        string                `json:"status"`
+	Progress      int                   `json:"progress"`
+	EstimatedTime int                   `json:"estimated_time"`
+	Result        interface{}           `json:"result,omitempty"`
+	ErrorMessage  string                `json:"error_message,omitempty"`
+	Cooldown      *ProviderCooldownInfo `json:"cooldown,omitempty"`
+	Warnings      []PlanWarningInfo     `json:"warnings,omitempty"`
+	// Existing marks a task the user already had pending, returned in
+	// place of starting another generation
+	Existing bool `json:"existing,omitempty"`
+	// ExpiresAt is when a finished task stops being available; polling
+	// can stop once it is set
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+// TaskListResponse lists a user's recent generation tasks, newest first
+type TaskListResponse struct {
+	Tasks []TaskSummaryInfo `json:"tasks"`
+}
+
+// TaskSummaryInfo is a generation task without its result; PlanID is set
+// once the task has completed
+type TaskSummaryInfo struct {
+	TaskID       string `json:"task_id"`
+	Status       string `json:"status"`
+	Progress     int    `json:"progress"`
+	Message      string `json:"message,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+	PlanID       *int64 `json:"plan_id,omitempty"`
+	CreatedAt    string `json:"created_at"`
+	UpdatedAt    string `json:"updated_at"`
+	ExpiresAt    string `json:"expires_at,omitempty"`
+}
+
+type TaskHistoryResponse struct {
+	Tasks []GenerationTaskInfo `json:"tasks"`
+}
+
+// GenerationTaskInfo is a finished generation task from the task history;
+// Params is the task's request as it was queued. ResponseArchiveID is set
+// when the AI response behind the task's plan is archived.
+type GenerationTaskInfo struct {
+	TaskID            string                 `json:"task_id"`
+	UserID            int64                  `json:"user_id"`
+	TaskType          string                 `json:"task_type"`
+	Status            string                 `json:"status"`
+	ErrorMessage      string                 `json:"error_message,omitempty"`
+	PlanID            *int64                 `json:"plan_id,omitempty"`
+	Params            map[string]interface{} `json:"params,omitempty"`
+	ResponseArchiveID *int64                 `json:"response_archive_id,omitempty"`
+	DurationMs        int64                  `json:"duration_ms"`
+	CreatedAt         string                 `json:"created_at"`
+	FinishedAt        string                 `json:"finished_at"`
+}
+
+// PlanWarningInfo points out something the user can fix to get a better
+// plan; Code is stable for clients to match on
+type PlanWarningInfo struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ProviderCooldownInfo struct {
+	Provider       string `json:"provider"`
+	Until          string `json:"until"`
+	RetryInSeconds int    `json:"retry_in_seconds"`
 }
 
 type PlanListResponse struct {
@@ -18,30 +79,232 @@ type PlanListResponse struct {
 }
 
 type PlanInfo struct {
-	ID              int64  `json:"id"`
-	Name            string `json:"name"`
-	StartDate       string `json:"start_date"`
-	EndDate         string `json:"end_date"`
-	TotalWeeks      int    `json:"total_weeks"`
-	DifficultyLevel string `json:"difficulty_level"`
-	Status          string `json:"status"`
+	ID              int64   `json:"id"`
+	Name            string  `json:"name"`
+	StartDate       string  `json:"start_date"`
+	EndDate         string  `json:"end_date"`
+	TotalWeeks      int     `json:"total_weeks"`
+	DifficultyLevel string  `json:"difficulty_level"`
+	ParentPlanID    *int64  `json:"parent_plan_id,omitempty"`
+	MacrocycleID    *int64  `json:"macrocycle_id,omitempty"`
+	BlockNumber     *int    `json:"block_number,omitempty"`
+	BlockPhase      *string `json:"block_phase,omitempty"`
+	Status          string  `json:"status"`
+}
+
+type PlanComparisonResponse struct {
+	PlanA           ComparedPlanInfo     `json:"plan_a"`
+	PlanB           ComparedPlanInfo     `json:"plan_b"`
+	Weeks           []PlanWeekComparison `json:"weeks"`
+	ExerciseOverlap ExerciseOverlapInfo  `json:"exercise_overlap"`
+}
+
+type ComparedPlanInfo struct {
+	ID                     int64          `json:"id"`
+	Name                   string         `json:"name"`
+	CreatedAt              string         `json:"created_at"`
+	TotalWeeks             int            `json:"total_weeks"`
+	AvgTrainingDaysPerWeek float64        `json:"avg_training_days_per_week"`
+	TotalSets              int            `json:"total_sets"`
+	TotalDurationMinutes   int            `json:"total_duration_minutes"`
+	Intensity              map[string]int `json:"intensity"`
+}
+
+type PlanWeekComparison struct {
+	Week               int            `json:"week"`
+	A                  *PlanWeekStats `json:"a"`
+	B                  *PlanWeekStats `json:"b"`
+	TrainingDaysChange int            `json:"training_days_change"`
+	TotalSetsChange    int            `json:"total_sets_change"`
+	DurationChange     int            `json:"duration_minutes_change"`
+}
+
+type PlanWeekStats struct {
+	TrainingDays      int            `json:"training_days"`
+	RestDays          int            `json:"rest_days"`
+	Exercises         int            `json:"exercises"`
+	TotalSets         int            `json:"total_sets"`
+	DurationMinutes   int            `json:"duration_minutes"`
+	EstimatedCalories int            `json:"estimated_calories"`
+	Intensity         map[string]int `json:"intensity"`
+}
+
+type ExerciseOverlapInfo struct {
+	Shared  []string `json:"shared"`
+	OnlyInA []string `json:"only_in_a"`
+	OnlyInB []string `json:"only_in_b"`
+	Jaccard float64  `json:"jaccard"`
+}
+
+type ProgressionAuditResponse struct {
+	PlanID        int64                 `json:"plan_id"`
+	Score         int                   `json:"score"`
+	OverallChange float64               `json:"overall_change"`
+	Flat          bool                  `json:"flat"`
+	Issues        []string              `json:"issues"`
+	Weeks         []ProgressionWeekInfo `json:"weeks"`
+}
+
+type ProgressionWeekInfo struct {
+	Week      int     `json:"week"`
+	Exercises int     `json:"exercises"`
+	Change    float64 `json:"change"`
+}
+
+// PlanDayCompletionResponse is a plan day marked as completed
+type PlanDayCompletionResponse struct {
+	PlanID      int64  `json:"plan_id"`
+	Date        string `json:"date"`
+	RecordID    *int64 `json:"record_id"`
+	CompletedAt string `json:"completed_at"`
+}
+
+// ExerciseSubstitutionResponse is an exercise of a plan day replaced by a
+// substitute; Source is library or ai. The exercises have the structure of
+// the entries of a plan day's exercises.
+type ExerciseSubstitutionResponse struct {
+	PlanID   int64                  `json:"plan_id"`
+	Date     string                 `json:"date"`
+	Index    int                    `json:"index"`
+	Source   string                 `json:"source"`
+	Original map[string]interface{} `json:"original"`
+	Exercise map[string]interface{} `json:"exercise"`
+}
+
+// TrainingWeekResponse is a regenerated week of a training plan and the
+// plan version it is part of
+type TrainingWeekResponse struct {
+	PlanID  int64        `json:"plan_id"`
+	Version int          `json:"version"`
+	Week    PlanWeekInfo `json:"week"`
+}
+
+// PlanVersionInfo is an earlier version of a training plan. Week and
+// Feedback describe the regeneration that replaced it; PlanData is only set
+// when a single version is requested.
+type PlanVersionInfo struct {
+	Version    int                   `json:"version"`
+	Week       *int                  `json:"week,omitempty"`
+	Feedback   string                `json:"feedback,omitempty"`
+	ReplacedAt string                `json:"replaced_at"`
+	PlanData   *TrainingPlanDataInfo `json:"plan_data,omitempty"`
+}
+
+// PlanVersionListResponse lists the earlier versions of a training plan,
+// newest first
+type PlanVersionListResponse struct {
+	PlanID   int64             `json:"plan_id"`
+	Versions []PlanVersionInfo `json:"versions"`
+}
+
+// PlanVersionResponse is an earlier version of a training plan with its data
+type PlanVersionResponse struct {
+	PlanID  int64           `json:"plan_id"`
+	Version PlanVersionInfo `json:"version"`
+}
+
+// PlanShareResponse is a public link token for a training plan
+type PlanShareResponse struct {
+	PlanID    int64  `json:"plan_id"`
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// SharedPlanResponse is a training plan opened through a share link. It
+// carries the program only: no owner, AI settings, constraints, completion
+// or substitution history.
+type SharedPlanResponse struct {
+	Name            string                `json:"name"`
+	StartDate       string                `json:"start_date"`
+	EndDate         string                `json:"end_date"`
+	TotalWeeks      int                   `json:"total_weeks"`
+	DifficultyLevel string                `json:"difficulty_level"`
+	TrainingPurpose string                `json:"training_purpose,omitempty"`
+	PlanData        *TrainingPlanDataInfo `json:"plan_data"`
+	ExpiresAt       string                `json:"expires_at"`
+}
+
+type WeekSummaryResponse struct {
+	PlanID         int64            `json:"plan_id"`
+	Week           int              `json:"week"`
+	TotalWeeks     int              `json:"total_weeks"`
+	StartDate      string           `json:"start_date"`
+	EndDate        string           `json:"end_date"`
+	TrainingDays   int              `json:"training_days"`
+	CompletedDays  int              `json:"completed_days"`
+	PlannedMinutes int              `json:"planned_minutes"`
+	LoggedMinutes  int              `json:"logged_minutes"`
+	CompletionRate float64          `json:"completion_rate"`
+	Days           []WeekDaySummary `json:"days"`
+}
+
+type WeekDaySummary struct {
+	Day             int     `json:"day"`
+	Date            string  `json:"date"`
+	Type            string  `json:"type"`
+	FocusArea       string  `json:"focus_area,omitempty"`
+	DurationMinutes int     `json:"duration_minutes"`
+	Exercises       int     `json:"exercises"`
+	PlannedSets     int     `json:"planned_sets"`
+	LoggedSets      int     `json:"logged_sets"`
+	LoggedMinutes   int     `json:"logged_minutes"`
+	Completion      float64 `json:"completion"`
+	Status          string  `json:"status"` // rest, upcoming, missed, partial, completed
 }
 
 type PlanDetailResponse struct {
 	Plan PlanDetailInfo `json:"plan"`
 }
 
+// PlanDetailInfo is a training plan; PlanData is only set when requested
+// with include=plan_data
 type PlanDetailInfo struct {
-	ID              int64                  `json:"id"`
-	Name            string                 `json:"name"`
-	StartDate       string                 `json:"start_date"`
-	EndDate         string                 `json:"end_date"`
-	TotalWeeks      int                    `json:"total_weeks"`
-	DifficultyLevel string                 `json:"difficulty_level"`
-	TrainingPurpose string                 `json:"training_purpose,omitempty"`
-	PlanData        map[string]interface{} `json:"plan_data"`
-	Status          string                 `json:"status"`
-	CreatedAt       string                 `json:"created_at"`
+	ID              int64                 `json:"id"`
+	Name            string                `json:"name"`
+	StartDate       string                `json:"start_date"`
+	EndDate         string                `json:"end_date"`
+	TotalWeeks      int                   `json:"total_weeks"`
+	DifficultyLevel string                `json:"difficulty_level"`
+	TrainingPurpose string                `json:"training_purpose,omitempty"`
+	Version         int                   `json:"version"` // raised each time a week is regenerated
+	ParentPlanID    *int64                `json:"parent_plan_id,omitempty"`
+	MacrocycleID    *int64                `json:"macrocycle_id,omitempty"`
+	BlockNumber     *int                  `json:"block_number,omitempty"`
+	BlockPhase      *string               `json:"block_phase,omitempty"`
+	PlanData        *TrainingPlanDataInfo `json:"plan_data,omitempty"`
+	Status          string                `json:"status"`
+	PausedAt        string                `json:"paused_at,omitempty"`
+	CompletedAt     string                `json:"completed_at,omitempty"`
+	CreatedAt       string                `json:"created_at"`
+	UpdatedAt       string                `json:"updated_at"`
+}
+
+// TrainingPlanDataInfo is the weekly schedule of a training plan
+type TrainingPlanDataInfo struct {
+	Weeks            []PlanWeekInfo            `json:"weeks"`
+	Periodization    *PeriodizationInfo        `json:"periodization,omitempty"`
+	ProgressionAudit *PlanProgressionAuditInfo `json:"progression_audit,omitempty"`
+}
+
+// PlanWeekInfo is a week of a training plan
+type PlanWeekInfo struct {
+	Week   int           `json:"week"`
+	Deload bool          `json:"deload,omitempty"`
+	Days   []ScheduleDay `json:"days"`
+}
+
+// PeriodizationInfo is the periodization the plan was generated with
+type PeriodizationInfo struct {
+	Style       string `json:"style,omitempty"`
+	DeloadEvery int    `json:"deload_every,omitempty"`
+}
+
+// PlanProgressionAuditInfo is the progression audit stored when the plan was
+// generated
+type PlanProgressionAuditInfo struct {
+	Score         int     `json:"score"`
+	OverallChange float64 `json:"overall_change"`
+	Flat          bool    `json:"flat"`
 }
 
 type TodayTrainingResponse struct {
@@ -54,11 +317,33 @@ type TodaySchedule struct {
 	FocusArea          string         `json:"focus_area"`
 	Exercises          []ExerciseInfo `json:"exercises"`
 	Duration           int            `json:"duration"`
+	CircuitRounds      int            `json:"circuit_rounds,omitempty"`
 	IsCompleted        bool           `json:"is_completed"`
 	CompletedExercises int            `json:"completed_exercises"`
 	TotalExercises     int            `json:"total_exercises"`
 }
 
+// PlanScheduleResponse lists the days of a plan in the requested range
+type PlanScheduleResponse struct {
+	PlanID int64         `json:"plan_id"`
+	Start  string        `json:"start"`
+	End    string        `json:"end"`
+	Days   []ScheduleDay `json:"days"`
+}
+
+// ScheduleDay is one day of a plan schedule
+type ScheduleDay struct {
+	Day               int            `json:"day"`
+	Date              string         `json:"date"`
+	Type              string         `json:"type"`
+	FocusArea         string         `json:"focus_area"`
+	Exercises         []ExerciseInfo `json:"exercises"`
+	Duration          int            `json:"duration"`
+	EstimatedCalories int            `json:"estimated_calories"`
+	CircuitRounds     int            `json:"circuit_rounds,omitempty"`
+	IsCompleted       bool           `json:"is_completed"`
+}
+
 type ExerciseInfo struct {
 	Name        string `json:"name"`
 	Sets        int    `json:"sets"`
@@ -67,6 +352,16 @@ type ExerciseInfo struct {
 	Rest        string `json:"rest"`
 	Difficulty  string `json:"difficulty"`
 	SafetyNotes string `json:"safety_notes"`
+	// SafetyNotesEn is only set for exercises with curated safety notes
+	SafetyNotesEn string `json:"safety_notes_en,omitempty"`
+	// SubstitutedFor is set on exercises that replaced a planned one
+	SubstitutedFor string `json:"substituted_for,omitempty"`
+	// SupersetID is shared by the exercises of a superset
+	SupersetID string `json:"superset_id,omitempty"`
+	Tempo      string `json:"tempo,omitempty"`
+	// PlannedRest is the plan's rest, set when the user's default rest
+	// replaced it in Rest
+	PlannedRest string `json:"planned_rest,omitempty"`
 }
 
 type TodayNutritionResponse struct {
@@ -89,18 +384,27 @@ type MealInfo struct {
 
 type TrainingRecordInfo struct {
 	ID              int64                  `json:"id"`
-	PlanID          int64                  `json:"plan_id,omitempty"`
+	PlanID          *int64                 `json:"plan_id"`
 	WorkoutDate     string                 `json:"workout_date"`
 	WorkoutType     string                 `json:"workout_type"`
-	DurationMinutes int                    `json:"duration_minutes,omitempty"`
-	Exercises       map[string]interface{} `json:"exercises,omitempty"`
-	PerformanceData map[string]interface{} `json:"performance_data,omitempty"`
-	Notes           string                 `json:"notes,omitempty"`
-	Rating          int                    `json:"rating,omitempty"`
-	InjuryReport    string                 `json:"injury_report,omitempty"`
+	DurationMinutes *int                   `json:"duration_minutes"`
+	StartedAt       string                 `json:"started_at,omitempty"`
+	EndedAt         string                 `json:"ended_at,omitempty"`
+	DurationFlag    *string                `json:"duration_flag,omitempty"`
+	Exercises       map[string]interface{} `json:"exercises"`
+	PerformanceData map[string]interface{} `json:"performance_data"`
+	Notes           *string                `json:"notes"`
+	Rating          *int                   `json:"rating"`
+	InjuryReport    *string                `json:"injury_report"`
 	CreatedAt       string                 `json:"created_at"`
 }
 
+// TrainingRecordResponse represents a saved training record
+type TrainingRecordResponse struct {
+	Record  TrainingRecordInfo `json:"record"`
+	Message string             `json:"message,omitempty"`
+}
+
 type TrainingRecordListResponse struct {
 	Records    []TrainingRecordInfo `json:"records"`
 	Pagination PaginationInfo       `json:"pagination"`
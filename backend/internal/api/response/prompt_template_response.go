@@ -0,0 +1,43 @@
+package response
+
+type PromptTemplateInfo struct {
+	ID             int64    `json:"id"`
+	Category       string   `json:"category"`
+	Subcategory    *string  `json:"subcategory"`
+	Name           string   `json:"name"`
+	Template       string   `json:"template"`
+	Variables      []string `json:"variables"`
+	IsDefault      bool     `json:"is_default"`
+	IsCustomized   bool     `json:"is_customized"`
+	RolloutPercent int      `json:"rollout_percent"`
+	Description    *string  `json:"description"`
+	CreatedAt      string   `json:"created_at"`
+	UpdatedAt      string   `json:"updated_at"`
+}
+
+type PromptTemplatePreviewResponse struct {
+	Prompt string `json:"prompt"`
+}
+
+// PromptVariantInfo is a template of a prompt experiment with the plans
+// generated from it. The rates are nil while there are no plans.
+type PromptVariantInfo struct {
+	TemplateID      int64    `json:"template_id"`
+	Name            string   `json:"name"`
+	IsDefault       bool     `json:"is_default"`
+	RolloutPercent  int      `json:"rollout_percent"`
+	Plans           int64    `json:"plans"`
+	AdjustedPlans   int64    `json:"adjusted_plans"`
+	AdjustmentRate  *float64 `json:"adjustment_rate"`
+	CompletedPlans  int64    `json:"completed_plans"`
+	CompletionRate  *float64 `json:"completion_rate"`
+	Feedbacks       int64    `json:"feedbacks"`
+	AvgSatisfaction *float64 `json:"avg_satisfaction"`
+}
+
+type PromptExperimentResponse struct {
+	Category    string              `json:"category"`
+	Subcategory string              `json:"subcategory"`
+	Since       string              `json:"since"`
+	Variants    []PromptVariantInfo `json:"variants"`
+}
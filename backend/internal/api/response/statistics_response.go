@@ -11,6 +11,7 @@ type TrainingStatsResponse struct {
 	AverageRating     float64          `json:"average_rating"`
 	WorkoutsByType    map[string]int64 `json:"workouts_by_type,omitempty"`
 	AverageDuration   float64          `json:"average_duration_minutes"`
+	FlaggedWorkouts   int64            `json:"flagged_workouts"`
 	HasSufficientData bool             `json:"has_sufficient_data"`
 	Message           string           `json:"message,omitempty"`
 }
@@ -58,6 +59,7 @@ type WorkoutCompareInfo struct {
 // TrendsReportResponse represents trends report response
 type TrendsReportResponse struct {
 	Period            string           `json:"period"`
+	WeekStart         string           `json:"week_start,omitempty"`
 	DataPoints        []TrendPointInfo `json:"data_points"`
 	HasSufficientData bool             `json:"has_sufficient_data"`
 	Message           string           `json:"message,omitempty"`
@@ -65,11 +67,99 @@ type TrendsReportResponse struct {
 
 // TrendPointInfo represents a single data point in the trend
 type TrendPointInfo struct {
-	PeriodLabel   string  `json:"period_label"`
-	StartDate     string  `json:"start_date"`
-	EndDate       string  `json:"end_date"`
-	TotalWorkouts int64   `json:"total_workouts"`
-	TotalDuration int64   `json:"total_duration_minutes"`
-	TotalCalories int64   `json:"total_calories"`
-	AverageRating float64 `json:"average_rating"`
+	PeriodLabel       string   `json:"period_label"`
+	StartDate         string   `json:"start_date"`
+	EndDate           string   `json:"end_date"`
+	Partial           bool     `json:"partial"`
+	TotalWorkouts     int64    `json:"total_workouts"`
+	TotalDuration     int64    `json:"total_duration_minutes"`
+	TotalCalories     int64    `json:"total_calories"`
+	AverageRating     float64  `json:"average_rating"`
+	Tonnage           float64  `json:"tonnage_kg"`
+	RelativeIntensity *float64 `json:"relative_intensity_pct,omitempty"`
+}
+
+// ExerciseProgressionResponse represents the progression of one exercise
+type ExerciseProgressionResponse struct {
+	Exercise          string                   `json:"exercise"`
+	Days              int                      `json:"days"`
+	Sessions          []ProgressionSessionInfo `json:"sessions"`
+	Trend             *ProgressionTrendInfo    `json:"trend,omitempty"`
+	Suggestion        *LoadSuggestionInfo      `json:"suggestion,omitempty"`
+	HasSufficientData bool                     `json:"has_sufficient_data"`
+	Message           string                   `json:"message,omitempty"`
+}
+
+// ProgressionSessionInfo represents the sets of an exercise on one day
+type ProgressionSessionInfo struct {
+	Date               string    `json:"date"`
+	RecordID           int64     `json:"record_id"`
+	Sets               int       `json:"sets"`
+	RepsPerSet         []int     `json:"reps_per_set"`
+	WeightUsed         []float64 `json:"weight_used"`
+	TopWeight          float64   `json:"top_weight_kg"`
+	TopReps            int       `json:"top_reps"`
+	TotalReps          int       `json:"total_reps"`
+	Volume             float64   `json:"volume_kg"`
+	EstimatedOneRepMax float64   `json:"estimated_one_rep_max_kg,omitempty"`
+}
+
+// ProgressionTrendInfo represents the trend of the estimated 1RM
+type ProgressionTrendInfo struct {
+	StartOneRepMax float64 `json:"start_one_rep_max_kg"`
+	EndOneRepMax   float64 `json:"end_one_rep_max_kg"`
+	Change         float64 `json:"change_kg"`
+	ChangePercent  float64 `json:"change_percent"`
+	WeeklyChange   float64 `json:"weekly_change_kg"`
+	Direction      string  `json:"direction"`
+}
+
+// LoadSuggestionInfo represents the suggested load for the next session
+type LoadSuggestionInfo struct {
+	Action     string  `json:"action"`
+	Weight     float64 `json:"weight_kg"`
+	Sets       int     `json:"sets"`
+	RepsLow    int     `json:"reps_low"`
+	RepsHigh   int     `json:"reps_high"`
+	TargetReps int     `json:"target_reps"`
+	Reason     string  `json:"reason"`
+}
+
+// OneRepMaxResponse represents estimated 1RMs compared with strength standards
+type OneRepMaxResponse struct {
+	Days              int                 `json:"days"`
+	BodyWeight        *float64            `json:"body_weight_kg,omitempty"`
+	Gender            string              `json:"gender,omitempty"`
+	Lifts             []LiftOneRepMaxInfo `json:"lifts"`
+	HasSufficientData bool                `json:"has_sufficient_data"`
+	Message           string              `json:"message,omitempty"`
+}
+
+// LiftOneRepMaxInfo represents the estimated 1RM of a main lift; Source is
+// records or profile
+type LiftOneRepMaxInfo struct {
+	Lift      string         `json:"lift"`
+	OneRepMax float64        `json:"one_rep_max_kg"`
+	Source    string         `json:"source"`
+	Epley     float64        `json:"epley_kg,omitempty"`
+	Brzycki   float64        `json:"brzycki_kg,omitempty"`
+	BestSet   *BestSetInfo   `json:"best_set,omitempty"`
+	Level     *LiftLevelInfo `json:"level,omitempty"`
+}
+
+// BestSetInfo represents the logged set a 1RM was estimated from
+type BestSetInfo struct {
+	RecordID int64   `json:"record_id"`
+	Date     string  `json:"date"`
+	Exercise string  `json:"exercise"`
+	Weight   float64 `json:"weight_kg"`
+	Reps     int     `json:"reps"`
+}
+
+// LiftLevelInfo represents a 1RM classified against strength standards
+type LiftLevelInfo struct {
+	BodyweightRatio    float64 `json:"bodyweight_ratio"`
+	Level              string  `json:"level"`
+	NextLevel          string  `json:"next_level,omitempty"`
+	NextLevelOneRepMax float64 `json:"next_level_one_rep_max_kg,omitempty"`
 }
@@ -0,0 +1,14 @@
+package response
+
+type StrengthProfileEntryInfo struct {
+	Lift           string  `json:"lift"`
+	OneRepMax      float64 `json:"one_rep_max"`
+	Source         string  `json:"source"`
+	SourceRecordID int64   `json:"source_record_id,omitempty"`
+	AchievedAt     string  `json:"achieved_at"`
+	UpdatedAt      string  `json:"updated_at"`
+}
+
+type StrengthProfileResponse struct {
+	Lifts []StrengthProfileEntryInfo `json:"lifts"`
+}
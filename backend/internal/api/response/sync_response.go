@@ -0,0 +1,55 @@
+package response
+
+type SyncResponse struct {
+	ServerTime       string                    `json:"server_time"`
+	TrainingRecords  []SyncTrainingRecordInfo  `json:"training_records"`
+	NutritionRecords []SyncNutritionRecordInfo `json:"nutrition_records"`
+	Conflicts        []SyncConflictInfo        `json:"conflicts"`
+	Rejected         []SyncRejectionInfo       `json:"rejected"`
+}
+
+type SyncTrainingRecordInfo struct {
+	ID              int64                  `json:"id"`
+	ClientID        string                 `json:"client_id"`
+	Deleted         bool                   `json:"deleted"`
+	PlanID          *int64                 `json:"plan_id,omitempty"`
+	WorkoutDate     string                 `json:"workout_date"`
+	WorkoutType     string                 `json:"workout_type"`
+	DurationMinutes *int                   `json:"duration_minutes,omitempty"`
+	StartedAt       *string                `json:"started_at,omitempty"`
+	EndedAt         *string                `json:"ended_at,omitempty"`
+	DurationFlag    *string                `json:"duration_flag,omitempty"`
+	Exercises       map[string]interface{} `json:"exercises,omitempty"`
+	PerformanceData map[string]interface{} `json:"performance_data,omitempty"`
+	Notes           *string                `json:"notes,omitempty"`
+	Rating          *int                   `json:"rating,omitempty"`
+	InjuryReport    *string                `json:"injury_report,omitempty"`
+	UpdatedAt       string                 `json:"updated_at"`
+}
+
+type SyncNutritionRecordInfo struct {
+	ID        int64                  `json:"id"`
+	ClientID  string                 `json:"client_id"`
+	Deleted   bool                   `json:"deleted"`
+	MealDate  string                 `json:"meal_date"`
+	MealType  string                 `json:"meal_type"`
+	Calories  float64                `json:"calories"`
+	Protein   float64                `json:"protein"`
+	Carbs     float64                `json:"carbs"`
+	Fat       float64                `json:"fat"`
+	Fiber     float64                `json:"fiber"`
+	Foods     map[string]interface{} `json:"foods,omitempty"`
+	UpdatedAt string                 `json:"updated_at"`
+}
+
+type SyncConflictInfo struct {
+	Entity     string `json:"entity"`
+	ClientID   string `json:"client_id"`
+	Resolution string `json:"resolution"`
+}
+
+type SyncRejectionInfo struct {
+	Entity   string `json:"entity"`
+	ClientID string `json:"client_id"`
+	Reason   string `json:"reason"`
+}
@@ -27,10 +27,12 @@ type GoalInfo struct {
 	InitialBodyFat  float64 `json:"initial_body_fat,omitempty"`
 	InitialMuscle   float64 `json:"initial_muscle_mass,omitempty"`
 	TargetWeight    float64 `json:"target_weight,omitempty"`
+	TargetBodyFat   float64 `json:"target_body_fat,omitempty"`
 	Deadline        string  `json:"deadline,omitempty"`
 	TargetDate      string  `json:"target_date,omitempty"`
 	Priority        int     `json:"priority"`
 	Status          string  `json:"status"`
+	CompletedAt     string  `json:"completed_at,omitempty"`
 	CreatedAt       string  `json:"created_at"`
 }
 
@@ -7,12 +7,31 @@ import (
 )
 
 type Config struct {
-	App       AppConfig       `mapstructure:"app"`
-	Database  DatabaseConfig  `mapstructure:"database"`
-	JWT       JWTConfig       `mapstructure:"jwt"`
-	AI        AIConfig        `mapstructure:"ai"`
-	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
-	Log       LogConfig       `mapstructure:"log"`
+	App             AppConfig             `mapstructure:"app"`
+	Database        DatabaseConfig        `mapstructure:"database"`
+	JWT             JWTConfig             `mapstructure:"jwt"`
+	AI              AIConfig              `mapstructure:"ai"`
+	RateLimit       RateLimitConfig       `mapstructure:"rate_limit"`
+	Log             LogConfig             `mapstructure:"log"`
+	Startup         StartupConfig         `mapstructure:"startup"`
+	Abuse           AbuseConfig           `mapstructure:"abuse"`
+	Export          ExportConfig          `mapstructure:"export"`
+	Goals           GoalsConfig           `mapstructure:"goals"`
+	CheckIn         CheckInConfig         `mapstructure:"check_in"`
+	Sync            SyncConfig            `mapstructure:"sync"`
+	Mail            MailConfig            `mapstructure:"mail"`
+	Invite          InviteConfig          `mapstructure:"invite"`
+	Integrity       IntegrityConfig       `mapstructure:"integrity"`
+	Session         SessionConfig         `mapstructure:"session"`
+	Coach           CoachConfig           `mapstructure:"coach"`
+	Queue           QueueConfig           `mapstructure:"queue"`
+	Rollover        RolloverConfig        `mapstructure:"rollover"`
+	TodayCache      TodayCacheConfig      `mapstructure:"today_cache"`
+	Analytics       AnalyticsConfig       `mapstructure:"analytics"`
+	Events          EventsConfig          `mapstructure:"events"`
+	Timeout         TimeoutConfig         `mapstructure:"timeout"`
+	SystemEndpoints SystemEndpointsConfig `mapstructure:"system_endpoints"`
+	ResponseArchive ResponseArchiveConfig `mapstructure:"response_archive"`
 }
 
 type AppConfig struct {
@@ -37,6 +56,7 @@ type MySQLConfig struct {
 	MaxOpenConns    int           `mapstructure:"max_open_conns"`
 	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	AutoMigrate     bool          `mapstructure:"auto_migrate"`
 }
 
 type RedisConfig struct {
@@ -52,6 +72,17 @@ type JWTConfig struct {
 	Secret             string        `mapstructure:"secret"`
 	AccessTokenExpire  time.Duration `mapstructure:"access_token_expire"`
 	RefreshTokenExpire time.Duration `mapstructure:"refresh_token_expire"`
+	// ImpersonationExpire bounds how long an admin impersonation token is valid
+	ImpersonationExpire time.Duration `mapstructure:"impersonation_expire"`
+	// ServiceSecret signs tokens for trusted internal services; it must
+	// differ from Secret. Empty disables service tokens.
+	ServiceSecret string `mapstructure:"service_secret"`
+	// ServiceTokenExpire is the longest lifetime an admin can give a
+	// service token
+	ServiceTokenExpire time.Duration `mapstructure:"service_token_expire"`
+	// ShareTokenExpire is the longest a shared training plan link stays
+	// valid, and the lifetime of links made without one
+	ShareTokenExpire time.Duration `mapstructure:"share_token_expire"`
 }
 
 type AIConfig struct {
@@ -59,12 +90,43 @@ type AIConfig struct {
 	Timeout               time.Duration `mapstructure:"timeout"`
 	RetryAttempts         int           `mapstructure:"retry_attempts"`
 	RetryDelay            time.Duration `mapstructure:"retry_delay"`
+	// RetryDelayMin/Max bound the per-provider backoff base, which is tuned
+	// from recent latency and 429 frequency over RetryWindow calls
+	RetryDelayMin time.Duration `mapstructure:"retry_delay_min"`
+	RetryDelayMax time.Duration `mapstructure:"retry_delay_max"`
+	RetryWindow   int           `mapstructure:"retry_window"`
+	// CircuitFailureThreshold consecutive 5xx/timeout failures open a
+	// provider endpoint's circuit for CircuitCooldown; 0 disables the breaker
+	CircuitFailureThreshold int           `mapstructure:"circuit_failure_threshold"`
+	CircuitCooldown         time.Duration `mapstructure:"circuit_cooldown"`
+	// Pricing lists per-model prices used to estimate the cost of recorded
+	// token usage; models without a price are reported with no cost
+	Pricing []AIModelPrice `mapstructure:"pricing"`
+	// ResponseCacheTTL is how long a completion is reused when a user
+	// regenerates a plan with identical parameters; 0 disables the cache
+	ResponseCacheTTL time.Duration `mapstructure:"response_cache_ttl"`
+	// AllowedProviders, when set, is the only providers users may configure
+	// and generate with, e.g. only domestic or self-hosted ones for data
+	// residency; empty allows all
+	AllowedProviders []string `mapstructure:"allowed_providers"`
+}
+
+// AIModelPrice is a model's price in USD per million tokens. Model also
+// matches dated or suffixed variants, e.g. "gpt-4o" matches "gpt-4o-2024-08-06".
+type AIModelPrice struct {
+	Model                string  `mapstructure:"model"`
+	PromptPerMillion     float64 `mapstructure:"prompt_per_million"`
+	CompletionPerMillion float64 `mapstructure:"completion_per_million"`
 }
 
 type RateLimitConfig struct {
 	APICallsPerMinute int64 `mapstructure:"api_calls_per_minute"`
 	APICallsPerHour   int64 `mapstructure:"api_calls_per_hour"`
 	APICallsPerDay    int64 `mapstructure:"api_calls_per_day"`
+	// AIGenerationsPerDay/PerMonth cap accepted plan generation requests per
+	// user per calendar day/month; 0 means unlimited
+	AIGenerationsPerDay   int64 `mapstructure:"ai_generations_per_day"`
+	AIGenerationsPerMonth int64 `mapstructure:"ai_generations_per_month"`
 }
 
 type LogConfig struct {
@@ -75,6 +137,239 @@ type LogConfig struct {
 	MaxAge     int    `mapstructure:"max_age"`
 }
 
+// StartupConfig controls how the server waits for its dependencies at boot
+type StartupConfig struct {
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+	InitialDelay time.Duration `mapstructure:"initial_delay"`
+	MaxDelay     time.Duration `mapstructure:"max_delay"`
+	DegradedMode bool          `mapstructure:"degraded_mode"`
+}
+
+// AbuseConfig holds thresholds for AI generation abuse detection
+type AbuseConfig struct {
+	Enabled                 bool          `mapstructure:"enabled"`
+	SpikeThresholdPerHour   int64         `mapstructure:"spike_threshold_per_hour"`
+	DuplicatePromptAccounts int64         `mapstructure:"duplicate_prompt_accounts"`
+	DuplicatePromptWindow   time.Duration `mapstructure:"duplicate_prompt_window"`
+	SuspensionDuration      time.Duration `mapstructure:"suspension_duration"`
+	AllowedHosts            []string      `mapstructure:"allowed_hosts"`
+	// AllowLocalProviders lets the ollama provider use localhost and private
+	// network endpoints; enable it only on self-hosted deployments
+	AllowLocalProviders bool `mapstructure:"allow_local_providers"`
+}
+
+// ExportConfig controls how exports are shaped: small exports are returned
+// inline, larger ones run on the low-priority job queue
+type ExportConfig struct {
+	SyncRowLimit int64         `mapstructure:"sync_row_limit"`
+	Workers      int           `mapstructure:"workers"`
+	QueueSize    int           `mapstructure:"queue_size"`
+	JobTimeout   time.Duration `mapstructure:"job_timeout"`
+	ResultTTL    time.Duration `mapstructure:"result_ttl"`
+}
+
+// GoalsConfig controls the scheduled evaluation that auto-completes body
+// composition goals once the target has held for SustainDays
+type GoalsConfig struct {
+	EvaluationEnabled  bool          `mapstructure:"evaluation_enabled"`
+	EvaluationInterval time.Duration `mapstructure:"evaluation_interval"`
+	SustainDays        int           `mapstructure:"sustain_days"`
+}
+
+// CheckInConfig controls weekly check-in reminders. Reminders go out on
+// ReminderWeekday (0 = Sunday) to users who have not checked in that week.
+type CheckInConfig struct {
+	ReminderEnabled  bool          `mapstructure:"reminder_enabled"`
+	ReminderWeekday  int           `mapstructure:"reminder_weekday"`
+	ReminderInterval time.Duration `mapstructure:"reminder_interval"`
+}
+
+// RolloverConfig controls the scheduled rollover that generates the next
+// block of a plan once it has ended, for users who opted in. Plans that
+// ended more than LookbackDays ago are left alone.
+type RolloverConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+	LookbackDays  int           `mapstructure:"lookback_days"`
+}
+
+// TodayCacheConfig controls caching each user's training day and meals for
+// the current date. Every WarmupInterval a job checks whether the day has
+// turned over and, once per day, fills the cache for all active users.
+type TodayCacheConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	WarmupInterval time.Duration `mapstructure:"warmup_interval"`
+}
+
+// AnalyticsConfig controls the scheduled export of anonymized workout and
+// weight events for offline analytics. User IDs are replaced by an HMAC
+// keyed with PseudonymKey, and events are only exported in groups of at
+// least K users sharing the same generalized attributes. The first export
+// covers the LookbackDays before it; later ones continue where the last
+// ended. Users who opted out are never exported.
+type AnalyticsConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	ExportInterval time.Duration `mapstructure:"export_interval"`
+	LookbackDays   int           `mapstructure:"lookback_days"`
+	PseudonymKey   string        `mapstructure:"pseudonym_key"`
+	K              int           `mapstructure:"k"`
+	// Sink is "file", "s3" or "kafka"
+	Sink  string               `mapstructure:"sink"`
+	Dir   string               `mapstructure:"dir"`
+	S3    AnalyticsS3Config    `mapstructure:"s3"`
+	Kafka AnalyticsKafkaConfig `mapstructure:"kafka"`
+}
+
+// EventsConfig controls streaming domain events (plan, record and goal
+// changes) to a message broker for external pipelines
+type EventsConfig struct {
+	StreamEnabled bool `mapstructure:"stream_enabled"`
+	// Transport is "kafka" or "nats"
+	Transport   string `mapstructure:"transport"`
+	TopicPrefix string `mapstructure:"topic_prefix"`
+	BufferSize  int    `mapstructure:"buffer_size"`
+	// KafkaRESTProxyURL is the Kafka REST proxy (v2 API) events are
+	// produced through
+	KafkaRESTProxyURL string `mapstructure:"kafka_rest_proxy_url"`
+	NATSURL           string `mapstructure:"nats_url"`
+	NATSUser          string `mapstructure:"nats_user"`
+	NATSPassword      string `mapstructure:"nats_password"`
+	NATSToken         string `mapstructure:"nats_token"`
+}
+
+// AnalyticsS3Config locates the bucket exports are uploaded to; Endpoint
+// is only needed for S3-compatible stores
+type AnalyticsS3Config struct {
+	Endpoint        string `mapstructure:"endpoint"`
+	Region          string `mapstructure:"region"`
+	Bucket          string `mapstructure:"bucket"`
+	Prefix          string `mapstructure:"prefix"`
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+}
+
+// AnalyticsKafkaConfig names the topic exports are produced to through a
+// Kafka REST proxy
+type AnalyticsKafkaConfig struct {
+	RESTProxyURL string `mapstructure:"rest_proxy_url"`
+	Topic        string `mapstructure:"topic"`
+}
+
+// SyncConfig sets how offline sync resolves a record changed both on the
+// server and on the client since the client's last sync: "server_wins" keeps
+// the server copy, "client_wins" applies the client's change
+type SyncConfig struct {
+	TrainingRecordPolicy  string `mapstructure:"training_record_policy"`
+	NutritionRecordPolicy string `mapstructure:"nutrition_record_policy"`
+	MaxBatchSize          int    `mapstructure:"max_batch_size"`
+}
+
+// MailConfig holds the SMTP server used for outgoing mail. With no host,
+// mail is only logged.
+type MailConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+}
+
+// InviteConfig controls invitations sent to users provisioned for an
+// organization. AcceptURL is the frontend page the emailed token is appended to.
+type InviteConfig struct {
+	TTL       time.Duration `mapstructure:"ttl"`
+	AcceptURL string        `mapstructure:"accept_url"`
+}
+
+// IntegrityConfig controls the scheduled data consistency check. With
+// AutoRepair, scheduled runs also apply the safe fixes admins can trigger
+// manually.
+type IntegrityConfig struct {
+	CheckEnabled  bool          `mapstructure:"check_enabled"`
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+	AutoRepair    bool          `mapstructure:"auto_repair"`
+}
+
+// SessionConfig controls how authentication behaves while Redis is down.
+// Sessions read in the last LocalCacheTTL keep working; with DegradedAuth,
+// other requests are accepted on JWT signature and expiry alone.
+type SessionConfig struct {
+	// Mode is "redis" (default) or "stateless", which stores no sessions and
+	// trusts tokens, keeping only an in-memory denylist of logouts. Rate
+	// limiting and abuse detection still use Redis in either mode.
+	Mode          string        `mapstructure:"mode"`
+	LocalCacheTTL time.Duration `mapstructure:"local_cache_ttl"`
+	DegradedAuth  bool          `mapstructure:"degraded_auth"`
+	// SlidingExpiration extends active sessions to IdleTimeout from their
+	// last activity, writing at most once per RefreshInterval and never past
+	// MaxLifetime after login (0 for no cap). Stateless mode ignores it.
+	SlidingExpiration bool          `mapstructure:"sliding_expiration"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
+	RefreshInterval   time.Duration `mapstructure:"refresh_interval"`
+	MaxLifetime       time.Duration `mapstructure:"max_lifetime"`
+}
+
+// CoachConfig controls the AI coach chat. HistoryMessages earlier messages
+// are sent with each question and kept in Redis for HistoryTTL after the
+// last one; the full conversation stays in the database.
+type CoachConfig struct {
+	HistoryMessages int           `mapstructure:"history_messages"`
+	HistoryTTL      time.Duration `mapstructure:"history_ttl"`
+}
+
+// QueueConfig controls the Redis-backed queue that runs plan generation.
+// A task is tried up to MaxAttempts times, waiting RetryDelay before the
+// first retry and doubling up to MaxRetryDelay; a task whose worker has not
+// renewed its Lease is handed to another worker. The status of a finished
+// task is kept for TaskRetention, swept every TaskSweepInterval; 0 keeps it
+// until restart.
+type QueueConfig struct {
+	Workers           int           `mapstructure:"workers"`
+	MaxAttempts       int           `mapstructure:"max_attempts"`
+	RetryDelay        time.Duration `mapstructure:"retry_delay"`
+	MaxRetryDelay     time.Duration `mapstructure:"max_retry_delay"`
+	Lease             time.Duration `mapstructure:"lease"`
+	PollInterval      time.Duration `mapstructure:"poll_interval"`
+	ShutdownTimeout   time.Duration `mapstructure:"shutdown_timeout"`
+	TaskRetention     time.Duration `mapstructure:"task_retention"`
+	TaskSweepInterval time.Duration `mapstructure:"task_sweep_interval"`
+	// GenerationLockTTL bounds how long a user's pending generation blocks
+	// their next one, should the task be lost; 0 disables the lock
+	GenerationLockTTL time.Duration `mapstructure:"generation_lock_ttl"`
+}
+
+// TimeoutConfig bounds how long the API works on a request before answering
+// 504. AI covers the endpoints that wait on an AI provider, Bulk exports,
+// downloads and batch sync, and Default everything else.
+type TimeoutConfig struct {
+	Default time.Duration `mapstructure:"default"`
+	AI      time.Duration `mapstructure:"ai"`
+	Bulk    time.Duration `mapstructure:"bulk"`
+}
+
+// SystemEndpointsConfig guards /health, /metrics and /swagger, which are
+// served without a user token. In release mode each client IP may request them
+// RequestsPerMinute times a minute (0 for no limit), and they require basic
+// auth once BasicAuthUsername is set. SwaggerEnabled false removes the API
+// docs in every mode.
+type SystemEndpointsConfig struct {
+	SwaggerEnabled    bool   `mapstructure:"swagger_enabled"`
+	RequestsPerMinute int    `mapstructure:"requests_per_minute"`
+	BasicAuthUsername string `mapstructure:"basic_auth_username"`
+	BasicAuthPassword string `mapstructure:"basic_auth_password"`
+}
+
+// ResponseArchiveConfig controls archiving the AI response each generated
+// plan was parsed from, with personal data and credentials masked, so
+// support can look into disputes over the AI's advice. Archives are kept
+// for RetentionDays and purged every PurgeInterval, along with those of
+// users who opted out since.
+type ResponseArchiveConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	RetentionDays int           `mapstructure:"retention_days"`
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+}
+
 var GlobalConfig *Config
 
 func InitConfig() error {
@@ -120,6 +415,7 @@ func setDefaults() {
 	viper.SetDefault("database.mysql.max_open_conns", 25)
 	viper.SetDefault("database.mysql.max_idle_conns", 5)
 	viper.SetDefault("database.mysql.conn_max_lifetime", "300s")
+	viper.SetDefault("database.mysql.auto_migrate", false)
 
 	viper.SetDefault("database.redis.port", 6379)
 	viper.SetDefault("database.redis.db", 0)
@@ -129,17 +425,36 @@ func setDefaults() {
 	// JWT默认配置
 	viper.SetDefault("jwt.access_token_expire", "3600s")
 	viper.SetDefault("jwt.refresh_token_expire", "604800s")
+	viper.SetDefault("jwt.impersonation_expire", "1800s")
+	viper.SetDefault("jwt.service_token_expire", "24h")
+	viper.SetDefault("jwt.share_token_expire", "720h")
 
 	// AI默认配置
 	viper.SetDefault("ai.max_concurrent_requests", 10)
 	viper.SetDefault("ai.timeout", "60s")
 	viper.SetDefault("ai.retry_attempts", 3)
 	viper.SetDefault("ai.retry_delay", "5s")
+	viper.SetDefault("ai.retry_delay_min", "1s")
+	viper.SetDefault("ai.retry_delay_max", "60s")
+	viper.SetDefault("ai.retry_window", 50)
+	viper.SetDefault("ai.circuit_failure_threshold", 5)
+	viper.SetDefault("ai.circuit_cooldown", "60s")
+	viper.SetDefault("ai.response_cache_ttl", "10m")
+	viper.SetDefault("ai.pricing", []map[string]interface{}{
+		{"model": "gpt-4o-mini", "prompt_per_million": 0.15, "completion_per_million": 0.6},
+		{"model": "gpt-4o", "prompt_per_million": 2.5, "completion_per_million": 10},
+		{"model": "gpt-3.5-turbo", "prompt_per_million": 0.5, "completion_per_million": 1.5},
+		{"model": "deepseek-chat", "prompt_per_million": 0.27, "completion_per_million": 1.1},
+		{"model": "qwen-plus", "prompt_per_million": 0.4, "completion_per_million": 1.2},
+		{"model": "claude-sonnet-4-5", "prompt_per_million": 3, "completion_per_million": 15},
+	})
 
 	// 限流默认配置
 	viper.SetDefault("rate_limit.api_calls_per_minute", 60)
 	viper.SetDefault("rate_limit.api_calls_per_hour", 1000)
 	viper.SetDefault("rate_limit.api_calls_per_day", 10000)
+	viper.SetDefault("rate_limit.ai_generations_per_day", 20)
+	viper.SetDefault("rate_limit.ai_generations_per_month", 300)
 
 	// 日志默认配置
 	viper.SetDefault("log.level", "info")
@@ -147,6 +462,121 @@ func setDefaults() {
 	viper.SetDefault("log.max_size", 500)
 	viper.SetDefault("log.max_backups", 10)
 	viper.SetDefault("log.max_age", 30)
+
+	// 启动依赖重试默认配置
+	viper.SetDefault("startup.max_attempts", 5)
+	viper.SetDefault("startup.initial_delay", "1s")
+	viper.SetDefault("startup.max_delay", "30s")
+	viper.SetDefault("startup.degraded_mode", true)
+
+	// 滥用检测默认配置
+	viper.SetDefault("abuse.enabled", true)
+	viper.SetDefault("abuse.spike_threshold_per_hour", 20)
+	viper.SetDefault("abuse.duplicate_prompt_accounts", 5)
+	viper.SetDefault("abuse.duplicate_prompt_window", "1h")
+	viper.SetDefault("abuse.suspension_duration", "24h")
+	viper.SetDefault("abuse.allowed_hosts", []string{})
+	viper.SetDefault("abuse.allow_local_providers", false)
+
+	// 导出默认配置
+	viper.SetDefault("export.sync_row_limit", 500)
+	viper.SetDefault("export.workers", 2)
+	viper.SetDefault("export.queue_size", 20)
+	viper.SetDefault("export.job_timeout", "5m")
+	viper.SetDefault("export.result_ttl", "1h")
+
+	// 目标自动达成评估默认配置
+	viper.SetDefault("goals.evaluation_enabled", true)
+	viper.SetDefault("goals.evaluation_interval", "1h")
+	viper.SetDefault("goals.sustain_days", 7)
+
+	// 每周签到提醒默认配置
+	viper.SetDefault("check_in.reminder_enabled", true)
+	viper.SetDefault("check_in.reminder_weekday", 0)
+	viper.SetDefault("check_in.reminder_interval", "1h")
+
+	// 计划自动续期默认配置
+	viper.SetDefault("rollover.enabled", true)
+	viper.SetDefault("rollover.check_interval", "1h")
+	viper.SetDefault("rollover.lookback_days", 7)
+
+	// 今日训练与餐食缓存默认配置
+	viper.SetDefault("today_cache.enabled", true)
+	viper.SetDefault("today_cache.warmup_interval", "5m")
+
+	// 匿名分析数据导出默认配置
+	viper.SetDefault("analytics.enabled", false)
+	viper.SetDefault("analytics.export_interval", "24h")
+	viper.SetDefault("analytics.lookback_days", 30)
+	viper.SetDefault("analytics.k", 5)
+	viper.SetDefault("analytics.sink", "file")
+	viper.SetDefault("analytics.dir", "./data/analytics")
+
+	// 领域事件流默认配置
+	viper.SetDefault("events.stream_enabled", false)
+	viper.SetDefault("events.transport", "kafka")
+	viper.SetDefault("events.topic_prefix", "fitplanner")
+	viper.SetDefault("events.buffer_size", 1000)
+
+	// 请求超时默认配置
+	viper.SetDefault("timeout.default", "10s")
+	viper.SetDefault("timeout.ai", "120s")
+	viper.SetDefault("timeout.bulk", "60s")
+
+	// 健康检查与接口文档访问控制默认配置
+	viper.SetDefault("system_endpoints.swagger_enabled", true)
+	viper.SetDefault("system_endpoints.requests_per_minute", 60)
+	viper.SetDefault("system_endpoints.basic_auth_username", "")
+	viper.SetDefault("system_endpoints.basic_auth_password", "")
+
+	// AI响应存档默认配置
+	viper.SetDefault("response_archive.enabled", false)
+	viper.SetDefault("response_archive.retention_days", 30)
+	viper.SetDefault("response_archive.purge_interval", "1h")
+
+	// 离线同步默认配置
+	viper.SetDefault("sync.training_record_policy", "client_wins")
+	viper.SetDefault("sync.nutrition_record_policy", "server_wins")
+	viper.SetDefault("sync.max_batch_size", 200)
+
+	// 邮件默认配置
+	viper.SetDefault("mail.host", "")
+	viper.SetDefault("mail.port", 587)
+	viper.SetDefault("mail.from", "noreply@ai-fitness-planner.local")
+
+	// 组织用户邀请默认配置
+	viper.SetDefault("invite.ttl", "168h")
+	viper.SetDefault("invite.accept_url", "http://localhost:3000/accept-invitation")
+
+	// 数据一致性检查默认配置
+	viper.SetDefault("integrity.check_enabled", true)
+	viper.SetDefault("integrity.check_interval", "24h")
+	viper.SetDefault("integrity.auto_repair", false)
+
+	// 会话存储默认配置
+	viper.SetDefault("session.mode", "redis")
+	viper.SetDefault("session.local_cache_ttl", "5m")
+	viper.SetDefault("session.degraded_auth", false)
+	viper.SetDefault("session.sliding_expiration", true)
+	viper.SetDefault("session.idle_timeout", "168h")
+	viper.SetDefault("session.refresh_interval", "10m")
+	viper.SetDefault("session.max_lifetime", "720h")
+
+	// AI教练对话默认配置
+	viper.SetDefault("coach.history_messages", 20)
+	viper.SetDefault("coach.history_ttl", "24h")
+
+	// 生成任务队列默认配置
+	viper.SetDefault("queue.workers", 4)
+	viper.SetDefault("queue.max_attempts", 3)
+	viper.SetDefault("queue.retry_delay", "30s")
+	viper.SetDefault("queue.max_retry_delay", "5m")
+	viper.SetDefault("queue.lease", "1m")
+	viper.SetDefault("queue.poll_interval", "1s")
+	viper.SetDefault("queue.shutdown_timeout", "20s")
+	viper.SetDefault("queue.task_retention", "24h")
+	viper.SetDefault("queue.task_sweep_interval", "10m")
+	viper.SetDefault("queue.generation_lock_ttl", "30m")
 }
 
 func GetDSN() string {
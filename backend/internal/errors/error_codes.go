@@ -15,18 +15,22 @@ const (
 	ErrConflict         = 4090 // 冲突
 
 	// 服务器错误 (5000系列)
-	ErrInternalServer  = 5000 // 内部错误
-	ErrExternalService = 5001 // 外部服务错误
-	ErrDatabase        = 5002 // 数据库错误
-	ErrCache           = 5003 // 缓存错误
+	ErrInternalServer     = 5000 // 内部错误
+	ErrExternalService    = 5001 // 外部服务错误
+	ErrDatabase           = 5002 // 数据库错误
+	ErrCache              = 5003 // 缓存错误
+	ErrServiceUnavailable = 5030 // 依赖服务不可用
+	ErrGatewayTimeout     = 5040 // 请求处理超时
 
 	// 业务错误 (6000系列)
-	ErrUserExists         = 6001 // 用户已存在
-	ErrUserNotFound       = 6002 // 用户不存在
-	ErrWrongPassword      = 6003 // 密码错误
-	ErrTokenExpired       = 6004 // Token过期
-	ErrPlanNotFound       = 6005 // 计划不存在
-	ErrAiApiNotConfigured = 6006 // AI API未配置
-	ErrApiLimitExceeded   = 6007 // API调用超限
-	ErrInvalidCredentials = 6008 // 无效的凭证
+	ErrUserExists           = 6001 // 用户已存在
+	ErrUserNotFound         = 6002 // 用户不存在
+	ErrWrongPassword        = 6003 // 密码错误
+	ErrTokenExpired         = 6004 // Token过期
+	ErrPlanNotFound         = 6005 // 计划不存在
+	ErrAiApiNotConfigured   = 6006 // AI API未配置
+	ErrApiLimitExceeded     = 6007 // API调用超限
+	ErrInvalidCredentials   = 6008 // 无效的凭证
+	ErrAIAPISuspended       = 6009 // AI API因异常使用被暂停
+	ErrAIProviderNotAllowed = 6010 // AI服务商不在部署允许范围内
 )
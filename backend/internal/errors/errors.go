@@ -6,6 +6,9 @@ type AppError struct {
 	Code    int
 	Message string
 	Err     error
+	// Data is returned to the client alongside the error, e.g. the ID of the
+	// record a conflict was detected against
+	Data interface{}
 }
 
 func (e *AppError) Error() string {
@@ -30,6 +33,13 @@ func Wrap(err error, code int, message string) *AppError {
 	}
 }
 
+// WithData returns a copy of the error carrying data for the client
+func (e *AppError) WithData(data interface{}) *AppError {
+	copied := *e
+	copied.Data = data
+	return &copied
+}
+
 // 常用错误
 var (
 	ErrUsernameExists   = New(ErrUserExists, "用户名已存在")
@@ -0,0 +1,570 @@
+package handler
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/api/request"
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler handles admin support HTTP requests
+type AdminHandler struct {
+	*BaseHandler
+	adminService service.AdminService
+}
+
+// NewAdminHandler creates a new AdminHandler instance
+func NewAdminHandler(adminService service.AdminService) *AdminHandler {
+	return &AdminHandler{
+		BaseHandler:  NewBaseHandler(),
+		adminService: adminService,
+	}
+}
+
+// Impersonate handles POST /api/v1/admin/users/:id/impersonate
+// @Summary Impersonate a user
+// @Description Issue a time-boxed access token that acts as the given user. Requests made with it are audit-logged and cannot use the user's AI API keys.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body request.ImpersonateRequest true "Impersonation reason"
+// @Success 200 {object} response.ImpersonationResponse "Impersonation token issued"
+// @Failure 403 {object} response.BaseResponse "Not an admin"
+// @Failure 404 {object} response.BaseResponse "User not found"
+// @Router /admin/users/{id}/impersonate [post]
+func (h *AdminHandler) Impersonate(c *gin.Context) {
+	adminID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	targetID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	var req request.ImpersonateRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	result, err := h.adminService.Impersonate(c.Request.Context(), adminID, targetID, req.Reason, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	userInfo := response.UserInfo{
+		ID:                    result.User.ID,
+		Username:              result.User.Username,
+		Email:                 result.User.Email,
+		WeekStart:             result.User.WeekStart,
+		AutoRollover:          result.User.AutoRollover,
+		AnalyticsOptOut:       result.User.AnalyticsOptOut,
+		ResponseArchiveOptOut: result.User.ResponseArchiveOptOut,
+		CreatedAt:             response.FormatTime(result.User.CreatedAt),
+	}
+	if result.User.Nickname != nil {
+		userInfo.Nickname = *result.User.Nickname
+	}
+	userInfo.BusyWeekdays, userInfo.BlackoutDates = busyDayInfo(result.User)
+	userInfo.RestIntervals = restIntervalInfo(result.User)
+
+	h.Success(c, response.ImpersonationResponse{
+		AccessToken: result.AccessToken,
+		ExpiresAt:   response.FormatTime(result.ExpiresAt),
+		User:        userInfo,
+	})
+}
+
+// ListImpersonationLogs handles GET /api/v1/admin/impersonation-logs
+// @Summary List impersonation audit logs
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query int false "Filter by impersonated user"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} response.ImpersonationLogListResponse "Audit log entries"
+// @Router /admin/impersonation-logs [get]
+func (h *AdminHandler) ListImpersonationLogs(c *gin.Context) {
+	var query request.ImpersonationLogQuery
+	if !h.BindQuery(c, &query) {
+		return
+	}
+
+	page, limit, offset := h.GetPagination(c)
+	logs, total, err := h.adminService.ListImpersonationLogs(c.Request.Context(), query.UserID, limit, offset)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	infos := make([]response.ImpersonationLogInfo, 0, len(logs))
+	for _, l := range logs {
+		info := response.ImpersonationLogInfo{
+			ID:         l.ID,
+			AdminID:    l.AdminID,
+			UserID:     l.UserID,
+			SessionID:  l.SessionID,
+			Method:     l.Method,
+			Path:       l.Path,
+			StatusCode: l.StatusCode,
+			IPAddress:  l.IPAddress,
+			CreatedAt:  response.FormatTime(l.CreatedAt),
+		}
+		if l.Reason != nil {
+			info.Reason = *l.Reason
+		}
+		infos = append(infos, info)
+	}
+
+	h.Success(c, response.ImpersonationLogListResponse{
+		Logs:       infos,
+		Pagination: h.BuildPaginationInfo(page, limit, total),
+	})
+}
+
+// IssueServiceToken handles POST /api/v1/admin/service-tokens
+// @Summary Issue a service token
+// @Description Issue a token for a trusted internal service (scheduler, bot, admin tool) to act for a user. Requests made with it skip session checks and rate limits, but not AI generation quotas, and every one is recorded in the service audit log.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request.IssueServiceTokenRequest true "Service and user"
+// @Success 200 {object} response.ServiceTokenResponse "Service token issued"
+// @Failure 403 {object} response.BaseResponse "Not an admin"
+// @Failure 404 {object} response.BaseResponse "User not found"
+// @Failure 503 {object} response.BaseResponse "Service tokens are not configured"
+// @Router /admin/service-tokens [post]
+func (h *AdminHandler) IssueServiceToken(c *gin.Context) {
+	adminID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.IssueServiceTokenRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	ttl := time.Duration(req.TTLHours) * time.Hour
+	result, err := h.adminService.IssueServiceToken(c.Request.Context(), adminID, req.Service, req.UserID, ttl, c.ClientIP())
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, response.ServiceTokenResponse{
+		Token:     result.Token,
+		TokenID:   result.TokenID,
+		Service:   result.Service,
+		UserID:    result.UserID,
+		ExpiresAt: response.FormatTime(result.ExpiresAt),
+	})
+}
+
+// RevokeServiceToken handles DELETE /api/v1/admin/service-tokens/:id
+// @Summary Revoke a service token
+// @Description Makes a service token invalid before it expires; requests made with it are refused from then on. The revocation is recorded in the service audit log.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Token ID"
+// @Success 200 {object} response.ServiceTokenRevocationResponse "Service token revoked"
+// @Failure 403 {object} response.BaseResponse "Not an admin"
+// @Failure 404 {object} response.BaseResponse "Token not found"
+// @Router /admin/service-tokens/{id} [delete]
+func (h *AdminHandler) RevokeServiceToken(c *gin.Context) {
+	adminID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	revocation, err := h.adminService.RevokeServiceToken(c.Request.Context(), adminID, c.Param("id"), c.ClientIP())
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, response.ServiceTokenRevocationResponse{
+		TokenID:   revocation.TokenID,
+		Service:   revocation.Service,
+		UserID:    revocation.UserID,
+		RevokedBy: revocation.RevokedBy,
+		RevokedAt: response.FormatTime(revocation.RevokedAt),
+	})
+}
+
+// ListServiceAuditLogs handles GET /api/v1/admin/service-audit-logs
+// @Summary List service token audit logs
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param service query string false "Filter by service"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} response.ServiceAuditLogListResponse "Audit log entries"
+// @Router /admin/service-audit-logs [get]
+func (h *AdminHandler) ListServiceAuditLogs(c *gin.Context) {
+	var query request.ServiceAuditLogQuery
+	if !h.BindQuery(c, &query) {
+		return
+	}
+
+	page, limit, offset := h.GetPagination(c)
+	logs, total, err := h.adminService.ListServiceAuditLogs(c.Request.Context(), query.Service, limit, offset)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	infos := make([]response.ServiceAuditLogInfo, 0, len(logs))
+	for _, l := range logs {
+		infos = append(infos, response.ServiceAuditLogInfo{
+			ID:         l.ID,
+			Service:    l.Service,
+			TokenID:    l.TokenID,
+			UserID:     l.UserID,
+			AdminID:    l.AdminID,
+			Method:     l.Method,
+			Path:       l.Path,
+			StatusCode: l.StatusCode,
+			IPAddress:  l.IPAddress,
+			CreatedAt:  response.FormatTime(l.CreatedAt),
+		})
+	}
+
+	h.Success(c, response.ServiceAuditLogListResponse{
+		Logs:       infos,
+		Pagination: h.BuildPaginationInfo(page, limit, total),
+	})
+}
+
+// ListGenerationTasks handles GET /api/v1/admin/generation-tasks
+// @Summary List finished generation tasks
+// @Description Task history of plan generations and adjustments, for investigating failures
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query int false "Filter by user"
+// @Param status query string false "completed or failed"
+// @Param task_type query string false "training:generate, training:adjust, nutrition:generate or nutrition:adjust"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} response.GenerationTaskListResponse "Generation tasks"
+// @Router /admin/generation-tasks [get]
+func (h *AdminHandler) ListGenerationTasks(c *gin.Context) {
+	var query request.GenerationTaskQuery
+	if !h.BindQuery(c, &query) {
+		return
+	}
+
+	filter := repository.GenerationTaskFilter{
+		UserID: query.UserID,
+		Status: query.Status,
+	}
+	if query.TaskType != "" {
+		filter.TaskTypes = []string{query.TaskType}
+	}
+
+	page, limit, offset := h.GetPagination(c)
+	tasks, total, err := h.adminService.ListGenerationTasks(c.Request.Context(), filter, limit, offset)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, response.GenerationTaskListResponse{
+		Tasks:      toGenerationTaskInfos(tasks),
+		Pagination: h.BuildPaginationInfo(page, limit, total),
+	})
+}
+
+// ListAbuseFlags handles GET /api/v1/admin/abuse-flags
+// @Summary List AI abuse flags
+// @Description Review queue of AI API configs suspended by abuse detection
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, dismissed or confirmed"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} response.AbuseFlagListResponse "Abuse flags"
+// @Router /admin/abuse-flags [get]
+func (h *AdminHandler) ListAbuseFlags(c *gin.Context) {
+	var query request.AbuseFlagQuery
+	if !h.BindQuery(c, &query) {
+		return
+	}
+
+	page, limit, offset := h.GetPagination(c)
+	flags, total, err := h.adminService.ListAbuseFlags(c.Request.Context(), query.Status, limit, offset)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	infos := make([]response.AbuseFlagInfo, 0, len(flags))
+	for _, f := range flags {
+		infos = append(infos, toAbuseFlagInfo(f))
+	}
+
+	h.Success(c, response.AbuseFlagListResponse{
+		Flags:      infos,
+		Pagination: h.BuildPaginationInfo(page, limit, total),
+	})
+}
+
+// ReviewAbuseFlag handles POST /api/v1/admin/abuse-flags/:id/review
+// @Summary Review an AI abuse flag
+// @Description Dismiss a flag to lift the suspension, or confirm it to disable the AI API config
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Flag ID"
+// @Param request body request.ReviewAbuseFlagRequest true "Review decision"
+// @Success 200 {object} response.AbuseFlagInfo "Reviewed flag"
+// @Failure 404 {object} response.BaseResponse "Flag not found"
+// @Failure 409 {object} response.BaseResponse "Flag already reviewed"
+// @Router /admin/abuse-flags/{id}/review [post]
+func (h *AdminHandler) ReviewAbuseFlag(c *gin.Context) {
+	adminID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	flagID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的记录ID")
+		return
+	}
+
+	var req request.ReviewAbuseFlagRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	flag, err := h.adminService.ReviewAbuseFlag(c.Request.Context(), adminID, flagID, req.Action, req.Note)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, toAbuseFlagInfo(flag))
+}
+
+// GetParseFailureStats handles GET /api/v1/admin/ai/parse-failures
+// @Summary Plan parse failure statistics
+// @Description Counts of AI plan responses that failed to parse, by provider, model, generation purpose and cause (non_json, truncated_json, malformed_json, wrong_schema, empty_plan, constraint_violation)
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.ParseFailureStatsResponse "Parse failure counters"
+// @Router /admin/ai/parse-failures [get]
+func (h *AdminHandler) GetParseFailureStats(c *gin.Context) {
+	counts, err := h.adminService.ParseFailureStats(c.Request.Context())
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	resp := response.ParseFailureStatsResponse{
+		ByKind:   make(map[string]int64),
+		Counters: make([]response.ParseFailureInfo, 0, len(counts)),
+	}
+	for _, count := range counts {
+		resp.Total += count.Count
+		resp.ByKind[string(count.Kind)] += count.Count
+		resp.Counters = append(resp.Counters, response.ParseFailureInfo{
+			Provider: count.Provider,
+			Model:    count.Model,
+			Purpose:  count.Purpose,
+			Kind:     string(count.Kind),
+			Count:    count.Count,
+		})
+	}
+
+	h.Success(c, resp)
+}
+
+// MergeAccounts handles POST /api/v1/admin/account-merges
+// @Summary Merge duplicate accounts
+// @Description Moves the plans, records, body data and goals of the source account to the target account and disables the source. Conflicts are resolved by rule: records whose client ID or idempotency key the target already uses get a new ID or lose the key, and the source's current plans and active goals are deactivated when the target has its own. Everything changed is kept in the audit record so the merge can be reverted. With dry_run nothing is changed.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request.MergeAccountsRequest true "Accounts to merge"
+// @Success 200 {object} response.AccountMergeInfo "Dry run result"
+// @Success 201 {object} response.AccountMergeInfo "Merge audit record"
+// @Failure 403 {object} response.BaseResponse "Not an admin, or an admin account"
+// @Failure 404 {object} response.BaseResponse "User not found"
+// @Router /admin/account-merges [post]
+func (h *AdminHandler) MergeAccounts(c *gin.Context) {
+	adminID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.MergeAccountsRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	merge, err := h.adminService.MergeAccounts(c.Request.Context(), adminID, &service.MergeAccountsRequest{
+		SourceUserID: req.SourceUserID,
+		TargetUserID: req.TargetUserID,
+		Reason:       req.Reason,
+		DryRun:       req.DryRun,
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	info := toAccountMergeInfo(merge)
+	if req.DryRun {
+		info.DryRun = true
+		h.Success(c, info)
+		return
+	}
+	h.Created(c, info)
+}
+
+// ListAccountMerges handles GET /api/v1/admin/account-merges
+// @Summary List account merges
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query int false "Filter by source or target user"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} response.AccountMergeListResponse "Account merges"
+// @Router /admin/account-merges [get]
+func (h *AdminHandler) ListAccountMerges(c *gin.Context) {
+	var query request.AccountMergeQuery
+	if !h.BindQuery(c, &query) {
+		return
+	}
+
+	page, limit, offset := h.GetPagination(c)
+	merges, total, err := h.adminService.ListAccountMerges(c.Request.Context(), query.UserID, limit, offset)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	infos := make([]response.AccountMergeInfo, 0, len(merges))
+	for _, m := range merges {
+		infos = append(infos, toAccountMergeInfo(m))
+	}
+
+	h.Success(c, response.AccountMergeListResponse{
+		Merges:     infos,
+		Pagination: h.BuildPaginationInfo(page, limit, total),
+	})
+}
+
+// RevertAccountMerge handles POST /api/v1/admin/account-merges/:id/revert
+// @Summary Revert an account merge
+// @Description Moves the merged rows still with the target back to the source account, restores the values changed by conflict rules and re-enables the source account
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Merge ID"
+// @Success 200 {object} response.AccountMergeInfo "Reverted merge"
+// @Failure 404 {object} response.BaseResponse "Merge not found"
+// @Failure 409 {object} response.BaseResponse "Merge already reverted"
+// @Router /admin/account-merges/{id}/revert [post]
+func (h *AdminHandler) RevertAccountMerge(c *gin.Context) {
+	adminID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	mergeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的合并记录ID")
+		return
+	}
+
+	merge, err := h.adminService.RevertAccountMerge(c.Request.Context(), adminID, mergeID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, toAccountMergeInfo(merge))
+}
+
+// toAccountMergeInfo converts an account merge model to its response DTO
+func toAccountMergeInfo(m *model.AccountMerge) response.AccountMergeInfo {
+	info := response.AccountMergeInfo{
+		ID:           m.ID,
+		AdminID:      m.AdminID,
+		SourceUserID: m.SourceUserID,
+		TargetUserID: m.TargetUserID,
+		Reason:       m.Reason,
+		Status:       m.Status,
+		Moved:        make(map[string]int, len(m.Changes.Moved)),
+		Conflicts:    make([]response.AccountMergeConflictInfo, 0, len(m.Changes.Conflicts)),
+		CreatedAt:    response.FormatTime(m.CreatedAt),
+	}
+	for table, ids := range m.Changes.Moved {
+		info.Moved[table] = len(ids)
+	}
+	for _, c := range m.Changes.Conflicts {
+		info.Conflicts = append(info.Conflicts, response.AccountMergeConflictInfo{
+			Table:  c.Table,
+			ID:     c.ID,
+			Column: c.Column,
+			Old:    c.Old,
+			New:    c.New,
+			Rule:   c.Rule,
+		})
+	}
+	if m.RevertedBy != nil {
+		info.RevertedBy = *m.RevertedBy
+	}
+	if m.RevertedAt != nil {
+		info.RevertedAt = response.FormatTime(*m.RevertedAt)
+	}
+	return info
+}
+
+// toAbuseFlagInfo converts an abuse flag model to its response DTO
+func toAbuseFlagInfo(f *model.AIAbuseFlag) response.AbuseFlagInfo {
+	info := response.AbuseFlagInfo{
+		ID:        f.ID,
+		UserID:    f.UserID,
+		AIAPIID:   f.AIAPIID,
+		Reason:    f.Reason,
+		Details:   f.Details,
+		Status:    f.Status,
+		CreatedAt: response.FormatTime(f.CreatedAt),
+	}
+	if f.SuspendedUntil != nil {
+		info.SuspendedUntil = response.FormatTime(*f.SuspendedUntil)
+	}
+	if f.ReviewedBy != nil {
+		info.ReviewedBy = *f.ReviewedBy
+	}
+	if f.ReviewNote != nil {
+		info.ReviewNote = *f.ReviewNote
+	}
+	if f.ReviewedAt != nil {
+		info.ReviewedAt = response.FormatTime(*f.ReviewedAt)
+	}
+	return info
+}
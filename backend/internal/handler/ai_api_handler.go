@@ -5,6 +5,7 @@ import (
 
 	"github.com/ai-fitness-planner/backend/internal/api/request"
 	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/repository"
 	"github.com/ai-fitness-planner/backend/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -178,3 +179,130 @@ func (h *AIAPIHandler) SetDefault(c *gin.Context) {
 
 	h.Success(c, gin.H{"message": "已设置为默认API"})
 }
+
+// SetFallbackOrder handles PUT /api/v1/ai-apis/fallback-order
+// @Summary Set AI API fallback chain
+// @Description When the API chosen for training plan generation still fails after retries, the listed APIs are tried in this order. An empty list disables fallback.
+// @Tags AI APIs
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request.SetFallbackOrderRequest true "API IDs in fallback order"
+// @Success 200 {object} response.AIAPIListResponse "AI APIs with their fallback priority"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 404 {object} response.BaseResponse "AI API not found"
+// @Router /ai-apis/fallback-order [put]
+func (h *AIAPIHandler) SetFallbackOrder(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.SetFallbackOrderRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	listResp, err := h.aiAPIService.SetFallbackOrder(c.Request.Context(), userID, req.APIIDs)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, listResp)
+}
+
+// GetUsage handles GET /api/v1/ai-apis/:id/usage
+// @Summary Get AI API token usage
+// @Description Aggregates the prompt and completion tokens recorded for plan generation with this API over the last days, per model, with a cost estimate from the configured model prices.
+// @Tags AI APIs
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "AI API ID"
+// @Param days query int false "Number of days to include (1-365, default 30)"
+// @Success 200 {object} response.AIUsageResponse "Token usage and estimated cost"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 404 {object} response.BaseResponse "AI API not found"
+// @Router /ai-apis/{id}/usage [get]
+func (h *AIAPIHandler) GetUsage(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	apiID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的API ID")
+		return
+	}
+
+	var query request.AIUsageQuery
+	if !h.BindQuery(c, &query) {
+		return
+	}
+
+	usage, err := h.aiAPIService.GetUsage(c.Request.Context(), userID, apiID, query.Days)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, usage)
+}
+
+// ListCallLogs handles GET /api/v1/ai-apis/call-logs
+// @Summary List AI call history
+// @Description Lists the authenticated user's calls to AI providers, newest first, with status, error, latency and token usage, to help explain failed plan generations
+// @Tags AI APIs
+// @Produce json
+// @Security BearerAuth
+// @Param api_id query int false "Only calls made with this AI API"
+// @Param status query string false "success or error"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} response.AICallLogListResponse "AI call history"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Router /ai-apis/call-logs [get]
+func (h *AIAPIHandler) ListCallLogs(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var query request.AICallLogQuery
+	if !h.BindQuery(c, &query) {
+		return
+	}
+
+	page, limit, offset := h.GetPagination(c)
+	filter := repository.AICallLogFilter{AIAPIID: query.APIID, Status: query.Status}
+	logs, total, err := h.aiAPIService.ListCallLogs(c.Request.Context(), userID, filter, limit, offset)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	calls := make([]response.AICallLogInfo, 0, len(logs))
+	for _, l := range logs {
+		calls = append(calls, response.AICallLogInfo{
+			ID:               l.ID,
+			APIID:            l.AIAPIID,
+			Provider:         l.Provider,
+			Model:            l.Model,
+			Purpose:          l.Purpose,
+			Status:           l.Status,
+			Error:            l.Error,
+			LatencyMs:        l.LatencyMs,
+			PromptTokens:     l.PromptTokens,
+			CompletionTokens: l.CompletionTokens,
+			TotalTokens:      l.TotalTokens,
+			PromptHash:       l.PromptHash,
+			CreatedAt:        response.FormatTime(l.CreatedAt),
+		})
+	}
+
+	h.Success(c, response.AICallLogListResponse{
+		Calls:      calls,
+		Pagination: h.BuildPaginationInfo(page, limit, total),
+	})
+}
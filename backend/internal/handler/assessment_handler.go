@@ -115,8 +115,8 @@ func (h *AssessmentHandler) buildAssessmentInfo(assessment *model.FitnessAssessm
 		ExperienceLevel:       assessment.ExperienceLevel,
 		WeeklyAvailableDays:   assessment.WeeklyAvailableDays,
 		DailyAvailableMinutes: assessment.DailyAvailableMinutes,
-		AssessmentDate:        assessment.AssessmentDate.Format("2006-01-02"),
-		CreatedAt:             assessment.CreatedAt.Format(time.RFC3339),
+		AssessmentDate:        response.FormatDate(assessment.AssessmentDate),
+		CreatedAt:             response.FormatTime(assessment.CreatedAt),
 	}
 
 	if assessment.ActivityType != nil {
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/ai-fitness-planner/backend/internal/api/request"
 	"github.com/ai-fitness-planner/backend/internal/api/response"
@@ -60,10 +59,14 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	// Build response
 	resp := response.AuthResponse{
 		User: response.UserInfo{
-			ID:        authResp.User.ID,
-			Username:  authResp.User.Username,
-			Email:     authResp.User.Email,
-			CreatedAt: authResp.User.CreatedAt.Format(time.RFC3339),
+			ID:                    authResp.User.ID,
+			Username:              authResp.User.Username,
+			Email:                 authResp.User.Email,
+			WeekStart:             authResp.User.WeekStart,
+			AutoRollover:          authResp.User.AutoRollover,
+			AnalyticsOptOut:       authResp.User.AnalyticsOptOut,
+			ResponseArchiveOptOut: authResp.User.ResponseArchiveOptOut,
+			CreatedAt:             response.FormatTime(authResp.User.CreatedAt),
 		},
 		AccessToken:  authResp.AccessToken,
 		RefreshToken: authResp.RefreshToken,
@@ -79,6 +82,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	if authResp.User.Avatar != nil {
 		resp.User.Avatar = *authResp.User.Avatar
 	}
+	resp.User.BusyWeekdays, resp.User.BlackoutDates = busyDayInfo(authResp.User)
+	resp.User.RestIntervals = restIntervalInfo(authResp.User)
 
 	h.Created(c, resp)
 }
@@ -121,10 +126,14 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	// Build response
 	resp := response.AuthResponse{
 		User: response.UserInfo{
-			ID:        authResp.User.ID,
-			Username:  authResp.User.Username,
-			Email:     authResp.User.Email,
-			CreatedAt: authResp.User.CreatedAt.Format(time.RFC3339),
+			ID:                    authResp.User.ID,
+			Username:              authResp.User.Username,
+			Email:                 authResp.User.Email,
+			WeekStart:             authResp.User.WeekStart,
+			AutoRollover:          authResp.User.AutoRollover,
+			AnalyticsOptOut:       authResp.User.AnalyticsOptOut,
+			ResponseArchiveOptOut: authResp.User.ResponseArchiveOptOut,
+			CreatedAt:             response.FormatTime(authResp.User.CreatedAt),
 		},
 		AccessToken:  authResp.AccessToken,
 		RefreshToken: authResp.RefreshToken,
@@ -140,6 +149,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	if authResp.User.Avatar != nil {
 		resp.User.Avatar = *authResp.User.Avatar
 	}
+	resp.User.BusyWeekdays, resp.User.BlackoutDates = busyDayInfo(authResp.User)
+	resp.User.RestIntervals = restIntervalInfo(authResp.User)
 
 	h.Success(c, resp)
 }
@@ -8,6 +8,7 @@ import (
 	apperrors "github.com/ai-fitness-planner/backend/internal/errors"
 	"github.com/ai-fitness-planner/backend/internal/middleware"
 	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/pkg/warning"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -22,7 +23,9 @@ func NewBaseHandler() *BaseHandler {
 
 // Success sends a successful response with data
 func (h *BaseHandler) Success(c *gin.Context, data interface{}) {
-	c.JSON(http.StatusOK, response.Success(data))
+	resp := response.Success(data)
+	resp.Warnings = h.Warnings(c)
+	c.JSON(http.StatusOK, resp)
 }
 
 // SuccessWithMessage sends a successful response with a custom message
@@ -31,13 +34,35 @@ func (h *BaseHandler) SuccessWithMessage(c *gin.Context, message string, data in
 		Code:      200,
 		Message:   message,
 		Data:      data,
+		Warnings:  h.Warnings(c),
 		Timestamp: time.Now().Unix(),
 	})
 }
 
 // Created sends a 201 Created response
 func (h *BaseHandler) Created(c *gin.Context, data interface{}) {
-	c.JSON(http.StatusCreated, response.Success(data))
+	resp := response.Success(data)
+	resp.Warnings = h.Warnings(c)
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Warn adds a non-fatal warning to the response of the current request.
+// Services add theirs with warning.Add on the request context.
+func (h *BaseHandler) Warn(c *gin.Context, code, message string) {
+	warning.Add(c.Request.Context(), code, message)
+}
+
+// Warnings returns the warnings added while handling the current request
+func (h *BaseHandler) Warnings(c *gin.Context) []response.Warning {
+	warnings := warning.List(c.Request.Context())
+	if len(warnings) == 0 {
+		return nil
+	}
+	infos := make([]response.Warning, 0, len(warnings))
+	for _, w := range warnings {
+		infos = append(infos, response.Warning{Code: w.Code, Message: w.Message})
+	}
+	return infos
 }
 
 // NoContent sends a 204 No Content response
@@ -47,6 +72,13 @@ func (h *BaseHandler) NoContent(c *gin.Context) {
 
 // Error handles error responses based on error type
 func (h *BaseHandler) Error(c *gin.Context, err error) {
+	// Whatever a service made of its cancelled queries and calls, the
+	// request ran out of time
+	if middleware.TimedOut(c) {
+		middleware.AbortWithTimeout(c)
+		return
+	}
+
 	appErr, ok := err.(*apperrors.AppError)
 	if !ok {
 		// Unknown error - log and return generic error
@@ -57,6 +89,10 @@ func (h *BaseHandler) Error(c *gin.Context, err error) {
 
 	// Map error code to HTTP status
 	httpStatus := h.mapErrorCodeToHTTPStatus(appErr.Code)
+	if appErr.Data != nil {
+		c.JSON(httpStatus, response.ErrorWithData(appErr.Code, appErr.Message, appErr.Data))
+		return
+	}
 	c.JSON(httpStatus, response.Error(appErr.Code, appErr.Message))
 }
 
@@ -100,6 +136,10 @@ func (h *BaseHandler) mapErrorCodeToHTTPStatus(code int) int {
 		return http.StatusMethodNotAllowed
 	case code >= 4090 && code < 5000:
 		return http.StatusConflict
+	case code == apperrors.ErrServiceUnavailable:
+		return http.StatusServiceUnavailable
+	case code == apperrors.ErrGatewayTimeout:
+		return http.StatusGatewayTimeout
 	case code >= 5000 && code < 6000:
 		return http.StatusInternalServerError
 	case code >= 6000:
@@ -117,6 +157,8 @@ func (h *BaseHandler) mapErrorCodeToHTTPStatus(code int) int {
 			return http.StatusBadRequest
 		case apperrors.ErrApiLimitExceeded:
 			return http.StatusTooManyRequests
+		case apperrors.ErrAIAPISuspended, apperrors.ErrAIProviderNotAllowed:
+			return http.StatusForbidden
 		default:
 			return http.StatusBadRequest
 		}
@@ -140,6 +182,18 @@ func (h *BaseHandler) GetSessionID(c *gin.Context) (string, bool) {
 	return middleware.GetSessionID(c)
 }
 
+// RefundAIQuota gives back the AI quota charged for a request that did not
+// start a generation, such as one answered with the user's pending task
+func (h *BaseHandler) RefundAIQuota(c *gin.Context) {
+	middleware.RefundAIQuota(c)
+}
+
+// GetUsername extracts the username from context; service tokens have none
+func (h *BaseHandler) GetUsername(c *gin.Context) string {
+	username, _ := middleware.GetUsername(c)
+	return username
+}
+
 // PaginationParams represents pagination query parameters
 type PaginationParams struct {
 	Page  int `form:"page" binding:"omitempty,min=1"`
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/api/request"
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// CheckInHandler handles weekly check-in HTTP requests
+type CheckInHandler struct {
+	*BaseHandler
+	checkInService service.CheckInService
+}
+
+// NewCheckInHandler creates a new CheckInHandler instance
+func NewCheckInHandler(checkInService service.CheckInService) *CheckInHandler {
+	return &CheckInHandler{
+		BaseHandler:    NewBaseHandler(),
+		checkInService: checkInService,
+	}
+}
+
+// SubmitCheckIn handles POST /api/v1/check-ins
+// @Summary Submit weekly check-in
+// @Description Record weight, adherence, energy, hunger, notes and photos for a week. Submitting again for the same week replaces the earlier check-in.
+// @Tags CheckIn
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request.CheckInRequest true "Check-in data"
+// @Success 200 {object} response.CheckInInfo "Check-in saved"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 401 {object} response.BaseResponse "Unauthorized"
+// @Router /check-ins [post]
+func (h *CheckInHandler) SubmitCheckIn(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.CheckInRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	serviceReq := &service.CheckInRequest{
+		Weight:          req.Weight,
+		AdherenceRating: req.AdherenceRating,
+		EnergyLevel:     req.EnergyLevel,
+		HungerLevel:     req.HungerLevel,
+		Notes:           req.Notes,
+		Photos:          req.Photos,
+	}
+	if req.WeekOf != nil {
+		weekOf, err := time.ParseInLocation("2006-01-02", *req.WeekOf, time.Local)
+		if err != nil {
+			h.BadRequest(c, "无效的日期格式")
+			return
+		}
+		serviceReq.WeekOf = &weekOf
+	}
+
+	checkIn, err := h.checkInService.SubmitCheckIn(c.Request.Context(), userID, serviceReq)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, toCheckInInfo(checkIn))
+}
+
+// ListCheckIns handles GET /api/v1/check-ins
+// @Summary List weekly check-ins
+// @Tags CheckIn
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} response.CheckInListResponse "Check-ins"
+// @Failure 401 {object} response.BaseResponse "Unauthorized"
+// @Router /check-ins [get]
+func (h *CheckInHandler) ListCheckIns(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	page, limit, offset := h.GetPagination(c)
+	checkIns, total, err := h.checkInService.ListCheckIns(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	infos := make([]response.CheckInInfo, 0, len(checkIns))
+	for _, ci := range checkIns {
+		infos = append(infos, toCheckInInfo(ci))
+	}
+
+	h.Success(c, response.CheckInListResponse{
+		CheckIns:   infos,
+		Pagination: h.BuildPaginationInfo(page, limit, total),
+	})
+}
+
+// GetLatestCheckIn handles GET /api/v1/check-ins/latest
+// @Summary Get latest weekly check-in
+// @Tags CheckIn
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.CheckInInfo "Latest check-in"
+// @Failure 404 {object} response.BaseResponse "No check-in yet"
+// @Router /check-ins/latest [get]
+func (h *CheckInHandler) GetLatestCheckIn(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	checkIn, err := h.checkInService.GetLatestCheckIn(c.Request.Context(), userID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, toCheckInInfo(checkIn))
+}
+
+// toCheckInInfo converts a check-in model to its response DTO
+func toCheckInInfo(ci *model.WeeklyCheckIn) response.CheckInInfo {
+	info := response.CheckInInfo{
+		ID:              ci.ID,
+		WeekStart:       response.FormatDate(ci.WeekStart),
+		AdherenceRating: ci.AdherenceRating,
+		EnergyLevel:     ci.EnergyLevel,
+		HungerLevel:     ci.HungerLevel,
+		Photos:          make([]string, 0, len(ci.Photos)),
+		CreatedAt:       response.FormatTime(ci.CreatedAt),
+		UpdatedAt:       response.FormatTime(ci.UpdatedAt),
+	}
+	if ci.Weight != nil {
+		info.Weight = *ci.Weight
+	}
+	if ci.Notes != nil {
+		info.Notes = *ci.Notes
+	}
+	for _, p := range ci.Photos {
+		if url, ok := p.(string); ok {
+			info.Photos = append(info.Photos, url)
+		}
+	}
+	return info
+}
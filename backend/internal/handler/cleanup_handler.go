@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/api/request"
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/pkg/jobqueue"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// CleanupHandler handles bulk data deletion HTTP requests
+type CleanupHandler struct {
+	*BaseHandler
+	cleanupService service.CleanupService
+}
+
+// NewCleanupHandler creates a new CleanupHandler instance
+func NewCleanupHandler(cleanupService service.CleanupService) *CleanupHandler {
+	return &CleanupHandler{
+		BaseHandler:    NewBaseHandler(),
+		cleanupService: cleanupService,
+	}
+}
+
+// Cleanup handles POST /api/v1/cleanup/:kind
+// @Summary Bulk delete user data
+// @Description Deletes all nutrition records in a date range (nutrition-records), all inactive and completed plans (archived-plans), or the AI call logs, generation tasks and coach conversation created in a date range (ai-history), together with cached AI responses. Without confirmation_token the deletion is previewed and a token is returned; repeating the request with the token queues it and answers 202 with a task ID to poll.
+// @Tags Cleanup
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param kind path string true "nutrition-records, archived-plans or ai-history"
+// @Param request body request.CleanupRequest true "Date range and confirmation token"
+// @Success 200 {object} response.BaseResponse{data=response.CleanupPreviewResponse}
+// @Success 202 {object} response.BaseResponse{data=response.TaskResponse}
+// @Failure 400 {object} response.BaseResponse
+// @Router /cleanup/{kind} [post]
+func (h *CleanupHandler) Cleanup(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.CleanupRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+	if !h.ValidateDateRange(c, req.StartDate, req.EndDate) {
+		return
+	}
+
+	serviceReq := &service.CleanupRequest{
+		Kind:              c.Param("kind"),
+		ConfirmationToken: req.ConfirmationToken,
+	}
+	if req.StartDate != "" {
+		t, _ := time.ParseInLocation("2006-01-02", req.StartDate, time.Local)
+		serviceReq.StartDate = &t
+	}
+	if req.EndDate != "" {
+		t, _ := time.ParseInLocation("2006-01-02", req.EndDate, time.Local)
+		serviceReq.EndDate = &t
+	}
+
+	outcome, err := h.cleanupService.Cleanup(c.Request.Context(), userID, serviceReq)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	if outcome.Preview != nil {
+		h.Success(c, response.CleanupPreviewResponse{
+			Kind:              outcome.Preview.Kind,
+			Count:             outcome.Preview.Count,
+			ConfirmationToken: outcome.Preview.ConfirmationToken,
+			ExpiresAt:         response.FormatTime(outcome.Preview.ExpiresAt),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, response.Success(response.TaskResponse{
+		TaskID: outcome.TaskID,
+		Status: jobqueue.StatusPending,
+	}))
+}
+
+// GetCleanupTask handles GET /api/v1/cleanup/tasks/:taskId
+// @Summary Get bulk deletion progress
+// @Description Progress is the share of items deleted; the result holds the deleted and total counts
+// @Tags Cleanup
+// @Produce json
+// @Security BearerAuth
+// @Param taskId path string true "Task ID"
+// @Success 200 {object} response.BaseResponse{data=response.TaskResponse}
+// @Failure 404 {object} response.BaseResponse
+// @Router /cleanup/tasks/{taskId} [get]
+func (h *CleanupHandler) GetCleanupTask(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.cleanupService.GetCleanupTask(c.Request.Context(), userID, c.Param("taskId"))
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	resp := response.TaskResponse{
+		TaskID:       job.ID,
+		Status:       job.Status,
+		ErrorMessage: job.Error,
+		Result:       gin.H{"deleted": job.Done, "total": job.Total},
+	}
+	if job.Total > 0 {
+		resp.Progress = int(job.Done * 100 / job.Total)
+	}
+	if job.Status == jobqueue.StatusCompleted {
+		resp.Progress = 100
+	}
+
+	h.Success(c, resp)
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"github.com/ai-fitness-planner/backend/internal/api/request"
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultCoachHistoryLimit is the number of messages returned when the
+// history request sets no limit
+const defaultCoachHistoryLimit = 50
+
+// CoachHandler handles AI coach chat HTTP requests
+type CoachHandler struct {
+	*BaseHandler
+	coachService service.CoachService
+}
+
+// NewCoachHandler creates a new CoachHandler instance
+func NewCoachHandler(coachService service.CoachService) *CoachHandler {
+	return &CoachHandler{
+		BaseHandler:  NewBaseHandler(),
+		coachService: coachService,
+	}
+}
+
+// Chat handles POST /api/v1/coach/chat
+// @Summary Ask the AI coach
+// @Description Answer a question such as "can I swap tomorrow's squats?" using the user's current training and nutrition plans, latest body data, goals, medical constraints and recent conversation. Uses the given AI API or the user's default. Both the question and the reply are saved to the conversation history.
+// @Tags Coach
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request.CoachChatRequest true "Question"
+// @Success 200 {object} response.CoachChatResponse "Coach reply"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 401 {object} response.BaseResponse "Unauthorized"
+// @Failure 404 {object} response.BaseResponse "AI API not found"
+// @Failure 429 {object} response.BaseResponse "Rate limit exceeded"
+// @Failure 500 {object} response.BaseResponse "AI service error"
+// @Router /coach/chat [post]
+func (h *CoachHandler) Chat(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.CoachChatRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	result, err := h.coachService.Chat(c.Request.Context(), userID, &service.CoachChatRequest{
+		Message: req.Message,
+		AIAPIID: req.AIAPIID,
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, response.CoachChatResponse{
+		Question: toCoachMessageInfo(result.Question),
+		Reply:    toCoachMessageInfo(result.Reply),
+	})
+}
+
+// GetHistory handles GET /api/v1/coach/history
+// @Summary Get AI coach conversation
+// @Description Return the most recent messages, oldest first
+// @Tags Coach
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of messages (default 50, max 200)"
+// @Success 200 {object} response.CoachHistoryResponse "Conversation"
+// @Failure 401 {object} response.BaseResponse "Unauthorized"
+// @Router /coach/history [get]
+func (h *CoachHandler) GetHistory(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.CoachHistoryRequest
+	if !h.BindQuery(c, &req) {
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultCoachHistoryLimit
+	}
+
+	messages, err := h.coachService.History(c.Request.Context(), userID, req.Limit)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	infos := make([]response.CoachMessageInfo, 0, len(messages))
+	for _, m := range messages {
+		infos = append(infos, toCoachMessageInfo(m))
+	}
+	h.Success(c, response.CoachHistoryResponse{Messages: infos})
+}
+
+// ClearHistory handles DELETE /api/v1/coach/history
+// @Summary Clear AI coach conversation
+// @Tags Coach
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.BaseResponse "Conversation cleared"
+// @Failure 401 {object} response.BaseResponse "Unauthorized"
+// @Router /coach/history [delete]
+func (h *CoachHandler) ClearHistory(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.coachService.ClearHistory(c.Request.Context(), userID); err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.SuccessWithMessage(c, "对话记录已清空", nil)
+}
+
+// toCoachMessageInfo converts a coach message to its response DTO
+func toCoachMessageInfo(m *model.CoachMessage) response.CoachMessageInfo {
+	return response.CoachMessageInfo{
+		ID:        m.ID,
+		Role:      m.Role,
+		Content:   m.Content,
+		AIAPIID:   m.AIAPIID,
+		CreatedAt: response.FormatTime(m.CreatedAt),
+	}
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/service"
+)
+
+// envelopeUser is the account behind the auth and profile fixtures
+func envelopeUser() *model.User {
+	return &model.User{
+		ID:              envelopeUserID,
+		Username:        "lifter",
+		Nickname:        ptr("举铁人"),
+		Email:           "lifter@example.com",
+		Status:          1,
+		Role:            "user",
+		WeekStart:       model.WeekStartMonday,
+		AutoRollover:    true,
+		BusyWeekdays:    model.JSONSlice{float64(3)},
+		BlackoutDates:   model.JSONSlice{"2024-03-15"},
+		RestPreferences: model.JSONMap{"strength": float64(150)},
+		CreatedAt:       time.Date(2024, 1, 2, 9, 0, 0, 0, envelopeZone),
+		UpdatedAt:       time.Date(2024, 3, 1, 9, 0, 0, 0, envelopeZone),
+	}
+}
+
+// envelopeAuthService signs in only with the password "correct"
+type envelopeAuthService struct {
+	service.AuthService
+}
+
+func (envelopeAuthService) Login(ctx context.Context, req *service.LoginRequest, ipAddress, userAgent string) (*service.AuthResponse, error) {
+	if req.Password != "correct" {
+		return nil, errors.New(errors.ErrInvalidCredentials, "invalid username or password")
+	}
+	return &service.AuthResponse{AccessToken: "access-token", RefreshToken: "refresh-token", User: envelopeUser()}, nil
+}
+
+func (envelopeAuthService) RefreshToken(ctx context.Context, refreshToken string) (*service.TokenResponse, error) {
+	return &service.TokenResponse{AccessToken: "access-token"}, nil
+}
+
+// envelopeUserService answers the profile, body data and goal endpoints
+// with fixed data
+type envelopeUserService struct {
+	service.UserService
+}
+
+func (envelopeUserService) GetProfile(ctx context.Context, userID int64) (*model.User, error) {
+	return envelopeUser(), nil
+}
+
+func (envelopeUserService) AddBodyData(ctx context.Context, userID int64, req *service.BodyDataRequest) (*model.UserBodyData, error) {
+	return &model.UserBodyData{
+		ID: 31, UserID: userID, Age: req.Age, Gender: req.Gender, Height: req.Height, Weight: req.Weight,
+		BodyFatPercentage: req.BodyFatPercentage, MeasurementDate: req.MeasurementDate,
+		CreatedAt: time.Date(2024, 3, 8, 7, 10, 0, 0, envelopeZone),
+	}, nil
+}
+
+func (envelopeUserService) GetBodyDataHistory(ctx context.Context, userID int64) ([]*model.UserBodyData, error) {
+	return []*model.UserBodyData{
+		{
+			ID: 31, UserID: userID, Age: 30, Gender: "male", Height: 178, Weight: 80.5,
+			BodyFatPercentage: ptr(18.2), MusclePercentage: ptr(41.0),
+			MeasurementDate: time.Date(2024, 3, 8, 0, 0, 0, 0, envelopeZone),
+			CreatedAt:       time.Date(2024, 3, 8, 7, 10, 0, 0, envelopeZone),
+		},
+		{
+			ID: 30, UserID: userID, Age: 30, Gender: "male", Height: 178, Weight: 81.2,
+			MeasurementDate: time.Date(2024, 3, 1, 0, 0, 0, 0, envelopeZone),
+			CreatedAt:       time.Date(2024, 3, 1, 7, 5, 0, 0, envelopeZone),
+		},
+	}, nil
+}
+
+func (envelopeUserService) GetFitnessGoals(ctx context.Context, userID int64) ([]*model.FitnessGoal, error) {
+	return []*model.FitnessGoal{
+		{
+			ID: 41, UserID: userID, GoalType: "fat_loss", GoalDescription: ptr("夏天前减到75kg"),
+			InitialWeight: ptr(82.0), TargetWeight: ptr(75.0), TargetBodyFat: ptr(15.0),
+			Deadline: ptr(time.Date(2024, 6, 30, 0, 0, 0, 0, envelopeZone)),
+			Priority: 1, Status: "active",
+			CreatedAt: time.Date(2024, 1, 2, 9, 5, 0, 0, envelopeZone),
+			UpdatedAt: time.Date(2024, 1, 2, 9, 5, 0, 0, envelopeZone),
+		},
+		{
+			ID: 40, UserID: userID, GoalType: "strength", Priority: 2, Status: "completed",
+			CompletedAt: ptr(time.Date(2024, 2, 20, 19, 30, 0, 0, envelopeZone)),
+			CreatedAt:   time.Date(2023, 11, 5, 20, 0, 0, 0, envelopeZone),
+			UpdatedAt:   time.Date(2024, 2, 20, 19, 30, 0, 0, envelopeZone),
+		},
+	}, nil
+}
+
+func TestEnvelope_Account(t *testing.T) {
+	auth := NewAuthHandler(envelopeAuthService{})
+	user := NewUserHandler(envelopeUserService{})
+
+	runEnvelopeCases(t, []envelopeCase{
+		{golden: "auth_login", method: http.MethodPost, route: "/auth/login", target: "/auth/login",
+			body: `{"username": "lifter", "password": "correct"}`, handler: auth.Login},
+		{golden: "auth_login_invalid_credentials", method: http.MethodPost, route: "/auth/login", target: "/auth/login",
+			body: `{"username": "lifter", "password": "wrong"}`, handler: auth.Login},
+		{golden: "auth_login_missing_fields", method: http.MethodPost, route: "/auth/login", target: "/auth/login",
+			body: `{"username": "lifter"}`, handler: auth.Login},
+		{golden: "auth_refresh", method: http.MethodPost, route: "/auth/refresh", target: "/auth/refresh",
+			body: `{"refresh_token": "refresh-token"}`, handler: auth.RefreshToken},
+		{golden: "user_profile", route: "/user/profile", target: "/user/profile", handler: user.GetProfile},
+		{golden: "user_body_data", route: "/user/body-data", target: "/user/body-data", handler: user.GetBodyDataHistory},
+		{golden: "user_body_data_created", method: http.MethodPost, route: "/user/body-data", target: "/user/body-data",
+			body:    `{"age": 30, "gender": "male", "height": 178, "weight": 80.5, "body_fat_percentage": 18.2, "measurement_date": "2024-03-08"}`,
+			handler: user.AddBodyData},
+		{golden: "user_body_data_invalid_date", method: http.MethodPost, route: "/user/body-data", target: "/user/body-data",
+			body:    `{"age": 30, "gender": "male", "height": 178, "weight": 80.5, "measurement_date": "08/03/2024"}`,
+			handler: user.AddBodyData},
+		{golden: "user_fitness_goals", route: "/user/fitness-goals", target: "/user/fitness-goals", handler: user.GetFitnessGoals},
+	})
+}
@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/jobqueue"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"github.com/ai-fitness-planner/backend/internal/service"
+)
+
+// envelopeAdminService answers the admin endpoints with fixed data
+type envelopeAdminService struct {
+	service.AdminService
+}
+
+func (envelopeAdminService) Impersonate(ctx context.Context, adminID, targetUserID int64, reason, ipAddress, userAgent string) (*service.ImpersonationResult, error) {
+	return &service.ImpersonationResult{
+		AccessToken: "impersonation-token",
+		ExpiresAt:   time.Date(2024, 3, 8, 10, 15, 0, 0, envelopeZone),
+		User:        envelopeUser(),
+	}, nil
+}
+
+func (envelopeAdminService) ListImpersonationLogs(ctx context.Context, userID int64, limit, offset int) ([]*model.ImpersonationAuditLog, int64, error) {
+	return []*model.ImpersonationAuditLog{
+		{
+			ID: 3, AdminID: 1, UserID: envelopeUserID, SessionID: "imp-session-1",
+			Method: http.MethodGet, Path: "/api/v1/training-plans", StatusCode: http.StatusOK,
+			Reason: ptr("排查计划生成失败"), IPAddress: "10.0.0.2",
+			CreatedAt: time.Date(2024, 3, 8, 9, 16, 0, 0, envelopeZone),
+		},
+	}, 1, nil
+}
+
+func (envelopeAdminService) IssueServiceToken(ctx context.Context, adminID int64, svc string, userID int64, ttl time.Duration, ipAddress string) (*service.ServiceTokenResult, error) {
+	return &service.ServiceTokenResult{
+		Token: "service-token", TokenID: "st-1", Service: svc, UserID: userID,
+		ExpiresAt: time.Date(2024, 3, 9, 9, 0, 0, 0, envelopeZone),
+	}, nil
+}
+
+func (envelopeAdminService) RevokeServiceToken(ctx context.Context, adminID int64, tokenID, ipAddress string) (*model.ServiceTokenRevocation, error) {
+	return &model.ServiceTokenRevocation{
+		TokenID: tokenID, Service: "scheduler", UserID: envelopeUserID, RevokedBy: adminID,
+		RevokedAt: time.Date(2024, 3, 8, 12, 0, 0, 0, envelopeZone),
+	}, nil
+}
+
+func (envelopeAdminService) ListServiceAuditLogs(ctx context.Context, svc string, limit, offset int) ([]*model.ServiceAuditLog, int64, error) {
+	return []*model.ServiceAuditLog{
+		{
+			ID: 12, Service: "scheduler", TokenID: "st-1", UserID: envelopeUserID, AdminID: ptr(int64(1)),
+			Method: http.MethodPost, Path: "/api/v1/admin/service-tokens", StatusCode: http.StatusOK,
+			IPAddress: "10.0.0.2", CreatedAt: time.Date(2024, 3, 8, 9, 0, 0, 0, envelopeZone),
+		},
+	}, 1, nil
+}
+
+func (envelopeAdminService) ListGenerationTasks(ctx context.Context, filter repository.GenerationTaskFilter, limit, offset int) ([]*model.GenerationTask, int64, error) {
+	return []*model.GenerationTask{
+		{
+			ID: 8, TaskID: "task-0", UserID: envelopeUserID, TaskType: "training:generate",
+			Status:     "failed",
+			Error:      ptr("AI服务响应超时"),
+			DurationMs: 120000,
+			CreatedAt:  time.Date(2024, 3, 3, 20, 0, 0, 0, envelopeZone),
+			FinishedAt: time.Date(2024, 3, 3, 20, 2, 0, 0, envelopeZone),
+		},
+	}, 1, nil
+}
+
+func (envelopeAdminService) abuseFlag() *model.AIAbuseFlag {
+	return &model.AIAbuseFlag{
+		ID: 2, UserID: envelopeUserID, AIAPIID: 4, Reason: model.AbuseReasonUsageSpike,
+		Details:        model.JSONMap{"count": float64(61), "threshold": float64(60)},
+		Status:         model.AbuseFlagStatusPending,
+		SuspendedUntil: ptr(time.Date(2024, 3, 9, 8, 0, 0, 0, envelopeZone)),
+		CreatedAt:      time.Date(2024, 3, 8, 8, 0, 0, 0, envelopeZone),
+	}
+}
+
+func (s envelopeAdminService) ListAbuseFlags(ctx context.Context, status string, limit, offset int) ([]*model.AIAbuseFlag, int64, error) {
+	return []*model.AIAbuseFlag{s.abuseFlag()}, 1, nil
+}
+
+func (s envelopeAdminService) ReviewAbuseFlag(ctx context.Context, adminID, flagID int64, action, note string) (*model.AIAbuseFlag, error) {
+	if flagID != 2 {
+		return nil, errors.New(errors.ErrNotFound, "异常使用记录不存在")
+	}
+	flag := s.abuseFlag()
+	flag.Status = model.AbuseFlagStatusDismissed
+	flag.ReviewedBy = &adminID
+	flag.ReviewNote = &note
+	flag.ReviewedAt = ptr(time.Date(2024, 3, 8, 11, 0, 0, 0, envelopeZone))
+	return flag, nil
+}
+
+func (envelopeAdminService) ParseFailureStats(ctx context.Context) ([]service.ParseFailureCount, error) {
+	return []service.ParseFailureCount{
+		{Provider: "openai", Model: "gpt-4o", Purpose: "training_plan", Kind: service.ParseFailureTruncated, Count: 3},
+		{Provider: "openai", Model: "gpt-4o", Purpose: "nutrition_plan", Kind: service.ParseFailureWrongSchema, Count: 1},
+	}, nil
+}
+
+func (envelopeAdminService) merge() *model.AccountMerge {
+	return &model.AccountMerge{
+		ID: 6, AdminID: 1, SourceUserID: 9, TargetUserID: envelopeUserID, Reason: "同一用户的重复注册",
+		Changes: model.AccountMergeChanges{
+			Moved: map[string][]int64{"training_records": {101, 102}, "user_body_data": {30}},
+			Conflicts: []model.AccountMergeConflict{
+				{Table: "training_records", ID: 102, Column: "client_id", Old: "c-1", New: "c-1-merged-9", Rule: "rename_client_id"},
+			},
+			SourceStatus: 1,
+		},
+		Status:    model.AccountMergeStatusMerged,
+		CreatedAt: time.Date(2024, 3, 8, 14, 0, 0, 0, envelopeZone),
+	}
+}
+
+func (s envelopeAdminService) MergeAccounts(ctx context.Context, adminID int64, req *service.MergeAccountsRequest) (*model.AccountMerge, error) {
+	if req.SourceUserID == req.TargetUserID {
+		return nil, errors.New(errors.ErrInvalidParam, "不能将账号合并到自身")
+	}
+	return s.merge(), nil
+}
+
+func (s envelopeAdminService) ListAccountMerges(ctx context.Context, userID int64, limit, offset int) ([]*model.AccountMerge, int64, error) {
+	return []*model.AccountMerge{s.merge()}, 1, nil
+}
+
+func (s envelopeAdminService) RevertAccountMerge(ctx context.Context, adminID, mergeID int64) (*model.AccountMerge, error) {
+	merge := s.merge()
+	merge.Status = model.AccountMergeStatusReverted
+	merge.RevertedBy = &adminID
+	merge.RevertedAt = ptr(time.Date(2024, 3, 8, 15, 0, 0, 0, envelopeZone))
+	return merge, nil
+}
+
+// envelopeTaskOpsService reports a fixed queue; no task is claimed so that
+// no field depends on the time of the request
+type envelopeTaskOpsService struct {
+	service.TaskOpsService
+}
+
+func (envelopeTaskOpsService) ListStuckTasks(ctx context.Context, olderThan time.Duration) ([]*service.QueuedGenerationTask, error) {
+	return []*service.QueuedGenerationTask{
+		{
+			TaskID: "task-3", TaskType: "training:generate", UserID: envelopeUserID, AIAPIID: 4, Provider: "openai",
+			State: "scheduled", Attempt: 2, MaxAttempts: 3, LastError: "upstream 503",
+			EnqueuedAt: time.Date(2024, 3, 8, 9, 0, 0, 0, envelopeZone),
+			Deadline:   time.Date(2024, 3, 8, 9, 4, 0, 0, envelopeZone),
+		},
+	}, nil
+}
+
+func (envelopeTaskOpsService) QueueDepth(ctx context.Context) ([]service.ProviderQueueDepth, error) {
+	return []service.ProviderQueueDepth{{Provider: "openai", Pending: 2, Scheduled: 1, Active: 1}}, nil
+}
+
+// envelopeArchiveService holds one archived response
+type envelopeArchiveService struct {
+	service.ResponseArchiveService
+}
+
+func (envelopeArchiveService) Get(ctx context.Context, adminID, id int64) (*model.AIResponseArchive, error) {
+	if id != 15 {
+		return nil, errors.New(errors.ErrNotFound, "AI响应存档不存在或已过期")
+	}
+	return &model.AIResponseArchive{
+		ID: 15, TaskID: "task-1", UserID: envelopeUserID, TaskType: "training:generate", PlanID: 3, AIAPIID: 4,
+		Provider: "openai", Model: "gpt-4o", Response: `{"plan_name": "增肌计划"}`,
+		CreatedAt: time.Date(2024, 3, 3, 21, 20, 0, 0, envelopeZone),
+		ExpiresAt: time.Date(2024, 4, 2, 21, 20, 0, 0, envelopeZone),
+	}, nil
+}
+
+// envelopeExportService queues every export and knows one finished job
+type envelopeExportService struct {
+	service.ExportService
+}
+
+func (envelopeExportService) Export(ctx context.Context, userID int64, req *service.ExportRequest) (*service.ExportOutcome, error) {
+	return &service.ExportOutcome{TaskID: "export-1"}, nil
+}
+
+func (envelopeExportService) GetExportTask(ctx context.Context, userID int64, taskID string) (*jobqueue.Job, error) {
+	if taskID != "export-1" {
+		return nil, errors.New(errors.ErrNotFound, "导出任务不存在")
+	}
+	return &jobqueue.Job{
+		ID: "export-1", OwnerID: userID, Kind: "training", Status: jobqueue.StatusCompleted, Done: 120, Total: 120,
+		CreatedAt: time.Date(2024, 3, 8, 9, 0, 0, 0, envelopeZone),
+		UpdatedAt: time.Date(2024, 3, 8, 9, 0, 5, 0, envelopeZone),
+	}, nil
+}
+
+func TestEnvelope_Admin(t *testing.T) {
+	admin := NewAdminHandler(envelopeAdminService{})
+	taskOps := NewTaskOpsHandler(envelopeTaskOpsService{})
+	archive := NewResponseArchiveHandler(envelopeArchiveService{})
+
+	runEnvelopeCases(t, []envelopeCase{
+		{golden: "admin_impersonate", method: http.MethodPost, route: "/admin/users/:id/impersonate", target: "/admin/users/7/impersonate",
+			body: `{"reason": "排查计划生成失败"}`, handler: admin.Impersonate},
+		{golden: "admin_impersonation_logs", route: "/admin/impersonation-logs", target: "/admin/impersonation-logs?user_id=7", handler: admin.ListImpersonationLogs},
+		{golden: "admin_service_token", method: http.MethodPost, route: "/admin/service-tokens", target: "/admin/service-tokens",
+			body: `{"service": "scheduler", "user_id": 7, "ttl_hours": 24}`, handler: admin.IssueServiceToken},
+		{golden: "admin_service_token_revoked", method: http.MethodDelete, route: "/admin/service-tokens/:id", target: "/admin/service-tokens/st-1",
+			handler: admin.RevokeServiceToken},
+		{golden: "admin_service_audit_logs", route: "/admin/service-audit-logs", target: "/admin/service-audit-logs", handler: admin.ListServiceAuditLogs},
+		{golden: "admin_generation_tasks", route: "/admin/generation-tasks", target: "/admin/generation-tasks?status=failed", handler: admin.ListGenerationTasks},
+		{golden: "admin_abuse_flags", route: "/admin/abuse-flags", target: "/admin/abuse-flags", handler: admin.ListAbuseFlags},
+		{golden: "admin_abuse_flag_review", method: http.MethodPost, route: "/admin/abuse-flags/:id/review", target: "/admin/abuse-flags/2/review",
+			body: `{"action": "dismiss", "note": "压测流量"}`, handler: admin.ReviewAbuseFlag},
+		{golden: "admin_abuse_flag_review_invalid_action", method: http.MethodPost, route: "/admin/abuse-flags/:id/review", target: "/admin/abuse-flags/2/review",
+			body: `{"action": "ignore"}`, handler: admin.ReviewAbuseFlag},
+		{golden: "admin_parse_failures", route: "/admin/ai/parse-failures", target: "/admin/ai/parse-failures", handler: admin.GetParseFailureStats},
+		{golden: "admin_account_merge", method: http.MethodPost, route: "/admin/account-merges", target: "/admin/account-merges",
+			body: `{"source_user_id": 9, "target_user_id": 7, "reason": "同一用户的重复注册"}`, handler: admin.MergeAccounts},
+		{golden: "admin_account_merge_same_account", method: http.MethodPost, route: "/admin/account-merges", target: "/admin/account-merges",
+			body: `{"source_user_id": 7, "target_user_id": 7, "reason": "同一用户的重复注册"}`, handler: admin.MergeAccounts},
+		{golden: "admin_account_merges", route: "/admin/account-merges", target: "/admin/account-merges", handler: admin.ListAccountMerges},
+		{golden: "admin_account_merge_reverted", method: http.MethodPost, route: "/admin/account-merges/:id/revert", target: "/admin/account-merges/6/revert",
+			handler: admin.RevertAccountMerge},
+		{golden: "admin_stuck_tasks", route: "/admin/queue/stuck", target: "/admin/queue/stuck", handler: taskOps.ListStuckTasks},
+		{golden: "admin_queue_depth", route: "/admin/queue/depth", target: "/admin/queue/depth", handler: taskOps.GetQueueDepth},
+		{golden: "admin_response_archive", route: "/admin/ai-response-archives/:id", target: "/admin/ai-response-archives/15", handler: archive.GetArchive},
+		{golden: "admin_response_archive_not_found", route: "/admin/ai-response-archives/:id", target: "/admin/ai-response-archives/16", handler: archive.GetArchive},
+	})
+}
+
+func TestEnvelope_Export(t *testing.T) {
+	export := NewExportHandler(envelopeExportService{})
+
+	runEnvelopeCases(t, []envelopeCase{
+		{golden: "export_queued", route: "/exports/:kind", target: "/exports/training?start_date=2024-01-01&end_date=2024-03-01", handler: export.Export},
+		{golden: "export_invalid_range", route: "/exports/:kind", target: "/exports/training?start_date=2024-03-01&end_date=2024-01-01", handler: export.Export},
+		{golden: "export_task", route: "/exports/tasks/:taskId", target: "/exports/tasks/export-1", handler: export.GetExportTask},
+		{golden: "export_task_not_found", route: "/exports/tasks/:taskId", target: "/exports/tasks/export-2", handler: export.GetExportTask},
+	})
+}
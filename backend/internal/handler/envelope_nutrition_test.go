@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"github.com/ai-fitness-planner/backend/internal/service"
+)
+
+func (s envelopeNutritionService) ListPlans(ctx context.Context, userID int64, status string) ([]*model.NutritionPlan, error) {
+	active, _ := s.plan(5, false)
+	previous, _ := s.plan(4, false)
+	previous.Status = "inactive"
+	previous.StartDate = time.Date(2024, 2, 26, 0, 0, 0, 0, envelopeZone)
+	previous.EndDate = time.Date(2024, 3, 3, 0, 0, 0, 0, envelopeZone)
+	previous.BudgetLevel = ptr(model.NutritionBudgetLow)
+	previous.WeeklyBudget = ptr(300.0)
+	previous.RolledOverAt = ptr(time.Date(2024, 3, 3, 21, 20, 0, 0, envelopeZone))
+	return []*model.NutritionPlan{active, previous}, nil
+}
+
+func (s envelopeNutritionService) GetPlanStatus(ctx context.Context, taskID string) (*service.NutritionTaskStatus, error) {
+	if taskID != "task-1" {
+		return nil, errors.New(errors.ErrNotFound, "任务不存在")
+	}
+	plan, _ := s.plan(5, false)
+	return &service.NutritionTaskStatus{
+		TaskID:    "task-1",
+		Status:    "completed",
+		Progress:  100,
+		Result:    plan,
+		Warnings:  []service.PlanWarning{{Code: service.WarningMissingBodyData, Message: "未找到身体数据，使用默认热量目标"}},
+		CreatedAt: time.Date(2024, 3, 3, 21, 19, 0, 0, envelopeZone),
+		UpdatedAt: time.Date(2024, 3, 3, 21, 20, 0, 0, envelopeZone),
+		ExpiresAt: ptr(time.Date(2024, 3, 4, 21, 20, 0, 0, envelopeZone)),
+	}, nil
+}
+
+func (s envelopeNutritionService) ListTasks(ctx context.Context, userID int64) ([]*service.NutritionTaskStatus, error) {
+	done, _ := s.GetPlanStatus(ctx, "task-1")
+	return []*service.NutritionTaskStatus{
+		{
+			TaskID:    "task-2",
+			Status:    "processing",
+			Progress:  40,
+			Message:   "等待AI服务恢复",
+			Cooldown:  &service.ProviderCooldown{Provider: "openai", Until: time.Date(2024, 3, 5, 8, 1, 0, 0, envelopeZone)},
+			CreatedAt: time.Date(2024, 3, 5, 8, 0, 0, 0, envelopeZone),
+			UpdatedAt: time.Date(2024, 3, 5, 8, 0, 30, 0, envelopeZone),
+		},
+		done,
+	}, nil
+}
+
+func (s envelopeNutritionService) ListTaskHistory(ctx context.Context, userID int64) ([]*model.GenerationTask, error) {
+	return []*model.GenerationTask{
+		{
+			ID: 9, TaskID: "task-1", UserID: userID, TaskType: "nutrition_plan",
+			Params:     model.JSONMap{"plan_name": "减脂饮食计划", "duration_days": float64(7)},
+			Status:     "completed",
+			PlanID:     ptr(int64(5)),
+			DurationMs: 48210,
+			CreatedAt:  time.Date(2024, 3, 3, 21, 19, 0, 0, envelopeZone),
+			FinishedAt: time.Date(2024, 3, 3, 21, 20, 0, 0, envelopeZone),
+		},
+		{
+			ID: 8, TaskID: "task-0", UserID: userID, TaskType: "nutrition_plan",
+			Status:     "failed",
+			Error:      ptr("AI服务响应超时"),
+			DurationMs: 120000,
+			CreatedAt:  time.Date(2024, 3, 3, 20, 0, 0, 0, envelopeZone),
+			FinishedAt: time.Date(2024, 3, 3, 20, 2, 0, 0, envelopeZone),
+		},
+	}, nil
+}
+
+func (s envelopeNutritionService) GetShoppingList(ctx context.Context, userID, planID int64, week int) (*service.ShoppingList, error) {
+	if week != 1 {
+		return nil, errors.New(errors.ErrNotFound, "计划中没有该周")
+	}
+	return &service.ShoppingList{
+		PlanID:     planID,
+		Week:       1,
+		TotalWeeks: 1,
+		StartDate:  "2024-03-04",
+		EndDate:    "2024-03-10",
+		Items: []service.ShoppingListItem{
+			{Name: "燕麦", Quantity: "420g", Servings: 7, EstimatedCost: ptr(10.5)},
+			{Name: "鸡胸肉", Quantity: "150g + 1块", Servings: 2, EstimatedCost: ptr(12.0 / 3), Unpriced: 1},
+		},
+		EstimatedCost: ptr(10.5 + 12.0/3),
+		Unpriced:      1,
+		WeeklyBudget:  ptr(300.0),
+		BudgetLevel:   ptr(model.NutritionBudgetLow),
+	}, nil
+}
+
+func (s envelopeNutritionService) GetNutritionHistory(ctx context.Context, userID int64, startDate, endDate *time.Time) ([]*model.NutritionRecord, error) {
+	return []*model.NutritionRecord{
+		{
+			ID: 51, UserID: userID, ClientID: "9a8b7c6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d",
+			MealDate: time.Date(2024, 3, 4, 0, 0, 0, 0, envelopeZone), MealTime: "breakfast",
+			Foods:    model.JSONMap{"items": []interface{}{map[string]interface{}{"name": "燕麦", "amount": "60g"}}},
+			Calories: 230, Protein: 8, Carbs: 40, Fat: 4, Fiber: 6,
+			CreatedAt: time.Date(2024, 3, 4, 7, 45, 0, 0, envelopeZone),
+			UpdatedAt: time.Date(2024, 3, 4, 7, 45, 0, 0, envelopeZone),
+		},
+	}, nil
+}
+
+func (s envelopeNutritionService) GetDailySummary(ctx context.Context, userID int64, date time.Time) (*repository.DailyNutritionSummary, error) {
+	return &repository.DailyNutritionSummary{
+		Date: date, TotalCalories: 1850, TotalProtein: 140, TotalCarbs: 190, TotalFat: 55, TotalFiber: 24, MealCount: 4,
+	}, nil
+}
+
+func TestEnvelope_Nutrition(t *testing.T) {
+	nutrition := NewNutritionHandler(envelopeNutritionService{t: t})
+
+	runEnvelopeCases(t, []envelopeCase{
+		{golden: "nutrition_plans", route: "/nutrition-plans", target: "/nutrition-plans", handler: nutrition.ListPlans},
+		{golden: "nutrition_plan_summary", route: "/nutrition-plans/:id", target: "/nutrition-plans/5", handler: nutrition.GetPlanDetail},
+		{golden: "nutrition_task_status", route: "/nutrition-plans/tasks/:taskId", target: "/nutrition-plans/tasks/task-1", handler: nutrition.GetPlanStatus},
+		{golden: "nutrition_task_status_not_found", route: "/nutrition-plans/tasks/:taskId", target: "/nutrition-plans/tasks/task-9", handler: nutrition.GetPlanStatus},
+		{golden: "nutrition_tasks", route: "/nutrition-plans/tasks", target: "/nutrition-plans/tasks", handler: nutrition.ListTasks},
+		{golden: "nutrition_task_history", route: "/nutrition-plans/tasks/history", target: "/nutrition-plans/tasks/history", handler: nutrition.ListTaskHistory},
+		{golden: "nutrition_shopping_list", route: "/nutrition-plans/:id/weeks/:n/shopping-list", target: "/nutrition-plans/5/weeks/1/shopping-list", handler: nutrition.GetShoppingList},
+		{golden: "nutrition_shopping_list_no_week", route: "/nutrition-plans/:id/weeks/:n/shopping-list", target: "/nutrition-plans/5/weeks/2/shopping-list", handler: nutrition.GetShoppingList},
+		{golden: "nutrition_records", route: "/nutrition-records", target: "/nutrition-records?start_date=2024-03-04", handler: nutrition.ListNutritionRecords},
+		{golden: "nutrition_daily_summary", route: "/nutrition-records/daily-summary", target: "/nutrition-records/daily-summary?date=2024-03-04", handler: nutrition.GetDailySummary},
+		{golden: "nutrition_daily_summary_invalid", route: "/nutrition-records/daily-summary", target: "/nutrition-records/daily-summary", handler: nutrition.GetDailySummary},
+	})
+}
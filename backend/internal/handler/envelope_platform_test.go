@@ -0,0 +1,420 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/config"
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/migration"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/jobqueue"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"github.com/ai-fitness-planner/backend/internal/service"
+)
+
+// envelopeAIAPIService holds one OpenAI config and its call history
+type envelopeAIAPIService struct {
+	service.AIAPIService
+}
+
+func (envelopeAIAPIService) ListAPIs(ctx context.Context, userID int64) (*response.AIAPIListResponse, error) {
+	return &response.AIAPIListResponse{APIs: []response.AIAPIInfo{
+		{
+			ID: 4, Provider: "openai", Name: "主力", APIEndpoint: "https://api.openai.com/v1", Model: "gpt-4o",
+			MaxTokens: 4096, Temperature: 0.7, IsDefault: true, FallbackPriority: ptr(1), Status: true,
+			CreatedAt: response.FormatTime(time.Date(2024, 1, 2, 9, 30, 0, 0, envelopeZone)),
+		},
+	}}, nil
+}
+
+func (envelopeAIAPIService) GetUsage(ctx context.Context, userID int64, apiID int64, days int) (*response.AIUsageResponse, error) {
+	return &response.AIUsageResponse{
+		APIID: apiID, Provider: "openai", Since: response.FormatTime(time.Date(2024, 2, 7, 0, 0, 0, 0, envelopeZone)),
+		Calls: 12, PromptTokens: 24000, CompletionTokens: 36000, TotalTokens: 60000, EstimatedCostUSD: 0.42,
+		ByModel: []response.AIUsageModelInfo{
+			{Model: "gpt-4o", Calls: 12, PromptTokens: 24000, CompletionTokens: 36000, TotalTokens: 60000, EstimatedCostUSD: ptr(0.42)},
+		},
+	}, nil
+}
+
+func (envelopeAIAPIService) ListCallLogs(ctx context.Context, userID int64, filter repository.AICallLogFilter, limit, offset int) ([]*model.AICallLog, int64, error) {
+	return []*model.AICallLog{
+		{
+			ID: 301, UserID: userID, AIAPIID: 4, Provider: "openai", Model: "gpt-4o", Purpose: "training_plan",
+			Status: "error", Error: ptr("context deadline exceeded"), LatencyMs: 120000, PromptHash: "3f2a9c1d0b8e7f6a",
+			CreatedAt: time.Date(2024, 3, 3, 20, 2, 0, 0, envelopeZone),
+		},
+	}, 1, nil
+}
+
+// envelopeTemplateService holds the default training plan template and one
+// variant rolled out beside it
+type envelopeTemplateService struct {
+	service.PromptTemplateService
+}
+
+func (envelopeTemplateService) template(id int64) *model.PromptTemplate {
+	t := &model.PromptTemplate{
+		ID: id, Category: "training", Subcategory: ptr("plan_generation"), Name: "训练计划生成",
+		Template:  "为{{.Goal}}制定{{.DurationWeeks}}周的训练计划",
+		Variables: model.JSONSlice{"Goal", "DurationWeeks"}, IsDefault: true,
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, envelopeZone),
+		UpdatedAt: time.Date(2024, 2, 1, 10, 0, 0, 0, envelopeZone),
+	}
+	if id != 1 {
+		t.Name = "训练计划生成（简洁版）"
+		t.IsDefault = false
+		t.IsCustomized = true
+		t.RolloutPercent = 20
+		t.Description = ptr("更短的提示词")
+		t.CreatedAt = time.Date(2024, 2, 15, 14, 0, 0, 0, envelopeZone)
+		t.UpdatedAt = time.Date(2024, 2, 15, 14, 0, 0, 0, envelopeZone)
+	}
+	return t
+}
+
+func (s envelopeTemplateService) List(ctx context.Context, category, subcategory string) ([]*model.PromptTemplate, error) {
+	return []*model.PromptTemplate{s.template(1), s.template(2)}, nil
+}
+
+func (s envelopeTemplateService) Get(ctx context.Context, id int64) (*model.PromptTemplate, error) {
+	if id > 2 {
+		return nil, errors.New(errors.ErrNotFound, "提示词模板不存在")
+	}
+	return s.template(id), nil
+}
+
+func (s envelopeTemplateService) Experiment(ctx context.Context, category, subcategory string, days int) (*service.PromptExperiment, error) {
+	return &service.PromptExperiment{
+		Category: category, Subcategory: subcategory, Since: time.Date(2024, 2, 7, 0, 0, 0, 0, envelopeZone),
+		Variants: []*service.PromptVariantResult{
+			{Template: s.template(1), Stats: &repository.PromptVariantStats{TemplateID: 1, Plans: 40, AdjustedPlans: 10, CompletedPlans: 22, Feedbacks: 15, AvgSatisfaction: ptr(4.1)}},
+			{Template: s.template(2), Stats: &repository.PromptVariantStats{TemplateID: 2}},
+		},
+	}, nil
+}
+
+// envelopeProvisioningService holds one gym organization
+type envelopeProvisioningService struct {
+	service.ProvisioningService
+}
+
+func (envelopeProvisioningService) CreateOrganization(ctx context.Context, name string, seatLimit int) (*model.Organization, error) {
+	return &model.Organization{
+		ID: 2, Name: name, SeatLimit: seatLimit,
+		CreatedAt: time.Date(2024, 3, 8, 9, 0, 0, 0, envelopeZone),
+		UpdatedAt: time.Date(2024, 3, 8, 9, 0, 0, 0, envelopeZone),
+	}, nil
+}
+
+func (envelopeProvisioningService) ListOrganizations(ctx context.Context) ([]*service.OrganizationSummary, error) {
+	return []*service.OrganizationSummary{
+		{
+			Organization: &model.Organization{
+				ID: 1, Name: "铁馆健身", SeatLimit: 50,
+				CreatedAt: time.Date(2024, 1, 15, 9, 0, 0, 0, envelopeZone),
+				UpdatedAt: time.Date(2024, 1, 15, 9, 0, 0, 0, envelopeZone),
+			},
+			ActiveMembers: 32,
+		},
+	}, nil
+}
+
+// envelopeRuntimeService reports a fixed configuration
+type envelopeRuntimeService struct {
+	service.RuntimeService
+}
+
+func (envelopeRuntimeService) GetRuntimeInfo(ctx context.Context) (*service.RuntimeInfo, error) {
+	return &service.RuntimeInfo{
+		AppName: "ai-fitness-planner", Version: "1.4.0", Mode: "release", GoVersion: "go1.21.6",
+		StartedAt: time.Date(2024, 3, 8, 6, 0, 0, 0, envelopeZone),
+		RateLimit: config.RateLimitConfig{
+			APICallsPerMinute: 60, APICallsPerHour: 1000, APICallsPerDay: 10000,
+			AIGenerationsPerDay: 10, AIGenerationsPerMonth: 100,
+		},
+		AIMaxConcurrentRequests: 4, AITimeout: 120 * time.Second, AIRetryAttempts: 3,
+		SupportedProviders:  []string{"openai", "anthropic", "ollama"},
+		AllowedProviders:    []string{"openai", "anthropic"},
+		ConfiguredProviders: map[string]int64{"openai": 12},
+		Features:            map[string]bool{"plan_sharing": true, "response_archive": false},
+		SyncTrainingPolicy:  "client_wins", SyncNutritionPolicy: "server_wins",
+		Schema: &migration.SchemaStatus{Tracked: true, Current: "20240301_add_macrocycles", Latest: "20240301_add_macrocycles"},
+	}, nil
+}
+
+// envelopeIntegrityService reports one repaired and one unrepairable issue
+type envelopeIntegrityService struct {
+	service.IntegrityService
+}
+
+func (envelopeIntegrityService) LatestReport() *service.IntegrityReport {
+	return nil
+}
+
+func (envelopeIntegrityService) Check(ctx context.Context, repair bool) (*service.IntegrityReport, error) {
+	repaired := 0
+	if repair {
+		repaired = 1
+	}
+	return &service.IntegrityReport{
+		StartedAt:  time.Date(2024, 3, 8, 3, 0, 0, 0, envelopeZone),
+		FinishedAt: time.Date(2024, 3, 8, 3, 0, 4, 0, envelopeZone),
+		Repair:     repair,
+		Issues: []service.IntegrityIssue{
+			{Check: "orphan_training_records", Table: "training_records", RecordID: 88, UserID: 12, Detail: "plan 41 was deleted", Repairable: true, Repaired: repair},
+			{Check: "invalid_plan_dates", Table: "training_plans", RecordID: 9, UserID: 3, Detail: "end_date before start_date"},
+		},
+		Counts:    map[string]int{"orphan_training_records": 1, "invalid_plan_dates": 1},
+		Repaired:  repaired,
+		Truncated: []string{},
+	}, nil
+}
+
+// envelopeCleanupService previews a cleanup until it is confirmed
+type envelopeCleanupService struct {
+	service.CleanupService
+}
+
+func (envelopeCleanupService) Cleanup(ctx context.Context, userID int64, req *service.CleanupRequest) (*service.CleanupOutcome, error) {
+	if req.ConfirmationToken == "" {
+		return &service.CleanupOutcome{Preview: &service.CleanupPreview{
+			Kind: req.Kind, Count: 42, ConfirmationToken: "confirm-1",
+			ExpiresAt: time.Date(2024, 3, 8, 9, 10, 0, 0, envelopeZone),
+		}}, nil
+	}
+	return &service.CleanupOutcome{TaskID: "cleanup-1"}, nil
+}
+
+func (envelopeCleanupService) GetCleanupTask(ctx context.Context, userID int64, taskID string) (*jobqueue.Job, error) {
+	return &jobqueue.Job{
+		ID: taskID, OwnerID: userID, Kind: "ai_call_logs", Status: jobqueue.StatusProcessing, Done: 21, Total: 42,
+		CreatedAt: time.Date(2024, 3, 8, 9, 1, 0, 0, envelopeZone),
+		UpdatedAt: time.Date(2024, 3, 8, 9, 1, 2, 0, envelopeZone),
+	}, nil
+}
+
+// envelopeCoachService answers every question the same way
+type envelopeCoachService struct {
+	service.CoachService
+}
+
+func (envelopeCoachService) messages() []*model.CoachMessage {
+	return []*model.CoachMessage{
+		{ID: 71, UserID: envelopeUserID, Role: "user", Content: "深蹲膝盖疼怎么办？", CreatedAt: time.Date(2024, 3, 8, 20, 0, 0, 0, envelopeZone)},
+		{ID: 72, UserID: envelopeUserID, Role: "assistant", Content: "先降低重量，检查膝盖是否内扣。", AIAPIID: ptr(int64(4)),
+			CreatedAt: time.Date(2024, 3, 8, 20, 0, 6, 0, envelopeZone)},
+	}
+}
+
+func (s envelopeCoachService) Chat(ctx context.Context, userID int64, req *service.CoachChatRequest) (*service.CoachChatResult, error) {
+	messages := s.messages()
+	return &service.CoachChatResult{Question: messages[0], Reply: messages[1]}, nil
+}
+
+func (s envelopeCoachService) History(ctx context.Context, userID int64, limit int) ([]*model.CoachMessage, error) {
+	return s.messages(), nil
+}
+
+// envelopeMacrocycleService holds one macrocycle with its first block
+type envelopeMacrocycleService struct {
+	service.MacrocycleService
+	t *testing.T
+}
+
+func (envelopeMacrocycleService) macrocycle() *model.Macrocycle {
+	return &model.Macrocycle{
+		ID: 2, UserID: envelopeUserID, Name: "春季力量周期", Goal: "力量",
+		Phases:    model.JSONSlice{model.PhaseHypertrophy, model.PhaseStrength, model.PhasePower},
+		CreatedAt: time.Date(2024, 3, 3, 21, 0, 0, 0, envelopeZone),
+		UpdatedAt: time.Date(2024, 3, 3, 21, 0, 0, 0, envelopeZone),
+	}
+}
+
+func (s envelopeMacrocycleService) ListMacrocycles(ctx context.Context, userID int64) ([]*model.Macrocycle, error) {
+	return []*model.Macrocycle{s.macrocycle()}, nil
+}
+
+func (s envelopeMacrocycleService) GetMacrocycle(ctx context.Context, userID, macrocycleID int64) (*service.MacrocycleDetail, error) {
+	if macrocycleID != 2 {
+		return nil, errors.New(errors.ErrNotFound, "宏周期不存在")
+	}
+	plan, err := envelopeTrainingService{t: s.t}.plan(3, false)
+	if err != nil {
+		return nil, err
+	}
+	plan.BlockNumber = ptr(1)
+	plan.BlockPhase = ptr(model.PhaseHypertrophy)
+	return &service.MacrocycleDetail{
+		Macrocycle: s.macrocycle(),
+		Blocks:     []*service.MacrocycleBlockSummary{{Plan: plan, CompletionRate: 83.333}},
+		NextPhase:  model.PhaseStrength,
+	}, nil
+}
+
+func (envelopeShareService) SharePlan(ctx context.Context, userID, planID int64, ttl time.Duration) (*service.PlanShare, error) {
+	if planID != 3 {
+		return nil, errors.New(errors.ErrPlanNotFound, "训练计划不存在")
+	}
+	return &service.PlanShare{PlanID: planID, Token: "valid", ExpiresAt: time.Date(2024, 4, 3, 21, 15, 0, 0, envelopeZone)}, nil
+}
+
+func (s envelopeTrainingService) ListPlans(ctx context.Context, userID int64, status string) ([]*model.TrainingPlan, error) {
+	plan, err := s.plan(3, false)
+	if err != nil {
+		return nil, err
+	}
+	return []*model.TrainingPlan{plan}, nil
+}
+
+func (s envelopeTrainingService) GetPlanStatus(ctx context.Context, taskID string) (*service.TaskStatus, error) {
+	if taskID != "task-1" {
+		return nil, errors.New(errors.ErrNotFound, "任务不存在")
+	}
+	plan, err := s.plan(3, false)
+	if err != nil {
+		return nil, err
+	}
+	return &service.TaskStatus{
+		TaskID: "task-1", Status: "completed", Progress: 100, Result: plan,
+		CreatedAt: time.Date(2024, 3, 3, 21, 14, 0, 0, envelopeZone),
+		UpdatedAt: time.Date(2024, 3, 3, 21, 15, 0, 0, envelopeZone),
+		ExpiresAt: ptr(time.Date(2024, 3, 4, 21, 15, 0, 0, envelopeZone)),
+	}, nil
+}
+
+func (s envelopeTrainingService) ListTasks(ctx context.Context, userID int64) ([]*service.TaskStatus, error) {
+	done, err := s.GetPlanStatus(ctx, "task-1")
+	if err != nil {
+		return nil, err
+	}
+	return []*service.TaskStatus{
+		{
+			TaskID: "task-2", Status: "failed", Progress: 30, Error: "AI服务响应超时",
+			CreatedAt: time.Date(2024, 3, 5, 8, 0, 0, 0, envelopeZone),
+			UpdatedAt: time.Date(2024, 3, 5, 8, 2, 0, 0, envelopeZone),
+			ExpiresAt: ptr(time.Date(2024, 3, 6, 8, 2, 0, 0, envelopeZone)),
+		},
+		done,
+	}, nil
+}
+
+func (s envelopeTrainingService) ListTaskHistory(ctx context.Context, userID int64) ([]*model.GenerationTask, error) {
+	return []*model.GenerationTask{
+		{
+			ID: 7, TaskID: "task-1", UserID: userID, TaskType: "training:generate",
+			Params:     model.JSONMap{"plan_name": "四周力量计划", "duration_weeks": float64(4)},
+			Status:     "completed",
+			PlanID:     ptr(int64(3)),
+			DurationMs: 61200,
+			CreatedAt:  time.Date(2024, 3, 3, 21, 14, 0, 0, envelopeZone),
+			FinishedAt: time.Date(2024, 3, 3, 21, 15, 0, 0, envelopeZone),
+		},
+	}, nil
+}
+
+func (s envelopeTrainingService) PausePlan(ctx context.Context, userID, planID int64) (*model.TrainingPlan, error) {
+	return s.plan(planID, false)
+}
+
+func (envelopeStatsService) GetProgressReport(ctx context.Context, userID int64) (*service.ProgressReport, error) {
+	return &service.ProgressReport{
+		CurrentPeriod: &service.PeriodSummary{
+			StartDate: time.Date(2024, 3, 4, 0, 0, 0, 0, envelopeZone), EndDate: time.Date(2024, 3, 10, 0, 0, 0, 0, envelopeZone),
+			TotalWorkouts: 4, TotalDuration: 240, TotalCalories: 1600, AverageRating: 4.25,
+		},
+		PreviousPeriod: &service.PeriodSummary{
+			StartDate: time.Date(2024, 2, 26, 0, 0, 0, 0, envelopeZone), EndDate: time.Date(2024, 3, 3, 0, 0, 0, 0, envelopeZone),
+			TotalWorkouts: 3, TotalDuration: 170, TotalCalories: 1150, AverageRating: 3.67,
+		},
+		BodyProgress: &service.BodyProgressData{CurrentWeight: ptr(80.5), PreviousWeight: ptr(81.2), WeightChange: ptr(-0.7)},
+		WorkoutComparison: &service.WorkoutComparison{
+			WorkoutCountChange: 1, DurationChange: 70, CaloriesChange: 450,
+			WorkoutCountPercent: 33.33, DurationPercent: 41.18, CaloriesPercent: 39.13,
+		},
+		HasSufficientData: true,
+	}, nil
+}
+
+func (envelopeStatsService) CalculateTrends(ctx context.Context, userID int64, period string, count int) (*service.TrendsReport, error) {
+	return &service.TrendsReport{
+		Period: period, WeekStart: model.WeekStartMonday, HasSufficientData: true,
+		DataPoints: []service.TrendPoint{
+			{
+				PeriodLabel: "2024-W09", StartDate: time.Date(2024, 2, 26, 0, 0, 0, 0, envelopeZone), EndDate: time.Date(2024, 3, 3, 0, 0, 0, 0, envelopeZone),
+				TotalWorkouts: 3, TotalDuration: 170, TotalCalories: 1150, AverageRating: 3.67, Tonnage: 18250,
+			},
+			{
+				PeriodLabel: "2024-W10", StartDate: time.Date(2024, 3, 4, 0, 0, 0, 0, envelopeZone), EndDate: time.Date(2024, 3, 8, 0, 0, 0, 0, envelopeZone),
+				Partial: true, TotalWorkouts: 4, TotalDuration: 240, TotalCalories: 1600, AverageRating: 4.25, Tonnage: 22400,
+				RelativeIntensity: ptr(78.5),
+			},
+		},
+	}, nil
+}
+
+func TestEnvelope_Platform(t *testing.T) {
+	aiAPIs := NewAIAPIHandler(envelopeAIAPIService{})
+	templates := NewPromptTemplateHandler(envelopeTemplateService{})
+	organizations := NewOrganizationHandler(envelopeProvisioningService{})
+	meta := NewMetaHandler(envelopeRuntimeService{})
+	integrity := NewIntegrityHandler(envelopeIntegrityService{})
+	cleanup := NewCleanupHandler(envelopeCleanupService{})
+	coach := NewCoachHandler(envelopeCoachService{})
+	macrocycles := NewMacrocycleHandler(envelopeMacrocycleService{t: t})
+
+	runEnvelopeCases(t, []envelopeCase{
+		{golden: "ai_apis", route: "/ai-apis", target: "/ai-apis", handler: aiAPIs.ListAPIs},
+		{golden: "ai_api_usage", route: "/ai-apis/:id/usage", target: "/ai-apis/4/usage?days=30", handler: aiAPIs.GetUsage},
+		{golden: "ai_api_call_logs", route: "/ai-apis/call-logs", target: "/ai-apis/call-logs?status=error", handler: aiAPIs.ListCallLogs},
+		{golden: "prompt_templates", route: "/prompt-templates", target: "/prompt-templates?category=training", handler: templates.ListTemplates},
+		{golden: "prompt_template", route: "/prompt-templates/:id", target: "/prompt-templates/2", handler: templates.GetTemplate},
+		{golden: "prompt_template_not_found", route: "/prompt-templates/:id", target: "/prompt-templates/9", handler: templates.GetTemplate},
+		{golden: "prompt_experiment", route: "/admin/prompt-experiments", target: "/admin/prompt-experiments?category=training&subcategory=plan_generation",
+			handler: templates.GetExperiment},
+		{golden: "organization_created", method: http.MethodPost, route: "/admin/organizations", target: "/admin/organizations",
+			body: `{"name": " 城南健身 ", "seat_limit": 20}`, handler: organizations.CreateOrganization},
+		{golden: "organizations", route: "/admin/organizations", target: "/admin/organizations", handler: organizations.ListOrganizations},
+		{golden: "meta_runtime", route: "/meta/runtime", target: "/meta/runtime", handler: meta.GetRuntime},
+		{golden: "integrity_report", route: "/admin/integrity/report", target: "/admin/integrity/report", handler: integrity.GetReport},
+		{golden: "integrity_check_repair", method: http.MethodPost, route: "/admin/integrity/check", target: "/admin/integrity/check?repair=true",
+			handler: integrity.RunCheck},
+		{golden: "cleanup_preview", method: http.MethodPost, route: "/cleanup/:kind", target: "/cleanup/ai_call_logs",
+			body: `{"end_date": "2024-01-31"}`, handler: cleanup.Cleanup},
+		{golden: "cleanup_queued", method: http.MethodPost, route: "/cleanup/:kind", target: "/cleanup/ai_call_logs",
+			body: `{"end_date": "2024-01-31", "confirmation_token": "confirm-1"}`, handler: cleanup.Cleanup},
+		{golden: "cleanup_task", route: "/cleanup/tasks/:taskId", target: "/cleanup/tasks/cleanup-1", handler: cleanup.GetCleanupTask},
+		{golden: "coach_chat", method: http.MethodPost, route: "/coach/chat", target: "/coach/chat",
+			body: `{"message": "深蹲膝盖疼怎么办？"}`, handler: coach.Chat},
+		{golden: "coach_history", route: "/coach/history", target: "/coach/history", handler: coach.GetHistory},
+		{golden: "macrocycles", route: "/macrocycles", target: "/macrocycles", handler: macrocycles.ListMacrocycles},
+		{golden: "macrocycle", route: "/macrocycles/:id", target: "/macrocycles/2", handler: macrocycles.GetMacrocycle},
+		{golden: "macrocycle_not_found", route: "/macrocycles/:id", target: "/macrocycles/5", handler: macrocycles.GetMacrocycle},
+	})
+}
+
+func TestEnvelope_TrainingAndStats(t *testing.T) {
+	training := NewTrainingHandler(envelopeTrainingService{t: t})
+	share := NewPlanShareHandler(envelopeShareService{t: t})
+	stats := NewStatisticsHandler(envelopeStatsService{})
+
+	runEnvelopeCases(t, []envelopeCase{
+		{golden: "training_plans", route: "/training-plans", target: "/training-plans", handler: training.ListPlans},
+		{golden: "training_task_status", route: "/training-plans/tasks/:taskId", target: "/training-plans/tasks/task-1", handler: training.GetPlanStatus},
+		{golden: "training_task_status_not_found", route: "/training-plans/tasks/:taskId", target: "/training-plans/tasks/task-9", handler: training.GetPlanStatus},
+		{golden: "training_tasks", route: "/training-plans/tasks", target: "/training-plans/tasks", handler: training.ListTasks},
+		{golden: "training_task_history", route: "/training-plans/tasks/history", target: "/training-plans/tasks/history", handler: training.ListTaskHistory},
+		{golden: "training_plan_paused", method: http.MethodPost, route: "/training-plans/:id/pause", target: "/training-plans/3/pause", handler: training.PausePlan},
+		{golden: "training_plan_share", method: http.MethodPost, route: "/training-plans/:id/share", target: "/training-plans/3/share",
+			body: `{"ttl_hours": 720}`, handler: share.SharePlan},
+		{golden: "training_plan_share_not_found", method: http.MethodPost, route: "/training-plans/:id/share", target: "/training-plans/404/share",
+			handler: share.SharePlan},
+		{golden: "stats_progress", route: "/stats/progress", target: "/stats/progress", handler: stats.GetProgressReport},
+		{golden: "stats_trends", route: "/stats/trends", target: "/stats/trends?period=week&count=2", handler: stats.GetTrends},
+		{golden: "stats_trends_half_range", route: "/stats/trends", target: "/stats/trends?start_date=2024-03-01", handler: stats.GetTrends},
+	})
+}
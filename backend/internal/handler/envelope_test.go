@@ -0,0 +1,448 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/middleware"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	customvalidator "github.com/ai-fitness-planner/backend/internal/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// The envelope tests pin the JSON each endpoint answers with, status code
+// and all, to the files in testdata/golden. After an intended change to a
+// response, rewrite them with
+//
+//	go test ./internal/handler/ -run Envelope -update
+//
+// and review the diff.
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// envelopeUserID is the authenticated user of every request
+const envelopeUserID int64 = 7
+
+// envelopeZone is the zone fixture instants are created in; timestamps must
+// come out in UTC regardless
+var envelopeZone = time.FixedZone("CST", 8*3600)
+
+var (
+	snakeCaseKey = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	timestampish = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T`)
+	utcTimestamp = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`)
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	logger.Logger = zap.NewNop()
+	// Request bodies are bound with the custom tags main registers
+	v := binding.Validator.Engine().(*validator.Validate)
+	_ = v.RegisterValidation("password_strength", customvalidator.ValidatePasswordStrength)
+	_ = v.RegisterValidation("email_format", customvalidator.ValidateEmailFormat)
+	_ = v.RegisterValidation("macro_ratio", customvalidator.ValidateMacroRatio)
+	_ = v.RegisterValidation("future_date", customvalidator.ValidateNotFutureDate)
+	_ = v.RegisterValidation("avatar", customvalidator.ValidateAvatar)
+	os.Exit(m.Run())
+}
+
+// envelopeCase is a request to a single handler and the golden file its
+// response is compared with
+type envelopeCase struct {
+	golden string
+	// method defaults to GET; body, when set, is sent as JSON
+	method  string
+	route   string
+	target  string
+	body    string
+	handler gin.HandlerFunc
+	// expired runs the request with a deadline that has already passed
+	expired bool
+}
+
+func (tc envelopeCase) run(t *testing.T) {
+	method := tc.method
+	if method == "" {
+		method = http.MethodGet
+	}
+	router := gin.New()
+	router.Handle(method, tc.route, func(c *gin.Context) {
+		c.Set(middleware.ContextKeyUserID, envelopeUserID)
+		c.Set(middleware.ContextKeyUsername, "lifter")
+	}, tc.handler)
+
+	req := httptest.NewRequest(method, tc.target, strings.NewReader(tc.body))
+	if tc.body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if tc.expired {
+		ctx, cancel := context.WithDeadline(req.Context(), time.Now().Add(-time.Second))
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body), rec.Body.String())
+
+	// The timestamp is the time of the request; only its presence is pinned
+	timestamp, ok := body["timestamp"].(float64)
+	require.True(t, ok, "envelope has no timestamp")
+	assert.Positive(t, timestamp)
+	body["timestamp"] = 0
+
+	auditFields(t, "", body)
+
+	got, err := json.MarshalIndent(map[string]interface{}{
+		"status": rec.Code,
+		"body":   body,
+	}, "", "  ")
+	require.NoError(t, err)
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", "golden", tc.golden+".json")
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+		return
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "run with -update to create the golden file")
+	assert.Equal(t, string(bytes.TrimSpace(want)), string(bytes.TrimSpace(got)))
+}
+
+// auditFields checks that every key of the response is snake_case and every
+// timestamp is RFC 3339 in UTC
+func auditFields(t *testing.T, path string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			assert.Regexp(t, snakeCaseKey, key, "key at %s", path)
+			auditFields(t, path+"."+key, child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			auditFields(t, path+"[]", child)
+		}
+	case string:
+		if timestampish.MatchString(v) {
+			assert.Regexp(t, utcTimestamp, v, "timestamp at %s", path)
+		}
+	}
+}
+
+// jsonMap parses a JSON object the way plan data comes back from the
+// database, with numbers as float64
+func jsonMap(t *testing.T, raw string) model.JSONMap {
+	var m model.JSONMap
+	require.NoError(t, json.Unmarshal([]byte(raw), &m))
+	return m
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// envelopeStatsService answers the statistics endpoints with fixed data
+type envelopeStatsService struct {
+	service.StatisticsService
+}
+
+func (envelopeStatsService) GetTrainingStatistics(ctx context.Context, userID int64, period string) (*service.TrainingStats, error) {
+	return &service.TrainingStats{
+		Period:            period,
+		StartDate:         time.Date(2024, 3, 4, 0, 0, 0, 0, envelopeZone),
+		EndDate:           time.Date(2024, 3, 10, 23, 59, 59, 0, envelopeZone),
+		TotalWorkouts:     3,
+		TotalDuration:     165,
+		TotalCalories:     1200,
+		AverageRating:     4.33,
+		WorkoutsByType:    map[string]int64{"strength": 2, "cardio": 1},
+		AverageDuration:   55,
+		HasSufficientData: true,
+	}, nil
+}
+
+func (envelopeStatsService) GetExerciseProgression(ctx context.Context, userID int64, exercise string, days int) (*service.ExerciseProgression, error) {
+	return &service.ExerciseProgression{
+		Exercise: exercise,
+		Days:     90,
+		Sessions: []service.ProgressionSession{
+			{
+				Date: time.Date(2024, 3, 4, 0, 0, 0, 0, envelopeZone), RecordID: 11, Sets: 3,
+				RepsPerSet: []int{5, 5, 5}, WeightUsed: []float64{100, 100, 100},
+				TopWeight: 100, TopReps: 5, TotalReps: 15, Volume: 1500, EstimatedOneRepMax: 116.7,
+			},
+			{
+				Date: time.Date(2024, 3, 7, 0, 0, 0, 0, envelopeZone), RecordID: 12, Sets: 3,
+				RepsPerSet: []int{5, 5, 5}, WeightUsed: []float64{105, 105, 105},
+				TopWeight: 105, TopReps: 5, TotalReps: 15, Volume: 1575, EstimatedOneRepMax: 122.5,
+			},
+		},
+		Trend: &service.ProgressionTrend{
+			StartOneRepMax: 116.7, EndOneRepMax: 122.5, Change: 5.8,
+			ChangePercent: 4.97, WeeklyChange: 13.5, Direction: "up",
+		},
+		Suggestion: &service.LoadSuggestion{
+			Action: service.ProgressionIncreaseLoad, Weight: 110, Sets: 3,
+			RepsLow: 1, RepsHigh: 5, TargetReps: 5, Reason: "上次完成了全部目标次数，增加重量",
+		},
+		HasSufficientData: true,
+	}, nil
+}
+
+func (envelopeStatsService) GetOneRepMaxReport(ctx context.Context, userID int64, days int) (*service.OneRepMaxReport, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), errors.ErrDatabase, "获取训练记录失败")
+	}
+	return &service.OneRepMaxReport{
+		Days:       90,
+		BodyWeight: ptr(80.0),
+		Gender:     string(model.GenderMale),
+		Lifts: []service.LiftOneRepMax{
+			{
+				Lift: model.LiftSquat, OneRepMax: 140, Source: service.OneRepMaxSourceRecords,
+				Epley: 140, Brzycki: 137.1,
+				BestSet: &service.BestSet{
+					RecordID: 12, Date: time.Date(2024, 3, 7, 0, 0, 0, 0, envelopeZone),
+					Exercise: "杠铃深蹲", Weight: 120, Reps: 5,
+				},
+				Level: &service.LiftLevel{
+					BodyweightRatio: 1.75, Level: service.StrengthLevelIntermediate,
+					NextLevel: service.StrengthLevelAdvanced, NextLevelOneRepMax: 180,
+				},
+			},
+			{
+				Lift: model.LiftBenchPress, OneRepMax: 90, Source: service.OneRepMaxSourceProfile,
+				Level: &service.LiftLevel{
+					BodyweightRatio: 1.13, Level: service.StrengthLevelNovice,
+					NextLevel: service.StrengthLevelIntermediate, NextLevelOneRepMax: 100,
+				},
+			},
+		},
+		HasSufficientData: true,
+	}, nil
+}
+
+// envelopeTrainingService answers the training plan and record endpoints
+// with fixed data; plan 404 does not exist
+type envelopeTrainingService struct {
+	service.TrainingService
+	t *testing.T
+}
+
+func (s envelopeTrainingService) plan(planID int64, withData bool) (*model.TrainingPlan, error) {
+	if planID == 404 {
+		return nil, errors.New(errors.ErrPlanNotFound, "训练计划不存在")
+	}
+	plan := &model.TrainingPlan{
+		ID:              planID,
+		UserID:          envelopeUserID,
+		PlanName:        "四周力量计划",
+		StartDate:       time.Date(2024, 3, 4, 0, 0, 0, 0, envelopeZone),
+		EndDate:         time.Date(2024, 3, 31, 0, 0, 0, 0, envelopeZone),
+		TotalWeeks:      4,
+		DifficultyLevel: "medium",
+		TrainingPurpose: ptr("增肌"),
+		Version:         2,
+		Status:          "paused",
+		PausedAt:        ptr(time.Date(2024, 3, 12, 8, 30, 0, 0, envelopeZone)),
+		CreatedAt:       time.Date(2024, 3, 3, 21, 15, 0, 0, envelopeZone),
+		UpdatedAt:       time.Date(2024, 3, 12, 8, 30, 0, 0, envelopeZone),
+	}
+	if withData {
+		plan.PlanData = jsonMap(s.t, `{"weeks": [{"week": 1, "days": [
+			{"day": 1, "date": "2024-03-04", "type": "strength", "focus_area": "lower_body", "duration": 60, "estimated_calories": 400,
+			 "exercises": [{"name": "杠铃深蹲", "sets": 5, "reps": "5", "weight": "100kg", "rest": "180s", "difficulty": "medium", "tempo": "3-1-1-0"},
+			  {"name": "罗马尼亚硬拉", "sets": 3, "reps": "10", "weight": "60kg", "rest": "90s", "superset_id": "A"},
+			  {"name": "平板支撑", "sets": 3, "reps": "45s", "weight": "bodyweight", "rest": "90s", "superset_id": "A"}]},
+			{"day": 2, "date": "2024-03-05", "type": "rest"},
+			{"day": 3, "date": "2024-03-06", "type": "cardio", "focus_area": "full_body", "duration": 30, "estimated_calories": 300, "circuit_rounds": 4,
+			 "exercises": [{"name": "波比跳", "sets": 1, "reps": "10", "weight": "bodyweight", "rest": "0s"}, {"name": "登山跑", "sets": 1, "reps": "30s", "weight": "bodyweight", "rest": "60s"}]}
+		]}]}`)
+	}
+	return plan, nil
+}
+
+func (s envelopeTrainingService) GetPlanDetail(ctx context.Context, planID int64, userID int64) (*model.TrainingPlan, error) {
+	return s.plan(planID, true)
+}
+
+// GetRestIntervals customizes the squat's rest in the plan fixtures
+func (s envelopeTrainingService) GetRestIntervals(ctx context.Context, userID int64) (model.RestIntervals, error) {
+	return model.RestIntervals{Strength: 150, Hypertrophy: 75}, nil
+}
+
+func (s envelopeTrainingService) GetPlanSummary(ctx context.Context, planID int64, userID int64) (*model.TrainingPlan, error) {
+	return s.plan(planID, false)
+}
+
+func (s envelopeTrainingService) ListPlanVersions(ctx context.Context, userID, planID int64) ([]*model.TrainingPlanVersion, error) {
+	return []*model.TrainingPlanVersion{
+		{ID: 21, PlanID: planID, Version: 1, Week: ptr(2), Feedback: ptr("这周出差，只能用酒店健身房"), CreatedAt: time.Date(2024, 3, 10, 20, 0, 0, 0, envelopeZone)},
+	}, nil
+}
+
+func (s envelopeTrainingService) GetPlanVersion(ctx context.Context, userID, planID int64, version int) (*model.TrainingPlanVersion, error) {
+	if version != 1 {
+		return nil, errors.New(errors.ErrNotFound, "计划历史版本不存在")
+	}
+	return &model.TrainingPlanVersion{
+		ID: 21, PlanID: planID, Version: 1, Week: ptr(2),
+		PlanData: jsonMap(s.t, `{"weeks": [{"week": 1, "days": [
+			{"day": 1, "date": "2024-03-04", "type": "strength", "focus_area": "lower_body", "duration": 60,
+			 "exercises": [{"name": "杠铃深蹲", "sets": 5, "reps": "5", "weight": "100kg", "rest": "180s"}]}
+		]}, {"week": 2, "deload": true, "days": [{"day": 1, "date": "2024-03-11", "type": "rest"}]}]}`),
+		CreatedAt: time.Date(2024, 3, 10, 20, 0, 0, 0, envelopeZone),
+	}, nil
+}
+
+func (s envelopeTrainingService) GetTrainingHistory(ctx context.Context, userID int64, startDate, endDate *time.Time) ([]*model.TrainingRecord, error) {
+	return []*model.TrainingRecord{
+		{
+			ID:              12,
+			UserID:          userID,
+			ClientID:        "5d8f3c1e-0a4b-4c2d-9e7f-1a2b3c4d5e6f",
+			PlanID:          ptr(int64(3)),
+			WorkoutDate:     time.Date(2024, 3, 7, 0, 0, 0, 0, envelopeZone),
+			WorkoutType:     "strength",
+			DurationMinutes: ptr(62),
+			StartedAt:       ptr(time.Date(2024, 3, 7, 18, 0, 0, 0, envelopeZone)),
+			EndedAt:         ptr(time.Date(2024, 3, 7, 19, 2, 0, 0, envelopeZone)),
+			Exercises: jsonMap(s.t, `{"exercises": [
+				{"exercise_name": "杠铃深蹲", "sets_completed": 3, "reps_per_set": [5, 5, 5], "weight_used": [105, 105, 105]}
+			]}`),
+			Rating:    ptr(4),
+			CreatedAt: time.Date(2024, 3, 7, 19, 5, 0, 0, envelopeZone),
+		},
+		{
+			ID:           13,
+			UserID:       userID,
+			ClientID:     "0f9e8d7c-6b5a-4c3d-2e1f-0a9b8c7d6e5f",
+			WorkoutDate:  time.Date(2024, 3, 9, 0, 0, 0, 0, envelopeZone),
+			WorkoutType:  "cardio",
+			DurationFlag: ptr("estimated"),
+			Notes:        ptr("晨跑"),
+			CreatedAt:    time.Date(2024, 3, 9, 7, 40, 0, 0, envelopeZone),
+		},
+	}, nil
+}
+
+// envelopeNutritionService answers the nutrition plan endpoints with fixed
+// data
+type envelopeNutritionService struct {
+	service.NutritionService
+	t *testing.T
+}
+
+func (s envelopeNutritionService) plan(planID int64, withData bool) (*model.NutritionPlan, error) {
+	plan := &model.NutritionPlan{
+		ID:            planID,
+		UserID:        envelopeUserID,
+		PlanName:      "减脂饮食计划",
+		StartDate:     time.Date(2024, 3, 4, 0, 0, 0, 0, envelopeZone),
+		EndDate:       time.Date(2024, 3, 10, 0, 0, 0, 0, envelopeZone),
+		DailyCalories: 2100,
+		ProteinRatio:  0.3,
+		CarbRatio:     0.45,
+		FatRatio:      0.25,
+		AIAPIID:       1,
+		Status:        "active",
+		CreatedAt:     time.Date(2024, 3, 3, 21, 20, 0, 0, envelopeZone),
+		UpdatedAt:     time.Date(2024, 3, 3, 21, 20, 0, 0, envelopeZone),
+	}
+	if withData {
+		plan.PlanData = jsonMap(s.t, `{"days": [{"day": 1, "date": "2024-03-04",
+			"meals": {"breakfast": {"time": "07:30", "total_calories": 450,
+				"foods": [{"name": "燕麦", "amount": "60g", "calories": 230, "protein": 8, "carbs": 40, "fat": 4}]}},
+			"daily_totals": {"calories": 2100, "protein": 158, "carbs": 236, "fat": 58}
+		}]}`)
+	}
+	return plan, nil
+}
+
+func (s envelopeNutritionService) GetPlanDetail(ctx context.Context, planID int64, userID int64) (*model.NutritionPlan, error) {
+	return s.plan(planID, true)
+}
+
+func (s envelopeNutritionService) GetPlanSummary(ctx context.Context, planID int64, userID int64) (*model.NutritionPlan, error) {
+	return s.plan(planID, false)
+}
+
+// envelopeShareService opens share links with fixed data; only the token
+// "valid" grants access
+type envelopeShareService struct {
+	service.PlanShareService
+	t *testing.T
+}
+
+func (s envelopeShareService) GetSharedPlan(ctx context.Context, token string) (*service.SharedPlan, error) {
+	if token != "valid" {
+		return nil, errors.New(errors.ErrNotFound, "分享链接无效或已过期")
+	}
+	plan, err := envelopeTrainingService{t: s.t}.plan(3, true)
+	if err != nil {
+		return nil, err
+	}
+	// Owner data the response must leave out
+	plan.PlanData = jsonMap(s.t, `{"weeks": [{"week": 1, "days": [
+		{"day": 1, "date": "2024-03-04", "type": "strength", "focus_area": "lower_body", "duration": 60, "is_completed": true,
+		 "exercises": [{"name": "腿举", "sets": 4, "reps": "10", "weight": "120kg", "rest": "120s", "substituted_for": "杠铃深蹲"}]}
+	]}], "applied_constraints": [{"body_part": "knee"}]}`)
+	return &service.SharedPlan{
+		Plan:      plan,
+		ExpiresAt: time.Date(2024, 4, 3, 21, 15, 0, 0, envelopeZone),
+	}, nil
+}
+
+func TestEnvelope(t *testing.T) {
+	stats := NewStatisticsHandler(envelopeStatsService{})
+	training := NewTrainingHandler(envelopeTrainingService{t: t})
+	nutrition := NewNutritionHandler(envelopeNutritionService{t: t})
+	share := NewPlanShareHandler(envelopeShareService{t: t})
+
+	cases := []envelopeCase{
+		{golden: "stats_training", route: "/stats/training", target: "/stats/training?period=week", handler: stats.GetTrainingStatistics},
+		{golden: "stats_progression", route: "/stats/progression", target: "/stats/progression?exercise=%E6%B7%B1%E8%B9%B2", handler: stats.GetProgression},
+		{golden: "stats_progression_invalid", route: "/stats/progression", target: "/stats/progression?days=3", handler: stats.GetProgression},
+		{golden: "stats_one_rep_max", route: "/stats/one-rep-max", target: "/stats/one-rep-max", handler: stats.GetOneRepMax},
+		{golden: "stats_one_rep_max_timeout", route: "/stats/one-rep-max", target: "/stats/one-rep-max", handler: stats.GetOneRepMax, expired: true},
+		{golden: "training_plan_summary", route: "/training-plans/:id", target: "/training-plans/3", handler: training.GetPlanDetail},
+		{golden: "training_plan_detail", route: "/training-plans/:id", target: "/training-plans/3?include=plan_data", handler: training.GetPlanDetail},
+		{golden: "training_plan_not_found", route: "/training-plans/:id", target: "/training-plans/404", handler: training.GetPlanDetail},
+		{golden: "training_plan_versions", route: "/training-plans/:id/versions", target: "/training-plans/3/versions", handler: training.ListPlanVersions},
+		{golden: "training_plan_version", route: "/training-plans/:id/versions/:version", target: "/training-plans/3/versions/1", handler: training.GetPlanVersion},
+		{golden: "training_plan_version_not_found", route: "/training-plans/:id/versions/:version", target: "/training-plans/3/versions/5", handler: training.GetPlanVersion},
+		{golden: "shared_training_plan", route: "/shared/training-plans/:token", target: "/shared/training-plans/valid", handler: share.GetSharedPlan},
+		{golden: "shared_training_plan_invalid", route: "/shared/training-plans/:token", target: "/shared/training-plans/forged", handler: share.GetSharedPlan},
+		{golden: "training_records", route: "/training-records", target: "/training-records", handler: training.ListTrainingRecords},
+		{golden: "nutrition_plan_detail", route: "/nutrition-plans/:id", target: "/nutrition-plans/5?include=plan_data", handler: nutrition.GetPlanDetail},
+	}
+	runEnvelopeCases(t, cases)
+}
+
+// runEnvelopeCases runs each case as a subtest named after its golden file
+func runEnvelopeCases(t *testing.T, cases []envelopeCase) {
+	for _, tc := range cases {
+		t.Run(tc.golden, tc.run)
+	}
+}
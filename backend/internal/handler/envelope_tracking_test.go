@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"gorm.io/gorm"
+)
+
+// envelopeCheckInService holds one check-in for the week of 2024-03-04
+type envelopeCheckInService struct {
+	service.CheckInService
+}
+
+func (envelopeCheckInService) checkIn() *model.WeeklyCheckIn {
+	return &model.WeeklyCheckIn{
+		ID: 21, UserID: envelopeUserID, WeekStart: time.Date(2024, 3, 4, 0, 0, 0, 0, envelopeZone),
+		Weight: ptr(80.5), AdherenceRating: 4, EnergyLevel: 3, HungerLevel: 2, Notes: ptr("周三加班少练一次"),
+		Photos:    model.JSONSlice{"https://cdn.example.com/check-ins/21-front.jpg"},
+		CreatedAt: time.Date(2024, 3, 10, 20, 0, 0, 0, envelopeZone),
+		UpdatedAt: time.Date(2024, 3, 10, 20, 5, 0, 0, envelopeZone),
+	}
+}
+
+func (s envelopeCheckInService) SubmitCheckIn(ctx context.Context, userID int64, req *service.CheckInRequest) (*model.WeeklyCheckIn, error) {
+	return s.checkIn(), nil
+}
+
+func (s envelopeCheckInService) ListCheckIns(ctx context.Context, userID int64, limit, offset int) ([]*model.WeeklyCheckIn, int64, error) {
+	return []*model.WeeklyCheckIn{s.checkIn()}, 1, nil
+}
+
+func (s envelopeCheckInService) GetLatestCheckIn(ctx context.Context, userID int64) (*model.WeeklyCheckIn, error) {
+	return s.checkIn(), nil
+}
+
+// envelopeNotificationService holds one read and one unread notification
+type envelopeNotificationService struct {
+	service.NotificationService
+}
+
+func (envelopeNotificationService) List(ctx context.Context, userID int64, unreadOnly bool, limit, offset int) ([]*model.UserNotification, int64, error) {
+	return []*model.UserNotification{
+		{
+			ID: 62, UserID: userID, Type: "check_in_reminder", Title: "本周打卡提醒",
+			Data: model.JSONMap{"week_start": "2024-03-04"}, CreatedAt: time.Date(2024, 3, 10, 9, 0, 0, 0, envelopeZone),
+		},
+		{
+			ID: 61, UserID: userID, Type: "plan_ready", Title: "训练计划已生成", Content: ptr("你的增肌计划已经准备好了"),
+			Data:      model.JSONMap{"plan_id": float64(3)},
+			ReadAt:    ptr(time.Date(2024, 3, 3, 21, 30, 0, 0, envelopeZone)),
+			CreatedAt: time.Date(2024, 3, 3, 21, 20, 0, 0, envelopeZone),
+		},
+	}, 2, nil
+}
+
+func (envelopeNotificationService) MarkRead(ctx context.Context, userID, notificationID int64) (*model.UserNotification, error) {
+	if notificationID != 62 {
+		return nil, errors.New(errors.ErrNotFound, "通知不存在")
+	}
+	return &model.UserNotification{
+		ID: 62, UserID: userID, Type: "check_in_reminder", Title: "本周打卡提醒",
+		Data:      model.JSONMap{"week_start": "2024-03-04"},
+		ReadAt:    ptr(time.Date(2024, 3, 10, 12, 0, 0, 0, envelopeZone)),
+		CreatedAt: time.Date(2024, 3, 10, 9, 0, 0, 0, envelopeZone),
+	}, nil
+}
+
+// envelopeStrengthService holds a squat and bench 1RM
+type envelopeStrengthService struct {
+	service.StrengthProfileService
+}
+
+func (envelopeStrengthService) GetProfile(ctx context.Context, userID int64) ([]*model.StrengthProfileEntry, error) {
+	return []*model.StrengthProfileEntry{
+		{
+			ID: 1, UserID: userID, Lift: model.LiftSquat, OneRepMax: 140, Source: "record", SourceRecordID: ptr(int64(101)),
+			AchievedAt: time.Date(2024, 3, 6, 0, 0, 0, 0, envelopeZone),
+			UpdatedAt:  time.Date(2024, 3, 6, 19, 40, 0, 0, envelopeZone),
+		},
+		{
+			ID: 2, UserID: userID, Lift: model.LiftBenchPress, OneRepMax: 100, Source: "manual",
+			AchievedAt: time.Date(2024, 2, 20, 0, 0, 0, 0, envelopeZone),
+			UpdatedAt:  time.Date(2024, 2, 20, 21, 0, 0, 0, envelopeZone),
+		},
+	}, nil
+}
+
+func (envelopeStrengthService) SetOneRepMax(ctx context.Context, userID int64, lift string, oneRepMax float64, achievedAt time.Time) (*model.StrengthProfileEntry, error) {
+	return &model.StrengthProfileEntry{
+		ID: 3, UserID: userID, Lift: lift, OneRepMax: oneRepMax, Source: "manual", AchievedAt: achievedAt,
+		UpdatedAt: time.Date(2024, 3, 8, 7, 0, 0, 0, envelopeZone),
+	}, nil
+}
+
+// envelopeEquipmentService holds a home and a gym profile
+type envelopeEquipmentService struct {
+	service.EquipmentService
+}
+
+func (envelopeEquipmentService) ListProfiles(ctx context.Context, userID int64) ([]*model.EquipmentProfile, error) {
+	return []*model.EquipmentProfile{
+		{
+			ID: 11, UserID: userID, Name: "健身房", LocationType: "gym", IsDefault: true,
+			Equipment: model.JSONSlice{"barbell", "cable_machine"}, Weekdays: model.JSONSlice{float64(1), float64(3), float64(5)},
+			UpdatedAt: time.Date(2024, 2, 1, 12, 0, 0, 0, envelopeZone),
+		},
+		{
+			ID: 12, UserID: userID, Name: "家里", LocationType: "home",
+			Equipment: model.JSONSlice{"dumbbell"}, Weekdays: model.JSONSlice{float64(6)},
+			UpdatedAt: time.Date(2024, 2, 1, 12, 5, 0, 0, envelopeZone),
+		},
+	}, nil
+}
+
+func (envelopeEquipmentService) CreateProfile(ctx context.Context, userID int64, req *service.EquipmentProfileRequest) (*model.EquipmentProfile, error) {
+	if *req.Name == "健身房" {
+		return nil, errors.New(errors.ErrConflict, "器材清单名称已存在")
+	}
+	profile := &model.EquipmentProfile{
+		ID: 13, UserID: userID, Name: *req.Name, LocationType: "other",
+		UpdatedAt: time.Date(2024, 3, 8, 7, 0, 0, 0, envelopeZone),
+	}
+	if req.LocationType != nil {
+		profile.LocationType = *req.LocationType
+	}
+	for _, e := range req.Equipment {
+		profile.Equipment = append(profile.Equipment, e)
+	}
+	for _, d := range req.Weekdays {
+		profile.Weekdays = append(profile.Weekdays, d)
+	}
+	return profile, nil
+}
+
+// envelopeConstraintService holds one lapsed and one open-ended constraint,
+// so is_active does not depend on the day the test runs
+type envelopeConstraintService struct {
+	service.TrainingConstraintService
+}
+
+func (envelopeConstraintService) List(ctx context.Context, userID int64, activeOnly bool) ([]*model.TrainingConstraint, error) {
+	return []*model.TrainingConstraint{
+		{
+			ID: 5, UserID: userID, Source: "physio", Description: "右肩撞击综合征",
+			RestrictedMovements: model.JSONSlice{"overhead_press"},
+			Attachments:         model.JSONSlice{"https://cdn.example.com/constraints/5.pdf"},
+			StartsOn:            time.Date(2024, 3, 1, 0, 0, 0, 0, envelopeZone),
+			UpdatedAt:           time.Date(2024, 3, 1, 18, 0, 0, 0, envelopeZone),
+		},
+		{
+			ID: 4, UserID: userID, Source: "doctor", Description: "脚踝扭伤",
+			RestrictedMovements: model.JSONSlice{"box_jump"},
+			StartsOn:            time.Date(2024, 1, 10, 0, 0, 0, 0, envelopeZone),
+			ExpiresOn:           ptr(time.Date(2024, 1, 31, 0, 0, 0, 0, envelopeZone)),
+			UpdatedAt:           time.Date(2024, 1, 10, 18, 0, 0, 0, envelopeZone),
+		},
+	}, nil
+}
+
+func (envelopeConstraintService) Create(ctx context.Context, userID int64, req *service.TrainingConstraintRequest) (*model.TrainingConstraint, error) {
+	constraint := &model.TrainingConstraint{
+		ID: 6, UserID: userID, Source: "other", Description: *req.Description,
+		StartsOn:  *req.StartsOn,
+		ExpiresOn: req.ExpiresOn,
+		UpdatedAt: time.Date(2024, 3, 8, 7, 0, 0, 0, envelopeZone),
+	}
+	for _, m := range req.RestrictedMovements {
+		constraint.RestrictedMovements = append(constraint.RestrictedMovements, m)
+	}
+	return constraint, nil
+}
+
+// envelopeSyncService returns a pushed record, a tombstone, a conflict and a
+// rejection
+type envelopeSyncService struct {
+	service.SyncService
+}
+
+func (envelopeSyncService) Sync(ctx context.Context, userID int64, req *service.SyncRequest) (*service.SyncResult, error) {
+	return &service.SyncResult{
+		ServerTime: time.Date(2024, 3, 8, 20, 0, 0, 0, envelopeZone),
+		TrainingRecords: []*model.TrainingRecord{
+			{
+				ID: 101, UserID: userID, ClientID: "0b8e7f7e-3c1a-4c8e-9a53-1d2f3e4a5b6c", PlanID: ptr(int64(3)),
+				WorkoutDate: time.Date(2024, 3, 6, 0, 0, 0, 0, envelopeZone), WorkoutType: "力量训练", DurationMinutes: ptr(60),
+				StartedAt: ptr(time.Date(2024, 3, 6, 18, 30, 0, 0, envelopeZone)),
+				EndedAt:   ptr(time.Date(2024, 3, 6, 19, 30, 0, 0, envelopeZone)),
+				Exercises: model.JSONMap{"squat": map[string]interface{}{"sets": float64(5), "reps": float64(5), "weight": float64(120)}},
+				Rating:    ptr(4),
+				UpdatedAt: time.Date(2024, 3, 8, 20, 0, 0, 0, envelopeZone),
+			},
+		},
+		NutritionRecords: []*model.NutritionRecord{
+			{
+				ID: 51, UserID: userID, ClientID: "9a8b7c6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d",
+				MealDate: time.Date(2024, 3, 4, 0, 0, 0, 0, envelopeZone), MealTime: "breakfast", Foods: model.JSONMap{},
+				UpdatedAt: time.Date(2024, 3, 7, 8, 0, 0, 0, envelopeZone),
+				DeletedAt: gorm.DeletedAt{Time: time.Date(2024, 3, 7, 8, 0, 0, 0, envelopeZone), Valid: true},
+			},
+		},
+		Conflicts: []service.SyncConflict{{Entity: "training_record", ClientID: "0b8e7f7e-3c1a-4c8e-9a53-1d2f3e4a5b6c", Resolution: "client_wins"}},
+		Rejected:  []service.SyncRejection{{Entity: "nutrition_record", ClientID: "5f4e3d2c-1b0a-4988-8776-655443322110", Reason: "卡路里超出范围"}},
+	}, nil
+}
+
+// envelopeAssessmentRepo stores assessments with a fixed ID and creation time
+type envelopeAssessmentRepo struct {
+	repository.AssessmentRepository
+}
+
+func (envelopeAssessmentRepo) Create(ctx context.Context, assessment *model.FitnessAssessment) error {
+	assessment.ID = 17
+	assessment.CreatedAt = time.Date(2024, 3, 1, 10, 0, 0, 0, envelopeZone)
+	return nil
+}
+
+func (envelopeAssessmentRepo) GetLatest(ctx context.Context, userID int64) (*model.FitnessAssessment, error) {
+	return &model.FitnessAssessment{
+		ID: 17, UserID: userID, ExperienceLevel: "intermediate", WeeklyAvailableDays: 4, DailyAvailableMinutes: 75,
+		ActivityType: ptr("力量训练"), PreferredDays: model.JSONSlice{"monday", "wednesday", "friday"},
+		EquipmentAvailable: model.JSONSlice{"barbell", "dumbbell"},
+		AssessmentDate:     time.Date(2024, 3, 1, 0, 0, 0, 0, envelopeZone),
+		CreatedAt:          time.Date(2024, 3, 1, 10, 0, 0, 0, envelopeZone),
+	}, nil
+}
+
+func TestEnvelope_Tracking(t *testing.T) {
+	checkIns := NewCheckInHandler(envelopeCheckInService{})
+	notifications := NewNotificationHandler(envelopeNotificationService{})
+	strength := NewStrengthHandler(envelopeStrengthService{})
+	equipment := NewEquipmentHandler(envelopeEquipmentService{})
+	constraints := NewTrainingConstraintHandler(envelopeConstraintService{})
+	syncer := NewSyncHandler(envelopeSyncService{})
+	assessments := NewAssessmentHandler(envelopeAssessmentRepo{})
+
+	runEnvelopeCases(t, []envelopeCase{
+		{golden: "check_in_submitted", method: http.MethodPost, route: "/check-ins", target: "/check-ins",
+			body: `{"week_of": "2024-03-06", "weight": 80.5, "adherence_rating": 4, "energy_level": 3, "hunger_level": 2}`, handler: checkIns.SubmitCheckIn},
+		{golden: "check_in_invalid_rating", method: http.MethodPost, route: "/check-ins", target: "/check-ins",
+			body: `{"adherence_rating": 6, "energy_level": 3, "hunger_level": 2}`, handler: checkIns.SubmitCheckIn},
+		{golden: "check_ins", route: "/check-ins", target: "/check-ins", handler: checkIns.ListCheckIns},
+		{golden: "check_in_latest", route: "/check-ins/latest", target: "/check-ins/latest", handler: checkIns.GetLatestCheckIn},
+		{golden: "notifications", route: "/notifications", target: "/notifications", handler: notifications.ListNotifications},
+		{golden: "notification_read", method: http.MethodPost, route: "/notifications/:id/read", target: "/notifications/62/read", handler: notifications.MarkNotificationRead},
+		{golden: "notification_read_not_found", method: http.MethodPost, route: "/notifications/:id/read", target: "/notifications/60/read", handler: notifications.MarkNotificationRead},
+		{golden: "strength_profile", route: "/strength-profile", target: "/strength-profile", handler: strength.GetStrengthProfile},
+		{golden: "strength_one_rep_max", method: http.MethodPut, route: "/strength-profile/:lift", target: "/strength-profile/deadlift",
+			body: `{"one_rep_max": 180, "achieved_at": "2024-03-07"}`, handler: strength.SetOneRepMax},
+		{golden: "equipment_profiles", route: "/equipment-profiles", target: "/equipment-profiles", handler: equipment.ListEquipmentProfiles},
+		{golden: "equipment_profile_created", method: http.MethodPost, route: "/equipment-profiles", target: "/equipment-profiles",
+			body: `{"name": "酒店", "location_type": "other", "equipment": ["dumbbell"], "weekdays": [0]}`, handler: equipment.CreateEquipmentProfile},
+		{golden: "equipment_profile_name_taken", method: http.MethodPost, route: "/equipment-profiles", target: "/equipment-profiles",
+			body: `{"name": "健身房"}`, handler: equipment.CreateEquipmentProfile},
+		{golden: "training_constraints", route: "/training-constraints", target: "/training-constraints", handler: constraints.ListConstraints},
+		{golden: "training_constraint_created", method: http.MethodPost, route: "/training-constraints", target: "/training-constraints",
+			body:    `{"description": "腰椎间盘突出", "restricted_movements": ["deadlift"], "starts_on": "2024-03-08", "expires_on": "2024-03-31"}`,
+			handler: constraints.CreateConstraint},
+		{golden: "sync", method: http.MethodPost, route: "/sync", target: "/sync",
+			body: `{"last_synced_at": "2024-03-06T10:00:00Z"}`, handler: syncer.Sync},
+		{golden: "sync_invalid_cursor", method: http.MethodPost, route: "/sync", target: "/sync",
+			body: `{"last_synced_at": "2024-03-06"}`, handler: syncer.Sync},
+		{golden: "assessment_created", method: http.MethodPost, route: "/assessments", target: "/assessments",
+			body:    `{"experience_level": "intermediate", "weekly_available_days": 4, "daily_available_minutes": 75, "preferred_days": ["monday"], "assessment_date": "2024-03-01"}`,
+			handler: assessments.CreateAssessment},
+		{golden: "assessment_latest", route: "/assessments/latest", target: "/assessments/latest", handler: assessments.GetLatestAssessment},
+	})
+}
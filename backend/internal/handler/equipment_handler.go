@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ai-fitness-planner/backend/internal/api/request"
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// EquipmentHandler handles equipment inventory HTTP requests
+type EquipmentHandler struct {
+	*BaseHandler
+	equipmentService service.EquipmentService
+}
+
+// NewEquipmentHandler creates a new EquipmentHandler instance
+func NewEquipmentHandler(equipmentService service.EquipmentService) *EquipmentHandler {
+	return &EquipmentHandler{
+		BaseHandler:      NewBaseHandler(),
+		equipmentService: equipmentService,
+	}
+}
+
+// ListEquipmentProfiles handles GET /api/v1/equipment-profiles
+// @Summary List equipment profiles
+// @Description Equipment the user has at each location (home, gym, ...) and the weekdays (0 = Sunday) they train there, default profile first
+// @Tags Equipment
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.EquipmentProfileListResponse "Equipment profiles"
+// @Failure 401 {object} response.BaseResponse "Unauthorized"
+// @Router /equipment-profiles [get]
+func (h *EquipmentHandler) ListEquipmentProfiles(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	profiles, err := h.equipmentService.ListProfiles(c.Request.Context(), userID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	infos := make([]response.EquipmentProfileInfo, 0, len(profiles))
+	for _, p := range profiles {
+		infos = append(infos, toEquipmentProfileInfo(p))
+	}
+
+	h.Success(c, response.EquipmentProfileListResponse{Profiles: infos})
+}
+
+// CreateEquipmentProfile handles POST /api/v1/equipment-profiles
+// @Summary Create equipment profile
+// @Tags Equipment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request.CreateEquipmentProfileRequest true "Equipment profile"
+// @Success 201 {object} response.EquipmentProfileInfo "Created profile"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 409 {object} response.BaseResponse "Name already in use"
+// @Router /equipment-profiles [post]
+func (h *EquipmentHandler) CreateEquipmentProfile(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.CreateEquipmentProfileRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	profile, err := h.equipmentService.CreateProfile(c.Request.Context(), userID, &service.EquipmentProfileRequest{
+		Name:         &req.Name,
+		LocationType: req.LocationType,
+		Equipment:    req.Equipment,
+		Weekdays:     req.Weekdays,
+		IsDefault:    req.IsDefault,
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Created(c, toEquipmentProfileInfo(profile))
+}
+
+// UpdateEquipmentProfile handles PUT /api/v1/equipment-profiles/:id
+// @Summary Update equipment profile
+// @Description Omitted fields are left unchanged; equipment and weekdays, when given, replace the whole list. Weekdays assigned here are removed from the user's other profiles.
+// @Tags Equipment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Equipment profile ID"
+// @Param request body request.UpdateEquipmentProfileRequest true "Fields to update"
+// @Success 200 {object} response.EquipmentProfileInfo "Updated profile"
+// @Failure 404 {object} response.BaseResponse "Profile not found"
+// @Router /equipment-profiles/{id} [put]
+func (h *EquipmentHandler) UpdateEquipmentProfile(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	profileID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的器材清单ID")
+		return
+	}
+
+	var req request.UpdateEquipmentProfileRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	profile, err := h.equipmentService.UpdateProfile(c.Request.Context(), userID, profileID, &service.EquipmentProfileRequest{
+		Name:         req.Name,
+		LocationType: req.LocationType,
+		Equipment:    req.Equipment,
+		Weekdays:     req.Weekdays,
+		IsDefault:    req.IsDefault,
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, toEquipmentProfileInfo(profile))
+}
+
+// DeleteEquipmentProfile handles DELETE /api/v1/equipment-profiles/:id
+// @Summary Delete equipment profile
+// @Tags Equipment
+// @Security BearerAuth
+// @Param id path int true "Equipment profile ID"
+// @Success 204 "Deleted"
+// @Failure 404 {object} response.BaseResponse "Profile not found"
+// @Router /equipment-profiles/{id} [delete]
+func (h *EquipmentHandler) DeleteEquipmentProfile(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	profileID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的器材清单ID")
+		return
+	}
+
+	if err := h.equipmentService.DeleteProfile(c.Request.Context(), userID, profileID); err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.NoContent(c)
+}
+
+// toEquipmentProfileInfo converts an equipment profile to its response DTO
+func toEquipmentProfileInfo(p *model.EquipmentProfile) response.EquipmentProfileInfo {
+	equipment := make([]string, 0, len(p.Equipment))
+	for _, e := range p.Equipment {
+		equipment = append(equipment, fmt.Sprint(e))
+	}
+	weekdays := make([]int, 0, len(p.Weekdays))
+	for _, d := range p.WeekdayList() {
+		weekdays = append(weekdays, int(d))
+	}
+	return response.EquipmentProfileInfo{
+		ID:           p.ID,
+		Name:         p.Name,
+		LocationType: p.LocationType,
+		Equipment:    equipment,
+		Weekdays:     weekdays,
+		IsDefault:    p.IsDefault,
+		UpdatedAt:    response.FormatTime(p.UpdatedAt),
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/api/request"
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	apperrors "github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/pkg/jobqueue"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler handles data export HTTP requests
+type ExportHandler struct {
+	*BaseHandler
+	exportService service.ExportService
+}
+
+// NewExportHandler creates a new ExportHandler instance
+func NewExportHandler(exportService service.ExportService) *ExportHandler {
+	return &ExportHandler{
+		BaseHandler:   NewBaseHandler(),
+		exportService: exportService,
+	}
+}
+
+// Export handles GET /api/v1/exports/:kind
+// Small exports are returned as a CSV download; larger ones are queued and
+// answered with 202 and a task ID to poll. Sending an X-Export-Passphrase
+// header encrypts the file with that passphrase (AES-256-GCM, scrypt key).
+func (h *ExportHandler) Export(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var params request.ExportParams
+	if !h.BindQuery(c, &params) {
+		return
+	}
+	if !h.ValidateDateRange(c, params.StartDate, params.EndDate) {
+		return
+	}
+
+	req := &service.ExportRequest{
+		Kind:       c.Param("kind"),
+		Passphrase: c.GetHeader("X-Export-Passphrase"),
+	}
+	if params.StartDate != "" {
+		t, _ := time.ParseInLocation("2006-01-02", params.StartDate, time.Local)
+		req.StartDate = &t
+	}
+	if params.EndDate != "" {
+		t, _ := time.ParseInLocation("2006-01-02", params.EndDate, time.Local)
+		req.EndDate = &t
+	}
+
+	outcome, err := h.exportService.Export(c.Request.Context(), userID, req)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	if outcome.File != nil {
+		h.sendFile(c, outcome.File)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, response.Success(response.TaskResponse{
+		TaskID: outcome.TaskID,
+		Status: jobqueue.StatusPending,
+	}))
+}
+
+// GetExportTask handles GET /api/v1/exports/tasks/:taskId
+func (h *ExportHandler) GetExportTask(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.exportService.GetExportTask(c.Request.Context(), userID, c.Param("taskId"))
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	resp := response.TaskResponse{
+		TaskID:       job.ID,
+		Status:       job.Status,
+		ErrorMessage: job.Error,
+	}
+	if job.Status == jobqueue.StatusCompleted {
+		resp.Progress = 100
+		resp.Result = gin.H{"download_url": fmt.Sprintf("/api/v1/exports/tasks/%s/download", job.ID)}
+	}
+
+	h.Success(c, resp)
+}
+
+// DownloadExport handles GET /api/v1/exports/tasks/:taskId/download
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.exportService.GetExportTask(c.Request.Context(), userID, c.Param("taskId"))
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	if job.Status != jobqueue.StatusCompleted || job.Result == nil {
+		h.Error(c, apperrors.New(apperrors.ErrConflict, "导出任务尚未完成"))
+		return
+	}
+
+	h.sendFile(c, job.Result)
+}
+
+// sendFile writes an export result as a download
+func (h *ExportHandler) sendFile(c *gin.Context, file *jobqueue.Result) {
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Filename))
+	c.Data(http.StatusOK, file.ContentType, file.Data)
+}
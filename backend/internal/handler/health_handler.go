@@ -27,45 +27,49 @@ type HealthResponse struct {
 // @Tags System
 // @Produce json
 // @Success 200 {object} HealthResponse "Service is healthy"
-// @Failure 503 {object} HealthResponse "Service is unhealthy"
+// @Failure 503 {object} HealthResponse "Service is unhealthy or degraded"
 // @Router /health [get]
 func (h *HealthHandler) HealthCheck(c *gin.Context) {
 	services := make(map[string]string)
 
 	// Check database
-	if database.DB != nil {
-		sqlDB, err := database.DB.DB()
+	if db := database.GetDB(); db != nil {
+		sqlDB, err := db.DB()
 		if err != nil || sqlDB.Ping() != nil {
 			services["database"] = "unhealthy"
 		} else {
 			services["database"] = "healthy"
 		}
 	} else {
-		services["database"] = "not_initialized"
+		services["database"] = "down"
 	}
 
 	// Check Redis
-	if redis.Rdb != nil {
-		if err := redis.Rdb.Ping(c.Request.Context()).Err(); err != nil {
+	if client := redis.Client(); client != nil {
+		if err := client.Ping(c.Request.Context()).Err(); err != nil {
 			services["redis"] = "unhealthy"
 		} else {
 			services["redis"] = "healthy"
 		}
 	} else {
-		services["redis"] = "not_initialized"
+		services["redis"] = "down"
 	}
 
-	// Determine overall status
+	// Determine overall status; a dependency that never connected means the
+	// server is running in degraded mode and only /health is served
 	status := "healthy"
 	for _, serviceStatus := range services {
+		if serviceStatus == "down" {
+			status = "degraded"
+			break
+		}
 		if serviceStatus != "healthy" {
 			status = "unhealthy"
-			break
 		}
 	}
 
 	httpStatus := http.StatusOK
-	if status == "unhealthy" {
+	if status != "healthy" {
 		httpStatus = http.StatusServiceUnavailable
 	}
 
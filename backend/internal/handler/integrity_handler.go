@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"github.com/ai-fitness-planner/backend/internal/api/request"
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// IntegrityHandler handles data consistency check HTTP requests
+type IntegrityHandler struct {
+	*BaseHandler
+	integrityService service.IntegrityService
+}
+
+// NewIntegrityHandler creates a new IntegrityHandler instance
+func NewIntegrityHandler(integrityService service.IntegrityService) *IntegrityHandler {
+	return &IntegrityHandler{
+		BaseHandler:      NewBaseHandler(),
+		integrityService: integrityService,
+	}
+}
+
+// GetReport handles GET /api/v1/admin/integrity/report
+// @Summary Get the latest data integrity report
+// @Description Returns the report of the last scheduled or manual check; runs a read-only check if none has run since startup
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.IntegrityReportResponse "Integrity report"
+// @Failure 403 {object} response.BaseResponse "Not an admin"
+// @Router /admin/integrity/report [get]
+func (h *IntegrityHandler) GetReport(c *gin.Context) {
+	report := h.integrityService.LatestReport()
+	if report == nil {
+		var err error
+		report, err = h.integrityService.Check(c.Request.Context(), false)
+		if err != nil {
+			h.Error(c, err)
+			return
+		}
+	}
+
+	h.Success(c, integrityReportResponse(report))
+}
+
+// RunCheck handles POST /api/v1/admin/integrity/check
+// @Summary Run the data integrity checks
+// @Description Detects training records referencing deleted plans, plans with invalid date ranges and nutrition records with foods but zero macros. With repair=true, safe fixes are applied.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param repair query bool false "Apply automatic repairs"
+// @Success 200 {object} response.IntegrityReportResponse "Integrity report"
+// @Failure 403 {object} response.BaseResponse "Not an admin"
+// @Router /admin/integrity/check [post]
+func (h *IntegrityHandler) RunCheck(c *gin.Context) {
+	var query request.IntegrityCheckQuery
+	if !h.BindQuery(c, &query) {
+		return
+	}
+
+	report, err := h.integrityService.Check(c.Request.Context(), query.Repair)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, integrityReportResponse(report))
+}
+
+func integrityReportResponse(report *service.IntegrityReport) response.IntegrityReportResponse {
+	issues := make([]response.IntegrityIssueInfo, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		issues = append(issues, response.IntegrityIssueInfo{
+			Check:       issue.Check,
+			Table:       issue.Table,
+			RecordID:    issue.RecordID,
+			UserID:      issue.UserID,
+			Detail:      issue.Detail,
+			Repairable:  issue.Repairable,
+			Repaired:    issue.Repaired,
+			RepairError: issue.RepairError,
+		})
+	}
+
+	return response.IntegrityReportResponse{
+		StartedAt:  response.FormatTime(report.StartedAt),
+		FinishedAt: response.FormatTime(report.FinishedAt),
+		Repair:     report.Repair,
+		Counts:     report.Counts,
+		Repaired:   report.Repaired,
+		Truncated:  report.Truncated,
+		Issues:     issues,
+	}
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/ai-fitness-planner/backend/internal/api/request"
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// MacrocycleHandler handles macrocycle (long-term periodization) HTTP requests
+type MacrocycleHandler struct {
+	*BaseHandler
+	macrocycleService service.MacrocycleService
+}
+
+// NewMacrocycleHandler creates a new MacrocycleHandler instance
+func NewMacrocycleHandler(macrocycleService service.MacrocycleService) *MacrocycleHandler {
+	return &MacrocycleHandler{
+		BaseHandler:       NewBaseHandler(),
+		macrocycleService: macrocycleService,
+	}
+}
+
+// CreateMacrocycle handles POST /api/v1/macrocycles
+// @Summary Create a macrocycle
+// @Description Group sequential training plans (blocks) under a long-term goal, e.g. hypertrophy → strength → peak. phases lists the planned phase of each block in order. An existing plan given as initial_plan_id becomes block 1.
+// @Tags Macrocycle
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request.CreateMacrocycleRequest true "Macrocycle"
+// @Success 200 {object} response.MacrocycleDetailResponse "Macrocycle created"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 404 {object} response.BaseResponse "Initial plan not found"
+// @Failure 409 {object} response.BaseResponse "Initial plan already in a macrocycle"
+// @Router /macrocycles [post]
+func (h *MacrocycleHandler) CreateMacrocycle(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.CreateMacrocycleRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	detail, err := h.macrocycleService.CreateMacrocycle(c.Request.Context(), userID, &service.CreateMacrocycleRequest{
+		Name:          req.Name,
+		Goal:          req.Goal,
+		Phases:        req.Phases,
+		InitialPlanID: req.InitialPlanID,
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, toMacrocycleDetailResponse(detail))
+}
+
+// ListMacrocycles handles GET /api/v1/macrocycles
+// @Summary List macrocycles
+// @Tags Macrocycle
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.MacrocycleListResponse "Macrocycles"
+// @Failure 401 {object} response.BaseResponse "Unauthorized"
+// @Router /macrocycles [get]
+func (h *MacrocycleHandler) ListMacrocycles(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	macrocycles, err := h.macrocycleService.ListMacrocycles(c.Request.Context(), userID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	infos := make([]response.MacrocycleInfo, 0, len(macrocycles))
+	for _, m := range macrocycles {
+		infos = append(infos, toMacrocycleInfo(m))
+	}
+	h.Success(c, response.MacrocycleListResponse{Macrocycles: infos})
+}
+
+// GetMacrocycle handles GET /api/v1/macrocycles/:id
+// @Summary Get a macrocycle
+// @Description The macrocycle with its blocks in order, each with its completion rate so far, and the planned phase of the next block
+// @Tags Macrocycle
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Macrocycle ID"
+// @Success 200 {object} response.MacrocycleDetailResponse "Macrocycle"
+// @Failure 404 {object} response.BaseResponse "Macrocycle not found"
+// @Router /macrocycles/{id} [get]
+func (h *MacrocycleHandler) GetMacrocycle(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	macrocycleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的宏周期ID")
+		return
+	}
+
+	detail, err := h.macrocycleService.GetMacrocycle(c.Request.Context(), userID, macrocycleID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, toMacrocycleDetailResponse(detail))
+}
+
+// GenerateNextBlock handles POST /api/v1/macrocycles/:id/next-block
+// @Summary Generate the next macrocycle block
+// @Description Generates a training plan for the block after the macrocycle's last one, in the given phase or the next planned one. The prompt includes the previous block's adherence and the PRs set during it. The previous block is marked completed once the new plan is saved. Poll the task at /training-plans/tasks/{taskId}.
+// @Tags Macrocycle
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Macrocycle ID"
+// @Param request body request.GenerateNextBlockRequest true "Next block"
+// @Success 200 {object} response.TaskResponse "Generation task created"
+// @Failure 400 {object} response.BaseResponse "Bad request or no planned phase left"
+// @Failure 404 {object} response.BaseResponse "Macrocycle not found"
+// @Failure 429 {object} response.BaseResponse "Rate limit exceeded"
+// @Router /macrocycles/{id}/next-block [post]
+func (h *MacrocycleHandler) GenerateNextBlock(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	macrocycleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的宏周期ID")
+		return
+	}
+
+	var req request.GenerateNextBlockRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	taskResp, err := h.macrocycleService.GenerateNextBlock(c.Request.Context(), userID, macrocycleID, &service.NextBlockRequest{
+		PlanName:        req.PlanName,
+		DurationWeeks:   req.DurationWeeks,
+		DifficultyLevel: req.DifficultyLevel,
+		Phase:           req.Phase,
+		AIAPIID:         req.AIAPIID,
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	if taskResp.Existing {
+		h.RefundAIQuota(c)
+	}
+
+	h.Success(c, response.TaskResponse{
+		TaskID:        taskResp.TaskID,
+		Status:        taskResp.Status,
+		Progress:      0,
+		EstimatedTime: 60,
+		Existing:      taskResp.Existing,
+	})
+}
+
+// toMacrocycleInfo converts a macrocycle model to its response DTO
+func toMacrocycleInfo(m *model.Macrocycle) response.MacrocycleInfo {
+	return response.MacrocycleInfo{
+		ID:        m.ID,
+		Name:      m.Name,
+		Goal:      m.Goal,
+		Phases:    m.PhaseNames(),
+		CreatedAt: response.FormatTime(m.CreatedAt),
+	}
+}
+
+// toMacrocycleDetailResponse converts a macrocycle and its blocks to the
+// response DTO
+func toMacrocycleDetailResponse(d *service.MacrocycleDetail) response.MacrocycleDetailResponse {
+	blocks := make([]response.MacrocycleBlockInfo, 0, len(d.Blocks))
+	for _, b := range d.Blocks {
+		info := response.MacrocycleBlockInfo{
+			PlanID:         b.Plan.ID,
+			PlanName:       b.Plan.PlanName,
+			StartDate:      response.FormatDate(b.Plan.StartDate),
+			EndDate:        response.FormatDate(b.Plan.EndDate),
+			TotalWeeks:     b.Plan.TotalWeeks,
+			Status:         b.Plan.Status,
+			CompletionRate: math.Round(b.CompletionRate*10) / 10,
+		}
+		if b.Plan.BlockNumber != nil {
+			info.BlockNumber = *b.Plan.BlockNumber
+		}
+		if b.Plan.BlockPhase != nil {
+			info.Phase = *b.Plan.BlockPhase
+		}
+		blocks = append(blocks, info)
+	}
+
+	return response.MacrocycleDetailResponse{
+		MacrocycleInfo: toMacrocycleInfo(d.Macrocycle),
+		Blocks:         blocks,
+		NextPhase:      d.NextPhase,
+	}
+}
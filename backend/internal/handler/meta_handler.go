@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// MetaHandler serves information about the running deployment
+type MetaHandler struct {
+	*BaseHandler
+	runtimeService service.RuntimeService
+}
+
+// NewMetaHandler creates a new MetaHandler instance
+func NewMetaHandler(runtimeService service.RuntimeService) *MetaHandler {
+	return &MetaHandler{
+		BaseHandler:    NewBaseHandler(),
+		runtimeService: runtimeService,
+	}
+}
+
+// GetRuntime handles GET /api/v1/meta/runtime
+// @Summary Get runtime configuration
+// @Description Effective non-secret configuration of this deployment: mode, rate limits, AI providers, feature flags and schema migration version. Admin only.
+// @Tags Meta
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.RuntimeInfoResponse "Runtime configuration"
+// @Failure 403 {object} response.BaseResponse "Not an administrator"
+// @Router /meta/runtime [get]
+func (h *MetaHandler) GetRuntime(c *gin.Context) {
+	info, err := h.runtimeService.GetRuntimeInfo(c.Request.Context())
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	schema := response.RuntimeSchemaInfo{
+		Tracked: info.Schema.Tracked,
+		Current: info.Schema.Current,
+		Latest:  info.Schema.Latest,
+		Pending: info.Schema.Pending,
+	}
+	if schema.Pending == nil {
+		schema.Pending = []string{}
+	}
+
+	h.Success(c, response.RuntimeInfoResponse{
+		App: response.RuntimeAppInfo{
+			Name:      info.AppName,
+			Version:   info.Version,
+			Mode:      info.Mode,
+			GoVersion: info.GoVersion,
+			StartedAt: response.FormatTime(info.StartedAt),
+		},
+		RateLimit: response.RuntimeRateLimitInfo{
+			APICallsPerMinute:     info.RateLimit.APICallsPerMinute,
+			APICallsPerHour:       info.RateLimit.APICallsPerHour,
+			APICallsPerDay:        info.RateLimit.APICallsPerDay,
+			AIGenerationsPerDay:   info.RateLimit.AIGenerationsPerDay,
+			AIGenerationsPerMonth: info.RateLimit.AIGenerationsPerMonth,
+		},
+		AI: response.RuntimeAIInfo{
+			MaxConcurrentRequests: info.AIMaxConcurrentRequests,
+			TimeoutSeconds:        int(info.AITimeout / time.Second),
+			RetryAttempts:         info.AIRetryAttempts,
+			SupportedProviders:    info.SupportedProviders,
+			AllowedProviders:      info.AllowedProviders,
+			ConfiguredProviders:   info.ConfiguredProviders,
+		},
+		Features: info.Features,
+		Sync: response.RuntimeSyncInfo{
+			TrainingRecordPolicy:  info.SyncTrainingPolicy,
+			NutritionRecordPolicy: info.SyncNutritionPolicy,
+		},
+		Schema: schema,
+	})
+}
+
+// GetNutritionOptions handles GET /api/v1/meta/nutrition-options
+// @Summary List nutrition plan options
+// @Description The cuisines accepted as preferences and the dietary restrictions accepted when generating a nutrition plan. Plans store the codes.
+// @Tags Meta
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.NutritionOptionsResponse "Nutrition plan options"
+// @Router /meta/nutrition-options [get]
+func (h *MetaHandler) GetNutritionOptions(c *gin.Context) {
+	h.Success(c, response.NutritionOptionsResponse{
+		Cuisines:            toDietTermInfos(service.CuisineTerms()),
+		DietaryRestrictions: toDietTermInfos(service.DietaryRestrictionTerms()),
+	})
+}
+
+// toDietTermInfos converts vocabulary terms to their response DTOs
+func toDietTermInfos(terms []service.DietTerm) []response.DietTermInfo {
+	infos := make([]response.DietTermInfo, 0, len(terms))
+	for _, t := range terms {
+		infos = append(infos, response.DietTermInfo{
+			Code:        t.Code,
+			Name:        t.Name,
+			EnglishName: t.EnglishName,
+		})
+	}
+	return infos
+}
+
+// GetExerciseOptions handles GET /api/v1/meta/exercise-options
+// @Summary List exercise substitution options
+// @Description The equipment and joints the exercise library knows, accepted as unavailable_equipment and avoid_joints when substituting an exercise of a training plan.
+// @Tags Meta
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.ExerciseOptionsResponse "Exercise substitution options"
+// @Router /meta/exercise-options [get]
+func (h *MetaHandler) GetExerciseOptions(c *gin.Context) {
+	h.Success(c, response.ExerciseOptionsResponse{
+		Equipment: toExerciseTermInfos(service.EquipmentTerms()),
+		Joints:    toExerciseTermInfos(service.JointTerms()),
+	})
+}
+
+// toExerciseTermInfos converts exercise library terms to their response DTOs
+func toExerciseTermInfos(terms []service.ExerciseTerm) []response.ExerciseTermInfo {
+	infos := make([]response.ExerciseTermInfo, 0, len(terms))
+	for _, t := range terms {
+		infos = append(infos, response.ExerciseTermInfo{
+			Code:        t.Code,
+			Name:        t.Name,
+			EnglishName: t.EnglishName,
+		})
+	}
+	return infos
+}
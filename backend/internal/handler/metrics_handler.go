@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsHandler serves counters in the Prometheus text format
+type MetricsHandler struct {
+	parseMetrics service.ParseFailureMetrics
+}
+
+// NewMetricsHandler creates a new MetricsHandler. parseMetrics may be nil.
+func NewMetricsHandler(parseMetrics service.ParseFailureMetrics) *MetricsHandler {
+	return &MetricsHandler{parseMetrics: parseMetrics}
+}
+
+// labelEscaper escapes label values as the text format requires
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Metrics handles GET /metrics
+// @Summary Prometheus metrics
+// @Description Counters in the Prometheus text exposition format
+// @Tags System
+// @Produce plain
+// @Success 200 {string} string "Metrics"
+// @Failure 503 {string} string "Counters unavailable"
+// @Router /metrics [get]
+func (h *MetricsHandler) Metrics(c *gin.Context) {
+	var b strings.Builder
+	b.WriteString("# HELP ai_plan_parse_failures_total AI plan responses that failed to parse, by cause.\n")
+	b.WriteString("# TYPE ai_plan_parse_failures_total counter\n")
+
+	if h.parseMetrics != nil {
+		counts, err := h.parseMetrics.Counts(c.Request.Context())
+		if err != nil {
+			c.String(http.StatusServiceUnavailable, "failed to read metrics: %v\n", err)
+			return
+		}
+		for _, count := range counts {
+			fmt.Fprintf(&b, "ai_plan_parse_failures_total{provider=\"%s\",model=\"%s\",purpose=\"%s\",kind=\"%s\"} %d\n",
+				labelEscaper.Replace(count.Provider),
+				labelEscaper.Replace(count.Model),
+				labelEscaper.Replace(count.Purpose),
+				labelEscaper.Replace(string(count.Kind)),
+				count.Count,
+			)
+		}
+	}
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/ai-fitness-planner/backend/internal/api/request"
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler handles user notification HTTP requests
+type NotificationHandler struct {
+	*BaseHandler
+	notificationService service.NotificationService
+}
+
+// NewNotificationHandler creates a new NotificationHandler instance
+func NewNotificationHandler(notificationService service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		BaseHandler:         NewBaseHandler(),
+		notificationService: notificationService,
+	}
+}
+
+// ListNotifications handles GET /api/v1/notifications
+// @Summary List notifications
+// @Description List the authenticated user's notifications, such as achieved goals
+// @Tags Notification
+// @Produce json
+// @Security BearerAuth
+// @Param unread_only query bool false "Only unread notifications"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} response.NotificationListResponse "Notifications"
+// @Failure 401 {object} response.BaseResponse "Unauthorized"
+// @Router /notifications [get]
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var query request.NotificationQuery
+	if !h.BindQuery(c, &query) {
+		return
+	}
+
+	page, limit, offset := h.GetPagination(c)
+	notifications, total, err := h.notificationService.List(c.Request.Context(), userID, query.UnreadOnly, limit, offset)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	infos := make([]response.NotificationInfo, 0, len(notifications))
+	for _, n := range notifications {
+		infos = append(infos, toNotificationInfo(n))
+	}
+
+	h.Success(c, response.NotificationListResponse{
+		Notifications: infos,
+		Pagination:    h.BuildPaginationInfo(page, limit, total),
+	})
+}
+
+// MarkNotificationRead handles POST /api/v1/notifications/:id/read
+// @Summary Mark a notification as read
+// @Tags Notification
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Notification ID"
+// @Success 200 {object} response.NotificationInfo "Notification"
+// @Failure 404 {object} response.BaseResponse "Notification not found"
+// @Router /notifications/{id}/read [post]
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	notificationID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的通知ID")
+		return
+	}
+
+	notification, err := h.notificationService.MarkRead(c.Request.Context(), userID, notificationID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, toNotificationInfo(notification))
+}
+
+// toNotificationInfo converts a notification model to its response DTO
+func toNotificationInfo(n *model.UserNotification) response.NotificationInfo {
+	info := response.NotificationInfo{
+		ID:        n.ID,
+		Type:      n.Type,
+		Title:     n.Title,
+		Data:      n.Data,
+		Read:      n.ReadAt != nil,
+		CreatedAt: response.FormatTime(n.CreatedAt),
+	}
+	if n.Content != nil {
+		info.Content = *n.Content
+	}
+	if n.ReadAt != nil {
+		info.ReadAt = response.FormatTime(*n.ReadAt)
+	}
+	return info
+}
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"fmt"
+	"math"
+	"net/http"
 	"strconv"
 	"time"
 
@@ -54,6 +57,9 @@ func (h *NutritionHandler) GeneratePlan(c *gin.Context) {
 		FatRatio:            req.FatRatio,
 		DietaryRestrictions: req.DietaryRestrictions,
 		Preferences:         req.Preferences,
+		LeftoverLunch:       req.LeftoverLunch,
+		BudgetLevel:         req.BudgetLevel,
+		WeeklyBudget:        req.WeeklyBudget,
 		AIAPIID:             req.AIAPIID,
 	}
 
@@ -63,11 +69,16 @@ func (h *NutritionHandler) GeneratePlan(c *gin.Context) {
 		return
 	}
 
+	if taskResp.Existing {
+		h.RefundAIQuota(c)
+	}
+
 	resp := response.TaskResponse{
 		TaskID:        taskResp.TaskID,
 		Status:        taskResp.Status,
 		Progress:      0,
 		EstimatedTime: 60, // Estimated 60 seconds
+		Existing:      taskResp.Existing,
 	}
 
 	h.Success(c, resp)
@@ -97,14 +108,201 @@ func (h *NutritionHandler) GetPlanStatus(c *gin.Context) {
 	if taskStatus.Error != "" {
 		resp.ErrorMessage = taskStatus.Error
 	}
+	resp.Cooldown = toProviderCooldownInfo(taskStatus.Cooldown)
+	resp.ExpiresAt = formatTaskExpiry(taskStatus.ExpiresAt)
 
 	if taskStatus.Result != nil {
 		resp.Result = h.buildPlanInfo(taskStatus.Result)
 	}
+	resp.Warnings = toPlanWarningInfos(taskStatus.Warnings)
 
 	h.Success(c, resp)
 }
 
+// ListTaskHistory handles GET /api/v1/nutrition-plans/tasks/history
+// @Summary List finished generation tasks
+// @Description The user's last 10 finished generation tasks with their parameters, outcome and duration, kept after the tasks expire from the task list.
+// @Tags Nutrition
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.TaskHistoryResponse "Finished tasks, newest first"
+// @Router /nutrition-plans/tasks/history [get]
+func (h *NutritionHandler) ListTaskHistory(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.nutritionService.ListTaskHistory(c.Request.Context(), userID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, response.TaskHistoryResponse{Tasks: toGenerationTaskInfos(tasks)})
+}
+
+// RetryTask handles POST /api/v1/nutrition-plans/tasks/:taskId/retry
+// @Summary Retry a failed generation task
+// @Description Queues a failed generation task again under the same task ID with the parameters it was submitted with. A retry does not count against the AI generation rate limit.
+// @Tags Nutrition
+// @Produce json
+// @Security BearerAuth
+// @Param taskId path string true "Task ID"
+// @Success 200 {object} response.TaskResponse "Task queued again"
+// @Failure 404 {object} response.BaseResponse "Task not found"
+// @Failure 409 {object} response.BaseResponse "Task has not failed"
+// @Router /nutrition-plans/tasks/{taskId}/retry [post]
+func (h *NutritionHandler) RetryTask(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	taskResp, err := h.nutritionService.RetryTask(c.Request.Context(), userID, c.Param("taskId"))
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	if taskResp.Existing {
+		h.RefundAIQuota(c)
+	}
+
+	h.Success(c, response.TaskResponse{
+		TaskID:        taskResp.TaskID,
+		Status:        taskResp.Status,
+		Progress:      0,
+		EstimatedTime: 60,
+		Existing:      taskResp.Existing,
+	})
+}
+
+// StreamPlanStatus handles GET /api/v1/nutrition-plans/tasks/:taskId/stream
+// @Summary Stream plan generation
+// @Description Server-sent events for a generation task: "progress" ({status, progress, message, cooldown}) on every change, then "completed" (the plan) or "failed" ({error}) before the stream ends. Replaces polling the task status.
+// @Tags Nutrition
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param taskId path string true "Task ID"
+// @Success 200 {string} string "Event stream"
+// @Failure 404 {object} response.BaseResponse "Task not found"
+// @Router /nutrition-plans/tasks/{taskId}/stream [get]
+func (h *NutritionHandler) StreamPlanStatus(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+	taskID := c.Param("taskId")
+
+	// Fail with a normal JSON error before switching to SSE
+	task, changed, err := h.nutritionService.WatchPlanTask(c.Request.Context(), userID, taskID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	rc := http.NewResponseController(c.Writer)
+	send := func(event string, data interface{}) {
+		rc.SetWriteDeadline(time.Now().Add(2 * planStreamHeartbeat))
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+	}
+
+	heartbeat := time.NewTicker(planStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	var lastStatus string
+	var lastCooldown *service.ProviderCooldown
+	lastProgress := -1
+	for {
+		if task.Status != lastStatus || task.Progress != lastProgress || task.Cooldown != lastCooldown {
+			send("progress", gin.H{
+				"status":   task.Status,
+				"progress": task.Progress,
+				"message":  task.Message,
+				"cooldown": toProviderCooldownInfo(task.Cooldown),
+			})
+			lastStatus, lastProgress, lastCooldown = task.Status, task.Progress, task.Cooldown
+		}
+
+		switch task.Status {
+		case service.TaskStatusCompleted:
+			if task.Result != nil {
+				send("completed", h.buildPlanInfo(task.Result))
+			}
+			return
+		case service.TaskStatusFailed:
+			send("failed", gin.H{"error": task.Error})
+			return
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			rc.SetWriteDeadline(time.Now().Add(2 * planStreamHeartbeat))
+			c.Writer.WriteString(": heartbeat\n\n")
+			c.Writer.Flush()
+			continue
+		case <-changed:
+		}
+
+		task, changed, err = h.nutritionService.WatchPlanTask(c.Request.Context(), userID, taskID)
+		if err != nil {
+			send("failed", gin.H{"error": "任务不存在"})
+			return
+		}
+	}
+}
+
+// ListTasks handles GET /api/v1/nutrition-plans/tasks
+// @Summary List generation tasks
+// @Description The user's most recent nutrition plan generation and adjustment tasks, newest first. plan_id is set once a task has completed.
+// @Tags Nutrition
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.TaskListResponse "Tasks"
+// @Router /nutrition-plans/tasks [get]
+func (h *NutritionHandler) ListTasks(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.nutritionService.ListTasks(c.Request.Context(), userID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	infos := make([]response.TaskSummaryInfo, 0, len(tasks))
+	for _, task := range tasks {
+		info := response.TaskSummaryInfo{
+			TaskID:       task.TaskID,
+			Status:       task.Status,
+			Progress:     task.Progress,
+			Message:      task.Message,
+			ErrorMessage: task.Error,
+			CreatedAt:    response.FormatTime(task.CreatedAt),
+			UpdatedAt:    response.FormatTime(task.UpdatedAt),
+			ExpiresAt:    formatTaskExpiry(task.ExpiresAt),
+		}
+		if task.Result != nil {
+			info.PlanID = &task.Result.ID
+		}
+		infos = append(infos, info)
+	}
+
+	h.Success(c, response.TaskListResponse{Tasks: infos})
+}
+
 // ListPlans handles GET /api/v1/nutrition-plans
 // Requirements: 6.3
 func (h *NutritionHandler) ListPlans(c *gin.Context) {
@@ -137,7 +335,114 @@ func (h *NutritionHandler) ListPlans(c *gin.Context) {
 	h.Success(c, resp)
 }
 
-// GetPlanDetail handles GET /api/v1/nutrition-plans/:id
+// AdjustPlan handles POST /api/v1/nutrition-plans/:id/adjust
+// @Summary Adjust a nutrition plan
+// @Description Generates a new version of the plan from the last two weeks of meal records, the share of plan days with meals logged, weight change, the latest check-in and the user's feedback. The new plan starts today, links to the original through parent_plan_id and replaces it as the active plan.
+// @Tags Nutrition
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Param request body request.AdjustNutritionPlanRequest true "Feedback on the current plan"
+// @Success 200 {object} response.TaskResponse "Adjustment task created"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 404 {object} response.BaseResponse "Plan not found"
+// @Router /nutrition-plans/{id}/adjust [post]
+func (h *NutritionHandler) AdjustPlan(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+
+	var req request.AdjustNutritionPlanRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	taskResp, err := h.nutritionService.AdjustPlan(c.Request.Context(), userID, planID, &service.AdjustNutritionPlanRequest{
+		Feedback:           req.Feedback,
+		SatisfactionRating: req.SatisfactionRating,
+		WeightChange:       req.WeightChange,
+		AIAPIID:            req.AIAPIID,
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	if taskResp.Existing {
+		h.RefundAIQuota(c)
+	}
+
+	h.Success(c, response.TaskResponse{
+		TaskID:        taskResp.TaskID,
+		Status:        taskResp.Status,
+		Progress:      0,
+		EstimatedTime: 60,
+		Existing:      taskResp.Existing,
+	})
+}
+
+// RegenerateDay handles POST /api/v1/nutrition-plans/:id/days/:date/regenerate
+// @Summary Regenerate one day of a nutrition plan
+// @Description Asks the AI for new meals for a single date of the plan, keeping its calorie and macro targets, dietary restrictions and preferences, and saves them in place of that day. The request body is optional.
+// @Tags Nutrition
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Param date path string true "Date (YYYY-MM-DD)"
+// @Param request body request.RegenerateNutritionDayRequest false "Feedback on the day's meals"
+// @Success 200 {object} response.NutritionDayResponse "Regenerated day"
+// @Failure 400 {object} response.BaseResponse "Date outside the plan"
+// @Failure 404 {object} response.BaseResponse "Plan or day not found"
+// @Router /nutrition-plans/{id}/days/{date}/regenerate [post]
+func (h *NutritionHandler) RegenerateDay(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+	date, err := time.ParseInLocation("2006-01-02", c.Param("date"), time.Local)
+	if err != nil {
+		h.BadRequest(c, "日期格式无效，应为YYYY-MM-DD")
+		return
+	}
+
+	var req request.RegenerateNutritionDayRequest
+	if c.Request.ContentLength != 0 && !h.BindJSON(c, &req) {
+		return
+	}
+
+	day, err := h.nutritionService.RegenerateDay(c.Request.Context(), userID, planID, date, &service.RegenerateNutritionDayRequest{
+		Feedback: req.Feedback,
+		AIAPIID:  req.AIAPIID,
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, response.NutritionDayResponse{
+		PlanID: planID,
+		Date:   response.FormatDate(date),
+		Day:    day,
+	})
+}
+
+// GetPlanDetail handles GET /api/v1/nutrition-plans/:id; the daily meals
+// are returned with include=plan_data
 // Requirements: 6.3
 func (h *NutritionHandler) GetPlanDetail(c *gin.Context) {
 	userID, ok := h.GetUserID(c)
@@ -151,15 +456,141 @@ func (h *NutritionHandler) GetPlanDetail(c *gin.Context) {
 		return
 	}
 
-	plan, err := h.nutritionService.GetPlanDetail(c.Request.Context(), planID, userID)
+	var params request.PlanDetailParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		h.BadRequest(c, "include参数只支持plan_data")
+		return
+	}
+
+	// The plan data is the bulk of the row; load it only when asked for
+	var plan *model.NutritionPlan
+	if params.Include == request.IncludePlanData {
+		plan, err = h.nutritionService.GetPlanDetail(c.Request.Context(), planID, userID)
+	} else {
+		plan, err = h.nutritionService.GetPlanSummary(c.Request.Context(), planID, userID)
+	}
 	if err != nil {
 		h.Error(c, err)
 		return
 	}
 
-	h.Success(c, gin.H{
-		"plan": plan,
+	info := response.NutritionPlanDetailInfo{
+		NutritionPlanInfo: h.buildPlanInfo(plan),
+		UpdatedAt:         response.FormatTime(plan.UpdatedAt),
+	}
+	if params.Include == request.IncludePlanData {
+		info.PlanData = toNutritionPlanDataInfo(plan.PlanData)
+	}
+	h.Success(c, response.NutritionPlanDetailResponse{Plan: info})
+}
+
+// ExportPDF handles GET /api/v1/nutrition-plans/:id/export.pdf
+// @Summary Export a nutrition plan as PDF
+// @Description Renders the plan as a printable A4 document, day by day, with each meal's foods, portions, calories and macros. Every page is watermarked with the exporting user and time. Sending an X-Export-Passphrase header password-protects the file; it then opens only with that password and can be printed but not copied.
+// @Tags Nutrition
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Param X-Export-Passphrase header string false "Password to open the PDF with, 8-32 printable ASCII characters"
+// @Success 200 {file} file "PDF document"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 404 {object} response.BaseResponse "Plan not found"
+// @Router /nutrition-plans/{id}/export.pdf [get]
+func (h *NutritionHandler) ExportPDF(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+
+	data, err := h.nutritionService.ExportPDF(c.Request.Context(), userID, planID, service.PDFExportOptions{
+		Username: h.GetUsername(c),
+		Password: c.GetHeader("X-Export-Passphrase"),
 	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("nutrition-plan-%d.pdf", planID)))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// GetShoppingList handles GET /api/v1/nutrition-plans/:id/weeks/:n/shopping-list
+// @Summary Shopping list for a week of a nutrition plan
+// @Description Adds up the foods of week n by name, summing amounts that share a unit. Foods the AI priced (it is asked to when the plan has a budget) give a rough cost estimate in CNY, compared with the weekly budget; unpriced servings are counted and left out of the estimate.
+// @Tags Nutrition
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Param n path int true "Week number, from 1"
+// @Success 200 {object} response.ShoppingListResponse "Shopping list"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 404 {object} response.BaseResponse "Plan or week not found"
+// @Router /nutrition-plans/{id}/weeks/{n}/shopping-list [get]
+func (h *NutritionHandler) GetShoppingList(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+	week, err := strconv.Atoi(c.Param("n"))
+	if err != nil || week < 1 {
+		h.BadRequest(c, "无效的周次")
+		return
+	}
+
+	list, err := h.nutritionService.GetShoppingList(c.Request.Context(), userID, planID, week)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	items := make([]response.ShoppingListItem, 0, len(list.Items))
+	for _, item := range list.Items {
+		items = append(items, response.ShoppingListItem{
+			Name:          item.Name,
+			Quantity:      item.Quantity,
+			Servings:      item.Servings,
+			EstimatedCost: roundCost(item.EstimatedCost),
+			UnpricedCount: item.Unpriced,
+		})
+	}
+	resp := response.ShoppingListResponse{
+		PlanID:        list.PlanID,
+		Week:          list.Week,
+		TotalWeeks:    list.TotalWeeks,
+		StartDate:     list.StartDate,
+		EndDate:       list.EndDate,
+		EstimatedCost: roundCost(list.EstimatedCost),
+		UnpricedCount: list.Unpriced,
+		WeeklyBudget:  list.WeeklyBudget,
+		OverBudget:    list.OverBudget,
+		Items:         items,
+	}
+	if list.BudgetLevel != nil {
+		resp.BudgetLevel = *list.BudgetLevel
+	}
+	h.Success(c, resp)
+}
+
+// roundCost rounds a cost estimate to the fen
+func roundCost(cost *float64) *float64 {
+	if cost == nil {
+		return nil
+	}
+	rounded := math.Round(*cost*100) / 100
+	return &rounded
 }
 
 // GetTodayMeals handles GET /api/v1/nutrition-plans/today
@@ -332,14 +763,24 @@ func (h *NutritionHandler) buildPlanInfo(plan *model.NutritionPlan) response.Nut
 	info := response.NutritionPlanInfo{
 		ID:            plan.ID,
 		PlanName:      plan.PlanName,
-		StartDate:     plan.StartDate.Format("2006-01-02"),
-		EndDate:       plan.EndDate.Format("2006-01-02"),
+		StartDate:     response.FormatDate(plan.StartDate),
+		EndDate:       response.FormatDate(plan.EndDate),
 		DailyCalories: plan.DailyCalories,
 		ProteinRatio:  plan.ProteinRatio,
 		CarbRatio:     plan.CarbRatio,
 		FatRatio:      plan.FatRatio,
+		LeftoverLunch: plan.LeftoverLunch,
+		WeeklyBudget:  plan.WeeklyBudget,
+		ParentPlanID:  plan.ParentPlanID,
 		Status:        plan.Status,
-		CreatedAt:     plan.CreatedAt.Format(time.RFC3339),
+		CreatedAt:     response.FormatTime(plan.CreatedAt),
+		Warnings:      toPlanWarningInfos(service.NutritionPlanWarnings(plan)),
+	}
+	if plan.CalorieBasis != nil {
+		info.CalorieBasis = *plan.CalorieBasis
+	}
+	if plan.BudgetLevel != nil {
+		info.BudgetLevel = *plan.BudgetLevel
 	}
 
 	// Convert JSONSlice to string slice
@@ -364,18 +805,30 @@ func (h *NutritionHandler) buildPlanInfo(plan *model.NutritionPlan) response.Nut
 	return info
 }
 
+// toPlanWarningInfos converts plan warnings to response format
+func toPlanWarningInfos(warnings []service.PlanWarning) []response.PlanWarningInfo {
+	if len(warnings) == 0 {
+		return nil
+	}
+	infos := make([]response.PlanWarningInfo, 0, len(warnings))
+	for _, w := range warnings {
+		infos = append(infos, response.PlanWarningInfo{Code: w.Code, Message: w.Message})
+	}
+	return infos
+}
+
 // buildRecordInfo converts model to response format
 func (h *NutritionHandler) buildRecordInfo(record *model.NutritionRecord) response.NutritionRecordInfo {
 	info := response.NutritionRecordInfo{
 		ID:        record.ID,
-		MealDate:  record.MealDate.Format("2006-01-02"),
+		MealDate:  response.FormatDate(record.MealDate),
 		MealType:  record.MealTime,
 		Calories:  record.Calories,
 		Protein:   record.Protein,
 		Carbs:     record.Carbs,
 		Fat:       record.Fat,
 		Fiber:     record.Fiber,
-		CreatedAt: record.CreatedAt.Format(time.RFC3339),
+		CreatedAt: response.FormatTime(record.CreatedAt),
 	}
 
 	if record.Foods != nil {
@@ -384,3 +837,40 @@ func (h *NutritionHandler) buildRecordInfo(record *model.NutritionRecord) respon
 
 	return info
 }
+
+// toNutritionPlanDataInfo parses a nutrition plan's days; entries of the
+// wrong shape are skipped
+func toNutritionPlanDataInfo(data model.JSONMap) *response.NutritionPlanDataInfo {
+	info := &response.NutritionPlanDataInfo{Days: []response.NutritionPlanDayInfo{}}
+
+	days, _ := data["days"].([]interface{})
+	for _, d := range days {
+		dayMap, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		day := model.NutritionPlanDayFromData(dayMap)
+		dayInfo := response.NutritionPlanDayInfo{
+			Day:   day.Day,
+			Date:  day.Date,
+			Meals: make(map[string]response.MealInfo, len(day.Meals)),
+		}
+		for key, meal := range day.Meals {
+			dayInfo.Meals[key] = response.MealInfo{
+				Time:          meal.Time,
+				Foods:         meal.Foods,
+				TotalCalories: meal.TotalCalories,
+			}
+		}
+		if t := day.DailyTotals; t != nil {
+			dayInfo.DailyTotals = &response.NutritionTotalsInfo{
+				Calories: t.Calories,
+				Protein:  t.Protein,
+				Carbs:    t.Carbs,
+				Fat:      t.Fat,
+			}
+		}
+		info.Days = append(info.Days, dayInfo)
+	}
+	return info
+}
@@ -0,0 +1,311 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ai-fitness-planner/backend/internal/api/request"
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// maxProvisionCSVBytes bounds uploaded CSV bodies
+const maxProvisionCSVBytes = 1 << 20
+
+// OrganizationHandler handles organization provisioning HTTP requests
+type OrganizationHandler struct {
+	*BaseHandler
+	provisioningService service.ProvisioningService
+}
+
+// NewOrganizationHandler creates a new OrganizationHandler instance
+func NewOrganizationHandler(provisioningService service.ProvisioningService) *OrganizationHandler {
+	return &OrganizationHandler{
+		BaseHandler:         NewBaseHandler(),
+		provisioningService: provisioningService,
+	}
+}
+
+// CreateOrganization handles POST /api/v1/admin/organizations
+// @Summary Create organization
+// @Description Create an enterprise or gym tenant with a limit on active users
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request.CreateOrganizationRequest true "Organization"
+// @Success 201 {object} response.OrganizationInfo "Created organization"
+// @Failure 403 {object} response.BaseResponse "Not an admin"
+// @Failure 409 {object} response.BaseResponse "Name already in use"
+// @Router /admin/organizations [post]
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var req request.CreateOrganizationRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	org, err := h.provisioningService.CreateOrganization(c.Request.Context(), strings.TrimSpace(req.Name), req.SeatLimit)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Created(c, response.OrganizationInfo{
+		ID:        org.ID,
+		Name:      org.Name,
+		SeatLimit: org.SeatLimit,
+		CreatedAt: response.FormatTime(org.CreatedAt),
+	})
+}
+
+// ListOrganizations handles GET /api/v1/admin/organizations
+// @Summary List organizations
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.OrganizationListResponse "Organizations with seat usage"
+// @Failure 403 {object} response.BaseResponse "Not an admin"
+// @Router /admin/organizations [get]
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	summaries, err := h.provisioningService.ListOrganizations(c.Request.Context())
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	infos := make([]response.OrganizationInfo, 0, len(summaries))
+	for _, s := range summaries {
+		infos = append(infos, response.OrganizationInfo{
+			ID:        s.Organization.ID,
+			Name:      s.Organization.Name,
+			SeatLimit: s.Organization.SeatLimit,
+			SeatsUsed: s.ActiveMembers,
+			CreatedAt: response.FormatTime(s.Organization.CreatedAt),
+		})
+	}
+
+	h.Success(c, response.OrganizationListResponse{Organizations: infos})
+}
+
+// ProvisionUsers handles POST /api/v1/admin/organizations/:id/users
+// @Summary Bulk provision organization users
+// @Description Create or reactivate users and email each an invitation to set a password. Accepts SCIM-style JSON ({"Resources": [{"userName", "displayName", "emails": [{"value", "primary"}]}]}) or text/csv with an email column and optional username and nickname columns. Users past the seat limit are rejected individually.
+// @Tags Admin
+// @Accept json
+// @Accept text/csv
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param request body request.ProvisionUsersRequest true "Users to provision"
+// @Success 200 {object} response.ProvisionUsersResponse "Per-user outcome"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 404 {object} response.BaseResponse "Organization not found"
+// @Router /admin/organizations/{id}/users [post]
+func (h *OrganizationHandler) ProvisionUsers(c *gin.Context) {
+	adminID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的组织ID")
+		return
+	}
+
+	var users []service.ProvisionUser
+	if isCSVRequest(c) {
+		rows, err := readCSVRows(c, "email", "username", "nickname")
+		if err != nil {
+			h.BadRequest(c, "CSV格式无效: "+err.Error())
+			return
+		}
+		for _, row := range rows {
+			users = append(users, service.ProvisionUser{Email: row[0], Username: row[1], Nickname: row[2]})
+		}
+	} else {
+		var req request.ProvisionUsersRequest
+		if !h.BindJSON(c, &req) {
+			return
+		}
+		for _, r := range req.Resources {
+			users = append(users, service.ProvisionUser{
+				Email:    primarySCIMEmail(r.Emails),
+				Username: r.UserName,
+				Nickname: r.DisplayName,
+			})
+		}
+	}
+
+	result, err := h.provisioningService.ProvisionUsers(c.Request.Context(), adminID, orgID, users)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	resp := response.ProvisionUsersResponse{
+		Provisioned: make([]response.ProvisionedUserInfo, 0, len(result.Provisioned)),
+		Skipped:     toProvisionIssueInfos(result.Skipped),
+		Rejected:    toProvisionIssueInfos(result.Rejected),
+		SeatsUsed:   result.SeatsUsed,
+		SeatLimit:   result.SeatLimit,
+	}
+	for _, p := range result.Provisioned {
+		resp.Provisioned = append(resp.Provisioned, response.ProvisionedUserInfo{
+			Email:          p.Email,
+			UserID:         p.UserID,
+			Reactivated:    p.Reactivated,
+			InvitationSent: p.InvitationSent,
+		})
+	}
+
+	h.Success(c, resp)
+}
+
+// DeactivateUsers handles POST /api/v1/admin/organizations/:id/users/deactivate
+// @Summary Bulk deactivate organization users
+// @Description Disable members by email and end their sessions, freeing their seats. Accepts JSON ({"emails": [...]}) or text/csv with an email column.
+// @Tags Admin
+// @Accept json
+// @Accept text/csv
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param request body request.DeactivateUsersRequest true "Users to deactivate"
+// @Success 200 {object} response.DeactivateUsersResponse "Per-user outcome"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 404 {object} response.BaseResponse "Organization not found"
+// @Router /admin/organizations/{id}/users/deactivate [post]
+func (h *OrganizationHandler) DeactivateUsers(c *gin.Context) {
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的组织ID")
+		return
+	}
+
+	var emails []string
+	if isCSVRequest(c) {
+		rows, err := readCSVRows(c, "email")
+		if err != nil {
+			h.BadRequest(c, "CSV格式无效: "+err.Error())
+			return
+		}
+		for _, row := range rows {
+			emails = append(emails, row[0])
+		}
+	} else {
+		var req request.DeactivateUsersRequest
+		if !h.BindJSON(c, &req) {
+			return
+		}
+		emails = req.Emails
+	}
+
+	result, err := h.provisioningService.DeactivateUsers(c.Request.Context(), orgID, emails)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	deactivated := result.Deactivated
+	if deactivated == nil {
+		deactivated = []string{}
+	}
+	h.Success(c, response.DeactivateUsersResponse{
+		Deactivated: deactivated,
+		Rejected:    toProvisionIssueInfos(result.Rejected),
+		SeatsUsed:   result.SeatsUsed,
+	})
+}
+
+// AcceptInvitation handles POST /api/v1/auth/accept-invitation
+// @Summary Accept organization invitation
+// @Description Set the password of a provisioned user using the emailed invitation token
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body request.AcceptInvitationRequest true "Invitation token and new password"
+// @Success 204 "Password set"
+// @Failure 400 {object} response.BaseResponse "Invalid or expired invitation"
+// @Router /auth/accept-invitation [post]
+func (h *OrganizationHandler) AcceptInvitation(c *gin.Context) {
+	var req request.AcceptInvitationRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	if err := h.provisioningService.AcceptInvitation(c.Request.Context(), strings.ToLower(req.Token), req.Password); err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.NoContent(c)
+}
+
+func isCSVRequest(c *gin.Context) bool {
+	return strings.HasPrefix(c.ContentType(), "text/csv")
+}
+
+// readCSVRows reads a CSV body whose header names the columns. Rows are
+// returned with the requested columns in order; the first is required and
+// missing optional columns read as "".
+func readCSVRows(c *gin.Context, columns ...string) ([][]string, error) {
+	r := csv.NewReader(io.LimitReader(c.Request.Body, maxProvisionCSVBytes))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := index[columns[0]]; !ok {
+		return nil, fmt.Errorf("缺少%s列", columns[0])
+	}
+
+	var rows [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make([]string, len(columns))
+		for i, col := range columns {
+			if j, ok := index[col]; ok && j < len(record) {
+				row[i] = strings.TrimSpace(record[j])
+			}
+		}
+		if row[0] == "" {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func primarySCIMEmail(emails []request.SCIMEmail) string {
+	for _, e := range emails {
+		if e.Primary {
+			return e.Value
+		}
+	}
+	return emails[0].Value
+}
+
+func toProvisionIssueInfos(issues []service.ProvisionIssue) []response.ProvisionIssueInfo {
+	infos := make([]response.ProvisionIssueInfo, 0, len(issues))
+	for _, i := range issues {
+		infos = append(infos, response.ProvisionIssueInfo{Email: i.Email, Reason: i.Reason})
+	}
+	return infos
+}
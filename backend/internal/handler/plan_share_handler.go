@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/api/request"
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// PlanShareHandler handles training plan share link HTTP requests
+type PlanShareHandler struct {
+	*BaseHandler
+	shareService service.PlanShareService
+}
+
+// NewPlanShareHandler creates a new PlanShareHandler instance
+func NewPlanShareHandler(shareService service.PlanShareService) *PlanShareHandler {
+	return &PlanShareHandler{
+		BaseHandler:  NewBaseHandler(),
+		shareService: shareService,
+	}
+}
+
+// SharePlan handles POST /api/v1/training-plans/:id/share
+// @Summary Share a training plan
+// @Description Makes a signed, expiring token for a public read-only link to the plan, for sharing the program with friends or a coach. Anyone with the token can open the plan at /shared/training-plans/{token} until it expires or the plan is deleted. The request body is optional.
+// @Tags Training
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Param request body request.ShareTrainingPlanRequest false "Link lifetime"
+// @Success 200 {object} response.PlanShareResponse "Share token"
+// @Failure 404 {object} response.BaseResponse "Plan not found"
+// @Router /training-plans/{id}/share [post]
+func (h *PlanShareHandler) SharePlan(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+
+	var req request.ShareTrainingPlanRequest
+	if c.Request.ContentLength != 0 && !h.BindJSON(c, &req) {
+		return
+	}
+
+	share, err := h.shareService.SharePlan(c.Request.Context(), userID, planID, time.Duration(req.TTLHours)*time.Hour)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, response.PlanShareResponse{
+		PlanID:    share.PlanID,
+		Token:     share.Token,
+		ExpiresAt: response.FormatTime(share.ExpiresAt),
+	})
+}
+
+// GetSharedPlan handles GET /api/v1/shared/training-plans/:token
+// @Summary Open a shared training plan
+// @Description Returns the plan a share token grants access to, with its weekly schedule but none of its owner's data. No authentication is needed.
+// @Tags Training
+// @Produce json
+// @Param token path string true "Share token"
+// @Success 200 {object} response.SharedPlanResponse "Shared plan"
+// @Failure 404 {object} response.BaseResponse "Invalid or expired link"
+// @Router /shared/training-plans/{token} [get]
+func (h *PlanShareHandler) GetSharedPlan(c *gin.Context) {
+	shared, err := h.shareService.GetSharedPlan(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, toSharedPlanResponse(shared.Plan, shared.ExpiresAt))
+}
+
+// toSharedPlanResponse converts a plan for a share link, dropping what
+// describes its owner rather than the program: completed days, exercises
+// substituted for injuries or missing equipment, and the progression audit
+func toSharedPlanResponse(plan *model.TrainingPlan, expiresAt time.Time) response.SharedPlanResponse {
+	// Shared plans show the owner's plan as generated, without their rests
+	data := toTrainingPlanDataInfo(plan.PlanData, model.RestIntervals{})
+	data.ProgressionAudit = nil
+	for _, week := range data.Weeks {
+		for i := range week.Days {
+			week.Days[i].IsCompleted = false
+			for j := range week.Days[i].Exercises {
+				week.Days[i].Exercises[j].SubstitutedFor = ""
+			}
+		}
+	}
+
+	resp := response.SharedPlanResponse{
+		Name:            plan.PlanName,
+		StartDate:       response.FormatDate(plan.StartDate),
+		EndDate:         response.FormatDate(plan.EndDate),
+		TotalWeeks:      plan.TotalWeeks,
+		DifficultyLevel: plan.DifficultyLevel,
+		PlanData:        data,
+		ExpiresAt:       response.FormatTime(expiresAt),
+	}
+	if plan.TrainingPurpose != nil {
+		resp.TrainingPurpose = *plan.TrainingPurpose
+	}
+	return resp
+}
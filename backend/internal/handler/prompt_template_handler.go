@@ -0,0 +1,359 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ai-fitness-planner/backend/internal/api/request"
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// PromptTemplateHandler handles prompt template administration HTTP requests
+type PromptTemplateHandler struct {
+	*BaseHandler
+	templateService service.PromptTemplateService
+}
+
+// NewPromptTemplateHandler creates a new PromptTemplateHandler instance
+func NewPromptTemplateHandler(templateService service.PromptTemplateService) *PromptTemplateHandler {
+	return &PromptTemplateHandler{
+		BaseHandler:     NewBaseHandler(),
+		templateService: templateService,
+	}
+}
+
+// ListTemplates handles GET /api/v1/prompt-templates
+// @Summary List prompt templates
+// @Description Lists the stored prompt templates, optionally filtered by category and subcategory
+// @Tags PromptTemplates
+// @Produce json
+// @Security BearerAuth
+// @Param category query string false "Category (training, nutrition, assessment, safety, coach)"
+// @Param subcategory query string false "Subcategory, e.g. plan_generation"
+// @Success 200 {array} response.PromptTemplateInfo "Prompt templates"
+// @Failure 403 {object} response.BaseResponse "Not an admin"
+// @Router /prompt-templates [get]
+func (h *PromptTemplateHandler) ListTemplates(c *gin.Context) {
+	var query request.PromptTemplateQuery
+	if !h.BindQuery(c, &query) {
+		return
+	}
+
+	templates, err := h.templateService.List(c.Request.Context(), query.Category, query.Subcategory)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	items := make([]response.PromptTemplateInfo, 0, len(templates))
+	for _, t := range templates {
+		items = append(items, toPromptTemplateInfo(t))
+	}
+	h.Success(c, items)
+}
+
+// GetTemplate handles GET /api/v1/prompt-templates/:id
+// @Summary Get a prompt template
+// @Tags PromptTemplates
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} response.PromptTemplateInfo "Prompt template"
+// @Failure 404 {object} response.BaseResponse "Template not found"
+// @Router /prompt-templates/{id} [get]
+func (h *PromptTemplateHandler) GetTemplate(c *gin.Context) {
+	templateID, ok := h.templateID(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.templateService.Get(c.Request.Context(), templateID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, toPromptTemplateInfo(template))
+}
+
+// CreateTemplate handles POST /api/v1/prompt-templates
+// @Summary Create a prompt template version
+// @Description Adds a new template version. It is validated against sample variables and only used for generation once marked default.
+// @Tags PromptTemplates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request.CreatePromptTemplateRequest true "Template"
+// @Success 201 {object} response.PromptTemplateInfo "Created template"
+// @Failure 400 {object} response.BaseResponse "Template does not render"
+// @Router /prompt-templates [post]
+func (h *PromptTemplateHandler) CreateTemplate(c *gin.Context) {
+	var req request.CreatePromptTemplateRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	variables := make(model.JSONSlice, 0, len(req.Variables))
+	for _, v := range req.Variables {
+		variables = append(variables, v)
+	}
+
+	template, err := h.templateService.Create(c.Request.Context(), &model.PromptTemplate{
+		Category:    req.Category,
+		Subcategory: req.Subcategory,
+		Name:        req.Name,
+		Template:    req.Template,
+		Variables:   variables,
+		Description: req.Description,
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Created(c, toPromptTemplateInfo(template))
+}
+
+// UpdateTemplate handles PUT /api/v1/prompt-templates/:id
+// @Summary Update a prompt template
+// @Description Edits a template after validating it against sample variables. Edited templates are no longer overwritten by seeding.
+// @Tags PromptTemplates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param request body request.UpdatePromptTemplateRequest true "Fields to change"
+// @Success 200 {object} response.PromptTemplateInfo "Updated template"
+// @Failure 400 {object} response.BaseResponse "Template does not render"
+// @Failure 404 {object} response.BaseResponse "Template not found"
+// @Router /prompt-templates/{id} [put]
+func (h *PromptTemplateHandler) UpdateTemplate(c *gin.Context) {
+	templateID, ok := h.templateID(c)
+	if !ok {
+		return
+	}
+
+	var req request.UpdatePromptTemplateRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	template, err := h.templateService.Update(c.Request.Context(), templateID, &service.PromptTemplateUpdate{
+		Name:        req.Name,
+		Template:    req.Template,
+		Variables:   req.Variables,
+		Description: req.Description,
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, toPromptTemplateInfo(template))
+}
+
+// PreviewTemplate handles POST /api/v1/prompt-templates/:id/preview
+// @Summary Preview a rendered prompt template
+// @Description Renders the stored template, or the unsaved template text in the request, with sample variables overridden by the given ones
+// @Tags PromptTemplates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param request body request.PreviewPromptTemplateRequest false "Template text and variables"
+// @Success 200 {object} response.PromptTemplatePreviewResponse "Rendered prompt"
+// @Failure 400 {object} response.BaseResponse "Template does not render"
+// @Failure 404 {object} response.BaseResponse "Template not found"
+// @Router /prompt-templates/{id}/preview [post]
+func (h *PromptTemplateHandler) PreviewTemplate(c *gin.Context) {
+	templateID, ok := h.templateID(c)
+	if !ok {
+		return
+	}
+
+	var req request.PreviewPromptTemplateRequest
+	if c.Request.ContentLength != 0 && !h.BindJSON(c, &req) {
+		return
+	}
+
+	prompt, err := h.templateService.Preview(c.Request.Context(), templateID, req.Template, req.Variables)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, response.PromptTemplatePreviewResponse{Prompt: prompt})
+}
+
+// SetDefaultTemplate handles POST /api/v1/prompt-templates/:id/default
+// @Summary Mark a prompt template as default
+// @Description Makes the template the one used for generation in its category and subcategory
+// @Tags PromptTemplates
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} response.PromptTemplateInfo "Default template"
+// @Failure 404 {object} response.BaseResponse "Template not found"
+// @Router /prompt-templates/{id}/default [post]
+func (h *PromptTemplateHandler) SetDefaultTemplate(c *gin.Context) {
+	templateID, ok := h.templateID(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.templateService.SetDefault(c.Request.Context(), templateID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, toPromptTemplateInfo(template))
+}
+
+// SetTemplateRollout handles PUT /api/v1/prompt-templates/:id/rollout
+// @Summary Roll out a prompt template variant
+// @Description Serves a non-default template to a percentage of the users of its category and subcategory, assigned by a stable hash of the user ID. The rollouts of a subcategory add up to at most 100%; the remaining users get the default. 0 ends the rollout.
+// @Tags PromptTemplates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param request body request.PromptTemplateRolloutRequest true "Rollout percentage"
+// @Success 200 {object} response.PromptTemplateInfo "Rolled out template"
+// @Failure 400 {object} response.BaseResponse "Rollouts would exceed 100%"
+// @Failure 404 {object} response.BaseResponse "Template not found"
+// @Failure 409 {object} response.BaseResponse "Template is the default"
+// @Router /prompt-templates/{id}/rollout [put]
+func (h *PromptTemplateHandler) SetTemplateRollout(c *gin.Context) {
+	templateID, ok := h.templateID(c)
+	if !ok {
+		return
+	}
+
+	var req request.PromptTemplateRolloutRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	template, err := h.templateService.SetRollout(c.Request.Context(), templateID, *req.Percent)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, toPromptTemplateInfo(template))
+}
+
+// PromoteTemplate handles POST /api/v1/prompt-templates/:id/promote
+// @Summary Promote a prompt template variant
+// @Description Makes the template the default for all users of its category and subcategory and ends every rollout there
+// @Tags PromptTemplates
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} response.PromptTemplateInfo "New default template"
+// @Failure 404 {object} response.BaseResponse "Template not found"
+// @Router /prompt-templates/{id}/promote [post]
+func (h *PromptTemplateHandler) PromoteTemplate(c *gin.Context) {
+	templateID, ok := h.templateID(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.templateService.Promote(c.Request.Context(), templateID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, toPromptTemplateInfo(template))
+}
+
+// GetExperiment handles GET /api/v1/admin/prompt-experiments
+// @Summary Compare prompt template variants
+// @Description Compares the plans generated from the default template and each rolled out variant: how many were later adjusted or completed and the users' average satisfaction
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param category query string true "Category (training, nutrition)"
+// @Param subcategory query string true "Subcategory, e.g. plan_generation"
+// @Param days query int false "Only plans of the last days days (default 30)"
+// @Success 200 {object} response.PromptExperimentResponse "Variant comparison"
+// @Failure 403 {object} response.BaseResponse "Not an admin"
+// @Router /admin/prompt-experiments [get]
+func (h *PromptTemplateHandler) GetExperiment(c *gin.Context) {
+	var query request.PromptExperimentQuery
+	if !h.BindQuery(c, &query) {
+		return
+	}
+
+	experiment, err := h.templateService.Experiment(c.Request.Context(), query.Category, query.Subcategory, query.Days)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	resp := response.PromptExperimentResponse{
+		Category:    experiment.Category,
+		Subcategory: experiment.Subcategory,
+		Since:       response.FormatTime(experiment.Since),
+		Variants:    make([]response.PromptVariantInfo, 0, len(experiment.Variants)),
+	}
+	for _, v := range experiment.Variants {
+		info := response.PromptVariantInfo{
+			TemplateID:      v.Template.ID,
+			Name:            v.Template.Name,
+			IsDefault:       v.Template.IsDefault,
+			RolloutPercent:  v.Template.RolloutPercent,
+			Plans:           v.Stats.Plans,
+			AdjustedPlans:   v.Stats.AdjustedPlans,
+			CompletedPlans:  v.Stats.CompletedPlans,
+			Feedbacks:       v.Stats.Feedbacks,
+			AvgSatisfaction: v.Stats.AvgSatisfaction,
+		}
+		if v.Stats.Plans > 0 {
+			adjustment := float64(v.Stats.AdjustedPlans) / float64(v.Stats.Plans)
+			completion := float64(v.Stats.CompletedPlans) / float64(v.Stats.Plans)
+			info.AdjustmentRate = &adjustment
+			info.CompletionRate = &completion
+		}
+		resp.Variants = append(resp.Variants, info)
+	}
+	h.Success(c, resp)
+}
+
+// templateID parses the template ID path parameter
+func (h *PromptTemplateHandler) templateID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的模板ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// toPromptTemplateInfo converts a prompt template model to its response DTO
+func toPromptTemplateInfo(t *model.PromptTemplate) response.PromptTemplateInfo {
+	variables := make([]string, 0, len(t.Variables))
+	for _, v := range t.Variables {
+		variables = append(variables, fmt.Sprint(v))
+	}
+
+	return response.PromptTemplateInfo{
+		ID:             t.ID,
+		Category:       t.Category,
+		Subcategory:    t.Subcategory,
+		Name:           t.Name,
+		Template:       t.Template,
+		Variables:      variables,
+		IsDefault:      t.IsDefault,
+		IsCustomized:   t.IsCustomized,
+		RolloutPercent: t.RolloutPercent,
+		Description:    t.Description,
+		CreatedAt:      response.FormatTime(t.CreatedAt),
+		UpdatedAt:      response.FormatTime(t.UpdatedAt),
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseArchiveHandler handles admin HTTP requests for archived AI responses
+type ResponseArchiveHandler struct {
+	*BaseHandler
+	archiveService service.ResponseArchiveService
+}
+
+// NewResponseArchiveHandler creates a new ResponseArchiveHandler instance
+func NewResponseArchiveHandler(archiveService service.ResponseArchiveService) *ResponseArchiveHandler {
+	return &ResponseArchiveHandler{
+		BaseHandler:    NewBaseHandler(),
+		archiveService: archiveService,
+	}
+}
+
+// GetArchive handles GET /api/v1/admin/ai-response-archives/:id
+// @Summary Get an archived AI response
+// @Description Returns the AI response a generated plan was parsed from, with emails, phone numbers and credentials masked. The ID is linked from the task history as response_archive_id. Each read is logged.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Archive ID"
+// @Success 200 {object} response.AIResponseArchiveInfo "Archived response"
+// @Failure 403 {object} response.BaseResponse "Not an admin"
+// @Failure 404 {object} response.BaseResponse "Archive not found or expired"
+// @Router /admin/ai-response-archives/{id} [get]
+func (h *ResponseArchiveHandler) GetArchive(c *gin.Context) {
+	adminID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	archiveID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的存档ID")
+		return
+	}
+
+	archive, err := h.archiveService.Get(c.Request.Context(), adminID, archiveID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, response.AIResponseArchiveInfo{
+		ID:        archive.ID,
+		TaskID:    archive.TaskID,
+		UserID:    archive.UserID,
+		TaskType:  archive.TaskType,
+		PlanID:    archive.PlanID,
+		AIAPIID:   archive.AIAPIID,
+		Provider:  archive.Provider,
+		Model:     archive.Model,
+		Response:  archive.Response,
+		CreatedAt: response.FormatTime(archive.CreatedAt),
+		ExpiresAt: response.FormatTime(archive.ExpiresAt),
+	})
+}
@@ -46,14 +46,15 @@ func (h *StatisticsHandler) GetTrainingStatistics(c *gin.Context) {
 
 	resp := response.TrainingStatsResponse{
 		Period:            stats.Period,
-		StartDate:         stats.StartDate.Format("2006-01-02"),
-		EndDate:           stats.EndDate.Format("2006-01-02"),
+		StartDate:         response.FormatDate(stats.StartDate),
+		EndDate:           response.FormatDate(stats.EndDate),
 		TotalWorkouts:     stats.TotalWorkouts,
 		TotalDuration:     stats.TotalDuration,
 		TotalCalories:     stats.TotalCalories,
 		AverageRating:     stats.AverageRating,
 		WorkoutsByType:    stats.WorkoutsByType,
 		AverageDuration:   stats.AverageDuration,
+		FlaggedWorkouts:   stats.FlaggedWorkouts,
 		HasSufficientData: stats.HasSufficientData,
 		Message:           stats.Message,
 	}
@@ -77,16 +78,16 @@ func (h *StatisticsHandler) GetProgressReport(c *gin.Context) {
 
 	resp := response.ProgressReportResponse{
 		CurrentPeriod: &response.PeriodSummaryInfo{
-			StartDate:     report.CurrentPeriod.StartDate.Format("2006-01-02"),
-			EndDate:       report.CurrentPeriod.EndDate.Format("2006-01-02"),
+			StartDate:     response.FormatDate(report.CurrentPeriod.StartDate),
+			EndDate:       response.FormatDate(report.CurrentPeriod.EndDate),
 			TotalWorkouts: report.CurrentPeriod.TotalWorkouts,
 			TotalDuration: report.CurrentPeriod.TotalDuration,
 			TotalCalories: report.CurrentPeriod.TotalCalories,
 			AverageRating: report.CurrentPeriod.AverageRating,
 		},
 		PreviousPeriod: &response.PeriodSummaryInfo{
-			StartDate:     report.PreviousPeriod.StartDate.Format("2006-01-02"),
-			EndDate:       report.PreviousPeriod.EndDate.Format("2006-01-02"),
+			StartDate:     response.FormatDate(report.PreviousPeriod.StartDate),
+			EndDate:       response.FormatDate(report.PreviousPeriod.EndDate),
 			TotalWorkouts: report.PreviousPeriod.TotalWorkouts,
 			TotalDuration: report.PreviousPeriod.TotalDuration,
 			TotalCalories: report.PreviousPeriod.TotalCalories,
@@ -142,18 +143,22 @@ func (h *StatisticsHandler) GetTrends(c *gin.Context) {
 	dataPoints := make([]response.TrendPointInfo, 0, len(trends.DataPoints))
 	for _, dp := range trends.DataPoints {
 		dataPoints = append(dataPoints, response.TrendPointInfo{
-			PeriodLabel:   dp.PeriodLabel,
-			StartDate:     dp.StartDate.Format("2006-01-02"),
-			EndDate:       dp.EndDate.Format("2006-01-02"),
-			TotalWorkouts: dp.TotalWorkouts,
-			TotalDuration: dp.TotalDuration,
-			TotalCalories: dp.TotalCalories,
-			AverageRating: dp.AverageRating,
+			PeriodLabel:       dp.PeriodLabel,
+			StartDate:         response.FormatDate(dp.StartDate),
+			EndDate:           response.FormatDate(dp.EndDate),
+			Partial:           dp.Partial,
+			TotalWorkouts:     dp.TotalWorkouts,
+			TotalDuration:     dp.TotalDuration,
+			TotalCalories:     dp.TotalCalories,
+			AverageRating:     dp.AverageRating,
+			Tonnage:           dp.Tonnage,
+			RelativeIntensity: dp.RelativeIntensity,
 		})
 	}
 
 	resp := response.TrendsReportResponse{
 		Period:            trends.Period,
+		WeekStart:         trends.WeekStart,
 		DataPoints:        dataPoints,
 		HasSufficientData: trends.HasSufficientData,
 		Message:           trends.Message,
@@ -162,6 +167,129 @@ func (h *StatisticsHandler) GetTrends(c *gin.Context) {
 	h.Success(c, resp)
 }
 
+// GetProgression handles GET /api/v1/stats/progression
+func (h *StatisticsHandler) GetProgression(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var params request.ProgressionParams
+	if !h.BindQuery(c, &params) {
+		return
+	}
+
+	progression, err := h.statsService.GetExerciseProgression(c.Request.Context(), userID, params.Exercise, params.Days)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	sessions := make([]response.ProgressionSessionInfo, 0, len(progression.Sessions))
+	for _, s := range progression.Sessions {
+		sessions = append(sessions, response.ProgressionSessionInfo{
+			Date:               response.FormatDate(s.Date),
+			RecordID:           s.RecordID,
+			Sets:               s.Sets,
+			RepsPerSet:         s.RepsPerSet,
+			WeightUsed:         s.WeightUsed,
+			TopWeight:          s.TopWeight,
+			TopReps:            s.TopReps,
+			TotalReps:          s.TotalReps,
+			Volume:             s.Volume,
+			EstimatedOneRepMax: s.EstimatedOneRepMax,
+		})
+	}
+
+	resp := response.ExerciseProgressionResponse{
+		Exercise:          progression.Exercise,
+		Days:              progression.Days,
+		Sessions:          sessions,
+		HasSufficientData: progression.HasSufficientData,
+		Message:           progression.Message,
+	}
+	if t := progression.Trend; t != nil {
+		resp.Trend = &response.ProgressionTrendInfo{
+			StartOneRepMax: t.StartOneRepMax,
+			EndOneRepMax:   t.EndOneRepMax,
+			Change:         t.Change,
+			ChangePercent:  t.ChangePercent,
+			WeeklyChange:   t.WeeklyChange,
+			Direction:      t.Direction,
+		}
+	}
+	if s := progression.Suggestion; s != nil {
+		resp.Suggestion = &response.LoadSuggestionInfo{
+			Action:     s.Action,
+			Weight:     s.Weight,
+			Sets:       s.Sets,
+			RepsLow:    s.RepsLow,
+			RepsHigh:   s.RepsHigh,
+			TargetReps: s.TargetReps,
+			Reason:     s.Reason,
+		}
+	}
+
+	h.Success(c, resp)
+}
+
+// GetOneRepMax handles GET /api/v1/stats/one-rep-max
+func (h *StatisticsHandler) GetOneRepMax(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var params request.OneRepMaxParams
+	if !h.BindQuery(c, &params) {
+		return
+	}
+
+	report, err := h.statsService.GetOneRepMaxReport(c.Request.Context(), userID, params.Days)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	lifts := make([]response.LiftOneRepMaxInfo, 0, len(report.Lifts))
+	for _, l := range report.Lifts {
+		info := response.LiftOneRepMaxInfo{
+			Lift:      l.Lift,
+			OneRepMax: l.OneRepMax,
+			Source:    l.Source,
+			Epley:     l.Epley,
+			Brzycki:   l.Brzycki,
+		}
+		if s := l.BestSet; s != nil {
+			info.BestSet = &response.BestSetInfo{
+				RecordID: s.RecordID,
+				Date:     response.FormatDate(s.Date),
+				Exercise: s.Exercise,
+				Weight:   s.Weight,
+				Reps:     s.Reps,
+			}
+		}
+		if lv := l.Level; lv != nil {
+			info.Level = &response.LiftLevelInfo{
+				BodyweightRatio:    lv.BodyweightRatio,
+				Level:              lv.Level,
+				NextLevel:          lv.NextLevel,
+				NextLevelOneRepMax: lv.NextLevelOneRepMax,
+			}
+		}
+		lifts = append(lifts, info)
+	}
+
+	h.Success(c, response.OneRepMaxResponse{
+		Days:              report.Days,
+		BodyWeight:        report.BodyWeight,
+		Gender:            report.Gender,
+		Lifts:             lifts,
+		HasSufficientData: report.HasSufficientData,
+		Message:           report.Message,
+	})
+}
+
 func (h *StatisticsHandler) getTrainingStats(c *gin.Context, userID int64, params request.TrainingStatsParams) (*service.TrainingStats, error) {
 	startProvided := params.StartDate != "" || params.EndDate != ""
 	if startProvided {
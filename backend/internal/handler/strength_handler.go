@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/api/request"
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// StrengthHandler handles strength profile HTTP requests
+type StrengthHandler struct {
+	*BaseHandler
+	strengthService service.StrengthProfileService
+}
+
+// NewStrengthHandler creates a new StrengthHandler instance
+func NewStrengthHandler(strengthService service.StrengthProfileService) *StrengthHandler {
+	return &StrengthHandler{
+		BaseHandler:     NewBaseHandler(),
+		strengthService: strengthService,
+	}
+}
+
+// GetStrengthProfile handles GET /api/v1/strength-profile
+// @Summary Get strength profile
+// @Description Current 1RM per main lift, from manual entry or personal records in training logs
+// @Tags Strength
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.StrengthProfileResponse "Strength profile"
+// @Failure 401 {object} response.BaseResponse "Unauthorized"
+// @Router /strength-profile [get]
+func (h *StrengthHandler) GetStrengthProfile(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	entries, err := h.strengthService.GetProfile(c.Request.Context(), userID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	lifts := make([]response.StrengthProfileEntryInfo, 0, len(entries))
+	for _, e := range entries {
+		lifts = append(lifts, toStrengthProfileEntryInfo(e))
+	}
+
+	h.Success(c, response.StrengthProfileResponse{Lifts: lifts})
+}
+
+// SetOneRepMax handles PUT /api/v1/strength-profile/:lift
+// @Summary Set 1RM for a main lift
+// @Tags Strength
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param lift path string true "squat, bench_press, deadlift or overhead_press"
+// @Param request body request.SetOneRepMaxRequest true "1RM"
+// @Success 200 {object} response.StrengthProfileEntryInfo "Updated entry"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Router /strength-profile/{lift} [put]
+func (h *StrengthHandler) SetOneRepMax(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.SetOneRepMaxRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	achievedAt := time.Now()
+	if req.AchievedAt != nil {
+		parsed, err := time.ParseInLocation("2006-01-02", *req.AchievedAt, time.Local)
+		if err != nil {
+			h.BadRequest(c, "无效的日期格式")
+			return
+		}
+		achievedAt = parsed
+	}
+
+	entry, err := h.strengthService.SetOneRepMax(c.Request.Context(), userID, c.Param("lift"), req.OneRepMax, achievedAt)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, toStrengthProfileEntryInfo(entry))
+}
+
+// toStrengthProfileEntryInfo converts a strength profile entry to its response DTO
+func toStrengthProfileEntryInfo(e *model.StrengthProfileEntry) response.StrengthProfileEntryInfo {
+	info := response.StrengthProfileEntryInfo{
+		Lift:       e.Lift,
+		OneRepMax:  e.OneRepMax,
+		Source:     e.Source,
+		AchievedAt: response.FormatDate(e.AchievedAt),
+		UpdatedAt:  response.FormatTime(e.UpdatedAt),
+	}
+	if e.SourceRecordID != nil {
+		info.SourceRecordID = *e.SourceRecordID
+	}
+	return info
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/api/request"
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// SyncHandler handles offline sync HTTP requests from mobile clients
+type SyncHandler struct {
+	*BaseHandler
+	syncService service.SyncService
+}
+
+// NewSyncHandler creates a new SyncHandler instance
+func NewSyncHandler(syncService service.SyncService) *SyncHandler {
+	return &SyncHandler{
+		BaseHandler: NewBaseHandler(),
+		syncService: syncService,
+	}
+}
+
+// Sync handles POST /api/v1/sync
+// @Summary Sync offline changes
+// @Description Pushes training and nutrition records created, edited or deleted offline (keyed by client-generated UUIDs), then returns every record changed since last_synced_at, deleted ones as tombstones. Records changed on both sides are resolved per entity (server_wins or client_wins) and listed in conflicts.
+// @Tags Sync
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request.SyncRequest true "Offline changes and sync cursor"
+// @Success 200 {object} response.SyncResponse "Changes to apply locally and the next cursor"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 401 {object} response.BaseResponse "Unauthorized"
+// @Router /sync [post]
+func (h *SyncHandler) Sync(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.SyncRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	lastSyncedAt, err := parseOptionalTimestamp(req.LastSyncedAt)
+	if err != nil {
+		h.BadRequest(c, "无效的同步时间格式")
+		return
+	}
+
+	syncReq := &service.SyncRequest{LastSyncedAt: lastSyncedAt}
+	for _, item := range req.TrainingRecords {
+		change := service.SyncTrainingChange{ClientID: item.ClientID, Deleted: item.Deleted}
+		if !item.Deleted {
+			record, err := toSyncedTrainingRecord(item)
+			if err != nil {
+				h.BadRequest(c, "无效的训练记录时间格式")
+				return
+			}
+			change.Record = record
+		}
+		syncReq.TrainingRecords = append(syncReq.TrainingRecords, change)
+	}
+	for _, item := range req.NutritionRecords {
+		change := service.SyncNutritionChange{ClientID: item.ClientID, Deleted: item.Deleted}
+		if !item.Deleted {
+			record, err := toSyncedNutritionRecord(item)
+			if err != nil {
+				h.BadRequest(c, "无效的用餐日期格式")
+				return
+			}
+			change.Record = record
+		}
+		syncReq.NutritionRecords = append(syncReq.NutritionRecords, change)
+	}
+
+	result, err := h.syncService.Sync(c.Request.Context(), userID, syncReq)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	resp := response.SyncResponse{
+		ServerTime:       response.FormatTime(result.ServerTime),
+		TrainingRecords:  make([]response.SyncTrainingRecordInfo, 0, len(result.TrainingRecords)),
+		NutritionRecords: make([]response.SyncNutritionRecordInfo, 0, len(result.NutritionRecords)),
+		Conflicts:        make([]response.SyncConflictInfo, 0, len(result.Conflicts)),
+		Rejected:         make([]response.SyncRejectionInfo, 0, len(result.Rejected)),
+	}
+	for _, r := range result.TrainingRecords {
+		resp.TrainingRecords = append(resp.TrainingRecords, toSyncTrainingRecordInfo(r))
+	}
+	for _, r := range result.NutritionRecords {
+		resp.NutritionRecords = append(resp.NutritionRecords, toSyncNutritionRecordInfo(r))
+	}
+	for _, conflict := range result.Conflicts {
+		resp.Conflicts = append(resp.Conflicts, response.SyncConflictInfo{
+			Entity:     conflict.Entity,
+			ClientID:   conflict.ClientID,
+			Resolution: conflict.Resolution,
+		})
+	}
+	for _, rejection := range result.Rejected {
+		resp.Rejected = append(resp.Rejected, response.SyncRejectionInfo{
+			Entity:   rejection.Entity,
+			ClientID: rejection.ClientID,
+			Reason:   rejection.Reason,
+		})
+	}
+
+	h.Success(c, resp)
+}
+
+func toSyncedTrainingRecord(item request.SyncTrainingRecord) (*model.TrainingRecord, error) {
+	record := &model.TrainingRecord{
+		PlanID:          item.PlanID,
+		WorkoutType:     item.WorkoutType,
+		DurationMinutes: item.DurationMinutes,
+		Notes:           item.Notes,
+		Rating:          item.Rating,
+		InjuryReport:    item.InjuryReport,
+	}
+	if item.WorkoutDate != "" {
+		workoutDate, err := time.ParseInLocation("2006-01-02", item.WorkoutDate, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		record.WorkoutDate = workoutDate
+	}
+	var err error
+	if record.StartedAt, err = parseOptionalTimestamp(item.StartedAt); err != nil {
+		return nil, err
+	}
+	if record.EndedAt, err = parseOptionalTimestamp(item.EndedAt); err != nil {
+		return nil, err
+	}
+	if item.Exercises != nil {
+		record.Exercises = model.JSONMap(item.Exercises)
+	}
+	if item.PerformanceData != nil {
+		record.PerformanceData = model.JSONMap(item.PerformanceData)
+	}
+	return record, nil
+}
+
+func toSyncedNutritionRecord(item request.SyncNutritionRecord) (*model.NutritionRecord, error) {
+	record := &model.NutritionRecord{
+		MealTime: item.MealType,
+		Calories: item.Calories,
+		Protein:  item.Protein,
+		Carbs:    item.Carbs,
+		Fat:      item.Fat,
+		Fiber:    item.Fiber,
+		Foods:    model.JSONMap{},
+	}
+	if item.MealDate != "" {
+		mealDate, err := time.ParseInLocation("2006-01-02", item.MealDate, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		record.MealDate = mealDate
+	}
+	if item.Foods != nil {
+		record.Foods = model.JSONMap(item.Foods)
+	}
+	return record, nil
+}
+
+func toSyncTrainingRecordInfo(r *model.TrainingRecord) response.SyncTrainingRecordInfo {
+	return response.SyncTrainingRecordInfo{
+		ID:              r.ID,
+		ClientID:        r.ClientID,
+		Deleted:         r.DeletedAt.Valid,
+		PlanID:          r.PlanID,
+		WorkoutDate:     response.FormatDate(r.WorkoutDate),
+		WorkoutType:     r.WorkoutType,
+		DurationMinutes: r.DurationMinutes,
+		StartedAt:       formatOptionalTimestamp(r.StartedAt),
+		EndedAt:         formatOptionalTimestamp(r.EndedAt),
+		DurationFlag:    r.DurationFlag,
+		Exercises:       r.Exercises,
+		PerformanceData: r.PerformanceData,
+		Notes:           r.Notes,
+		Rating:          r.Rating,
+		InjuryReport:    r.InjuryReport,
+		UpdatedAt:       response.FormatTime(r.UpdatedAt),
+	}
+}
+
+func toSyncNutritionRecordInfo(r *model.NutritionRecord) response.SyncNutritionRecordInfo {
+	return response.SyncNutritionRecordInfo{
+		ID:        r.ID,
+		ClientID:  r.ClientID,
+		Deleted:   r.DeletedAt.Valid,
+		MealDate:  response.FormatDate(r.MealDate),
+		MealType:  r.MealTime,
+		Calories:  r.Calories,
+		Protein:   r.Protein,
+		Carbs:     r.Carbs,
+		Fat:       r.Fat,
+		Fiber:     r.Fiber,
+		Foods:     r.Foods,
+		UpdatedAt: response.FormatTime(r.UpdatedAt),
+	}
+}
+
+func formatOptionalTimestamp(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := response.FormatTime(*t)
+	return &s
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/api/request"
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// TaskOpsHandler handles admin HTTP requests on the generation task queue
+type TaskOpsHandler struct {
+	*BaseHandler
+	taskOpsService service.TaskOpsService
+}
+
+// NewTaskOpsHandler creates a new TaskOpsHandler instance
+func NewTaskOpsHandler(taskOpsService service.TaskOpsService) *TaskOpsHandler {
+	return &TaskOpsHandler{
+		BaseHandler:    NewBaseHandler(),
+		taskOpsService: taskOpsService,
+	}
+}
+
+// ListStuckTasks handles GET /api/v1/admin/queue/stuck-tasks
+// @Summary List stuck generation tasks
+// @Description Lists queued generation tasks whose current attempt has been processing for longer than the threshold, longest first
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param older_than_minutes query int false "Processing time threshold in minutes (default 10)"
+// @Success 200 {object} response.StuckTaskListResponse "Stuck tasks"
+// @Failure 403 {object} response.BaseResponse "Not an admin"
+// @Router /admin/queue/stuck-tasks [get]
+func (h *TaskOpsHandler) ListStuckTasks(c *gin.Context) {
+	var query request.StuckTaskQuery
+	if !h.BindQuery(c, &query) {
+		return
+	}
+
+	tasks, err := h.taskOpsService.ListStuckTasks(c.Request.Context(), time.Duration(query.OlderThanMinutes)*time.Minute)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	now := time.Now()
+	infos := make([]response.QueuedTaskInfo, 0, len(tasks))
+	for _, task := range tasks {
+		info := response.QueuedTaskInfo{
+			TaskID:      task.TaskID,
+			TaskType:    task.TaskType,
+			UserID:      task.UserID,
+			AIAPIID:     task.AIAPIID,
+			Provider:    task.Provider,
+			State:       task.State,
+			Attempt:     task.Attempt,
+			MaxAttempts: task.MaxAttempts,
+			LastError:   task.LastError,
+			EnqueuedAt:  response.FormatTime(task.EnqueuedAt),
+		}
+		if !task.ClaimedAt.IsZero() {
+			info.ClaimedAt = response.FormatTime(task.ClaimedAt)
+			info.ProcessingSeconds = int64(now.Sub(task.ClaimedAt).Seconds())
+		}
+		if !task.Deadline.IsZero() {
+			info.Deadline = response.FormatTime(task.Deadline)
+		}
+		infos = append(infos, info)
+	}
+
+	h.Success(c, response.StuckTaskListResponse{Tasks: infos})
+}
+
+// RequeueTask handles POST /api/v1/admin/queue/tasks/:id/requeue
+// @Summary Requeue a generation task
+// @Description Moves a processing or scheduled task to the front of the queue with its attempts reset. A worker still running the task is not stopped.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Task ID"
+// @Success 200 {object} response.BaseResponse "Task requeued"
+// @Failure 403 {object} response.BaseResponse "Not an admin"
+// @Failure 404 {object} response.BaseResponse "Task not in the queue"
+// @Failure 409 {object} response.BaseResponse "Task already pending"
+// @Router /admin/queue/tasks/{id}/requeue [post]
+func (h *TaskOpsHandler) RequeueTask(c *gin.Context) {
+	adminID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.taskOpsService.RequeueTask(c.Request.Context(), adminID, c.Param("id")); err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.SuccessWithMessage(c, "任务已重新排队", nil)
+}
+
+// FailTask handles POST /api/v1/admin/queue/tasks/:id/fail
+// @Summary Fail a generation task
+// @Description Removes a task from the queue and records it as failed with the given reason, releasing the user's generation lock so they can submit again
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Task ID"
+// @Param request body request.FailTaskRequest true "Failure reason shown to the user"
+// @Success 200 {object} response.BaseResponse "Task failed"
+// @Failure 403 {object} response.BaseResponse "Not an admin"
+// @Failure 404 {object} response.BaseResponse "Task not in the queue"
+// @Router /admin/queue/tasks/{id}/fail [post]
+func (h *TaskOpsHandler) FailTask(c *gin.Context) {
+	adminID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.FailTaskRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	if err := h.taskOpsService.FailTask(c.Request.Context(), adminID, c.Param("id"), req.Reason); err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.SuccessWithMessage(c, "任务已终止", nil)
+}
+
+// GetQueueDepth handles GET /api/v1/admin/queue/depth
+// @Summary Get generation queue depth per provider
+// @Description Counts pending, scheduled and processing generation tasks by the AI provider they were submitted with; rule-based generations are counted as "template"
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.QueueDepthResponse "Queue depth"
+// @Failure 403 {object} response.BaseResponse "Not an admin"
+// @Router /admin/queue/depth [get]
+func (h *TaskOpsHandler) GetQueueDepth(c *gin.Context) {
+	depths, err := h.taskOpsService.QueueDepth(c.Request.Context())
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	resp := response.QueueDepthResponse{Providers: make([]response.ProviderQueueDepthInfo, 0, len(depths))}
+	for _, depth := range depths {
+		resp.Providers = append(resp.Providers, response.ProviderQueueDepthInfo{
+			Provider:  depth.Provider,
+			Pending:   depth.Pending,
+			Scheduled: depth.Scheduled,
+			Active:    depth.Active,
+		})
+		resp.Total += depth.Pending + depth.Scheduled + depth.Active
+	}
+
+	h.Success(c, resp)
+}
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/api/request"
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// TrainingConstraintHandler handles doctor/physio training constraint HTTP requests
+type TrainingConstraintHandler struct {
+	*BaseHandler
+	constraintService service.TrainingConstraintService
+}
+
+// NewTrainingConstraintHandler creates a new TrainingConstraintHandler instance
+func NewTrainingConstraintHandler(constraintService service.TrainingConstraintService) *TrainingConstraintHandler {
+	return &TrainingConstraintHandler{
+		BaseHandler:       NewBaseHandler(),
+		constraintService: constraintService,
+	}
+}
+
+// ListConstraints handles GET /api/v1/training-constraints
+// @Summary List training constraints
+// @Description Medical restrictions (e.g. "no overhead pressing for 6 weeks") that plan generation honors, newest first
+// @Tags TrainingConstraints
+// @Produce json
+// @Security BearerAuth
+// @Param active_only query bool false "Drop expired constraints"
+// @Success 200 {object} response.TrainingConstraintListResponse "Training constraints"
+// @Failure 401 {object} response.BaseResponse "Unauthorized"
+// @Router /training-constraints [get]
+func (h *TrainingConstraintHandler) ListConstraints(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var query request.TrainingConstraintQuery
+	if !h.BindQuery(c, &query) {
+		return
+	}
+
+	constraints, err := h.constraintService.List(c.Request.Context(), userID, query.ActiveOnly)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	now := time.Now()
+	infos := make([]response.TrainingConstraintInfo, 0, len(constraints))
+	for _, tc := range constraints {
+		infos = append(infos, toTrainingConstraintInfo(tc, now))
+	}
+
+	h.Success(c, response.TrainingConstraintListResponse{Constraints: infos})
+}
+
+// CreateConstraint handles POST /api/v1/training-constraints
+// @Summary Create training constraint
+// @Description Records a restriction. Restricted movements are matched against exercise names; generated plans that include one are rejected and regenerated. Users with an active plan are notified to regenerate it.
+// @Tags TrainingConstraints
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request.CreateTrainingConstraintRequest true "Training constraint"
+// @Success 201 {object} response.TrainingConstraintInfo "Created constraint"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Router /training-constraints [post]
+func (h *TrainingConstraintHandler) CreateConstraint(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.CreateTrainingConstraintRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	startsOn, expiresOn, ok := h.parseDates(c, req.StartsOn, req.ExpiresOn)
+	if !ok {
+		return
+	}
+
+	constraint, err := h.constraintService.Create(c.Request.Context(), userID, &service.TrainingConstraintRequest{
+		Source:              req.Source,
+		Description:         &req.Description,
+		RestrictedMovements: req.RestrictedMovements,
+		Attachments:         req.Attachments,
+		StartsOn:            startsOn,
+		ExpiresOn:           expiresOn,
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Created(c, toTrainingConstraintInfo(constraint, time.Now()))
+}
+
+// UpdateConstraint handles PUT /api/v1/training-constraints/:id
+// @Summary Update training constraint
+// @Description Omitted fields are left unchanged; restricted movements and attachments, when given, replace the whole list
+// @Tags TrainingConstraints
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Training constraint ID"
+// @Param request body request.UpdateTrainingConstraintRequest true "Fields to update"
+// @Success 200 {object} response.TrainingConstraintInfo "Updated constraint"
+// @Failure 404 {object} response.BaseResponse "Constraint not found"
+// @Router /training-constraints/{id} [put]
+func (h *TrainingConstraintHandler) UpdateConstraint(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	constraintID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的训练限制ID")
+		return
+	}
+
+	var req request.UpdateTrainingConstraintRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	startsOn, expiresOn, ok := h.parseDates(c, req.StartsOn, req.ExpiresOn)
+	if !ok {
+		return
+	}
+
+	constraint, err := h.constraintService.Update(c.Request.Context(), userID, constraintID, &service.TrainingConstraintRequest{
+		Source:              req.Source,
+		Description:         req.Description,
+		RestrictedMovements: req.RestrictedMovements,
+		Attachments:         req.Attachments,
+		StartsOn:            startsOn,
+		ExpiresOn:           expiresOn,
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, toTrainingConstraintInfo(constraint, time.Now()))
+}
+
+// DeleteConstraint handles DELETE /api/v1/training-constraints/:id
+// @Summary Delete training constraint
+// @Tags TrainingConstraints
+// @Security BearerAuth
+// @Param id path int true "Training constraint ID"
+// @Success 204 "Deleted"
+// @Failure 404 {object} response.BaseResponse "Constraint not found"
+// @Router /training-constraints/{id} [delete]
+func (h *TrainingConstraintHandler) DeleteConstraint(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	constraintID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的训练限制ID")
+		return
+	}
+
+	if err := h.constraintService.Delete(c.Request.Context(), userID, constraintID); err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.NoContent(c)
+}
+
+// parseDates parses the optional starts_on and expires_on fields
+func (h *TrainingConstraintHandler) parseDates(c *gin.Context, startsOn, expiresOn *string) (*time.Time, *time.Time, bool) {
+	var start, expiry *time.Time
+	for _, field := range []struct {
+		value *string
+		dest  **time.Time
+	}{{startsOn, &start}, {expiresOn, &expiry}} {
+		if field.value == nil {
+			continue
+		}
+		day, err := time.ParseInLocation("2006-01-02", *field.value, time.Local)
+		if err != nil {
+			h.BadRequest(c, "无效的日期格式")
+			return nil, nil, false
+		}
+		*field.dest = &day
+	}
+	return start, expiry, true
+}
+
+// toTrainingConstraintInfo converts a training constraint to its response DTO
+func toTrainingConstraintInfo(tc *model.TrainingConstraint, now time.Time) response.TrainingConstraintInfo {
+	attachments := make([]string, 0, len(tc.Attachments))
+	for _, a := range tc.Attachments {
+		attachments = append(attachments, fmt.Sprint(a))
+	}
+
+	info := response.TrainingConstraintInfo{
+		ID:                  tc.ID,
+		Source:              tc.Source,
+		Description:         tc.Description,
+		RestrictedMovements: tc.Movements(),
+		Attachments:         attachments,
+		StartsOn:            response.FormatDate(tc.StartsOn),
+		IsActive:            tc.ActiveOn(now),
+		UpdatedAt:           response.FormatTime(tc.UpdatedAt),
+	}
+	if tc.ExpiresOn != nil {
+		expiresOn := response.FormatDate(*tc.ExpiresOn)
+		info.ExpiresOn = &expiresOn
+	}
+	return info
+}
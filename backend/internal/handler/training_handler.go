@@ -2,7 +2,11 @@ package handler
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ai-fitness-planner/backend/internal/api/request"
@@ -42,11 +46,14 @@ func (h *TrainingHandler) GeneratePlan(c *gin.Context) {
 
 	// Convert to service request
 	serviceReq := &service.GeneratePlanRequest{
-		PlanName:        req.PlanName,
-		DurationWeeks:   req.DurationWeeks,
-		Goal:            req.Goal,
-		DifficultyLevel: req.DifficultyLevel,
-		AIAPIID:         req.AIAPIID,
+		PlanName:           req.PlanName,
+		DurationWeeks:      req.DurationWeeks,
+		Goal:               req.Goal,
+		DifficultyLevel:    req.DifficultyLevel,
+		AIAPIID:            req.AIAPIID,
+		IncludeDeload:      req.IncludeDeload,
+		DeloadEvery:        req.DeloadEvery,
+		PeriodizationStyle: req.PeriodizationStyle,
 	}
 
 	taskResp, err := h.trainingService.GeneratePlan(c.Request.Context(), userID, serviceReq)
@@ -55,85 +62,992 @@ func (h *TrainingHandler) GeneratePlan(c *gin.Context) {
 		return
 	}
 
+	if taskResp.Existing {
+		h.RefundAIQuota(c)
+	}
+
 	resp := response.TaskResponse{
 		TaskID:        taskResp.TaskID,
 		Status:        taskResp.Status,
 		Progress:      0,
 		EstimatedTime: 60, // Estimated 60 seconds
+		Existing:      taskResp.Existing,
+	}
+
+	h.Success(c, resp)
+}
+
+// GetPlanStatus handles GET /api/v1/training-plans/tasks/:taskId
+func (h *TrainingHandler) GetPlanStatus(c *gin.Context) {
+	taskID := c.Param("taskId")
+	if taskID == "" {
+		h.BadRequest(c, "任务ID不能为空")
+		return
+	}
+
+	taskStatus, err := h.trainingService.GetPlanStatus(c.Request.Context(), taskID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	resp := response.TaskResponse{
+		TaskID:   taskStatus.TaskID,
+		Status:   taskStatus.Status,
+		Progress: taskStatus.Progress,
+	}
+
+	if taskStatus.Error != "" {
+		resp.ErrorMessage = taskStatus.Error
+	}
+	resp.Cooldown = toProviderCooldownInfo(taskStatus.Cooldown)
+	resp.ExpiresAt = formatTaskExpiry(taskStatus.ExpiresAt)
+
+	if taskStatus.Result != nil {
+		resp.Result = h.buildPlanInfo(taskStatus.Result)
+	}
+
+	h.Success(c, resp)
+}
+
+// ListTasks handles GET /api/v1/training-plans/tasks
+// @Summary List generation tasks
+// @Description The user's most recent training plan generation and adjustment tasks, newest first, so a task can be found again after navigating away. plan_id is set once a task has completed.
+// @Tags Training
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.TaskListResponse "Tasks"
+// @Router /training-plans/tasks [get]
+func (h *TrainingHandler) ListTasks(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.trainingService.ListTasks(c.Request.Context(), userID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	infos := make([]response.TaskSummaryInfo, 0, len(tasks))
+	for _, task := range tasks {
+		info := response.TaskSummaryInfo{
+			TaskID:       task.TaskID,
+			Status:       task.Status,
+			Progress:     task.Progress,
+			Message:      task.Message,
+			ErrorMessage: task.Error,
+			CreatedAt:    response.FormatTime(task.CreatedAt),
+			UpdatedAt:    response.FormatTime(task.UpdatedAt),
+			ExpiresAt:    formatTaskExpiry(task.ExpiresAt),
+		}
+		if task.Result != nil {
+			info.PlanID = &task.Result.ID
+		}
+		infos = append(infos, info)
+	}
+
+	h.Success(c, response.TaskListResponse{Tasks: infos})
+}
+
+// planStreamHeartbeat keeps idle SSE connections open through proxies
+const planStreamHeartbeat = 15 * time.Second
+
+// ListTaskHistory handles GET /api/v1/training-plans/tasks/history
+// @Summary List finished generation tasks
+// @Description The user's last 10 finished generation tasks with their parameters, outcome and duration, kept after the tasks expire from the task list.
+// @Tags Training
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.TaskHistoryResponse "Finished tasks, newest first"
+// @Router /training-plans/tasks/history [get]
+func (h *TrainingHandler) ListTaskHistory(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.trainingService.ListTaskHistory(c.Request.Context(), userID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, response.TaskHistoryResponse{Tasks: toGenerationTaskInfos(tasks)})
+}
+
+// RetryTask handles POST /api/v1/training-plans/tasks/:taskId/retry
+// @Summary Retry a failed generation task
+// @Description Queues a failed generation task again under the same task ID with the parameters it was submitted with. A retry does not count against the AI generation rate limit.
+// @Tags Training
+// @Produce json
+// @Security BearerAuth
+// @Param taskId path string true "Task ID"
+// @Success 200 {object} response.TaskResponse "Task queued again"
+// @Failure 404 {object} response.BaseResponse "Task not found"
+// @Failure 409 {object} response.BaseResponse "Task has not failed"
+// @Router /training-plans/tasks/{taskId}/retry [post]
+func (h *TrainingHandler) RetryTask(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	taskResp, err := h.trainingService.RetryTask(c.Request.Context(), userID, c.Param("taskId"))
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	if taskResp.Existing {
+		h.RefundAIQuota(c)
+	}
+
+	h.Success(c, response.TaskResponse{
+		TaskID:        taskResp.TaskID,
+		Status:        taskResp.Status,
+		Progress:      0,
+		EstimatedTime: 60,
+		Existing:      taskResp.Existing,
+	})
+}
+
+// StreamPlanStatus handles GET /api/v1/training-plans/tasks/:taskId/stream
+// @Summary Stream plan generation
+// @Description Server-sent events for a generation task: "progress" ({status, progress, message, cooldown}) on every change, "chunk" ({text}) for each piece of plan text, "reset" when a retry discards the text so far, then "completed" (the plan) or "failed" ({error}) before the stream ends. cooldown is set while generation waits for a throttling provider.
+// @Tags Training
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param taskId path string true "Task ID"
+// @Success 200 {string} string "Event stream"
+// @Failure 404 {object} response.BaseResponse "Task not found"
+// @Router /training-plans/tasks/{taskId}/stream [get]
+func (h *TrainingHandler) StreamPlanStatus(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+	taskID := c.Param("taskId")
+
+	// Fail with a normal JSON error before switching to SSE
+	task, changed, err := h.trainingService.WatchPlanTask(c.Request.Context(), userID, taskID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// The server's WriteTimeout would cut the stream; extend it per event
+	rc := http.NewResponseController(c.Writer)
+	send := func(event string, data interface{}) {
+		rc.SetWriteDeadline(time.Now().Add(2 * planStreamHeartbeat))
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+	}
+
+	heartbeat := time.NewTicker(planStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	var lastStatus string
+	var lastCooldown *service.ProviderCooldown
+	lastProgress, sent := -1, 0
+	for {
+		if task.Status != lastStatus || task.Progress != lastProgress || task.Cooldown != lastCooldown {
+			send("progress", gin.H{
+				"status":   task.Status,
+				"progress": task.Progress,
+				"message":  task.Message,
+				"cooldown": toProviderCooldownInfo(task.Cooldown),
+			})
+			lastStatus, lastProgress, lastCooldown = task.Status, task.Progress, task.Cooldown
+		}
+		if len(task.Output) < sent {
+			send("reset", gin.H{})
+			sent = 0
+		}
+		if len(task.Output) > sent {
+			send("chunk", gin.H{"text": task.Output[sent:]})
+			sent = len(task.Output)
+		}
+
+		switch task.Status {
+		case service.TaskStatusCompleted:
+			if task.Result != nil {
+				send("completed", h.buildPlanInfo(task.Result))
+			}
+			return
+		case service.TaskStatusFailed:
+			send("failed", gin.H{"error": task.Error})
+			return
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			rc.SetWriteDeadline(time.Now().Add(2 * planStreamHeartbeat))
+			c.Writer.WriteString(": heartbeat\n\n")
+			c.Writer.Flush()
+			continue
+		case <-changed:
+		}
+
+		task, changed, err = h.trainingService.WatchPlanTask(c.Request.Context(), userID, taskID)
+		if err != nil {
+			send("failed", gin.H{"error": "任务不存在"})
+			return
+		}
+	}
+}
+
+// ListPlans handles GET /api/v1/training-plans
+// Requirements: 5.5
+func (h *TrainingHandler) ListPlans(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var params request.TrainingPlanListParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		// Use defaults if binding fails
+		params.Status = ""
+	}
+
+	plans, err := h.trainingService.ListPlans(c.Request.Context(), userID, params.Status)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	// Convert to response format
+	planInfos := make([]response.PlanInfo, 0, len(plans))
+	for _, plan := range plans {
+		planInfos = append(planInfos, h.buildPlanInfo(plan))
+	}
+
+	page, limit, _ := h.GetPagination(c)
+	resp := response.PlanListResponse{
+		Plans:      planInfos,
+		Pagination: h.BuildPaginationInfo(page, limit, int64(len(plans))),
+	}
+
+	h.Success(c, resp)
+}
+
+// GetPlanDetail handles GET /api/v1/training-plans/:id; the weekly
+// schedule is returned with include=plan_data
+// Requirements: 5.4
+func (h *TrainingHandler) GetPlanDetail(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+
+	var params request.PlanDetailParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		h.BadRequest(c, "include参数只支持plan_data")
+		return
+	}
+
+	// The plan data is the bulk of the row; load it only when asked for
+	var plan *model.TrainingPlan
+	if params.Include == request.IncludePlanData {
+		plan, err = h.trainingService.GetPlanDetail(c.Request.Context(), planID, userID)
+	} else {
+		plan, err = h.trainingService.GetPlanSummary(c.Request.Context(), planID, userID)
+	}
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	var rest model.RestIntervals
+	if params.Include == request.IncludePlanData {
+		if rest, ok = h.restIntervals(c, userID); !ok {
+			return
+		}
+	}
+
+	h.Success(c, response.PlanDetailResponse{
+		Plan: toPlanDetailInfo(plan, params.Include == request.IncludePlanData, rest),
+	})
+}
+
+// UpdatePlan handles PUT /api/v1/training-plans/:id
+// @Summary Update a training plan
+// @Description Renames the plan or changes its status; omitted fields are left unchanged.
+// @Tags Training
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Param request body request.UpdateTrainingPlanRequest true "Fields to update"
+// @Success 200 {object} response.BaseResponse "Updated plan"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 404 {object} response.BaseResponse "Plan not found"
+// @Router /training-plans/{id} [put]
+func (h *TrainingHandler) UpdatePlan(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+
+	var req request.UpdateTrainingPlanRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	plan, err := h.trainingService.UpdatePlan(c.Request.Context(), userID, planID, &service.UpdatePlanRequest{
+		PlanName: req.PlanName,
+		Status:   req.Status,
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	rest, ok := h.restIntervals(c, userID)
+	if !ok {
+		return
+	}
+	h.Success(c, response.PlanDetailResponse{Plan: toPlanDetailInfo(plan, true, rest)})
+}
+
+// DeletePlan handles DELETE /api/v1/training-plans/:id
+// @Summary Delete a training plan
+// @Description Training records logged against the plan are kept without the plan link, or deleted with it when delete_records is set. Plans adjusted from this one lose their parent link.
+// @Tags Training
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Param delete_records query bool false "Also delete the plan's training records"
+// @Success 204 "Deleted"
+// @Failure 404 {object} response.BaseResponse "Plan not found"
+// @Router /training-plans/{id} [delete]
+func (h *TrainingHandler) DeletePlan(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+
+	var params request.DeleteTrainingPlanParams
+	if !h.BindQuery(c, &params) {
+		return
+	}
+
+	if err := h.trainingService.DeletePlan(c.Request.Context(), userID, planID, params.DeleteRecords); err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.NoContent(c)
+}
+
+// ClonePlan handles POST /api/v1/training-plans/:id/clone
+// @Summary Clone a training plan
+// @Description Copies the plan to start on a new date without another AI generation. Every day moves by the same number of days, so the end date follows from the original's length, and workouts that land on the user's busy days are moved. The copy is a new active plan.
+// @Tags Training
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Param request body request.ClonePlanRequest true "Start date and optional name"
+// @Success 201 {object} response.BaseResponse "Cloned plan"
+// @Failure 400 {object} response.BaseResponse "Start date in the past"
+// @Failure 404 {object} response.BaseResponse "Plan not found"
+// @Router /training-plans/{id}/clone [post]
+func (h *TrainingHandler) ClonePlan(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+
+	var req request.ClonePlanRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	startDate, err := time.ParseInLocation("2006-01-02", req.StartDate, time.Local)
+	if err != nil {
+		h.BadRequest(c, "无效的开始日期格式")
+		return
+	}
+
+	plan, err := h.trainingService.ClonePlan(c.Request.Context(), userID, planID, &service.ClonePlanRequest{
+		StartDate: startDate,
+		PlanName:  req.PlanName,
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	rest, ok := h.restIntervals(c, userID)
+	if !ok {
+		return
+	}
+	h.Created(c, response.PlanDetailResponse{Plan: toPlanDetailInfo(plan, true, rest)})
+}
+
+// PausePlan handles POST /api/v1/training-plans/:id/pause
+// @Summary Pause a training plan
+// @Description Only active plans can be paused. A paused plan has no training for today until it is resumed.
+// @Tags Training
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Success 200 {object} response.BaseResponse "Paused plan"
+// @Failure 404 {object} response.BaseResponse "Plan not found"
+// @Failure 409 {object} response.BaseResponse "Plan cannot be paused"
+// @Router /training-plans/{id}/pause [post]
+func (h *TrainingHandler) PausePlan(c *gin.Context) {
+	h.changePlanStatus(c, h.trainingService.PausePlan)
+}
+
+// ResumePlan handles POST /api/v1/training-plans/:id/resume
+// @Summary Resume a paused training plan
+// @Description The days from the pause on move back by the number of whole days the plan was paused, as does its end date; workouts that land on the user's busy days are moved again.
+// @Tags Training
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Success 200 {object} response.BaseResponse "Resumed plan"
+// @Failure 404 {object} response.BaseResponse "Plan not found"
+// @Failure 409 {object} response.BaseResponse "Plan is not paused"
+// @Router /training-plans/{id}/resume [post]
+func (h *TrainingHandler) ResumePlan(c *gin.Context) {
+	h.changePlanStatus(c, h.trainingService.ResumePlan)
+}
+
+// CompletePlan handles POST /api/v1/training-plans/:id/complete
+// @Summary Complete a training plan
+// @Description Only active plans can be completed; the completion time is recorded in completed_at.
+// @Tags Training
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Success 200 {object} response.BaseResponse "Completed plan"
+// @Failure 404 {object} response.BaseResponse "Plan not found"
+// @Failure 409 {object} response.BaseResponse "Plan cannot be completed"
+// @Router /training-plans/{id}/complete [post]
+func (h *TrainingHandler) CompletePlan(c *gin.Context) {
+	h.changePlanStatus(c, h.trainingService.CompletePlan)
+}
+
+// changePlanStatus applies a plan status change to the plan in the path
+func (h *TrainingHandler) changePlanStatus(c *gin.Context, change func(ctx context.Context, userID, planID int64) (*model.TrainingPlan, error)) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+
+	plan, err := change(c.Request.Context(), userID, planID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	rest, ok := h.restIntervals(c, userID)
+	if !ok {
+		return
+	}
+	h.Success(c, response.PlanDetailResponse{Plan: toPlanDetailInfo(plan, true, rest)})
+}
+
+// AdjustPlan handles POST /api/v1/training-plans/:id/adjust
+// @Summary Adjust a training plan
+// @Description Generates a new version of the plan from the last two weeks of training records, the completion rate so far, the latest check-in and the user's feedback. The new plan starts today, links to the original through parent_plan_id and replaces it as the active plan.
+// @Tags Training
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Param request body request.AdjustTrainingPlanRequest true "Feedback on the current plan"
+// @Success 200 {object} response.TaskResponse "Adjustment task created"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 404 {object} response.BaseResponse "Plan not found"
+// @Router /training-plans/{id}/adjust [post]
+func (h *TrainingHandler) AdjustPlan(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+
+	var req request.AdjustTrainingPlanRequest
+	if !h.BindJSON(c, &req) {
+		return
+	}
+
+	taskResp, err := h.trainingService.AdjustPlan(c.Request.Context(), userID, planID, &service.AdjustPlanRequest{
+		Feedback:         req.Feedback,
+		DifficultyRating: req.DifficultyRating,
+		InjuryReport:     req.InjuryReport,
+		AIAPIID:          req.AIAPIID,
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	if taskResp.Existing {
+		h.RefundAIQuota(c)
+	}
+
+	h.Success(c, response.TaskResponse{
+		TaskID:        taskResp.TaskID,
+		Status:        taskResp.Status,
+		Progress:      0,
+		EstimatedTime: 60,
+		Existing:      taskResp.Existing,
+	})
+}
+
+// ComparePlans handles GET /api/v1/training-plans/compare
+// @Summary Compare two training plans
+// @Description Per-week volume (sets, duration), frequency (training days) and intensity distribution (exercises per difficulty) of plans a and b, aligned by week number, plus which exercises the plans share. Changes are b minus a.
+// @Tags Training
+// @Produce json
+// @Security BearerAuth
+// @Param a query int true "First plan ID"
+// @Param b query int true "Second plan ID"
+// @Success 200 {object} response.PlanComparisonResponse "Plan comparison"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 404 {object} response.BaseResponse "Plan not found"
+// @Router /training-plans/compare [get]
+func (h *TrainingHandler) ComparePlans(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var params request.ComparePlansParams
+	if !h.BindQuery(c, &params) {
+		return
+	}
+
+	comparison, err := h.trainingService.ComparePlans(c.Request.Context(), userID, params.A, params.B)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	var weeksA, weeksB []*service.PlanWeekStats
+	weeks := make([]response.PlanWeekComparison, 0, len(comparison.Weeks))
+	for _, pair := range comparison.Weeks {
+		week := response.PlanWeekComparison{
+			Week: pair.Week,
+			A:    toPlanWeekStats(pair.A),
+			B:    toPlanWeekStats(pair.B),
+		}
+		if pair.A != nil {
+			weeksA = append(weeksA, pair.A)
+			week.TrainingDaysChange -= pair.A.TrainingDays
+			week.TotalSetsChange -= pair.A.TotalSets
+			week.DurationChange -= pair.A.DurationMinutes
+		}
+		if pair.B != nil {
+			weeksB = append(weeksB, pair.B)
+			week.TrainingDaysChange += pair.B.TrainingDays
+			week.TotalSetsChange += pair.B.TotalSets
+			week.DurationChange += pair.B.DurationMinutes
+		}
+		weeks = append(weeks, week)
+	}
+
+	h.Success(c, response.PlanComparisonResponse{
+		PlanA: toComparedPlanInfo(comparison.PlanA, weeksA),
+		PlanB: toComparedPlanInfo(comparison.PlanB, weeksB),
+		Weeks: weeks,
+		ExerciseOverlap: response.ExerciseOverlapInfo{
+			Shared:  nonNilStrings(comparison.SharedExercises),
+			OnlyInA: nonNilStrings(comparison.OnlyInA),
+			OnlyInB: nonNilStrings(comparison.OnlyInB),
+			Jaccard: comparison.ExerciseOverlap,
+		},
+	})
+}
+
+// AuditPlanProgression handles GET /api/v1/training-plans/:id/progression
+// @Summary Audit a training plan's progression
+// @Description Checks that the plan gets harder week by week. Each week's change is the geometric mean of its exercises' sets × reps × load relative to the same exercises the week before. The score is the share of weeks that progress, counting a single lighter week followed by a harder one as a deload; flat plans should be regenerated.
+// @Tags Training
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Success 200 {object} response.ProgressionAuditResponse "Progression audit"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 404 {object} response.BaseResponse "Plan not found"
+// @Router /training-plans/{id}/progression [get]
+func (h *TrainingHandler) AuditPlanProgression(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+
+	audit, err := h.trainingService.AuditPlanProgression(c.Request.Context(), userID, planID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	weeks := make([]response.ProgressionWeekInfo, 0, len(audit.Weeks))
+	for _, w := range audit.Weeks {
+		weeks = append(weeks, response.ProgressionWeekInfo{
+			Week:      w.Week,
+			Exercises: w.Exercises,
+			Change:    w.Change,
+		})
+	}
+
+	h.Success(c, response.ProgressionAuditResponse{
+		PlanID:        planID,
+		Score:         audit.Score,
+		OverallChange: audit.OverallChange,
+		Flat:          audit.Flat,
+		Issues:        nonNilStrings(audit.Issues),
+		Weeks:         weeks,
+	})
+}
+
+// GetWeekSummary handles GET /api/v1/training-plans/:id/weeks/:n/summary
+// @Summary Summarize a week of a training plan
+// @Description Lists each day of week n with its type, focus and duration, and how much of it was logged. A day's completion is the share of its planned sets found in the training records of that date; a record without exercise details completes the day. The week's completion rate averages the days due so far.
+// @Tags Training
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Param n path int true "Week number, from 1"
+// @Success 200 {object} response.WeekSummaryResponse "Week summary"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 404 {object} response.BaseResponse "Plan or week not found"
+// @Router /training-plans/{id}/weeks/{n}/summary [get]
+func (h *TrainingHandler) GetWeekSummary(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+	week, err := strconv.Atoi(c.Param("n"))
+	if err != nil || week < 1 {
+		h.BadRequest(c, "无效的周次")
+		return
+	}
+
+	summary, err := h.trainingService.GetWeekSummary(c.Request.Context(), userID, planID, week)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	days := make([]response.WeekDaySummary, 0, len(summary.Days))
+	for _, d := range summary.Days {
+		days = append(days, response.WeekDaySummary{
+			Day:             d.Day,
+			Date:            d.Date,
+			Type:            d.Type,
+			FocusArea:       d.FocusArea,
+			DurationMinutes: d.DurationMinutes,
+			Exercises:       d.Exercises,
+			PlannedSets:     d.PlannedSets,
+			LoggedSets:      d.LoggedSets,
+			LoggedMinutes:   d.LoggedMinutes,
+			Completion:      d.Completion,
+			Status:          d.Status,
+		})
+	}
+
+	h.Success(c, response.WeekSummaryResponse{
+		PlanID:         summary.PlanID,
+		Week:           summary.Week,
+		TotalWeeks:     summary.TotalWeeks,
+		StartDate:      summary.StartDate,
+		EndDate:        summary.EndDate,
+		TrainingDays:   summary.TrainingDays,
+		CompletedDays:  summary.CompletedDays,
+		PlannedMinutes: summary.PlannedMinutes,
+		LoggedMinutes:  summary.LoggedMinutes,
+		CompletionRate: summary.CompletionRate,
+		Days:           days,
+	})
+}
+
+// CompletePlanDay handles POST /api/v1/training-plans/:id/days/:date/complete
+// @Summary Mark a plan day as completed
+// @Description Marks a training day of the plan as completed, so today's training reports is_completed. The training record logged for the day can be linked; it must be the user's and of the same date. Marking a day again replaces the link. The request body is optional.
+// @Tags Training
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Param date path string true "Date (YYYY-MM-DD)"
+// @Param request body request.CompletePlanDayRequest false "Training record to link"
+// @Success 200 {object} response.PlanDayCompletionResponse "Completed day"
+// @Failure 400 {object} response.BaseResponse "Bad request, future date or rest day"
+// @Failure 404 {object} response.BaseResponse "Plan, day or record not found"
+// @Router /training-plans/{id}/days/{date}/complete [post]
+func (h *TrainingHandler) CompletePlanDay(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+	date, err := time.ParseInLocation("2006-01-02", c.Param("date"), time.Local)
+	if err != nil {
+		h.BadRequest(c, "日期格式无效，应为YYYY-MM-DD")
+		return
+	}
+
+	var req request.CompletePlanDayRequest
+	if c.Request.ContentLength != 0 && !h.BindJSON(c, &req) {
+		return
 	}
 
-	h.Success(c, resp)
+	completion, err := h.trainingService.CompletePlanDay(c.Request.Context(), userID, planID, date, req.RecordID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	h.Success(c, response.PlanDayCompletionResponse{
+		PlanID:      completion.PlanID,
+		Date:        response.FormatDate(completion.PlanDate),
+		RecordID:    completion.RecordID,
+		CompletedAt: response.FormatTime(completion.CompletedAt),
+	})
 }
 
-// GetPlanStatus handles GET /api/v1/training-plans/tasks/:taskId
-func (h *TrainingHandler) GetPlanStatus(c *gin.Context) {
-	taskID := c.Param("taskId")
-	if taskID == "" {
-		h.BadRequest(c, "任务ID不能为空")
+// SubstituteExercise handles POST /api/v1/training-plans/:id/days/:date/exercises/:index/substitute
+// @Summary Substitute an exercise of a plan day
+// @Description Replaces one exercise of a training day with one for the same muscle group, for users missing equipment or nursing an injury. The built-in exercise library is tried first, preferring the same equipment; otherwise, or when notes are given, the AI suggests one. Active training constraints are respected. The substitute is saved in the plan with the planned exercise's name under substituted_for. The request body is optional.
+// @Tags Training
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Param date path string true "Date (YYYY-MM-DD)"
+// @Param index path int true "Position of the exercise in the day, from 0"
+// @Param request body request.SubstituteExerciseRequest false "What the substitute must avoid"
+// @Success 200 {object} response.ExerciseSubstitutionResponse "Substituted exercise"
+// @Failure 400 {object} response.BaseResponse "Bad request or unknown equipment or joint"
+// @Failure 404 {object} response.BaseResponse "Plan, day or exercise not found"
+// @Router /training-plans/{id}/days/{date}/exercises/{index}/substitute [post]
+func (h *TrainingHandler) SubstituteExercise(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
 		return
 	}
 
-	taskStatus, err := h.trainingService.GetPlanStatus(c.Request.Context(), taskID)
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		h.Error(c, err)
+		h.BadRequest(c, "无效的计划ID")
 		return
 	}
-
-	resp := response.TaskResponse{
-		TaskID:   taskStatus.TaskID,
-		Status:   taskStatus.Status,
-		Progress: taskStatus.Progress,
+	date, err := time.ParseInLocation("2006-01-02", c.Param("date"), time.Local)
+	if err != nil {
+		h.BadRequest(c, "日期格式无效，应为YYYY-MM-DD")
+		return
+	}
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 {
+		h.BadRequest(c, "无效的动作序号")
+		return
 	}
 
-	if taskStatus.Error != "" {
-		resp.ErrorMessage = taskStatus.Error
+	var req request.SubstituteExerciseRequest
+	if c.Request.ContentLength != 0 && !h.BindJSON(c, &req) {
+		return
 	}
 
-	if taskStatus.Result != nil {
-		resp.Result = h.buildPlanInfo(taskStatus.Result)
+	substitution, err := h.trainingService.SubstituteExercise(c.Request.Context(), userID, planID, date, index, &service.SubstituteExerciseRequest{
+		UnavailableEquipment: req.UnavailableEquipment,
+		AvoidJoints:          req.AvoidJoints,
+		Notes:                req.Notes,
+		AIAPIID:              req.AIAPIID,
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
 	}
 
-	h.Success(c, resp)
+	h.Success(c, response.ExerciseSubstitutionResponse{
+		PlanID:   substitution.PlanID,
+		Date:     response.FormatDate(substitution.Date),
+		Index:    substitution.Index,
+		Source:   substitution.Source,
+		Original: substitution.Original,
+		Exercise: substitution.Exercise,
+	})
 }
 
-// ListPlans handles GET /api/v1/training-plans
-// Requirements: 5.5
-func (h *TrainingHandler) ListPlans(c *gin.Context) {
+// RegenerateWeek handles POST /api/v1/training-plans/:id/weeks/:n/regenerate
+// @Summary Regenerate one week of a training plan
+// @Description Asks the AI for a new version of a single week from the user's feedback, injuries and recent training records, and saves it in place of that week. The other weeks are unchanged. The plan's version is raised and the plan data replaced is kept as the previous version. The request body is optional.
+// @Tags Training
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Param n path int true "Week number, from 1"
+// @Param request body request.RegenerateTrainingWeekRequest false "Feedback on the week"
+// @Success 200 {object} response.TrainingWeekResponse "Regenerated week"
+// @Failure 404 {object} response.BaseResponse "Plan or week not found"
+// @Failure 409 {object} response.BaseResponse "Plan changed while the week was regenerated"
+// @Router /training-plans/{id}/weeks/{n}/regenerate [post]
+func (h *TrainingHandler) RegenerateWeek(c *gin.Context) {
 	userID, ok := h.GetUserID(c)
 	if !ok {
 		return
 	}
 
-	var params request.TrainingPlanListParams
-	if err := c.ShouldBindQuery(&params); err != nil {
-		// Use defaults if binding fails
-		params.Status = ""
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+	week, err := strconv.Atoi(c.Param("n"))
+	if err != nil || week < 1 {
+		h.BadRequest(c, "无效的周次")
+		return
 	}
 
-	plans, err := h.trainingService.ListPlans(c.Request.Context(), userID, params.Status)
+	var req request.RegenerateTrainingWeekRequest
+	if c.Request.ContentLength != 0 && !h.BindJSON(c, &req) {
+		return
+	}
+
+	plan, err := h.trainingService.RegenerateWeek(c.Request.Context(), userID, planID, week, &service.RegenerateWeekRequest{
+		Feedback:     req.Feedback,
+		InjuryReport: req.InjuryReport,
+		AIAPIID:      req.AIAPIID,
+	})
 	if err != nil {
 		h.Error(c, err)
 		return
 	}
 
-	// Convert to response format
-	planInfos := make([]response.PlanInfo, 0, len(plans))
-	for _, plan := range plans {
-		planInfos = append(planInfos, h.buildPlanInfo(plan))
+	rest, ok := h.restIntervals(c, userID)
+	if !ok {
+		return
 	}
 
-	page, limit, _ := h.GetPagination(c)
-	resp := response.PlanListResponse{
-		Plans:      planInfos,
-		Pagination: h.BuildPaginationInfo(page, limit, int64(len(plans))),
+	resp := response.TrainingWeekResponse{PlanID: plan.ID, Version: plan.Version}
+	for _, w := range toTrainingPlanDataInfo(plan.PlanData, rest).Weeks {
+		if w.Week == week {
+			resp.Week = w
+			break
+		}
 	}
-
 	h.Success(c, resp)
 }
 
-// GetPlanDetail handles GET /api/v1/training-plans/:id
-// Requirements: 5.4
-func (h *TrainingHandler) GetPlanDetail(c *gin.Context) {
+// ListPlanVersions handles GET /api/v1/training-plans/:id/versions
+// @Summary List earlier versions of a training plan
+// @Description Lists the versions a plan had before weeks of it were regenerated, newest first, with the week and feedback of the regeneration that replaced each.
+// @Tags Training
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Success 200 {object} response.PlanVersionListResponse "Earlier versions"
+// @Failure 404 {object} response.BaseResponse "Plan not found"
+// @Router /training-plans/{id}/versions [get]
+func (h *TrainingHandler) ListPlanVersions(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+
+	versions, err := h.trainingService.ListPlanVersions(c.Request.Context(), userID, planID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	infos := make([]response.PlanVersionInfo, 0, len(versions))
+	for _, v := range versions {
+		infos = append(infos, toPlanVersionInfo(v, false, model.RestIntervals{}))
+	}
+	h.Success(c, response.PlanVersionListResponse{
+		PlanID:   planID,
+		Versions: infos,
+	})
+}
+
+// GetPlanVersion handles GET /api/v1/training-plans/:id/versions/:version
+// @Summary Get an earlier version of a training plan
+// @Description Returns the weekly schedule a plan had at an earlier version.
+// @Tags Training
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Param version path int true "Version number"
+// @Success 200 {object} response.PlanVersionResponse "Earlier version"
+// @Failure 404 {object} response.BaseResponse "Plan or version not found"
+// @Router /training-plans/{id}/versions/{version} [get]
+func (h *TrainingHandler) GetPlanVersion(c *gin.Context) {
 	userID, ok := h.GetUserID(c)
 	if !ok {
 		return
@@ -144,15 +1058,26 @@ func (h *TrainingHandler) GetPlanDetail(c *gin.Context) {
 		h.BadRequest(c, "无效的计划ID")
 		return
 	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		h.BadRequest(c, "无效的版本号")
+		return
+	}
 
-	plan, err := h.trainingService.GetPlanDetail(c.Request.Context(), planID, userID)
+	v, err := h.trainingService.GetPlanVersion(c.Request.Context(), userID, planID, version)
 	if err != nil {
 		h.Error(c, err)
 		return
 	}
 
-	h.Success(c, gin.H{
-		"plan": plan,
+	rest, ok := h.restIntervals(c, userID)
+	if !ok {
+		return
+	}
+
+	h.Success(c, response.PlanVersionResponse{
+		PlanID:  planID,
+		Version: toPlanVersionInfo(v, true, rest),
 	})
 }
 
@@ -173,7 +1098,7 @@ func (h *TrainingHandler) GetTodayTraining(c *gin.Context) {
 	if dayPlan == nil {
 		h.Success(c, response.TodayTrainingResponse{
 			Schedule: response.TodaySchedule{
-				Date:        time.Now().Format("2006-01-02"),
+				Date:        response.FormatDate(time.Now()),
 				Type:        "rest",
 				FocusArea:   "",
 				Exercises:   []response.ExerciseInfo{},
@@ -184,19 +1109,11 @@ func (h *TrainingHandler) GetTodayTraining(c *gin.Context) {
 		return
 	}
 
-	// Convert exercises to response format
-	exercises := make([]response.ExerciseInfo, 0, len(dayPlan.Exercises))
-	for _, ex := range dayPlan.Exercises {
-		exercises = append(exercises, response.ExerciseInfo{
-			Name:        ex.Name,
-			Sets:        ex.Sets,
-			Reps:        ex.Reps,
-			Weight:      ex.Weight,
-			Rest:        ex.Rest,
-			Difficulty:  ex.Difficulty,
-			SafetyNotes: ex.SafetyNotes,
-		})
+	rest, ok := h.restIntervals(c, userID)
+	if !ok {
+		return
 	}
+	exercises := toExerciseInfos(dayPlan, rest)
 
 	resp := response.TodayTrainingResponse{
 		Schedule: response.TodaySchedule{
@@ -205,7 +1122,8 @@ func (h *TrainingHandler) GetTodayTraining(c *gin.Context) {
 			FocusArea:      dayPlan.FocusArea,
 			Exercises:      exercises,
 			Duration:       dayPlan.Duration,
-			IsCompleted:    false,
+			CircuitRounds:  dayPlan.CircuitRounds,
+			IsCompleted:    dayPlan.IsCompleted,
 			TotalExercises: len(exercises),
 		},
 	}
@@ -213,6 +1131,142 @@ func (h *TrainingHandler) GetTodayTraining(c *gin.Context) {
 	h.Success(c, resp)
 }
 
+// GetSchedule handles GET /api/v1/training-plans/:id/schedule
+// @Summary Get a training plan's schedule for a date range
+// @Description Returns the plan's days from start to end inclusive, in date order, so a calendar can show a range without loading the whole plan. Dates outside the plan are left out. The range may span at most 92 days.
+// @Tags Training
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Param start query string true "First date (YYYY-MM-DD)"
+// @Param end query string true "Last date (YYYY-MM-DD)"
+// @Success 200 {object} response.PlanScheduleResponse "Plan days in the range"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 404 {object} response.BaseResponse "Plan not found"
+// @Router /training-plans/{id}/schedule [get]
+func (h *TrainingHandler) GetSchedule(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+
+	var params request.PlanScheduleParams
+	if !h.BindQuery(c, &params) {
+		return
+	}
+	start, _ := time.ParseInLocation("2006-01-02", params.Start, time.Local)
+	end, _ := time.ParseInLocation("2006-01-02", params.End, time.Local)
+
+	schedule, err := h.trainingService.GetSchedule(c.Request.Context(), userID, planID, start, end)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	rest, ok := h.restIntervals(c, userID)
+	if !ok {
+		return
+	}
+
+	days := make([]response.ScheduleDay, 0, len(schedule))
+	for _, d := range schedule {
+		days = append(days, response.ScheduleDay{
+			Day:               d.Day,
+			Date:              d.Date,
+			Type:              d.Type,
+			FocusArea:         d.FocusArea,
+			Exercises:         toExerciseInfos(d, rest),
+			Duration:          d.Duration,
+			EstimatedCalories: d.EstimatedCalories,
+			CircuitRounds:     d.CircuitRounds,
+			IsCompleted:       d.IsCompleted,
+		})
+	}
+
+	h.Success(c, response.PlanScheduleResponse{
+		PlanID: planID,
+		Start:  params.Start,
+		End:    params.End,
+		Days:   days,
+	})
+}
+
+// ExportCalendar handles GET /api/v1/training-plans/:id/export.ics
+// @Summary Export a training plan as iCalendar
+// @Description Returns the plan's training days as an iCalendar file with one all-day event per day, titled with the day's type, focus area and duration and listing its exercises. Rest days are left out. Import the file into Apple or Google Calendar.
+// @Tags Training
+// @Produce text/calendar
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Success 200 {file} file "iCalendar file"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 404 {object} response.BaseResponse "Plan not found"
+// @Router /training-plans/{id}/export.ics [get]
+func (h *TrainingHandler) ExportCalendar(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+
+	data, err := h.trainingService.ExportCalendar(c.Request.Context(), userID, planID)
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("training-plan-%d.ics", planID)))
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", data)
+}
+
+// ExportPDF handles GET /api/v1/training-plans/:id/export.pdf
+// @Summary Export a training plan as PDF
+// @Description Renders the plan as a printable A4 document, week by week, with each day's exercises, sets, reps, loads, rest and safety notes. Every page is watermarked with the exporting user and time. Sending an X-Export-Passphrase header password-protects the file; it then opens only with that password and can be printed but not copied.
+// @Tags Training
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Param X-Export-Passphrase header string false "Password to open the PDF with, 8-32 printable ASCII characters"
+// @Success 200 {file} file "PDF document"
+// @Failure 400 {object} response.BaseResponse "Bad request"
+// @Failure 404 {object} response.BaseResponse "Plan not found"
+// @Router /training-plans/{id}/export.pdf [get]
+func (h *TrainingHandler) ExportPDF(c *gin.Context) {
+	userID, ok := h.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.BadRequest(c, "无效的计划ID")
+		return
+	}
+
+	data, err := h.trainingService.ExportPDF(c.Request.Context(), userID, planID, service.PDFExportOptions{
+		Username: h.GetUsername(c),
+		Password: c.GetHeader("X-Export-Passphrase"),
+	})
+	if err != nil {
+		h.Error(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("training-plan-%d.pdf", planID)))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
 // RecordTraining handles POST /api/v1/training-records
 // Requirements: 7.1, 7.2, 7.3
 func (h *TrainingHandler) RecordTraining(c *gin.Context) {
@@ -233,6 +1287,17 @@ func (h *TrainingHandler) RecordTraining(c *gin.Context) {
 		return
 	}
 
+	startedAt, err := parseOptionalTimestamp(req.StartedAt)
+	if err != nil {
+		h.BadRequest(c, "无效的开始时间格式")
+		return
+	}
+	endedAt, err := parseOptionalTimestamp(req.EndedAt)
+	if err != nil {
+		h.BadRequest(c, "无效的结束时间格式")
+		return
+	}
+
 	// Create record model
 	record := &model.TrainingRecord{
 		UserID:          userID,
@@ -240,11 +1305,20 @@ func (h *TrainingHandler) RecordTraining(c *gin.Context) {
 		WorkoutDate:     workoutDate,
 		WorkoutType:     req.WorkoutType,
 		DurationMinutes: req.DurationMinutes,
+		StartedAt:       startedAt,
+		EndedAt:         endedAt,
 		Notes:           req.Notes,
 		Rating:          req.Rating,
 		InjuryReport:    req.InjuryReport,
 		CreatedAt:       time.Now(),
 	}
+	if key := strings.TrimSpace(c.GetHeader("Idempotency-Key")); key != "" {
+		if len(key) > 64 {
+			h.BadRequest(c, "Idempotency-Key长度不能超过64")
+			return
+		}
+		record.IdempotencyKey = &key
+	}
 
 	// Convert exercises and performance data to JSONMap
 	if req.Exercises != nil {
@@ -254,26 +1328,14 @@ func (h *TrainingHandler) RecordTraining(c *gin.Context) {
 		record.PerformanceData = model.JSONMap(req.PerformanceData)
 	}
 
-	if err := h.trainingService.RecordTraining(c.Request.Context(), userID, record); err != nil {
+	if err := h.trainingService.RecordTraining(c.Request.Context(), userID, record, req.AllowDuplicate); err != nil {
 		h.Error(c, err)
 		return
 	}
 
-	h.Created(c, gin.H{
-		"record": gin.H{
-			"id":               record.ID,
-			"plan_id":          record.PlanID,
-			"workout_date":     record.WorkoutDate.Format("2006-01-02"),
-			"workout_type":     record.WorkoutType,
-			"duration_minutes": record.DurationMinutes,
-			"exercises":        record.Exercises,
-			"performance_data": record.PerformanceData,
-			"notes":            record.Notes,
-			"rating":           record.Rating,
-			"injury_report":    record.InjuryReport,
-			"created_at":       record.CreatedAt,
-		},
-		"message": "训练记录已保存",
+	h.Created(c, response.TrainingRecordResponse{
+		Record:  toTrainingRecordInfo(record),
+		Message: "训练记录已保存",
 	})
 }
 
@@ -315,29 +1377,313 @@ func (h *TrainingHandler) ListTrainingRecords(c *gin.Context) {
 		}
 
 		page, limit, _ := h.GetPagination(c)
-		h.Success(c, gin.H{
-			"records":    records,
-			"pagination": h.BuildPaginationInfo(page, limit, int64(len(records))),
-		})
+		resp := response.TrainingRecordListResponse{
+			Records:    make([]response.TrainingRecordInfo, 0, len(records)),
+			Pagination: h.BuildPaginationInfo(page, limit, int64(len(records))),
+		}
+		for _, record := range records {
+			resp.Records = append(resp.Records, toTrainingRecordInfo(record))
+		}
+		h.Success(c, resp)
 		return
 	}
 
 	// Fallback if method not available
-	h.Success(c, gin.H{
-		"records":    []interface{}{},
-		"pagination": h.BuildPaginationInfo(1, 20, 0),
+	h.Success(c, response.TrainingRecordListResponse{
+		Records:    []response.TrainingRecordInfo{},
+		Pagination: h.BuildPaginationInfo(1, 20, 0),
 	})
 }
 
+// toTrainingRecordInfo converts a training record to its response DTO
+func toTrainingRecordInfo(record *model.TrainingRecord) response.TrainingRecordInfo {
+	return response.TrainingRecordInfo{
+		ID:              record.ID,
+		PlanID:          record.PlanID,
+		WorkoutDate:     response.FormatDate(record.WorkoutDate),
+		WorkoutType:     record.WorkoutType,
+		DurationMinutes: record.DurationMinutes,
+		StartedAt:       response.FormatTimePtr(record.StartedAt),
+		EndedAt:         response.FormatTimePtr(record.EndedAt),
+		DurationFlag:    record.DurationFlag,
+		Exercises:       record.Exercises,
+		PerformanceData: record.PerformanceData,
+		Notes:           record.Notes,
+		Rating:          record.Rating,
+		InjuryReport:    record.InjuryReport,
+		CreatedAt:       response.FormatTime(record.CreatedAt),
+	}
+}
+
+// parseOptionalTimestamp parses an optional RFC3339 timestamp
+func parseOptionalTimestamp(value *string) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// toProviderCooldownInfo converts a task's provider cooldown to its response DTO
+func toProviderCooldownInfo(cooldown *service.ProviderCooldown) *response.ProviderCooldownInfo {
+	if cooldown == nil {
+		return nil
+	}
+	retryIn := int(math.Ceil(time.Until(cooldown.Until).Seconds()))
+	if retryIn < 0 {
+		retryIn = 0
+	}
+	return &response.ProviderCooldownInfo{
+		Provider:       cooldown.Provider,
+		Until:          response.FormatTime(cooldown.Until),
+		RetryInSeconds: retryIn,
+	}
+}
+
+// formatTaskExpiry formats when a finished task expires, or "" while it runs
+func formatTaskExpiry(expiresAt *time.Time) string {
+	return response.FormatTimePtr(expiresAt)
+}
+
+// restIntervals looks up the user's default rests for showing plan days. On
+// failure it responds with the error and returns false.
+func (h *TrainingHandler) restIntervals(c *gin.Context, userID int64) (model.RestIntervals, bool) {
+	rest, err := h.trainingService.GetRestIntervals(c.Request.Context(), userID)
+	if err != nil {
+		h.Error(c, err)
+		return model.RestIntervals{}, false
+	}
+	return rest, true
+}
+
 // buildPlanInfo converts model to response format
 func (h *TrainingHandler) buildPlanInfo(plan *model.TrainingPlan) response.PlanInfo {
 	return response.PlanInfo{
 		ID:              plan.ID,
 		Name:            plan.PlanName,
-		StartDate:       plan.StartDate.Format("2006-01-02"),
-		EndDate:         plan.EndDate.Format("2006-01-02"),
+		StartDate:       response.FormatDate(plan.StartDate),
+		EndDate:         response.FormatDate(plan.EndDate),
+		TotalWeeks:      plan.TotalWeeks,
+		DifficultyLevel: plan.DifficultyLevel,
+		ParentPlanID:    plan.ParentPlanID,
+		MacrocycleID:    plan.MacrocycleID,
+		BlockNumber:     plan.BlockNumber,
+		BlockPhase:      plan.BlockPhase,
+		Status:          plan.Status,
+	}
+}
+
+// toPlanDetailInfo converts a training plan for the detail response,
+// parsing its plan data with the user's rests when withData is set
+func toPlanDetailInfo(plan *model.TrainingPlan, withData bool, rest model.RestIntervals) response.PlanDetailInfo {
+	info := response.PlanDetailInfo{
+		ID:              plan.ID,
+		Name:            plan.PlanName,
+		StartDate:       response.FormatDate(plan.StartDate),
+		EndDate:         response.FormatDate(plan.EndDate),
 		TotalWeeks:      plan.TotalWeeks,
 		DifficultyLevel: plan.DifficultyLevel,
+		Version:         plan.Version,
+		ParentPlanID:    plan.ParentPlanID,
+		MacrocycleID:    plan.MacrocycleID,
+		BlockNumber:     plan.BlockNumber,
+		BlockPhase:      plan.BlockPhase,
 		Status:          plan.Status,
+		CreatedAt:       response.FormatTime(plan.CreatedAt),
+		UpdatedAt:       response.FormatTime(plan.UpdatedAt),
+	}
+	if plan.TrainingPurpose != nil {
+		info.TrainingPurpose = *plan.TrainingPurpose
+	}
+	if plan.PausedAt != nil {
+		info.PausedAt = response.FormatTime(*plan.PausedAt)
+	}
+	if plan.CompletedAt != nil {
+		info.CompletedAt = response.FormatTime(*plan.CompletedAt)
+	}
+	if withData {
+		info.PlanData = toTrainingPlanDataInfo(plan.PlanData, rest)
+	}
+	return info
+}
+
+// toPlanVersionInfo converts an earlier plan version, with its weekly
+// schedule when withData is set
+func toPlanVersionInfo(v *model.TrainingPlanVersion, withData bool, rest model.RestIntervals) response.PlanVersionInfo {
+	info := response.PlanVersionInfo{
+		Version:    v.Version,
+		Week:       v.Week,
+		ReplacedAt: response.FormatTime(v.CreatedAt),
+	}
+	if v.Feedback != nil {
+		info.Feedback = *v.Feedback
+	}
+	if withData {
+		info.PlanData = toTrainingPlanDataInfo(v.PlanData, rest)
+	}
+	return info
+}
+
+// toTrainingPlanDataInfo parses a training plan's weeks and days, with the
+// user's default rests in place of the plan's; entries
+// of the wrong shape are skipped
+func toTrainingPlanDataInfo(data model.JSONMap, rest model.RestIntervals) *response.TrainingPlanDataInfo {
+	info := &response.TrainingPlanDataInfo{Weeks: []response.PlanWeekInfo{}}
+
+	weeks, _ := data["weeks"].([]interface{})
+	for _, w := range weeks {
+		weekMap, ok := w.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		week := response.PlanWeekInfo{Days: []response.ScheduleDay{}}
+		if n, ok := weekMap["week"].(float64); ok {
+			week.Week = int(n)
+		}
+		week.Deload, _ = weekMap["deload"].(bool)
+		days, _ := weekMap["days"].([]interface{})
+		for _, d := range days {
+			dayMap, ok := d.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			day := model.DayPlanFromData(dayMap)
+			week.Days = append(week.Days, response.ScheduleDay{
+				Day:               day.Day,
+				Date:              day.Date,
+				Type:              day.Type,
+				FocusArea:         day.FocusArea,
+				Exercises:         toExerciseInfos(day, rest),
+				Duration:          day.Duration,
+				EstimatedCalories: day.EstimatedCalories,
+				CircuitRounds:     day.CircuitRounds,
+			})
+		}
+		info.Weeks = append(info.Weeks, week)
+	}
+
+	if p, ok := data["periodization"].(map[string]interface{}); ok {
+		info.Periodization = &response.PeriodizationInfo{}
+		info.Periodization.Style, _ = p["style"].(string)
+		if n, ok := p["deload_every"].(float64); ok {
+			info.Periodization.DeloadEvery = int(n)
+		}
+	}
+	if audit, ok := data["progression_audit"].(map[string]interface{}); ok {
+		info.ProgressionAudit = &response.PlanProgressionAuditInfo{}
+		if score, ok := audit["score"].(float64); ok {
+			info.ProgressionAudit.Score = int(score)
+		}
+		if change, ok := audit["overall_change"].(float64); ok {
+			info.ProgressionAudit.OverallChange = change
+		}
+		if flat, ok := audit["flat"].(bool); ok {
+			info.ProgressionAudit.Flat = flat
+		}
+	}
+	return info
+}
+
+// toPlanWeekStats converts week stats to their response DTO
+func toPlanWeekStats(w *service.PlanWeekStats) *response.PlanWeekStats {
+	if w == nil {
+		return nil
+	}
+	return &response.PlanWeekStats{
+		TrainingDays:      w.TrainingDays,
+		RestDays:          w.RestDays,
+		Exercises:         w.Exercises,
+		TotalSets:         w.TotalSets,
+		DurationMinutes:   w.DurationMinutes,
+		EstimatedCalories: w.EstimatedCalories,
+		Intensity:         w.Intensity,
+	}
+}
+
+// toComparedPlanInfo summarizes one side of a plan comparison
+func toComparedPlanInfo(plan *model.TrainingPlan, weeks []*service.PlanWeekStats) response.ComparedPlanInfo {
+	info := response.ComparedPlanInfo{
+		ID:         plan.ID,
+		Name:       plan.PlanName,
+		CreatedAt:  response.FormatTime(plan.CreatedAt),
+		TotalWeeks: plan.TotalWeeks,
+		Intensity:  map[string]int{},
+	}
+
+	trainingDays := 0
+	for _, w := range weeks {
+		trainingDays += w.TrainingDays
+		info.TotalSets += w.TotalSets
+		info.TotalDurationMinutes += w.DurationMinutes
+		for difficulty, count := range w.Intensity {
+			info.Intensity[difficulty] += count
+		}
+	}
+	if len(weeks) > 0 {
+		info.AvgTrainingDaysPerWeek = float64(trainingDays) / float64(len(weeks))
+	}
+	return info
+}
+
+// nonNilStrings returns s, or an empty slice so it encodes as [] not null
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// toGenerationTaskInfos converts task history records for a response
+func toGenerationTaskInfos(tasks []*model.GenerationTask) []response.GenerationTaskInfo {
+	infos := make([]response.GenerationTaskInfo, 0, len(tasks))
+	for _, t := range tasks {
+		info := response.GenerationTaskInfo{
+			TaskID:            t.TaskID,
+			UserID:            t.UserID,
+			TaskType:          t.TaskType,
+			Status:            t.Status,
+			PlanID:            t.PlanID,
+			Params:            t.Params,
+			ResponseArchiveID: t.ResponseArchiveID,
+			DurationMs:        t.DurationMs,
+			CreatedAt:         response.FormatTime(t.CreatedAt),
+			FinishedAt:        response.FormatTime(t.FinishedAt),
+		}
+		if t.Error != nil {
+			info.ErrorMessage = *t.Error
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// toExerciseInfos converts a plan day's exercises to the response format,
+// replacing rests the user has a default for
+func toExerciseInfos(day *model.DayPlan, rest model.RestIntervals) []response.ExerciseInfo {
+	customRests := rest.CustomRests(day)
+	infos := make([]response.ExerciseInfo, 0, len(day.Exercises))
+	for i, ex := range day.Exercises {
+		info := response.ExerciseInfo{
+			Name:           ex.Name,
+			Sets:           ex.Sets,
+			Reps:           ex.Reps,
+			Weight:         ex.Weight,
+			Rest:           ex.Rest,
+			Difficulty:     ex.Difficulty,
+			SafetyNotes:    ex.SafetyNotes,
+			SafetyNotesEn:  ex.SafetyNotesEn,
+			SubstitutedFor: ex.SubstitutedFor,
+			SupersetID:     ex.SupersetID,
+			Tempo:          ex.Tempo,
+		}
+		if custom, ok := customRests[i]; ok && custom != ex.Rest {
+			info.Rest = custom
+			info.PlannedRest = ex.Rest
+		}
+		infos = append(infos, info)
 	}
+	return infos
 }
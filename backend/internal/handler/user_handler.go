@@ -5,6 +5,7 @@ import (
 
 	"github.com/ai-fitness-planner/backend/internal/api/request"
 	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/model"
 	"github.com/ai-fitness-planner/backend/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -50,10 +51,14 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 
 	resp := response.UserProfileResponse{
 		User: response.UserInfo{
-			ID:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt.Format(time.RFC3339),
+			ID:              user.ID,
+			Username:        user.Username,
+			Email:           user.Email,
+			WeekStart:       user.WeekStart,
+			AutoRollover:    user.AutoRollover,
+			AnalyticsOptOut: user.AnalyticsOptOut,
+			ResponseArchiveOptOut: user.ResponseArchiveOptOut,
+			CreatedAt:       response.FormatTime(user.CreatedAt),
 		},
 	}
 
@@ -66,6 +71,8 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	if user.Avatar != nil {
 		resp.User.Avatar = *user.Avatar
 	}
+	resp.User.BusyWeekdays, resp.User.BlackoutDates = busyDayInfo(user)
+	resp.User.RestIntervals = restIntervalInfo(user)
 
 	h.Success(c, resp)
 }
@@ -94,6 +101,25 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	if req.Avatar != "" {
 		serviceReq.Avatar = &req.Avatar
 	}
+	if req.WeekStart != "" {
+		serviceReq.WeekStart = &req.WeekStart
+	}
+	serviceReq.AutoRollover = req.AutoRollover
+	serviceReq.AnalyticsOptOut = req.AnalyticsOptOut
+	serviceReq.ResponseArchiveOptOut = req.ResponseArchiveOptOut
+	if req.BusyWeekdays != nil {
+		serviceReq.BusyWeekdays = &req.BusyWeekdays
+	}
+	if req.BlackoutDates != nil {
+		serviceReq.BlackoutDates = &req.BlackoutDates
+	}
+	if req.RestIntervals != nil {
+		serviceReq.RestIntervals = &model.RestIntervals{
+			Strength:    req.RestIntervals.Strength,
+			Hypertrophy: req.RestIntervals.Hypertrophy,
+			Cardio:      req.RestIntervals.Cardio,
+		}
+	}
 
 	user, err := h.userService.UpdateProfile(c.Request.Context(), userID, serviceReq)
 	if err != nil {
@@ -102,10 +128,14 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	}
 
 	resp := response.UserInfo{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt.Format(time.RFC3339),
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		WeekStart:       user.WeekStart,
+		AutoRollover:    user.AutoRollover,
+		AnalyticsOptOut: user.AnalyticsOptOut,
+		ResponseArchiveOptOut: user.ResponseArchiveOptOut,
+		CreatedAt:       response.FormatTime(user.CreatedAt),
 	}
 
 	if user.Nickname != nil {
@@ -117,6 +147,8 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	if user.Avatar != nil {
 		resp.Avatar = *user.Avatar
 	}
+	resp.BusyWeekdays, resp.BlackoutDates = busyDayInfo(user)
+	resp.RestIntervals = restIntervalInfo(user)
 
 	h.Success(c, resp)
 }
@@ -163,8 +195,8 @@ func (h *UserHandler) AddBodyData(c *gin.Context) {
 		Gender:          bodyData.Gender,
 		Height:          bodyData.Height,
 		Weight:          bodyData.Weight,
-		MeasurementDate: bodyData.MeasurementDate.Format("2006-01-02"),
-		CreatedAt:       bodyData.CreatedAt.Format(time.RFC3339),
+		MeasurementDate: response.FormatDate(bodyData.MeasurementDate),
+		CreatedAt:       response.FormatTime(bodyData.CreatedAt),
 	}
 
 	if bodyData.BodyFatPercentage != nil {
@@ -200,8 +232,8 @@ func (h *UserHandler) GetBodyDataHistory(c *gin.Context) {
 			Gender:          bd.Gender,
 			Height:          bd.Height,
 			Weight:          bd.Weight,
-			MeasurementDate: bd.MeasurementDate.Format("2006-01-02"),
-			CreatedAt:       bd.CreatedAt.Format(time.RFC3339),
+			MeasurementDate: response.FormatDate(bd.MeasurementDate),
+			CreatedAt:       response.FormatTime(bd.CreatedAt),
 		}
 		if bd.BodyFatPercentage != nil {
 			info.BodyFatPercentage = *bd.BodyFatPercentage
@@ -252,6 +284,7 @@ func (h *UserHandler) SetFitnessGoals(c *gin.Context) {
 		GoalType:        req.GoalType,
 		GoalDescription: goalDescriptionPtr,
 		TargetWeight:    req.TargetWeight,
+		TargetBodyFat:   req.TargetBodyFat,
 		Priority:        1, // Default priority
 	}
 
@@ -282,7 +315,7 @@ func (h *UserHandler) SetFitnessGoals(c *gin.Context) {
 		GoalType:  goal.GoalType,
 		Priority:  goal.Priority,
 		Status:    goal.Status,
-		CreatedAt: goal.CreatedAt.Format(time.RFC3339),
+		CreatedAt: response.FormatTime(goal.CreatedAt),
 	}
 
 	if goal.GoalDescription != nil {
@@ -301,8 +334,14 @@ func (h *UserHandler) SetFitnessGoals(c *gin.Context) {
 	if goal.TargetWeight != nil {
 		resp.TargetWeight = *goal.TargetWeight
 	}
+	if goal.TargetBodyFat != nil {
+		resp.TargetBodyFat = *goal.TargetBodyFat
+	}
+	if goal.CompletedAt != nil {
+		resp.CompletedAt = response.FormatTime(*goal.CompletedAt)
+	}
 	if goal.Deadline != nil {
-		resp.Deadline = goal.Deadline.Format("2006-01-02")
+		resp.Deadline = response.FormatDate(*goal.Deadline)
 		resp.TargetDate = resp.Deadline
 	}
 
@@ -341,7 +380,7 @@ func (h *UserHandler) GetFitnessGoals(c *gin.Context) {
 			GoalType:  goal.GoalType,
 			Priority:  goal.Priority,
 			Status:    goal.Status,
-			CreatedAt: goal.CreatedAt.Format(time.RFC3339),
+			CreatedAt: response.FormatTime(goal.CreatedAt),
 		}
 		if goal.GoalDescription != nil {
 			info.GoalDescription = *goal.GoalDescription
@@ -359,8 +398,14 @@ func (h *UserHandler) GetFitnessGoals(c *gin.Context) {
 		if goal.TargetWeight != nil {
 			info.TargetWeight = *goal.TargetWeight
 		}
+		if goal.TargetBodyFat != nil {
+			info.TargetBodyFat = *goal.TargetBodyFat
+		}
+		if goal.CompletedAt != nil {
+			info.CompletedAt = response.FormatTime(*goal.CompletedAt)
+		}
 		if goal.Deadline != nil {
-			info.Deadline = goal.Deadline.Format("2006-01-02")
+			info.Deadline = response.FormatDate(*goal.Deadline)
 			info.TargetDate = info.Deadline
 		}
 		goalInfos = append(goalInfos, info)
@@ -423,6 +468,7 @@ func (h *UserHandler) UpdateFitnessGoals(c *gin.Context) {
 			GoalType:        req.GoalType,
 			GoalDescription: goalDescriptionPtr,
 			TargetWeight:    req.TargetWeight,
+			TargetBodyFat:   req.TargetBodyFat,
 			Priority:        1,
 		}
 		if req.Priority != nil {
@@ -450,7 +496,7 @@ func (h *UserHandler) UpdateFitnessGoals(c *gin.Context) {
 			GoalType:  goal.GoalType,
 			Priority:  goal.Priority,
 			Status:    goal.Status,
-			CreatedAt: goal.CreatedAt.Format(time.RFC3339),
+			CreatedAt: response.FormatTime(goal.CreatedAt),
 		}
 		if goal.GoalDescription != nil {
 			resp.GoalDescription = *goal.GoalDescription
@@ -468,8 +514,14 @@ func (h *UserHandler) UpdateFitnessGoals(c *gin.Context) {
 		if goal.TargetWeight != nil {
 			resp.TargetWeight = *goal.TargetWeight
 		}
+		if goal.TargetBodyFat != nil {
+			resp.TargetBodyFat = *goal.TargetBodyFat
+		}
+		if goal.CompletedAt != nil {
+			resp.CompletedAt = response.FormatTime(*goal.CompletedAt)
+		}
 		if goal.Deadline != nil {
-			resp.Deadline = goal.Deadline.Format("2006-01-02")
+			resp.Deadline = response.FormatDate(*goal.Deadline)
 			resp.TargetDate = resp.Deadline
 		}
 
@@ -496,6 +548,7 @@ func (h *UserHandler) UpdateFitnessGoals(c *gin.Context) {
 		GoalType:        req.GoalType,
 		GoalDescription: goalDescriptionPtr,
 		TargetWeight:    req.TargetWeight,
+		TargetBodyFat:   req.TargetBodyFat,
 		Priority:        1,
 	}
 	if req.Priority != nil {
@@ -523,7 +576,7 @@ func (h *UserHandler) UpdateFitnessGoals(c *gin.Context) {
 		GoalType:  goal.GoalType,
 		Priority:  goal.Priority,
 		Status:    goal.Status,
-		CreatedAt: goal.CreatedAt.Format(time.RFC3339),
+		CreatedAt: response.FormatTime(goal.CreatedAt),
 	}
 	if goal.GoalDescription != nil {
 		resp.GoalDescription = *goal.GoalDescription
@@ -541,10 +594,44 @@ func (h *UserHandler) UpdateFitnessGoals(c *gin.Context) {
 	if goal.TargetWeight != nil {
 		resp.TargetWeight = *goal.TargetWeight
 	}
+	if goal.TargetBodyFat != nil {
+		resp.TargetBodyFat = *goal.TargetBodyFat
+	}
+	if goal.CompletedAt != nil {
+		resp.CompletedAt = response.FormatTime(*goal.CompletedAt)
+	}
 	if goal.Deadline != nil {
-		resp.Deadline = goal.Deadline.Format("2006-01-02")
+		resp.Deadline = response.FormatDate(*goal.Deadline)
 		resp.TargetDate = resp.Deadline
 	}
 
 	h.Success(c, resp)
 }
+
+// busyDayInfo returns the user's busy weekdays and blackout dates for a
+// response
+func busyDayInfo(user *model.User) ([]int, []string) {
+	var weekdays []int
+	for _, d := range user.BusyWeekdayList() {
+		weekdays = append(weekdays, int(d))
+	}
+	var dates []string
+	for _, d := range user.BlackoutDateList() {
+		dates = append(dates, response.FormatDate(d))
+	}
+	return weekdays, dates
+}
+
+// restIntervalInfo returns the user's rest preferences for a response, or
+// nil when none is set
+func restIntervalInfo(user *model.User) *response.RestIntervalInfo {
+	rest := user.RestIntervals()
+	if rest.IsZero() {
+		return nil
+	}
+	return &response.RestIntervalInfo{
+		Strength:    rest.Strength,
+		Hypertrophy: rest.Hypertrophy,
+		Cardio:      rest.Cardio,
+	}
+}
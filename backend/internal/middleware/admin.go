@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RequireAdminMiddleware allows the request only for users with the admin role.
+// Impersonation tokens are always rejected so an admin session cannot be
+// reached through an impersonated user.
+// Must be used after AuthMiddleware.
+func RequireAdminMiddleware(userRepo repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, impersonating := GetImpersonatorID(c); impersonating {
+			c.AbortWithStatusJSON(http.StatusForbidden, response.ForbiddenError("代登录会话无法访问管理接口"))
+			return
+		}
+
+		userID, ok := GetUserID(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, response.UnauthorizedError("用户未认证"))
+			return
+		}
+
+		user, err := userRepo.GetByID(c.Request.Context(), userID)
+		if err != nil {
+			logger.Error("获取用户失败", zap.Error(err), zap.Int64("user_id", userID))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, response.InternalServerError("权限验证失败"))
+			return
+		}
+
+		if user == nil || user.Role != model.UserRoleAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, response.ForbiddenError("权限不足"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ImpersonationAuditMiddleware writes an audit log entry for every request
+// made with an impersonation token. Must be used after AuthMiddleware.
+func ImpersonationAuditMiddleware(auditRepo repository.ImpersonationAuditRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, impersonating := GetImpersonatorID(c)
+		if !impersonating {
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		userID, _ := GetUserID(c)
+		sessionID, _ := GetSessionID(c)
+		entry := &model.ImpersonationAuditLog{
+			AdminID:    adminID,
+			UserID:     userID,
+			SessionID:  sessionID,
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			StatusCode: c.Writer.Status(),
+			IPAddress:  c.ClientIP(),
+			CreatedAt:  time.Now(),
+		}
+
+		// Use a detached context so a client disconnect does not drop the entry
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := auditRepo.Create(ctx, entry); err != nil {
+			logger.Error("写入代登录审计日志失败",
+				zap.Error(err),
+				zap.Int64("admin_id", adminID),
+				zap.Int64("user_id", userID),
+				zap.String("path", entry.Path),
+			)
+		}
+	}
+}
+
+// ServiceAuditMiddleware records every request made with a service token
+func ServiceAuditMiddleware(auditRepo repository.ServiceAuditRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		service, ok := GetService(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		userID, _ := GetUserID(c)
+		tokenID, _ := c.Get(ContextKeyServiceTokenID)
+		entry := &model.ServiceAuditLog{
+			Service:    service,
+			UserID:     userID,
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			StatusCode: c.Writer.Status(),
+			IPAddress:  c.ClientIP(),
+			CreatedAt:  time.Now(),
+		}
+		entry.TokenID, _ = tokenID.(string)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := auditRepo.Create(ctx, entry); err != nil {
+			logger.Error("写入服务令牌审计日志失败",
+				zap.Error(err),
+				zap.String("service", service),
+				zap.Int64("user_id", userID),
+				zap.String("path", entry.Path),
+			)
+		}
+	}
+}
+
+// DenyImpersonationMiddleware blocks routes that decrypt the user's AI API keys
+// (connection tests and AI generation) while an admin is impersonating.
+func DenyImpersonationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, impersonating := GetImpersonatorID(c); impersonating {
+			c.AbortWithStatusJSON(http.StatusForbidden, response.ForbiddenError("代登录会话不允许使用用户的AI API密钥"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// DenyServiceTokenMiddleware blocks routes a service token must not reach,
+// such as the admin routes
+func DenyServiceTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetService(c); ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, response.ForbiddenError("服务令牌不能执行此操作"))
+			return
+		}
+		c.Next()
+	}
+}
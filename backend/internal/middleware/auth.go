@@ -1,13 +1,17 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/model"
 	"github.com/ai-fitness-planner/backend/internal/pkg/jwt"
 	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
 	"github.com/ai-fitness-planner/backend/internal/pkg/session"
+	"github.com/ai-fitness-planner/backend/internal/repository"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -17,10 +21,17 @@ const (
 	ContextKeyUserID    = "user_id"
 	ContextKeyUsername  = "username"
 	ContextKeySessionID = "session_id"
+	// ContextKeyImpersonatorID is set only when an admin is impersonating the user
+	ContextKeyImpersonatorID = "impersonator_id"
+	// ContextKeyService and ContextKeyServiceTokenID are set only for
+	// requests made with a service token
+	ContextKeyService        = "service"
+	ContextKeyServiceTokenID = "service_token_id"
 )
 
-// AuthMiddleware creates authentication middleware with JWT validation and session verification
-func AuthMiddleware(jwtManager jwt.JWTManager, sessionManager session.SessionManager) gin.HandlerFunc {
+// AuthMiddleware creates authentication middleware with JWT validation and session verification.
+// Service tokens are checked against revocations instead of sessions.
+func AuthMiddleware(jwtManager jwt.JWTManager, sessionManager session.SessionManager, revocations repository.ServiceTokenRevocationRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Extract token from Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -41,6 +52,25 @@ func AuthMiddleware(jwtManager jwt.JWTManager, sessionManager session.SessionMan
 		// Validate JWT token
 		claims, err := jwtManager.ValidateToken(tokenString)
 		if err != nil {
+			// Trusted internal services act for a user without a session
+			if serviceClaims, serviceErr := jwtManager.ValidateServiceToken(tokenString); serviceErr == nil {
+				revoked, err := revocations.IsRevoked(c.Request.Context(), serviceClaims.ID)
+				if err != nil {
+					// Fail closed: a revoked token must not slip through an outage
+					logger.Error("服务令牌吊销检查失败", zap.Error(err), zap.String("token_id", serviceClaims.ID))
+					c.AbortWithStatusJSON(http.StatusUnauthorized, response.UnauthorizedError("无法验证服务令牌"))
+					return
+				}
+				if revoked {
+					c.AbortWithStatusJSON(http.StatusUnauthorized, response.UnauthorizedError("服务令牌已吊销"))
+					return
+				}
+				c.Set(ContextKeyUserID, serviceClaims.UserID)
+				c.Set(ContextKeyService, serviceClaims.Service)
+				c.Set(ContextKeyServiceTokenID, serviceClaims.ID)
+				c.Next()
+				return
+			}
 			logger.Warn("JWT验证失败",
 				zap.Error(err),
 				zap.String("ip", c.ClientIP()),
@@ -57,6 +87,19 @@ func AuthMiddleware(jwtManager jwt.JWTManager, sessionManager session.SessionMan
 
 		// Verify session exists in Redis
 		sess, err := sessionManager.GetSession(c.Request.Context(), claims.SessionID)
+		if errors.Is(err, session.ErrStatelessSession) {
+			sess, err = claimsSession(sessionManager, claims), nil
+		}
+		if errors.Is(err, session.ErrSessionUnverified) && claims.ImpersonatorID == 0 {
+			// Degraded mode: the session store is down, so the token's
+			// signature and expiry are all that has been checked
+			logger.Warn("会话存储不可用，仅凭JWT放行请求",
+				zap.String("session_id", claims.SessionID),
+				zap.Int64("user_id", claims.UserID),
+				zap.String("path", c.FullPath()),
+			)
+			sess, err = claimsSession(sessionManager, claims), nil
+		}
 		if err != nil {
 			logger.Error("获取会话失败",
 				zap.Error(err),
@@ -89,11 +132,47 @@ func AuthMiddleware(jwtManager jwt.JWTManager, sessionManager session.SessionMan
 		c.Set(ContextKeyUserID, claims.UserID)
 		c.Set(ContextKeyUsername, claims.Username)
 		c.Set(ContextKeySessionID, claims.SessionID)
+		if claims.ImpersonatorID != 0 {
+			c.Set(ContextKeyImpersonatorID, claims.ImpersonatorID)
+		}
 
 		c.Next()
 	}
 }
 
+// claimsSession stands in for a stored session using the token's claims
+func claimsSession(sessionManager session.SessionManager, claims *jwt.Claims) *model.Session {
+	var issuedAt, expiresAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	return session.ClaimsSession(sessionManager, claims.SessionID, claims.UserID, claims.Username, issuedAt, expiresAt)
+}
+
+// GetImpersonatorID returns the admin user ID when the request is impersonated
+func GetImpersonatorID(c *gin.Context) (int64, bool) {
+	impersonatorID, exists := c.Get(ContextKeyImpersonatorID)
+	if !exists {
+		return 0, false
+	}
+	id, ok := impersonatorID.(int64)
+	return id, ok
+}
+
+// GetService returns the internal service name when the request was made
+// with a service token
+func GetService(c *gin.Context) (string, bool) {
+	service, exists := c.Get(ContextKeyService)
+	if !exists {
+		return "", false
+	}
+	name, ok := service.(string)
+	return name, ok
+}
+
 // GetUserID extracts user ID from context
 func GetUserID(c *gin.Context) (int64, bool) {
 	userID, exists := c.Get(ContextKeyUserID)
@@ -153,6 +232,9 @@ func OptionalAuthMiddleware(jwtManager jwt.JWTManager, sessionManager session.Se
 		}
 
 		sess, err := sessionManager.GetSession(c.Request.Context(), claims.SessionID)
+		if errors.Is(err, session.ErrStatelessSession) {
+			sess, err = claimsSession(sessionManager, claims), nil
+		}
 		if err != nil || sess == nil || sess.UserID != claims.UserID {
 			c.Next()
 			return
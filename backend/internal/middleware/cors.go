@@ -52,6 +52,8 @@ func DefaultCORSConfig() *CORSConfig {
 			"Authorization",
 			"X-Request-ID",
 			"X-Requested-With",
+			"Idempotency-Key",
+			"X-Export-Passphrase",
 		},
 		ExposedHeaders: []string{
 			"Content-Length",
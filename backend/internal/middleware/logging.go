@@ -3,6 +3,7 @@ package middleware
 import (
 	"bytes"
 	"io"
+	"net/http"
 	"regexp"
 	"strings"
 	"time"
@@ -67,6 +68,12 @@ func (w *responseWriter) Write(b []byte) (int, error) {
 	return w.ResponseWriter.Write(b)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, which
+// streaming handlers need to extend the write deadline
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // LoggingMiddleware creates logging middleware for request/response logging
 func LoggingMiddleware(config *LoggingConfig) gin.HandlerFunc {
 	if config == nil {
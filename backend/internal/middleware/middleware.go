@@ -7,6 +7,7 @@
 //   - Logging middleware (request/response logging with sensitive data masking)
 //   - CORS middleware (cross-origin resource sharing configuration)
 //   - Recovery middleware (panic recovery with stack trace logging)
+//   - Warning middleware (non-fatal warnings returned with the response)
 //
 // Usage example:
 //
@@ -17,9 +18,10 @@
 //	router.Use(middleware.LoggingMiddleware(nil))
 //	router.Use(middleware.CORSMiddleware(nil))
 //	router.Use(middleware.SecurityMiddleware(nil))
+//	router.Use(middleware.WarningMiddleware())
 //
 //	// Protected routes
 //	protected := router.Group("/api/v1")
-//	protected.Use(middleware.AuthMiddleware(jwtManager, sessionManager))
+//	protected.Use(middleware.AuthMiddleware(jwtManager, sessionManager, revocationRepo))
 //	protected.Use(rateLimiter.RateLimitMiddleware())
 package middleware
@@ -25,6 +25,10 @@ type RateLimitConfig struct {
 
 	// AI generation endpoint limits (stricter)
 	AIGenerationPerMinute int64
+
+	// AI generation quotas per calendar day/month; 0 means unlimited
+	AIGenerationPerDay   int64
+	AIGenerationPerMonth int64
 }
 
 // DefaultRateLimitConfig returns default rate limit configuration
@@ -54,9 +58,15 @@ func NewRateLimiter(client *redis.Client, config *RateLimitConfig) *RateLimiter
 	}
 }
 
-// RateLimitMiddleware creates rate limiting middleware for general API endpoints
+// RateLimitMiddleware creates rate limiting middleware for general API
+// endpoints. Requests made with a service token are not limited.
 func (rl *RateLimiter) RateLimitMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if _, ok := GetService(c); ok {
+			c.Next()
+			return
+		}
+
 		ctx := c.Request.Context()
 
 		// Check per-IP rate limit
@@ -115,7 +125,9 @@ func (rl *RateLimiter) RateLimitMiddleware() gin.HandlerFunc {
 	}
 }
 
-// AIGenerationRateLimitMiddleware creates stricter rate limiting for AI generation endpoints
+// AIGenerationRateLimitMiddleware creates stricter rate limiting for AI
+// generation endpoints. Service tokens skip the per-minute limit but still
+// draw on the user's daily and monthly quotas, which bound spending.
 func (rl *RateLimiter) AIGenerationRateLimitMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := c.Request.Context()
@@ -128,21 +140,148 @@ func (rl *RateLimiter) AIGenerationRateLimitMiddleware() gin.HandlerFunc {
 		}
 
 		// Stricter per-minute limit for AI generation
-		aiKey := fmt.Sprintf("ratelimit:ai:%d:minute", userID)
-		allowed, retryAfter, err := rl.checkRateLimit(ctx, aiKey, rl.config.AIGenerationPerMinute, time.Minute)
-		if err != nil {
-			logger.Error("AI生成限流检查失败", zap.Error(err), zap.Int64("user_id", userID))
-			c.Next()
-			return
+		if _, service := GetService(c); !service {
+			aiKey := fmt.Sprintf("ratelimit:ai:%d:minute", userID)
+			allowed, retryAfter, err := rl.checkRateLimit(ctx, aiKey, rl.config.AIGenerationPerMinute, time.Minute)
+			if err != nil {
+				logger.Error("AI生成限流检查失败", zap.Error(err), zap.Int64("user_id", userID))
+				c.Next()
+				return
+			}
+
+			if !allowed {
+				c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, response.Error(4290, "AI生成请求过于频繁，请稍后再试"))
+				return
+			}
 		}
 
-		if !allowed {
-			c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, response.Error(4290, "AI生成请求过于频繁，请稍后再试"))
+		quotas, ok := rl.consumeAIQuotas(c, userID)
+		if !ok {
 			return
 		}
+		charge := &aiQuotaCharge{limiter: rl, quotas: quotas}
+		c.Set(contextKeyAIQuotaCharge, charge)
 
 		c.Next()
+
+		// Requests that were rejected or failed did not start a generation
+		if c.Writer.Status() >= http.StatusBadRequest && !charge.refunded {
+			rl.refundQuotas(ctx, quotas)
+		}
+	}
+}
+
+// contextKeyAIQuotaCharge holds the quotas a generation request was charged
+const contextKeyAIQuotaCharge = "ai_quota_charge"
+
+// aiQuotaCharge is what AIGenerationRateLimitMiddleware took from the
+// user's quotas for a request
+type aiQuotaCharge struct {
+	limiter  *RateLimiter
+	quotas   []aiQuota
+	refunded bool
+}
+
+// RefundAIQuota gives back the generation a request was charged for when the
+// handler answers without starting one, e.g. by returning the task the user
+// already has pending. It updates the X-AI-Quota-Remaining-* headers, so it
+// must be called before the response is written.
+func RefundAIQuota(c *gin.Context) {
+	value, exists := c.Get(contextKeyAIQuotaCharge)
+	if !exists {
+		return
+	}
+	charge, ok := value.(*aiQuotaCharge)
+	if !ok || charge.refunded {
+		return
+	}
+	charge.refunded = true
+	charge.limiter.refundQuotas(c.Request.Context(), charge.quotas)
+	for _, quota := range charge.quotas {
+		c.Header("X-AI-Quota-Remaining-"+quota.name, strconv.FormatInt(quota.remaining+1, 10))
+	}
+}
+
+// aiQuota is one calendar-period generation quota
+type aiQuota struct {
+	name    string // "Day" or "Month", used in header names
+	key     string
+	limit   int64
+	resetAt time.Time
+	message string
+	// remaining is what was left once this request was charged
+	remaining int64
+}
+
+// aiQuotas returns the user's configured AI generation quotas for now
+func (rl *RateLimiter) aiQuotas(userID int64, now time.Time) []aiQuota {
+	var quotas []aiQuota
+	if rl.config.AIGenerationPerDay > 0 {
+		quotas = append(quotas, aiQuota{
+			name:    "Day",
+			key:     fmt.Sprintf("quota:ai:%d:day:%s", userID, now.Format("20060102")),
+			limit:   rl.config.AIGenerationPerDay,
+			resetAt: time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()),
+			message: "今日AI生成次数已用完，请明天再试",
+		})
+	}
+	if rl.config.AIGenerationPerMonth > 0 {
+		quotas = append(quotas, aiQuota{
+			name:    "Month",
+			key:     fmt.Sprintf("quota:ai:%d:month:%s", userID, now.Format("200601")),
+			limit:   rl.config.AIGenerationPerMonth,
+			resetAt: time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location()),
+			message: "本月AI生成次数已用完",
+		})
+	}
+	return quotas
+}
+
+// consumeAIQuotas takes one generation from each quota and sets the
+// X-AI-Quota-* headers. When a quota is exhausted it aborts with 429, gives
+// back what was taken and returns false. Redis errors let the request through.
+func (rl *RateLimiter) consumeAIQuotas(c *gin.Context, userID int64) ([]aiQuota, bool) {
+	ctx := c.Request.Context()
+	now := time.Now()
+
+	var consumed []aiQuota
+	for _, quota := range rl.aiQuotas(userID, now) {
+		pipe := rl.client.Pipeline()
+		incrCmd := pipe.Incr(ctx, quota.key)
+		// Keep the counter a little past the reset so the key never expires early
+		pipe.ExpireAt(ctx, quota.key, quota.resetAt.Add(time.Hour))
+		if _, err := pipe.Exec(ctx); err != nil {
+			logger.Error("AI生成配额检查失败", zap.Error(err), zap.Int64("user_id", userID))
+			return consumed, true
+		}
+
+		used := incrCmd.Val()
+		quota.remaining = quota.limit - used
+		if quota.remaining < 0 {
+			quota.remaining = 0
+		}
+		consumed = append(consumed, quota)
+		c.Header("X-AI-Quota-Limit-"+quota.name, strconv.FormatInt(quota.limit, 10))
+		c.Header("X-AI-Quota-Remaining-"+quota.name, strconv.FormatInt(quota.remaining, 10))
+		c.Header("X-AI-Quota-Reset-"+quota.name, strconv.FormatInt(quota.resetAt.Unix(), 10))
+
+		if used > quota.limit {
+			rl.refundQuotas(ctx, consumed)
+			c.Header("Retry-After", strconv.FormatInt(int64(quota.resetAt.Sub(now).Seconds())+1, 10))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, response.Error(4291, quota.message))
+			return nil, false
+		}
+	}
+	return consumed, true
+}
+
+// refundQuotas gives back one generation to each quota
+func (rl *RateLimiter) refundQuotas(ctx context.Context, quotas []aiQuota) {
+	for _, quota := range quotas {
+		if err := rl.client.Decr(ctx, quota.key).Err(); err != nil {
+			logger.Warn("退还AI生成配额失败", zap.Error(err), zap.String("key", quota.key))
+		}
 	}
 }
 
@@ -57,6 +57,7 @@ func DefaultSecurityConfig() *SecurityConfig {
 			"application/json",
 			"application/x-www-form-urlencoded",
 			"multipart/form-data",
+			"text/csv",
 		},
 	}
 }
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/gin-gonic/gin"
+)
+
+// IPThrottle limits requests per client IP in fixed one-minute windows. It
+// keeps its counters in memory rather than Redis, so it also guards /health
+// while the server runs in degraded mode; each instance counts on its own.
+type IPThrottle struct {
+	limit int
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[string]int
+}
+
+// NewIPThrottle creates a throttle allowing limit requests per IP per
+// minute. It returns nil, which allows every request, when limit is 0 or
+// less.
+func NewIPThrottle(limit int) *IPThrottle {
+	if limit <= 0 {
+		return nil
+	}
+	return &IPThrottle{limit: limit, counts: make(map[string]int)}
+}
+
+// allow counts a request from ip and reports whether it is within the
+// limit, and if not how many seconds remain of the window. All counters are
+// dropped when a new window starts, which bounds their memory.
+func (t *IPThrottle) allow(ip string, now time.Time) (bool, int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Sub(t.windowStart) >= time.Minute {
+		t.windowStart = now
+		t.counts = make(map[string]int)
+	}
+	t.counts[ip]++
+	if t.counts[ip] <= t.limit {
+		return true, 0
+	}
+
+	retryAfter := int64(t.windowStart.Add(time.Minute).Sub(now).Seconds())
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	return false, retryAfter
+}
+
+// Middleware rejects requests over the limit with 429
+func (t *IPThrottle) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if t == nil {
+			c.Next()
+			return
+		}
+		if ok, retryAfter := t.allow(c.ClientIP(), time.Now()); !ok {
+			c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, response.Error(4290, "请求过于频繁，请稍后再试"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// BasicAuthMiddleware requires HTTP basic auth with the given credentials.
+// Both are compared in constant time, and the challenge header lets a
+// browser opening the Swagger UI ask for them.
+func BasicAuthMiddleware(username, password string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, pass, ok := c.Request.BasicAuth()
+		userMatch := subtle.ConstantTimeCompare([]byte(user), []byte(username)) == 1
+		passMatch := subtle.ConstantTimeCompare([]byte(pass), []byte(password)) == 1
+		if !ok || !userMatch || !passMatch {
+			c.Header("WWW-Authenticate", `Basic realm="ai-fitness-planner"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, response.UnauthorizedError("需要认证"))
+			return
+		}
+		c.Next()
+	}
+}
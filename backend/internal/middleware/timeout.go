@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Context keys of the request context before the first timeout was applied,
+// which later timeouts derive from, and of the context with the deadline in
+// effect
+const (
+	contextKeyTimeoutParent = "timeout_parent"
+	contextKeyTimeoutActive = "timeout_active"
+)
+
+// TimeoutMiddleware bounds the request by timeout through its context, which
+// services pass on to the database and AI providers. A timeout set on a route
+// replaces the one of its group rather than nesting inside it, so routes can
+// be given a longer budget than the default; 0 removes the deadline, for
+// streams. Handling is not interrupted: a request whose deadline passed
+// before anything was written is answered with 504.
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parent := c.Request.Context()
+		if base, ok := c.Get(contextKeyTimeoutParent); ok {
+			parent = base.(context.Context)
+		} else {
+			c.Set(contextKeyTimeoutParent, parent)
+		}
+
+		ctx, cancel := parent, context.CancelFunc(func() {})
+		if timeout > 0 {
+			ctx, cancel = context.WithTimeout(parent, timeout)
+		}
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Set(contextKeyTimeoutActive, ctx)
+
+		c.Next()
+
+		// A timeout replaced by a route's own is not this one's to report
+		if active, _ := c.Get(contextKeyTimeoutActive); active != ctx || ctx.Err() != context.DeadlineExceeded {
+			return
+		}
+		logger.Warn("请求处理超时",
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.FullPath()),
+			zap.Duration("timeout", timeout),
+		)
+		if !c.Writer.Written() {
+			AbortWithTimeout(c)
+		}
+	}
+}
+
+// TimedOut reports whether the request's deadline has passed
+func TimedOut(c *gin.Context) bool {
+	return c.Request.Context().Err() == context.DeadlineExceeded
+}
+
+// AbortWithTimeout answers the request with 504
+func AbortWithTimeout(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusGatewayTimeout, response.Error(errors.ErrGatewayTimeout, "请求处理超时，请稍后重试"))
+}
@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/ai-fitness-planner/backend/internal/pkg/warning"
+	"github.com/gin-gonic/gin"
+)
+
+// WarningMiddleware lets the services a request reaches add warnings to its
+// context; the base handler returns them with a successful response
+func WarningMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(warning.WithCollector(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,358 @@
+package migration
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Embedded SQL migrations and default prompt templates so the binary can
+// migrate and seed a database without the repository being mounted.
+//
+//go:embed sql/*.sql
+var sqlFS embed.FS
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// schemaMigrationsDDL creates the bookkeeping table for applied migrations
+const schemaMigrationsDDL = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version VARCHAR(100) PRIMARY KEY,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+// baselineVersion is the migration that reproduces database/schema.sql as it
+// was before migrations were tracked
+const baselineVersion = "0001_init"
+
+// DefaultTemplate describes a built-in prompt template seeded on migration
+type DefaultTemplate struct {
+	Category    string
+	Subcategory string
+	Name        string
+	File        string
+	Variables   []string
+	IsDefault   bool
+	Description string
+}
+
+// DefaultTemplates lists the prompt templates shipped with the binary
+var DefaultTemplates = []DefaultTemplate{
+	{
+		Category:    "training",
+		Subcategory: "plan_generation",
+		Name:        "训练计划生成模板",
+		File:        "training_plan_generation.tmpl",
+		Variables:   []string{"PlanName", "Goal", "DifficultyLevel", "TotalWeeks", "HasAssessment", "ExperienceLevel", "WeeklyAvailableDays", "DailyAvailableMinutes", "InjuryHistory", "HealthConditions", "EquipmentAvailable", "HasBodyData", "Age", "Gender", "Height", "Weight", "BodyFatPercentage", "FitnessGoals", "ConstraintSection", "EquipmentSection", "StrengthSection", "CheckInSection", "MacrocycleSection", "ScheduleSection", "PeriodizationSection"},
+		IsDefault:   true,
+		Description: "用于生成个性化训练计划的默认模板",
+	},
+	{
+		Category:    "nutrition",
+		Subcategory: "plan_generation",
+		Name:        "饮食计划生成模板",
+		File:        "nutrition_plan_generation.tmpl",
+		Variables:   []string{"PlanName", "TotalDays", "DailyCalories", "ProteinRatio", "CarbRatio", "FatRatio", "DietaryRestrictions", "Preferences", "LeftoverLunch", "WeeklyBudget", "BudgetLevel", "HasBodyData", "Age", "Gender", "Height", "Weight", "FitnessGoals", "CheckInSection"},
+		IsDefault:   true,
+		Description: "用于生成个性化饮食计划的默认模板",
+	},
+	{
+		Category:    "training",
+		Subcategory: "adjustment",
+		Name:        "训练计划调整模板",
+		File:        "training_adjustment.tmpl",
+		Variables:   []string{"CurrentPlan", "TotalWeeks", "StartDate", "CompletionRate", "DifficultyRating", "InjuryReport", "Feedback", "RecentRecords", "HasCheckIn", "CheckInAdherence", "CheckInEnergy", "CheckInHunger", "CheckInNotes", "ConstraintSection", "ScheduleSection", "ProgressionSection", "PeriodizationSection"},
+		Description: "用于根据用户反馈调整训练计划",
+	},
+	{
+		Category:    "nutrition",
+		Subcategory: "adjustment",
+		Name:        "饮食计划调整模板",
+		File:        "nutrition_adjustment.tmpl",
+		Variables:   []string{"CurrentPlan", "TotalDays", "StartDate", "CompletionRate", "SatisfactionRating", "LeftoverLunch", "WeeklyBudget", "BudgetLevel", "HasWeightChange", "WeightChange", "Feedback", "RecentRecords", "HasCheckIn", "CheckInAdherence", "CheckInEnergy", "CheckInHunger", "CheckInNotes"},
+		Description: "用于根据用户反馈调整饮食计划",
+	},
+	{
+		Category:    "nutrition",
+		Subcategory: "day_regeneration",
+		Name:        "饮食计划单日重新生成模板",
+		File:        "nutrition_day_regeneration.tmpl",
+		Variables:   []string{"PlanName", "Date", "DayNumber", "CurrentDay", "DailyCalories", "ProteinRatio", "CarbRatio", "FatRatio", "DietaryRestrictions", "Preferences", "LeftoverLunch", "WeeklyBudget", "BudgetLevel", "Feedback"},
+		IsDefault:   true,
+		Description: "用于在保持热量目标和饮食限制的前提下重新生成饮食计划中的某一天",
+	},
+	{
+		Category:    "training",
+		Subcategory: "week_regeneration",
+		Name:        "训练计划单周重新生成模板",
+		File:        "training_week_regeneration.tmpl",
+		Variables:   []string{"PlanName", "Week", "TotalWeeks", "StartDate", "EndDate", "CurrentWeek", "PreviousWeek", "NextWeek", "Deload", "InjuryReport", "Feedback", "RecentRecords", "ConstraintSection", "ScheduleSection", "SafetyNotesSection", "ProgressionSection"},
+		IsDefault:   true,
+		Description: "用于根据用户反馈和伤病情况重新生成训练计划中的某一周，其他周保持不变",
+	},
+	{
+		Category:    "training",
+		Subcategory: "exercise_substitution",
+		Name:        "训练动作替换模板",
+		File:        "exercise_substitution.tmpl",
+		Variables:   []string{"PlanName", "Date", "CurrentDay", "Exercise", "UnavailableEquipment", "AvoidJoints", "Notes", "ConstraintSection"},
+		IsDefault:   true,
+		Description: "用于在缺少器材或有伤病时为训练计划中的某个动作推荐替代动作",
+	},
+	{
+		Category:    "coach",
+		Subcategory: "chat",
+		Name:        "AI教练对话模板",
+		File:        "coach_chat.tmpl",
+		Variables:   []string{"Date", "HasTrainingPlan", "TrainingPlanName", "TrainingPlanWeeks", "TodayTraining", "TomorrowTraining", "HasNutritionPlan", "NutritionPlanName", "DailyCalories", "TodayMeals", "HasBodyData", "Age", "Gender", "Height", "Weight", "FitnessGoals", "ConstraintSection", "History", "Question"},
+		IsDefault:   true,
+		Description: "用于AI教练结合用户计划和身体数据回答问题",
+	},
+}
+
+// TemplateText returns the embedded body of a default template
+func TemplateText(file string) (string, error) {
+	data, err := templateFS.ReadFile(path.Join("templates", file))
+	if err != nil {
+		return "", fmt.Errorf("failed to read template %s: %w", file, err)
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
+
+// Migrate applies every embedded SQL migration that has not been applied yet,
+// in file name order. A database loaded from the old hand-written schema is
+// adopted at the baseline first.
+func Migrate(db *gorm.DB) ([]string, error) {
+	if err := db.Exec(schemaMigrationsDDL).Error; err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	if err := adoptExistingSchema(db); err != nil {
+		return nil, err
+	}
+
+	versions, err := Versions()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []string
+	for _, version := range versions {
+		var count int64
+		if err := db.Table("schema_migrations").Where("version = ?", version).Count(&count).Error; err != nil {
+			return applied, fmt.Errorf("failed to check migration %s: %w", version, err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		content, err := sqlFS.ReadFile(path.Join("sql", version+".sql"))
+		if err != nil {
+			return applied, fmt.Errorf("failed to read migration %s: %w", version, err)
+		}
+
+		for _, stmt := range SplitStatements(string(content)) {
+			if err := db.Exec(stmt).Error; err != nil {
+				return applied, fmt.Errorf("failed to apply migration %s: %w", version, err)
+			}
+		}
+
+		if err := db.Exec("INSERT INTO schema_migrations (version) VALUES (?)", version).Error; err != nil {
+			return applied, fmt.Errorf("failed to record migration %s: %w", version, err)
+		}
+		applied = append(applied, version)
+	}
+
+	return applied, nil
+}
+
+// adoptExistingSchema records the baseline as applied when no migration is
+// recorded but its tables already exist, as on installs whose schema was
+// loaded from database/schema.sql before migrations were tracked. Without
+// this the baseline's CREATE TABLE statements fail on upgrade.
+func adoptExistingSchema(db *gorm.DB) error {
+	var count int64
+	if err := db.Table("schema_migrations").Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check applied migrations: %w", err)
+	}
+	if count > 0 || !db.Migrator().HasTable("users") {
+		return nil
+	}
+
+	if err := db.Exec("INSERT INTO schema_migrations (version) VALUES (?)", baselineVersion).Error; err != nil {
+		return fmt.Errorf("failed to record baseline migration: %w", err)
+	}
+	return nil
+}
+
+// Schema renders every migration in apply order, followed by the
+// schema_migrations rows recording them, as one script. database/schema.sql
+// is generated from it for loading an empty database by hand or through the
+// MySQL container's init scripts; the app then finds nothing to migrate.
+func Schema() (string, error) {
+	versions, err := Versions()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("-- 由 internal/migration 中的迁移文件生成，请勿手动修改；修改表结构请新增迁移文件后执行 make schema\n")
+	for _, version := range versions {
+		content, err := sqlFS.ReadFile(path.Join("sql", version+".sql"))
+		if err != nil {
+			return "", fmt.Errorf("failed to read migration %s: %w", version, err)
+		}
+		fmt.Fprintf(&b, "\n-- ==== %s ====\n%s", version, strings.TrimRight(string(content), "\n")+"\n")
+	}
+
+	fmt.Fprintf(&b, "\n-- 已应用的迁移\n%s;\n", schemaMigrationsDDL)
+	for _, version := range versions {
+		fmt.Fprintf(&b, "INSERT INTO schema_migrations (version) VALUES ('%s');\n", version)
+	}
+	return b.String(), nil
+}
+
+// SchemaStatus describes how far a database's schema is migrated
+type SchemaStatus struct {
+	// Tracked is false when there is no schema_migrations table, i.e. the
+	// schema was loaded by hand and applied versions are unknown
+	Tracked bool
+	Current string
+	Latest  string
+	Pending []string
+}
+
+// Versions returns the embedded migration versions in apply order
+func Versions() ([]string, error) {
+	files, err := fs.Glob(sqlFS, "sql/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	sort.Strings(files)
+
+	versions := make([]string, 0, len(files))
+	for _, file := range files {
+		versions = append(versions, strings.TrimSuffix(path.Base(file), ".sql"))
+	}
+	return versions, nil
+}
+
+// Status compares the embedded migrations with those recorded as applied
+func Status(db *gorm.DB) (*SchemaStatus, error) {
+	versions, err := Versions()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &SchemaStatus{}
+	if len(versions) > 0 {
+		status.Latest = versions[len(versions)-1]
+	}
+	if !db.Migrator().HasTable("schema_migrations") {
+		return status, nil
+	}
+	status.Tracked = true
+
+	var applied []string
+	if err := db.Table("schema_migrations").Order("version").Pluck("version", &applied).Error; err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	if len(applied) > 0 {
+		status.Current = applied[len(applied)-1]
+	}
+
+	status.Pending = pendingVersions(versions, applied)
+	return status, nil
+}
+
+// pendingVersions returns the versions not in applied, keeping their order
+func pendingVersions(versions, applied []string) []string {
+	done := make(map[string]bool, len(applied))
+	for _, v := range applied {
+		done[v] = true
+	}
+
+	pending := []string{}
+	for _, v := range versions {
+		if !done[v] {
+			pending = append(pending, v)
+		}
+	}
+	return pending
+}
+
+// SeedPromptTemplates inserts the default prompt templates, refreshing the
+// body of templates that already exist unless an operator has edited them
+func SeedPromptTemplates(db *gorm.DB) error {
+	for _, tpl := range DefaultTemplates {
+		text, err := TemplateText(tpl.File)
+		if err != nil {
+			return err
+		}
+
+		variables := `["` + strings.Join(tpl.Variables, `","`) + `"]`
+		isDefault := 0
+		if tpl.IsDefault {
+			isDefault = 1
+		}
+
+		var count int64
+		if err := db.Table("prompt_templates").
+			Where("category = ? AND subcategory = ? AND name = ?", tpl.Category, tpl.Subcategory, tpl.Name).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check template %s: %w", tpl.Name, err)
+		}
+
+		if count > 0 {
+			err = db.Exec(`UPDATE prompt_templates SET template = ?, variables = ?, description = ?
+				WHERE category = ? AND subcategory = ? AND name = ? AND is_customized = 0`,
+				text, variables, tpl.Description, tpl.Category, tpl.Subcategory, tpl.Name,
+			).Error
+		} else {
+			err = db.Exec(`INSERT INTO prompt_templates (category, subcategory, name, template, variables, is_default, description)
+				VALUES (?, ?, ?, ?, ?, ?, ?)`,
+				tpl.Category, tpl.Subcategory, tpl.Name, text, variables, isDefault, tpl.Description,
+			).Error
+		}
+		if err != nil {
+			return fmt.Errorf("failed to insert template %s: %w", tpl.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// SplitStatements splits a migration file into individual statements on
+// semicolons that end a line, dropping comment-only lines
+func SplitStatements(content string) []string {
+	var statements []string
+	var current strings.Builder
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if strings.HasSuffix(trimmed, ";") {
+			stmt := strings.TrimSuffix(strings.TrimSpace(current.String()), ";")
+			if stmt != "" {
+				statements = append(statements, stmt)
+			}
+			current.Reset()
+		}
+	}
+
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+
+	return statements
+}
@@ -0,0 +1,104 @@
+package migration
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+)
+
+var update = flag.Bool("update", false, "regenerate database/schema.sql")
+
+// schemaPath is the generated schema loaded into new databases
+var schemaPath = filepath.Join("..", "..", "..", "database", "schema.sql")
+
+func TestSplitStatements(t *testing.T) {
+	content := `-- users table
+CREATE TABLE users (
+    id BIGINT PRIMARY KEY,
+    name VARCHAR(50) COMMENT '名称'
+);
+
+-- second table
+CREATE TABLE items (id BIGINT);
+`
+
+	stmts := SplitStatements(content)
+	if len(stmts) != 2 {
+		t.Fatalf("expected 2 statements, got %d: %v", len(stmts), stmts)
+	}
+	if stmts[1] != "CREATE TABLE items (id BIGINT)" {
+		t.Errorf("unexpected second statement: %q", stmts[1])
+	}
+}
+
+func TestEmbeddedAssets(t *testing.T) {
+	for _, tpl := range DefaultTemplates {
+		text, err := TemplateText(tpl.File)
+		if err != nil {
+			t.Fatalf("template %s not embedded: %v", tpl.File, err)
+		}
+		if text == "" {
+			t.Errorf("template %s is empty", tpl.File)
+		}
+	}
+
+	content, err := sqlFS.ReadFile("sql/0001_init.sql")
+	if err != nil {
+		t.Fatalf("initial migration not embedded: %v", err)
+	}
+	if len(SplitStatements(string(content))) == 0 {
+		t.Error("initial migration has no statements")
+	}
+}
+
+func TestTemplatesParse(t *testing.T) {
+	for _, tpl := range DefaultTemplates {
+		text, err := TemplateText(tpl.File)
+		if err != nil {
+			t.Fatalf("template %s not embedded: %v", tpl.File, err)
+		}
+		if _, err := template.New(tpl.File).Parse(text); err != nil {
+			t.Errorf("template %s does not parse: %v", tpl.File, err)
+		}
+	}
+}
+
+func TestVersionsAndPending(t *testing.T) {
+	versions, err := Versions()
+	if err != nil {
+		t.Fatalf("Versions: %v", err)
+	}
+	if len(versions) == 0 || versions[0] != "0001_init" {
+		t.Fatalf("expected versions to start with 0001_init, got %v", versions)
+	}
+
+	pending := pendingVersions([]string{"0001_init", "0002_a", "0003_b"}, []string{"0001_init", "0003_b"})
+	if len(pending) != 1 || pending[0] != "0002_a" {
+		t.Errorf("expected [0002_a] pending, got %v", pending)
+	}
+	if pending := pendingVersions(versions, versions); len(pending) != 0 {
+		t.Errorf("expected nothing pending, got %v", pending)
+	}
+}
+
+func TestSchemaSQLIsGenerated(t *testing.T) {
+	schema, err := Schema()
+	if err != nil {
+		t.Fatalf("Schema: %v", err)
+	}
+	if *update {
+		if err := os.WriteFile(schemaPath, []byte(schema), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", schemaPath, err)
+		}
+	}
+
+	current, err := os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", schemaPath, err)
+	}
+	if string(current) != schema {
+		t.Errorf("%s is out of date with the migrations; run make schema", schemaPath)
+	}
+}
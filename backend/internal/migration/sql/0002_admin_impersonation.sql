@@ -0,0 +1,20 @@
+-- 用户角色
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user' COMMENT 'user/admin' AFTER status;
+
+-- 管理员代登录审计表
+CREATE TABLE impersonation_audit_logs (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    admin_id BIGINT NOT NULL COMMENT '管理员用户ID',
+    user_id BIGINT NOT NULL COMMENT '被代登录用户ID',
+    session_id VARCHAR(64) NOT NULL COMMENT '代登录会话ID',
+    method VARCHAR(10) NOT NULL COMMENT '请求方法',
+    path VARCHAR(500) NOT NULL COMMENT '请求路径',
+    status_code INT COMMENT '响应状态码',
+    reason VARCHAR(500) COMMENT '代登录原因',
+    ip_address VARCHAR(45) COMMENT '来源IP',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_admin_date (admin_id, created_at),
+    INDEX idx_user_date (user_id, created_at),
+    INDEX idx_session (session_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='管理员代登录审计表';
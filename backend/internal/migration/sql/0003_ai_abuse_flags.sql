@@ -0,0 +1,18 @@
+-- AI调用异常检测记录表（管理员审核队列）
+CREATE TABLE ai_abuse_flags (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    ai_api_id BIGINT NOT NULL COMMENT '被暂停的AI API配置',
+    reason VARCHAR(50) NOT NULL COMMENT 'usage_spike/duplicate_prompt/non_ai_host',
+    details JSON COMMENT '检测详情',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' COMMENT 'pending/dismissed/confirmed',
+    suspended_until TIMESTAMP NULL COMMENT '暂停截止时间',
+    reviewed_by BIGINT COMMENT '审核管理员ID',
+    review_note VARCHAR(500) COMMENT '审核备注',
+    reviewed_at TIMESTAMP NULL COMMENT '审核时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (ai_api_id) REFERENCES ai_apis(id) ON DELETE CASCADE,
+    INDEX idx_status_date (status, created_at),
+    INDEX idx_api_status (ai_api_id, status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='AI调用异常检测表';
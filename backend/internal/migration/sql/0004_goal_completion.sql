@@ -0,0 +1,19 @@
+-- 身体成分目标：目标体脂与自动达成时间
+ALTER TABLE fitness_goals
+    ADD COLUMN target_body_fat DECIMAL(4,2) COMMENT '目标体脂' AFTER target_weight,
+    ADD COLUMN completed_at TIMESTAMP NULL COMMENT '达成时间' AFTER status;
+
+-- 用户通知表（目标达成等）
+CREATE TABLE user_notifications (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    type VARCHAR(50) NOT NULL COMMENT '通知类型',
+    title VARCHAR(200) NOT NULL COMMENT '标题',
+    content TEXT COMMENT '内容',
+    data JSON COMMENT '附加数据',
+    read_at TIMESTAMP NULL COMMENT '已读时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_user_read (user_id, read_at),
+    INDEX idx_user_date (user_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户通知表';
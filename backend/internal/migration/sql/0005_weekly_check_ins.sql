@@ -0,0 +1,16 @@
+-- 每周签到表
+CREATE TABLE weekly_check_ins (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    week_start DATE NOT NULL COMMENT '所属周（周一）',
+    weight DECIMAL(5,2) COMMENT '体重',
+    adherence_rating TINYINT NOT NULL COMMENT '计划执行自评 1-5',
+    energy_level TINYINT NOT NULL COMMENT '精力 1-5',
+    hunger_level TINYINT NOT NULL COMMENT '饥饿感 1-5',
+    notes TEXT COMMENT '备注',
+    photos JSON COMMENT '照片URL列表',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY uk_user_week (user_id, week_start)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='每周签到表';
@@ -0,0 +1,14 @@
+-- 力量档案表（主项当前1RM）
+CREATE TABLE strength_profiles (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    lift VARCHAR(50) NOT NULL COMMENT 'squat/bench_press/deadlift/overhead_press',
+    one_rep_max DECIMAL(6,2) NOT NULL COMMENT '1RM(kg)',
+    source VARCHAR(20) NOT NULL DEFAULT 'manual' COMMENT 'manual/pr',
+    source_record_id BIGINT COMMENT '来源训练记录ID',
+    achieved_at DATE NOT NULL COMMENT '达成日期',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY uk_user_lift (user_id, lift)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='力量档案表';
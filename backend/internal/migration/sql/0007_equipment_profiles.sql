@@ -0,0 +1,25 @@
+-- 器材清单表（家/健身房等多套配置，可随时编辑）
+CREATE TABLE equipment_profiles (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    name VARCHAR(50) NOT NULL COMMENT '名称',
+    location_type VARCHAR(20) NOT NULL DEFAULT 'other' COMMENT 'home/gym/other',
+    equipment JSON COMMENT '器材列表',
+    is_default BOOLEAN DEFAULT FALSE COMMENT '是否默认',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY uk_user_name (user_id, name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='器材清单表';
+
+-- 从每个用户最近一次评估中迁移已有器材数据
+INSERT INTO equipment_profiles (user_id, name, location_type, equipment, is_default)
+SELECT fa.user_id, '默认', 'other', fa.equipment_available, TRUE
+FROM fitness_assessments fa
+JOIN (
+    SELECT user_id, MAX(id) AS id
+    FROM fitness_assessments
+    GROUP BY user_id
+) latest ON latest.id = fa.id
+WHERE fa.equipment_available IS NOT NULL
+  AND JSON_LENGTH(fa.equipment_available) > 0;
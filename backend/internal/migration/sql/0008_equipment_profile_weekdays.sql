@@ -0,0 +1,3 @@
+-- 器材清单按星期分配训练地点（0=周日 ... 6=周六）
+ALTER TABLE equipment_profiles
+    ADD COLUMN weekdays JSON COMMENT '适用星期' AFTER equipment;
@@ -0,0 +1,5 @@
+-- 训练记录：开始/结束时间与时长异常标记
+ALTER TABLE training_records
+    ADD COLUMN started_at TIMESTAMP NULL COMMENT '开始时间' AFTER duration_minutes,
+    ADD COLUMN ended_at TIMESTAMP NULL COMMENT '结束时间' AFTER started_at,
+    ADD COLUMN duration_flag VARCHAR(20) NULL COMMENT '时长异常标记' AFTER ended_at;
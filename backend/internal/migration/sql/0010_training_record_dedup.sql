@@ -0,0 +1,4 @@
+-- 训练记录幂等键，防止网络重试导致重复提交
+ALTER TABLE training_records
+    ADD COLUMN idempotency_key VARCHAR(64) NULL COMMENT '幂等键' AFTER injury_report,
+    ADD UNIQUE KEY uk_user_idempotency_key (user_id, idempotency_key);
@@ -0,0 +1,24 @@
+-- 离线同步：客户端UUID、更新时间与删除墓碑
+ALTER TABLE training_records
+    ADD COLUMN client_id CHAR(36) NULL COMMENT '客户端UUID' AFTER user_id,
+    ADD COLUMN updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP AFTER created_at,
+    ADD COLUMN deleted_at TIMESTAMP NULL COMMENT '删除时间' AFTER updated_at;
+
+UPDATE training_records SET client_id = UUID() WHERE client_id IS NULL;
+
+ALTER TABLE training_records
+    MODIFY COLUMN client_id CHAR(36) NOT NULL COMMENT '客户端UUID',
+    ADD UNIQUE KEY uk_user_client_id (user_id, client_id),
+    ADD INDEX idx_user_updated (user_id, updated_at);
+
+ALTER TABLE nutrition_records
+    ADD COLUMN client_id CHAR(36) NULL COMMENT '客户端UUID' AFTER user_id,
+    ADD COLUMN updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP AFTER created_at,
+    ADD COLUMN deleted_at TIMESTAMP NULL COMMENT '删除时间' AFTER updated_at;
+
+UPDATE nutrition_records SET client_id = UUID() WHERE client_id IS NULL;
+
+ALTER TABLE nutrition_records
+    MODIFY COLUMN client_id CHAR(36) NOT NULL COMMENT '客户端UUID',
+    ADD UNIQUE KEY uk_user_client_id (user_id, client_id),
+    ADD INDEX idx_user_updated (user_id, updated_at);
@@ -0,0 +1,29 @@
+-- 组织（企业/健身房）表
+CREATE TABLE organizations (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(100) UNIQUE NOT NULL COMMENT '组织名称',
+    seat_limit INT NOT NULL COMMENT '席位上限（启用用户数）',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='组织表';
+
+-- 用户所属组织
+ALTER TABLE users
+    ADD COLUMN organization_id BIGINT NULL COMMENT '所属组织ID' AFTER role,
+    ADD INDEX idx_organization_status (organization_id, status);
+
+-- 组织用户邀请表
+CREATE TABLE user_invitations (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '被邀请用户ID',
+    organization_id BIGINT NOT NULL COMMENT '组织ID',
+    token_hash CHAR(64) NOT NULL COMMENT '邀请令牌SHA-256',
+    invited_by BIGINT NOT NULL COMMENT '发起邀请的管理员ID',
+    expires_at TIMESTAMP NOT NULL COMMENT '过期时间',
+    accepted_at TIMESTAMP NULL COMMENT '接受时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    UNIQUE KEY uk_token_hash (token_hash),
+    INDEX idx_user (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='组织用户邀请表';
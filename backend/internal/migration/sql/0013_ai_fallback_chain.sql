@@ -0,0 +1,7 @@
+-- AI API备用链：主API重试失败后按顺序尝试备用API，并记录实际生成计划的服务提供商
+ALTER TABLE ai_apis
+    ADD COLUMN fallback_priority INT NULL COMMENT '备用顺序，越小越先尝试，NULL表示不参与' AFTER is_default,
+    ADD INDEX idx_user_fallback (user_id, fallback_priority);
+
+ALTER TABLE training_plans
+    ADD COLUMN ai_provider VARCHAR(50) NULL COMMENT '实际生成计划的服务提供商' AFTER ai_api_id;
@@ -0,0 +1,17 @@
+-- AI调用Token用量表，用于按API统计用量和估算费用
+CREATE TABLE ai_usage (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    ai_api_id BIGINT NOT NULL COMMENT 'AI API配置ID',
+    provider VARCHAR(50) NOT NULL COMMENT '服务提供商',
+    model VARCHAR(100) COMMENT '调用的模型',
+    purpose VARCHAR(30) NOT NULL COMMENT 'training_plan/nutrition_plan',
+    prompt_tokens INT NOT NULL DEFAULT 0 COMMENT '输入Token数',
+    completion_tokens INT NOT NULL DEFAULT 0 COMMENT '输出Token数',
+    total_tokens INT NOT NULL DEFAULT 0 COMMENT '总Token数',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (ai_api_id) REFERENCES ai_apis(id) ON DELETE CASCADE,
+    INDEX idx_api_date (ai_api_id, created_at),
+    INDEX idx_user_date (user_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='AI调用Token用量表';
@@ -0,0 +1,3 @@
+-- 用户每周起始日设置，用于按自然周统计趋势
+ALTER TABLE users
+    ADD COLUMN week_start VARCHAR(10) NOT NULL DEFAULT 'monday' COMMENT '每周起始日 monday/sunday' AFTER organization_id;
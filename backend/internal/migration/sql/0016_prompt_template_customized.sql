@@ -0,0 +1,3 @@
+-- 标记被运营人员修改过的提示词模板，种子数据刷新时不再覆盖
+ALTER TABLE prompt_templates
+    ADD COLUMN is_customized TINYINT NOT NULL DEFAULT 0 COMMENT '是否已被修改' AFTER is_default;
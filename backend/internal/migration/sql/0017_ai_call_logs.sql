@@ -0,0 +1,21 @@
+-- AI调用日志表，记录每次调用的结果和耗时，用于排查生成失败
+CREATE TABLE ai_call_logs (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    ai_api_id BIGINT NOT NULL COMMENT 'AI API配置ID',
+    provider VARCHAR(50) NOT NULL COMMENT '服务提供商',
+    model VARCHAR(100) COMMENT '调用的模型',
+    purpose VARCHAR(30) NOT NULL COMMENT 'training_plan/nutrition_plan',
+    status VARCHAR(20) NOT NULL COMMENT 'success/error',
+    error VARCHAR(500) COMMENT '错误信息',
+    latency_ms BIGINT NOT NULL DEFAULT 0 COMMENT '调用耗时(毫秒)',
+    prompt_tokens INT NOT NULL DEFAULT 0 COMMENT '输入Token数',
+    completion_tokens INT NOT NULL DEFAULT 0 COMMENT '输出Token数',
+    total_tokens INT NOT NULL DEFAULT 0 COMMENT '总Token数',
+    prompt_hash VARCHAR(16) NOT NULL COMMENT '提示词SHA-256前16位',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (ai_api_id) REFERENCES ai_apis(id) ON DELETE CASCADE,
+    INDEX idx_user_date (user_id, created_at),
+    INDEX idx_api_date (ai_api_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='AI调用日志表';
@@ -0,0 +1,15 @@
+-- 训练限制表，记录医生或康复师医嘱中的动作限制，生成训练计划时必须遵守
+CREATE TABLE training_constraints (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    source VARCHAR(20) NOT NULL DEFAULT 'other' COMMENT 'doctor/physio/other',
+    description VARCHAR(500) NOT NULL COMMENT '限制说明',
+    restricted_movements JSON COMMENT '禁止的动作关键词',
+    attachments JSON COMMENT '医嘱附件URL',
+    starts_on DATE NOT NULL COMMENT '生效日期',
+    expires_on DATE COMMENT '最后生效日期，为空表示长期有效',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_user_expires (user_id, expires_on)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='训练限制表';
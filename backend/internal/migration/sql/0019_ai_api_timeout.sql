@@ -0,0 +1,3 @@
+-- AI API单次调用超时：未设置时使用全局配置 ai.timeout
+ALTER TABLE ai_apis
+    ADD COLUMN timeout_seconds INT NULL COMMENT '单次调用超时（秒），NULL表示使用全局配置' AFTER temperature;
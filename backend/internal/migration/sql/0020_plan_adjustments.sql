@@ -0,0 +1,8 @@
+-- 计划调整：根据执行记录和反馈生成的新版本计划指向原计划
+ALTER TABLE training_plans
+    ADD COLUMN parent_plan_id BIGINT NULL COMMENT '调整前的原计划，NULL表示新生成' AFTER ai_provider,
+    ADD CONSTRAINT fk_training_plans_parent FOREIGN KEY (parent_plan_id) REFERENCES training_plans(id) ON DELETE SET NULL;
+
+ALTER TABLE nutrition_plans
+    ADD COLUMN parent_plan_id BIGINT NULL COMMENT '调整前的原计划，NULL表示新生成' AFTER ai_api_id,
+    ADD CONSTRAINT fk_nutrition_plans_parent FOREIGN KEY (parent_plan_id) REFERENCES nutrition_plans(id) ON DELETE SET NULL;
@@ -0,0 +1,12 @@
+-- AI教练对话表，保存用户与AI教练的完整对话历史，Redis中只缓存最近的消息
+CREATE TABLE coach_messages (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    role VARCHAR(20) NOT NULL COMMENT 'user/assistant',
+    content TEXT NOT NULL COMMENT '消息内容',
+    ai_api_id BIGINT COMMENT '生成回复的AI API，用户消息为空',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (ai_api_id) REFERENCES ai_apis(id) ON DELETE SET NULL,
+    INDEX idx_user_created (user_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='AI教练对话表';
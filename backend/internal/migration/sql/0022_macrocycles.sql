@@ -0,0 +1,18 @@
+-- 宏周期：按顺序排列的训练计划块（如增肌→力量→巅峰），下一块根据上一块的执行情况生成
+CREATE TABLE macrocycles (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    name VARCHAR(200) NOT NULL COMMENT '宏周期名称',
+    goal VARCHAR(100) NOT NULL COMMENT '长期目标',
+    phases JSON NOT NULL COMMENT '计划的阶段顺序，如["hypertrophy","strength","peak"]',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='宏周期表';
+
+ALTER TABLE training_plans
+    ADD COLUMN macrocycle_id BIGINT NULL COMMENT '所属宏周期' AFTER parent_plan_id,
+    ADD COLUMN block_number INT NULL COMMENT '在宏周期中的块序号，从1开始' AFTER macrocycle_id,
+    ADD COLUMN block_phase VARCHAR(30) NULL COMMENT '块的训练阶段' AFTER block_number,
+    ADD CONSTRAINT fk_training_plans_macrocycle FOREIGN KEY (macrocycle_id) REFERENCES macrocycles(id) ON DELETE SET NULL,
+    ADD INDEX idx_macrocycle_block (macrocycle_id, block_number);
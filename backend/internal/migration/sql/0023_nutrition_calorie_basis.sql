@@ -0,0 +1,3 @@
+-- 记录饮食计划每日热量的来源，缺少身体数据时使用默认值需要提示用户补充
+ALTER TABLE nutrition_plans
+    ADD COLUMN calorie_basis VARCHAR(20) NULL COMMENT 'provided/calculated/default，NULL表示记录前生成' AFTER daily_calories;
@@ -0,0 +1,3 @@
+-- 剩菜安排：晚餐多做一份作为次日午餐，减少单人用户的做饭次数
+ALTER TABLE nutrition_plans
+    ADD COLUMN leftover_lunch TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否将晚餐剩菜安排为次日午餐' AFTER preferences;
@@ -0,0 +1,4 @@
+-- 食物预算：按档位或每周金额（元）约束饮食计划的食材选择，两者最多填写一个
+ALTER TABLE nutrition_plans
+    ADD COLUMN budget_level VARCHAR(10) NULL COMMENT '食物预算档位：low/medium/high' AFTER leftover_lunch,
+    ADD COLUMN weekly_budget DECIMAL(8,2) NULL COMMENT '每周食物预算（元）' AFTER budget_level;
@@ -0,0 +1,9 @@
+-- 计划自动续期：用户开启后，计划结束时自动生成下一周期；rolled_over_at 防止同一计划重复续期
+ALTER TABLE users
+    ADD COLUMN auto_rollover TINYINT(1) NOT NULL DEFAULT 0 COMMENT '计划结束后是否自动生成下一周期' AFTER week_start;
+
+ALTER TABLE training_plans
+    ADD COLUMN rolled_over_at DATETIME NULL COMMENT '自动续期任务创建时间，NULL表示未续期' AFTER block_phase;
+
+ALTER TABLE nutrition_plans
+    ADD COLUMN rolled_over_at DATETIME NULL COMMENT '自动续期任务创建时间，NULL表示未续期' AFTER parent_plan_id;
@@ -0,0 +1,18 @@
+-- 生成任务历史表，任务结束后写入，内存中的任务过期后仍可查询，便于排查生成失败
+CREATE TABLE generation_tasks (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    task_id VARCHAR(36) NOT NULL COMMENT '任务ID',
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    task_type VARCHAR(30) NOT NULL COMMENT 'training:generate/training:adjust/nutrition:generate/nutrition:adjust',
+    params JSON COMMENT '任务参数',
+    status VARCHAR(20) NOT NULL COMMENT 'completed/failed',
+    error TEXT COMMENT '失败原因',
+    plan_id BIGINT COMMENT '生成的计划ID',
+    duration_ms BIGINT NOT NULL DEFAULT 0 COMMENT '从创建到结束的耗时(毫秒)',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '任务创建时间',
+    finished_at TIMESTAMP NULL COMMENT '任务结束时间',
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY uk_task_id (task_id),
+    INDEX idx_user_created (user_id, created_at),
+    INDEX idx_status_created (status, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='生成任务历史表';
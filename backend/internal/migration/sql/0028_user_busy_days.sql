@@ -0,0 +1,4 @@
+-- 用户忙碌日：每周固定没空训练的星期和具体的不可训练日期，生成计划时安排为休息日
+ALTER TABLE users
+    ADD COLUMN busy_weekdays JSON NULL COMMENT '每周固定忙碌的星期，0=周日' AFTER auto_rollover,
+    ADD COLUMN blackout_dates JSON NULL COMMENT '不可训练的日期 YYYY-MM-DD' AFTER busy_weekdays;
@@ -0,0 +1,4 @@
+-- 训练计划生命周期：暂停时间用于恢复时顺延剩余训练日，完成时间记录计划结束的日期
+ALTER TABLE training_plans
+    ADD COLUMN paused_at DATETIME NULL COMMENT '暂停时间，NULL表示未暂停' AFTER rolled_over_at,
+    ADD COLUMN completed_at DATETIME NULL COMMENT '完成时间' AFTER paused_at;
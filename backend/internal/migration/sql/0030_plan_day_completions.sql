@@ -0,0 +1,14 @@
+-- 训练计划日完成表：记录用户标记完成的计划日及对应的训练记录，今日训练据此返回完成状态
+CREATE TABLE plan_day_completions (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    plan_id BIGINT NOT NULL COMMENT '训练计划ID',
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    plan_date DATE NOT NULL COMMENT '完成的计划日',
+    record_id BIGINT NULL COMMENT '对应的训练记录ID',
+    completed_at TIMESTAMP NOT NULL COMMENT '标记完成时间',
+    FOREIGN KEY (plan_id) REFERENCES training_plans(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (record_id) REFERENCES training_records(id) ON DELETE SET NULL,
+    UNIQUE KEY uk_plan_date (plan_id, plan_date),
+    INDEX idx_user_date (user_id, plan_date)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='训练计划日完成表';
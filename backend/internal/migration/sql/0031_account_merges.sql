@@ -0,0 +1,18 @@
+-- 账号合并审计表：管理员将重复注册账号的计划、记录、身体数据和目标迁移到保留账号，记录迁移内容以便撤销
+CREATE TABLE account_merges (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    admin_id BIGINT NOT NULL COMMENT '执行合并的管理员ID',
+    source_user_id BIGINT NOT NULL COMMENT '被合并并停用的账号',
+    target_user_id BIGINT NOT NULL COMMENT '保留的账号',
+    reason VARCHAR(500) NOT NULL COMMENT '合并原因',
+    changes JSON NOT NULL COMMENT '迁移的记录ID与冲突处理，用于撤销',
+    status VARCHAR(20) NOT NULL DEFAULT 'merged' COMMENT 'merged/reverted',
+    reverted_by BIGINT NULL COMMENT '撤销的管理员ID',
+    reverted_at TIMESTAMP NULL COMMENT '撤销时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (source_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (target_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_source_user (source_user_id),
+    INDEX idx_target_user (target_user_id),
+    INDEX idx_admin_date (admin_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='账号合并审计表';
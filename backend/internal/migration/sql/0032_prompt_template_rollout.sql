@@ -0,0 +1,11 @@
+-- 提示词模板灰度：非默认模板按百分比分配给用户，计划记录生成时使用的模板以便比较各版本的效果
+ALTER TABLE prompt_templates
+    ADD COLUMN rollout_percent TINYINT NOT NULL DEFAULT 0 COMMENT '灰度比例（0-100），分配到的用户使用该模板' AFTER is_customized;
+
+ALTER TABLE training_plans
+    ADD COLUMN prompt_template_id BIGINT NULL COMMENT '生成计划时使用的提示词模板，NULL表示内置模板' AFTER parent_plan_id,
+    ADD INDEX idx_prompt_template (prompt_template_id);
+
+ALTER TABLE nutrition_plans
+    ADD COLUMN prompt_template_id BIGINT NULL COMMENT '生成计划时使用的提示词模板，NULL表示内置模板' AFTER parent_plan_id,
+    ADD INDEX idx_prompt_template (prompt_template_id);
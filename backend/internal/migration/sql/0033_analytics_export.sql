@@ -0,0 +1,15 @@
+-- 分析数据导出：用户可选择退出，导出记录保存每次导出的时间窗口，下次从上次结束处继续
+ALTER TABLE users
+    ADD COLUMN analytics_opt_out TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否退出匿名分析数据导出' AFTER auto_rollover;
+
+CREATE TABLE analytics_exports (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    sink VARCHAR(20) NOT NULL COMMENT '导出目标 file/s3/kafka',
+    window_start DATE NOT NULL COMMENT '导出数据的起始日期（含）',
+    window_end DATE NOT NULL COMMENT '导出数据的结束日期（不含）',
+    events INT NOT NULL DEFAULT 0 COMMENT '导出的事件数',
+    suppressed INT NOT NULL DEFAULT 0 COMMENT '因分组人数不足k而未导出的事件数',
+    location VARCHAR(500) NOT NULL DEFAULT '' COMMENT '导出文件路径、对象键或主题',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_window_start (window_start)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='分析数据导出记录表';
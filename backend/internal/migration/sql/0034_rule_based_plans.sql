@@ -0,0 +1,3 @@
+-- 规则模板生成：未配置AI API的用户按训练模板生成计划，这类计划没有对应的AI API
+ALTER TABLE training_plans
+    MODIFY COLUMN ai_api_id BIGINT NULL COMMENT '使用的AI API，按训练模板生成的计划为NULL';
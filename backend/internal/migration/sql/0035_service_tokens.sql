@@ -0,0 +1,17 @@
+-- 内部服务令牌审计表：调度器、机器人和管理工具使用服务令牌时跳过会话校验和限流，每个请求都记录在此
+CREATE TABLE service_audit_logs (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    service VARCHAR(100) NOT NULL COMMENT '服务名称',
+    token_id VARCHAR(64) NOT NULL COMMENT '服务令牌ID',
+    user_id BIGINT NOT NULL COMMENT '服务代表的用户ID',
+    admin_id BIGINT COMMENT '签发令牌的管理员，仅签发记录有值',
+    method VARCHAR(10) NOT NULL COMMENT '请求方法',
+    path VARCHAR(500) NOT NULL COMMENT '请求路径',
+    status_code INT COMMENT '响应状态码',
+    ip_address VARCHAR(45) COMMENT '来源IP',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_service_date (service, created_at),
+    INDEX idx_user_date (user_id, created_at),
+    INDEX idx_token (token_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='内部服务令牌审计表';
@@ -0,0 +1,15 @@
+-- 训练计划版本：单周重新生成会替换计划中的一周，替换前的计划数据保存为历史版本，可随时查看
+ALTER TABLE training_plans
+    ADD COLUMN version INT NOT NULL DEFAULT 1 COMMENT '当前计划数据的版本号，每次单周重新生成加一' AFTER plan_data;
+
+CREATE TABLE training_plan_versions (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    plan_id BIGINT NOT NULL COMMENT '训练计划ID',
+    version INT NOT NULL COMMENT '被替换的版本号',
+    plan_data JSON NOT NULL COMMENT '该版本的计划数据',
+    week INT NULL COMMENT '替换该版本时重新生成的周',
+    feedback TEXT NULL COMMENT '重新生成时用户的反馈',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '该版本被替换的时间',
+    FOREIGN KEY (plan_id) REFERENCES training_plans(id) ON DELETE CASCADE,
+    UNIQUE KEY uk_plan_version (plan_id, version)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='训练计划历史版本表';
@@ -0,0 +1,3 @@
+-- 用户组间休息偏好：按力量、增肌、有氧分别设置默认休息秒数，展示计划时替换AI给出的休息时间
+ALTER TABLE users
+    ADD COLUMN rest_preferences JSON NULL COMMENT '默认组间休息秒数 {strength, hypertrophy, cardio}' AFTER blackout_dates;
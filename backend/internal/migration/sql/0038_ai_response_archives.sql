@@ -0,0 +1,21 @@
+-- AI响应存档：可选保存生成计划的AI原始响应（已脱敏）若干天，供客服核查用户对AI建议的投诉；用户可选择不存档，到期自动清除
+ALTER TABLE users
+    ADD COLUMN response_archive_opt_out TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否不存档生成计划的AI原始响应' AFTER rest_preferences;
+
+CREATE TABLE ai_response_archives (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    task_id VARCHAR(36) NOT NULL COMMENT '生成任务ID',
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    task_type VARCHAR(30) NOT NULL COMMENT '任务类型',
+    plan_id BIGINT NOT NULL COMMENT '生成的计划ID',
+    ai_api_id BIGINT NOT NULL COMMENT 'AI API配置ID',
+    provider VARCHAR(50) NOT NULL COMMENT '服务提供商',
+    model VARCHAR(100) COMMENT '模型',
+    response MEDIUMTEXT NOT NULL COMMENT '脱敏后的AI原始响应',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL COMMENT '到期自动清除时间',
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY uk_task_id (task_id),
+    INDEX idx_user_created (user_id, created_at),
+    INDEX idx_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='AI响应存档表';
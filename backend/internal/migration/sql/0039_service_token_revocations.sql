@@ -0,0 +1,9 @@
+-- 服务令牌吊销表：管理员吊销的服务令牌在到期前即失效，每个服务令牌请求都会检查此表
+CREATE TABLE service_token_revocations (
+    token_id VARCHAR(64) PRIMARY KEY COMMENT '服务令牌ID',
+    service VARCHAR(100) NOT NULL COMMENT '服务名称',
+    user_id BIGINT NOT NULL COMMENT '服务代表的用户ID',
+    revoked_by BIGINT NOT NULL COMMENT '吊销令牌的管理员',
+    revoked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='服务令牌吊销表';
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// AnalyticsExport records one export of anonymized events to the analytics
+// sink. Windows are whole days; the next export starts at WindowEnd.
+type AnalyticsExport struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Sink        string    `gorm:"size:20;not null" json:"sink"`
+	WindowStart time.Time `gorm:"type:date;not null;uniqueIndex" json:"window_start"`
+	WindowEnd   time.Time `gorm:"type:date;not null" json:"window_end"`
+	Events      int       `gorm:"not null;default:0" json:"events"`
+	// Suppressed counts the events left out because fewer than k users
+	// shared their generalized attributes
+	Suppressed int       `gorm:"not null;default:0" json:"suppressed"`
+	Location   string    `gorm:"size:500;not null;default:''" json:"location"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (AnalyticsExport) TableName() string {
+	return "analytics_exports"
+}
@@ -0,0 +1,162 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ImpersonationAuditLog records a single request made by an admin while
+// impersonating a user
+type ImpersonationAuditLog struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	AdminID    int64     `gorm:"not null;index" json:"admin_id"`
+	UserID     int64     `gorm:"not null;index" json:"user_id"`
+	SessionID  string    `gorm:"size:64;not null;index" json:"session_id"`
+	Method     string    `gorm:"size:10;not null" json:"method"`
+	Path       string    `gorm:"size:500;not null" json:"path"`
+	StatusCode int       `json:"status_code"`
+	Reason     *string   `gorm:"size:500" json:"reason,omitempty"`
+	IPAddress  string    `gorm:"size:45" json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (ImpersonationAuditLog) TableName() string {
+	return "impersonation_audit_logs"
+}
+
+// Methods of service audit entries that record a token's issue or revocation
+// rather than a request
+const (
+	ServiceAuditMethodIssue  = "ISSUE"
+	ServiceAuditMethodRevoke = "REVOKE"
+)
+
+// ServiceAuditLog records a single request made with a service token, or
+// the issue or revocation of one
+type ServiceAuditLog struct {
+	ID      int64  `gorm:"primaryKey;autoIncrement" json:"id"`
+	Service string `gorm:"size:100;not null;index" json:"service"`
+	TokenID string `gorm:"size:64;not null;index" json:"token_id"`
+	UserID  int64  `gorm:"not null;index" json:"user_id"`
+	// AdminID is the admin who issued or revoked the token; set only on
+	// issue and revocation entries
+	AdminID    *int64    `json:"admin_id,omitempty"`
+	Method     string    `gorm:"size:10;not null" json:"method"`
+	Path       string    `gorm:"size:500;not null" json:"path"`
+	StatusCode int       `json:"status_code"`
+	IPAddress  string    `gorm:"size:45" json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (ServiceAuditLog) TableName() string {
+	return "service_audit_logs"
+}
+
+// ServiceTokenRevocation marks a service token as revoked before it expires
+type ServiceTokenRevocation struct {
+	TokenID   string    `gorm:"primaryKey;size:64" json:"token_id"`
+	Service   string    `gorm:"size:100;not null" json:"service"`
+	UserID    int64     `gorm:"not null" json:"user_id"`
+	RevokedBy int64     `gorm:"not null" json:"revoked_by"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+func (ServiceTokenRevocation) TableName() string {
+	return "service_token_revocations"
+}
+
+// AIAbuseFlag records suspicious AI generation usage detected for an API
+// config. While pending, the config is suspended until SuspendedUntil.
+type AIAbuseFlag struct {
+	ID             int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID         int64      `gorm:"not null;index" json:"user_id"`
+	AIAPIID        int64      `gorm:"column:ai_api_id;not null;index" json:"ai_api_id"`
+	Reason         string     `gorm:"size:50;not null" json:"reason"`
+	Details        JSONMap    `gorm:"type:json" json:"details"`
+	Status         string     `gorm:"size:20;not null;default:pending" json:"status"`
+	SuspendedUntil *time.Time `json:"suspended_until"`
+	ReviewedBy     *int64     `json:"reviewed_by"`
+	ReviewNote     *string    `gorm:"size:500" json:"review_note"`
+	ReviewedAt     *time.Time `json:"reviewed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (AIAbuseFlag) TableName() string {
+	return "ai_abuse_flags"
+}
+
+// Abuse flag reasons
+const (
+	AbuseReasonUsageSpike      = "usage_spike"
+	AbuseReasonDuplicatePrompt = "duplicate_prompt"
+	AbuseReasonNonAIHost       = "non_ai_host"
+)
+
+// Abuse flag review statuses
+const (
+	AbuseFlagStatusPending   = "pending"
+	AbuseFlagStatusDismissed = "dismissed"
+	AbuseFlagStatusConfirmed = "confirmed"
+)
+
+// AccountMerge records an admin moving the data of a duplicate account into
+// the surviving account. Changes holds everything the merge did, so it can
+// be reverted.
+type AccountMerge struct {
+	ID           int64               `gorm:"primaryKey;autoIncrement" json:"id"`
+	AdminID      int64               `gorm:"not null;index" json:"admin_id"`
+	SourceUserID int64               `gorm:"not null;index" json:"source_user_id"` // merged and disabled account
+	TargetUserID int64               `gorm:"not null;index" json:"target_user_id"` // surviving account
+	Reason       string              `gorm:"size:500;not null" json:"reason"`
+	Changes      AccountMergeChanges `gorm:"type:json;not null" json:"changes"`
+	Status       string              `gorm:"size:20;not null;default:merged" json:"status"`
+	RevertedBy   *int64              `json:"reverted_by"`
+	RevertedAt   *time.Time          `json:"reverted_at"`
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
+func (AccountMerge) TableName() string {
+	return "account_merges"
+}
+
+// Account merge statuses
+const (
+	AccountMergeStatusMerged   = "merged"
+	AccountMergeStatusReverted = "reverted"
+)
+
+// AccountMergeChanges lists what a merge changed. Moved holds the IDs of
+// the rows reassigned to the target per table; Conflicts the column values
+// changed so those rows fit alongside the target's own.
+type AccountMergeChanges struct {
+	Moved        map[string][]int64     `json:"moved"`
+	Conflicts    []AccountMergeConflict `json:"conflicts"`
+	SourceStatus int8                   `json:"source_status"`
+}
+
+// AccountMergeConflict is one column of a moved row changed by a merge's
+// conflict rules; Old and New are nil for NULL
+type AccountMergeConflict struct {
+	Table  string      `json:"table"`
+	ID     int64       `json:"id"`
+	Column string      `json:"column"`
+	Old    interface{} `json:"old"`
+	New    interface{} `json:"new"`
+	Rule   string      `json:"rule"`
+}
+
+// Scan implements the sql.Scanner interface for AccountMergeChanges
+func (c *AccountMergeChanges) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, c)
+}
+
+// Value implements the driver.Valuer interface for AccountMergeChanges
+func (c AccountMergeChanges) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
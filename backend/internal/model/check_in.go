@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+)
+
+// WeeklyCheckIn is a user's structured self-report for one week
+type WeeklyCheckIn struct {
+	ID              int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID          int64     `gorm:"not null;uniqueIndex:uk_user_week" json:"user_id"`
+	WeekStart       time.Time `gorm:"type:date;not null;uniqueIndex:uk_user_week" json:"week_start"`
+	Weight          *float64  `gorm:"type:decimal(5,2)" json:"weight"`
+	AdherenceRating int       `gorm:"not null" json:"adherence_rating"`
+	EnergyLevel     int       `gorm:"not null" json:"energy_level"`
+	HungerLevel     int       `gorm:"not null" json:"hunger_level"`
+	Notes           *string   `gorm:"type:text" json:"notes"`
+	Photos          JSONSlice `gorm:"type:json" json:"photos"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (WeeklyCheckIn) TableName() string {
+	return "weekly_check_ins"
+}
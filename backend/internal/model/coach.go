@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+)
+
+// Coach message roles
+const (
+	CoachRoleUser      = "user"
+	CoachRoleAssistant = "assistant"
+)
+
+// CoachMessage is one turn of a user's conversation with the AI coach.
+// AIAPIID is the API that wrote an assistant reply and nil for user messages.
+type CoachMessage struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64     `gorm:"not null;index" json:"user_id"`
+	Role      string    `gorm:"size:20;not null" json:"role"`
+	Content   string    `gorm:"type:text;not null" json:"content"`
+	AIAPIID   *int64    `gorm:"column:ai_api_id" json:"ai_api_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (CoachMessage) TableName() string {
+	return "coach_messages"
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+)
+
+// TrainingConstraint is a restriction from a doctor's or physiotherapist's
+// note, such as "no overhead pressing for 6 weeks". RestrictedMovements are
+// keywords that must not appear in exercise names while the constraint is
+// active; Attachments are URLs of the scanned notes.
+type TrainingConstraint struct {
+	ID                  int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID              int64      `gorm:"not null;index" json:"user_id"`
+	Source              string     `gorm:"size:20;not null;default:other" json:"source"`
+	Description         string     `gorm:"size:500;not null" json:"description"`
+	RestrictedMovements JSONSlice  `gorm:"type:json" json:"restricted_movements"`
+	Attachments         JSONSlice  `gorm:"type:json" json:"attachments"`
+	StartsOn            time.Time  `gorm:"type:date;not null" json:"starts_on"`
+	ExpiresOn           *time.Time `gorm:"type:date" json:"expires_on"` // last day in force; nil = until removed
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+func (TrainingConstraint) TableName() string {
+	return "training_constraints"
+}
+
+// ActiveOn reports whether the constraint is in force on day's date
+func (c *TrainingConstraint) ActiveOn(day time.Time) bool {
+	date := day.Format("2006-01-02")
+	if date < c.StartsOn.Format("2006-01-02") {
+		return false
+	}
+	return c.ExpiresOn == nil || date <= c.ExpiresOn.Format("2006-01-02")
+}
+
+// Movements returns the restricted movement keywords
+func (c *TrainingConstraint) Movements() []string {
+	movements := make([]string, 0, len(c.RestrictedMovements))
+	for _, m := range c.RestrictedMovements {
+		if s, ok := m.(string); ok && s != "" {
+			movements = append(movements, s)
+		}
+	}
+	return movements
+}
+
+// Training constraint sources
+const (
+	ConstraintSourceDoctor = "doctor"
+	ConstraintSourcePhysio = "physio"
+	ConstraintSourceOther  = "other"
+)
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+)
+
+// EquipmentProfile is a named set of equipment the user has access to at one
+// location, e.g. a home setup or a commercial gym. Weekdays lists the days
+// the user trains there, 0 = Sunday as in time.Weekday.
+type EquipmentProfile struct {
+	ID           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       int64     `gorm:"not null;uniqueIndex:uk_user_name" json:"user_id"`
+	Name         string    `gorm:"size:50;not null;uniqueIndex:uk_user_name" json:"name"`
+	LocationType string    `gorm:"size:20;not null;default:other" json:"location_type"`
+	Equipment    JSONSlice `gorm:"type:json" json:"equipment"`
+	Weekdays     JSONSlice `gorm:"type:json" json:"weekdays"`
+	IsDefault    bool      `gorm:"default:false" json:"is_default"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (EquipmentProfile) TableName() string {
+	return "equipment_profiles"
+}
+
+// WeekdayList returns the profile's weekdays, skipping invalid entries
+func (p *EquipmentProfile) WeekdayList() []time.Weekday {
+	days := make([]time.Weekday, 0, len(p.Weekdays))
+	for _, v := range p.Weekdays {
+		if f, ok := v.(float64); ok && f >= 0 && f <= 6 {
+			days = append(days, time.Weekday(f))
+		} else if i, ok := v.(int); ok && i >= 0 && i <= 6 {
+			days = append(days, time.Weekday(i))
+		}
+	}
+	return days
+}
+
+// Equipment profile location types
+const (
+	LocationTypeHome  = "home"
+	LocationTypeGym   = "gym"
+	LocationTypeOther = "other"
+)
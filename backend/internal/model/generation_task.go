@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+)
+
+// GenerationTask is the record of a finished plan generation task. Tasks
+// live in memory while they run and are written here once they complete or
+// fail, so they can be looked up after they expire.
+type GenerationTask struct {
+	ID       int64  `gorm:"primaryKey;autoIncrement" json:"id"`
+	TaskID   string `gorm:"size:36;not null;uniqueIndex" json:"task_id"`
+	UserID   int64  `gorm:"not null;index" json:"user_id"`
+	TaskType string `gorm:"size:30;not null" json:"task_type"`
+	// Params is the payload the task was queued with
+	Params     JSONMap   `gorm:"type:json" json:"params"`
+	Status     string    `gorm:"size:20;not null" json:"status"`
+	Error      *string   `gorm:"type:text" json:"error"`
+	PlanID     *int64    `json:"plan_id"`
+	DurationMs int64     `gorm:"not null" json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// ResponseArchiveID links the archived AI response of the task, when
+	// there is one; it is filled in when tasks are listed
+	ResponseArchiveID *int64 `gorm:"-" json:"response_archive_id,omitempty"`
+}
+
+func (GenerationTask) TableName() string {
+	return "generation_tasks"
+}
+
+// AIResponseArchive is the redacted AI completion a generation task's plan
+// was parsed from, kept until ExpiresAt so support can check what the AI
+// actually said
+type AIResponseArchive struct {
+	ID       int64  `gorm:"primaryKey;autoIncrement" json:"id"`
+	TaskID   string `gorm:"size:36;not null;uniqueIndex" json:"task_id"`
+	UserID   int64  `gorm:"not null;index" json:"user_id"`
+	TaskType string `gorm:"size:30;not null" json:"task_type"`
+	PlanID   int64  `gorm:"not null" json:"plan_id"`
+	AIAPIID  int64  `gorm:"column:ai_api_id;not null" json:"ai_api_id"`
+	Provider string `gorm:"size:50;not null" json:"provider"`
+	Model    string `gorm:"size:100" json:"model"`
+	// Response has emails, phone numbers and credentials masked
+	Response  string    `gorm:"type:mediumtext;not null" json:"response"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+}
+
+func (AIResponseArchive) TableName() string {
+	return "ai_response_archives"
+}
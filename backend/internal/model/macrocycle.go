@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+)
+
+// Macrocycle groups a user's sequential training plans, called blocks, under
+// one long-term goal. Phases is the planned phase of each block in order.
+type Macrocycle struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64     `gorm:"not null;index" json:"user_id"`
+	Name      string    `gorm:"size:200;not null" json:"name"`
+	Goal      string    `gorm:"size:100;not null" json:"goal"`
+	Phases    JSONSlice `gorm:"type:json;not null" json:"phases"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Macrocycle) TableName() string {
+	return "macrocycles"
+}
+
+// Training block phases
+const (
+	PhaseHypertrophy = "hypertrophy"
+	PhaseStrength    = "strength"
+	PhasePower       = "power"
+	PhasePeak        = "peak"
+	PhaseEndurance   = "endurance"
+)
+
+// PhaseNames returns the planned phases in order
+func (m *Macrocycle) PhaseNames() []string {
+	phases := make([]string, 0, len(m.Phases))
+	for _, p := range m.Phases {
+		if name, ok := p.(string); ok {
+			phases = append(phases, name)
+		}
+	}
+	return phases
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+)
+
+// UserNotification is an in-app message for a user
+type UserNotification struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64      `gorm:"not null;index" json:"user_id"`
+	Type      string     `gorm:"size:50;not null" json:"type"`
+	Title     string     `gorm:"size:200;not null" json:"title"`
+	Content   *string    `gorm:"type:text" json:"content"`
+	Data      JSONMap    `gorm:"type:json" json:"data"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (UserNotification) TableName() string {
+	return "user_notifications"
+}
+
+// Notification types
+const (
+	NotificationTypeGoalAchieved       = "goal_achieved"
+	NotificationTypeCheckInReminder    = "check_in_reminder"
+	NotificationTypeConstraintsChanged = "constraints_changed"
+)
@@ -2,25 +2,34 @@ package model
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type NutritionPlan struct {
-	ID                  int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID              int64     `gorm:"not null;index" json:"user_id" validate:"required"`
-	PlanName            string    `gorm:"size:200;not null" json:"plan_name" validate:"required,min=1,max=200"`
-	StartDate           time.Time `gorm:"type:date;not null" json:"start_date" validate:"required"`
-	EndDate             time.Time `gorm:"type:date;not null" json:"end_date" validate:"required,gtfield=StartDate"`
-	DailyCalories       float64   `gorm:"type:decimal(7,2)" json:"daily_calories" validate:"min=0"`
-	ProteinRatio        float64   `gorm:"type:decimal(3,2)" json:"protein_ratio" validate:"min=0,max=1"`
-	CarbRatio           float64   `gorm:"type:decimal(3,2)" json:"carb_ratio" validate:"min=0,max=1"`
-	FatRatio            float64   `gorm:"type:decimal(3,2)" json:"fat_ratio" validate:"min=0,max=1"`
-	DietaryRestrictions JSONSlice `gorm:"type:json" json:"dietary_restrictions"`
-	Preferences         JSONSlice `gorm:"type:json" json:"preferences"`
-	PlanData            JSONMap   `gorm:"type:json;not null" json:"plan_data"`
-	AIAPIID             int64     `gorm:"not null;index" json:"ai_api_id" validate:"required"`
-	Status              string    `gorm:"size:20;default:'active'" json:"status" validate:"oneof=active inactive completed"`
-	CreatedAt           time.Time `json:"created_at"`
-	UpdatedAt           time.Time `json:"updated_at"`
+	ID                  int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID              int64      `gorm:"not null;index" json:"user_id" validate:"required"`
+	PlanName            string     `gorm:"size:200;not null" json:"plan_name" validate:"required,min=1,max=200"`
+	StartDate           time.Time  `gorm:"type:date;not null" json:"start_date" validate:"required"`
+	EndDate             time.Time  `gorm:"type:date;not null" json:"end_date" validate:"required,gtfield=StartDate"`
+	DailyCalories       float64    `gorm:"type:decimal(7,2)" json:"daily_calories" validate:"min=0"`
+	CalorieBasis        *string    `gorm:"size:20" json:"calorie_basis"` // nil for plans created before it was recorded
+	ProteinRatio        float64    `gorm:"type:decimal(3,2)" json:"protein_ratio" validate:"min=0,max=1"`
+	CarbRatio           float64    `gorm:"type:decimal(3,2)" json:"carb_ratio" validate:"min=0,max=1"`
+	FatRatio            float64    `gorm:"type:decimal(3,2)" json:"fat_ratio" validate:"min=0,max=1"`
+	DietaryRestrictions JSONSlice  `gorm:"type:json" json:"dietary_restrictions"`
+	Preferences         JSONSlice  `gorm:"type:json" json:"preferences"`
+	LeftoverLunch       bool       `gorm:"not null;default:false" json:"leftover_lunch"` // dinner is cooked twice and eaten again at the next day's lunch
+	BudgetLevel         *string    `gorm:"size:10" json:"budget_level"`                  // a NutritionBudget* value; nil when no level was given
+	WeeklyBudget        *float64   `gorm:"type:decimal(8,2)" json:"weekly_budget"`       // weekly food budget in CNY; nil when none was given
+	PlanData            JSONMap    `gorm:"type:json;not null" json:"plan_data"`
+	AIAPIID             int64      `gorm:"not null;index" json:"ai_api_id" validate:"required"`
+	ParentPlanID        *int64     `gorm:"index" json:"parent_plan_id"`     // plan this one adjusts
+	PromptTemplateID    *int64     `gorm:"index" json:"prompt_template_id"` // stored template the prompt came from; nil for the built-in one
+	RolledOverAt        *time.Time `json:"rolled_over_at"`                  // when the next block was queued automatically
+	Status              string     `gorm:"size:20;default:'active'" json:"status" validate:"oneof=active inactive completed"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
 
 	// 关联关系
 	User  User  `gorm:"foreignKey:UserID" json:"user,omitempty"`
@@ -31,18 +40,38 @@ func (NutritionPlan) TableName() string {
 	return "nutrition_plans"
 }
 
+// How a nutrition plan's daily calorie target was arrived at
+const (
+	// CalorieBasisProvided is a target the user entered
+	CalorieBasisProvided = "provided"
+	// CalorieBasisCalculated is a target calculated from the user's body data
+	CalorieBasisCalculated = "calculated"
+	// CalorieBasisDefault is the fallback target used without body data
+	CalorieBasisDefault = "default"
+)
+
+// Food budget levels of a nutrition plan
+const (
+	NutritionBudgetLow    = "low"
+	NutritionBudgetMedium = "medium"
+	NutritionBudgetHigh   = "high"
+)
+
 type NutritionRecord struct {
-	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID    int64     `gorm:"not null;index;index:user_date" json:"user_id" validate:"required"`
-	MealDate  time.Time `gorm:"type:date;not null;index:user_date" json:"meal_date" validate:"required"`
-	MealTime  string    `gorm:"type:enum('breakfast','lunch','dinner','snack')" json:"meal_time" validate:"oneof=breakfast lunch dinner snack"`
-	Foods     JSONMap   `gorm:"type:json;not null" json:"foods"`
-	Calories  float64   `gorm:"type:decimal(7,2)" json:"calories" validate:"min=0"`
-	Protein   float64   `gorm:"type:decimal(6,2)" json:"protein" validate:"min=0"`
-	Carbs     float64   `gorm:"type:decimal(6,2)" json:"carbs" validate:"min=0"`
-	Fat       float64   `gorm:"type:decimal(6,2)" json:"fat" validate:"min=0"`
-	Fiber     float64   `gorm:"type:decimal(6,2)" json:"fiber" validate:"min=0"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int64          `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64          `gorm:"not null;index;index:user_date" json:"user_id" validate:"required"`
+	ClientID  string         `gorm:"size:36;not null" json:"client_id"`
+	MealDate  time.Time      `gorm:"type:date;not null;index:user_date" json:"meal_date" validate:"required"`
+	MealTime  string         `gorm:"type:enum('breakfast','lunch','dinner','snack')" json:"meal_time" validate:"oneof=breakfast lunch dinner snack"`
+	Foods     JSONMap        `gorm:"type:json;not null" json:"foods"`
+	Calories  float64        `gorm:"type:decimal(7,2)" json:"calories" validate:"min=0"`
+	Protein   float64        `gorm:"type:decimal(6,2)" json:"protein" validate:"min=0"`
+	Carbs     float64        `gorm:"type:decimal(6,2)" json:"carbs" validate:"min=0"`
+	Fat       float64        `gorm:"type:decimal(6,2)" json:"fat" validate:"min=0"`
+	Fiber     float64        `gorm:"type:decimal(6,2)" json:"fiber" validate:"min=0"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// 关联关系
 	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
@@ -75,4 +104,107 @@ type NutritionFoodItem struct {
 	Carbs    float64 `json:"carbs"`
 	Fat      float64 `json:"fat"`
 	Fiber    float64 `json:"fiber,omitempty"`
+	// Price is the rough cost in CNY of Amount, asked of the AI for plans
+	// with a food budget; nil when not given
+	Price *float64 `json:"price,omitempty"`
+}
+
+// NutritionPlanDay represents a day of a nutrition plan's PlanData; Meals
+// is keyed by breakfast, lunch, dinner and snacks
+type NutritionPlanDay struct {
+	Day         int                          `json:"day"`
+	Date        string                       `json:"date"`
+	Meals       map[string]NutritionPlanMeal `json:"meals"`
+	DailyTotals *NutritionTotals             `json:"daily_totals,omitempty"`
+}
+
+// NutritionTotals are the planned calories and macros of a day
+type NutritionTotals struct {
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein"`
+	Carbs    float64 `json:"carbs"`
+	Fat      float64 `json:"fat"`
+}
+
+// NutritionPlanDayFromData reads a day of a plan's PlanData. Fields that are
+// missing or of the wrong type are left empty.
+func NutritionPlanDayFromData(dayMap map[string]interface{}) *NutritionPlanDay {
+	day := &NutritionPlanDay{Meals: map[string]NutritionPlanMeal{}}
+	if n, ok := dayMap["day"].(float64); ok {
+		day.Day = int(n)
+	}
+	if date, ok := dayMap["date"].(string); ok {
+		day.Date = date
+	}
+	if meals, ok := dayMap["meals"].(map[string]interface{}); ok {
+		for key, raw := range meals {
+			if mealMap, ok := raw.(map[string]interface{}); ok {
+				day.Meals[key] = NutritionPlanMealFromData(mealMap)
+			}
+		}
+	}
+	if totals, ok := dayMap["daily_totals"].(map[string]interface{}); ok {
+		day.DailyTotals = &NutritionTotals{}
+		if calories, ok := totals["calories"].(float64); ok {
+			day.DailyTotals.Calories = calories
+		}
+		if protein, ok := totals["protein"].(float64); ok {
+			day.DailyTotals.Protein = protein
+		}
+		if carbs, ok := totals["carbs"].(float64); ok {
+			day.DailyTotals.Carbs = carbs
+		}
+		if fat, ok := totals["fat"].(float64); ok {
+			day.DailyTotals.Fat = fat
+		}
+	}
+	return day
+}
+
+// NutritionPlanMealFromData reads a meal of a plan's PlanData. Fields that
+// are missing or of the wrong type are left empty.
+func NutritionPlanMealFromData(mealMap map[string]interface{}) NutritionPlanMeal {
+	meal := NutritionPlanMeal{Foods: []NutritionFoodItem{}}
+	if t, ok := mealMap["time"].(string); ok {
+		meal.Time = t
+	}
+	if totalCal, ok := mealMap["total_calories"].(float64); ok {
+		meal.TotalCalories = totalCal
+	}
+
+	foodsInterface, _ := mealMap["foods"].([]interface{})
+	for _, foodInterface := range foodsInterface {
+		foodMap, ok := foodInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		food := NutritionFoodItem{}
+		if name, ok := foodMap["name"].(string); ok {
+			food.Name = name
+		}
+		if amount, ok := foodMap["amount"].(string); ok {
+			food.Amount = amount
+		}
+		if calories, ok := foodMap["calories"].(float64); ok {
+			food.Calories = calories
+		}
+		if protein, ok := foodMap["protein"].(float64); ok {
+			food.Protein = protein
+		}
+		if carbs, ok := foodMap["carbs"].(float64); ok {
+			food.Carbs = carbs
+		}
+		if fat, ok := foodMap["fat"].(float64); ok {
+			food.Fat = fat
+		}
+		if fiber, ok := foodMap["fiber"].(float64); ok {
+			food.Fiber = fiber
+		}
+		if price, ok := foodMap["price"].(float64); ok && price >= 0 {
+			food.Price = &price
+		}
+		meal.Foods = append(meal.Foods, food)
+	}
+	return meal
 }
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+)
+
+// Organization is an enterprise or gym tenant whose users are provisioned by
+// an admin. SeatLimit caps the number of active users.
+type Organization struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name      string    `gorm:"size:100;uniqueIndex;not null" json:"name"`
+	SeatLimit int       `gorm:"not null" json:"seat_limit"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// UserInvitation lets a provisioned user set their password. Only the
+// SHA-256 of the token is stored; the token itself is sent by email.
+type UserInvitation struct {
+	ID             int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID         int64      `gorm:"not null;index" json:"user_id"`
+	OrganizationID int64      `gorm:"not null" json:"organization_id"`
+	TokenHash      string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	InvitedBy      int64      `gorm:"not null" json:"invited_by"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (UserInvitation) TableName() string {
+	return "user_invitations"
+}
@@ -2,21 +2,31 @@ package model
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type TrainingRecord struct {
-	ID              int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID          int64     `gorm:"not null;index;index:user_date" json:"user_id" validate:"required"`
-	PlanID          *int64    `gorm:"index;index:user_date" json:"plan_id"`
-	WorkoutDate     time.Time `gorm:"type:date;not null;index:user_date" json:"workout_date" validate:"required"`
-	WorkoutType     string    `gorm:"size:100;not null" json:"workout_type" validate:"required,max=100"`
-	DurationMinutes *int      `json:"duration_minutes" validate:"omitempty,min=0"`
-	Exercises       JSONMap   `gorm:"type:json" json:"exercises"`
-	PerformanceData JSONMap   `gorm:"type:json" json:"performance_data"`
-	Notes           *string   `gorm:"type:text" json:"notes"`
-	Rating          *int      `json:"rating" validate:"omitempty,min=1,max=5"`
-	InjuryReport    *string   `gorm:"type:text" json:"injury_report"`
-	CreatedAt       time.Time `json:"created_at"`
+	ID              int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID          int64      `gorm:"not null;index;index:user_date" json:"user_id" validate:"required"`
+	ClientID        string     `gorm:"size:36;not null" json:"client_id"`
+	PlanID          *int64     `gorm:"index;index:user_date" json:"plan_id"`
+	WorkoutDate     time.Time  `gorm:"type:date;not null;index:user_date" json:"workout_date" validate:"required"`
+	WorkoutType     string     `gorm:"size:100;not null" json:"workout_type" validate:"required,max=100"`
+	DurationMinutes *int       `json:"duration_minutes" validate:"omitempty,min=0"`
+	StartedAt       *time.Time `json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at"`
+	DurationFlag    *string    `gorm:"size:20" json:"duration_flag"`
+	Exercises       JSONMap    `gorm:"type:json" json:"exercises"`
+	PerformanceData JSONMap    `gorm:"type:json" json:"performance_data"`
+	Notes           *string    `gorm:"type:text" json:"notes"`
+	Rating          *int       `json:"rating" validate:"omitempty,min=1,max=5"`
+	InjuryReport    *string    `gorm:"type:text" json:"injury_report"`
+	IdempotencyKey  *string    `gorm:"size:64" json:"-"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	// DeletedAt is the sync tombstone; deleted records are hidden from queries
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// 关联关系
 	User         User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
@@ -27,6 +37,14 @@ func (TrainingRecord) TableName() string {
 	return "training_records"
 }
 
+// Duration flags mark training records whose duration looks implausible;
+// flagged records are kept but called out in statistics
+const (
+	DurationFlagTooShort = "too_short"
+	DurationFlagTooLong  = "too_long"
+	DurationFlagMismatch = "mismatch" // entered duration disagrees with the session timestamps
+)
+
 type ExerciseRecord struct {
 	ExerciseName string    `json:"exercise_name"`
 	Sets         int       `json:"sets"`
@@ -42,3 +60,18 @@ type PerformanceData struct {
 	AvgHeartRate      *int    `json:"avg_heart_rate"`
 	MaxHeartRate      *int    `json:"max_heart_rate"`
 }
+
+// PlanDayCompletion marks a day of a training plan as done. RecordID links
+// the training record logged for the day, if the user gave one.
+type PlanDayCompletion struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	PlanID      int64     `gorm:"not null;uniqueIndex:uk_plan_date" json:"plan_id"`
+	UserID      int64     `gorm:"not null;index:idx_user_date" json:"user_id"`
+	PlanDate    time.Time `gorm:"type:date;not null;uniqueIndex:uk_plan_date;index:idx_user_date" json:"plan_date"`
+	RecordID    *int64    `json:"record_id"`
+	CompletedAt time.Time `gorm:"not null" json:"completed_at"`
+}
+
+func (PlanDayCompletion) TableName() string {
+	return "plan_day_completions"
+}
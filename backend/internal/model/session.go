@@ -11,6 +11,9 @@ type Session struct {
 	Username  string    `json:"username"`
 	CreatedAt time.Time `json:"created_at"`
 	ExpiresAt time.Time `json:"expires_at"`
-	IPAddress string    `json:"ip_address,omitempty"`
-	UserAgent string    `json:"user_agent,omitempty"`
+	// RefreshedAt is when sliding expiry last extended the session; zero
+	// until the first extension
+	RefreshedAt time.Time `json:"refreshed_at"`
+	IPAddress   string    `json:"ip_address,omitempty"`
+	UserAgent   string    `json:"user_agent,omitempty"`
 }
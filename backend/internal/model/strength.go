@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+)
+
+// StrengthProfileEntry is a user's current one-rep max for a main lift
+type StrengthProfileEntry struct {
+	ID             int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID         int64     `gorm:"not null;uniqueIndex:uk_user_lift" json:"user_id"`
+	Lift           string    `gorm:"size:50;not null;uniqueIndex:uk_user_lift" json:"lift"`
+	OneRepMax      float64   `gorm:"type:decimal(6,2);not null" json:"one_rep_max"`
+	Source         string    `gorm:"size:20;not null;default:manual" json:"source"`
+	SourceRecordID *int64    `json:"source_record_id"`
+	AchievedAt     time.Time `gorm:"type:date;not null" json:"achieved_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (StrengthProfileEntry) TableName() string {
+	return "strength_profiles"
+}
+
+// Main lifts tracked in the strength profile
+const (
+	LiftSquat         = "squat"
+	LiftBenchPress    = "bench_press"
+	LiftDeadlift      = "deadlift"
+	LiftOverheadPress = "overhead_press"
+)
+
+// MainLifts lists the lifts tracked in the strength profile
+var MainLifts = []string{LiftSquat, LiftBenchPress, LiftDeadlift, LiftOverheadPress}
+
+// Strength profile entry sources
+const (
+	StrengthSourceManual = "manual"
+	StrengthSourcePR     = "pr"
+)
@@ -12,9 +12,15 @@ type PromptTemplate struct {
 	Template    string    `gorm:"type:text;not null" json:"template"`
 	Variables   JSONSlice `gorm:"type:json" json:"variables"`
 	IsDefault   bool      `gorm:"default:false;index" json:"is_default"`
-	Description *string   `gorm:"type:text" json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	// IsCustomized is set once the template is edited through the API, so
+	// seeding no longer refreshes its body
+	IsCustomized bool `gorm:"default:false" json:"is_customized"`
+	// RolloutPercent is the share of users, 0-100, a non-default template is
+	// served to instead of the default
+	RolloutPercent int       `gorm:"not null;default:0" json:"rollout_percent"`
+	Description    *string   `gorm:"type:text" json:"description"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 func (PromptTemplate) TableName() string {
@@ -28,6 +34,7 @@ const (
 	PromptCategoryNutrition  PromptCategory = "nutrition"
 	PromptCategoryAssessment PromptCategory = "assessment"
 	PromptCategorySafety     PromptCategory = "safety"
+	PromptCategoryCoach      PromptCategory = "coach"
 )
 
 type TemplateVariable struct {
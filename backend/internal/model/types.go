@@ -4,6 +4,9 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
 	"time"
 )
 
@@ -61,37 +64,164 @@ func (j JSONSlice) Value() (driver.Value, error) {
 
 // User model represents a registered user in the system
 type User struct {
-	ID           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	Username     string    `gorm:"uniqueIndex;size:50;not null" json:"username" validate:"required,min=3,max=50"`
-	Nickname     *string   `gorm:"size:50" json:"nickname" validate:"omitempty,min=1,max=50"`
-	Email        string    `gorm:"uniqueIndex;size:100;not null" json:"email" validate:"required,email,max=100"`
-	Phone        *string   `gorm:"size:20" json:"phone" validate:"omitempty,max=20"`
-	PasswordHash string    `gorm:"size:255;not null" json:"-"`
-	Avatar       *string   `gorm:"type:mediumtext" json:"avatar" validate:"omitempty,avatar"`
-	Status       int8      `gorm:"default:1" json:"status" validate:"oneof=0 1"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                    int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Username              string    `gorm:"uniqueIndex;size:50;not null" json:"username" validate:"required,min=3,max=50"`
+	Nickname              *string   `gorm:"size:50" json:"nickname" validate:"omitempty,min=1,max=50"`
+	Email                 string    `gorm:"uniqueIndex;size:100;not null" json:"email" validate:"required,email,max=100"`
+	Phone                 *string   `gorm:"size:20" json:"phone" validate:"omitempty,max=20"`
+	PasswordHash          string    `gorm:"size:255;not null" json:"-"`
+	Avatar                *string   `gorm:"type:mediumtext" json:"avatar" validate:"omitempty,avatar"`
+	Status                int8      `gorm:"default:1" json:"status" validate:"oneof=0 1"`
+	Role                  string    `gorm:"size:20;not null;default:user" json:"role" validate:"omitempty,oneof=user admin"`
+	OrganizationID        *int64    `gorm:"index" json:"organization_id,omitempty"`
+	WeekStart             string    `gorm:"size:10;not null;default:monday" json:"week_start" validate:"omitempty,oneof=monday sunday"`
+	AutoRollover          bool      `gorm:"not null;default:false" json:"auto_rollover"`            // generate the next block when a plan ends
+	AnalyticsOptOut       bool      `gorm:"not null;default:false" json:"analytics_opt_out"`        // keep the user's data out of analytics exports
+	BusyWeekdays          JSONSlice `gorm:"type:json" json:"busy_weekdays"`                         // weekdays the user cannot train, 0 = Sunday
+	BlackoutDates         JSONSlice `gorm:"type:json" json:"blackout_dates"`                        // dates the user cannot train, YYYY-MM-DD
+	RestPreferences       JSONMap   `gorm:"type:json" json:"rest_preferences"`                      // default rests in seconds by RestKind
+	ResponseArchiveOptOut bool      `gorm:"not null;default:false" json:"response_archive_opt_out"` // keep plan AI responses out of the support archive
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
 }
 
 func (User) TableName() string {
 	return "users"
 }
 
+// Week start settings
+const (
+	WeekStartMonday = "monday"
+	WeekStartSunday = "sunday"
+)
+
+// FirstWeekday returns the day the user's calendar weeks start on
+func (u *User) FirstWeekday() time.Weekday {
+	if u.WeekStart == WeekStartSunday {
+		return time.Sunday
+	}
+	return time.Monday
+}
+
+// BusyWeekdayList returns the user's busy weekdays, skipping invalid entries
+func (u *User) BusyWeekdayList() []time.Weekday {
+	days := make([]time.Weekday, 0, len(u.BusyWeekdays))
+	for _, v := range u.BusyWeekdays {
+		if f, ok := v.(float64); ok && f >= 0 && f <= 6 {
+			days = append(days, time.Weekday(f))
+		} else if i, ok := v.(int); ok && i >= 0 && i <= 6 {
+			days = append(days, time.Weekday(i))
+		}
+	}
+	return days
+}
+
+// BlackoutDateList returns the user's blackout dates as local midnights,
+// skipping invalid entries
+func (u *User) BlackoutDateList() []time.Time {
+	dates := make([]time.Time, 0, len(u.BlackoutDates))
+	for _, v := range u.BlackoutDates {
+		s, _ := v.(string)
+		if d, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+			dates = append(dates, d)
+		}
+	}
+	return dates
+}
+
+// RestIntervals are a user's default rests between sets in seconds by kind
+// of work. A kind left at 0 keeps the rests the plan gives.
+type RestIntervals struct {
+	Strength    int `json:"strength,omitempty" validate:"omitempty,min=10,max=600"`
+	Hypertrophy int `json:"hypertrophy,omitempty" validate:"omitempty,min=10,max=600"`
+	Cardio      int `json:"cardio,omitempty" validate:"omitempty,min=10,max=600"`
+}
+
+// Kinds of work rest preferences are set for
+const (
+	RestKindStrength    = "strength"
+	RestKindHypertrophy = "hypertrophy"
+	RestKindCardio      = "cardio"
+)
+
+// IsZero reports whether no rest is customized
+func (r RestIntervals) IsZero() bool {
+	return r.Strength == 0 && r.Hypertrophy == 0 && r.Cardio == 0
+}
+
+// Seconds returns the customized rest for a kind of work, or 0
+func (r RestIntervals) Seconds(kind string) int {
+	switch kind {
+	case RestKindStrength:
+		return r.Strength
+	case RestKindHypertrophy:
+		return r.Hypertrophy
+	case RestKindCardio:
+		return r.Cardio
+	}
+	return 0
+}
+
+// JSONMap returns the preferences as stored, without the kinds left at 0
+func (r RestIntervals) JSONMap() JSONMap {
+	m := make(JSONMap)
+	for kind, seconds := range map[string]int{
+		RestKindStrength:    r.Strength,
+		RestKindHypertrophy: r.Hypertrophy,
+		RestKindCardio:      r.Cardio,
+	} {
+		if seconds > 0 {
+			m[kind] = seconds
+		}
+	}
+	return m
+}
+
+// RestIntervals returns the user's rest preferences, skipping invalid entries
+func (u *User) RestIntervals() RestIntervals {
+	seconds := func(kind string) int {
+		switch v := u.RestPreferences[kind].(type) {
+		case float64:
+			if v > 0 {
+				return int(v)
+			}
+		case int:
+			if v > 0 {
+				return v
+			}
+		}
+		return 0
+	}
+	return RestIntervals{
+		Strength:    seconds(RestKindStrength),
+		Hypertrophy: seconds(RestKindHypertrophy),
+		Cardio:      seconds(RestKindCardio),
+	}
+}
+
+// User roles
+const (
+	UserRoleUser  = "user"
+	UserRoleAdmin = "admin"
+)
+
 // AIAPI model represents user's AI service configuration
 type AIAPI struct {
-	ID              int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID          int64     `gorm:"not null;index" json:"user_id" validate:"required"`
-	Provider        string    `gorm:"size:50;not null" json:"provider" validate:"required,oneof=openai wenxin tongyi"`
-	Name            string    `gorm:"size:100;not null" json:"name" validate:"required,min=1,max=100"`
-	APIEndpoint     string    `gorm:"size:500;not null" json:"api_endpoint" validate:"required,url,max=500"`
-	APIKeyEncrypted string    `gorm:"type:text;not null" json:"-"`
-	Model           *string   `gorm:"size:100" json:"model" validate:"omitempty,max=100"`
-	MaxTokens       *int      `json:"max_tokens" validate:"omitempty,min=1,max=32000"`
-	Temperature     *float32  `gorm:"type:decimal(3,2)" json:"temperature" validate:"omitempty,min=0,max=2"`
-	IsDefault       bool      `gorm:"default:false" json:"is_default"`
-	Status          int8      `gorm:"default:1" json:"status" validate:"oneof=0 1"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID               int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID           int64     `gorm:"not null;index" json:"user_id" validate:"required"`
+	Provider         string    `gorm:"size:50;not null" json:"provider" validate:"required,oneof=openai wenxin tongyi anthropic deepseek ollama"`
+	Name             string    `gorm:"size:100;not null" json:"name" validate:"required,min=1,max=100"`
+	APIEndpoint      string    `gorm:"size:500;not null" json:"api_endpoint" validate:"required,url,max=500"`
+	APIKeyEncrypted  string    `gorm:"type:text;not null" json:"-"`
+	Model            *string   `gorm:"size:100" json:"model" validate:"omitempty,max=100"`
+	MaxTokens        *int      `json:"max_tokens" validate:"omitempty,min=1,max=32000"`
+	Temperature      *float32  `gorm:"type:decimal(3,2)" json:"temperature" validate:"omitempty,min=0,max=2"`
+	TimeoutSeconds   *int      `json:"timeout_seconds" validate:"omitempty,min=5,max=600"` // nil = ai.timeout
+	IsDefault        bool      `gorm:"default:false" json:"is_default"`
+	FallbackPriority *int      `json:"fallback_priority"`
+	Status           int8      `gorm:"default:1" json:"status" validate:"oneof=0 1"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
 }
 
 func (AIAPI) TableName() string {
@@ -100,25 +230,52 @@ func (AIAPI) TableName() string {
 
 // TrainingPlan model represents a user's training plan
 type TrainingPlan struct {
-	ID              int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID          int64     `gorm:"not null;index" json:"user_id" validate:"required"`
-	PlanName        string    `gorm:"size:200;not null" json:"plan_name" validate:"required,min=1,max=200"`
-	StartDate       time.Time `gorm:"type:date;not null" json:"start_date" validate:"required"`
-	EndDate         time.Time `gorm:"type:date;not null" json:"end_date" validate:"required,gtfield=StartDate"`
-	TotalWeeks      int       `gorm:"not null" json:"total_weeks" validate:"required,min=1,max=52"`
-	DifficultyLevel string    `gorm:"type:enum('easy','medium','hard','extreme')" json:"difficulty_level" validate:"oneof=easy medium hard extreme"`
-	TrainingPurpose *string   `gorm:"size:100" json:"training_purpose" validate:"omitempty,max=100"`
-	AIAPIID         int64     `gorm:"not null;index" json:"ai_api_id" validate:"required"`
-	PlanData        JSONMap   `gorm:"type:json;not null" json:"plan_data"`
-	Status          string    `gorm:"size:20;default:'active'" json:"status" validate:"oneof=active inactive completed"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID               int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID           int64      `gorm:"not null;index" json:"user_id" validate:"required"`
+	PlanName         string     `gorm:"size:200;not null" json:"plan_name" validate:"required,min=1,max=200"`
+	StartDate        time.Time  `gorm:"type:date;not null" json:"start_date" validate:"required"`
+	EndDate          time.Time  `gorm:"type:date;not null" json:"end_date" validate:"required,gtfield=StartDate"`
+	TotalWeeks       int        `gorm:"not null" json:"total_weeks" validate:"required,min=1,max=52"`
+	DifficultyLevel  string     `gorm:"type:enum('easy','medium','hard','extreme')" json:"difficulty_level" validate:"oneof=easy medium hard extreme"`
+	TrainingPurpose  *string    `gorm:"size:100" json:"training_purpose" validate:"omitempty,max=100"`
+	AIAPIID          *int64     `gorm:"index" json:"ai_api_id"` // nil for plans built from training templates
+	AIProvider       *string    `gorm:"column:ai_provider;size:50" json:"ai_provider"`
+	ParentPlanID     *int64     `gorm:"index" json:"parent_plan_id"`     // plan this one adjusts
+	PromptTemplateID *int64     `gorm:"index" json:"prompt_template_id"` // stored template the prompt came from; nil for the built-in one
+	MacrocycleID     *int64     `gorm:"index" json:"macrocycle_id"`
+	BlockNumber      *int       `json:"block_number"` // 1-based position in the macrocycle
+	BlockPhase       *string    `gorm:"size:30" json:"block_phase"`
+	RolledOverAt     *time.Time `json:"rolled_over_at"` // when the next block was queued automatically
+	PausedAt         *time.Time `json:"paused_at"`      // set while the plan is paused
+	CompletedAt      *time.Time `json:"completed_at"`
+	PlanData         JSONMap    `gorm:"type:json;not null" json:"plan_data"`
+	Version          int        `gorm:"not null;default:1" json:"version"` // raised each time a week is regenerated
+	Status           string     `gorm:"size:20;default:'active'" json:"status" validate:"oneof=active paused inactive completed"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
 }
 
 func (TrainingPlan) TableName() string {
 	return "training_plans"
 }
 
+// TrainingPlanVersion is plan data a training plan had before one of its
+// weeks was regenerated. Week and Feedback describe the regeneration that
+// replaced it.
+type TrainingPlanVersion struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	PlanID    int64     `gorm:"not null;uniqueIndex:uk_plan_version" json:"plan_id"`
+	Version   int       `gorm:"not null;uniqueIndex:uk_plan_version" json:"version"`
+	PlanData  JSONMap   `gorm:"type:json;not null" json:"plan_data"`
+	Week      *int      `json:"week"`
+	Feedback  *string   `gorm:"type:text" json:"feedback"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (TrainingPlanVersion) TableName() string {
+	return "training_plan_versions"
+}
+
 // PlanData represents the structure of training plan data stored in JSON
 type PlanData struct {
 	Weeks []WeekPlan `json:"weeks"`
@@ -126,12 +283,15 @@ type PlanData struct {
 
 // WeekPlan represents a week in the training plan
 type WeekPlan struct {
-	Week int       `json:"week"`
-	Days []DayPlan `json:"days"`
+	Week int `json:"week"`
+	// Deload marks a lighter recovery week
+	Deload bool      `json:"deload,omitempty"`
+	Days   []DayPlan `json:"days"`
 }
 
 // DayPlan represents a single day's training schedule
 type DayPlan struct {
+	PlanID            int64      `json:"plan_id,omitempty"`
 	Day               int        `json:"day"`
 	Date              string     `json:"date"`
 	Type              string     `json:"type"` // strength/cardio/rest
@@ -139,6 +299,10 @@ type DayPlan struct {
 	Exercises         []Exercise `json:"exercises"`
 	Duration          int        `json:"duration"` // minutes
 	EstimatedCalories int        `json:"estimated_calories"`
+	// CircuitRounds, when set, makes the day a circuit: every exercise is
+	// done once per round, one after another, for this many rounds
+	CircuitRounds int  `json:"circuit_rounds,omitempty"`
+	IsCompleted   bool `json:"is_completed,omitempty"` // the user marked the day as completed
 }
 
 // Exercise represents a single exercise in a training plan
@@ -150,4 +314,147 @@ type Exercise struct {
 	Rest        string `json:"rest"` // "90s"
 	Difficulty  string `json:"difficulty"`
 	SafetyNotes string `json:"safety_notes"`
+	// SafetyNotesEn is the English version of curated safety notes
+	SafetyNotesEn string `json:"safety_notes_en,omitempty"`
+	// SubstitutedFor is the planned exercise this one replaced
+	SubstitutedFor string `json:"substituted_for,omitempty"`
+	// SupersetID groups the exercises of a day that are done back to back,
+	// resting only after the last of them
+	SupersetID string `json:"superset_id,omitempty"`
+	// Tempo is the lifting tempo, e.g. "3-1-1-0": seconds lowering, paused,
+	// lifting and paused at the top
+	Tempo string `json:"tempo,omitempty"`
+}
+
+// maxStrengthReps is the most reps per set counted as strength work
+const maxStrengthReps = 6
+
+// repCountPattern matches a rep count such as "5", "8-12" or "10次"; timed
+// sets such as "45s" do not match
+var repCountPattern = regexp.MustCompile(`^\s*(\d+)(?:\s*[-~～–]\s*(\d+))?\s*(?:次|reps?)?\s*$`)
+
+// RestKind classifies an exercise of a day of dayType for rest preferences.
+// Everything on a cardio day is cardio; otherwise sets of at most 6 reps are
+// strength work and longer ones hypertrophy. Timed sets and reps without a
+// count, such as "AMRAP", return "".
+func (e Exercise) RestKind(dayType string) string {
+	if dayType == RestKindCardio {
+		return RestKindCardio
+	}
+	m := repCountPattern.FindStringSubmatch(e.Reps)
+	if m == nil {
+		return ""
+	}
+	reps, _ := strconv.Atoi(m[1])
+	if m[2] != "" {
+		reps, _ = strconv.Atoi(m[2])
+	}
+	if reps <= maxStrengthReps {
+		return RestKindStrength
+	}
+	return RestKindHypertrophy
+}
+
+// CustomRests returns the rests of the day's exercises that the preferences
+// replace, by exercise index. Within a superset only the last exercise's
+// rest is replaced, as that is the rest taken after the whole group, and
+// circuit days keep theirs, as they only pause to change exercise.
+func (r RestIntervals) CustomRests(day *DayPlan) map[int]string {
+	if r.IsZero() || day.CircuitRounds > 0 {
+		return nil
+	}
+
+	lastOfSuperset := make(map[string]int)
+	for i, ex := range day.Exercises {
+		if ex.SupersetID != "" {
+			lastOfSuperset[ex.SupersetID] = i
+		}
+	}
+
+	rests := make(map[int]string)
+	for i, ex := range day.Exercises {
+		if ex.SupersetID != "" && lastOfSuperset[ex.SupersetID] != i {
+			continue
+		}
+		if seconds := r.Seconds(ex.RestKind(day.Type)); seconds > 0 {
+			rests[i] = fmt.Sprintf("%ds", seconds)
+		}
+	}
+	return rests
+}
+
+// DayPlanFromData reads a day of a plan's PlanData. Fields that are missing
+// or of the wrong type are left empty.
+func DayPlanFromData(dayMap map[string]interface{}) *DayPlan {
+	dayPlan := &DayPlan{}
+	if day, ok := dayMap["day"].(float64); ok {
+		dayPlan.Day = int(day)
+	}
+	if date, ok := dayMap["date"].(string); ok {
+		dayPlan.Date = date
+	}
+	if typ, ok := dayMap["type"].(string); ok {
+		dayPlan.Type = typ
+	}
+	if focus, ok := dayMap["focus_area"].(string); ok {
+		dayPlan.FocusArea = focus
+	}
+	if duration, ok := dayMap["duration"].(float64); ok {
+		dayPlan.Duration = int(duration)
+	}
+	if calories, ok := dayMap["estimated_calories"].(float64); ok {
+		dayPlan.EstimatedCalories = int(calories)
+	}
+	if rounds, ok := dayMap["circuit_rounds"].(float64); ok {
+		dayPlan.CircuitRounds = int(rounds)
+	}
+
+	if exercisesInterface, ok := dayMap["exercises"].([]interface{}); ok {
+		exercises := make([]Exercise, 0, len(exercisesInterface))
+		for _, exInterface := range exercisesInterface {
+			exMap, ok := exInterface.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			exercise := Exercise{}
+			if name, ok := exMap["name"].(string); ok {
+				exercise.Name = name
+			}
+			if sets, ok := exMap["sets"].(float64); ok {
+				exercise.Sets = int(sets)
+			}
+			if reps, ok := exMap["reps"].(string); ok {
+				exercise.Reps = reps
+			}
+			if weight, ok := exMap["weight"].(string); ok {
+				exercise.Weight = weight
+			}
+			if rest, ok := exMap["rest"].(string); ok {
+				exercise.Rest = rest
+			}
+			if difficulty, ok := exMap["difficulty"].(string); ok {
+				exercise.Difficulty = difficulty
+			}
+			if safety, ok := exMap["safety_notes"].(string); ok {
+				exercise.SafetyNotes = safety
+			}
+			if safety, ok := exMap["safety_notes_en"].(string); ok {
+				exercise.SafetyNotesEn = safety
+			}
+			if planned, ok := exMap["substituted_for"].(string); ok {
+				exercise.SubstitutedFor = planned
+			}
+			if superset, ok := exMap["superset_id"].(string); ok {
+				exercise.SupersetID = superset
+			}
+			if tempo, ok := exMap["tempo"].(string); ok {
+				exercise.Tempo = tempo
+			}
+
+			exercises = append(exercises, exercise)
+		}
+		dayPlan.Exercises = exercises
+	}
+	return dayPlan
 }
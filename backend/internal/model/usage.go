@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+)
+
+// AIUsage records the tokens consumed by one AI call
+type AIUsage struct {
+	ID               int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID           int64     `gorm:"not null;index" json:"user_id"`
+	AIAPIID          int64     `gorm:"column:ai_api_id;not null;index" json:"ai_api_id"`
+	Provider         string    `gorm:"size:50;not null" json:"provider"`
+	Model            string    `gorm:"size:100" json:"model"`
+	Purpose          string    `gorm:"size:30;not null" json:"purpose"`
+	PromptTokens     int       `gorm:"not null" json:"prompt_tokens"`
+	CompletionTokens int       `gorm:"not null" json:"completion_tokens"`
+	TotalTokens      int       `gorm:"not null" json:"total_tokens"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func (AIUsage) TableName() string {
+	return "ai_usage"
+}
+
+// AI usage purposes
+const (
+	AIUsagePurposeTrainingPlan  = "training_plan"
+	AIUsagePurposeNutritionPlan = "nutrition_plan"
+	AIUsagePurposeCoachChat     = "coach_chat"
+)
+
+// AICallLog records one call to an AI provider, successful or not
+type AICallLog struct {
+	ID       int64   `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID   int64   `gorm:"not null;index" json:"user_id"`
+	AIAPIID  int64   `gorm:"column:ai_api_id;not null;index" json:"ai_api_id"`
+	Provider string  `gorm:"size:50;not null" json:"provider"`
+	Model    string  `gorm:"size:100" json:"model"`
+	Purpose  string  `gorm:"size:30;not null" json:"purpose"`
+	Status   string  `gorm:"size:20;not null" json:"status"`
+	Error    *string `gorm:"size:500" json:"error"`
+	// LatencyMs excludes time spent waiting for a concurrency slot
+	LatencyMs        int64 `gorm:"not null" json:"latency_ms"`
+	PromptTokens     int   `gorm:"not null" json:"prompt_tokens"`
+	CompletionTokens int   `gorm:"not null" json:"completion_tokens"`
+	TotalTokens      int   `gorm:"not null" json:"total_tokens"`
+	// PromptHash is a prefix of the prompt's SHA-256, enough to match
+	// identical prompts without storing them
+	PromptHash string    `gorm:"size:16;not null" json:"prompt_hash"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (AICallLog) TableName() string {
+	return "ai_call_logs"
+}
+
+// AI call statuses
+const (
+	AICallStatusSuccess = "success"
+	AICallStatusError   = "error"
+)
@@ -35,9 +35,11 @@ type FitnessGoal struct {
 	InitialBodyFat  *float64   `gorm:"type:decimal(4,2)" json:"initial_body_fat" validate:"omitempty,min=0,max=100"`
 	InitialMuscle   *float64   `gorm:"type:decimal(4,2)" json:"initial_muscle_mass" validate:"omitempty,min=0,max=100"`
 	TargetWeight    *float64   `gorm:"type:decimal(5,2)" json:"target_weight" validate:"omitempty,min=20,max=500"`
+	TargetBodyFat   *float64   `gorm:"type:decimal(4,2)" json:"target_body_fat" validate:"omitempty,min=0,max=100"`
 	Deadline        *time.Time `gorm:"type:date" json:"deadline"`
 	Priority        int        `gorm:"default:1" json:"priority" validate:"min=1,max=10"`
 	Status          string     `gorm:"size:20;default:'active';index:user_status" json:"status" validate:"oneof=active completed cancelled"`
+	CompletedAt     *time.Time `json:"completed_at"`
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
 
@@ -66,3 +68,6 @@ const (
 	GoalStatusCompleted GoalStatus = "completed"
 	GoalStatusCancelled GoalStatus = "cancelled"
 )
+
+// GoalTypeMaintenance is suggested once a body composition goal is achieved
+const GoalTypeMaintenance = "maintenance"
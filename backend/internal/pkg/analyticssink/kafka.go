@@ -0,0 +1,92 @@
+package analyticssink
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// kafkaBatchSize is the number of records sent per REST proxy request
+const kafkaBatchSize = 500
+
+// kafkaSink produces each event as a JSON record through a Kafka REST proxy
+type kafkaSink struct {
+	cfg    KafkaConfig
+	client *http.Client
+}
+
+func (s *kafkaSink) Type() string {
+	return TypeKafka
+}
+
+// kafkaRecords is the v2 REST proxy produce request body
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaRecord struct {
+	Value json.RawMessage `json:"value"`
+}
+
+// Write produces one record per line of data. The batch name is not part of
+// the records; consumers tell batches apart by the events' dates.
+func (s *kafkaSink) Write(ctx context.Context, name string, data []byte) (string, error) {
+	var batch []kafkaRecord
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		batch = append(batch, kafkaRecord{Value: append(json.RawMessage(nil), line...)})
+		if len(batch) == kafkaBatchSize {
+			if err := s.produce(ctx, batch); err != nil {
+				return "", err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read analytics batch: %w", err)
+	}
+	if len(batch) > 0 {
+		if err := s.produce(ctx, batch); err != nil {
+			return "", err
+		}
+	}
+	return "kafka://" + s.cfg.Topic, nil
+}
+
+// produce sends records to the topic in one request
+func (s *kafkaSink) produce(ctx context.Context, records []kafkaRecord) error {
+	body, err := json.Marshal(kafkaRecords{Records: records})
+	if err != nil {
+		return err
+	}
+
+	target := strings.TrimRight(s.cfg.RESTProxyURL, "/") + "/topics/" + url.PathEscape(s.cfg.Topic)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to produce analytics events: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Kafka REST proxy rejected analytics events: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+// Package analyticssink delivers exported analytics events to the store an
+// offline analytics pipeline reads from: a directory, an S3 bucket or a
+// Kafka topic behind a REST proxy.
+package analyticssink
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Sink types
+const (
+	TypeFile  = "file"
+	TypeS3    = "s3"
+	TypeKafka = "kafka"
+)
+
+// Sink stores batches of events
+type Sink interface {
+	// Type is the sink type recorded with each export
+	Type() string
+	// Write stores data, newline-delimited JSON events, as the batch name
+	// and returns where it was stored
+	Write(ctx context.Context, name string, data []byte) (string, error)
+}
+
+// Config selects and configures the sink. An empty Type selects the file
+// sink.
+type Config struct {
+	Type  string
+	Dir   string
+	S3    S3Config
+	Kafka KafkaConfig
+}
+
+// S3Config locates the bucket batches are uploaded to. Endpoint defaults
+// to AWS; set it for S3-compatible stores, which are addressed path-style.
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// KafkaConfig names the topic events are produced to and the Kafka REST
+// proxy (v2 API) that produces them
+type KafkaConfig struct {
+	RESTProxyURL string
+	Topic        string
+}
+
+// requestTimeout bounds each upload to S3 or the REST proxy
+const requestTimeout = 30 * time.Second
+
+// New returns the sink cfg selects
+func New(cfg Config) (Sink, error) {
+	client := &http.Client{Timeout: requestTimeout}
+	switch cfg.Type {
+	case "", TypeFile:
+		if cfg.Dir == "" {
+			return nil, fmt.Errorf("analytics file sink needs a directory")
+		}
+		return &fileSink{dir: cfg.Dir}, nil
+	case TypeS3:
+		if cfg.S3.Bucket == "" || cfg.S3.Region == "" {
+			return nil, fmt.Errorf("analytics S3 sink needs a bucket and region")
+		}
+		return &s3Sink{cfg: cfg.S3, client: client, now: time.Now}, nil
+	case TypeKafka:
+		if cfg.Kafka.RESTProxyURL == "" || cfg.Kafka.Topic == "" {
+			return nil, fmt.Errorf("analytics Kafka sink needs a REST proxy URL and topic")
+		}
+		return &kafkaSink{cfg: cfg.Kafka, client: client}, nil
+	default:
+		return nil, fmt.Errorf("unknown analytics sink type %q", cfg.Type)
+	}
+}
+
+// fileSink writes each batch to a file in a directory
+type fileSink struct {
+	dir string
+}
+
+func (s *fileSink) Type() string {
+	return TypeFile
+}
+
+// Write writes the batch to a temporary file and renames it into place, so
+// a pipeline watching the directory never reads a partial batch
+func (s *fileSink) Write(ctx context.Context, name string, data []byte) (string, error) {
+	if err := os.MkdirAll(s.dir, 0750); err != nil {
+		return "", fmt.Errorf("failed to create analytics directory: %w", err)
+	}
+	path := filepath.Join(s.dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0640); err != nil {
+		return "", fmt.Errorf("failed to write analytics batch: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to write analytics batch: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,120 @@
+package analyticssink
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_RejectsIncompleteConfig(t *testing.T) {
+	_, err := New(Config{Type: TypeFile})
+	assert.Error(t, err)
+	_, err = New(Config{Type: TypeS3, S3: S3Config{Bucket: "events"}})
+	assert.Error(t, err)
+	_, err = New(Config{Type: TypeKafka, Kafka: KafkaConfig{Topic: "events"}})
+	assert.Error(t, err)
+	_, err = New(Config{Type: "ftp"})
+	assert.Error(t, err)
+}
+
+func TestFileSink_WritesBatch(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "analytics")
+	sink, err := New(Config{Dir: dir})
+	require.NoError(t, err)
+
+	location, err := sink.Write(context.Background(), "events-2024-01-01.jsonl", []byte("{\"a\":1}\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(dir, "events-2024-01-01.jsonl"), location)
+	data, err := os.ReadFile(location)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"a\":1}\n", string(data))
+	_, err = os.Stat(location + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestKafkaSink_ProducesOneRecordPerLine(t *testing.T) {
+	var requests []kafkaRecords
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/topics/health-events", r.URL.Path)
+		assert.Equal(t, "application/vnd.kafka.json.v2+json", r.Header.Get("Content-Type"))
+		var body kafkaRecords
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink, err := New(Config{Type: TypeKafka, Kafka: KafkaConfig{RESTProxyURL: server.URL + "/", Topic: "health-events"}})
+	require.NoError(t, err)
+
+	var data strings.Builder
+	for i := 0; i < kafkaBatchSize+1; i++ {
+		data.WriteString("{\"event\":\"workout\"}\n")
+	}
+	location, err := sink.Write(context.Background(), "batch", []byte(data.String()))
+	require.NoError(t, err)
+
+	assert.Equal(t, "kafka://health-events", location)
+	require.Len(t, requests, 2)
+	assert.Len(t, requests[0].Records, kafkaBatchSize)
+	assert.Len(t, requests[1].Records, 1)
+	assert.JSONEq(t, `{"event":"workout"}`, string(requests[1].Records[0].Value))
+}
+
+func TestKafkaSink_ReportsRejection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unknown topic", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	sink, err := New(Config{Type: TypeKafka, Kafka: KafkaConfig{RESTProxyURL: server.URL, Topic: "missing"}})
+	require.NoError(t, err)
+
+	_, err = sink.Write(context.Background(), "batch", []byte("{}\n"))
+	assert.ErrorContains(t, err, "unknown topic")
+}
+
+func TestS3Sink_PutsSignedObject(t *testing.T) {
+	var gotPath, gotAuth, gotHash string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		gotHash = r.Header.Get("X-Amz-Content-Sha256")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink, err := New(Config{Type: TypeS3, S3: S3Config{
+		Endpoint:        server.URL,
+		Region:          "us-east-1",
+		Bucket:          "analytics",
+		Prefix:          "fitness/",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+	}})
+	require.NoError(t, err)
+	sink.(*s3Sink).now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	location, err := sink.Write(context.Background(), "events.jsonl", []byte("{}\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "s3://analytics/fitness/events.jsonl", location)
+	assert.Equal(t, "/analytics/fitness/events.jsonl", gotPath)
+	assert.Equal(t, "{}\n", string(gotBody))
+	assert.Equal(t, sha256Hex([]byte("{}\n")), gotHash)
+	assert.True(t, strings.HasPrefix(gotAuth,
+		"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20240102/us-east-1/s3/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date, Signature="))
+}
@@ -0,0 +1,103 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// PassphraseFileExt is appended to the name of passphrase-encrypted files
+const PassphraseFileExt = ".aes"
+
+// MinPassphraseLength is the shortest passphrase accepted for file encryption
+const MinPassphraseLength = 8
+
+// passphraseMagic identifies the file format: magic | salt | nonce | AES-256-GCM ciphertext
+var passphraseMagic = []byte("AIFPENC1")
+
+const (
+	passphraseSaltSize = 16
+	scryptN            = 1 << 15
+	scryptR            = 8
+	scryptP            = 1
+)
+
+// ErrInvalidPassphrase is returned when decryption fails, which is almost
+// always a wrong passphrase
+var ErrInvalidPassphrase = errors.New("invalid passphrase or corrupted file")
+
+// EncryptWithPassphrase encrypts data with a key derived from passphrase via
+// scrypt. The salt and nonce are stored in the output, so only the passphrase
+// is needed to decrypt.
+func EncryptWithPassphrase(data []byte, passphrase string) ([]byte, error) {
+	if len(passphrase) < MinPassphraseLength {
+		return nil, fmt.Errorf("passphrase must be at least %d characters", MinPassphraseLength)
+	}
+
+	salt := make([]byte, passphraseSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	gcm, err := passphraseCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := make([]byte, 0, len(passphraseMagic)+len(salt)+len(nonce)+len(data)+gcm.Overhead())
+	out = append(out, passphraseMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	// The header is authenticated so it cannot be swapped
+	return gcm.Seal(out, nonce, data, out), nil
+}
+
+// DecryptWithPassphrase reverses EncryptWithPassphrase
+func DecryptWithPassphrase(data []byte, passphrase string) ([]byte, error) {
+	if !bytes.HasPrefix(data, passphraseMagic) {
+		return nil, ErrInvalidPassphrase
+	}
+	saltEnd := len(passphraseMagic) + passphraseSaltSize
+	if len(data) < saltEnd {
+		return nil, ErrInvalidPassphrase
+	}
+	gcm, err := passphraseCipher(passphrase, data[len(passphraseMagic):saltEnd])
+	if err != nil {
+		return nil, err
+	}
+	headerEnd := saltEnd + gcm.NonceSize()
+	if len(data) < headerEnd+gcm.Overhead() {
+		return nil, ErrInvalidPassphrase
+	}
+
+	plaintext, err := gcm.Open(nil, data[saltEnd:headerEnd], data[headerEnd:], data[:headerEnd])
+	if err != nil {
+		return nil, ErrInvalidPassphrase
+	}
+	return plaintext, nil
+}
+
+func passphraseCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,37 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassphraseRoundTrip(t *testing.T) {
+	data := []byte("id,workout_date\n1,2024-01-02\n")
+
+	encrypted, err := EncryptWithPassphrase(data, "correct horse")
+	require.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "workout_date")
+
+	decrypted, err := DecryptWithPassphrase(encrypted, "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, data, decrypted)
+
+	_, err = DecryptWithPassphrase(encrypted, "wrong passphrase")
+	assert.ErrorIs(t, err, ErrInvalidPassphrase)
+}
+
+func TestPassphraseTamperedHeader(t *testing.T) {
+	encrypted, err := EncryptWithPassphrase([]byte("data"), "correct horse")
+	require.NoError(t, err)
+
+	encrypted[len(passphraseMagic)] ^= 0xff
+	_, err = DecryptWithPassphrase(encrypted, "correct horse")
+	assert.ErrorIs(t, err, ErrInvalidPassphrase)
+}
+
+func TestPassphraseTooShort(t *testing.T) {
+	_, err := EncryptWithPassphrase([]byte("data"), "short")
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/ai-fitness-planner/backend/internal/config"
@@ -10,7 +11,9 @@ import (
 	"gorm.io/gorm/logger"
 )
 
-var DB *gorm.DB
+// db is published atomically: the connection may be made in the background
+// while /health reads it during degraded mode
+var db atomic.Pointer[gorm.DB]
 
 // InitDatabase initializes the MySQL database connection with GORM
 func InitDatabase() error {
@@ -34,7 +37,7 @@ func InitDatabase() error {
 	}
 
 	// Open database connection
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+	conn, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
 		Logger: gormLogger,
 		NowFunc: func() time.Time {
 			return time.Now().Local()
@@ -45,7 +48,7 @@ func InitDatabase() error {
 	}
 
 	// Get underlying SQL DB to configure connection pool
-	sqlDB, err := db.DB()
+	sqlDB, err := conn.DB()
 	if err != nil {
 		return fmt.Errorf("failed to get database instance: %w", err)
 	}
@@ -57,17 +60,18 @@ func InitDatabase() error {
 
 	// Test connection
 	if err := sqlDB.Ping(); err != nil {
+		sqlDB.Close()
 		return fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	DB = db
+	db.Store(conn)
 	return nil
 }
 
 // Close closes the database connection
 func Close() error {
-	if DB != nil {
-		sqlDB, err := DB.DB()
+	if conn := GetDB(); conn != nil {
+		sqlDB, err := conn.DB()
 		if err != nil {
 			return err
 		}
@@ -76,7 +80,7 @@ func Close() error {
 	return nil
 }
 
-// GetDB returns the database instance
+// GetDB returns the database instance, or nil before InitDatabase succeeds
 func GetDB() *gorm.DB {
-	return DB
+	return db.Load()
 }
@@ -0,0 +1,141 @@
+// Package events is an in-process domain event bus. Services publish what
+// happened to plans, records and goals; subscribers such as the stream
+// publisher react to it. Publishing never fails the operation that caused
+// the event: handlers run synchronously on the publishing goroutine and must
+// hand slow work off instead of blocking.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types
+const (
+	TrainingPlanCreated       = "training_plan.created"
+	TrainingPlanStatusChanged = "training_plan.status_changed"
+	TrainingPlanDeleted       = "training_plan.deleted"
+	NutritionPlanCreated      = "nutrition_plan.created"
+	TrainingRecordCreated     = "training_record.created"
+	NutritionRecordCreated    = "nutrition_record.created"
+	GoalSet                   = "goal.set"
+	GoalAchieved              = "goal.achieved"
+)
+
+// SchemaVersions is the payload schema version of each event type. Bump a
+// type's version whenever a field of its payload is renamed, removed or
+// changes meaning; adding a field keeps the version.
+var SchemaVersions = map[string]int{
+	TrainingPlanCreated:       1,
+	TrainingPlanStatusChanged: 1,
+	TrainingPlanDeleted:       1,
+	NutritionPlanCreated:      1,
+	TrainingRecordCreated:     1,
+	NutritionRecordCreated:    1,
+	GoalSet:                   1,
+	GoalAchieved:              1,
+}
+
+// Event is something that happened to a user's data
+type Event struct {
+	ID         string
+	Type       string
+	UserID     int64
+	OccurredAt time.Time
+	// Data is the payload, one of the payload types below
+	Data interface{}
+}
+
+// PlanPayload describes a training or nutrition plan event
+type PlanPayload struct {
+	PlanID int64  `json:"plan_id"`
+	Name   string `json:"name,omitempty"`
+	// Source is how a created plan came about: "generated", "adjusted",
+	// "cloned" or "manual"
+	Source string `json:"source,omitempty"`
+	// ParentPlanID is the plan an adjusted or cloned plan was made from
+	ParentPlanID *int64 `json:"parent_plan_id,omitempty"`
+	Status       string `json:"status,omitempty"`
+	PrevStatus   string `json:"prev_status,omitempty"`
+	StartDate    string `json:"start_date,omitempty"`
+	EndDate      string `json:"end_date,omitempty"`
+}
+
+// RecordPayload describes a training or nutrition record event
+type RecordPayload struct {
+	RecordID int64  `json:"record_id"`
+	PlanID   *int64 `json:"plan_id,omitempty"`
+	Date     string `json:"date"`
+	// Kind is the workout type or meal time
+	Kind            string   `json:"kind,omitempty"`
+	DurationMinutes *int     `json:"duration_minutes,omitempty"`
+	Calories        *float64 `json:"calories,omitempty"`
+}
+
+// GoalPayload describes a fitness goal event
+type GoalPayload struct {
+	GoalID        int64    `json:"goal_id"`
+	GoalType      string   `json:"goal_type"`
+	TargetWeight  *float64 `json:"target_weight,omitempty"`
+	TargetBodyFat *float64 `json:"target_body_fat,omitempty"`
+	Deadline      string   `json:"deadline,omitempty"`
+}
+
+// Handler reacts to an event
+type Handler func(ctx context.Context, event Event)
+
+// Bus dispatches published events to its subscribers
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+// NewBus creates a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe adds a handler for every event published from now on
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish stamps the event with an ID and time, if unset, and passes it to
+// each subscriber in subscription order
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+	if len(handlers) == 0 {
+		return
+	}
+
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	for _, handler := range handlers {
+		handler(ctx, event)
+	}
+}
+
+// defaultBus is the process-wide bus services publish to
+var defaultBus = NewBus()
+
+// Subscribe adds a handler to the process-wide bus
+func Subscribe(handler Handler) {
+	defaultBus.Subscribe(handler)
+}
+
+// Publish publishes an event of the given type on the process-wide bus. It
+// costs nothing while nobody is subscribed.
+func Publish(ctx context.Context, eventType string, userID int64, data interface{}) {
+	defaultBus.Publish(ctx, Event{Type: eventType, UserID: userID, Data: data})
+}
@@ -0,0 +1,283 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus_PublishStampsAndDispatchesInOrder(t *testing.T) {
+	bus := NewBus()
+	var got []string
+	bus.Subscribe(func(ctx context.Context, event Event) {
+		assert.NotEmpty(t, event.ID)
+		assert.False(t, event.OccurredAt.IsZero())
+		got = append(got, "first:"+event.Type)
+	})
+	bus.Subscribe(func(ctx context.Context, event Event) {
+		got = append(got, "second:"+event.Type)
+	})
+
+	bus.Publish(context.Background(), Event{Type: GoalSet, UserID: 7})
+
+	assert.Equal(t, []string{"first:goal.set", "second:goal.set"}, got)
+}
+
+func TestSchemaVersions_CoverEveryEventType(t *testing.T) {
+	for _, eventType := range []string{
+		TrainingPlanCreated, TrainingPlanStatusChanged, TrainingPlanDeleted,
+		NutritionPlanCreated, TrainingRecordCreated, NutritionRecordCreated,
+		GoalSet, GoalAchieved,
+	} {
+		assert.Positive(t, SchemaVersions[eventType], eventType)
+	}
+}
+
+func TestNewEnvelope_CarriesSchemaVersion(t *testing.T) {
+	occurred := time.Date(2024, 3, 4, 10, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	envelope := NewEnvelope(Event{
+		ID:         "e1",
+		Type:       TrainingPlanCreated,
+		UserID:     42,
+		OccurredAt: occurred,
+		Data:       PlanPayload{PlanID: 3, Source: "generated"},
+	})
+
+	data, err := json.Marshal(envelope)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": "e1",
+		"type": "training_plan.created",
+		"schema_version": 1,
+		"occurred_at": "2024-03-04T02:00:00Z",
+		"user_id": 42,
+		"data": {"plan_id": 3, "source": "generated"}
+	}`, string(data))
+}
+
+func TestNewTransport_RejectsIncompleteConfig(t *testing.T) {
+	_, err := NewTransport(StreamConfig{Transport: TransportKafka})
+	assert.Error(t, err)
+	_, err = NewTransport(StreamConfig{Transport: TransportNATS})
+	assert.Error(t, err)
+	_, err = NewTransport(StreamConfig{Transport: "amqp"})
+	assert.Error(t, err)
+}
+
+func TestStreamer_Topic(t *testing.T) {
+	streamer := NewStreamer(&recordingTransport{}, StreamConfig{TopicPrefix: "prod"})
+	assert.Equal(t, "prod.training_plan", streamer.Topic(TrainingPlanCreated))
+	assert.Equal(t, "prod.goal", streamer.Topic(GoalAchieved))
+}
+
+func TestStreamer_DeliversQueuedEventsOnShutdown(t *testing.T) {
+	transport := &recordingTransport{}
+	streamer := NewStreamer(transport, StreamConfig{})
+	streamer.Start()
+
+	streamer.Handle(context.Background(), Event{ID: "a", Type: TrainingRecordCreated, UserID: 5, OccurredAt: time.Now()})
+	streamer.Handle(context.Background(), Event{ID: "b", Type: GoalAchieved, UserID: 5, OccurredAt: time.Now()})
+	require.NoError(t, streamer.Shutdown(context.Background()))
+
+	require.Len(t, transport.sent, 2)
+	assert.Equal(t, "fitplanner.training_record", transport.sent[0].topic)
+	assert.Equal(t, "5", transport.sent[0].key)
+	assert.Equal(t, "fitplanner.goal", transport.sent[1].topic)
+	assert.True(t, transport.closed)
+
+	// Events after shutdown are ignored
+	streamer.Handle(context.Background(), Event{ID: "c", Type: GoalSet})
+	assert.Len(t, transport.sent, 2)
+}
+
+func TestStreamer_DropsEventsWhenBufferFull(t *testing.T) {
+	var dropped []string
+	streamer := NewStreamer(&recordingTransport{}, StreamConfig{
+		BufferSize: 1,
+		OnError: func(event Event, err error) {
+			assert.ErrorIs(t, err, ErrBufferFull)
+			dropped = append(dropped, event.ID)
+		},
+	})
+
+	// Not started, so the first event fills the buffer
+	streamer.Handle(context.Background(), Event{ID: "a", Type: GoalSet})
+	streamer.Handle(context.Background(), Event{ID: "b", Type: GoalSet})
+
+	assert.Equal(t, []string{"b"}, dropped)
+}
+
+func TestKafkaTransport_ProducesKeyedRecord(t *testing.T) {
+	var body kafkaRecords
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/topics/fitplanner.goal", r.URL.Path)
+		assert.Equal(t, "application/vnd.kafka.json.v2+json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	transport, err := NewTransport(StreamConfig{Transport: TransportKafka, KafkaRESTProxyURL: server.URL + "/"})
+	require.NoError(t, err)
+
+	require.NoError(t, transport.Send(context.Background(), "fitplanner.goal", "9", []byte(`{"id":"e1"}`)))
+	require.Len(t, body.Records, 1)
+	assert.Equal(t, "9", body.Records[0].Key)
+	assert.JSONEq(t, `{"id":"e1"}`, string(body.Records[0].Value))
+}
+
+func TestKafkaTransport_ReportsRejection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unknown topic", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	transport, err := NewTransport(StreamConfig{Transport: TransportKafka, KafkaRESTProxyURL: server.URL})
+	require.NoError(t, err)
+
+	err = transport.Send(context.Background(), "fitplanner.goal", "9", []byte(`{}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown topic")
+}
+
+func TestNATSTransport_PublishesAfterConnect(t *testing.T) {
+	server := newFakeNATSServer(t)
+	defer server.Close()
+
+	transport, err := NewTransport(StreamConfig{Transport: TransportNATS, NATSURL: "nats://" + server.Addr(), NATSToken: "secret"})
+	require.NoError(t, err)
+	defer transport.Close()
+
+	require.NoError(t, transport.Send(context.Background(), "fitplanner.goal", "9", []byte(`{"id":"e1"}`)))
+	require.NoError(t, transport.Send(context.Background(), "fitplanner.goal", "9", []byte(`{"id":"e2"}`)))
+
+	connects, published := server.Received()
+	require.Len(t, connects, 1)
+	assert.Contains(t, connects[0], `"auth_token":"secret"`)
+	assert.Equal(t, []string{"fitplanner.goal {\"id\":\"e1\"}", "fitplanner.goal {\"id\":\"e2\"}"}, published)
+}
+
+func TestNATSTransport_ReportsServerError(t *testing.T) {
+	server := newFakeNATSServer(t)
+	server.reject = true
+	defer server.Close()
+
+	transport, err := NewTransport(StreamConfig{Transport: TransportNATS, NATSURL: server.Addr()})
+	require.NoError(t, err)
+	defer transport.Close()
+
+	err = transport.Send(context.Background(), "fitplanner.goal", "9", []byte(`{}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Permissions Violation")
+}
+
+type sentEvent struct {
+	topic   string
+	key     string
+	payload []byte
+}
+
+type recordingTransport struct {
+	mu     sync.Mutex
+	sent   []sentEvent
+	closed bool
+}
+
+func (t *recordingTransport) Send(ctx context.Context, topic, key string, payload []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sent = append(t.sent, sentEvent{topic: topic, key: key, payload: payload})
+	return nil
+}
+
+func (t *recordingTransport) Close() error {
+	t.closed = true
+	return nil
+}
+
+// fakeNATSServer speaks enough of the NATS protocol to accept a connection
+// and publishes
+type fakeNATSServer struct {
+	listener net.Listener
+	reject   bool
+
+	mu        sync.Mutex
+	connects  []string
+	published []string
+}
+
+func newFakeNATSServer(t *testing.T) *fakeNATSServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &fakeNATSServer{listener: listener}
+	go server.serve()
+	return server
+}
+
+func (s *fakeNATSServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeNATSServer) Close() {
+	s.listener.Close()
+}
+
+func (s *fakeNATSServer) Received() ([]string, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.connects...), append([]string(nil), s.published...)
+}
+
+func (s *fakeNATSServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeNATSServer) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.Write([]byte("INFO {\"server_id\":\"fake\"}\r\n"))
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "CONNECT "):
+			s.mu.Lock()
+			s.connects = append(s.connects, strings.TrimPrefix(line, "CONNECT "))
+			s.mu.Unlock()
+		case strings.HasPrefix(line, "PUB "):
+			fields := strings.Fields(line)
+			payload, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if s.reject {
+				conn.Write([]byte("-ERR 'Permissions Violation for Publish to " + fields[1] + "'\r\n"))
+				return
+			}
+			s.mu.Lock()
+			s.published = append(s.published, fields[1]+" "+strings.TrimRight(payload, "\r\n"))
+			s.mu.Unlock()
+		case line == "PING":
+			conn.Write([]byte("PONG\r\n"))
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsDefaultPort is used when the server URL has no port
+const natsDefaultPort = "4222"
+
+// natsTransport publishes events to NATS subjects over the plain text
+// protocol. Each publish is followed by a PING so that a PONG confirms the
+// server processed it; NATS has no message keys, so key is not sent.
+type natsTransport struct {
+	addr    string
+	connect natsConnect
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// natsConnect is the CONNECT message body
+type natsConnect struct {
+	Verbose   bool   `json:"verbose"`
+	Pedantic  bool   `json:"pedantic"`
+	Name      string `json:"name"`
+	Lang      string `json:"lang"`
+	Version   string `json:"version"`
+	User      string `json:"user,omitempty"`
+	Pass      string `json:"pass,omitempty"`
+	AuthToken string `json:"auth_token,omitempty"`
+}
+
+func newNATSTransport(cfg StreamConfig) (*natsTransport, error) {
+	server := cfg.NATSURL
+	if !strings.Contains(server, "://") {
+		server = "nats://" + server
+	}
+	u, err := url.Parse(server)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NATS server URL: %w", err)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid NATS server URL %q", cfg.NATSURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = natsDefaultPort
+	}
+
+	connect := natsConnect{
+		Name:      "fitplanner-events",
+		Lang:      "go",
+		Version:   "1",
+		User:      cfg.NATSUser,
+		Pass:      cfg.NATSPassword,
+		AuthToken: cfg.NATSToken,
+	}
+	if u.User != nil && connect.User == "" {
+		connect.User = u.User.Username()
+		connect.Pass, _ = u.User.Password()
+	}
+	return &natsTransport{addr: net.JoinHostPort(u.Hostname(), port), connect: connect}, nil
+}
+
+// Send publishes payload, reconnecting once if the connection was lost
+func (t *natsTransport) Send(ctx context.Context, topic, key string, payload []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.publish(ctx, topic, payload)
+	if err != nil && ctx.Err() == nil {
+		t.reset()
+		err = t.publish(ctx, topic, payload)
+	}
+	if err != nil {
+		t.reset()
+		return fmt.Errorf("failed to publish event to NATS: %w", err)
+	}
+	return nil
+}
+
+func (t *natsTransport) publish(ctx context.Context, subject string, payload []byte) error {
+	if t.conn == nil {
+		if err := t.dial(ctx); err != nil {
+			return err
+		}
+	}
+	t.setDeadline(ctx)
+	if _, err := fmt.Fprintf(t.conn, "PUB %s %d\r\n%s\r\nPING\r\n", subject, len(payload), payload); err != nil {
+		return err
+	}
+	return t.awaitPong()
+}
+
+// dial connects and authenticates
+func (t *natsTransport) dial(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return err
+	}
+	t.conn = conn
+	t.reader = bufio.NewReader(conn)
+	t.setDeadline(ctx)
+
+	line, err := t.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO") {
+		return fmt.Errorf("unexpected NATS greeting %q", line)
+	}
+	body, err := json.Marshal(t.connect)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(t.conn, "CONNECT %s\r\nPING\r\n", body); err != nil {
+		return err
+	}
+	return t.awaitPong()
+}
+
+// awaitPong reads until the server answers our PING, answering the
+// server's own PINGs on the way
+func (t *natsTransport) awaitPong() error {
+	for {
+		line, err := t.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := t.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS server error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+func (t *natsTransport) readLine() (string, error) {
+	line, err := t.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (t *natsTransport) setDeadline(ctx context.Context) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(sendTimeout)
+	}
+	t.conn.SetDeadline(deadline)
+}
+
+// reset drops the connection so the next publish dials again
+func (t *natsTransport) reset() {
+	if t.conn != nil {
+		t.conn.Close()
+		t.conn = nil
+		t.reader = nil
+	}
+}
+
+func (t *natsTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reset()
+	return nil
+}
@@ -0,0 +1,228 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transport types
+const (
+	TransportKafka = "kafka"
+	TransportNATS  = "nats"
+)
+
+// ErrBufferFull is reported for events dropped because the transport fell
+// too far behind
+var ErrBufferFull = errors.New("event stream buffer full")
+
+// Transport delivers serialized events to a message broker
+type Transport interface {
+	// Send publishes payload to topic; key groups the events of one user
+	// so brokers that partition keep them in order
+	Send(ctx context.Context, topic, key string, payload []byte) error
+	Close() error
+}
+
+// Envelope is the wire format of a streamed event. Consumers read
+// SchemaVersion to pick the decoder for Data.
+type Envelope struct {
+	ID            string      `json:"id"`
+	Type          string      `json:"type"`
+	SchemaVersion int         `json:"schema_version"`
+	OccurredAt    time.Time   `json:"occurred_at"`
+	UserID        int64       `json:"user_id"`
+	Data          interface{} `json:"data"`
+}
+
+// NewEnvelope wraps an event for the wire
+func NewEnvelope(event Event) Envelope {
+	version := SchemaVersions[event.Type]
+	if version == 0 {
+		version = 1
+	}
+	return Envelope{
+		ID:            event.ID,
+		Type:          event.Type,
+		SchemaVersion: version,
+		OccurredAt:    event.OccurredAt.UTC(),
+		UserID:        event.UserID,
+		Data:          event.Data,
+	}
+}
+
+// StreamConfig configures a Streamer
+type StreamConfig struct {
+	// Transport is "kafka" or "nats"
+	Transport string
+	// TopicPrefix starts every topic; events go to "<prefix>.<entity>",
+	// e.g. "fitplanner.training_plan"
+	TopicPrefix string
+	// BufferSize is the number of events held while the broker is slow or
+	// unreachable; events beyond it are dropped
+	BufferSize int
+
+	// KafkaRESTProxyURL is the Kafka REST proxy (v2 API) events are
+	// produced through
+	KafkaRESTProxyURL string
+
+	// NATSURL is the NATS server, e.g. "nats://localhost:4222"
+	NATSURL      string
+	NATSUser     string
+	NATSPassword string
+	NATSToken    string
+
+	// OnError, if set, is told about events that could not be delivered
+	OnError func(event Event, err error)
+}
+
+// sendTimeout bounds the delivery of one event
+const sendTimeout = 10 * time.Second
+
+// NewTransport returns the transport cfg selects
+func NewTransport(cfg StreamConfig) (Transport, error) {
+	switch cfg.Transport {
+	case TransportKafka:
+		if cfg.KafkaRESTProxyURL == "" {
+			return nil, fmt.Errorf("Kafka event stream needs a REST proxy URL")
+		}
+		return &kafkaTransport{
+			proxyURL: strings.TrimRight(cfg.KafkaRESTProxyURL, "/"),
+			client:   &http.Client{Timeout: sendTimeout},
+		}, nil
+	case TransportNATS:
+		if cfg.NATSURL == "" {
+			return nil, fmt.Errorf("NATS event stream needs a server URL")
+		}
+		return newNATSTransport(cfg)
+	default:
+		return nil, fmt.Errorf("unknown event stream transport %q", cfg.Transport)
+	}
+}
+
+// Streamer forwards bus events to a broker. Handle only queues the event,
+// so publishers never wait on the network; a single goroutine delivers the
+// queue in order.
+type Streamer struct {
+	transport Transport
+	prefix    string
+	onError   func(event Event, err error)
+	queue     chan Event
+
+	mu      sync.Mutex
+	started bool
+	closed  bool
+	done    chan struct{}
+}
+
+// NewStreamer creates a streamer sending through transport
+func NewStreamer(transport Transport, cfg StreamConfig) *Streamer {
+	if cfg.TopicPrefix == "" {
+		cfg.TopicPrefix = "fitplanner"
+	}
+	if cfg.BufferSize < 1 {
+		cfg.BufferSize = 1000
+	}
+	return &Streamer{
+		transport: transport,
+		prefix:    cfg.TopicPrefix,
+		onError:   cfg.OnError,
+		queue:     make(chan Event, cfg.BufferSize),
+		done:      make(chan struct{}),
+	}
+}
+
+// Handle queues an event for delivery. It is a bus Handler.
+func (s *Streamer) Handle(ctx context.Context, event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- event:
+	default:
+		s.report(event, ErrBufferFull)
+	}
+}
+
+// Start starts delivering queued events
+func (s *Streamer) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	go s.run()
+}
+
+// Shutdown stops accepting events and waits until the queued ones are
+// delivered or ctx is done, then closes the transport
+func (s *Streamer) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.queue)
+	started := s.started
+	s.mu.Unlock()
+
+	var err error
+	if started {
+		select {
+		case <-s.done:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	if closeErr := s.transport.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (s *Streamer) run() {
+	defer close(s.done)
+	for event := range s.queue {
+		s.send(event)
+	}
+}
+
+// send delivers one event; a failed event is reported and dropped rather
+// than retried, so a broker outage cannot back the queue up indefinitely
+func (s *Streamer) send(event Event) {
+	payload, err := json.Marshal(NewEnvelope(event))
+	if err != nil {
+		s.report(event, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	if err := s.transport.Send(ctx, s.Topic(event.Type), strconv.FormatInt(event.UserID, 10), payload); err != nil {
+		s.report(event, err)
+	}
+}
+
+// Topic returns the topic events of the given type are sent to
+func (s *Streamer) Topic(eventType string) string {
+	entity := eventType
+	if i := strings.Index(eventType, "."); i > 0 {
+		entity = eventType[:i]
+	}
+	return s.prefix + "." + entity
+}
+
+func (s *Streamer) report(event Event, err error) {
+	if s.onError != nil {
+		s.onError(event, err)
+	}
+}
@@ -0,0 +1,244 @@
+// Package jobqueue provides an in-process background job queue with separate
+// worker classes so that expensive, low-priority work (such as large exports)
+// runs on a capped pool and cannot starve latency-sensitive requests.
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Class identifies a worker pool
+type Class string
+
+const (
+	// ClassDefault is for regular background work
+	ClassDefault Class = "default"
+	// ClassLow is for expensive work such as exports, with fewer workers
+	ClassLow Class = "low"
+)
+
+// Job statuses
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+)
+
+// ErrQueueFull is returned by Submit when the class backlog is at capacity
+var ErrQueueFull = errors.New("job queue is full")
+
+// ErrUnknownClass is returned by Submit for a class without a pool
+var ErrUnknownClass = errors.New("unknown job class")
+
+// Result is the output of a completed job
+type Result struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Func performs the work of a job
+type Func func(ctx context.Context) (*Result, error)
+
+// Job holds the state of a submitted job. Done and Total are the progress
+// last reported by the job, both 0 if it reports none.
+type Job struct {
+	ID        string
+	Class     Class
+	OwnerID   int64
+	Kind      string
+	Status    string
+	Error     string
+	Result    *Result
+	Done      int64
+	Total     int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PoolConfig sizes a worker pool
+type PoolConfig struct {
+	Workers   int
+	QueueSize int
+}
+
+type queuedJob struct {
+	id string
+	fn Func
+}
+
+type progressKey struct{}
+
+// progressReporter is stored on a job's context to update its progress
+type progressReporter struct {
+	q  *Queue
+	id string
+}
+
+// ReportProgress records that done of total units of the job running with
+// ctx have been processed. It does nothing outside a job.
+func ReportProgress(ctx context.Context, done, total int64) {
+	p, ok := ctx.Value(progressKey{}).(progressReporter)
+	if !ok {
+		return
+	}
+	p.q.mu.Lock()
+	defer p.q.mu.Unlock()
+	if job, ok := p.q.jobs[p.id]; ok {
+		job.Done = done
+		job.Total = total
+		job.UpdatedAt = time.Now()
+	}
+}
+
+// Queue runs jobs on per-class worker pools
+type Queue struct {
+	pools     map[Class]chan queuedJob
+	jobs      map[string]*Job
+	mu        sync.RWMutex
+	resultTTL time.Duration
+	timeout   time.Duration
+}
+
+// New creates a queue and starts the workers for each configured class.
+// Finished jobs are kept for resultTTL; each job is bounded by timeout.
+func New(pools map[Class]PoolConfig, resultTTL, timeout time.Duration) *Queue {
+	q := &Queue{
+		pools:     make(map[Class]chan queuedJob, len(pools)),
+		jobs:      make(map[string]*Job),
+		resultTTL: resultTTL,
+		timeout:   timeout,
+	}
+
+	for class, cfg := range pools {
+		workers := cfg.Workers
+		if workers < 1 {
+			workers = 1
+		}
+		size := cfg.QueueSize
+		if size < 1 {
+			size = 1
+		}
+
+		ch := make(chan queuedJob, size)
+		q.pools[class] = ch
+		for i := 0; i < workers; i++ {
+			go q.worker(ch)
+		}
+	}
+
+	return q
+}
+
+// Submit enqueues fn on the given class and returns the job ID
+func (q *Queue) Submit(class Class, ownerID int64, kind string, fn Func) (string, error) {
+	ch, ok := q.pools[class]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownClass, class)
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:        uuid.New().String(),
+		Class:     class,
+		OwnerID:   ownerID,
+		Kind:      kind,
+		Status:    StatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	q.mu.Lock()
+	q.sweepLocked(now)
+	q.jobs[job.ID] = job
+	q.mu.Unlock()
+
+	select {
+	case ch <- queuedJob{id: job.ID, fn: fn}:
+		return job.ID, nil
+	default:
+		q.mu.Lock()
+		delete(q.jobs, job.ID)
+		q.mu.Unlock()
+		return "", ErrQueueFull
+	}
+}
+
+// Get returns a copy of the job state
+func (q *Queue) Get(id string) (*Job, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *job
+	return &copied, true
+}
+
+// worker processes jobs from a class channel until the process exits
+func (q *Queue) worker(ch chan queuedJob) {
+	for qj := range ch {
+		q.setStatus(qj.id, StatusProcessing, "", nil)
+
+		ctx := context.WithValue(context.Background(), progressKey{}, progressReporter{q: q, id: qj.id})
+		var cancel context.CancelFunc
+		if q.timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, q.timeout)
+		}
+		result, err := runJob(ctx, qj.fn)
+		if cancel != nil {
+			cancel()
+		}
+
+		if err != nil {
+			q.setStatus(qj.id, StatusFailed, err.Error(), nil)
+			continue
+		}
+		q.setStatus(qj.id, StatusCompleted, "", result)
+	}
+}
+
+// runJob calls fn and converts a panic into an error so a bad job cannot
+// take down its worker
+func runJob(ctx context.Context, fn Func) (result *Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+func (q *Queue) setStatus(id, status, errMsg string, result *Result) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job, ok := q.jobs[id]; ok {
+		job.Status = status
+		job.Error = errMsg
+		job.Result = result
+		job.UpdatedAt = time.Now()
+	}
+}
+
+// sweepLocked drops finished jobs older than the result TTL; q.mu must be held
+func (q *Queue) sweepLocked(now time.Time) {
+	if q.resultTTL <= 0 {
+		return
+	}
+	for id, job := range q.jobs {
+		finished := job.Status == StatusCompleted || job.Status == StatusFailed
+		if finished && now.Sub(job.UpdatedAt) > q.resultTTL {
+			delete(q.jobs, id)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func waitForStatus(t *testing.T, q *Queue, id string) *Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		job, ok := q.Get(id)
+		require.True(t, ok)
+		if job.Status == StatusCompleted || job.Status == StatusFailed {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return nil
+}
+
+func TestSubmit_Completes(t *testing.T) {
+	q := New(map[Class]PoolConfig{ClassLow: {Workers: 1, QueueSize: 2}}, time.Hour, time.Second)
+
+	id, err := q.Submit(ClassLow, 42, "export", func(ctx context.Context) (*Result, error) {
+		return &Result{Filename: "a.csv", ContentType: "text/csv", Data: []byte("x")}, nil
+	})
+	require.NoError(t, err)
+
+	job := waitForStatus(t, q, id)
+	assert.Equal(t, StatusCompleted, job.Status)
+	assert.Equal(t, int64(42), job.OwnerID)
+	require.NotNil(t, job.Result)
+	assert.Equal(t, "a.csv", job.Result.Filename)
+}
+
+func TestSubmit_FailureAndPanic(t *testing.T) {
+	q := New(map[Class]PoolConfig{ClassLow: {Workers: 1, QueueSize: 2}}, time.Hour, time.Second)
+
+	failID, err := q.Submit(ClassLow, 1, "export", func(ctx context.Context) (*Result, error) {
+		return nil, errors.New("boom")
+	})
+	require.NoError(t, err)
+	panicID, err := q.Submit(ClassLow, 1, "export", func(ctx context.Context) (*Result, error) {
+		panic("bad job")
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "boom", waitForStatus(t, q, failID).Error)
+	assert.Equal(t, StatusFailed, waitForStatus(t, q, panicID).Status)
+}
+
+func TestSubmit_QueueFull(t *testing.T) {
+	q := New(map[Class]PoolConfig{ClassLow: {Workers: 1, QueueSize: 1}}, time.Hour, time.Second)
+
+	block := make(chan struct{})
+	defer close(block)
+	slow := func(ctx context.Context) (*Result, error) {
+		<-block
+		return &Result{}, nil
+	}
+
+	// One job occupies the worker, one fills the backlog
+	_, err := q.Submit(ClassLow, 1, "export", slow)
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = q.Submit(ClassLow, 1, "export", slow)
+	require.NoError(t, err)
+
+	_, err = q.Submit(ClassLow, 1, "export", slow)
+	assert.ErrorIs(t, err, ErrQueueFull)
+}
+
+func TestSubmit_UnknownClass(t *testing.T) {
+	q := New(map[Class]PoolConfig{ClassLow: {Workers: 1, QueueSize: 1}}, time.Hour, time.Second)
+
+	_, err := q.Submit(ClassDefault, 1, "export", func(ctx context.Context) (*Result, error) {
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, ErrUnknownClass)
+}
+
+func TestReportProgress(t *testing.T) {
+	q := New(map[Class]PoolConfig{ClassLow: {Workers: 1, QueueSize: 1}}, time.Hour, time.Second)
+
+	reported := make(chan struct{})
+	resume := make(chan struct{})
+	id, err := q.Submit(ClassLow, 1, "cleanup", func(ctx context.Context) (*Result, error) {
+		ReportProgress(ctx, 3, 10)
+		close(reported)
+		<-resume
+		ReportProgress(ctx, 10, 10)
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	<-reported
+	job, ok := q.Get(id)
+	require.True(t, ok)
+	assert.Equal(t, int64(3), job.Done)
+	assert.Equal(t, int64(10), job.Total)
+
+	close(resume)
+	job = waitForStatus(t, q, id)
+	assert.Equal(t, StatusCompleted, job.Status)
+	assert.Equal(t, int64(10), job.Done)
+
+	// Outside a job it is a no-op
+	ReportProgress(context.Background(), 1, 1)
+}
@@ -3,6 +3,7 @@ package jwt
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,12 +11,53 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Token issuers. Service tokens have their own issuer and signing secret so
+// that neither kind of token is accepted in place of the other. Share tokens
+// are signed with the user secret but have their own issuer, so a shared
+// link never authenticates a request.
+const (
+	UserIssuer    = "ai-fitness-planner"
+	ServiceIssuer = "ai-fitness-planner-internal"
+	ShareIssuer   = "ai-fitness-planner-share"
+)
+
+// Token type claims
+const (
+	TokenTypeService = "service"
+	TokenTypeShare   = "share"
+)
+
+// ErrServiceTokensDisabled is returned when no service secret is configured
+var ErrServiceTokensDisabled = errors.New("service tokens are disabled")
+
 // Claims represents JWT claims with user information
 type Claims struct {
 	UserID    int64  `json:"user_id"`
 	Username  string `json:"username"`
 	SessionID string `json:"session_id"`
 	Type      string `json:"type"` // "access" or "refresh"
+	// ImpersonatorID is the admin acting as this user; zero for normal tokens
+	ImpersonatorID int64 `json:"impersonator_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// ServiceClaims identifies a trusted internal service such as the scheduler,
+// a bot or an admin tool. The token's ID names it in audit logs.
+type ServiceClaims struct {
+	Service string `json:"service"`
+	// UserID is the user the service acts for
+	UserID int64  `json:"user_id"`
+	Type   string `json:"type"`
+	jwt.RegisteredClaims
+}
+
+// ShareClaims grants read-only access to one training plan through a public
+// link. UserID is the owner when the link was made, so the link stops
+// working if the plan changes hands.
+type ShareClaims struct {
+	PlanID int64  `json:"plan_id"`
+	UserID int64  `json:"user_id"`
+	Type   string `json:"type"`
 	jwt.RegisteredClaims
 }
 
@@ -25,19 +67,27 @@ type JWTManager interface {
 	GenerateRefreshToken(userID int64, username string) (string, error)
 	ValidateToken(tokenString string) (*Claims, error)
 	RefreshAccessToken(refreshToken string) (string, error)
+	GenerateImpersonationToken(userID int64, username string, impersonatorID int64, ttl time.Duration) (string, error)
+	GenerateServiceToken(service string, userID int64, ttl time.Duration) (string, error)
+	ValidateServiceToken(tokenString string) (*ServiceClaims, error)
+	GenerateShareToken(planID, userID int64, ttl time.Duration) (string, error)
+	ValidateShareToken(tokenString string) (*ShareClaims, error)
 }
 
 // DefaultJWTManager implements the JWTManager interface
 type DefaultJWTManager struct {
 	secret             string
+	serviceSecret      string
 	accessTokenExpire  time.Duration
 	refreshTokenExpire time.Duration
 }
 
-// NewJWTManager creates a new JWT manager with configuration
-func NewJWTManager(secret string, accessExpire, refreshExpire time.Duration) JWTManager {
+// NewJWTManager creates a new JWT manager with configuration. An empty
+// serviceSecret disables service tokens.
+func NewJWTManager(secret, serviceSecret string, accessExpire, refreshExpire time.Duration) JWTManager {
 	return &DefaultJWTManager{
 		secret:             secret,
+		serviceSecret:      serviceSecret,
 		accessTokenExpire:  accessExpire,
 		refreshTokenExpire: refreshExpire,
 	}
@@ -48,6 +98,7 @@ func NewJWTManagerFromConfig() JWTManager {
 	jwtConfig := config.GlobalConfig.JWT
 	return NewJWTManager(
 		jwtConfig.Secret,
+		jwtConfig.ServiceSecret,
 		jwtConfig.AccessTokenExpire,
 		jwtConfig.RefreshTokenExpire,
 	)
@@ -66,7 +117,7 @@ func (m *DefaultJWTManager) GenerateAccessToken(userID int64, username string) (
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.accessTokenExpire)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "ai-fitness-planner",
+			Issuer:    UserIssuer,
 		},
 	}
 
@@ -92,7 +143,7 @@ func (m *DefaultJWTManager) GenerateRefreshToken(userID int64, username string)
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.refreshTokenExpire)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "ai-fitness-planner",
+			Issuer:    UserIssuer,
 		},
 	}
 
@@ -112,7 +163,7 @@ func (m *DefaultJWTManager) ValidateToken(tokenString string) (*Claims, error) {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(m.secret), nil
-	})
+	}, jwt.WithIssuer(UserIssuer))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -147,7 +198,7 @@ func (m *DefaultJWTManager) RefreshAccessToken(refreshToken string) (string, err
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.accessTokenExpire)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "ai-fitness-planner",
+			Issuer:    UserIssuer,
 		},
 	}
 
@@ -160,6 +211,138 @@ func (m *DefaultJWTManager) RefreshAccessToken(refreshToken string) (string, err
 	return newAccessToken, nil
 }
 
+// GenerateImpersonationToken generates a short-lived access token that lets an
+// admin act as the given user. It has no refresh counterpart.
+func (m *DefaultJWTManager) GenerateImpersonationToken(userID int64, username string, impersonatorID int64, ttl time.Duration) (string, error) {
+	claims := Claims{
+		UserID:         userID,
+		Username:       username,
+		SessionID:      generateSessionID(),
+		Type:           "access",
+		ImpersonatorID: impersonatorID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    UserIssuer,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(m.secret))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate impersonation token: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+// GenerateServiceToken generates a token for an internal service acting for
+// the given user. Requests made with it skip session checks and rate limits.
+func (m *DefaultJWTManager) GenerateServiceToken(service string, userID int64, ttl time.Duration) (string, error) {
+	if m.serviceSecret == "" {
+		return "", ErrServiceTokensDisabled
+	}
+	if service == "" || userID <= 0 {
+		return "", fmt.Errorf("service token needs a service name and user")
+	}
+
+	claims := ServiceClaims{
+		Service: service,
+		UserID:  userID,
+		Type:    TokenTypeService,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        generateSessionID(),
+			Subject:   service,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    ServiceIssuer,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(m.serviceSecret))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate service token: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+// ValidateServiceToken validates a service token and returns its claims
+func (m *DefaultJWTManager) ValidateServiceToken(tokenString string) (*ServiceClaims, error) {
+	if m.serviceSecret == "" {
+		return nil, ErrServiceTokensDisabled
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &ServiceClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(m.serviceSecret), nil
+	}, jwt.WithIssuer(ServiceIssuer))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse service token: %w", err)
+	}
+
+	claims, ok := token.Claims.(*ServiceClaims)
+	if !ok || !token.Valid || claims.Type != TokenTypeService || claims.Service == "" || claims.UserID <= 0 {
+		return nil, fmt.Errorf("invalid service token")
+	}
+	return claims, nil
+}
+
+// GenerateShareToken generates a token for the public link to a user's
+// training plan
+func (m *DefaultJWTManager) GenerateShareToken(planID, userID int64, ttl time.Duration) (string, error) {
+	if planID <= 0 || userID <= 0 {
+		return "", fmt.Errorf("share token needs a plan and its owner")
+	}
+
+	claims := ShareClaims{
+		PlanID: planID,
+		UserID: userID,
+		Type:   TokenTypeShare,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        generateSessionID(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    ShareIssuer,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(m.secret))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate share token: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+// ValidateShareToken validates a share token and returns its claims
+func (m *DefaultJWTManager) ValidateShareToken(tokenString string) (*ShareClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ShareClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(m.secret), nil
+	}, jwt.WithIssuer(ShareIssuer))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse share token: %w", err)
+	}
+
+	claims, ok := token.Claims.(*ShareClaims)
+	if !ok || !token.Valid || claims.Type != TokenTypeShare || claims.PlanID <= 0 || claims.UserID <= 0 {
+		return nil, fmt.Errorf("invalid share token")
+	}
+	return claims, nil
+}
+
 // generateSessionID generates a unique session ID using crypto/rand
 func generateSessionID() string {
 	b := make([]byte, 16)
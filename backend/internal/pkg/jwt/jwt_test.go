@@ -0,0 +1,104 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceToken_RoundTrip(t *testing.T) {
+	manager := NewJWTManager("user-secret", "service-secret", time.Hour, 24*time.Hour)
+
+	token, err := manager.GenerateServiceToken("scheduler", 42, time.Hour)
+	require.NoError(t, err)
+
+	claims, err := manager.ValidateServiceToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "scheduler", claims.Service)
+	assert.Equal(t, int64(42), claims.UserID)
+	assert.Equal(t, ServiceIssuer, claims.Issuer)
+	assert.NotEmpty(t, claims.ID)
+}
+
+func TestServiceToken_NotAcceptedAsUserToken(t *testing.T) {
+	// Even with one secret for both, the issuer keeps the kinds apart
+	manager := NewJWTManager("shared-secret", "shared-secret", time.Hour, 24*time.Hour)
+
+	serviceToken, err := manager.GenerateServiceToken("bot", 7, time.Hour)
+	require.NoError(t, err)
+	_, err = manager.ValidateToken(serviceToken)
+	assert.Error(t, err)
+
+	accessToken, err := manager.GenerateAccessToken(7, "alice")
+	require.NoError(t, err)
+	_, err = manager.ValidateServiceToken(accessToken)
+	assert.Error(t, err)
+}
+
+func TestServiceToken_Disabled(t *testing.T) {
+	manager := NewJWTManager("user-secret", "", time.Hour, 24*time.Hour)
+
+	_, err := manager.GenerateServiceToken("scheduler", 42, time.Hour)
+	assert.ErrorIs(t, err, ErrServiceTokensDisabled)
+	_, err = manager.ValidateServiceToken("anything")
+	assert.ErrorIs(t, err, ErrServiceTokensDisabled)
+}
+
+func TestServiceToken_Expired(t *testing.T) {
+	manager := NewJWTManager("user-secret", "service-secret", time.Hour, 24*time.Hour)
+
+	token, err := manager.GenerateServiceToken("scheduler", 42, -time.Minute)
+	require.NoError(t, err)
+	_, err = manager.ValidateServiceToken(token)
+	assert.Error(t, err)
+}
+
+func TestShareToken_RoundTrip(t *testing.T) {
+	manager := NewJWTManager("user-secret", "service-secret", time.Hour, 24*time.Hour)
+
+	token, err := manager.GenerateShareToken(3, 42, 24*time.Hour)
+	require.NoError(t, err)
+
+	claims, err := manager.ValidateShareToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), claims.PlanID)
+	assert.Equal(t, int64(42), claims.UserID)
+	assert.Equal(t, ShareIssuer, claims.Issuer)
+}
+
+func TestShareToken_NotAcceptedAsUserToken(t *testing.T) {
+	// Share tokens are signed with the user secret; only the issuer keeps a
+	// public link from authenticating as its owner
+	manager := NewJWTManager("user-secret", "service-secret", time.Hour, 24*time.Hour)
+
+	shareToken, err := manager.GenerateShareToken(3, 42, time.Hour)
+	require.NoError(t, err)
+	_, err = manager.ValidateToken(shareToken)
+	assert.Error(t, err)
+
+	accessToken, err := manager.GenerateAccessToken(42, "alice")
+	require.NoError(t, err)
+	_, err = manager.ValidateShareToken(accessToken)
+	assert.Error(t, err)
+}
+
+func TestShareToken_Expired(t *testing.T) {
+	manager := NewJWTManager("user-secret", "service-secret", time.Hour, 24*time.Hour)
+
+	token, err := manager.GenerateShareToken(3, 42, -time.Minute)
+	require.NoError(t, err)
+	_, err = manager.ValidateShareToken(token)
+	assert.Error(t, err)
+}
+
+func TestShareToken_Tampered(t *testing.T) {
+	manager := NewJWTManager("user-secret", "service-secret", time.Hour, 24*time.Hour)
+	other := NewJWTManager("other-secret", "service-secret", time.Hour, 24*time.Hour)
+
+	token, err := other.GenerateShareToken(3, 42, time.Hour)
+	require.NoError(t, err)
+	_, err = manager.ValidateShareToken(token)
+	assert.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Mailer sends plain-text emails
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// Config holds SMTP settings. An empty Host selects the log mailer.
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// New returns an SMTP mailer, or a mailer that only logs when no SMTP host is
+// configured so development setups work without a mail server
+func New(cfg Config) Mailer {
+	if cfg.Host == "" {
+		return &logMailer{}
+	}
+	return &smtpMailer{cfg: cfg}
+}
+
+// smtpMailer sends mail through an SMTP server, using STARTTLS when offered
+type smtpMailer struct {
+	cfg Config
+}
+
+// Send delivers one message
+func (m *smtpMailer) Send(ctx context.Context, to, subject, body string) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", m.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(body)
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+
+	// net/smtp has no context support; run it aside so ctx can still bound the wait
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, m.cfg.From, []string{to}, msg.Bytes())
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send mail: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// logMailer writes messages to the log instead of sending them
+type logMailer struct{}
+
+// Send logs the message
+func (m *logMailer) Send(ctx context.Context, to, subject, body string) error {
+	logger.Info("Mail not sent, no SMTP host configured",
+		zap.String("to", to),
+		zap.String("subject", subject),
+		zap.String("body", body),
+	)
+	return nil
+}
@@ -0,0 +1,172 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// MaxPasswordLength is the longest password the standard security handler
+// uses; longer passwords would be cut short
+const MaxPasswordLength = 32
+
+// ErrInvalidPassword is returned for passwords viewers could not reproduce:
+// revision 4 of the standard security handler takes at most 32 bytes of
+// PDFDocEncoding, so only printable ASCII is portable
+var ErrInvalidPassword = errors.New("pdf: password must be 1-32 printable ASCII characters")
+
+// CheckPassword returns ErrInvalidPassword for passwords Encrypt rejects
+func CheckPassword(password string) error {
+	if len(password) == 0 || len(password) > MaxPasswordLength {
+		return ErrInvalidPassword
+	}
+	for _, c := range []byte(password) {
+		if c < 0x20 || c > 0x7e {
+			return ErrInvalidPassword
+		}
+	}
+	return nil
+}
+
+// passwordPadding pads passwords to 32 bytes (ISO 32000-1, 7.6.3.3)
+var passwordPadding = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+// permissions allows printing and nothing else: bits 3 and 12 are set, as
+// are the reserved bits 7-8 and 13-32
+const permissions int32 = -1852
+
+// encryption holds the keys of a document encrypted with the standard
+// security handler, revision 4, using 128-bit AES for strings and streams
+type encryption struct {
+	id  []byte
+	key []byte
+	o   []byte
+	u   []byte
+}
+
+// newEncryption derives the keys for password. The owner password, which
+// would lift the restrictions, is random and discarded.
+func newEncryption(password string) (*encryption, error) {
+	if err := CheckPassword(password); err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	owner := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(owner); err != nil {
+		return nil, err
+	}
+
+	e := &encryption{id: id}
+	e.o = ownerHash(owner, []byte(password))
+	e.key = fileKey([]byte(password), e.o, id)
+	e.u = userHash(e.key, id)
+	return e, nil
+}
+
+// padPassword cuts or pads password to 32 bytes (algorithm 2, step a)
+func padPassword(password []byte) []byte {
+	if len(password) > 32 {
+		password = password[:32]
+	}
+	padded := append([]byte(nil), password...)
+	return append(padded, passwordPadding[:32-len(padded)]...)
+}
+
+// rc4Rounds encrypts data in place twenty times, the key XORed with the
+// round number each time (algorithms 3 and 5)
+func rc4Rounds(key, data []byte) {
+	roundKey := make([]byte, len(key))
+	for i := 0; i < 20; i++ {
+		for j := range key {
+			roundKey[j] = key[j] ^ byte(i)
+		}
+		c, _ := rc4.NewCipher(roundKey)
+		c.XORKeyStream(data, data)
+	}
+}
+
+// ownerHash computes the O entry (algorithm 3)
+func ownerHash(owner, user []byte) []byte {
+	sum := md5.Sum(padPassword(owner))
+	for i := 0; i < 50; i++ {
+		sum = md5.Sum(sum[:])
+	}
+	o := padPassword(user)
+	rc4Rounds(sum[:], o)
+	return o
+}
+
+// fileKey computes the 128-bit file encryption key (algorithm 2)
+func fileKey(user, o, id []byte) []byte {
+	h := md5.New()
+	h.Write(padPassword(user))
+	h.Write(o)
+	p := permissions
+	h.Write(binary.LittleEndian.AppendUint32(nil, uint32(p)))
+	h.Write(id)
+	sum := h.Sum(nil)
+	for i := 0; i < 50; i++ {
+		next := md5.Sum(sum)
+		sum = next[:]
+	}
+	return sum
+}
+
+// userHash computes the U entry (algorithm 5). Only its first 16 bytes are
+// checked; the rest is padding.
+func userHash(key, id []byte) []byte {
+	h := md5.New()
+	h.Write(passwordPadding)
+	h.Write(id)
+	u := h.Sum(nil)
+	rc4Rounds(key, u)
+	return append(u, make([]byte, 16)...)
+}
+
+// objectKey derives the key of an object's strings and streams
+// (algorithm 1)
+func (e *encryption) objectKey(object int) []byte {
+	h := md5.New()
+	h.Write(e.key)
+	h.Write([]byte{byte(object), byte(object >> 8), byte(object >> 16), 0, 0})
+	h.Write([]byte("sAlT"))
+	return h.Sum(nil)
+}
+
+// encrypt encrypts a string or stream of object with AES-128-CBC: a random
+// IV followed by the PKCS#7 padded ciphertext
+func (e *encryption) encrypt(object int, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(e.objectKey(object))
+	if err != nil {
+		return nil, err
+	}
+	pad := aes.BlockSize - len(data)%aes.BlockSize
+	plain := append(append([]byte(nil), data...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+
+	out := make([]byte, aes.BlockSize+len(plain))
+	if _, err := rand.Read(out[:aes.BlockSize]); err != nil {
+		return nil, err
+	}
+	cipher.NewCBCEncrypter(block, out[:aes.BlockSize]).CryptBlocks(out[aes.BlockSize:], plain)
+	return out, nil
+}
+
+// dictionary returns the Encrypt dictionary
+func (e *encryption) dictionary() string {
+	return fmt.Sprintf("<< /Filter /Standard /V 4 /R 4 /Length 128 "+
+		"/CF << /StdCF << /Type /CryptFilter /CFM /AESV2 /AuthEvent /DocOpen /Length 16 >> >> "+
+		"/StmF /StdCF /StrF /StdCF /O <%X> /U <%X> /P %d >>", e.o, e.u, permissions)
+}
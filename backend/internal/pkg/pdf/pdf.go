@@ -0,0 +1,329 @@
+// Package pdf writes simple printable documents: styled, word-wrapped lines
+// of text flowed over A4 pages. Text is set in STSong-Light, one of the CJK
+// fonts PDF viewers are required to provide, so Chinese renders without
+// embedding a font file.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// A4 page size and margins, in points
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	margin     = 50.0
+	// footerY is the baseline of the page number
+	footerY = 30.0
+	// lineSpacing is the line height as a multiple of the font size
+	lineSpacing = 1.4
+	// watermarkSize and watermarkAngle set the watermark's font size and its
+	// slant in degrees; it is repeated every watermarkGap points up the page
+	watermarkSize  = 22.0
+	watermarkAngle = 30.0
+	watermarkGap   = 220.0
+	watermarkGray  = 0.85
+)
+
+// Style sets how a block of text is drawn
+type Style struct {
+	Size float64
+	// Indent is the left indent from the margin, in points
+	Indent float64
+	// SpaceBefore is extra space above the block, in points
+	SpaceBefore float64
+	Bold        bool
+	// Gray is the text gray level, 0 for black
+	Gray float64
+}
+
+// Predefined styles
+var (
+	Title      = Style{Size: 18, Bold: true}
+	Heading    = Style{Size: 14, SpaceBefore: 14, Bold: true}
+	Subheading = Style{Size: 11, SpaceBefore: 8, Bold: true}
+	Body       = Style{Size: 10, SpaceBefore: 2}
+	Item       = Style{Size: 10, Indent: 14, SpaceBefore: 1}
+	Note       = Style{Size: 9, Indent: 28, Gray: 0.35}
+)
+
+// Document is a PDF being written. The zero value is not usable; create
+// documents with New.
+type Document struct {
+	title     string
+	watermark string
+	pages     []*bytes.Buffer
+	// y is the baseline of the last line on the current page
+	y float64
+}
+
+// New creates an empty document; title is stored in its metadata
+func New(title string) *Document {
+	return &Document{title: title}
+}
+
+// Write adds text in the given style below what was written before,
+// wrapping it to the page width and starting new pages as needed. Newlines
+// in text start new lines.
+func (d *Document) Write(style Style, text string) {
+	if style.Size <= 0 {
+		style.Size = Body.Size
+	}
+	width := pageWidth - 2*margin - style.Indent
+	lineHeight := style.Size * lineSpacing
+
+	first := true
+	for _, paragraph := range strings.Split(text, "\n") {
+		for _, line := range wrap(paragraph, style.Size, width) {
+			advance := lineHeight
+			if first && len(d.pages) > 0 && d.y < pageHeight-margin {
+				advance += style.SpaceBefore
+			}
+			first = false
+			if len(d.pages) == 0 || d.y-advance < margin {
+				d.newPage()
+				advance = lineHeight
+			}
+			d.y -= advance
+			d.drawLine(d.pages[len(d.pages)-1], style, margin+style.Indent, d.y, line)
+		}
+	}
+}
+
+// Space adds vertical space, ignored at the top of a page
+func (d *Document) Space(points float64) {
+	if len(d.pages) > 0 && d.y < pageHeight-margin {
+		d.y -= points
+	}
+}
+
+// Watermark sets text drawn in light gray across every page, beneath the
+// content
+func (d *Document) Watermark(text string) {
+	d.watermark = text
+}
+
+// PageCount returns the number of pages written so far
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pageHeight - margin
+}
+
+func (d *Document) drawLine(page *bytes.Buffer, style Style, x, y float64, text string) {
+	page.WriteString("BT\n")
+	if style.Gray > 0 {
+		fmt.Fprintf(page, "%.2f g\n", style.Gray)
+	}
+	if style.Bold {
+		// Fill and stroke the glyphs; the font has no bold face
+		fmt.Fprintf(page, "2 Tr %.2f w\n", style.Size*0.03)
+	}
+	fmt.Fprintf(page, "/F1 %.1f Tf\n%.2f %.2f Td\n<%s> Tj\nET\n", style.Size, x, y, encodeText(text))
+	if style.Gray > 0 {
+		page.WriteString("0 g\n")
+	}
+}
+
+// drawWatermark repeats the watermark up the page, slanted and centred
+func (d *Document) drawWatermark(page *bytes.Buffer) {
+	if d.watermark == "" {
+		return
+	}
+	sin, cos := math.Sincos(watermarkAngle * math.Pi / 180)
+	width := textWidth(d.watermark, watermarkSize)
+	text := encodeText(d.watermark)
+
+	fmt.Fprintf(page, "BT\n%.2f g\n/F1 %.1f Tf\n", watermarkGray, watermarkSize)
+	for y := watermarkGap / 2; y < pageHeight; y += watermarkGap {
+		x := (pageWidth - cos*width) / 2
+		fmt.Fprintf(page, "%.4f %.4f %.4f %.4f %.2f %.2f Tm\n<%s> Tj\n", cos, sin, -sin, cos, x, y-sin*width/2, text)
+	}
+	page.WriteString("ET\n0 g\n")
+}
+
+// Bytes renders the document, numbering its pages
+func (d *Document) Bytes() []byte {
+	// Nothing can fail without encryption
+	data, _ := d.render(nil)
+	return data
+}
+
+// Encrypt renders the document so that it opens only with password, using
+// 128-bit AES. Printing is allowed; copying and editing are not.
+func (d *Document) Encrypt(password string) ([]byte, error) {
+	enc, err := newEncryption(password)
+	if err != nil {
+		return nil, err
+	}
+	return d.render(enc)
+}
+
+// render writes the document, encrypting its strings and streams when enc
+// is set
+func (d *Document) render(enc *encryption) ([]byte, error) {
+	if len(d.pages) == 0 {
+		d.newPage()
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	// seal encrypts data as the next object's, leaving it as is without enc
+	seal := func(data []byte) ([]byte, error) {
+		if enc == nil {
+			return data, nil
+		}
+		return enc.encrypt(len(offsets)+1, data)
+	}
+
+	// AES encryption came with PDF 1.6
+	if enc != nil {
+		out.WriteString("%PDF-1.6\n%\xe2\xe3\xcf\xd3\n")
+	} else {
+		out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	}
+
+	// Objects 1-6 are fixed; each page then takes a page object and its
+	// content stream
+	const firstPage = 7
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UTF16-H /DescendantFonts [4 0 R] >>")
+	object("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 4 >> " +
+		"/FontDescriptor 5 0 R /DW 1000 /W [1 95 500] >>")
+	object("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] " +
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+	if enc == nil {
+		object(fmt.Sprintf("<< /Title <FEFF%s> /Producer (AI Fitness Planner) >>", encodeText(d.title)))
+	} else {
+		title, err := seal(append([]byte{0xFE, 0xFF}, utf16BE(d.title)...))
+		if err != nil {
+			return nil, err
+		}
+		producer, err := seal([]byte("AI Fitness Planner"))
+		if err != nil {
+			return nil, err
+		}
+		object(fmt.Sprintf("<< /Title <%X> /Producer <%X> >>", title, producer))
+	}
+
+	for i, page := range d.pages {
+		var content bytes.Buffer
+		d.drawWatermark(&content)
+		content.Write(page.Bytes())
+		number := fmt.Sprintf("%d / %d", i+1, len(d.pages))
+		d.drawLine(&content, Style{Size: 8, Gray: 0.5}, (pageWidth-textWidth(number, 8))/2, footerY, number)
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] "+
+			"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, firstPage+2*i+1))
+		stream, err := seal(content.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream))
+	}
+
+	trailer := "/Root 1 0 R /Info 6 0 R"
+	if enc != nil {
+		object(enc.dictionary())
+		trailer += fmt.Sprintf(" /Encrypt %d 0 R /ID [<%X> <%X>]", len(offsets), enc.id, enc.id)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d %s >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, trailer, xref)
+	return out.Bytes(), nil
+}
+
+// encodeText returns text as UTF-16BE hex, the encoding of the font's CMap
+func encodeText(text string) string {
+	return fmt.Sprintf("%X", utf16BE(text))
+}
+
+// utf16BE returns text as UTF-16BE bytes
+func utf16BE(text string) []byte {
+	units := utf16.Encode([]rune(text))
+	b := make([]byte, 0, 2*len(units))
+	for _, unit := range units {
+		b = append(b, byte(unit>>8), byte(unit))
+	}
+	return b
+}
+
+// runeWidth is the advance width of r in ems: the font's Latin glyphs are
+// half width and everything else is full width
+func runeWidth(r rune) float64 {
+	if r < 0x80 {
+		return 0.5
+	}
+	return 1
+}
+
+func textWidth(text string, size float64) float64 {
+	width := 0.0
+	for _, r := range text {
+		width += runeWidth(r)
+	}
+	return width * size
+}
+
+// wrap breaks text into lines no wider than width. Latin words are kept
+// whole where possible; CJK text breaks between any two characters.
+func wrap(text string, size, width float64) []string {
+	var lines []string
+	var line []rune
+	lineWidth := 0.0
+	lastSpace := -1
+	for _, r := range text {
+		if r == '\t' {
+			r = ' '
+		}
+		if unicode.IsControl(r) {
+			continue
+		}
+		w := runeWidth(r) * size
+		if lineWidth+w > width && len(line) > 0 {
+			cut := len(line)
+			if r != ' ' && r < 0x80 && lastSpace > 0 {
+				cut = lastSpace
+			}
+			lines = append(lines, strings.TrimRight(string(line[:cut]), " "))
+			rest := []rune(strings.TrimLeft(string(line[cut:]), " "))
+			line = append([]rune(nil), rest...)
+			lineWidth = 0
+			for _, c := range line {
+				lineWidth += runeWidth(c) * size
+			}
+			lastSpace = -1
+			if r == ' ' && len(line) == 0 {
+				continue
+			}
+		}
+		if r == ' ' {
+			lastSpace = len(line)
+		}
+		line = append(line, r)
+		lineWidth += w
+	}
+	return append(lines, strings.TrimRight(string(line), " "))
+}
@@ -0,0 +1,126 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrap_KeepsLatinWordsWhole(t *testing.T) {
+	// 10pt Latin characters are 5pt wide, so 50pt fits 10 of them
+	lines := wrap("barbell bench press", 10, 50)
+	assert.Equal(t, []string{"barbell", "bench", "press"}, lines)
+}
+
+func TestWrap_BreaksCJKAnywhere(t *testing.T) {
+	lines := wrap("杠铃卧推哑铃划船", 10, 30)
+	assert.Equal(t, []string{"杠铃卧", "推哑铃", "划船"}, lines)
+}
+
+func TestWrap_EmptyTextIsOneEmptyLine(t *testing.T) {
+	assert.Equal(t, []string{""}, wrap("", 10, 100))
+}
+
+func TestEncodeText_UTF16BE(t *testing.T) {
+	assert.Equal(t, "0041676F", encodeText("A杯"))
+}
+
+func TestDocument_FlowsOntoNewPages(t *testing.T) {
+	doc := New("训练计划")
+	for i := 0; i < 100; i++ {
+		doc.Write(Body, fmt.Sprintf("第%d行", i+1))
+	}
+	assert.Greater(t, doc.PageCount(), 1)
+}
+
+func TestDocument_BytesHasValidXref(t *testing.T) {
+	doc := New("训练计划")
+	doc.Write(Title, "训练计划")
+	doc.Write(Item, "1. 杠铃卧推 4×8-10 60kg")
+	data := doc.Bytes()
+
+	require.True(t, bytes.HasPrefix(data, []byte("%PDF-1.4")))
+	require.True(t, bytes.HasSuffix(data, []byte("%%EOF\n")))
+
+	// startxref points at the xref table, whose entries point at objects
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	require.NotNil(t, match)
+	xref, err := strconv.Atoi(string(match[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(data[xref:], []byte("xref\n")))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1)
+	require.Len(t, entries, 8)
+	for i, entry := range entries {
+		offset, err := strconv.Atoi(string(entry[1]))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(data[offset:]), fmt.Sprintf("%d 0 obj", i+1)), "object %d", i+1)
+	}
+}
+
+func TestDocument_WatermarksEveryPage(t *testing.T) {
+	doc := New("训练计划")
+	doc.Watermark("alice 导出于 2026-01-02 15:04")
+	for i := 0; i < 100; i++ {
+		doc.Write(Body, fmt.Sprintf("第%d行", i+1))
+	}
+	data := doc.Bytes()
+
+	pages := bytes.Count(data, []byte("/Type /Page /Parent"))
+	require.Greater(t, pages, 1)
+	assert.Equal(t, pages, bytes.Count(data, []byte("Tm\n<"+encodeText("alice 导出于 2026-01-02 15:04")+"> Tj\n"))/4)
+}
+
+func TestDocument_EncryptOpensOnlyWithPassword(t *testing.T) {
+	doc := New("训练计划")
+	doc.Write(Item, "1. 杠铃卧推 4×8-10 60kg")
+	data, err := doc.Encrypt("correct horse")
+	require.NoError(t, err)
+
+	require.True(t, bytes.HasPrefix(data, []byte("%PDF-1.6")))
+	assert.NotContains(t, string(data), encodeText("杠铃卧推"))
+	assert.NotContains(t, string(data), "AI Fitness Planner")
+
+	trailer := regexp.MustCompile(`/Encrypt (\d+) 0 R /ID \[<([0-9A-F]+)> `).FindSubmatch(data)
+	require.NotNil(t, trailer)
+	dict := regexp.MustCompile(`(?s)` + string(trailer[1]) + ` 0 obj\n(<< /Filter /Standard .*?>>)\nendobj`).FindSubmatch(data)
+	require.NotNil(t, dict)
+	entries := regexp.MustCompile(`/O <([0-9A-F]+)> /U <([0-9A-F]+)>`).FindSubmatch(dict[1])
+	require.NotNil(t, entries)
+	id, _ := hex.DecodeString(string(trailer[2]))
+	o, _ := hex.DecodeString(string(entries[1]))
+	u, _ := hex.DecodeString(string(entries[2]))
+
+	// A viewer authenticates the password by recomputing U from it
+	key := fileKey([]byte("correct horse"), o, id)
+	assert.Equal(t, u[:16], userHash(key, id)[:16])
+	wrong := fileKey([]byte("wrong horse"), o, id)
+	assert.NotEqual(t, u[:16], userHash(wrong, id)[:16])
+
+	// and decrypts the page's content stream, object 8, with it
+	stream := regexp.MustCompile(`(?s)8 0 obj\n<< /Length (\d+) >>\nstream\n`).FindSubmatchIndex(data)
+	require.NotNil(t, stream)
+	length, _ := strconv.Atoi(string(data[stream[2]:stream[3]]))
+	sealed := data[stream[1] : stream[1]+length]
+	block, err := aes.NewCipher((&encryption{key: key}).objectKey(8))
+	require.NoError(t, err)
+	plain := make([]byte, len(sealed)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, sealed[:aes.BlockSize]).CryptBlocks(plain, sealed[aes.BlockSize:])
+	assert.Contains(t, string(plain), encodeText("杠铃卧推"))
+}
+
+func TestDocument_EncryptRejectsUnportablePasswords(t *testing.T) {
+	for _, password := range []string{"", "密码密码密码密码", strings.Repeat("a", MaxPasswordLength+1)} {
+		_, err := New("训练计划").Encrypt(password)
+		assert.ErrorIs(t, err, ErrInvalidPassword, password)
+	}
+}
@@ -3,18 +3,27 @@ package redis
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
+	"time"
+
 	"github.com/ai-fitness-planner/backend/internal/config"
 	"github.com/redis/go-redis/v9"
-	"time"
 )
 
-var Rdb *redis.Client
+// rdb is published atomically: the client may connect in the background
+// while /health reads it during degraded mode
+var rdb atomic.Pointer[redis.Client]
 var ctx = context.Background()
 
+// Client returns the connected client, or nil before InitRedis succeeds
+func Client() *redis.Client {
+	return rdb.Load()
+}
+
 func InitRedis() error {
 	redisCfg := config.GlobalConfig.Database.Redis
 
-	Rdb = redis.NewClient(&redis.Options{
+	client := redis.NewClient(&redis.Options{
 		Addr:       fmt.Sprintf("%s:%d", redisCfg.Host, redisCfg.Port),
 		Password:   redisCfg.Password,
 		DB:         redisCfg.DB,
@@ -22,18 +31,20 @@ func InitRedis() error {
 		MaxRetries: redisCfg.MaxRetries,
 	})
 
-	// 测试连接
-	_, err := Rdb.Ping(ctx).Result()
+	// 测试连接，失败时不保留客户端，便于启动阶段重试
+	_, err := client.Ping(ctx).Result()
 	if err != nil {
+		client.Close()
 		return fmt.Errorf("Redis连接失败: %w", err)
 	}
 
+	rdb.Store(client)
 	return nil
 }
 
 func Close() error {
-	if Rdb != nil {
-		return Rdb.Close()
+	if client := Client(); client != nil {
+		return client.Close()
 	}
 	return nil
 }
@@ -41,12 +52,12 @@ func Close() error {
 // Session操作
 func SetSession(sessionID string, userID int64, ttl time.Duration) error {
 	key := fmt.Sprintf("session:%s", sessionID)
-	return Rdb.Set(ctx, key, userID, ttl).Err()
+	return Client().Set(ctx, key, userID, ttl).Err()
 }
 
 func GetSession(sessionID string) (int64, error) {
 	key := fmt.Sprintf("session:%s", sessionID)
-	userID, err := Rdb.Get(ctx, key).Int64()
+	userID, err := Client().Get(ctx, key).Int64()
 	if err == redis.Nil {
 		return 0, nil // Session不存在
 	}
@@ -58,18 +69,18 @@ func GetSession(sessionID string) (int64, error) {
 
 func DeleteSession(sessionID string) error {
 	key := fmt.Sprintf("session:%s", sessionID)
-	return Rdb.Del(ctx, key).Err()
+	return Client().Del(ctx, key).Err()
 }
 
 // API限流
 func CheckRateLimit(key string, limit int64, duration time.Duration) (bool, error) {
-	current, err := Rdb.Incr(ctx, key).Result()
+	current, err := Client().Incr(ctx, key).Result()
 	if err != nil {
 		return false, err
 	}
 
 	if current == 1 {
-		Rdb.Expire(ctx, key, duration)
+		Client().Expire(ctx, key, duration)
 	}
 
 	return current <= limit, nil
@@ -78,30 +89,30 @@ func CheckRateLimit(key string, limit int64, duration time.Duration) (bool, erro
 // Plan生成任务状态
 func SetPlanTask(taskID string, data interface{}, ttl time.Duration) error {
 	key := fmt.Sprintf("plan_task:%s", taskID)
-	return Rdb.Set(ctx, key, data, ttl).Err()
+	return Client().Set(ctx, key, data, ttl).Err()
 }
 
 func GetPlanTask(taskID string) (string, error) {
 	key := fmt.Sprintf("plan_task:%s", taskID)
-	return Rdb.Get(ctx, key).Result()
+	return Client().Get(ctx, key).Result()
 }
 
 func DeletePlanTask(taskID string) error {
 	key := fmt.Sprintf("plan_task:%s", taskID)
-	return Rdb.Del(ctx, key).Err()
+	return Client().Del(ctx, key).Err()
 }
 
 // 缓存操作
 func SetCache(key string, value interface{}, ttl time.Duration) error {
-	return Rdb.Set(ctx, key, value, ttl).Err()
+	return Client().Set(ctx, key, value, ttl).Err()
 }
 
 func GetCache(key string) (string, error) {
-	return Rdb.Get(ctx, key).Result()
+	return Client().Get(ctx, key).Result()
 }
 
 func DeleteCache(key string) error {
-	return Rdb.Del(ctx, key).Err()
+	return Client().Del(ctx, key).Err()
 }
 
 // AI API调用次数统计
@@ -110,24 +121,24 @@ func IncrementAPICall(userID int64, apiID int64) error {
 
 	// 分钟级别
 	minuteKey := fmt.Sprintf("api_calls:minute:%d:%d:%s", userID, apiID, now.Format("200601021504"))
-	if err := Rdb.Incr(ctx, minuteKey).Err(); err != nil {
+	if err := Client().Incr(ctx, minuteKey).Err(); err != nil {
 		return err
 	}
-	Rdb.Expire(ctx, minuteKey, time.Hour)
+	Client().Expire(ctx, minuteKey, time.Hour)
 
 	// 小时级别
 	hourKey := fmt.Sprintf("api_calls:hour:%d:%d:%s", userID, apiID, now.Format("2006010215"))
-	if err := Rdb.Incr(ctx, hourKey).Err(); err != nil {
+	if err := Client().Incr(ctx, hourKey).Err(); err != nil {
 		return err
 	}
-	Rdb.Expire(ctx, hourKey, 24*time.Hour)
+	Client().Expire(ctx, hourKey, 24*time.Hour)
 
 	// 天级别
 	dayKey := fmt.Sprintf("api_calls:day:%d:%d:%s", userID, apiID, now.Format("20060102"))
-	if err := Rdb.Incr(ctx, dayKey).Err(); err != nil {
+	if err := Client().Incr(ctx, dayKey).Err(); err != nil {
 		return err
 	}
-	Rdb.Expire(ctx, dayKey, 7*24*time.Hour)
+	Client().Expire(ctx, dayKey, 7*24*time.Hour)
 
 	return nil
 }
@@ -147,7 +158,7 @@ func GetAPICallCount(userID int64, apiID int64, period string) (int64, error) {
 		return 0, fmt.Errorf("不支持的时间段: %s", period)
 	}
 
-	count, err := Rdb.Get(ctx, key).Int64()
+	count, err := Client().Get(ctx, key).Int64()
 	if err == redis.Nil {
 		return 0, nil
 	}
@@ -0,0 +1,48 @@
+package redis
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/ai-fitness-planner/backend/internal/config"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClient_PublishedWhileRead connects in the background while other
+// goroutines read the client, as /health does in degraded mode; run with
+// -race to check the publish
+func TestClient_PublishedWhileRead(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	port, err := strconv.Atoi(mr.Port())
+	require.NoError(t, err)
+	config.GlobalConfig = &config.Config{}
+	config.GlobalConfig.Database.Redis = config.RedisConfig{Host: mr.Host(), Port: port}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if client := Client(); client != nil {
+					_ = client.Options().Addr
+				}
+			}
+		}()
+	}
+	require.NoError(t, InitRedis())
+	wg.Wait()
+	t.Cleanup(func() { _ = Close() })
+
+	require.NotNil(t, Client())
+	assert.NoError(t, SetCache("k", "v", 0))
+	v, err := GetCache("k")
+	require.NoError(t, err)
+	assert.Equal(t, "v", v)
+}
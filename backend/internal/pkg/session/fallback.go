@@ -0,0 +1,195 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// ErrSessionUnverified is returned by GetSession when the session store is
+// unreachable, the session is not cached locally and degraded auth is
+// enabled. Callers may accept the request on its validated token alone.
+var ErrSessionUnverified = errors.New("session store unavailable, session unverified")
+
+// maxCachedSessions bounds the local cache; expired entries are pruned once
+// it is reached
+const maxCachedSessions = 10000
+
+// FallbackConfig controls how sessions are served while the store is down
+type FallbackConfig struct {
+	// CacheTTL is how long a session read from the store may be served
+	// locally during an outage; 0 disables the local cache
+	CacheTTL time.Duration
+	// DegradedAuth lets requests through on JWT signature and expiry alone
+	// when a session can be neither read nor found in the local cache
+	DegradedAuth bool
+}
+
+// FallbackSessionManager wraps a SessionManager so a brief store outage does
+// not log out every user. Sessions read successfully are remembered for
+// CacheTTL and served from memory while the store fails. A logout on another
+// instance during an outage is only seen once the local entry expires, which
+// is why the cache TTL should stay short.
+type FallbackSessionManager struct {
+	store SessionManager
+	cfg   FallbackConfig
+
+	mu    sync.Mutex
+	cache map[string]cachedSession
+	// outageSince is zero while the store is healthy
+	outageSince time.Time
+	cacheHits   int64
+	unverified  int64
+}
+
+type cachedSession struct {
+	session  *model.Session
+	cachedAt time.Time
+}
+
+// NewFallbackSessionManager wraps store with a local cache and, optionally,
+// degraded JWT-only authentication
+func NewFallbackSessionManager(store SessionManager, cfg FallbackConfig) SessionManager {
+	return &FallbackSessionManager{
+		store: store,
+		cfg:   cfg,
+		cache: make(map[string]cachedSession),
+	}
+}
+
+// CreateSession creates the session in the store and caches it locally
+func (m *FallbackSessionManager) CreateSession(ctx context.Context, userID int64, sessionID string, username string, ttl time.Duration, ipAddress string, userAgent string) error {
+	if err := m.store.CreateSession(ctx, userID, sessionID, username, ttl, ipAddress, userAgent); err != nil {
+		m.storeFailed(err)
+		return err
+	}
+	m.storeRecovered()
+	return nil
+}
+
+// GetSession reads the session from the store, falling back to the local
+// cache and then to ErrSessionUnverified when the store fails
+func (m *FallbackSessionManager) GetSession(ctx context.Context, sessionID string) (*model.Session, error) {
+	session, err := m.store.GetSession(ctx, sessionID)
+	if err == nil {
+		m.storeRecovered()
+		m.mu.Lock()
+		if session != nil {
+			m.put(sessionID, session)
+		} else {
+			delete(m.cache, sessionID)
+		}
+		m.mu.Unlock()
+		return session, nil
+	}
+
+	m.storeFailed(err)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cached, ok := m.cache[sessionID]; ok {
+		if m.fresh(cached, time.Now()) {
+			m.cacheHits++
+			return cached.session, nil
+		}
+		delete(m.cache, sessionID)
+	}
+	if m.cfg.DegradedAuth {
+		m.unverified++
+		return nil, ErrSessionUnverified
+	}
+	return nil, err
+}
+
+// DeleteSession deletes the session from the store. The local entry is
+// dropped even if the store fails, so a logout always takes effect here.
+func (m *FallbackSessionManager) DeleteSession(ctx context.Context, sessionID string) error {
+	m.mu.Lock()
+	delete(m.cache, sessionID)
+	m.mu.Unlock()
+
+	if err := m.store.DeleteSession(ctx, sessionID); err != nil {
+		m.storeFailed(err)
+		return err
+	}
+	return nil
+}
+
+// DeleteAllUserSessions deletes the user's sessions from the store and the
+// local cache
+func (m *FallbackSessionManager) DeleteAllUserSessions(ctx context.Context, userID int64) error {
+	m.mu.Lock()
+	for id, cached := range m.cache {
+		if cached.session.UserID == userID {
+			delete(m.cache, id)
+		}
+	}
+	m.mu.Unlock()
+
+	if err := m.store.DeleteAllUserSessions(ctx, userID); err != nil {
+		m.storeFailed(err)
+		return err
+	}
+	return nil
+}
+
+// put caches session; callers hold m.mu
+func (m *FallbackSessionManager) put(sessionID string, session *model.Session) {
+	if m.cfg.CacheTTL <= 0 {
+		return
+	}
+	now := time.Now()
+	if len(m.cache) >= maxCachedSessions {
+		for id, cached := range m.cache {
+			if !m.fresh(cached, now) {
+				delete(m.cache, id)
+			}
+		}
+		if len(m.cache) >= maxCachedSessions {
+			return
+		}
+	}
+	m.cache[sessionID] = cachedSession{session: session, cachedAt: now}
+}
+
+// fresh reports whether a cached session may still be served
+func (m *FallbackSessionManager) fresh(cached cachedSession, now time.Time) bool {
+	return now.Sub(cached.cachedAt) < m.cfg.CacheTTL && now.Before(cached.session.ExpiresAt)
+}
+
+// storeFailed logs the start of an outage once rather than on every request
+func (m *FallbackSessionManager) storeFailed(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.outageSince.IsZero() {
+		return
+	}
+	m.outageSince = time.Now()
+	logger.Error("Session store unavailable, serving sessions from local cache",
+		zap.Error(err),
+		zap.Duration("cache_ttl", m.cfg.CacheTTL),
+		zap.Bool("degraded_auth", m.cfg.DegradedAuth),
+	)
+}
+
+// storeRecovered logs the end of an outage with how requests were served
+func (m *FallbackSessionManager) storeRecovered() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.outageSince.IsZero() {
+		return
+	}
+	logger.Warn("Session store recovered",
+		zap.Duration("outage", time.Since(m.outageSince)),
+		zap.Int64("cache_hits", m.cacheHits),
+		zap.Int64("unverified_sessions", m.unverified),
+	)
+	m.outageSince = time.Time{}
+	m.cacheHits = 0
+	m.unverified = 0
+}
@@ -0,0 +1,103 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func init() {
+	if logger.Logger == nil {
+		logger.Logger = zap.NewNop()
+	}
+}
+
+func TestFallbackSessionManager_ServesCachedSessionDuringOutage(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer client.Close()
+
+	manager := NewFallbackSessionManager(NewSessionManager(client), FallbackConfig{CacheTTL: time.Minute})
+	ctx := context.Background()
+
+	require.NoError(t, manager.CreateSession(ctx, 1, "cached", "user", time.Hour, "", ""))
+	session, err := manager.GetSession(ctx, "cached")
+	require.NoError(t, err)
+	require.NotNil(t, session)
+
+	mr.Close()
+
+	session, err = manager.GetSession(ctx, "cached")
+	assert.NoError(t, err)
+	require.NotNil(t, session)
+	assert.Equal(t, int64(1), session.UserID)
+
+	// A session never read before the outage cannot be verified
+	session, err = manager.GetSession(ctx, "unknown")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrSessionUnverified)
+	assert.Nil(t, session)
+}
+
+func TestFallbackSessionManager_DegradedAuth(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer client.Close()
+
+	manager := NewFallbackSessionManager(NewSessionManager(client), FallbackConfig{CacheTTL: time.Minute, DegradedAuth: true})
+	ctx := context.Background()
+
+	mr.Close()
+
+	session, err := manager.GetSession(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrSessionUnverified)
+	assert.Nil(t, session)
+}
+
+func TestFallbackSessionManager_LogoutDropsCachedSession(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer client.Close()
+
+	manager := NewFallbackSessionManager(NewSessionManager(client), FallbackConfig{CacheTTL: time.Minute})
+	ctx := context.Background()
+
+	require.NoError(t, manager.CreateSession(ctx, 1, "first", "user", time.Hour, "", ""))
+	require.NoError(t, manager.CreateSession(ctx, 1, "second", "user", time.Hour, "", ""))
+	_, err := manager.GetSession(ctx, "first")
+	require.NoError(t, err)
+	_, err = manager.GetSession(ctx, "second")
+	require.NoError(t, err)
+
+	require.NoError(t, manager.DeleteSession(ctx, "first"))
+	mr.Close()
+
+	_, err = manager.GetSession(ctx, "first")
+	assert.Error(t, err)
+
+	// The store is down, but the local entries are still dropped
+	assert.Error(t, manager.DeleteAllUserSessions(ctx, 1))
+	_, err = manager.GetSession(ctx, "second")
+	assert.Error(t, err)
+}
+
+func TestFallbackSessionManager_CacheTTL(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer client.Close()
+
+	manager := NewFallbackSessionManager(NewSessionManager(client), FallbackConfig{CacheTTL: 50 * time.Millisecond})
+	ctx := context.Background()
+
+	require.NoError(t, manager.CreateSession(ctx, 1, "cached", "user", time.Hour, "", ""))
+	_, err := manager.GetSession(ctx, "cached")
+	require.NoError(t, err)
+
+	mr.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	session, err := manager.GetSession(ctx, "cached")
+	assert.Error(t, err)
+	assert.Nil(t, session)
+}
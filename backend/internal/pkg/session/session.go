@@ -90,6 +90,38 @@ func (m *RedisSessionManager) GetSession(ctx context.Context, sessionID string)
 	return &session, nil
 }
 
+// ExtendSession rewrites the session with a new expiry and Redis TTL. SET XX
+// keeps a concurrent logout from being undone.
+func (m *RedisSessionManager) ExtendSession(ctx context.Context, session *model.Session, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	sessionData, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	sessionKey := fmt.Sprintf("session:%s", session.SessionID)
+	stored, err := m.client.SetXX(ctx, sessionKey, sessionData, ttl).Result()
+	if err != nil {
+		return fmt.Errorf("failed to extend session in Redis: %w", err)
+	}
+	if !stored {
+		return nil
+	}
+
+	// Only lengthen the user sessions set's TTL; other sessions may outlive
+	// this one
+	userSessionsKey := fmt.Sprintf("user_sessions:%d", session.UserID)
+	if err := m.client.ExpireGT(ctx, userSessionsKey, ttl+time.Hour).Err(); err != nil {
+		return fmt.Errorf("failed to extend TTL on user sessions set: %w", err)
+	}
+
+	return nil
+}
+
 // DeleteSession deletes a session from Redis
 func (m *RedisSessionManager) DeleteSession(ctx context.Context, sessionID string) error {
 	sessionKey := fmt.Sprintf("session:%s", sessionID)
@@ -0,0 +1,105 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// SessionExtender is implemented by managers whose sessions can be kept alive
+// past their original expiry
+type SessionExtender interface {
+	// ExtendSession stores session with a new expiry. It does nothing if the
+	// session has been deleted in the meantime.
+	ExtendSession(ctx context.Context, session *model.Session, expiresAt time.Time) error
+}
+
+// SlidingConfig controls activity-based session extension
+type SlidingConfig struct {
+	// IdleTimeout is how long a session lives after its last refresh
+	IdleTimeout time.Duration
+	// RefreshInterval throttles writes: a session is extended at most once
+	// per interval however many requests it makes
+	RefreshInterval time.Duration
+	// MaxLifetime caps how long after creation a session can be extended to;
+	// 0 means no cap
+	MaxLifetime time.Duration
+}
+
+// SlidingSessionManager wraps a SessionManager so active sessions do not
+// expire. Each read of a session last refreshed more than RefreshInterval ago
+// pushes its expiry out to IdleTimeout from now, bounded by MaxLifetime.
+type SlidingSessionManager struct {
+	SessionManager
+	extender SessionExtender
+	cfg      SlidingConfig
+}
+
+// NewSlidingSessionManager wraps store with sliding expiry. store is returned
+// unchanged if it cannot extend sessions.
+func NewSlidingSessionManager(store SessionManager, cfg SlidingConfig) SessionManager {
+	extender, ok := store.(SessionExtender)
+	if !ok || cfg.IdleTimeout <= 0 {
+		return store
+	}
+	return &SlidingSessionManager{
+		SessionManager: store,
+		extender:       extender,
+		cfg:            cfg,
+	}
+}
+
+// GetSession reads the session and extends it if it is due. A failed
+// extension is logged and the session is still returned, since it has not
+// expired yet.
+func (m *SlidingSessionManager) GetSession(ctx context.Context, sessionID string) (*model.Session, error) {
+	session, err := m.SessionManager.GetSession(ctx, sessionID)
+	if err != nil || session == nil {
+		return session, err
+	}
+
+	now := time.Now()
+	expiresAt, ok := m.nextExpiry(session, now)
+	if !ok {
+		return session, nil
+	}
+
+	extended := *session
+	extended.ExpiresAt = expiresAt
+	extended.RefreshedAt = now
+	if err := m.extender.ExtendSession(ctx, &extended, expiresAt); err != nil {
+		logger.Warn("Failed to extend session",
+			zap.String("session_id", sessionID),
+			zap.Int64("user_id", session.UserID),
+			zap.Error(err),
+		)
+		return session, nil
+	}
+	return &extended, nil
+}
+
+// nextExpiry returns the extended expiry of session, or false if it was
+// refreshed recently or cannot be extended any further
+func (m *SlidingSessionManager) nextExpiry(session *model.Session, now time.Time) (time.Time, bool) {
+	lastRefresh := session.RefreshedAt
+	if lastRefresh.IsZero() {
+		lastRefresh = session.CreatedAt
+	}
+	if now.Sub(lastRefresh) < m.cfg.RefreshInterval {
+		return time.Time{}, false
+	}
+
+	expiresAt := now.Add(m.cfg.IdleTimeout)
+	if m.cfg.MaxLifetime > 0 {
+		if limit := session.CreatedAt.Add(m.cfg.MaxLifetime); expiresAt.After(limit) {
+			expiresAt = limit
+		}
+	}
+	if !expiresAt.After(session.ExpiresAt) {
+		return time.Time{}, false
+	}
+	return expiresAt, true
+}
@@ -0,0 +1,96 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlidingSessionManager_ExtendsActiveSession(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+	defer client.Close()
+
+	manager := NewSlidingSessionManager(NewSessionManager(client), SlidingConfig{IdleTimeout: 2 * time.Hour})
+	ctx := context.Background()
+
+	require.NoError(t, manager.CreateSession(ctx, 1, "active", "user", time.Hour, "", ""))
+
+	session, err := manager.GetSession(ctx, "active")
+	require.NoError(t, err)
+	require.NotNil(t, session)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), session.ExpiresAt, 5*time.Second)
+	assert.False(t, session.RefreshedAt.IsZero())
+
+	ttl := mr.TTL("session:active")
+	assert.True(t, ttl > time.Hour && ttl <= 2*time.Hour)
+
+	stored, err := NewSessionManager(client).GetSession(ctx, "active")
+	require.NoError(t, err)
+	assert.WithinDuration(t, session.ExpiresAt, stored.ExpiresAt, time.Second)
+}
+
+func TestSlidingSessionManager_ThrottlesRefresh(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+	defer client.Close()
+
+	manager := NewSlidingSessionManager(NewSessionManager(client), SlidingConfig{
+		IdleTimeout:     2 * time.Hour,
+		RefreshInterval: 10 * time.Minute,
+	})
+	ctx := context.Background()
+
+	require.NoError(t, manager.CreateSession(ctx, 1, "fresh", "user", time.Hour, "", ""))
+
+	session, err := manager.GetSession(ctx, "fresh")
+	require.NoError(t, err)
+	require.NotNil(t, session)
+	assert.True(t, session.RefreshedAt.IsZero())
+	assert.WithinDuration(t, time.Now().Add(time.Hour), session.ExpiresAt, 5*time.Second)
+}
+
+func TestSlidingSessionManager_MaxLifetime(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+	defer client.Close()
+
+	manager := NewSlidingSessionManager(NewSessionManager(client), SlidingConfig{
+		IdleTimeout: 2 * time.Hour,
+		MaxLifetime: 90 * time.Minute,
+	})
+	ctx := context.Background()
+
+	require.NoError(t, manager.CreateSession(ctx, 1, "capped", "user", time.Hour, "", ""))
+
+	session, err := manager.GetSession(ctx, "capped")
+	require.NoError(t, err)
+	require.NotNil(t, session)
+	assert.WithinDuration(t, session.CreatedAt.Add(90*time.Minute), session.ExpiresAt, time.Second)
+
+	// Already at the cap, so nothing more is written
+	again, err := manager.GetSession(ctx, "capped")
+	require.NoError(t, err)
+	assert.Equal(t, session.RefreshedAt.Unix(), again.RefreshedAt.Unix())
+}
+
+func TestSlidingSessionManager_DeletedSessionStaysDeleted(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+	defer client.Close()
+
+	store := NewSessionManager(client)
+	ctx := context.Background()
+
+	require.NoError(t, store.CreateSession(ctx, 1, "gone", "user", time.Hour, "", ""))
+	session, err := store.GetSession(ctx, "gone")
+	require.NoError(t, err)
+	require.NoError(t, store.DeleteSession(ctx, "gone"))
+
+	// A logout racing with an extension must not bring the session back
+	require.NoError(t, store.(SessionExtender).ExtendSession(ctx, session, time.Now().Add(time.Hour)))
+	assert.False(t, mr.Exists("session:gone"))
+}
@@ -0,0 +1,128 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// ErrStatelessSession is returned by GetSession from managers that keep no
+// session state; the validated token's claims stand in for the session
+var ErrStatelessSession = errors.New("sessions are not stored in stateless mode")
+
+// TokenRevoker is implemented by managers that can revoke every token issued
+// to a user before a point in time
+type TokenRevoker interface {
+	// TokenRevoked reports whether a token issued to userID at issuedAt has
+	// been revoked by DeleteAllUserSessions
+	TokenRevoked(userID int64, issuedAt time.Time) bool
+}
+
+// StatelessSessionManager implements SessionManager without a session store,
+// for deployments that do not want server-side sessions. Tokens are trusted on signature and
+// expiry; logouts are kept on an in-memory denylist until the tokens they
+// revoke would have expired anyway. The denylist is per process: it is lost
+// on restart and not shared between instances.
+type StatelessSessionManager struct {
+	tokenTTL time.Duration
+
+	mu sync.Mutex
+	// denied maps revoked session IDs to when their tokens expire
+	denied map[string]time.Time
+	// revokedUsers maps user IDs to when all their sessions were revoked
+	revokedUsers map[int64]time.Time
+}
+
+// NewStatelessSessionManager creates a stateless session manager. tokenTTL
+// is the longest lifetime of a token that can be revoked, refresh tokens
+// included; denylist entries are dropped after it.
+func NewStatelessSessionManager(tokenTTL time.Duration) SessionManager {
+	return &StatelessSessionManager{
+		tokenTTL:     tokenTTL,
+		denied:       make(map[string]time.Time),
+		revokedUsers: make(map[int64]time.Time),
+	}
+}
+
+// CreateSession does nothing; the token itself is the session
+func (m *StatelessSessionManager) CreateSession(ctx context.Context, userID int64, sessionID string, username string, ttl time.Duration, ipAddress string, userAgent string) error {
+	return nil
+}
+
+// GetSession returns nil for a revoked session and ErrStatelessSession
+// otherwise, telling the caller to rely on the token
+func (m *StatelessSessionManager) GetSession(ctx context.Context, sessionID string) (*model.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if expiresAt, ok := m.denied[sessionID]; ok && time.Now().Before(expiresAt) {
+		return nil, nil
+	}
+	return nil, ErrStatelessSession
+}
+
+// DeleteSession adds the session to the denylist
+func (m *StatelessSessionManager) DeleteSession(ctx context.Context, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.prune(now)
+	m.denied[sessionID] = now.Add(m.tokenTTL)
+	return nil
+}
+
+// DeleteAllUserSessions revokes every token issued to the user so far
+func (m *StatelessSessionManager) DeleteAllUserSessions(ctx context.Context, userID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.prune(now)
+	m.revokedUsers[userID] = now
+	return nil
+}
+
+// TokenRevoked reports whether the user's sessions were revoked after the
+// token was issued. Token times have second precision, so a token issued in
+// the same second as the revocation counts as revoked.
+func (m *StatelessSessionManager) TokenRevoked(userID int64, issuedAt time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	revokedAt, ok := m.revokedUsers[userID]
+	return ok && !issuedAt.After(revokedAt)
+}
+
+// prune drops entries for tokens that have expired; callers hold m.mu
+func (m *StatelessSessionManager) prune(now time.Time) {
+	for id, expiresAt := range m.denied {
+		if !now.Before(expiresAt) {
+			delete(m.denied, id)
+		}
+	}
+	for userID, revokedAt := range m.revokedUsers {
+		if !now.Before(revokedAt.Add(m.tokenTTL)) {
+			delete(m.revokedUsers, userID)
+		}
+	}
+}
+
+// ClaimsSession builds the session implied by a validated token, for use when
+// GetSession returns ErrStatelessSession. It returns nil if the token has
+// been revoked.
+func ClaimsSession(m SessionManager, sessionID string, userID int64, username string, issuedAt, expiresAt time.Time) *model.Session {
+	if revoker, ok := m.(TokenRevoker); ok && revoker.TokenRevoked(userID, issuedAt) {
+		return nil
+	}
+	return &model.Session{
+		SessionID: sessionID,
+		UserID:    userID,
+		Username:  username,
+		CreatedAt: issuedAt,
+		ExpiresAt: expiresAt,
+	}
+}
@@ -0,0 +1,53 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatelessSessionManager_TrustsTokensUntilLogout(t *testing.T) {
+	manager := NewStatelessSessionManager(time.Hour)
+	ctx := context.Background()
+
+	require.NoError(t, manager.CreateSession(ctx, 1, "session", "user", time.Hour, "", ""))
+
+	session, err := manager.GetSession(ctx, "session")
+	assert.ErrorIs(t, err, ErrStatelessSession)
+	assert.Nil(t, session)
+
+	claimed := ClaimsSession(manager, "session", 1, "user", time.Now(), time.Now().Add(time.Hour))
+	require.NotNil(t, claimed)
+	assert.Equal(t, int64(1), claimed.UserID)
+
+	require.NoError(t, manager.DeleteSession(ctx, "session"))
+	session, err = manager.GetSession(ctx, "session")
+	assert.NoError(t, err)
+	assert.Nil(t, session)
+}
+
+func TestStatelessSessionManager_DeleteAllUserSessions(t *testing.T) {
+	manager := NewStatelessSessionManager(time.Hour)
+	ctx := context.Background()
+
+	issuedAt := time.Now().Add(-time.Minute)
+	require.NoError(t, manager.DeleteAllUserSessions(ctx, 1))
+
+	assert.Nil(t, ClaimsSession(manager, "old", 1, "user", issuedAt, time.Now().Add(time.Hour)))
+	assert.NotNil(t, ClaimsSession(manager, "other", 2, "other", issuedAt, time.Now().Add(time.Hour)))
+	assert.NotNil(t, ClaimsSession(manager, "new", 1, "user", time.Now().Add(time.Second), time.Now().Add(time.Hour)))
+}
+
+func TestStatelessSessionManager_DenylistExpires(t *testing.T) {
+	manager := NewStatelessSessionManager(10 * time.Millisecond)
+	ctx := context.Background()
+
+	require.NoError(t, manager.DeleteSession(ctx, "session"))
+	time.Sleep(20 * time.Millisecond)
+
+	_, err := manager.GetSession(ctx, "session")
+	assert.ErrorIs(t, err, ErrStatelessSession)
+}
@@ -0,0 +1,171 @@
+package taskqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Where a task is in the queue
+const (
+	// StateActive tasks are claimed by a worker
+	StateActive = "active"
+	// StateScheduled tasks wait for a retry to become due
+	StateScheduled = "scheduled"
+	// StatePending tasks wait for a free worker
+	StatePending = "pending"
+)
+
+// ErrTaskNotFound is returned for an ID the queue does not hold
+var ErrTaskNotFound = errors.New("task not found")
+
+// ErrTaskPending is returned by Requeue for a task already waiting to run
+var ErrTaskPending = errors.New("task is already pending")
+
+// TaskInfo is a stored task and where it is in the queue. Task.Attempt is
+// the number of times the task has been claimed.
+type TaskInfo struct {
+	Task
+	State string
+	// ClaimedAt is when the current attempt of an active task began
+	ClaimedAt time.Time
+	// Deadline is when the lease of an active task expires, or when a
+	// scheduled task is due
+	Deadline time.Time
+}
+
+// requeueScript moves an active or scheduled task to the front of pending
+// with its attempts reset. It returns 0 for an unknown task and -1 for one
+// already pending.
+var requeueScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+local moved = redis.call('ZREM', KEYS[2], ARGV[1]) + redis.call('ZREM', KEYS[3], ARGV[1])
+if moved == 0 then
+	return -1
+end
+redis.call('HDEL', KEYS[4], ARGV[1])
+redis.call('HDEL', KEYS[5], ARGV[1])
+redis.call('RPUSH', KEYS[6], ARGV[1])
+return 1
+`)
+
+// removeScript deletes a task wherever it is and returns its JSON
+var removeScript = redis.NewScript(`
+local data = redis.call('HGET', KEYS[1], ARGV[1])
+if not data then
+	return false
+end
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('ZREM', KEYS[3], ARGV[1])
+redis.call('LREM', KEYS[6], 0, ARGV[1])
+redis.call('HDEL', KEYS[4], ARGV[1])
+redis.call('HDEL', KEYS[5], ARGV[1])
+redis.call('HDEL', KEYS[1], ARGV[1])
+return data
+`)
+
+// Tasks lists every stored task: active tasks, then scheduled ones, then
+// pending ones in the order they will run. It reads the whole queue, which
+// is meant for operators looking into a backlog rather than for hot paths.
+func (q *Queue) Tasks(ctx context.Context) ([]*TaskInfo, error) {
+	pipe := q.client.Pipeline()
+	activeCmd := pipe.ZRangeWithScores(ctx, q.key(keyActive), 0, -1)
+	scheduledCmd := pipe.ZRangeWithScores(ctx, q.key(keyScheduled), 0, -1)
+	pendingCmd := pipe.LRange(ctx, q.key(keyPending), 0, -1)
+	tasksCmd := pipe.HGetAll(ctx, q.key(keyTasks))
+	attemptsCmd := pipe.HGetAll(ctx, q.key(keyAttempts))
+	claimedCmd := pipe.HGetAll(ctx, q.key(keyClaimed))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read queue: %w", err)
+	}
+
+	data := tasksCmd.Val()
+	attempts := attemptsCmd.Val()
+	claimed := claimedCmd.Val()
+	infos := make([]*TaskInfo, 0, len(data))
+	add := func(id, state string, deadline float64) {
+		raw, ok := data[id]
+		if !ok {
+			// Acknowledged while its ID was still listed
+			return
+		}
+		info := &TaskInfo{State: state}
+		if err := json.Unmarshal([]byte(raw), &info.Task); err != nil {
+			info.Task = Task{ID: id}
+		}
+		info.Attempt, _ = strconv.Atoi(attempts[id])
+		info.MaxAttempts = q.cfg.MaxAttempts
+		if deadline > 0 {
+			info.Deadline = time.UnixMilli(int64(deadline))
+		}
+		if state == StateActive {
+			if ms, err := strconv.ParseInt(claimed[id], 10, 64); err == nil {
+				info.ClaimedAt = time.UnixMilli(ms)
+			}
+		}
+		infos = append(infos, info)
+	}
+
+	for _, z := range activeCmd.Val() {
+		add(z.Member.(string), StateActive, z.Score)
+	}
+	for _, z := range scheduledCmd.Val() {
+		add(z.Member.(string), StateScheduled, z.Score)
+	}
+	// Workers pop pending tasks from the tail of the list
+	pending := pendingCmd.Val()
+	for i := len(pending) - 1; i >= 0; i-- {
+		add(pending[i], StatePending, 0)
+	}
+	return infos, nil
+}
+
+// Requeue moves an active or scheduled task to the front of the pending
+// tasks with its attempts reset, for a task whose worker hangs or whose
+// retry should not wait. A worker still running the task is not stopped,
+// so the task may run twice.
+func (q *Queue) Requeue(ctx context.Context, id string) error {
+	res, err := requeueScript.Run(ctx, q.client,
+		[]string{q.key(keyTasks), q.key(keyActive), q.key(keyScheduled), q.key(keyAttempts), q.key(keyClaimed), q.key(keyPending)},
+		id,
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to requeue task: %w", err)
+	}
+	switch res {
+	case 0:
+		return ErrTaskNotFound
+	case -1:
+		return ErrTaskPending
+	}
+	return nil
+}
+
+// Remove deletes a task wherever it is in the queue and returns it, so the
+// caller can record why it was given up. A worker still running the task
+// is not stopped.
+func (q *Queue) Remove(ctx context.Context, id string) (*Task, error) {
+	data, err := removeScript.Run(ctx, q.client,
+		[]string{q.key(keyTasks), q.key(keyActive), q.key(keyScheduled), q.key(keyAttempts), q.key(keyClaimed), q.key(keyPending)},
+		id,
+	).Text()
+	if err == redis.Nil {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove task: %w", err)
+	}
+
+	var task Task
+	if err := json.Unmarshal([]byte(data), &task); err != nil {
+		return &Task{ID: id}, nil
+	}
+	return &task, nil
+}
@@ -0,0 +1,91 @@
+package taskqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupBacklog queues three tasks without workers and claims the first, so
+// task-1 is active and task-2 and task-3 are pending
+func setupBacklog(t *testing.T) *Queue {
+	t.Helper()
+	q, _ := setupQueue(t, Config{MaxAttempts: 3, Lease: time.Minute})
+	q.Handle("generate", func(ctx context.Context, task *Task) error { return nil })
+
+	ctx := context.Background()
+	for _, id := range []string{"task-1", "task-2", "task-3"} {
+		require.NoError(t, q.Enqueue(ctx, "generate", id, map[string]string{"id": id}))
+	}
+	task, err := q.claim(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "task-1", task.ID)
+	return q
+}
+
+func TestTasks_ReportsStateInRunOrder(t *testing.T) {
+	q := setupBacklog(t)
+
+	before := time.Now()
+	tasks, err := q.Tasks(context.Background())
+	require.NoError(t, err)
+	require.Len(t, tasks, 3)
+
+	active := tasks[0]
+	assert.Equal(t, "task-1", active.ID)
+	assert.Equal(t, StateActive, active.State)
+	assert.Equal(t, 1, active.Attempt)
+	assert.Equal(t, 3, active.MaxAttempts)
+	assert.WithinDuration(t, before, active.ClaimedAt, 5*time.Second)
+	assert.True(t, active.Deadline.After(before))
+	assert.JSONEq(t, `{"id":"task-1"}`, string(active.Payload))
+
+	assert.Equal(t, []string{"task-2", "task-3"}, []string{tasks[1].ID, tasks[2].ID})
+	for _, task := range tasks[1:] {
+		assert.Equal(t, StatePending, task.State)
+		assert.Equal(t, 0, task.Attempt)
+		assert.True(t, task.ClaimedAt.IsZero())
+	}
+}
+
+func TestRequeue_MovesActiveTaskToFront(t *testing.T) {
+	q := setupBacklog(t)
+	ctx := context.Background()
+
+	require.NoError(t, q.Requeue(ctx, "task-1"))
+
+	// The requeued task runs next, as a first attempt
+	task, err := q.claim(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "task-1", task.ID)
+	assert.Equal(t, 1, task.Attempt)
+}
+
+func TestRequeue_RejectsPendingAndUnknownTasks(t *testing.T) {
+	q := setupBacklog(t)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, q.Requeue(ctx, "task-2"), ErrTaskPending)
+	assert.ErrorIs(t, q.Requeue(ctx, "missing"), ErrTaskNotFound)
+}
+
+func TestRemove_DeletesTaskWhereverItIs(t *testing.T) {
+	q := setupBacklog(t)
+	ctx := context.Background()
+
+	removed, err := q.Remove(ctx, "task-1")
+	require.NoError(t, err)
+	assert.Equal(t, "generate", removed.Type)
+	_, err = q.Remove(ctx, "task-2")
+	require.NoError(t, err)
+	_, err = q.Remove(ctx, "task-2")
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+
+	tasks, err := q.Tasks(ctx)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "task-3", tasks[0].ID)
+}
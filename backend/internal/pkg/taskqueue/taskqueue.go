@@ -0,0 +1,500 @@
+// Package taskqueue provides a durable background task queue on Redis. Tasks
+// are stored before they are acknowledged and a worker holds each one under a
+// lease, so a task whose worker crashes or is shut down is handed to another
+// worker: processing is at least once, and handlers must tolerate a task
+// running again after a partial attempt. Failed tasks are retried with
+// exponential backoff up to a configured number of attempts.
+package taskqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrSkipRetry marks a handler error that would fail the same way again.
+// Wrap it to end a task on its current attempt.
+var ErrSkipRetry = errors.New("skip retry")
+
+// ErrUnknownType is returned by Enqueue for a task type without a handler
+var ErrUnknownType = errors.New("unknown task type")
+
+// Task is a unit of work stored in the queue
+type Task struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+	// LastError is the error of the previous attempt, if any
+	LastError  string    `json:"last_error,omitempty"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+
+	// Attempt counts from 1 and includes attempts lost to a crashed worker
+	Attempt     int `json:"-"`
+	MaxAttempts int `json:"-"`
+}
+
+// FinalAttempt reports whether a failure of this attempt ends the task
+func (t *Task) FinalAttempt() bool {
+	return t.Attempt >= t.MaxAttempts
+}
+
+// Decode unmarshals the task payload into v
+func (t *Task) Decode(v interface{}) error {
+	return json.Unmarshal(t.Payload, v)
+}
+
+// Handler processes a task. A nil error acknowledges it; any other error
+// schedules a retry unless it wraps ErrSkipRetry or the attempt is final.
+type Handler func(ctx context.Context, task *Task) error
+
+// Config tunes a Queue
+type Config struct {
+	// Namespace prefixes every Redis key of the queue
+	Namespace string
+	// Workers is the number of tasks processed concurrently per process
+	Workers int
+	// MaxAttempts caps how often a task is run, including the first time
+	MaxAttempts int
+	// RetryDelay is the wait before the first retry, doubling per attempt
+	// up to MaxRetryDelay
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	// Lease is how long a worker may go without renewing its claim on a
+	// task before the task is given to another worker
+	Lease time.Duration
+	// PollInterval is how often idle workers look for tasks and how often
+	// due retries and expired leases are moved back to the queue
+	PollInterval time.Duration
+	// OnError, if set, is told about failed attempts and queue errors.
+	// task is nil for errors that are not about a single task.
+	OnError func(task *Task, err error)
+}
+
+// Queue stores tasks in Redis and runs them on a pool of workers
+type Queue struct {
+	client   *redis.Client
+	cfg      Config
+	handlers map[string]Handler
+
+	mu      sync.Mutex
+	started bool
+	// stop ends claiming; interrupt cancels the tasks still running
+	stop      context.CancelFunc
+	interrupt context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// Redis keys, relative to the namespace: tasks maps IDs to task JSON,
+// attempts to the number of times each was claimed and claimed to when the
+// current attempt began; pending lists IDs ready to run, active holds
+// claimed IDs by lease deadline and scheduled holds retries by due time.
+const (
+	keyTasks     = ":tasks"
+	keyAttempts  = ":attempts"
+	keyClaimed   = ":claimed"
+	keyPending   = ":pending"
+	keyActive    = ":active"
+	keyScheduled = ":scheduled"
+)
+
+// promoteBatch caps how many due tasks one sweep moves back to pending
+const promoteBatch = 100
+
+// claimScript moves the oldest pending task to the active set and returns
+// its ID, attempt count and JSON
+var claimScript = redis.NewScript(`
+local id = redis.call('RPOP', KEYS[1])
+if not id then
+	return false
+end
+redis.call('ZADD', KEYS[2], ARGV[1], id)
+local attempt = redis.call('HINCRBY', KEYS[3], id, 1)
+redis.call('HSET', KEYS[5], id, ARGV[2])
+local data = redis.call('HGET', KEYS[4], id)
+return {id, attempt, data}
+`)
+
+// promoteScript moves retries that are due and tasks whose lease expired
+// back to pending
+var promoteScript = redis.NewScript(`
+local moved = 0
+for i = 1, 2 do
+	local ids = redis.call('ZRANGEBYSCORE', KEYS[i], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+	for _, id in ipairs(ids) do
+		redis.call('ZREM', KEYS[i], id)
+		redis.call('LPUSH', KEYS[3], id)
+	end
+	moved = moved + #ids
+end
+return moved
+`)
+
+// New creates a queue on client. Handlers must be registered with Handle
+// before Start.
+func New(client *redis.Client, cfg Config) *Queue {
+	if cfg.Namespace == "" {
+		cfg.Namespace = "taskqueue"
+	}
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = time.Second
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = time.Minute
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+
+	return &Queue{
+		client:   client,
+		cfg:      cfg,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Handle registers the handler for a task type
+func (q *Queue) Handle(taskType string, h Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[taskType] = h
+}
+
+// Enqueue stores a task with the given ID and JSON-encoded payload. The
+// task is durable once Enqueue returns; an ID that is already queued is
+// replaced.
+func (q *Queue) Enqueue(ctx context.Context, taskType, id string, payload interface{}) error {
+	q.mu.Lock()
+	_, ok := q.handlers[taskType]
+	q.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownType, taskType)
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode task payload: %w", err)
+	}
+	data, err := json.Marshal(&Task{
+		ID:         id,
+		Type:       taskType,
+		Payload:    raw,
+		EnqueuedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode task: %w", err)
+	}
+
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, q.key(keyTasks), id, data)
+		pipe.HDel(ctx, q.key(keyAttempts), id)
+		pipe.HDel(ctx, q.key(keyClaimed), id)
+		pipe.LPush(ctx, q.key(keyPending), id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+	return nil
+}
+
+// Start launches the workers and the sweeper that requeues due retries and
+// abandoned tasks. Calling Start again has no effect.
+func (q *Queue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.started {
+		return
+	}
+	q.started = true
+
+	claimCtx, stop := context.WithCancel(context.Background())
+	runCtx, interrupt := context.WithCancel(context.Background())
+	q.stop = stop
+	q.interrupt = interrupt
+
+	q.wg.Add(q.cfg.Workers + 1)
+	for i := 0; i < q.cfg.Workers; i++ {
+		go q.work(claimCtx, runCtx)
+	}
+	go q.sweep(claimCtx)
+}
+
+// Shutdown stops claiming tasks and waits for running ones until ctx is
+// done. Tasks still running then are interrupted and requeued without
+// counting the attempt. A handler that ignores cancellation keeps its task
+// until the lease expires.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	stop, interrupt := q.stop, q.interrupt
+	q.mu.Unlock()
+	if stop == nil {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	stop()
+	select {
+	case <-done:
+		interrupt()
+		return nil
+	case <-ctx.Done():
+	}
+
+	interrupt()
+	select {
+	case <-done:
+	case <-time.After(q.cfg.PollInterval):
+	}
+	return ctx.Err()
+}
+
+// work claims tasks until claimCtx is cancelled and runs them under runCtx
+func (q *Queue) work(claimCtx, runCtx context.Context) {
+	defer q.wg.Done()
+
+	for {
+		task, err := q.claim(claimCtx)
+		if err != nil && claimCtx.Err() == nil {
+			q.reportError(nil, fmt.Errorf("failed to claim task: %w", err))
+		}
+		if task == nil {
+			select {
+			case <-claimCtx.Done():
+				return
+			case <-time.After(q.cfg.PollInterval):
+			}
+			continue
+		}
+		q.run(runCtx, task)
+	}
+}
+
+// claim takes the next pending task, or returns nil when none is ready
+func (q *Queue) claim(ctx context.Context) (*Task, error) {
+	if ctx.Err() != nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	res, err := claimScript.Run(ctx, q.client,
+		[]string{q.key(keyPending), q.key(keyActive), q.key(keyAttempts), q.key(keyTasks), q.key(keyClaimed)},
+		now.Add(q.cfg.Lease).UnixMilli(), now.UnixMilli(),
+	).Slice()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, _ := res[0].(string)
+	attempt, _ := res[1].(int64)
+	data, _ := res[2].(string)
+	if data == "" {
+		// The task was acknowledged while its ID was still listed
+		q.remove(ctx, id)
+		return nil, nil
+	}
+
+	var task Task
+	if err := json.Unmarshal([]byte(data), &task); err != nil {
+		q.remove(ctx, id)
+		return nil, fmt.Errorf("dropped undecodable task %s: %w", id, err)
+	}
+	task.Attempt = int(attempt)
+	task.MaxAttempts = q.cfg.MaxAttempts
+	return &task, nil
+}
+
+// run executes a claimed task and records its outcome
+func (q *Queue) run(ctx context.Context, task *Task) {
+	// Outcomes are stored even after shutdown has begun
+	store := context.WithoutCancel(ctx)
+
+	if task.Attempt > task.MaxAttempts {
+		// Every attempt was lost to a crashed or stopped worker
+		q.reportError(task, fmt.Errorf("task abandoned after %d attempts", task.MaxAttempts))
+		q.remove(store, task.ID)
+		return
+	}
+
+	q.mu.Lock()
+	handler, ok := q.handlers[task.Type]
+	q.mu.Unlock()
+	if !ok {
+		q.reportError(task, fmt.Errorf("%w: %s", ErrUnknownType, task.Type))
+		q.remove(store, task.ID)
+		return
+	}
+
+	taskCtx, cancel := context.WithCancel(ctx)
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		q.renewLease(taskCtx, task.ID)
+	}()
+	err := runHandler(taskCtx, handler, task)
+	cancel()
+	<-renewed
+
+	switch {
+	case err == nil:
+		q.remove(store, task.ID)
+	case ctx.Err() != nil:
+		// Interrupted by shutdown; the attempt does not count
+		q.requeue(store, task)
+	case errors.Is(err, ErrSkipRetry) || task.FinalAttempt():
+		q.reportError(task, err)
+		q.remove(store, task.ID)
+	default:
+		q.reportError(task, err)
+		q.retry(store, task, err)
+	}
+}
+
+// runHandler calls h and converts a panic into an error so a bad task
+// cannot take down its worker
+func runHandler(ctx context.Context, h Handler, task *Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+	}()
+	return h(ctx, task)
+}
+
+// renewLease extends the claim on a task until ctx is done
+func (q *Queue) renewLease(ctx context.Context, id string) {
+	ticker := time.NewTicker(q.cfg.Lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deadline := float64(time.Now().Add(q.cfg.Lease).UnixMilli())
+			err := q.client.ZAddXX(ctx, q.key(keyActive), redis.Z{Score: deadline, Member: id}).Err()
+			if err != nil && ctx.Err() == nil {
+				q.reportError(nil, fmt.Errorf("failed to renew lease of task %s: %w", id, err))
+			}
+		}
+	}
+}
+
+// sweep periodically requeues due retries and abandoned tasks
+func (q *Queue) sweep(ctx context.Context) {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := q.promote(ctx); err != nil && ctx.Err() == nil {
+				q.reportError(nil, fmt.Errorf("failed to requeue due tasks: %w", err))
+			}
+		}
+	}
+}
+
+// promote moves due retries and tasks with an expired lease to pending
+func (q *Queue) promote(ctx context.Context) error {
+	return promoteScript.Run(ctx, q.client,
+		[]string{q.key(keyActive), q.key(keyScheduled), q.key(keyPending)},
+		time.Now().UnixMilli(), promoteBatch,
+	).Err()
+}
+
+// retry schedules the next attempt of a failed task
+func (q *Queue) retry(ctx context.Context, task *Task, cause error) {
+	task.LastError = cause.Error()
+	data, err := json.Marshal(task)
+	if err != nil {
+		q.reportError(task, fmt.Errorf("failed to encode task for retry: %w", err))
+		q.remove(ctx, task.ID)
+		return
+	}
+
+	due := time.Now().Add(q.retryDelay(task.Attempt)).UnixMilli()
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, q.key(keyTasks), task.ID, data)
+		pipe.ZRem(ctx, q.key(keyActive), task.ID)
+		pipe.HDel(ctx, q.key(keyClaimed), task.ID)
+		pipe.ZAdd(ctx, q.key(keyScheduled), redis.Z{Score: float64(due), Member: task.ID})
+		return nil
+	})
+	if err != nil {
+		// The lease will expire and the task run again
+		q.reportError(task, fmt.Errorf("failed to schedule retry: %w", err))
+	}
+}
+
+// requeue returns an interrupted task to pending without counting the attempt
+func (q *Queue) requeue(ctx context.Context, task *Task) {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, q.key(keyActive), task.ID)
+		pipe.HIncrBy(ctx, q.key(keyAttempts), task.ID, -1)
+		pipe.HDel(ctx, q.key(keyClaimed), task.ID)
+		pipe.RPush(ctx, q.key(keyPending), task.ID)
+		return nil
+	})
+	if err != nil {
+		q.reportError(task, fmt.Errorf("failed to requeue interrupted task: %w", err))
+	}
+}
+
+// remove deletes a finished task
+func (q *Queue) remove(ctx context.Context, id string) {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, q.key(keyActive), id)
+		pipe.HDel(ctx, q.key(keyTasks), id)
+		pipe.HDel(ctx, q.key(keyAttempts), id)
+		pipe.HDel(ctx, q.key(keyClaimed), id)
+		return nil
+	})
+	if err != nil {
+		q.reportError(nil, fmt.Errorf("failed to remove task %s: %w", id, err))
+	}
+}
+
+// retryDelay returns the wait before the attempt after the given one
+func (q *Queue) retryDelay(attempt int) time.Duration {
+	delay := q.cfg.RetryDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if q.cfg.MaxRetryDelay > 0 && delay >= q.cfg.MaxRetryDelay {
+			return q.cfg.MaxRetryDelay
+		}
+	}
+	return delay
+}
+
+func (q *Queue) reportError(task *Task, err error) {
+	if q.cfg.OnError != nil {
+		q.cfg.OnError(task, err)
+	}
+}
+
+func (q *Queue) key(suffix string) string {
+	return q.cfg.Namespace + suffix
+}
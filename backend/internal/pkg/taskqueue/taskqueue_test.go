@@ -0,0 +1,234 @@
+package taskqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupQueue(t *testing.T, cfg Config) (*Queue, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		client.Close()
+		mr.Close()
+	})
+
+	cfg.Namespace = "test"
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = 10 * time.Millisecond
+	}
+	return New(client, cfg), mr
+}
+
+// attemptLog records the attempts a handler saw
+type attemptLog struct {
+	mu       sync.Mutex
+	attempts []int
+	errors   []string
+}
+
+func (l *attemptLog) add(task *Task) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.attempts = append(l.attempts, task.Attempt)
+	l.errors = append(l.errors, task.LastError)
+}
+
+func (l *attemptLog) snapshot() ([]int, []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]int(nil), l.attempts...), append([]string(nil), l.errors...)
+}
+
+// waitForDrain waits until no task is stored under the queue's namespace
+func waitForDrain(t *testing.T, mr *miniredis.Miniredis) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if !mr.Exists("test:tasks") {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("queue did not drain")
+}
+
+func TestEnqueue_ProcessesPayload(t *testing.T) {
+	q, mr := setupQueue(t, Config{Workers: 2, MaxAttempts: 3})
+
+	type payload struct {
+		UserID int64 `json:"user_id"`
+	}
+	got := make(chan payload, 1)
+	q.Handle("generate", func(ctx context.Context, task *Task) error {
+		var p payload
+		require.NoError(t, task.Decode(&p))
+		got <- p
+		return nil
+	})
+	q.Start()
+	defer q.Shutdown(context.Background())
+
+	require.NoError(t, q.Enqueue(context.Background(), "generate", "task-1", payload{UserID: 7}))
+
+	select {
+	case p := <-got:
+		assert.Equal(t, int64(7), p.UserID)
+	case <-time.After(2 * time.Second):
+		t.Fatal("task was not processed")
+	}
+	waitForDrain(t, mr)
+}
+
+func TestEnqueue_UnknownType(t *testing.T) {
+	q, _ := setupQueue(t, Config{})
+
+	err := q.Enqueue(context.Background(), "missing", "task-1", nil)
+	assert.ErrorIs(t, err, ErrUnknownType)
+}
+
+func TestRun_RetriesUntilSuccess(t *testing.T) {
+	q, mr := setupQueue(t, Config{MaxAttempts: 3, RetryDelay: time.Millisecond})
+
+	var log attemptLog
+	q.Handle("generate", func(ctx context.Context, task *Task) error {
+		log.add(task)
+		if task.Attempt < 3 {
+			return fmt.Errorf("attempt %d failed", task.Attempt)
+		}
+		return nil
+	})
+	q.Start()
+	defer q.Shutdown(context.Background())
+
+	require.NoError(t, q.Enqueue(context.Background(), "generate", "task-1", nil))
+	waitForDrain(t, mr)
+
+	attempts, lastErrors := log.snapshot()
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+	assert.Equal(t, []string{"", "attempt 1 failed", "attempt 2 failed"}, lastErrors)
+}
+
+func TestRun_StopsAfterFinalAttemptOrSkipRetry(t *testing.T) {
+	var reported []error
+	var mu sync.Mutex
+	q, mr := setupQueue(t, Config{
+		MaxAttempts: 2,
+		RetryDelay:  time.Millisecond,
+		OnError: func(task *Task, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			reported = append(reported, err)
+		},
+	})
+
+	var failing, skipped attemptLog
+	q.Handle("failing", func(ctx context.Context, task *Task) error {
+		failing.add(task)
+		return errors.New("boom")
+	})
+	q.Handle("invalid", func(ctx context.Context, task *Task) error {
+		skipped.add(task)
+		return fmt.Errorf("bad input: %w", ErrSkipRetry)
+	})
+	q.Start()
+	defer q.Shutdown(context.Background())
+
+	require.NoError(t, q.Enqueue(context.Background(), "failing", "task-1", nil))
+	require.NoError(t, q.Enqueue(context.Background(), "invalid", "task-2", nil))
+	waitForDrain(t, mr)
+
+	attempts, _ := failing.snapshot()
+	assert.Equal(t, []int{1, 2}, attempts)
+	attempts, _ = skipped.snapshot()
+	assert.Equal(t, []int{1}, attempts)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, reported, 3)
+}
+
+func TestRun_RecoversPanic(t *testing.T) {
+	q, mr := setupQueue(t, Config{MaxAttempts: 1})
+
+	q.Handle("generate", func(ctx context.Context, task *Task) error {
+		panic("bad task")
+	})
+	q.Start()
+	defer q.Shutdown(context.Background())
+
+	require.NoError(t, q.Enqueue(context.Background(), "generate", "task-1", nil))
+	waitForDrain(t, mr)
+}
+
+func TestSweep_ReclaimsAbandonedTask(t *testing.T) {
+	q, mr := setupQueue(t, Config{MaxAttempts: 3, Lease: 50 * time.Millisecond})
+
+	var log attemptLog
+	q.Handle("generate", func(ctx context.Context, task *Task) error {
+		log.add(task)
+		return nil
+	})
+	require.NoError(t, q.Enqueue(context.Background(), "generate", "task-1", nil))
+
+	// A worker that crashed after claiming leaves the task in the active
+	// set with a lease that is never renewed
+	task, err := q.claim(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, 1, task.Attempt)
+
+	q.Start()
+	defer q.Shutdown(context.Background())
+	waitForDrain(t, mr)
+
+	attempts, _ := log.snapshot()
+	assert.Equal(t, []int{2}, attempts)
+}
+
+func TestShutdown_RequeuesInterruptedTask(t *testing.T) {
+	q, mr := setupQueue(t, Config{MaxAttempts: 1})
+
+	started := make(chan struct{})
+	q.Handle("generate", func(ctx context.Context, task *Task) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	q.Start()
+	require.NoError(t, q.Enqueue(context.Background(), "generate", "task-1", nil))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.Shutdown(ctx), context.DeadlineExceeded)
+
+	// The task is pending again and its interrupted attempt is not counted
+	pending, err := mr.List("test:pending")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"task-1"}, pending)
+	attempts, err := mr.HKeys("test:attempts")
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, "0", mr.HGet("test:attempts", "task-1"))
+}
+
+func TestRetryDelay_DoublesUpToMax(t *testing.T) {
+	q := New(nil, Config{RetryDelay: time.Second, MaxRetryDelay: 5 * time.Second})
+
+	assert.Equal(t, time.Second, q.retryDelay(1))
+	assert.Equal(t, 2*time.Second, q.retryDelay(2))
+	assert.Equal(t, 4*time.Second, q.retryDelay(3))
+	assert.Equal(t, 5*time.Second, q.retryDelay(4))
+}
@@ -0,0 +1,67 @@
+// Package warning collects non-fatal warnings raised while handling a
+// request, such as a value that was clamped or estimated, so they can be
+// returned alongside a successful response.
+package warning
+
+import (
+	"context"
+	"sync"
+)
+
+// Warning is a non-fatal problem with a request; Code is stable for clients
+// to match on
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// collector holds the warnings of one request. Services may add warnings
+// from goroutines, so it is guarded by a mutex.
+type collector struct {
+	mu       sync.Mutex
+	warnings []Warning
+}
+
+type contextKey struct{}
+
+// WithCollector returns a context that collects the warnings added to it and
+// to contexts derived from it. A context that already collects is returned
+// as is.
+func WithCollector(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(contextKey{}).(*collector); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, &collector{})
+}
+
+// Add records a warning on ctx. It is dropped when ctx does not collect
+// warnings, such as in background tasks, and a warning with the same code
+// and message is only recorded once.
+func Add(ctx context.Context, code, message string) {
+	c, ok := ctx.Value(contextKey{}).(*collector)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, w := range c.warnings {
+		if w.Code == code && w.Message == message {
+			return
+		}
+	}
+	c.warnings = append(c.warnings, Warning{Code: code, Message: message})
+}
+
+// List returns the warnings recorded on ctx in the order they were added
+func List(ctx context.Context) []Warning {
+	c, ok := ctx.Value(contextKey{}).(*collector)
+	if !ok {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.warnings) == 0 {
+		return nil
+	}
+	return append([]Warning(nil), c.warnings...)
+}
@@ -0,0 +1,48 @@
+package warning
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdd_CollectsInOrder(t *testing.T) {
+	ctx := WithCollector(context.Background())
+
+	Add(ctx, "clamped_calories", "热量已调整")
+	Add(ctx, "estimated_record", "记录为估算值")
+
+	assert.Equal(t, []Warning{
+		{Code: "clamped_calories", Message: "热量已调整"},
+		{Code: "estimated_record", Message: "记录为估算值"},
+	}, List(ctx))
+}
+
+func TestAdd_SharedWithDerivedContexts(t *testing.T) {
+	ctx := WithCollector(context.Background())
+	derived, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	Add(derived, "stale_assessment", "评估已过期")
+
+	assert.Len(t, List(ctx), 1)
+	assert.Equal(t, ctx, WithCollector(ctx))
+}
+
+func TestAdd_DropsDuplicates(t *testing.T) {
+	ctx := WithCollector(context.Background())
+
+	Add(ctx, "clamped_calories", "热量已调整")
+	Add(ctx, "clamped_calories", "热量已调整")
+
+	assert.Len(t, List(ctx), 1)
+}
+
+func TestAdd_WithoutCollector(t *testing.T) {
+	ctx := context.Background()
+
+	Add(ctx, "clamped_calories", "热量已调整")
+
+	assert.Nil(t, List(ctx))
+}
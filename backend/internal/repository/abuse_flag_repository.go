@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// AbuseFlagRepository defines the interface for AI abuse flag operations
+type AbuseFlagRepository interface {
+	Create(ctx context.Context, flag *model.AIAbuseFlag) error
+	GetByID(ctx context.Context, id int64) (*model.AIAbuseFlag, error)
+	GetActiveSuspension(ctx context.Context, aiAPIID int64, now time.Time) (*model.AIAbuseFlag, error)
+	List(ctx context.Context, status string, limit, offset int) ([]*model.AIAbuseFlag, int64, error)
+	Update(ctx context.Context, flag *model.AIAbuseFlag) error
+}
+
+// abuseFlagRepository implements AbuseFlagRepository interface
+type abuseFlagRepository struct {
+	db *gorm.DB
+}
+
+// NewAbuseFlagRepository creates a new instance of AbuseFlagRepository
+func NewAbuseFlagRepository(db *gorm.DB) AbuseFlagRepository {
+	return &abuseFlagRepository{db: db}
+}
+
+// Create creates a new abuse flag
+func (r *abuseFlagRepository) Create(ctx context.Context, flag *model.AIAbuseFlag) error {
+	if err := r.db.WithContext(ctx).Create(flag).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetByID retrieves an abuse flag by its ID
+func (r *abuseFlagRepository) GetByID(ctx context.Context, id int64) (*model.AIAbuseFlag, error) {
+	var flag model.AIAbuseFlag
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&flag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// GetActiveSuspension retrieves a pending flag that still suspends the API config
+func (r *abuseFlagRepository) GetActiveSuspension(ctx context.Context, aiAPIID int64, now time.Time) (*model.AIAbuseFlag, error) {
+	var flag model.AIAbuseFlag
+	err := r.db.WithContext(ctx).
+		Where("ai_api_id = ? AND status = ? AND suspended_until > ?", aiAPIID, model.AbuseFlagStatusPending, now).
+		Order("suspended_until DESC").
+		First(&flag).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// List retrieves abuse flags, newest first; empty status means all
+func (r *abuseFlagRepository) List(ctx context.Context, status string, limit, offset int) ([]*model.AIAbuseFlag, int64, error) {
+	var flags []*model.AIAbuseFlag
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.AIAbuseFlag{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&flags).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return flags, total, nil
+}
+
+// Update updates an existing abuse flag
+func (r *abuseFlagRepository) Update(ctx context.Context, flag *model.AIAbuseFlag) error {
+	if err := r.db.WithContext(ctx).Save(flag).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrMergeReverted is returned when reverting a merge that was already
+// reverted
+var ErrMergeReverted = errors.New("account merge already reverted")
+
+// errMergeDryRun rolls back the transaction of a dry-run merge
+var errMergeDryRun = errors.New("account merge dry run")
+
+// Conflict rules a merge applies to the rows it moves
+const (
+	// MergeRuleClientIDTaken gives a record a new client ID when the target
+	// already has a record with its ID
+	MergeRuleClientIDTaken = "client_id_taken"
+	// MergeRuleIdempotencyKeyTaken clears a record's idempotency key when the
+	// target already used it
+	MergeRuleIdempotencyKeyTaken = "idempotency_key_taken"
+	// MergeRuleTargetHasActivePlan deactivates the source's current plans
+	// when the target has a current plan of the same kind
+	MergeRuleTargetHasActivePlan = "target_has_active_plan"
+	// MergeRuleTargetHasActiveGoal cancels the source's active goals when the
+	// target has an active goal
+	MergeRuleTargetHasActiveGoal = "target_has_active_goal"
+)
+
+// mergeTable is a table whose rows a merge moves to the target account.
+// Rows of touched tables get a new updated_at so sync clients pick them up.
+type mergeTable struct {
+	name  string
+	touch bool
+}
+
+// mergeTables are the plans, records, body data and goals a merge moves.
+// Other per-user settings, such as strength profiles and equipment, stay
+// with the source account.
+var mergeTables = []mergeTable{
+	{name: "macrocycles"},
+	{name: "training_plans"},
+	{name: "nutrition_plans"},
+	{name: "training_records", touch: true},
+	{name: "nutrition_records", touch: true},
+	{name: "plan_day_completions"},
+	{name: "user_body_data"},
+	{name: "fitness_goals"},
+}
+
+// mergeConflictColumns are the columns conflict rules change, per table
+var mergeConflictColumns = map[string][]string{
+	"training_records":  {"client_id", "idempotency_key"},
+	"nutrition_records": {"client_id"},
+	"training_plans":    {"status"},
+	"nutrition_plans":   {"status"},
+	"fitness_goals":     {"status"},
+}
+
+// AccountMergeRepository defines the interface for account merge operations
+type AccountMergeRepository interface {
+	// Merge moves the source account's plans, records, body data and goals
+	// to the target under the conflict rules, disables the source and saves
+	// merge with what it changed. With dryRun set nothing is saved; merge
+	// still receives the changes the merge would make.
+	Merge(ctx context.Context, merge *model.AccountMerge, dryRun bool) error
+	// Revert moves the rows a merge moved that are still with the target
+	// back to the source, undoes its conflict changes and restores the
+	// source account's status
+	Revert(ctx context.Context, merge *model.AccountMerge, adminID int64, at time.Time) error
+	GetByID(ctx context.Context, id int64) (*model.AccountMerge, error)
+	// List returns merges involving userID, as source or target, newest
+	// first; all merges when userID is 0
+	List(ctx context.Context, userID int64, limit, offset int) ([]*model.AccountMerge, int64, error)
+}
+
+// accountMergeRepository implements AccountMergeRepository interface
+type accountMergeRepository struct {
+	db *gorm.DB
+}
+
+// NewAccountMergeRepository creates a new instance of AccountMergeRepository
+func NewAccountMergeRepository(db *gorm.DB) AccountMergeRepository {
+	return &accountMergeRepository{db: db}
+}
+
+// Merge runs the merge in one transaction. Both users are locked so that
+// concurrent merges of the same accounts run one after the other.
+func (r *accountMergeRepository) Merge(ctx context.Context, merge *model.AccountMerge, dryRun bool) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var users []model.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []int64{merge.SourceUserID, merge.TargetUserID}).
+			Order("id").Find(&users).Error; err != nil {
+			return err
+		}
+		changes := model.AccountMergeChanges{Moved: make(map[string][]int64)}
+		for _, u := range users {
+			if u.ID == merge.SourceUserID {
+				changes.SourceStatus = u.Status
+			}
+		}
+
+		src, dst := merge.SourceUserID, merge.TargetUserID
+		newClientID := func() interface{} { return uuid.New().String() }
+		noKey := func() interface{} { return nil }
+		steps := []func() error{
+			func() error {
+				return resolveTakenValues(tx, &changes, "training_records", "client_id", src, dst, newClientID, MergeRuleClientIDTaken)
+			},
+			func() error {
+				return resolveTakenValues(tx, &changes, "nutrition_records", "client_id", src, dst, newClientID, MergeRuleClientIDTaken)
+			},
+			func() error {
+				return resolveTakenValues(tx, &changes, "training_records", "idempotency_key", src, dst, noKey, MergeRuleIdempotencyKeyTaken)
+			},
+			func() error {
+				return resolveActiveRows(tx, &changes, "training_plans", []string{"active", "paused"}, "inactive", src, dst, MergeRuleTargetHasActivePlan)
+			},
+			func() error {
+				return resolveActiveRows(tx, &changes, "nutrition_plans", []string{"active"}, "inactive", src, dst, MergeRuleTargetHasActivePlan)
+			},
+			func() error {
+				return resolveActiveRows(tx, &changes, "fitness_goals", []string{string(model.GoalStatusActive)}, string(model.GoalStatusCancelled), src, dst, MergeRuleTargetHasActiveGoal)
+			},
+		}
+		for _, step := range steps {
+			if err := step(); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		for _, table := range mergeTables {
+			var ids []int64
+			if err := tx.Table(table.name).Where("user_id = ?", src).Order("id").Pluck("id", &ids).Error; err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				continue
+			}
+			if err := moveRows(tx, table, ids, src, dst, now); err != nil {
+				return err
+			}
+			changes.Moved[table.name] = ids
+		}
+
+		if err := tx.Model(&model.User{}).Where("id = ?", src).Update("status", 0).Error; err != nil {
+			return err
+		}
+
+		merge.Changes = changes
+		if dryRun {
+			return errMergeDryRun
+		}
+		return tx.Create(merge).Error
+	})
+	if err == errMergeDryRun {
+		return nil
+	}
+	return err
+}
+
+// resolveTakenValues changes column of the source's rows whose value the
+// target already has, to the value replacement returns
+func resolveTakenValues(tx *gorm.DB, changes *model.AccountMergeChanges, table, column string, src, dst int64, replacement func() interface{}, rule string) error {
+	var rows []struct {
+		ID    int64
+		Value string
+	}
+	if err := tx.Table(fmt.Sprintf("%s AS s", table)).
+		Select(fmt.Sprintf("s.id AS id, s.%s AS value", column)).
+		Joins(fmt.Sprintf("JOIN %s AS t ON t.%s = s.%s AND t.user_id = ?", table, column, column), dst).
+		Where("s.user_id = ?", src).
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		value := replacement()
+		if err := tx.Table(table).Where("id = ?", row.ID).Update(column, value).Error; err != nil {
+			return err
+		}
+		changes.Conflicts = append(changes.Conflicts, model.AccountMergeConflict{
+			Table:  table,
+			ID:     row.ID,
+			Column: column,
+			Old:    row.Value,
+			New:    value,
+			Rule:   rule,
+		})
+	}
+	return nil
+}
+
+// resolveActiveRows sets the source's rows in one of statuses to status to
+// when the target has a row in one of them, so the target keeps one
+// current plan or goal
+func resolveActiveRows(tx *gorm.DB, changes *model.AccountMergeChanges, table string, statuses []string, to string, src, dst int64, rule string) error {
+	var taken int64
+	if err := tx.Table(table).Where("user_id = ? AND status IN ?", dst, statuses).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken == 0 {
+		return nil
+	}
+
+	var rows []struct {
+		ID     int64
+		Status string
+	}
+	if err := tx.Table(table).Select("id, status").
+		Where("user_id = ? AND status IN ?", src, statuses).
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if err := tx.Table(table).Where("id = ?", row.ID).Update("status", to).Error; err != nil {
+			return err
+		}
+		changes.Conflicts = append(changes.Conflicts, model.AccountMergeConflict{
+			Table:  table,
+			ID:     row.ID,
+			Column: "status",
+			Old:    row.Status,
+			New:    to,
+			Rule:   rule,
+		})
+	}
+	return nil
+}
+
+// moveRows reassigns the rows of table with ids that belong to from to to
+func moveRows(tx *gorm.DB, table mergeTable, ids []int64, from, to int64, now time.Time) error {
+	updates := map[string]interface{}{"user_id": to}
+	if table.touch {
+		updates["updated_at"] = now
+	}
+	return tx.Table(table.name).Where("id IN ? AND user_id = ?", ids, from).Updates(updates).Error
+}
+
+// Revert undoes a merge in one transaction. Rows are moved back before
+// their conflict changes are undone, so restored values such as client IDs
+// do not clash with the target's rows.
+func (r *accountMergeRepository) Revert(ctx context.Context, merge *model.AccountMerge, adminID int64, at time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.AccountMerge{}).
+			Where("id = ? AND status = ?", merge.ID, model.AccountMergeStatusMerged).
+			Updates(map[string]interface{}{
+				"status":      model.AccountMergeStatusReverted,
+				"reverted_by": adminID,
+				"reverted_at": at,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrMergeReverted
+		}
+
+		for _, table := range mergeTables {
+			ids := merge.Changes.Moved[table.name]
+			if len(ids) == 0 {
+				continue
+			}
+			if err := moveRows(tx, table, ids, merge.TargetUserID, merge.SourceUserID, at); err != nil {
+				return err
+			}
+		}
+
+		for i := len(merge.Changes.Conflicts) - 1; i >= 0; i-- {
+			c := merge.Changes.Conflicts[i]
+			if !isMergeConflictColumn(c.Table, c.Column) {
+				return fmt.Errorf("account merge %d changed unknown column %s.%s", merge.ID, c.Table, c.Column)
+			}
+			if err := tx.Table(c.Table).Where("id = ?", c.ID).Update(c.Column, c.Old).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&model.User{}).Where("id = ?", merge.SourceUserID).
+			Update("status", merge.Changes.SourceStatus).Error; err != nil {
+			return err
+		}
+
+		merge.Status = model.AccountMergeStatusReverted
+		merge.RevertedBy = &adminID
+		merge.RevertedAt = &at
+		return nil
+	})
+}
+
+// isMergeConflictColumn reports whether a merge's conflict rules change
+// column of table. Conflicts are read back from JSON, so they are checked
+// before being used in a query.
+func isMergeConflictColumn(table, column string) bool {
+	for _, c := range mergeConflictColumns[table] {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// GetByID retrieves an account merge by ID
+func (r *accountMergeRepository) GetByID(ctx context.Context, id int64) (*model.AccountMerge, error) {
+	var merge model.AccountMerge
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&merge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &merge, nil
+}
+
+// List retrieves a page of account merges
+func (r *accountMergeRepository) List(ctx context.Context, userID int64, limit, offset int) ([]*model.AccountMerge, int64, error) {
+	var merges []*model.AccountMerge
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.AccountMerge{})
+	if userID > 0 {
+		query = query.Where("source_user_id = ? OR target_user_id = ?", userID, userID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&merges).Error; err != nil {
+		return nil, 0, err
+	}
+	return merges, total, nil
+}
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The merge tests run against sqlmock, so they pin the statements a merge
+// sends to MySQL and their order. Account 9 is merged into account 7.
+const (
+	mergeSourceID int64 = 9
+	mergeTargetID int64 = 7
+)
+
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	return db, mock
+}
+
+// expectMergeRules expects both users to be locked and the conflict rules
+// to run. The target already has the client IDs of takenClientIDs, which
+// map the source's training record IDs to their client ID.
+func expectMergeRules(mock sqlmock.Sqlmock, takenClientIDs map[int64]string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE id IN (?,?) ORDER BY id FOR UPDATE")).
+		WithArgs(mergeSourceID, mergeTargetID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(mergeTargetID, 1).AddRow(mergeSourceID, 1))
+
+	taken := sqlmock.NewRows([]string{"id", "value"})
+	for id, clientID := range takenClientIDs {
+		taken.AddRow(id, clientID)
+	}
+	expectTakenValues(mock, "training_records", "client_id", taken)
+	for id := range takenClientIDs {
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `training_records` SET `client_id`=? WHERE id = ?")).
+			WithArgs(sqlmock.AnyArg(), id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	expectTakenValues(mock, "nutrition_records", "client_id", sqlmock.NewRows([]string{"id", "value"}))
+	expectTakenValues(mock, "training_records", "idempotency_key", sqlmock.NewRows([]string{"id", "value"}))
+
+	for _, table := range []string{"training_plans", "nutrition_plans", "fitness_goals"} {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `" + table + "` WHERE user_id = ? AND status IN")).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	}
+}
+
+func expectTakenValues(mock sqlmock.Sqlmock, table, column string, rows *sqlmock.Rows) {
+	mock.ExpectQuery(regexp.QuoteMeta("JOIN "+table+" AS t ON t."+column+" = s."+column+" AND t.user_id = ?")).
+		WithArgs(mergeTargetID, mergeSourceID).
+		WillReturnRows(rows)
+}
+
+// expectSourceRows expects the lookup of the source's rows in table
+func expectSourceRows(mock sqlmock.Sqlmock, table string, ids ...int64) {
+	rows := sqlmock.NewRows([]string{"id"})
+	for _, id := range ids {
+		rows.AddRow(id)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id` FROM `" + table + "` WHERE user_id = ? ORDER BY id")).
+		WithArgs(mergeSourceID).
+		WillReturnRows(rows)
+}
+
+// expectMoveRows expects the rows of a table that is not touched on move
+// to be reassigned from one account to the other
+func expectMoveRows(mock sqlmock.Sqlmock, table string, id, from, to int64) *sqlmock.ExpectedExec {
+	return mock.ExpectExec(regexp.QuoteMeta("UPDATE `"+table+"` SET `user_id`=? WHERE id IN (?) AND user_id = ?")).
+		WithArgs(to, id, from)
+}
+
+// expectMoveTouchedRows is expectMoveRows for tables whose moved rows get
+// a new updated_at
+func expectMoveTouchedRows(mock sqlmock.Sqlmock, table string, id, from, to int64) *sqlmock.ExpectedExec {
+	return mock.ExpectExec(regexp.QuoteMeta("UPDATE `"+table+"` SET `updated_at`=?,`user_id`=? WHERE id IN (?) AND user_id = ?")).
+		WithArgs(sqlmock.AnyArg(), to, id, from)
+}
+
+func TestAccountMergeRepository_MergeResolvesTakenClientID(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewAccountMergeRepository(db)
+
+	expectMergeRules(mock, map[int64]string{101: "c-1"})
+	expectSourceRows(mock, "macrocycles")
+	expectSourceRows(mock, "training_plans")
+	expectSourceRows(mock, "nutrition_plans")
+	expectSourceRows(mock, "training_records", 101)
+	expectMoveTouchedRows(mock, "training_records", 101, mergeSourceID, mergeTargetID).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSourceRows(mock, "nutrition_records")
+	expectSourceRows(mock, "plan_day_completions")
+	expectSourceRows(mock, "user_body_data", 55)
+	expectMoveRows(mock, "user_body_data", 55, mergeSourceID, mergeTargetID).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSourceRows(mock, "fitness_goals")
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET `status`=?,`updated_at`=? WHERE id = ?")).
+		WithArgs(0, sqlmock.AnyArg(), mergeSourceID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `account_merges`")).WillReturnResult(sqlmock.NewResult(6, 1))
+	mock.ExpectCommit()
+
+	merge := &model.AccountMerge{AdminID: 1, SourceUserID: mergeSourceID, TargetUserID: mergeTargetID, Reason: "重复注册", Status: model.AccountMergeStatusMerged}
+	require.NoError(t, repo.Merge(context.Background(), merge, false))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, int64(6), merge.ID)
+	assert.Equal(t, int8(1), merge.Changes.SourceStatus)
+	assert.Equal(t, map[string][]int64{"training_records": {101}, "user_body_data": {55}}, merge.Changes.Moved)
+	require.Len(t, merge.Changes.Conflicts, 1)
+	conflict := merge.Changes.Conflicts[0]
+	assert.Equal(t, "training_records", conflict.Table)
+	assert.Equal(t, int64(101), conflict.ID)
+	assert.Equal(t, "client_id", conflict.Column)
+	assert.Equal(t, "c-1", conflict.Old)
+	assert.Equal(t, MergeRuleClientIDTaken, conflict.Rule)
+	newClientID, ok := conflict.New.(string)
+	require.True(t, ok)
+	_, err := uuid.Parse(newClientID)
+	assert.NoError(t, err)
+}
+
+func TestAccountMergeRepository_MergeRollsBackOnDuplicateKey(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewAccountMergeRepository(db)
+
+	// A record the target created between the conflict check and the move
+	// still clashes with the unique index; nothing of the merge is kept
+	duplicate := &mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry '7-c-2' for key 'uk_user_client_id'"}
+	expectMergeRules(mock, nil)
+	expectSourceRows(mock, "macrocycles")
+	expectSourceRows(mock, "training_plans")
+	expectSourceRows(mock, "nutrition_plans")
+	expectSourceRows(mock, "training_records", 102)
+	expectMoveTouchedRows(mock, "training_records", 102, mergeSourceID, mergeTargetID).WillReturnError(duplicate)
+	mock.ExpectRollback()
+
+	merge := &model.AccountMerge{AdminID: 1, SourceUserID: mergeSourceID, TargetUserID: mergeTargetID, Reason: "重复注册", Status: model.AccountMergeStatusMerged}
+	err := repo.Merge(context.Background(), merge, false)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	var mysqlErr *mysqldriver.MySQLError
+	require.ErrorAs(t, err, &mysqlErr)
+	assert.Equal(t, uint16(1062), mysqlErr.Number)
+	assert.Zero(t, merge.ID)
+}
+
+func TestAccountMergeRepository_MergeDryRun(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewAccountMergeRepository(db)
+
+	expectMergeRules(mock, nil)
+	for _, table := range mergeTables {
+		if table.name == "training_records" {
+			expectSourceRows(mock, table.name, 101)
+			expectMoveTouchedRows(mock, table.name, 101, mergeSourceID, mergeTargetID).WillReturnResult(sqlmock.NewResult(0, 1))
+			continue
+		}
+		expectSourceRows(mock, table.name)
+	}
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET `status`=?,`updated_at`=? WHERE id = ?")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	merge := &model.AccountMerge{AdminID: 1, SourceUserID: mergeSourceID, TargetUserID: mergeTargetID, Reason: "重复注册", Status: model.AccountMergeStatusMerged}
+	require.NoError(t, repo.Merge(context.Background(), merge, true))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Zero(t, merge.ID)
+	assert.Equal(t, map[string][]int64{"training_records": {101}}, merge.Changes.Moved)
+}
+
+// mergedAccounts is a merge that moved a training record and a body data
+// row and gave the training record a new client ID
+func mergedAccounts() *model.AccountMerge {
+	return &model.AccountMerge{
+		ID: 6, AdminID: 1, SourceUserID: mergeSourceID, TargetUserID: mergeTargetID, Reason: "重复注册",
+		Status: model.AccountMergeStatusMerged,
+		Changes: model.AccountMergeChanges{
+			Moved: map[string][]int64{"training_records": {101}, "user_body_data": {55}},
+			Conflicts: []model.AccountMergeConflict{
+				{Table: "training_records", ID: 101, Column: "client_id", Old: "c-1", New: "5b1f0c7e-6a0e-4f3c-9d2a-0d8e7f6a5b4c", Rule: MergeRuleClientIDTaken},
+			},
+			SourceStatus: 1,
+		},
+	}
+}
+
+func expectRevertClaim(mock sqlmock.Sqlmock, at time.Time, rowsAffected int64) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `account_merges` SET `reverted_at`=?,`reverted_by`=?,`status`=? WHERE id = ? AND status = ?")).
+		WithArgs(at, int64(2), model.AccountMergeStatusReverted, int64(6), model.AccountMergeStatusMerged).
+		WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+}
+
+func TestAccountMergeRepository_Revert(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewAccountMergeRepository(db)
+	at := time.Date(2024, 3, 9, 10, 0, 0, 0, time.UTC)
+
+	// Rows move back before the old client ID is restored, so it cannot
+	// clash with the target's record that has it
+	expectRevertClaim(mock, at, 1)
+	expectMoveTouchedRows(mock, "training_records", 101, mergeTargetID, mergeSourceID).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectMoveRows(mock, "user_body_data", 55, mergeTargetID, mergeSourceID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `training_records` SET `client_id`=? WHERE id = ?")).
+		WithArgs("c-1", int64(101)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET `status`=?,`updated_at`=? WHERE id = ?")).
+		WithArgs(1, sqlmock.AnyArg(), mergeSourceID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	merge := mergedAccounts()
+	require.NoError(t, repo.Revert(context.Background(), merge, 2, at))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, model.AccountMergeStatusReverted, merge.Status)
+	require.NotNil(t, merge.RevertedBy)
+	assert.Equal(t, int64(2), *merge.RevertedBy)
+	require.NotNil(t, merge.RevertedAt)
+	assert.Equal(t, at, *merge.RevertedAt)
+}
+
+func TestAccountMergeRepository_RevertAlreadyReverted(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewAccountMergeRepository(db)
+	at := time.Date(2024, 3, 9, 10, 0, 0, 0, time.UTC)
+
+	// Another admin reverted the merge first; no rows move twice
+	expectRevertClaim(mock, at, 0)
+	mock.ExpectRollback()
+
+	merge := mergedAccounts()
+	err := repo.Revert(context.Background(), merge, 2, at)
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.ErrorIs(t, err, ErrMergeReverted)
+	assert.Equal(t, model.AccountMergeStatusMerged, merge.Status)
+}
+
+func TestAccountMergeRepository_RevertRejectsUnknownColumn(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewAccountMergeRepository(db)
+	at := time.Date(2024, 3, 9, 10, 0, 0, 0, time.UTC)
+
+	expectRevertClaim(mock, at, 1)
+	expectMoveTouchedRows(mock, "training_records", 101, mergeTargetID, mergeSourceID).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectMoveRows(mock, "user_body_data", 55, mergeTargetID, mergeSourceID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	merge := mergedAccounts()
+	merge.Changes.Conflicts[0].Column = "user_id"
+	err := repo.Revert(context.Background(), merge, 2, at)
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.EqualError(t, err, "account merge 6 changed unknown column training_records.user_id")
+}
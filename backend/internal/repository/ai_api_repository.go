@@ -17,6 +17,13 @@ type AIAPIRepository interface {
 	Delete(ctx context.Context, id int64) error
 	GetDefaultByUser(ctx context.Context, userID int64) (*model.AIAPI, error)
 	SetDefault(ctx context.Context, userID int64, apiID int64) error
+	// ListFallbackChain returns the user's active APIs in fallback order
+	ListFallbackChain(ctx context.Context, userID int64) ([]*model.AIAPI, error)
+	// SetFallbackOrder places apiIDs in the fallback chain in the given order
+	// and removes the user's other APIs from it
+	SetFallbackOrder(ctx context.Context, userID int64, apiIDs []int64) error
+	// CountActiveByProvider counts active configurations across all users
+	CountActiveByProvider(ctx context.Context) (map[string]int64, error)
 }
 
 // aiAPIRepository implements AIAPIRepository interface
@@ -112,3 +119,59 @@ func (r *aiAPIRepository) SetDefault(ctx context.Context, userID int64, apiID in
 		return nil
 	})
 }
+
+// ListFallbackChain retrieves the user's active AI APIs that take part in the
+// fallback chain, in priority order
+func (r *aiAPIRepository) ListFallbackChain(ctx context.Context, userID int64) ([]*model.AIAPI, error) {
+	var apis []*model.AIAPI
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND status = ? AND fallback_priority IS NOT NULL", userID, 1).
+		Order("fallback_priority ASC, id ASC").
+		Find(&apis).Error; err != nil {
+		return nil, err
+	}
+	return apis, nil
+}
+
+// SetFallbackOrder replaces the user's fallback chain in a transaction
+func (r *aiAPIRepository) SetFallbackOrder(ctx context.Context, userID int64, apiIDs []int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.AIAPI{}).
+			Where("user_id = ?", userID).
+			Update("fallback_priority", nil).Error; err != nil {
+			return err
+		}
+
+		for i, apiID := range apiIDs {
+			if err := tx.Model(&model.AIAPI{}).
+				Where("id = ? AND user_id = ?", apiID, userID).
+				Update("fallback_priority", i+1).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// CountActiveByProvider counts active AI API configurations per provider
+func (r *aiAPIRepository) CountActiveByProvider(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Provider string
+		Count    int64
+	}
+	if err := r.db.WithContext(ctx).
+		Model(&model.AIAPI{}).
+		Select("provider, COUNT(*) AS count").
+		Where("status = ?", 1).
+		Group("provider").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Provider] = row.Count
+	}
+	return counts, nil
+}
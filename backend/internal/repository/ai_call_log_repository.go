@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// AICallLogFilter narrows a user's AI call history; zero values match all
+type AICallLogFilter struct {
+	AIAPIID int64
+	Status  string
+}
+
+// AICallLogRepository defines the interface for AI call log operations
+type AICallLogRepository interface {
+	Create(ctx context.Context, log *model.AICallLog) error
+	// ListByUser returns a page of the user's calls, newest first, and the total
+	ListByUser(ctx context.Context, userID int64, filter AICallLogFilter, limit, offset int) ([]*model.AICallLog, int64, error)
+	// CountByUser and DeleteBatch serve the user's deletion of their AI
+	// history
+	CountByUser(ctx context.Context, userID int64, since, before *time.Time) (int64, error)
+	DeleteBatch(ctx context.Context, userID int64, since, before *time.Time, limit int) (int64, error)
+}
+
+// aiCallLogRepository implements AICallLogRepository interface
+type aiCallLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAICallLogRepository creates a new instance of AICallLogRepository
+func NewAICallLogRepository(db *gorm.DB) AICallLogRepository {
+	return &aiCallLogRepository{db: db}
+}
+
+// Create records one AI call
+func (r *aiCallLogRepository) Create(ctx context.Context, log *model.AICallLog) error {
+	return r.db.WithContext(ctx).Create(log).Error
+}
+
+// ListByUser retrieves the user's AI calls
+func (r *aiCallLogRepository) ListByUser(ctx context.Context, userID int64, filter AICallLogFilter, limit, offset int) ([]*model.AICallLog, int64, error) {
+	var logs []*model.AICallLog
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.AICallLog{}).Where("user_id = ?", userID)
+	if filter.AIAPIID != 0 {
+		query = query.Where("ai_api_id = ?", filter.AIAPIID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// CountByUser counts the user's AI calls created in [since, before); nil
+// bounds are open
+func (r *aiCallLogRepository) CountByUser(ctx context.Context, userID int64, since, before *time.Time) (int64, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&model.AICallLog{}).Where("user_id = ?", userID)
+
+	if since != nil {
+		query = query.Where("created_at >= ?", *since)
+	}
+
+	if before != nil {
+		query = query.Where("created_at < ?", *before)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// DeleteBatch permanently deletes up to limit of the user's AI calls
+// created in [since, before), oldest first, and returns how many it deleted
+func (r *aiCallLogRepository) DeleteBatch(ctx context.Context, userID int64, since, before *time.Time, limit int) (int64, error) {
+	var ids []int64
+	query := r.db.WithContext(ctx).Model(&model.AICallLog{}).Where("user_id = ?", userID)
+
+	if since != nil {
+		query = query.Where("created_at >= ?", *since)
+	}
+
+	if before != nil {
+		query = query.Where("created_at < ?", *before)
+	}
+
+	if err := query.Order("id ASC").Limit(limit).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result := r.db.WithContext(ctx).Where("id IN ?", ids).Delete(&model.AICallLog{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// AIResponseArchiveRepository defines the interface for archived AI responses
+type AIResponseArchiveRepository interface {
+	Create(ctx context.Context, archive *model.AIResponseArchive) error
+	GetByID(ctx context.Context, id int64) (*model.AIResponseArchive, error)
+	// DeleteExpired deletes up to limit archives expired before now
+	DeleteExpired(ctx context.Context, now time.Time, limit int) (int64, error)
+	// DeleteOptedOut deletes up to limit archives of users who have since
+	// opted out of archiving
+	DeleteOptedOut(ctx context.Context, limit int) (int64, error)
+	// CountByUser and DeleteBatch serve the user's deletion of their AI
+	// history
+	CountByUser(ctx context.Context, userID int64, since, before *time.Time) (int64, error)
+	DeleteBatch(ctx context.Context, userID int64, since, before *time.Time, limit int) (int64, error)
+}
+
+// aiResponseArchiveRepository implements AIResponseArchiveRepository interface
+type aiResponseArchiveRepository struct {
+	db *gorm.DB
+}
+
+// NewAIResponseArchiveRepository creates a new instance of AIResponseArchiveRepository
+func NewAIResponseArchiveRepository(db *gorm.DB) AIResponseArchiveRepository {
+	return &aiResponseArchiveRepository{db: db}
+}
+
+// Create inserts a new archived response
+func (r *aiResponseArchiveRepository) Create(ctx context.Context, archive *model.AIResponseArchive) error {
+	return r.db.WithContext(ctx).Create(archive).Error
+}
+
+// GetByID retrieves an archived response by ID
+func (r *aiResponseArchiveRepository) GetByID(ctx context.Context, id int64) (*model.AIResponseArchive, error) {
+	var archive model.AIResponseArchive
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&archive).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &archive, nil
+}
+
+// DeleteExpired deletes the oldest expired archives first
+func (r *aiResponseArchiveRepository) DeleteExpired(ctx context.Context, now time.Time, limit int) (int64, error) {
+	var ids []int64
+	if err := r.db.WithContext(ctx).Model(&model.AIResponseArchive{}).
+		Where("expires_at <= ?", now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	return r.deleteIDs(ctx, ids)
+}
+
+// DeleteOptedOut finds the archives to delete through the users' opt-out flag
+func (r *aiResponseArchiveRepository) DeleteOptedOut(ctx context.Context, limit int) (int64, error) {
+	var ids []int64
+	if err := r.db.WithContext(ctx).Model(&model.AIResponseArchive{}).
+		Where("user_id IN (?)", r.db.Model(&model.User{}).Select("id").Where("response_archive_opt_out = ?", true)).
+		Order("id ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	return r.deleteIDs(ctx, ids)
+}
+
+// CountByUser counts the user's archives created in [since, before); nil
+// bounds are open
+func (r *aiResponseArchiveRepository) CountByUser(ctx context.Context, userID int64, since, before *time.Time) (int64, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&model.AIResponseArchive{}).Where("user_id = ?", userID)
+
+	if since != nil {
+		query = query.Where("created_at >= ?", *since)
+	}
+
+	if before != nil {
+		query = query.Where("created_at < ?", *before)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// DeleteBatch permanently deletes up to limit of the user's archives created
+// in [since, before), oldest first, and returns how many it deleted
+func (r *aiResponseArchiveRepository) DeleteBatch(ctx context.Context, userID int64, since, before *time.Time, limit int) (int64, error) {
+	var ids []int64
+	query := r.db.WithContext(ctx).Model(&model.AIResponseArchive{}).Where("user_id = ?", userID)
+
+	if since != nil {
+		query = query.Where("created_at >= ?", *since)
+	}
+
+	if before != nil {
+		query = query.Where("created_at < ?", *before)
+	}
+
+	if err := query.Order("id ASC").Limit(limit).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	return r.deleteIDs(ctx, ids)
+}
+
+// deleteIDs deletes the archives with the given IDs
+func (r *aiResponseArchiveRepository) deleteIDs(ctx context.Context, ids []int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result := r.db.WithContext(ctx).Where("id IN ?", ids).Delete(&model.AIResponseArchive{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
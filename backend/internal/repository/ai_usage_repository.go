@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// AIUsageSummary aggregates the token usage of one model
+type AIUsageSummary struct {
+	Model            string
+	Calls            int64
+	PromptTokens     int64
+	CompletionTokens int64
+	TotalTokens      int64
+}
+
+// AIUsageRepository defines the interface for AI token usage operations
+type AIUsageRepository interface {
+	Create(ctx context.Context, usage *model.AIUsage) error
+	// SummarizeByAPI aggregates an API config's usage since the given time, per model
+	SummarizeByAPI(ctx context.Context, aiAPIID int64, since time.Time) ([]*AIUsageSummary, error)
+}
+
+// aiUsageRepository implements AIUsageRepository interface
+type aiUsageRepository struct {
+	db *gorm.DB
+}
+
+// NewAIUsageRepository creates a new instance of AIUsageRepository
+func NewAIUsageRepository(db *gorm.DB) AIUsageRepository {
+	return &aiUsageRepository{db: db}
+}
+
+// Create records the usage of one AI call
+func (r *aiUsageRepository) Create(ctx context.Context, usage *model.AIUsage) error {
+	if err := r.db.WithContext(ctx).Create(usage).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// SummarizeByAPI aggregates usage per model, most used first
+func (r *aiUsageRepository) SummarizeByAPI(ctx context.Context, aiAPIID int64, since time.Time) ([]*AIUsageSummary, error) {
+	var summaries []*AIUsageSummary
+	if err := r.db.WithContext(ctx).
+		Model(&model.AIUsage{}).
+		Select("COALESCE(model, '') as model, COUNT(*) as calls, "+
+			"COALESCE(SUM(prompt_tokens), 0) as prompt_tokens, "+
+			"COALESCE(SUM(completion_tokens), 0) as completion_tokens, "+
+			"COALESCE(SUM(total_tokens), 0) as total_tokens").
+		Where("ai_api_id = ? AND created_at >= ?", aiAPIID, since).
+		Group("model").
+		Order("total_tokens DESC").
+		Scan(&summaries).Error; err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AnalyticsWorkout is a training record selected for analytics export,
+// reduced to the fields the export generalizes
+type AnalyticsWorkout struct {
+	UserID          int64
+	WorkoutDate     time.Time
+	WorkoutType     string
+	DurationMinutes *int
+	Rating          *int
+}
+
+// AnalyticsMeasurement is a body data entry selected for analytics export
+type AnalyticsMeasurement struct {
+	UserID            int64
+	Age               int
+	Gender            string
+	Height            float64
+	Weight            float64
+	BodyFatPercentage *float64
+	MeasurementDate   time.Time
+}
+
+// AnalyticsRepository defines the interface for analytics export data access.
+// Every listing leaves out users who opted out of analytics and disabled
+// accounts.
+type AnalyticsRepository interface {
+	// ListWorkouts returns training records dated in [start, end)
+	ListWorkouts(ctx context.Context, start, end time.Time) ([]*AnalyticsWorkout, error)
+	// ListMeasurements returns body data measured in [start, end)
+	ListMeasurements(ctx context.Context, start, end time.Time) ([]*AnalyticsMeasurement, error)
+	// LatestMeasurements returns each user's most recent body data measured
+	// before end, keyed by user ID
+	LatestMeasurements(ctx context.Context, userIDs []int64, end time.Time) (map[int64]*AnalyticsMeasurement, error)
+	// GetLastExport returns the export with the latest window
+	GetLastExport(ctx context.Context) (*model.AnalyticsExport, error)
+	// ClaimExport inserts the export, reporting false when another run
+	// already claimed its window
+	ClaimExport(ctx context.Context, export *model.AnalyticsExport) (bool, error)
+	// CompleteExport records the outcome of a claimed export
+	CompleteExport(ctx context.Context, export *model.AnalyticsExport) error
+	// ReleaseExport deletes a claimed export whose events were not written,
+	// so that the next run exports its window again
+	ReleaseExport(ctx context.Context, id int64) error
+}
+
+// analyticsRepository implements AnalyticsRepository interface
+type analyticsRepository struct {
+	db *gorm.DB
+}
+
+// NewAnalyticsRepository creates a new instance of AnalyticsRepository
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+// ListWorkouts retrieves the training records of users included in analytics
+func (r *analyticsRepository) ListWorkouts(ctx context.Context, start, end time.Time) ([]*AnalyticsWorkout, error) {
+	var workouts []*AnalyticsWorkout
+	if err := r.db.WithContext(ctx).
+		Model(&model.TrainingRecord{}).
+		Select("training_records.user_id, training_records.workout_date, training_records.workout_type, training_records.duration_minutes, training_records.rating").
+		Joins("JOIN users ON users.id = training_records.user_id").
+		Where("users.analytics_opt_out = ? AND users.status = ?", false, 1).
+		Where("training_records.workout_date >= ? AND training_records.workout_date < ?", start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Order("training_records.workout_date ASC, training_records.id ASC").
+		Scan(&workouts).Error; err != nil {
+		return nil, err
+	}
+	return workouts, nil
+}
+
+// ListMeasurements retrieves the body data of users included in analytics
+func (r *analyticsRepository) ListMeasurements(ctx context.Context, start, end time.Time) ([]*AnalyticsMeasurement, error) {
+	var measurements []*AnalyticsMeasurement
+	if err := r.db.WithContext(ctx).
+		Model(&model.UserBodyData{}).
+		Select("user_body_data.user_id, user_body_data.age, user_body_data.gender, user_body_data.height, user_body_data.weight, user_body_data.body_fat_percentage, user_body_data.measurement_date").
+		Joins("JOIN users ON users.id = user_body_data.user_id").
+		Where("users.analytics_opt_out = ? AND users.status = ?", false, 1).
+		Where("user_body_data.measurement_date >= ? AND user_body_data.measurement_date < ?", start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Order("user_body_data.measurement_date ASC, user_body_data.id ASC").
+		Scan(&measurements).Error; err != nil {
+		return nil, err
+	}
+	return measurements, nil
+}
+
+// LatestMeasurements retrieves the latest body data of each user
+func (r *analyticsRepository) LatestMeasurements(ctx context.Context, userIDs []int64, end time.Time) (map[int64]*AnalyticsMeasurement, error) {
+	latest := make(map[int64]*AnalyticsMeasurement, len(userIDs))
+	if len(userIDs) == 0 {
+		return latest, nil
+	}
+
+	var measurements []*AnalyticsMeasurement
+	if err := r.db.WithContext(ctx).
+		Model(&model.UserBodyData{}).
+		Select("user_id, age, gender, height, weight, body_fat_percentage, measurement_date").
+		Where("user_id IN ? AND measurement_date < ?", userIDs, end.Format("2006-01-02")).
+		Order("measurement_date ASC, id ASC").
+		Scan(&measurements).Error; err != nil {
+		return nil, err
+	}
+	// Ascending order leaves each user's latest entry in the map
+	for _, m := range measurements {
+		latest[m.UserID] = m
+	}
+	return latest, nil
+}
+
+// GetLastExport retrieves the most recent export
+func (r *analyticsRepository) GetLastExport(ctx context.Context) (*model.AnalyticsExport, error) {
+	var export model.AnalyticsExport
+	if err := r.db.WithContext(ctx).
+		Order("window_start DESC").
+		First(&export).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+// ClaimExport inserts the export unless its window start is taken
+func (r *analyticsRepository) ClaimExport(ctx context.Context, export *model.AnalyticsExport) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(export)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CompleteExport updates the counts and location of an export
+func (r *analyticsRepository) CompleteExport(ctx context.Context, export *model.AnalyticsExport) error {
+	return r.db.WithContext(ctx).Model(&model.AnalyticsExport{}).
+		Where("id = ?", export.ID).
+		Updates(map[string]interface{}{
+			"events":     export.Events,
+			"suppressed": export.Suppressed,
+			"location":   export.Location,
+		}).Error
+}
+
+// ReleaseExport deletes an export
+func (r *analyticsRepository) ReleaseExport(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&model.AnalyticsExport{}, id).Error
+}
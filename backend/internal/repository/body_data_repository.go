@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/ai-fitness-planner/backend/internal/model"
 	"gorm.io/gorm"
@@ -13,6 +14,7 @@ type BodyDataRepository interface {
 	Create(ctx context.Context, bodyData *model.UserBodyData) error
 	GetByUserID(ctx context.Context, userID int64) ([]*model.UserBodyData, error)
 	GetLatestByUserID(ctx context.Context, userID int64) (*model.UserBodyData, error)
+	GetByUserIDSince(ctx context.Context, userID int64, since time.Time) ([]*model.UserBodyData, error)
 }
 
 // bodyDataRepository implements BodyDataRepository interface
@@ -59,3 +61,15 @@ func (r *bodyDataRepository) GetLatestByUserID(ctx context.Context, userID int64
 	}
 	return &bodyData, nil
 }
+
+// GetByUserIDSince retrieves body data measured on or after since, oldest first
+func (r *bodyDataRepository) GetByUserIDSince(ctx context.Context, userID int64, since time.Time) ([]*model.UserBodyData, error) {
+	var bodyDataList []*model.UserBodyData
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND measurement_date >= ?", userID, since).
+		Order("measurement_date ASC, id ASC").
+		Find(&bodyDataList).Error; err != nil {
+		return nil, err
+	}
+	return bodyDataList, nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// CheckInRepository defines the interface for weekly check-in operations
+type CheckInRepository interface {
+	Create(ctx context.Context, checkIn *model.WeeklyCheckIn) error
+	Update(ctx context.Context, checkIn *model.WeeklyCheckIn) error
+	GetByUserAndWeek(ctx context.Context, userID int64, weekStart time.Time) (*model.WeeklyCheckIn, error)
+	GetLatestByUserID(ctx context.Context, userID int64) (*model.WeeklyCheckIn, error)
+	ListByUser(ctx context.Context, userID int64, limit, offset int) ([]*model.WeeklyCheckIn, int64, error)
+	// ListUserIDsDueReminder returns active users with neither a check-in for
+	// the week nor a reminder sent since the week started
+	ListUserIDsDueReminder(ctx context.Context, weekStart time.Time) ([]int64, error)
+}
+
+// checkInRepository implements CheckInRepository interface
+type checkInRepository struct {
+	db *gorm.DB
+}
+
+// NewCheckInRepository creates a new instance of CheckInRepository
+func NewCheckInRepository(db *gorm.DB) CheckInRepository {
+	return &checkInRepository{db: db}
+}
+
+// Create creates a new weekly check-in
+func (r *checkInRepository) Create(ctx context.Context, checkIn *model.WeeklyCheckIn) error {
+	if err := r.db.WithContext(ctx).Create(checkIn).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// Update updates an existing weekly check-in
+func (r *checkInRepository) Update(ctx context.Context, checkIn *model.WeeklyCheckIn) error {
+	if err := r.db.WithContext(ctx).Save(checkIn).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetByUserAndWeek retrieves a user's check-in for the week starting on weekStart
+func (r *checkInRepository) GetByUserAndWeek(ctx context.Context, userID int64, weekStart time.Time) (*model.WeeklyCheckIn, error) {
+	var checkIn model.WeeklyCheckIn
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND week_start = ?", userID, weekStart.Format("2006-01-02")).
+		First(&checkIn).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &checkIn, nil
+}
+
+// GetLatestByUserID retrieves the user's most recent check-in
+func (r *checkInRepository) GetLatestByUserID(ctx context.Context, userID int64) (*model.WeeklyCheckIn, error) {
+	var checkIn model.WeeklyCheckIn
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("week_start DESC").
+		First(&checkIn).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &checkIn, nil
+}
+
+// ListByUser retrieves a user's check-ins, most recent week first
+func (r *checkInRepository) ListByUser(ctx context.Context, userID int64, limit, offset int) ([]*model.WeeklyCheckIn, int64, error) {
+	var checkIns []*model.WeeklyCheckIn
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.WeeklyCheckIn{}).Where("user_id = ?", userID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Order("week_start DESC").Limit(limit).Offset(offset).Find(&checkIns).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return checkIns, total, nil
+}
+
+// ListUserIDsDueReminder returns active users still missing this week's check-in
+func (r *checkInRepository) ListUserIDsDueReminder(ctx context.Context, weekStart time.Time) ([]int64, error) {
+	var userIDs []int64
+
+	checkedIn := r.db.Model(&model.WeeklyCheckIn{}).
+		Select("user_id").
+		Where("week_start = ?", weekStart.Format("2006-01-02"))
+	reminded := r.db.Model(&model.UserNotification{}).
+		Select("user_id").
+		Where("type = ? AND created_at >= ?", model.NotificationTypeCheckInReminder, weekStart)
+
+	if err := r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("status = ?", 1).
+		Where("id NOT IN (?)", checkedIn).
+		Where("id NOT IN (?)", reminded).
+		Pluck("id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// CoachMessageRepository defines the interface for AI coach conversation storage
+type CoachMessageRepository interface {
+	// CreateBatch saves messages in order, in one transaction
+	CreateBatch(ctx context.Context, messages []*model.CoachMessage) error
+	// ListRecent returns the user's last limit messages, oldest first
+	ListRecent(ctx context.Context, userID int64, limit int) ([]*model.CoachMessage, error)
+	DeleteByUser(ctx context.Context, userID int64) error
+	// CountByUser and DeleteBatch serve the user's deletion of their AI
+	// history
+	CountByUser(ctx context.Context, userID int64, since, before *time.Time) (int64, error)
+	DeleteBatch(ctx context.Context, userID int64, since, before *time.Time, limit int) (int64, error)
+}
+
+// coachMessageRepository implements CoachMessageRepository interface
+type coachMessageRepository struct {
+	db *gorm.DB
+}
+
+// NewCoachMessageRepository creates a new instance of CoachMessageRepository
+func NewCoachMessageRepository(db *gorm.DB) CoachMessageRepository {
+	return &coachMessageRepository{db: db}
+}
+
+// CreateBatch saves a user message together with the reply to it
+func (r *coachMessageRepository) CreateBatch(ctx context.Context, messages []*model.CoachMessage) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, message := range messages {
+			if err := tx.Create(message).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListRecent retrieves the user's most recent messages
+func (r *coachMessageRepository) ListRecent(ctx context.Context, userID int64, limit int) ([]*model.CoachMessage, error) {
+	var messages []*model.CoachMessage
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
+
+// DeleteByUser deletes the user's whole conversation
+func (r *coachMessageRepository) DeleteByUser(ctx context.Context, userID int64) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.CoachMessage{}).Error
+}
+
+// CountByUser counts the user's coach messages created in [since, before); nil
+// bounds are open
+func (r *coachMessageRepository) CountByUser(ctx context.Context, userID int64, since, before *time.Time) (int64, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&model.CoachMessage{}).Where("user_id = ?", userID)
+
+	if since != nil {
+		query = query.Where("created_at >= ?", *since)
+	}
+
+	if before != nil {
+		query = query.Where("created_at < ?", *before)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// DeleteBatch permanently deletes up to limit of the user's coach messages
+// created in [since, before), oldest first, and returns how many it deleted
+func (r *coachMessageRepository) DeleteBatch(ctx context.Context, userID int64, since, before *time.Time, limit int) (int64, error) {
+	var ids []int64
+	query := r.db.WithContext(ctx).Model(&model.CoachMessage{}).Where("user_id = ?", userID)
+
+	if since != nil {
+		query = query.Where("created_at >= ?", *since)
+	}
+
+	if before != nil {
+		query = query.Where("created_at < ?", *before)
+	}
+
+	if err := query.Order("id ASC").Limit(limit).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result := r.db.WithContext(ctx).Where("id IN ?", ids).Delete(&model.CoachMessage{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// EquipmentProfileRepository defines the interface for equipment profile operations
+type EquipmentProfileRepository interface {
+	Create(ctx context.Context, profile *model.EquipmentProfile) error
+	GetByID(ctx context.Context, id int64) (*model.EquipmentProfile, error)
+	GetByUserAndName(ctx context.Context, userID int64, name string) (*model.EquipmentProfile, error)
+	ListByUser(ctx context.Context, userID int64) ([]*model.EquipmentProfile, error)
+	CountByUser(ctx context.Context, userID int64) (int64, error)
+	Update(ctx context.Context, profile *model.EquipmentProfile) error
+	Delete(ctx context.Context, id int64) error
+	SetDefault(ctx context.Context, userID, id int64) error
+}
+
+// equipmentProfileRepository implements EquipmentProfileRepository interface
+type equipmentProfileRepository struct {
+	db *gorm.DB
+}
+
+// NewEquipmentProfileRepository creates a new instance of EquipmentProfileRepository
+func NewEquipmentProfileRepository(db *gorm.DB) EquipmentProfileRepository {
+	return &equipmentProfileRepository{db: db}
+}
+
+// Create creates a new equipment profile
+func (r *equipmentProfileRepository) Create(ctx context.Context, profile *model.EquipmentProfile) error {
+	if err := r.db.WithContext(ctx).Create(profile).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetByID retrieves an equipment profile by ID
+func (r *equipmentProfileRepository) GetByID(ctx context.Context, id int64) (*model.EquipmentProfile, error) {
+	var profile model.EquipmentProfile
+	if err := r.db.WithContext(ctx).First(&profile, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// GetByUserAndName retrieves a user's equipment profile by name
+func (r *equipmentProfileRepository) GetByUserAndName(ctx context.Context, userID int64, name string) (*model.EquipmentProfile, error) {
+	var profile model.EquipmentProfile
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND name = ?", userID, name).
+		First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// ListByUser retrieves all equipment profiles for a user, default first
+func (r *equipmentProfileRepository) ListByUser(ctx context.Context, userID int64) ([]*model.EquipmentProfile, error) {
+	var profiles []*model.EquipmentProfile
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("is_default DESC, id ASC").
+		Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// CountByUser counts a user's equipment profiles
+func (r *equipmentProfileRepository) CountByUser(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Model(&model.EquipmentProfile{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Update updates an equipment profile
+func (r *equipmentProfileRepository) Update(ctx context.Context, profile *model.EquipmentProfile) error {
+	if err := r.db.WithContext(ctx).Save(profile).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// Delete deletes an equipment profile
+func (r *equipmentProfileRepository) Delete(ctx context.Context, id int64) error {
+	if err := r.db.WithContext(ctx).Delete(&model.EquipmentProfile{}, id).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetDefault makes one profile the user's default and clears the flag on the rest
+func (r *equipmentProfileRepository) SetDefault(ctx context.Context, userID, id int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.EquipmentProfile{}).
+			Where("user_id = ? AND id <> ?", userID, id).
+			Update("is_default", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.EquipmentProfile{}).
+			Where("user_id = ? AND id = ?", userID, id).
+			Update("is_default", true).Error
+	})
+}
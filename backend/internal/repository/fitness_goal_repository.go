@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/ai-fitness-planner/backend/internal/model"
 	"gorm.io/gorm"
@@ -14,6 +15,8 @@ type FitnessGoalRepository interface {
 	GetByUserID(ctx context.Context, userID int64, status string) ([]*model.FitnessGoal, error)
 	Update(ctx context.Context, goal *model.FitnessGoal) error
 	Delete(ctx context.Context, id int64) error
+	ListActiveWithTargets(ctx context.Context) ([]*model.FitnessGoal, error)
+	MarkCompleted(ctx context.Context, id int64, completedAt time.Time) (bool, error)
 }
 
 // fitnessGoalRepository implements FitnessGoalRepository interface
@@ -67,3 +70,33 @@ func (r *fitnessGoalRepository) Delete(ctx context.Context, id int64) error {
 	}
 	return nil
 }
+
+// ListActiveWithTargets retrieves active goals that have a body composition target
+func (r *fitnessGoalRepository) ListActiveWithTargets(ctx context.Context) ([]*model.FitnessGoal, error) {
+	var goals []*model.FitnessGoal
+	if err := r.db.WithContext(ctx).
+		Where("status = ?", model.GoalStatusActive).
+		Where("target_weight IS NOT NULL OR target_body_fat IS NOT NULL").
+		Order("user_id, id").
+		Find(&goals).Error; err != nil {
+		return nil, err
+	}
+	return goals, nil
+}
+
+// MarkCompleted moves an active goal to completed; it reports false if the
+// goal was no longer active
+func (r *fitnessGoalRepository) MarkCompleted(ctx context.Context, id int64, completedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.FitnessGoal{}).
+		Where("id = ? AND status = ?", id, model.GoalStatusActive).
+		Updates(map[string]interface{}{
+			"status":       model.GoalStatusCompleted,
+			"completed_at": completedAt,
+			"updated_at":   completedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
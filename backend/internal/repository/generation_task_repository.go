@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GenerationTaskFilter narrows the generation task history; zero values
+// match all
+type GenerationTaskFilter struct {
+	UserID int64
+	Status string
+	// TaskTypes matches any of the listed task types
+	TaskTypes []string
+}
+
+// GenerationTaskRepository defines the interface for generation task history
+type GenerationTaskRepository interface {
+	// Save records a finished task, replacing the record of an earlier run
+	// of the same task, as left by a task that failed and was retried
+	Save(ctx context.Context, task *model.GenerationTask) error
+	// List returns a page of tasks, newest first, and the total. Each task
+	// links its archived AI response, if any.
+	List(ctx context.Context, filter GenerationTaskFilter, limit, offset int) ([]*model.GenerationTask, int64, error)
+	// CountByUser and DeleteBatch serve the user's deletion of their AI
+	// history
+	CountByUser(ctx context.Context, userID int64, since, before *time.Time) (int64, error)
+	DeleteBatch(ctx context.Context, userID int64, since, before *time.Time, limit int) (int64, error)
+}
+
+// generationTaskRepository implements GenerationTaskRepository interface
+type generationTaskRepository struct {
+	db *gorm.DB
+}
+
+// NewGenerationTaskRepository creates a new instance of GenerationTaskRepository
+func NewGenerationTaskRepository(db *gorm.DB) GenerationTaskRepository {
+	return &generationTaskRepository{db: db}
+}
+
+// Save inserts the task or updates the existing record with its task ID
+func (r *generationTaskRepository) Save(ctx context.Context, task *model.GenerationTask) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "task_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"params", "status", "error", "plan_id", "duration_ms", "finished_at"}),
+	}).Create(task).Error
+}
+
+// List retrieves generation tasks matching filter
+func (r *generationTaskRepository) List(ctx context.Context, filter GenerationTaskFilter, limit, offset int) ([]*model.GenerationTask, int64, error) {
+	var tasks []*model.GenerationTask
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.GenerationTask{})
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if len(filter.TaskTypes) > 0 {
+		query = query.Where("task_type IN ?", filter.TaskTypes)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&tasks).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.linkResponseArchives(ctx, tasks); err != nil {
+		return nil, 0, err
+	}
+
+	return tasks, total, nil
+}
+
+// linkResponseArchives sets the archive IDs of a page of tasks with one query
+func (r *generationTaskRepository) linkResponseArchives(ctx context.Context, tasks []*model.GenerationTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]string, len(tasks))
+	for i, task := range tasks {
+		taskIDs[i] = task.TaskID
+	}
+
+	var archives []model.AIResponseArchive
+	if err := r.db.WithContext(ctx).Model(&model.AIResponseArchive{}).
+		Select("id, task_id").
+		Where("task_id IN ?", taskIDs).
+		Find(&archives).Error; err != nil {
+		return err
+	}
+
+	ids := make(map[string]int64, len(archives))
+	for _, archive := range archives {
+		ids[archive.TaskID] = archive.ID
+	}
+	for _, task := range tasks {
+		if id, ok := ids[task.TaskID]; ok {
+			task.ResponseArchiveID = &id
+		}
+	}
+	return nil
+}
+
+// CountByUser counts the user's generation tasks created in [since, before); nil
+// bounds are open
+func (r *generationTaskRepository) CountByUser(ctx context.Context, userID int64, since, before *time.Time) (int64, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&model.GenerationTask{}).Where("user_id = ?", userID)
+
+	if since != nil {
+		query = query.Where("created_at >= ?", *since)
+	}
+
+	if before != nil {
+		query = query.Where("created_at < ?", *before)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// DeleteBatch permanently deletes up to limit of the user's generation tasks
+// created in [since, before), oldest first, and returns how many it deleted
+func (r *generationTaskRepository) DeleteBatch(ctx context.Context, userID int64, since, before *time.Time, limit int) (int64, error) {
+	var ids []int64
+	query := r.db.WithContext(ctx).Model(&model.GenerationTask{}).Where("user_id = ?", userID)
+
+	if since != nil {
+		query = query.Where("created_at >= ?", *since)
+	}
+
+	if before != nil {
+		query = query.Where("created_at < ?", *before)
+	}
+
+	if err := query.Order("id ASC").Limit(limit).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result := r.db.WithContext(ctx).Where("id IN ?", ids).Delete(&model.GenerationTask{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// ImpersonationAuditRepository defines the interface for impersonation audit log operations
+type ImpersonationAuditRepository interface {
+	Create(ctx context.Context, log *model.ImpersonationAuditLog) error
+	List(ctx context.Context, userID int64, limit, offset int) ([]*model.ImpersonationAuditLog, int64, error)
+}
+
+// impersonationAuditRepository implements ImpersonationAuditRepository interface
+type impersonationAuditRepository struct {
+	db *gorm.DB
+}
+
+// NewImpersonationAuditRepository creates a new instance of ImpersonationAuditRepository
+func NewImpersonationAuditRepository(db *gorm.DB) ImpersonationAuditRepository {
+	return &impersonationAuditRepository{db: db}
+}
+
+// Create stores a new audit log entry
+func (r *impersonationAuditRepository) Create(ctx context.Context, log *model.ImpersonationAuditLog) error {
+	if err := r.db.WithContext(ctx).Create(log).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// List retrieves audit log entries, newest first; userID 0 means all users
+func (r *impersonationAuditRepository) List(ctx context.Context, userID int64, limit, offset int) ([]*model.ImpersonationAuditLog, int64, error) {
+	var logs []*model.ImpersonationAuditLog
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.ImpersonationAuditLog{})
+	if userID > 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// IntegrityRepository defines the cross-table queries used by the data
+// consistency checks and the updates used to repair what they find
+type IntegrityRepository interface {
+	// ListOrphanedTrainingRecords returns records whose plan no longer exists
+	// or belongs to another user
+	ListOrphanedTrainingRecords(ctx context.Context, limit int) ([]*model.TrainingRecord, error)
+	ClearTrainingRecordPlan(ctx context.Context, recordID int64) error
+	// ListTrainingPlansWithInvalidDates returns plans ending before they start;
+	// plan_data is not loaded
+	ListTrainingPlansWithInvalidDates(ctx context.Context, limit int) ([]*model.TrainingPlan, error)
+	ListNutritionPlansWithInvalidDates(ctx context.Context, limit int) ([]*model.NutritionPlan, error)
+	SetTrainingPlanEndDate(ctx context.Context, planID int64, endDate time.Time) error
+	SetNutritionPlanEndDate(ctx context.Context, planID int64, endDate time.Time) error
+	// ListZeroMacroNutritionRecords returns records whose calories and macros
+	// are all zero, after afterID in ID order
+	ListZeroMacroNutritionRecords(ctx context.Context, afterID int64, limit int) ([]*model.NutritionRecord, error)
+	SetNutritionRecordMacros(ctx context.Context, record *model.NutritionRecord) error
+}
+
+// integrityRepository implements IntegrityRepository interface
+type integrityRepository struct {
+	db *gorm.DB
+}
+
+// NewIntegrityRepository creates a new instance of IntegrityRepository
+func NewIntegrityRepository(db *gorm.DB) IntegrityRepository {
+	return &integrityRepository{db: db}
+}
+
+// ListOrphanedTrainingRecords finds records referencing a missing or foreign plan
+func (r *integrityRepository) ListOrphanedTrainingRecords(ctx context.Context, limit int) ([]*model.TrainingRecord, error) {
+	var records []*model.TrainingRecord
+	if err := r.db.WithContext(ctx).
+		Select("training_records.id, training_records.user_id, training_records.plan_id, training_records.workout_date").
+		Joins("LEFT JOIN training_plans ON training_plans.id = training_records.plan_id").
+		Where("training_records.plan_id IS NOT NULL").
+		Where("training_plans.id IS NULL OR training_plans.user_id <> training_records.user_id").
+		Order("training_records.id").
+		Limit(limit).
+		Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// ClearTrainingRecordPlan detaches a record from its plan
+func (r *integrityRepository) ClearTrainingRecordPlan(ctx context.Context, recordID int64) error {
+	return r.db.WithContext(ctx).
+		Model(&model.TrainingRecord{}).
+		Where("id = ?", recordID).
+		Update("plan_id", nil).Error
+}
+
+// ListTrainingPlansWithInvalidDates finds training plans ending before they start
+func (r *integrityRepository) ListTrainingPlansWithInvalidDates(ctx context.Context, limit int) ([]*model.TrainingPlan, error) {
+	var plans []*model.TrainingPlan
+	if err := r.db.WithContext(ctx).
+		Select("id, user_id, start_date, end_date, total_weeks").
+		Where("end_date < start_date").
+		Order("id").
+		Limit(limit).
+		Find(&plans).Error; err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// ListNutritionPlansWithInvalidDates finds nutrition plans ending before they
+// start; plan_data is loaded since the plan's length comes from its days
+func (r *integrityRepository) ListNutritionPlansWithInvalidDates(ctx context.Context, limit int) ([]*model.NutritionPlan, error) {
+	var plans []*model.NutritionPlan
+	if err := r.db.WithContext(ctx).
+		Select("id, user_id, start_date, end_date, plan_data").
+		Where("end_date < start_date").
+		Order("id").
+		Limit(limit).
+		Find(&plans).Error; err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// SetTrainingPlanEndDate updates a training plan's end date
+func (r *integrityRepository) SetTrainingPlanEndDate(ctx context.Context, planID int64, endDate time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.TrainingPlan{}).
+		Where("id = ?", planID).
+		Update("end_date", endDate).Error
+}
+
+// SetNutritionPlanEndDate updates a nutrition plan's end date
+func (r *integrityRepository) SetNutritionPlanEndDate(ctx context.Context, planID int64, endDate time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.NutritionPlan{}).
+		Where("id = ?", planID).
+		Update("end_date", endDate).Error
+}
+
+// ListZeroMacroNutritionRecords pages through nutrition records with no
+// calories or macros recorded
+func (r *integrityRepository) ListZeroMacroNutritionRecords(ctx context.Context, afterID int64, limit int) ([]*model.NutritionRecord, error) {
+	var records []*model.NutritionRecord
+	if err := r.db.WithContext(ctx).
+		Where("id > ? AND calories = 0 AND protein = 0 AND carbs = 0 AND fat = 0", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// SetNutritionRecordMacros updates a nutrition record's calories and macros
+func (r *integrityRepository) SetNutritionRecordMacros(ctx context.Context, record *model.NutritionRecord) error {
+	return r.db.WithContext(ctx).
+		Model(&model.NutritionRecord{}).
+		Where("id = ?", record.ID).
+		Updates(map[string]interface{}{
+			"calories": record.Calories,
+			"protein":  record.Protein,
+			"carbs":    record.Carbs,
+			"fat":      record.Fat,
+			"fiber":    record.Fiber,
+		}).Error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// MacrocycleRepository defines the interface for macrocycle operations
+type MacrocycleRepository interface {
+	Create(ctx context.Context, macrocycle *model.Macrocycle) error
+	GetByID(ctx context.Context, id int64) (*model.Macrocycle, error)
+	ListByUser(ctx context.Context, userID int64) ([]*model.Macrocycle, error)
+	Update(ctx context.Context, macrocycle *model.Macrocycle) error
+}
+
+// macrocycleRepository implements MacrocycleRepository interface
+type macrocycleRepository struct {
+	db *gorm.DB
+}
+
+// NewMacrocycleRepository creates a new instance of MacrocycleRepository
+func NewMacrocycleRepository(db *gorm.DB) MacrocycleRepository {
+	return &macrocycleRepository{db: db}
+}
+
+// Create creates a new macrocycle
+func (r *macrocycleRepository) Create(ctx context.Context, macrocycle *model.Macrocycle) error {
+	return r.db.WithContext(ctx).Create(macrocycle).Error
+}
+
+// GetByID retrieves a macrocycle by ID
+func (r *macrocycleRepository) GetByID(ctx context.Context, id int64) (*model.Macrocycle, error) {
+	var macrocycle model.Macrocycle
+	if err := r.db.WithContext(ctx).First(&macrocycle, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &macrocycle, nil
+}
+
+// ListByUser retrieves a user's macrocycles, newest first
+func (r *macrocycleRepository) ListByUser(ctx context.Context, userID int64) ([]*model.Macrocycle, error) {
+	var macrocycles []*model.Macrocycle
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&macrocycles).Error; err != nil {
+		return nil, err
+	}
+	return macrocycles, nil
+}
+
+// Update updates a macrocycle
+func (r *macrocycleRepository) Update(ctx context.Context, macrocycle *model.Macrocycle) error {
+	return r.db.WithContext(ctx).Save(macrocycle).Error
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// NotificationRepository defines the interface for user notification operations
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *model.UserNotification) error
+	ListByUser(ctx context.Context, userID int64, unreadOnly bool, limit, offset int) ([]*model.UserNotification, int64, error)
+	GetByID(ctx context.Context, id int64) (*model.UserNotification, error)
+	MarkRead(ctx context.Context, id int64, readAt time.Time) error
+}
+
+// notificationRepository implements NotificationRepository interface
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new instance of NotificationRepository
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// Create creates a new notification
+func (r *notificationRepository) Create(ctx context.Context, notification *model.UserNotification) error {
+	if err := r.db.WithContext(ctx).Create(notification).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListByUser retrieves a user's notifications, newest first
+func (r *notificationRepository) ListByUser(ctx context.Context, userID int64, unreadOnly bool, limit, offset int) ([]*model.UserNotification, int64, error) {
+	var notifications []*model.UserNotification
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.UserNotification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, total, nil
+}
+
+// GetByID retrieves a notification by its ID
+func (r *notificationRepository) GetByID(ctx context.Context, id int64) (*model.UserNotification, error) {
+	var notification model.UserNotification
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&notification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// MarkRead sets the read time of an unread notification
+func (r *notificationRepository) MarkRead(ctx context.Context, id int64, readAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.UserNotification{}).
+		Where("id = ? AND read_at IS NULL", id).
+		Update("read_at", readAt).Error
+}
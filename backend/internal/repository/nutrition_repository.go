@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -13,17 +14,42 @@ import (
 type NutritionPlanRepository interface {
 	Create(ctx context.Context, plan *model.NutritionPlan) error
 	GetByID(ctx context.Context, id int64) (*model.NutritionPlan, error)
+	// GetSummaryByID is GetByID leaving out the plan data, which can run to
+	// hundreds of kilobytes
+	GetSummaryByID(ctx context.Context, id int64) (*model.NutritionPlan, error)
 	ListByUser(ctx context.Context, userID int64, status string) ([]*model.NutritionPlan, error)
+	// ListSummariesByUser is ListByUser leaving out the plan data, for
+	// listings that only show the plans' details
+	ListSummariesByUser(ctx context.Context, userID int64, status string) ([]*model.NutritionPlan, error)
 	Update(ctx context.Context, plan *model.NutritionPlan) error
 	Delete(ctx context.Context, id int64) error
 	GetTodayMeals(ctx context.Context, userID int64, date time.Time) ([]model.NutritionPlanMeal, error)
+	// GetActiveOn returns the active plan GetTodayMeals reads for a date,
+	// without its plan data, nil if there is none
+	GetActiveOn(ctx context.Context, userID int64, date time.Time) (*model.NutritionPlan, error)
+	// ListActiveUserIDs returns the enabled users with an active plan
+	// covering date
+	ListActiveUserIDs(ctx context.Context, date time.Time) ([]int64, error)
+	// ListRolloverDue returns active plans that ended in [since, before)
+	// and have not been rolled over, for users who opted in to rollover
+	ListRolloverDue(ctx context.Context, since, before time.Time) ([]*model.NutritionPlan, error)
+	// MarkRolledOver records that a plan's next block was queued, reporting
+	// false when another run already did
+	MarkRolledOver(ctx context.Context, planID int64, at time.Time) (bool, error)
 }
 
 // NutritionRecordRepository defines the interface for nutrition record operations
 type NutritionRecordRepository interface {
 	Create(ctx context.Context, record *model.NutritionRecord) error
 	GetByID(ctx context.Context, id int64) (*model.NutritionRecord, error)
+	GetByClientID(ctx context.Context, userID int64, clientID string) (*model.NutritionRecord, error)
+	ListChangedSince(ctx context.Context, userID int64, since *time.Time) ([]*model.NutritionRecord, error)
+	SaveSynced(ctx context.Context, record *model.NutritionRecord) error
 	ListByUser(ctx context.Context, userID int64, startDate, endDate *time.Time) ([]*model.NutritionRecord, error)
+	CountByUser(ctx context.Context, userID int64, startDate, endDate *time.Time) (int64, error)
+	// DeleteBatch deletes up to limit of the user's records within an
+	// optional date range and returns how many it deleted
+	DeleteBatch(ctx context.Context, userID int64, startDate, endDate *time.Time, limit int) (int64, error)
 	GetDailySummary(ctx context.Context, userID int64, date time.Time) (*DailyNutritionSummary, error)
 }
 
@@ -58,8 +84,17 @@ func (r *nutritionPlanRepository) Create(ctx context.Context, plan *model.Nutrit
 
 // GetByID retrieves a nutrition plan by ID
 func (r *nutritionPlanRepository) GetByID(ctx context.Context, id int64) (*model.NutritionPlan, error) {
+	return r.getByID(r.db.WithContext(ctx), id)
+}
+
+// GetSummaryByID retrieves a nutrition plan by ID without its plan data
+func (r *nutritionPlanRepository) GetSummaryByID(ctx context.Context, id int64) (*model.NutritionPlan, error) {
+	return r.getByID(r.db.WithContext(ctx).Omit(planDataColumn), id)
+}
+
+func (r *nutritionPlanRepository) getByID(db *gorm.DB, id int64) (*model.NutritionPlan, error) {
 	var plan model.NutritionPlan
-	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&plan).Error; err != nil {
+	if err := db.Where("id = ?", id).First(&plan).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -70,8 +105,18 @@ func (r *nutritionPlanRepository) GetByID(ctx context.Context, id int64) (*model
 
 // ListByUser retrieves all nutrition plans for a user, optionally filtered by status
 func (r *nutritionPlanRepository) ListByUser(ctx context.Context, userID int64, status string) ([]*model.NutritionPlan, error) {
+	return r.listByUser(r.db.WithContext(ctx), userID, status)
+}
+
+// ListSummariesByUser retrieves all nutrition plans for a user without their
+// plan data, optionally filtered by status
+func (r *nutritionPlanRepository) ListSummariesByUser(ctx context.Context, userID int64, status string) ([]*model.NutritionPlan, error) {
+	return r.listByUser(r.db.WithContext(ctx).Omit(planDataColumn), userID, status)
+}
+
+func (r *nutritionPlanRepository) listByUser(db *gorm.DB, userID int64, status string) ([]*model.NutritionPlan, error) {
 	var plans []*model.NutritionPlan
-	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	query := db.Where("user_id = ?", userID)
 
 	if status != "" {
 		query = query.Where("status = ?", status)
@@ -99,6 +144,64 @@ func (r *nutritionPlanRepository) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
+// ListRolloverDue retrieves ended plans awaiting an automatic rollover
+func (r *nutritionPlanRepository) ListRolloverDue(ctx context.Context, since, before time.Time) ([]*model.NutritionPlan, error) {
+	var plans []*model.NutritionPlan
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN users ON users.id = nutrition_plans.user_id").
+		Where("users.auto_rollover = ? AND users.status = ?", true, 1).
+		Where("nutrition_plans.status = ? AND nutrition_plans.rolled_over_at IS NULL", "active").
+		Where("nutrition_plans.end_date >= ? AND nutrition_plans.end_date < ?", since.Format("2006-01-02"), before.Format("2006-01-02")).
+		Order("nutrition_plans.end_date ASC, nutrition_plans.id ASC").
+		Find(&plans).Error; err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// GetActiveOn finds the plan with the same conditions as GetTodayMeals,
+// leaving out the plan data
+func (r *nutritionPlanRepository) GetActiveOn(ctx context.Context, userID int64, date time.Time) (*model.NutritionPlan, error) {
+	var plan model.NutritionPlan
+	if err := r.db.WithContext(ctx).Omit(planDataColumn).
+		Where("user_id = ? AND status = ? AND start_date <= ? AND end_date >= ?",
+			userID, "active", date, date).
+		First(&plan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// ListActiveUserIDs returns the IDs of enabled users with an active plan
+// covering date
+func (r *nutritionPlanRepository) ListActiveUserIDs(ctx context.Context, date time.Time) ([]int64, error) {
+	var userIDs []int64
+	if err := r.db.WithContext(ctx).Model(&model.NutritionPlan{}).
+		Joins("JOIN users ON users.id = nutrition_plans.user_id").
+		Where("users.status = ?", 1).
+		Where("nutrition_plans.status = ? AND nutrition_plans.start_date <= ? AND nutrition_plans.end_date >= ?", "active", date, date).
+		Distinct().
+		Order("nutrition_plans.user_id ASC").
+		Pluck("nutrition_plans.user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// MarkRolledOver sets rolled_over_at unless it is already set
+func (r *nutritionPlanRepository) MarkRolledOver(ctx context.Context, planID int64, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.NutritionPlan{}).
+		Where("id = ? AND rolled_over_at IS NULL", planID).
+		Update("rolled_over_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 // GetTodayMeals retrieves the meal plan for a specific date
 func (r *nutritionPlanRepository) GetTodayMeals(ctx context.Context, userID int64, date time.Time) ([]model.NutritionPlanMeal, error) {
 	var plan model.NutritionPlan
@@ -142,53 +245,7 @@ func (r *nutritionPlanRepository) GetTodayMeals(ctx context.Context, userID int6
 			continue
 		}
 
-		meal := model.NutritionPlanMeal{}
-
-		if time, ok := mealMap["time"].(string); ok {
-			meal.Time = time
-		}
-
-		if totalCal, ok := mealMap["total_calories"].(float64); ok {
-			meal.TotalCalories = totalCal
-		}
-
-		// Parse foods
-		if foodsInterface, ok := mealMap["foods"].([]interface{}); ok {
-			foods := make([]model.NutritionFoodItem, 0, len(foodsInterface))
-			for _, foodInterface := range foodsInterface {
-				foodMap, ok := foodInterface.(map[string]interface{})
-				if !ok {
-					continue
-				}
-
-				food := model.NutritionFoodItem{}
-				if name, ok := foodMap["name"].(string); ok {
-					food.Name = name
-				}
-				if amount, ok := foodMap["amount"].(string); ok {
-					food.Amount = amount
-				}
-				if calories, ok := foodMap["calories"].(float64); ok {
-					food.Calories = calories
-				}
-				if protein, ok := foodMap["protein"].(float64); ok {
-					food.Protein = protein
-				}
-				if carbs, ok := foodMap["carbs"].(float64); ok {
-					food.Carbs = carbs
-				}
-				if fat, ok := foodMap["fat"].(float64); ok {
-					food.Fat = fat
-				}
-				if fiber, ok := foodMap["fiber"].(float64); ok {
-					food.Fiber = fiber
-				}
-
-				foods = append(foods, food)
-			}
-			meal.Foods = foods
-		}
-
+		meal := model.NutritionPlanMealFromData(mealMap)
 		meals = append(meals, meal)
 	}
 
@@ -207,6 +264,9 @@ func NewNutritionRecordRepository(db *gorm.DB) NutritionRecordRepository {
 
 // Create creates a new nutrition record
 func (r *nutritionRecordRepository) Create(ctx context.Context, record *model.NutritionRecord) error {
+	if record.ClientID == "" {
+		record.ClientID = uuid.New().String()
+	}
 	if err := r.db.WithContext(ctx).Create(record).Error; err != nil {
 		return err
 	}
@@ -225,6 +285,44 @@ func (r *nutritionRecordRepository) GetByID(ctx context.Context, id int64) (*mod
 	return &record, nil
 }
 
+// GetByClientID retrieves a record by its client-generated UUID, including
+// deleted records
+func (r *nutritionRecordRepository) GetByClientID(ctx context.Context, userID int64, clientID string) (*model.NutritionRecord, error) {
+	var record model.NutritionRecord
+	if err := r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ? AND client_id = ?", userID, clientID).
+		First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// ListChangedSince retrieves records, deleted ones included, changed at or
+// after since; all records when since is nil
+func (r *nutritionRecordRepository) ListChangedSince(ctx context.Context, userID int64, since *time.Time) ([]*model.NutritionRecord, error) {
+	var records []*model.NutritionRecord
+	query := r.db.WithContext(ctx).Unscoped().Where("user_id = ?", userID)
+	if since != nil {
+		query = query.Where("updated_at >= ?", *since)
+	}
+	if err := query.Order("updated_at ASC, id ASC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// SaveSynced creates or updates a record pushed by a sync client, including
+// its tombstone
+func (r *nutritionRecordRepository) SaveSynced(ctx context.Context, record *model.NutritionRecord) error {
+	if err := r.db.WithContext(ctx).Unscoped().Save(record).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
 // ListByUser retrieves nutrition records for a user within an optional date range
 func (r *nutritionRecordRepository) ListByUser(ctx context.Context, userID int64, startDate, endDate *time.Time) ([]*model.NutritionRecord, error) {
 	var records []*model.NutritionRecord
@@ -244,6 +342,56 @@ func (r *nutritionRecordRepository) ListByUser(ctx context.Context, userID int64
 	return records, nil
 }
 
+// CountByUser counts records for a user within an optional date range
+func (r *nutritionRecordRepository) CountByUser(ctx context.Context, userID int64, startDate, endDate *time.Time) (int64, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&model.NutritionRecord{}).Where("user_id = ?", userID)
+
+	if startDate != nil {
+		query = query.Where("meal_date >= ?", *startDate)
+	}
+
+	if endDate != nil {
+		query = query.Where("meal_date <= ?", *endDate)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// DeleteBatch soft-deletes a batch of records. updated_at is set along with
+// deleted_at so that sync clients receive the tombstones.
+func (r *nutritionRecordRepository) DeleteBatch(ctx context.Context, userID int64, startDate, endDate *time.Time, limit int) (int64, error) {
+	var ids []int64
+	query := r.db.WithContext(ctx).Model(&model.NutritionRecord{}).Where("user_id = ?", userID)
+
+	if startDate != nil {
+		query = query.Where("meal_date >= ?", *startDate)
+	}
+
+	if endDate != nil {
+		query = query.Where("meal_date <= ?", *endDate)
+	}
+
+	if err := query.Order("id ASC").Limit(limit).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&model.NutritionRecord{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{"deleted_at": now, "updated_at": now})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
 // GetDailySummary calculates aggregated nutrition data for a specific day
 func (r *nutritionRecordRepository) GetDailySummary(ctx context.Context, userID int64, date time.Time) (*DailyNutritionSummary, error) {
 	summary := &DailyNutritionSummary{
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSeatLimitReached is returned when activating a member would exceed the
+// organization's seat limit
+var ErrSeatLimitReached = errors.New("organization seat limit reached")
+
+// ErrInvitationUsed is returned when an invitation was already accepted
+var ErrInvitationUsed = errors.New("invitation already accepted")
+
+// OrganizationRepository defines the interface for organization and member
+// provisioning data operations
+type OrganizationRepository interface {
+	Create(ctx context.Context, org *model.Organization) error
+	GetByID(ctx context.Context, id int64) (*model.Organization, error)
+	GetByName(ctx context.Context, name string) (*model.Organization, error)
+	List(ctx context.Context) ([]*model.Organization, error)
+	CountActiveMembers(ctx context.Context, orgID int64) (int64, error)
+	// AddMember creates the user (ID 0) or reactivates it as an active member
+	// of the organization and stores its invitation. The organization row is
+	// locked so concurrent batches cannot oversell seats.
+	AddMember(ctx context.Context, orgID int64, user *model.User, invitation *model.UserInvitation) error
+	DeactivateMember(ctx context.Context, orgID, userID int64) error
+	GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*model.UserInvitation, error)
+	// AcceptInvitation sets the user's password and marks the invitation used
+	AcceptInvitation(ctx context.Context, invitation *model.UserInvitation, passwordHash string) error
+}
+
+// organizationRepository implements OrganizationRepository interface
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+// NewOrganizationRepository creates a new instance of OrganizationRepository
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+// Create creates a new organization
+func (r *organizationRepository) Create(ctx context.Context, org *model.Organization) error {
+	if err := r.db.WithContext(ctx).Create(org).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetByID retrieves an organization by ID
+func (r *organizationRepository) GetByID(ctx context.Context, id int64) (*model.Organization, error) {
+	var org model.Organization
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&org).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+// GetByName retrieves an organization by name
+func (r *organizationRepository) GetByName(ctx context.Context, name string) (*model.Organization, error) {
+	var org model.Organization
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&org).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+// List retrieves all organizations ordered by name
+func (r *organizationRepository) List(ctx context.Context) ([]*model.Organization, error) {
+	var orgs []*model.Organization
+	if err := r.db.WithContext(ctx).Order("name ASC").Find(&orgs).Error; err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// CountActiveMembers counts the organization's active users
+func (r *organizationRepository) CountActiveMembers(ctx context.Context, orgID int64) (int64, error) {
+	return countActiveMembers(r.db.WithContext(ctx), orgID)
+}
+
+// AddMember activates a user in the organization within its seat limit
+func (r *organizationRepository) AddMember(ctx context.Context, orgID int64, user *model.User, invitation *model.UserInvitation) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var org model.Organization
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", orgID).First(&org).Error; err != nil {
+			return err
+		}
+
+		active, err := countActiveMembers(tx, orgID)
+		if err != nil {
+			return err
+		}
+		if active >= int64(org.SeatLimit) {
+			return ErrSeatLimitReached
+		}
+
+		user.OrganizationID = &orgID
+		user.Status = 1
+		if user.ID == 0 {
+			if err := tx.Create(user).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Model(&model.User{}).Where("id = ?", user.ID).
+			Updates(map[string]interface{}{"organization_id": orgID, "status": 1}).Error; err != nil {
+			return err
+		}
+
+		invitation.UserID = user.ID
+		invitation.OrganizationID = orgID
+		return tx.Create(invitation).Error
+	})
+}
+
+// DeactivateMember disables a member of the organization
+func (r *organizationRepository) DeactivateMember(ctx context.Context, orgID, userID int64) error {
+	if err := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND organization_id = ?", userID, orgID).
+		Update("status", 0).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetInvitationByTokenHash retrieves an invitation by its token hash
+func (r *organizationRepository) GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*model.UserInvitation, error) {
+	var invitation model.UserInvitation
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// AcceptInvitation sets the password and marks the invitation accepted. The
+// accepted_at guard makes a token usable only once even under concurrency.
+func (r *organizationRepository) AcceptInvitation(ctx context.Context, invitation *model.UserInvitation, passwordHash string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&model.UserInvitation{}).
+			Where("id = ? AND accepted_at IS NULL", invitation.ID).
+			Update("accepted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvitationUsed
+		}
+		invitation.AcceptedAt = &now
+
+		return tx.Model(&model.User{}).
+			Where("id = ?", invitation.UserID).
+			Update("password_hash", passwordHash).Error
+	})
+}
+
+func countActiveMembers(db *gorm.DB, orgID int64) (int64, error) {
+	var count int64
+	if err := db.Model(&model.User{}).
+		Where("organization_id = ? AND status = ?", orgID, 1).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
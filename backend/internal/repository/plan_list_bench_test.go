@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The benchmarks compare listing a user's plans with and without their plan
+// data. They need a MySQL database migrated with the project's schema, e.g.
+//
+//	FITNESS_TEST_MYSQL_DSN='user:pass@tcp(localhost:3306)/fitness_planner_test?parseTime=true&loc=Local' \
+//	  go test -run '^$' -bench PlanList -benchmem ./internal/repository/
+//
+// The plans are written in a transaction that is rolled back afterwards.
+
+// benchPlanCount is the number of plans of the benchmarked account
+const benchPlanCount = 60
+
+func openBenchDB(b *testing.B) *gorm.DB {
+	dsn := os.Getenv("FITNESS_TEST_MYSQL_DSN")
+	if dsn == "" {
+		b.Skip("FITNESS_TEST_MYSQL_DSN not set")
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		b.Fatalf("connect: %v", err)
+	}
+
+	tx := db.Begin()
+	b.Cleanup(func() { tx.Rollback() })
+	// The plans belong to a user and AI API that do not exist
+	if err := tx.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+		b.Fatalf("disable foreign key checks: %v", err)
+	}
+	return tx
+}
+
+// benchUserID is far above real user IDs, so the account holds only the
+// benchmark's plans
+func benchUserID() int64 {
+	return 1<<40 + time.Now().UnixNano()%1e6
+}
+
+// benchTrainingPlanData builds a 12-week plan of the size the AI returns
+func benchTrainingPlanData() model.JSONMap {
+	weeks := make([]interface{}, 0, 12)
+	for w := 1; w <= 12; w++ {
+		days := make([]interface{}, 0, 7)
+		for d := 1; d <= 7; d++ {
+			exercises := make([]interface{}, 0, 6)
+			for e := 1; e <= 6; e++ {
+				exercises = append(exercises, map[string]interface{}{
+					"name":         fmt.Sprintf("动作%d", e),
+					"sets":         4,
+					"reps":         "8-10",
+					"weight":       "60kg",
+					"rest":         "90s",
+					"difficulty":   "medium",
+					"safety_notes": "保持核心收紧，下放时控制速度，避免借力和耸肩，出现关节疼痛立即停止",
+				})
+			}
+			days = append(days, map[string]interface{}{
+				"day":                d,
+				"type":               "strength",
+				"focus_area":         "upper_body",
+				"exercises":          exercises,
+				"duration":           60,
+				"estimated_calories": 350,
+			})
+		}
+		weeks = append(weeks, map[string]interface{}{"week": w, "days": days})
+	}
+	return model.JSONMap{"weeks": weeks}
+}
+
+// benchNutritionPlanData builds a 7-day plan of the size the AI returns
+func benchNutritionPlanData() model.JSONMap {
+	days := make([]interface{}, 0, 7)
+	for d := 1; d <= 7; d++ {
+		meals := map[string]interface{}{}
+		for _, meal := range []string{"breakfast", "lunch", "dinner", "snacks"} {
+			foods := make([]interface{}, 0, 4)
+			for f := 1; f <= 4; f++ {
+				foods = append(foods, map[string]interface{}{
+					"name": fmt.Sprintf("食物%d", f), "amount": "100g",
+					"calories": 150, "protein": 10, "carbs": 20, "fat": 5, "fiber": 2,
+				})
+			}
+			meals[meal] = map[string]interface{}{"time": "08:00", "foods": foods, "total_calories": 600}
+		}
+		days = append(days, map[string]interface{}{
+			"day":          d,
+			"meals":        meals,
+			"daily_totals": map[string]interface{}{"calories": 2400, "protein": 160, "carbs": 280, "fat": 70},
+		})
+	}
+	return model.JSONMap{"days": days}
+}
+
+func seedTrainingPlans(b *testing.B, db *gorm.DB, userID int64) {
+	data := benchTrainingPlanData()
+	start := time.Now().AddDate(0, 0, -7)
+	for i := 0; i < benchPlanCount; i++ {
+		plan := &model.TrainingPlan{
+			UserID:          userID,
+			PlanName:        fmt.Sprintf("训练计划%d", i),
+			StartDate:       start,
+			EndDate:         start.AddDate(0, 0, 84),
+			TotalWeeks:      12,
+			DifficultyLevel: "medium",
+			PlanData:        data,
+			Status:          "completed",
+		}
+		if err := db.Create(plan).Error; err != nil {
+			b.Fatalf("seed training plan: %v", err)
+		}
+	}
+}
+
+func seedNutritionPlans(b *testing.B, db *gorm.DB, userID int64) {
+	data := benchNutritionPlanData()
+	start := time.Now().AddDate(0, 0, -7)
+	for i := 0; i < benchPlanCount; i++ {
+		plan := &model.NutritionPlan{
+			UserID:        userID,
+			PlanName:      fmt.Sprintf("饮食计划%d", i),
+			StartDate:     start,
+			EndDate:       start.AddDate(0, 0, 7),
+			DailyCalories: 2400,
+			ProteinRatio:  0.3,
+			CarbRatio:     0.45,
+			FatRatio:      0.25,
+			PlanData:      data,
+			AIAPIID:       1,
+			Status:        "completed",
+		}
+		if err := db.Create(plan).Error; err != nil {
+			b.Fatalf("seed nutrition plan: %v", err)
+		}
+	}
+}
+
+func BenchmarkTrainingPlanList(b *testing.B) {
+	db := openBenchDB(b)
+	userID := benchUserID()
+	seedTrainingPlans(b, db, userID)
+	repo := NewTrainingPlanRepository(db)
+	ctx := context.Background()
+
+	b.Run("Full", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.ListByUser(ctx, userID, ""); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Summary", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.ListSummariesByUser(ctx, userID, ""); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkNutritionPlanList(b *testing.B) {
+	db := openBenchDB(b)
+	userID := benchUserID()
+	seedNutritionPlans(b, db, userID)
+	repo := NewNutritionPlanRepository(db)
+	ctx := context.Background()
+
+	b.Run("Full", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.ListByUser(ctx, userID, ""); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Summary", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.ListSummariesByUser(ctx, userID, ""); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// PromptVariantStats aggregates the plans generated from one prompt template
+type PromptVariantStats struct {
+	TemplateID int64
+	Plans      int64
+	// AdjustedPlans were later adjusted by the AI at the user's request
+	AdjustedPlans  int64
+	CompletedPlans int64
+	// Feedbacks counts the satisfaction ratings left on the plans
+	Feedbacks       int64
+	AvgSatisfaction *float64
+}
+
+// promptVariantPlanTables maps the plan types whose templates can be
+// compared to their plan tables
+var promptVariantPlanTables = map[string]string{
+	"training":  "training_plans",
+	"nutrition": "nutrition_plans",
+}
+
+// PromptTemplateRepository defines the interface for prompt template operations
+type PromptTemplateRepository interface {
+	// GetDefault returns the default template for a category and subcategory.
+	// If several are marked default, the most recently updated one wins.
+	GetDefault(ctx context.Context, category, subcategory string) (*model.PromptTemplate, error)
+	// List returns templates, optionally filtered by category and subcategory
+	List(ctx context.Context, category, subcategory string) ([]*model.PromptTemplate, error)
+	GetByID(ctx context.Context, id int64) (*model.PromptTemplate, error)
+	Create(ctx context.Context, template *model.PromptTemplate) error
+	Update(ctx context.Context, template *model.PromptTemplate) error
+	// SetDefault makes the template the only default of its category and
+	// subcategory
+	SetDefault(ctx context.Context, template *model.PromptTemplate) error
+	// ListVariants returns the non-default templates of a category and
+	// subcategory that are rolled out to a share of users, oldest first
+	ListVariants(ctx context.Context, category, subcategory string) ([]*model.PromptTemplate, error)
+	// SetRollout changes the share of users a template is served to
+	SetRollout(ctx context.Context, template *model.PromptTemplate, percent int) error
+	// Promote makes the template the only default of its category and
+	// subcategory and ends the rollout of every template there
+	Promote(ctx context.Context, template *model.PromptTemplate) error
+	// VariantStats aggregates the plans of planType created since the given
+	// time from each of the templates. Templates without plans are omitted.
+	VariantStats(ctx context.Context, planType string, templateIDs []int64, since time.Time) ([]*PromptVariantStats, error)
+}
+
+// promptTemplateRepository implements PromptTemplateRepository interface
+type promptTemplateRepository struct {
+	db *gorm.DB
+}
+
+// NewPromptTemplateRepository creates a new instance of PromptTemplateRepository
+func NewPromptTemplateRepository(db *gorm.DB) PromptTemplateRepository {
+	return &promptTemplateRepository{db: db}
+}
+
+// GetDefault retrieves the default template for category and subcategory
+func (r *promptTemplateRepository) GetDefault(ctx context.Context, category, subcategory string) (*model.PromptTemplate, error) {
+	var template model.PromptTemplate
+	if err := r.db.WithContext(ctx).
+		Where("category = ? AND subcategory = ? AND is_default = ?", category, subcategory, true).
+		Order("updated_at DESC, id DESC").
+		First(&template).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// List retrieves templates ordered by category, subcategory and ID
+func (r *promptTemplateRepository) List(ctx context.Context, category, subcategory string) ([]*model.PromptTemplate, error) {
+	query := r.db.WithContext(ctx)
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if subcategory != "" {
+		query = query.Where("subcategory = ?", subcategory)
+	}
+
+	var templates []*model.PromptTemplate
+	if err := query.Order("category, subcategory, id").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// GetByID retrieves a template by ID
+func (r *promptTemplateRepository) GetByID(ctx context.Context, id int64) (*model.PromptTemplate, error) {
+	var template model.PromptTemplate
+	if err := r.db.WithContext(ctx).First(&template, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// Create creates a new template
+func (r *promptTemplateRepository) Create(ctx context.Context, template *model.PromptTemplate) error {
+	return r.db.WithContext(ctx).Create(template).Error
+}
+
+// Update saves every field of the template
+func (r *promptTemplateRepository) Update(ctx context.Context, template *model.PromptTemplate) error {
+	return r.db.WithContext(ctx).Save(template).Error
+}
+
+// SetDefault clears the default flag on the template's siblings and sets it
+// on the template in one transaction
+func (r *promptTemplateRepository) SetDefault(ctx context.Context, template *model.PromptTemplate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.PromptTemplate{}).
+			Where("category = ? AND subcategory <=> ? AND id <> ?", template.Category, template.Subcategory, template.ID).
+			Update("is_default", false).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(template).Update("is_default", true).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+// ListVariants retrieves the templates rolled out beside the default
+func (r *promptTemplateRepository) ListVariants(ctx context.Context, category, subcategory string) ([]*model.PromptTemplate, error) {
+	var templates []*model.PromptTemplate
+	if err := r.db.WithContext(ctx).
+		Where("category = ? AND subcategory = ? AND is_default = ? AND rollout_percent > 0", category, subcategory, false).
+		Order("id").
+		Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// SetRollout updates the rollout percentage of the template
+func (r *promptTemplateRepository) SetRollout(ctx context.Context, template *model.PromptTemplate, percent int) error {
+	return r.db.WithContext(ctx).Model(template).Update("rollout_percent", percent).Error
+}
+
+// Promote clears the default flag and rollout of the template's category
+// and subcategory and makes the template the default in one transaction
+func (r *promptTemplateRepository) Promote(ctx context.Context, template *model.PromptTemplate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.PromptTemplate{}).
+			Where("category = ? AND subcategory <=> ?", template.Category, template.Subcategory).
+			Updates(map[string]interface{}{"is_default": false, "rollout_percent": 0}).Error; err != nil {
+			return err
+		}
+		return tx.Model(template).Update("is_default", true).Error
+	})
+}
+
+// VariantStats aggregates plans and their satisfaction ratings per template
+func (r *promptTemplateRepository) VariantStats(ctx context.Context, planType string, templateIDs []int64, since time.Time) ([]*PromptVariantStats, error) {
+	table, ok := promptVariantPlanTables[planType]
+	if !ok || len(templateIDs) == 0 {
+		return nil, nil
+	}
+
+	var stats []*PromptVariantStats
+	if err := r.db.WithContext(ctx).
+		Table(table+" AS p").
+		Select("p.prompt_template_id AS template_id, COUNT(*) AS plans, "+
+			"COALESCE(SUM(p.status = 'completed'), 0) AS completed_plans, "+
+			"COALESCE(SUM(EXISTS (SELECT 1 FROM "+table+" AS a WHERE a.parent_plan_id = p.id)), 0) AS adjusted_plans").
+		Where("p.prompt_template_id IN ? AND p.created_at >= ?", templateIDs, since).
+		Group("p.prompt_template_id").
+		Order("p.prompt_template_id").
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+
+	var ratings []struct {
+		TemplateID      int64
+		Feedbacks       int64
+		AvgSatisfaction *float64
+	}
+	if err := r.db.WithContext(ctx).
+		Table("feedback_records AS f").
+		Select("p.prompt_template_id AS template_id, COUNT(*) AS feedbacks, AVG(f.satisfaction) AS avg_satisfaction").
+		Joins("JOIN "+table+" AS p ON p.id = f.plan_id").
+		Where("f.plan_type = ? AND f.satisfaction IS NOT NULL AND p.prompt_template_id IN ? AND p.created_at >= ?", planType, templateIDs, since).
+		Group("p.prompt_template_id").
+		Scan(&ratings).Error; err != nil {
+		return nil, err
+	}
+	for _, rating := range ratings {
+		for _, st := range stats {
+			if st.TemplateID == rating.TemplateID {
+				st.Feedbacks = rating.Feedbacks
+				st.AvgSatisfaction = rating.AvgSatisfaction
+			}
+		}
+	}
+	return stats, nil
+}
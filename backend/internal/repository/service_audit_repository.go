@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// ServiceAuditRepository defines the interface for service token audit log operations
+type ServiceAuditRepository interface {
+	Create(ctx context.Context, log *model.ServiceAuditLog) error
+	List(ctx context.Context, service string, limit, offset int) ([]*model.ServiceAuditLog, int64, error)
+	// GetIssue returns the entry recording the issue of a token, or nil
+	GetIssue(ctx context.Context, tokenID string) (*model.ServiceAuditLog, error)
+}
+
+// serviceAuditRepository implements ServiceAuditRepository interface
+type serviceAuditRepository struct {
+	db *gorm.DB
+}
+
+// NewServiceAuditRepository creates a new instance of ServiceAuditRepository
+func NewServiceAuditRepository(db *gorm.DB) ServiceAuditRepository {
+	return &serviceAuditRepository{db: db}
+}
+
+// Create stores a new audit log entry
+func (r *serviceAuditRepository) Create(ctx context.Context, log *model.ServiceAuditLog) error {
+	return r.db.WithContext(ctx).Create(log).Error
+}
+
+// List retrieves audit log entries, newest first; an empty service means all services
+func (r *serviceAuditRepository) List(ctx context.Context, service string, limit, offset int) ([]*model.ServiceAuditLog, int64, error) {
+	var logs []*model.ServiceAuditLog
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.ServiceAuditLog{})
+	if service != "" {
+		query = query.Where("service = ?", service)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// GetIssue retrieves the issue entry of a token
+func (r *serviceAuditRepository) GetIssue(ctx context.Context, tokenID string) (*model.ServiceAuditLog, error) {
+	var log model.ServiceAuditLog
+	err := r.db.WithContext(ctx).
+		Where("token_id = ? AND method = ?", tokenID, model.ServiceAuditMethodIssue).
+		First(&log).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &log, nil
+}
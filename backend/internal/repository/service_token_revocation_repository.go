@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ServiceTokenRevocationRepository defines the interface for service token revocation operations
+type ServiceTokenRevocationRepository interface {
+	// Create revokes a token; revoking it again keeps the first revocation
+	Create(ctx context.Context, revocation *model.ServiceTokenRevocation) error
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+// serviceTokenRevocationRepository implements ServiceTokenRevocationRepository interface
+type serviceTokenRevocationRepository struct {
+	db *gorm.DB
+}
+
+// NewServiceTokenRevocationRepository creates a new instance of ServiceTokenRevocationRepository
+func NewServiceTokenRevocationRepository(db *gorm.DB) ServiceTokenRevocationRepository {
+	return &serviceTokenRevocationRepository{db: db}
+}
+
+// Create stores a revocation unless the token is already revoked
+func (r *serviceTokenRevocationRepository) Create(ctx context.Context, revocation *model.ServiceTokenRevocation) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(revocation).Error
+}
+
+// IsRevoked reports whether a token has been revoked
+func (r *serviceTokenRevocationRepository) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.ServiceTokenRevocation{}).
+		Where("token_id = ?", tokenID).
+		Count(&count).Error
+	return count > 0, err
+}
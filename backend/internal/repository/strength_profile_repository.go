@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// StrengthProfileRepository defines the interface for strength profile operations
+type StrengthProfileRepository interface {
+	ListByUser(ctx context.Context, userID int64) ([]*model.StrengthProfileEntry, error)
+	GetByUserAndLift(ctx context.Context, userID int64, lift string) (*model.StrengthProfileEntry, error)
+	Save(ctx context.Context, entry *model.StrengthProfileEntry) error
+}
+
+// strengthProfileRepository implements StrengthProfileRepository interface
+type strengthProfileRepository struct {
+	db *gorm.DB
+}
+
+// NewStrengthProfileRepository creates a new instance of StrengthProfileRepository
+func NewStrengthProfileRepository(db *gorm.DB) StrengthProfileRepository {
+	return &strengthProfileRepository{db: db}
+}
+
+// ListByUser retrieves all strength profile entries for a user
+func (r *strengthProfileRepository) ListByUser(ctx context.Context, userID int64) ([]*model.StrengthProfileEntry, error) {
+	var entries []*model.StrengthProfileEntry
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("lift").
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetByUserAndLift retrieves a user's entry for one lift
+func (r *strengthProfileRepository) GetByUserAndLift(ctx context.Context, userID int64, lift string) (*model.StrengthProfileEntry, error) {
+	var entry model.StrengthProfileEntry
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND lift = ?", userID, lift).
+		First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Save creates or updates a strength profile entry
+func (r *strengthProfileRepository) Save(ctx context.Context, entry *model.StrengthProfileEntry) error {
+	if err := r.db.WithContext(ctx).Save(entry).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// TrainingConstraintRepository defines the interface for training constraint operations
+type TrainingConstraintRepository interface {
+	Create(ctx context.Context, constraint *model.TrainingConstraint) error
+	GetByID(ctx context.Context, id int64) (*model.TrainingConstraint, error)
+	// ListByUser returns the user's constraints, newest first. With
+	// activeOn set, only constraints that have not expired by that date are
+	// returned; ones starting later are included.
+	ListByUser(ctx context.Context, userID int64, activeOn *time.Time) ([]*model.TrainingConstraint, error)
+	Update(ctx context.Context, constraint *model.TrainingConstraint) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// trainingConstraintRepository implements TrainingConstraintRepository interface
+type trainingConstraintRepository struct {
+	db *gorm.DB
+}
+
+// NewTrainingConstraintRepository creates a new instance of TrainingConstraintRepository
+func NewTrainingConstraintRepository(db *gorm.DB) TrainingConstraintRepository {
+	return &trainingConstraintRepository{db: db}
+}
+
+// Create creates a new training constraint
+func (r *trainingConstraintRepository) Create(ctx context.Context, constraint *model.TrainingConstraint) error {
+	if err := r.db.WithContext(ctx).Create(constraint).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetByID retrieves a training constraint by ID
+func (r *trainingConstraintRepository) GetByID(ctx context.Context, id int64) (*model.TrainingConstraint, error) {
+	var constraint model.TrainingConstraint
+	if err := r.db.WithContext(ctx).First(&constraint, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &constraint, nil
+}
+
+// ListByUser retrieves a user's training constraints
+func (r *trainingConstraintRepository) ListByUser(ctx context.Context, userID int64, activeOn *time.Time) ([]*model.TrainingConstraint, error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if activeOn != nil {
+		query = query.Where("expires_on IS NULL OR expires_on >= ?", activeOn.Format("2006-01-02"))
+	}
+
+	var constraints []*model.TrainingConstraint
+	if err := query.Order("starts_on DESC, id DESC").Find(&constraints).Error; err != nil {
+		return nil, err
+	}
+	return constraints, nil
+}
+
+// Update updates a training constraint
+func (r *trainingConstraintRepository) Update(ctx context.Context, constraint *model.TrainingConstraint) error {
+	if err := r.db.WithContext(ctx).Save(constraint).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// Delete deletes a training constraint
+func (r *trainingConstraintRepository) Delete(ctx context.Context, id int64) error {
+	if err := r.db.WithContext(ctx).Delete(&model.TrainingConstraint{}, id).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -7,18 +7,68 @@ import (
 
 	"github.com/ai-fitness-planner/backend/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrPlanVersionChanged is returned when a plan's data was replaced by
+// another version after it was read
+var ErrPlanVersionChanged = errors.New("training plan version changed")
+
 // TrainingPlanRepository defines the interface for training plan operations
 type TrainingPlanRepository interface {
 	Create(ctx context.Context, plan *model.TrainingPlan) error
 	GetByID(ctx context.Context, id int64) (*model.TrainingPlan, error)
+	// GetSummaryByID is GetByID leaving out the plan data, which can run to
+	// hundreds of kilobytes
+	GetSummaryByID(ctx context.Context, id int64) (*model.TrainingPlan, error)
 	ListByUser(ctx context.Context, userID int64, status string) ([]*model.TrainingPlan, error)
+	// ListSummariesByUser is ListByUser leaving out the plan data, for
+	// listings that only show the plans' details
+	ListSummariesByUser(ctx context.Context, userID int64, status string) ([]*model.TrainingPlan, error)
 	Update(ctx context.Context, plan *model.TrainingPlan) error
-	Delete(ctx context.Context, id int64) error
+	// Delete removes a plan and detaches its training records, or deletes
+	// them too when deleteRecords is set
+	Delete(ctx context.Context, id int64, deleteRecords bool) error
 	GetTodaySchedule(ctx context.Context, userID int64, date time.Time) (*model.DayPlan, error)
+	// GetActiveOn returns the active plan GetTodaySchedule reads for a
+	// date, without its plan data, nil if there is none
+	GetActiveOn(ctx context.Context, userID int64, date time.Time) (*model.TrainingPlan, error)
+	// ListActiveUserIDs returns the enabled users with an active plan
+	// covering date
+	ListActiveUserIDs(ctx context.Context, date time.Time) ([]int64, error)
+	// SaveDayCompletion marks a plan day as completed, replacing an earlier
+	// completion of the same day
+	SaveDayCompletion(ctx context.Context, completion *model.PlanDayCompletion) error
+	// GetDayCompletion returns the completion of a plan day, nil if the day
+	// has not been completed
+	GetDayCompletion(ctx context.Context, planID int64, date time.Time) (*model.PlanDayCompletion, error)
+	// ListDayCompletions returns the completions of a plan's days dated in
+	// [start, end]
+	ListDayCompletions(ctx context.Context, planID int64, start, end time.Time) ([]*model.PlanDayCompletion, error)
+	// ListBlocks returns a macrocycle's plans in block order, leaving out
+	// versions superseded by an adjustment
+	ListBlocks(ctx context.Context, macrocycleID int64) ([]*model.TrainingPlan, error)
+	// ListRolloverDue returns active plans that ended in [since, before)
+	// and have not been rolled over, for users who opted in to rollover.
+	// Macrocycle blocks are left out; the macrocycle plans their successors.
+	ListRolloverDue(ctx context.Context, since, before time.Time) ([]*model.TrainingPlan, error)
+	// MarkRolledOver records that a plan's next block was queued, reporting
+	// false when another run already did
+	MarkRolledOver(ctx context.Context, planID int64, at time.Time) (bool, error)
+	// SaveVersion stores plan's data as plan.Version and keeps previous, the
+	// data it replaces, as history. It returns ErrPlanVersionChanged when
+	// the stored plan is no longer at previous.Version.
+	SaveVersion(ctx context.Context, plan *model.TrainingPlan, previous *model.TrainingPlanVersion) error
+	// ListVersions returns a plan's earlier versions, newest first, leaving
+	// out their plan data
+	ListVersions(ctx context.Context, planID int64) ([]*model.TrainingPlanVersion, error)
+	// GetVersion returns an earlier version of a plan, nil if it has none
+	GetVersion(ctx context.Context, planID int64, version int) (*model.TrainingPlanVersion, error)
 }
 
+// planDataColumn holds a plan's full schedule, left out of summaries
+const planDataColumn = "plan_data"
+
 // trainingPlanRepository implements TrainingPlanRepository interface
 type trainingPlanRepository struct {
 	db *gorm.DB
@@ -31,6 +81,11 @@ func NewTrainingPlanRepository(db *gorm.DB) TrainingPlanRepository {
 
 // Create creates a new training plan
 func (r *trainingPlanRepository) Create(ctx context.Context, plan *model.TrainingPlan) error {
+	// Set the first version here rather than leave it to the column default,
+	// so a later Save of the same struct does not write 0
+	if plan.Version == 0 {
+		plan.Version = 1
+	}
 	if err := r.db.WithContext(ctx).Create(plan).Error; err != nil {
 		return err
 	}
@@ -39,8 +94,17 @@ func (r *trainingPlanRepository) Create(ctx context.Context, plan *model.Trainin
 
 // GetByID retrieves a training plan by ID
 func (r *trainingPlanRepository) GetByID(ctx context.Context, id int64) (*model.TrainingPlan, error) {
+	return r.getByID(r.db.WithContext(ctx), id)
+}
+
+// GetSummaryByID retrieves a training plan by ID without its plan data
+func (r *trainingPlanRepository) GetSummaryByID(ctx context.Context, id int64) (*model.TrainingPlan, error) {
+	return r.getByID(r.db.WithContext(ctx).Omit(planDataColumn), id)
+}
+
+func (r *trainingPlanRepository) getByID(db *gorm.DB, id int64) (*model.TrainingPlan, error) {
 	var plan model.TrainingPlan
-	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&plan).Error; err != nil {
+	if err := db.Where("id = ?", id).First(&plan).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -51,8 +115,18 @@ func (r *trainingPlanRepository) GetByID(ctx context.Context, id int64) (*model.
 
 // ListByUser retrieves all training plans for a user, optionally filtered by status
 func (r *trainingPlanRepository) ListByUser(ctx context.Context, userID int64, status string) ([]*model.TrainingPlan, error) {
+	return r.listByUser(r.db.WithContext(ctx), userID, status)
+}
+
+// ListSummariesByUser retrieves all training plans for a user without their
+// plan data, optionally filtered by status
+func (r *trainingPlanRepository) ListSummariesByUser(ctx context.Context, userID int64, status string) ([]*model.TrainingPlan, error) {
+	return r.listByUser(r.db.WithContext(ctx).Omit(planDataColumn), userID, status)
+}
+
+func (r *trainingPlanRepository) listByUser(db *gorm.DB, userID int64, status string) ([]*model.TrainingPlan, error) {
 	var plans []*model.TrainingPlan
-	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	query := db.Where("user_id = ?", userID)
 
 	if status != "" {
 		query = query.Where("status = ?", status)
@@ -72,12 +146,95 @@ func (r *trainingPlanRepository) Update(ctx context.Context, plan *model.Trainin
 	return nil
 }
 
-// Delete deletes a training plan
-func (r *trainingPlanRepository) Delete(ctx context.Context, id int64) error {
-	if err := r.db.WithContext(ctx).Delete(&model.TrainingPlan{}, id).Error; err != nil {
-		return err
+// ListBlocks retrieves the current plan of each block in a macrocycle
+func (r *trainingPlanRepository) ListBlocks(ctx context.Context, macrocycleID int64) ([]*model.TrainingPlan, error) {
+	var plans []*model.TrainingPlan
+	if err := r.db.WithContext(ctx).
+		Where("macrocycle_id = ? AND status <> ?", macrocycleID, "inactive").
+		Order("block_number ASC, id ASC").
+		Find(&plans).Error; err != nil {
+		return nil, err
 	}
-	return nil
+	return plans, nil
+}
+
+// ListRolloverDue retrieves ended plans awaiting an automatic rollover
+func (r *trainingPlanRepository) ListRolloverDue(ctx context.Context, since, before time.Time) ([]*model.TrainingPlan, error) {
+	var plans []*model.TrainingPlan
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN users ON users.id = training_plans.user_id").
+		Where("users.auto_rollover = ? AND users.status = ?", true, 1).
+		Where("training_plans.status = ? AND training_plans.rolled_over_at IS NULL AND training_plans.macrocycle_id IS NULL", "active").
+		Where("training_plans.end_date >= ? AND training_plans.end_date < ?", since.Format("2006-01-02"), before.Format("2006-01-02")).
+		Order("training_plans.end_date ASC, training_plans.id ASC").
+		Find(&plans).Error; err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// GetActiveOn finds the plan with the same conditions as GetTodaySchedule,
+// leaving out the plan data
+func (r *trainingPlanRepository) GetActiveOn(ctx context.Context, userID int64, date time.Time) (*model.TrainingPlan, error) {
+	var plan model.TrainingPlan
+	if err := r.db.WithContext(ctx).Omit(planDataColumn).
+		Where("user_id = ? AND status = ? AND start_date <= ? AND end_date >= ?",
+			userID, "active", date, date).
+		First(&plan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// ListActiveUserIDs returns the IDs of enabled users with an active plan
+// covering date
+func (r *trainingPlanRepository) ListActiveUserIDs(ctx context.Context, date time.Time) ([]int64, error) {
+	var userIDs []int64
+	if err := r.db.WithContext(ctx).Model(&model.TrainingPlan{}).
+		Joins("JOIN users ON users.id = training_plans.user_id").
+		Where("users.status = ?", 1).
+		Where("training_plans.status = ? AND training_plans.start_date <= ? AND training_plans.end_date >= ?", "active", date, date).
+		Distinct().
+		Order("training_plans.user_id ASC").
+		Pluck("training_plans.user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// MarkRolledOver sets rolled_over_at unless it is already set
+func (r *trainingPlanRepository) MarkRolledOver(ctx context.Context, planID int64, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.TrainingPlan{}).
+		Where("id = ? AND rolled_over_at IS NULL", planID).
+		Update("rolled_over_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Delete deletes a training plan in one transaction with the handling of
+// its records. Records are updated explicitly rather than left to the
+// foreign key, so that updated_at moves and sync clients pick the change
+// up; deleted records become sync tombstones.
+func (r *trainingPlanRepository) Delete(ctx context.Context, id int64, deleteRecords bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		records := tx.Model(&model.TrainingRecord{}).Where("plan_id = ?", id)
+		var err error
+		if deleteRecords {
+			err = records.Updates(map[string]interface{}{"deleted_at": now, "updated_at": now}).Error
+		} else {
+			err = records.Unscoped().Updates(map[string]interface{}{"plan_id": nil, "updated_at": now}).Error
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&model.TrainingPlan{}, id).Error
+	})
 }
 
 // GetTodaySchedule retrieves the training schedule for a specific date
@@ -133,63 +290,8 @@ func (r *trainingPlanRepository) GetTodaySchedule(ctx context.Context, userID in
 			}
 
 			if dayDate == dateStr {
-				// Convert map to DayPlan struct
-				dayPlan := &model.DayPlan{}
-
-				if day, ok := dayMap["day"].(float64); ok {
-					dayPlan.Day = int(day)
-				}
-				dayPlan.Date = dayDate
-				if typ, ok := dayMap["type"].(string); ok {
-					dayPlan.Type = typ
-				}
-				if focus, ok := dayMap["focus_area"].(string); ok {
-					dayPlan.FocusArea = focus
-				}
-				if duration, ok := dayMap["duration"].(float64); ok {
-					dayPlan.Duration = int(duration)
-				}
-				if calories, ok := dayMap["estimated_calories"].(float64); ok {
-					dayPlan.EstimatedCalories = int(calories)
-				}
-
-				// Parse exercises
-				if exercisesInterface, ok := dayMap["exercises"].([]interface{}); ok {
-					exercises := make([]model.Exercise, 0, len(exercisesInterface))
-					for _, exInterface := range exercisesInterface {
-						exMap, ok := exInterface.(map[string]interface{})
-						if !ok {
-							continue
-						}
-
-						exercise := model.Exercise{}
-						if name, ok := exMap["name"].(string); ok {
-							exercise.Name = name
-						}
-						if sets, ok := exMap["sets"].(float64); ok {
-							exercise.Sets = int(sets)
-						}
-						if reps, ok := exMap["reps"].(string); ok {
-							exercise.Reps = reps
-						}
-						if weight, ok := exMap["weight"].(string); ok {
-							exercise.Weight = weight
-						}
-						if rest, ok := exMap["rest"].(string); ok {
-							exercise.Rest = rest
-						}
-						if difficulty, ok := exMap["difficulty"].(string); ok {
-							exercise.Difficulty = difficulty
-						}
-						if safety, ok := exMap["safety_notes"].(string); ok {
-							exercise.SafetyNotes = safety
-						}
-
-						exercises = append(exercises, exercise)
-					}
-					dayPlan.Exercises = exercises
-				}
-
+				dayPlan := model.DayPlanFromData(dayMap)
+				dayPlan.PlanID = plan.ID
 				return dayPlan, nil
 			}
 		}
@@ -197,3 +299,87 @@ func (r *trainingPlanRepository) GetTodaySchedule(ctx context.Context, userID in
 
 	return nil, nil
 }
+
+// SaveDayCompletion inserts the completion or updates the one already
+// recorded for its plan and date
+func (r *trainingPlanRepository) SaveDayCompletion(ctx context.Context, completion *model.PlanDayCompletion) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "plan_id"}, {Name: "plan_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"record_id", "completed_at"}),
+	}).Create(completion).Error
+}
+
+// GetDayCompletion retrieves the completion of a plan day
+func (r *trainingPlanRepository) GetDayCompletion(ctx context.Context, planID int64, date time.Time) (*model.PlanDayCompletion, error) {
+	var completion model.PlanDayCompletion
+	if err := r.db.WithContext(ctx).
+		Where("plan_id = ? AND plan_date = ?", planID, date.Format("2006-01-02")).
+		First(&completion).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &completion, nil
+}
+
+// ListDayCompletions retrieves the completions of a plan's days in a date range
+func (r *trainingPlanRepository) ListDayCompletions(ctx context.Context, planID int64, start, end time.Time) ([]*model.PlanDayCompletion, error) {
+	var completions []*model.PlanDayCompletion
+	if err := r.db.WithContext(ctx).
+		Where("plan_id = ? AND plan_date >= ? AND plan_date <= ?", planID, start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Order("plan_date ASC").
+		Find(&completions).Error; err != nil {
+		return nil, err
+	}
+	return completions, nil
+}
+
+// SaveVersion replaces the plan data if the stored plan is still at the
+// previous version, and records the previous version in the same transaction
+func (r *trainingPlanRepository) SaveVersion(ctx context.Context, plan *model.TrainingPlan, previous *model.TrainingPlanVersion) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		plan.UpdatedAt = time.Now()
+		result := tx.Model(&model.TrainingPlan{}).
+			Where("id = ? AND version = ?", plan.ID, previous.Version).
+			Updates(map[string]interface{}{
+				"plan_data":  plan.PlanData,
+				"version":    plan.Version,
+				"updated_at": plan.UpdatedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPlanVersionChanged
+		}
+		return tx.Create(previous).Error
+	})
+}
+
+// ListVersions retrieves the earlier versions of a plan without their data
+func (r *trainingPlanRepository) ListVersions(ctx context.Context, planID int64) ([]*model.TrainingPlanVersion, error) {
+	var versions []*model.TrainingPlanVersion
+	if err := r.db.WithContext(ctx).
+		Omit(planDataColumn).
+		Where("plan_id = ?", planID).
+		Order("version DESC").
+		Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetVersion retrieves an earlier version of a plan
+func (r *trainingPlanRepository) GetVersion(ctx context.Context, planID int64, version int) (*model.TrainingPlanVersion, error) {
+	var v model.TrainingPlanVersion
+	if err := r.db.WithContext(ctx).
+		Where("plan_id = ? AND version = ?", planID, version).
+		First(&v).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -13,17 +14,30 @@ import (
 type TrainingRecordRepository interface {
 	Create(ctx context.Context, record *model.TrainingRecord) error
 	GetByID(ctx context.Context, id int64) (*model.TrainingRecord, error)
+	GetByIdempotencyKey(ctx context.Context, userID int64, key string) (*model.TrainingRecord, error)
+	// GetByClientID includes deleted records so sync can see tombstones
+	GetByClientID(ctx context.Context, userID int64, clientID string) (*model.TrainingRecord, error)
+	// ListChangedSince returns records, deleted ones included, updated at or
+	// after since (all records when since is nil), oldest change first
+	ListChangedSince(ctx context.Context, userID int64, since *time.Time) ([]*model.TrainingRecord, error)
+	// SaveSynced creates or updates a record from sync, including its tombstone
+	SaveSynced(ctx context.Context, record *model.TrainingRecord) error
+	// FindDuplicate returns the latest record with the same date and type whose
+	// duration is within toleranceMinutes of durationMinutes (nil matches nil)
+	FindDuplicate(ctx context.Context, userID int64, workoutDate time.Time, workoutType string, durationMinutes *int, toleranceMinutes int) (*model.TrainingRecord, error)
 	ListByUser(ctx context.Context, userID int64, startDate, endDate *time.Time) ([]*model.TrainingRecord, error)
+	CountByUser(ctx context.Context, userID int64, startDate, endDate *time.Time) (int64, error)
 	GetStatistics(ctx context.Context, userID int64, startDate, endDate time.Time) (*TrainingStatistics, error)
 }
 
 // TrainingStatistics represents aggregated training statistics
 type TrainingStatistics struct {
-	TotalWorkouts  int64
-	TotalDuration  int64
-	TotalCalories  int64
-	AverageRating  float64
-	WorkoutsByType map[string]int64
+	TotalWorkouts   int64
+	TotalDuration   int64
+	TotalCalories   int64
+	AverageRating   float64
+	WorkoutsByType  map[string]int64
+	FlaggedWorkouts int64
 }
 
 // trainingRecordRepository implements TrainingRecordRepository interface
@@ -42,6 +56,9 @@ func (r *trainingRecordRepository) Create(ctx context.Context, record *model.Tra
 	if record.WorkoutDate.After(time.Now()) {
 		return errors.New("workout date cannot be in the future")
 	}
+	if record.ClientID == "" {
+		record.ClientID = uuid.New().String()
+	}
 
 	if err := r.db.WithContext(ctx).Create(record).Error; err != nil {
 		return err
@@ -61,6 +78,76 @@ func (r *trainingRecordRepository) GetByID(ctx context.Context, id int64) (*mode
 	return &record, nil
 }
 
+// GetByIdempotencyKey retrieves the record a user created with an idempotency key
+func (r *trainingRecordRepository) GetByIdempotencyKey(ctx context.Context, userID int64, key string) (*model.TrainingRecord, error) {
+	var record model.TrainingRecord
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND idempotency_key = ?", userID, key).
+		First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// GetByClientID retrieves a record by its client-generated UUID
+func (r *trainingRecordRepository) GetByClientID(ctx context.Context, userID int64, clientID string) (*model.TrainingRecord, error) {
+	var record model.TrainingRecord
+	if err := r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ? AND client_id = ?", userID, clientID).
+		First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// ListChangedSince retrieves records changed since a sync cursor
+func (r *trainingRecordRepository) ListChangedSince(ctx context.Context, userID int64, since *time.Time) ([]*model.TrainingRecord, error) {
+	var records []*model.TrainingRecord
+	query := r.db.WithContext(ctx).Unscoped().Where("user_id = ?", userID)
+	if since != nil {
+		query = query.Where("updated_at >= ?", *since)
+	}
+	if err := query.Order("updated_at ASC, id ASC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// SaveSynced saves a record pushed by a sync client
+func (r *trainingRecordRepository) SaveSynced(ctx context.Context, record *model.TrainingRecord) error {
+	if err := r.db.WithContext(ctx).Unscoped().Save(record).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// FindDuplicate looks for an existing record that matches a new submission
+func (r *trainingRecordRepository) FindDuplicate(ctx context.Context, userID int64, workoutDate time.Time, workoutType string, durationMinutes *int, toleranceMinutes int) (*model.TrainingRecord, error) {
+	query := r.db.WithContext(ctx).
+		Where("user_id = ? AND workout_date = ? AND workout_type = ?", userID, workoutDate.Format("2006-01-02"), workoutType)
+
+	if durationMinutes == nil {
+		query = query.Where("duration_minutes IS NULL")
+	} else {
+		query = query.Where("duration_minutes BETWEEN ? AND ?", *durationMinutes-toleranceMinutes, *durationMinutes+toleranceMinutes)
+	}
+
+	var record model.TrainingRecord
+	if err := query.Order("id DESC").First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
 // ListByUser retrieves training records for a user within an optional date range
 func (r *trainingRecordRepository) ListByUser(ctx context.Context, userID int64, startDate, endDate *time.Time) ([]*model.TrainingRecord, error) {
 	var records []*model.TrainingRecord
@@ -80,6 +167,25 @@ func (r *trainingRecordRepository) ListByUser(ctx context.Context, userID int64,
 	return records, nil
 }
 
+// CountByUser counts records for a user within an optional date range
+func (r *trainingRecordRepository) CountByUser(ctx context.Context, userID int64, startDate, endDate *time.Time) (int64, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&model.TrainingRecord{}).Where("user_id = ?", userID)
+
+	if startDate != nil {
+		query = query.Where("workout_date >= ?", *startDate)
+	}
+
+	if endDate != nil {
+		query = query.Where("workout_date <= ?", *endDate)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 // GetStatistics calculates aggregated statistics for a user's training records
 func (r *trainingRecordRepository) GetStatistics(ctx context.Context, userID int64, startDate, endDate time.Time) (*TrainingStatistics, error) {
 	stats := &TrainingStatistics{
@@ -112,6 +218,14 @@ func (r *trainingRecordRepository) GetStatistics(ctx context.Context, userID int
 	stats.TotalDuration = result.TotalDuration
 	stats.AverageRating = result.AvgRating
 
+	// Count records with a flagged duration
+	if err := r.db.WithContext(ctx).
+		Model(&model.TrainingRecord{}).
+		Where("user_id = ? AND workout_date >= ? AND workout_date <= ? AND duration_flag IS NOT NULL", userID, startDate, endDate).
+		Count(&stats.FlaggedWorkouts).Error; err != nil {
+		return nil, err
+	}
+
 	// Calculate total calories from performance_data JSON field
 	var records []*model.TrainingRecord
 	if err := r.db.WithContext(ctx).
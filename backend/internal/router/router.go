@@ -1,7 +1,11 @@
 package router
 
 import (
+	"net/http"
+
+	"github.com/ai-fitness-planner/backend/internal/api/response"
 	"github.com/ai-fitness-planner/backend/internal/config"
+	"github.com/ai-fitness-planner/backend/internal/errors"
 	"github.com/ai-fitness-planner/backend/internal/handler"
 	"github.com/ai-fitness-planner/backend/internal/middleware"
 	"github.com/ai-fitness-planner/backend/internal/pkg/jwt"
@@ -24,15 +28,40 @@ type Dependencies struct {
 	RateLimiter    *middleware.RateLimiter
 
 	// Services
-	AuthService       service.AuthService
-	UserService       service.UserService
-	AIAPIService      service.AIAPIService
-	TrainingService   service.TrainingService
-	NutritionService  service.NutritionService
-	StatisticsService service.StatisticsService
+	AuthService               service.AuthService
+	UserService               service.UserService
+	AIAPIService              service.AIAPIService
+	TrainingService           service.TrainingService
+	NutritionService          service.NutritionService
+	StatisticsService         service.StatisticsService
+	AdminService              service.AdminService
+	ExportService             service.ExportService
+	CleanupService            service.CleanupService
+	NotificationService       service.NotificationService
+	CheckInService            service.CheckInService
+	StrengthService           service.StrengthProfileService
+	EquipmentService          service.EquipmentService
+	TrainingConstraintService service.TrainingConstraintService
+	SyncService               service.SyncService
+	ProvisioningService       service.ProvisioningService
+	RuntimeService            service.RuntimeService
+	IntegrityService          service.IntegrityService
+	PromptTemplateService     service.PromptTemplateService
+	CoachService              service.CoachService
+	MacrocycleService         service.MacrocycleService
+	PlanShareService          service.PlanShareService
+	TaskOpsService            service.TaskOpsService
+	ResponseArchiveService    service.ResponseArchiveService
 
 	// Repositories
-	AssessmentRepo repository.AssessmentRepository
+	AssessmentRepo             repository.AssessmentRepository
+	UserRepo                   repository.UserRepository
+	ImpersonationAuditRepo     repository.ImpersonationAuditRepository
+	ServiceAuditRepo           repository.ServiceAuditRepository
+	ServiceTokenRevocationRepo repository.ServiceTokenRevocationRepository
+
+	// Metrics
+	ParseFailureMetrics service.ParseFailureMetrics
 }
 
 // SetupRouter configures and returns the Gin router with all routes and middleware
@@ -62,15 +91,24 @@ func SetupRouter(deps *Dependencies) *gin.Engine {
 	// 4. Security - input sanitization and security headers
 	router.Use(middleware.SecurityMiddleware(nil))
 
-	// Health check endpoint (no authentication required)
-	healthHandler := handler.NewHealthHandler()
-	router.GET("/health", healthHandler.HealthCheck)
+	// 5. Warnings - collect non-fatal warnings for the response envelope
+	router.Use(middleware.WarningMiddleware())
 
-	// Swagger documentation endpoint
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	// Health check, metrics scrape and Swagger documentation endpoints (no
+	// user authentication; guarded in release mode)
+	system := router.Group("", systemEndpointGuards()...)
+	healthHandler := handler.NewHealthHandler()
+	system.GET("/health", healthHandler.HealthCheck)
+	metricsHandler := handler.NewMetricsHandler(deps.ParseFailureMetrics)
+	system.GET("/metrics", metricsHandler.Metrics)
+	if config.GlobalConfig.SystemEndpoints.SwaggerEnabled {
+		system.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	}
 
-	// API v1 routes
+	// API v1 routes; every request is bounded by the default timeout unless
+	// its route sets its own
 	v1 := router.Group("/api/v1")
+	v1.Use(middleware.TimeoutMiddleware(config.GlobalConfig.Timeout.Default))
 	{
 		// Public routes (no authentication required)
 		setupPublicRoutes(v1, deps)
@@ -82,15 +120,66 @@ func SetupRouter(deps *Dependencies) *gin.Engine {
 	return router
 }
 
+// SetupDegradedRouter returns a minimal router used while required dependencies
+// are unavailable: /health reports which dependency is down and every other
+// route answers 503 until the full router is swapped in.
+func SetupDegradedRouter() *gin.Engine {
+	if config.GlobalConfig.App.Mode == "release" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.New()
+	router.Use(middleware.RecoveryMiddleware(nil))
+	router.Use(middleware.LoggingMiddleware(nil))
+	router.Use(middleware.CORSMiddleware(middleware.DefaultCORSConfig()))
+
+	healthHandler := handler.NewHealthHandler()
+	router.GET("/health", append(systemEndpointGuards(), healthHandler.HealthCheck)...)
+
+	router.NoRoute(func(c *gin.Context) {
+		c.Header("Retry-After", "5")
+		c.JSON(http.StatusServiceUnavailable, response.Error(errors.ErrServiceUnavailable, "服务依赖暂不可用，请稍后重试"))
+	})
+
+	return router
+}
+
+// systemEndpointGuards returns the middleware guarding /health, /metrics and
+// /swagger in release mode: a per-IP throttle, then basic auth when credentials are
+// configured. Other modes leave them open for local tools.
+func systemEndpointGuards() []gin.HandlerFunc {
+	if config.GlobalConfig.App.Mode != "release" {
+		return nil
+	}
+
+	cfg := config.GlobalConfig.SystemEndpoints
+	guards := []gin.HandlerFunc{middleware.NewIPThrottle(cfg.RequestsPerMinute).Middleware()}
+	if cfg.BasicAuthUsername != "" {
+		guards = append(guards, middleware.BasicAuthMiddleware(cfg.BasicAuthUsername, cfg.BasicAuthPassword))
+	}
+	return guards
+}
+
 // setupPublicRoutes configures public API routes (no authentication)
 func setupPublicRoutes(rg *gin.RouterGroup, deps *Dependencies) {
 	authHandler := handler.NewAuthHandler(deps.AuthService)
+	organizationHandler := handler.NewOrganizationHandler(deps.ProvisioningService)
+	planShareHandler := handler.NewPlanShareHandler(deps.PlanShareService)
 
 	auth := rg.Group("/auth")
 	{
 		auth.POST("/register", authHandler.Register)
 		auth.POST("/login", authHandler.Login)
 		auth.POST("/refresh", authHandler.RefreshToken)
+		auth.POST("/accept-invitation", organizationHandler.AcceptInvitation)
+	}
+
+	// Shared plan links are opened without an account, so only the
+	// per-IP limit applies
+	shared := rg.Group("/shared")
+	shared.Use(deps.RateLimiter.RateLimitMiddleware())
+	{
+		shared.GET("/training-plans/:token", planShareHandler.GetSharedPlan)
 	}
 }
 
@@ -98,8 +187,10 @@ func setupPublicRoutes(rg *gin.RouterGroup, deps *Dependencies) {
 func setupProtectedRoutes(rg *gin.RouterGroup, deps *Dependencies) {
 	// Create protected group with authentication and rate limiting
 	protected := rg.Group("")
-	protected.Use(middleware.AuthMiddleware(deps.JWTManager, deps.SessionManager))
+	protected.Use(middleware.AuthMiddleware(deps.JWTManager, deps.SessionManager, deps.ServiceTokenRevocationRepo))
 	protected.Use(deps.RateLimiter.RateLimitMiddleware())
+	protected.Use(middleware.ImpersonationAuditMiddleware(deps.ImpersonationAuditRepo))
+	protected.Use(middleware.ServiceAuditMiddleware(deps.ServiceAuditRepo))
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(deps.AuthService)
@@ -109,6 +200,30 @@ func setupProtectedRoutes(rg *gin.RouterGroup, deps *Dependencies) {
 	trainingHandler := handler.NewTrainingHandler(deps.TrainingService)
 	nutritionHandler := handler.NewNutritionHandler(deps.NutritionService)
 	statisticsHandler := handler.NewStatisticsHandler(deps.StatisticsService)
+	adminHandler := handler.NewAdminHandler(deps.AdminService)
+	exportHandler := handler.NewExportHandler(deps.ExportService)
+	cleanupHandler := handler.NewCleanupHandler(deps.CleanupService)
+	notificationHandler := handler.NewNotificationHandler(deps.NotificationService)
+	checkInHandler := handler.NewCheckInHandler(deps.CheckInService)
+	strengthHandler := handler.NewStrengthHandler(deps.StrengthService)
+	equipmentHandler := handler.NewEquipmentHandler(deps.EquipmentService)
+	constraintHandler := handler.NewTrainingConstraintHandler(deps.TrainingConstraintService)
+	syncHandler := handler.NewSyncHandler(deps.SyncService)
+	organizationHandler := handler.NewOrganizationHandler(deps.ProvisioningService)
+	metaHandler := handler.NewMetaHandler(deps.RuntimeService)
+	integrityHandler := handler.NewIntegrityHandler(deps.IntegrityService)
+	taskOpsHandler := handler.NewTaskOpsHandler(deps.TaskOpsService)
+	responseArchiveHandler := handler.NewResponseArchiveHandler(deps.ResponseArchiveService)
+	promptTemplateHandler := handler.NewPromptTemplateHandler(deps.PromptTemplateService)
+	coachHandler := handler.NewCoachHandler(deps.CoachService)
+	macrocycleHandler := handler.NewMacrocycleHandler(deps.MacrocycleService)
+	planShareHandler := handler.NewPlanShareHandler(deps.PlanShareService)
+
+	// Route timeouts replacing the default: requests that wait on an AI
+	// provider or work through many rows get longer, streams none
+	aiTimeout := middleware.TimeoutMiddleware(config.GlobalConfig.Timeout.AI)
+	bulkTimeout := middleware.TimeoutMiddleware(config.GlobalConfig.Timeout.Bulk)
+	noTimeout := middleware.TimeoutMiddleware(0)
 
 	// Auth routes (logout requires authentication)
 	{
@@ -132,11 +247,14 @@ func setupProtectedRoutes(rg *gin.RouterGroup, deps *Dependencies) {
 	{
 		aiAPIs.POST("", aiAPIHandler.AddAPI)
 		aiAPIs.GET("", aiAPIHandler.ListAPIs)
+		aiAPIs.PUT("/fallback-order", aiAPIHandler.SetFallbackOrder)
+		aiAPIs.GET("/call-logs", aiAPIHandler.ListCallLogs)
 		aiAPIs.GET("/:id", aiAPIHandler.GetAPI)
 		aiAPIs.PUT("/:id", aiAPIHandler.UpdateAPI)
 		aiAPIs.DELETE("/:id", aiAPIHandler.DeleteAPI)
-		aiAPIs.POST("/:id/test", aiAPIHandler.TestAPI)
+		aiAPIs.POST("/:id/test", aiTimeout, middleware.DenyImpersonationMiddleware(), aiAPIHandler.TestAPI)
 		aiAPIs.POST("/:id/set-default", aiAPIHandler.SetDefault)
+		aiAPIs.GET("/:id/usage", aiAPIHandler.GetUsage)
 	}
 
 	// Assessment routes
@@ -151,13 +269,38 @@ func setupProtectedRoutes(rg *gin.RouterGroup, deps *Dependencies) {
 	{
 		// AI generation endpoint with stricter rate limit
 		generation := trainingPlans.Group("")
+		generation.Use(middleware.DenyImpersonationMiddleware())
 		generation.Use(deps.RateLimiter.AIGenerationRateLimitMiddleware())
 		generation.POST("/generate", trainingHandler.GeneratePlan)
+		generation.POST("/:id/adjust", trainingHandler.AdjustPlan)
+		generation.POST("/:id/weeks/:n/regenerate", aiTimeout, trainingHandler.RegenerateWeek)
+		generation.POST("/:id/days/:date/exercises/:index/substitute", aiTimeout, trainingHandler.SubstituteExercise)
 
 		// Regular endpoints
+		trainingPlans.GET("/tasks", trainingHandler.ListTasks)
+		trainingPlans.GET("/tasks/history", trainingHandler.ListTaskHistory)
 		trainingPlans.GET("/tasks/:taskId", trainingHandler.GetPlanStatus)
+		trainingPlans.GET("/tasks/:taskId/stream", noTimeout, trainingHandler.StreamPlanStatus)
+		// A retry reruns a submitted generation, so it is not rate limited again
+		trainingPlans.POST("/tasks/:taskId/retry", middleware.DenyImpersonationMiddleware(), trainingHandler.RetryTask)
 		trainingPlans.GET("", trainingHandler.ListPlans)
+		trainingPlans.GET("/compare", trainingHandler.ComparePlans)
 		trainingPlans.GET("/:id", trainingHandler.GetPlanDetail)
+		trainingPlans.PUT("/:id", trainingHandler.UpdatePlan)
+		trainingPlans.DELETE("/:id", trainingHandler.DeletePlan)
+		trainingPlans.POST("/:id/clone", trainingHandler.ClonePlan)
+		trainingPlans.POST("/:id/share", planShareHandler.SharePlan)
+		trainingPlans.POST("/:id/pause", trainingHandler.PausePlan)
+		trainingPlans.POST("/:id/resume", trainingHandler.ResumePlan)
+		trainingPlans.POST("/:id/complete", trainingHandler.CompletePlan)
+		trainingPlans.GET("/:id/progression", trainingHandler.AuditPlanProgression)
+		trainingPlans.GET("/:id/weeks/:n/summary", trainingHandler.GetWeekSummary)
+		trainingPlans.GET("/:id/versions", trainingHandler.ListPlanVersions)
+		trainingPlans.GET("/:id/versions/:version", trainingHandler.GetPlanVersion)
+		trainingPlans.GET("/:id/schedule", trainingHandler.GetSchedule)
+		trainingPlans.GET("/:id/export.ics", bulkTimeout, trainingHandler.ExportCalendar)
+		trainingPlans.GET("/:id/export.pdf", bulkTimeout, trainingHandler.ExportPDF)
+		trainingPlans.POST("/:id/days/:date/complete", trainingHandler.CompletePlanDay)
 		trainingPlans.GET("/today", trainingHandler.GetTodayTraining)
 	}
 
@@ -168,21 +311,51 @@ func setupProtectedRoutes(rg *gin.RouterGroup, deps *Dependencies) {
 		trainingRecords.GET("", trainingHandler.ListTrainingRecords)
 	}
 
+	// Macrocycle routes; generating a block is an AI generation
+	macrocycles := protected.Group("/macrocycles")
+	{
+		generation := macrocycles.Group("")
+		generation.Use(middleware.DenyImpersonationMiddleware())
+		generation.Use(deps.RateLimiter.AIGenerationRateLimitMiddleware())
+		generation.POST("/:id/next-block", macrocycleHandler.GenerateNextBlock)
+
+		macrocycles.POST("", macrocycleHandler.CreateMacrocycle)
+		macrocycles.GET("", macrocycleHandler.ListMacrocycles)
+		macrocycles.GET("/:id", macrocycleHandler.GetMacrocycle)
+	}
+
 	// Nutrition plan routes (with stricter rate limiting for generation)
 	nutritionPlans := protected.Group("/nutrition-plans")
 	{
 		// AI generation endpoint with stricter rate limit
 		generation := nutritionPlans.Group("")
+		generation.Use(middleware.DenyImpersonationMiddleware())
 		generation.Use(deps.RateLimiter.AIGenerationRateLimitMiddleware())
 		generation.POST("/generate", nutritionHandler.GeneratePlan)
+		generation.POST("/:id/adjust", nutritionHandler.AdjustPlan)
+		generation.POST("/:id/days/:date/regenerate", aiTimeout, nutritionHandler.RegenerateDay)
+		nutritionPlans.GET("/tasks", nutritionHandler.ListTasks)
+		nutritionPlans.GET("/tasks/history", nutritionHandler.ListTaskHistory)
 		nutritionPlans.GET("/tasks/:taskId", nutritionHandler.GetPlanStatus)
+		nutritionPlans.GET("/tasks/:taskId/stream", noTimeout, nutritionHandler.StreamPlanStatus)
+		nutritionPlans.POST("/tasks/:taskId/retry", middleware.DenyImpersonationMiddleware(), nutritionHandler.RetryTask)
 
 		// Regular endpoints
 		nutritionPlans.GET("", nutritionHandler.ListPlans)
 		nutritionPlans.GET("/:id", nutritionHandler.GetPlanDetail)
+		nutritionPlans.GET("/:id/export.pdf", bulkTimeout, nutritionHandler.ExportPDF)
+		nutritionPlans.GET("/:id/weeks/:n/shopping-list", nutritionHandler.GetShoppingList)
 		nutritionPlans.GET("/today", nutritionHandler.GetTodayMeals)
 	}
 
+	// AI coach routes; asking counts against the AI generation limits
+	coach := protected.Group("/coach")
+	{
+		coach.POST("/chat", aiTimeout, middleware.DenyImpersonationMiddleware(), deps.RateLimiter.AIGenerationRateLimitMiddleware(), coachHandler.Chat)
+		coach.GET("/history", coachHandler.GetHistory)
+		coach.DELETE("/history", coachHandler.ClearHistory)
+	}
+
 	// Nutrition record routes
 	nutritionRecords := protected.Group("/nutrition-records")
 	{
@@ -197,5 +370,132 @@ func setupProtectedRoutes(rg *gin.RouterGroup, deps *Dependencies) {
 		stats.GET("/training", statisticsHandler.GetTrainingStatistics)
 		stats.GET("/progress", statisticsHandler.GetProgressReport)
 		stats.GET("/trends", statisticsHandler.GetTrends)
+		stats.GET("/progression", statisticsHandler.GetProgression)
+		stats.GET("/one-rep-max", statisticsHandler.GetOneRepMax)
+	}
+
+	// Export routes (large exports run on the low-priority job queue)
+	exports := protected.Group("/exports")
+	{
+		exports.GET("/tasks/:taskId", exportHandler.GetExportTask)
+		exports.GET("/tasks/:taskId/download", bulkTimeout, exportHandler.DownloadExport)
+		exports.GET("/:kind", bulkTimeout, exportHandler.Export)
+	}
+
+	// Bulk deletion routes (confirmed deletions run on the export job queue)
+	cleanup := protected.Group("/cleanup")
+	{
+		cleanup.GET("/tasks/:taskId", cleanupHandler.GetCleanupTask)
+		cleanup.POST("/:kind", cleanupHandler.Cleanup)
+	}
+
+	// Weekly check-in routes
+	checkIns := protected.Group("/check-ins")
+	{
+		checkIns.POST("", checkInHandler.SubmitCheckIn)
+		checkIns.GET("", checkInHandler.ListCheckIns)
+		checkIns.GET("/latest", checkInHandler.GetLatestCheckIn)
+	}
+
+	// Strength profile routes
+	strength := protected.Group("/strength-profile")
+	{
+		strength.GET("", strengthHandler.GetStrengthProfile)
+		strength.PUT("/:lift", strengthHandler.SetOneRepMax)
+	}
+
+	// Equipment inventory routes
+	equipment := protected.Group("/equipment-profiles")
+	{
+		equipment.GET("", equipmentHandler.ListEquipmentProfiles)
+		equipment.POST("", equipmentHandler.CreateEquipmentProfile)
+		equipment.PUT("/:id", equipmentHandler.UpdateEquipmentProfile)
+		equipment.DELETE("/:id", equipmentHandler.DeleteEquipmentProfile)
+	}
+
+	// Doctor/physio training constraint routes
+	constraints := protected.Group("/training-constraints")
+	{
+		constraints.GET("", constraintHandler.ListConstraints)
+		constraints.POST("", constraintHandler.CreateConstraint)
+		constraints.PUT("/:id", constraintHandler.UpdateConstraint)
+		constraints.DELETE("/:id", constraintHandler.DeleteConstraint)
+	}
+
+	// Offline sync routes
+	sync := protected.Group("/sync")
+	{
+		sync.POST("", bulkTimeout, syncHandler.Sync)
+	}
+
+	// Notification routes
+	notifications := protected.Group("/notifications")
+	{
+		notifications.GET("", notificationHandler.ListNotifications)
+		notifications.POST("/:id/read", notificationHandler.MarkNotificationRead)
+	}
+
+	// Metadata routes: option lists for clients, and deployment details for
+	// supporting self-hosted installs
+	meta := protected.Group("/meta")
+	{
+		meta.GET("/nutrition-options", metaHandler.GetNutritionOptions)
+		meta.GET("/exercise-options", metaHandler.GetExerciseOptions)
+
+		deployment := meta.Group("")
+		deployment.Use(middleware.DenyServiceTokenMiddleware())
+		deployment.Use(middleware.RequireAdminMiddleware(deps.UserRepo))
+		deployment.GET("/runtime", metaHandler.GetRuntime)
+	}
+
+	// Prompt template routes, for operators tuning generation prompts
+	promptTemplates := protected.Group("/prompt-templates")
+	promptTemplates.Use(middleware.DenyServiceTokenMiddleware())
+	promptTemplates.Use(middleware.RequireAdminMiddleware(deps.UserRepo))
+	{
+		promptTemplates.GET("", promptTemplateHandler.ListTemplates)
+		promptTemplates.POST("", promptTemplateHandler.CreateTemplate)
+		promptTemplates.GET("/:id", promptTemplateHandler.GetTemplate)
+		promptTemplates.PUT("/:id", promptTemplateHandler.UpdateTemplate)
+		promptTemplates.POST("/:id/preview", promptTemplateHandler.PreviewTemplate)
+		promptTemplates.POST("/:id/default", promptTemplateHandler.SetDefaultTemplate)
+		promptTemplates.PUT("/:id/rollout", promptTemplateHandler.SetTemplateRollout)
+		promptTemplates.POST("/:id/promote", promptTemplateHandler.PromoteTemplate)
+	}
+
+	// Admin support routes; a service token acting for an admin is not an
+	// admin session and cannot reach them
+	admin := protected.Group("/admin")
+	admin.Use(middleware.DenyServiceTokenMiddleware())
+	admin.Use(middleware.RequireAdminMiddleware(deps.UserRepo))
+	{
+		admin.POST("/users/:id/impersonate", adminHandler.Impersonate)
+		admin.GET("/impersonation-logs", adminHandler.ListImpersonationLogs)
+		admin.POST("/service-tokens", middleware.DenyImpersonationMiddleware(), adminHandler.IssueServiceToken)
+		admin.DELETE("/service-tokens/:id", middleware.DenyImpersonationMiddleware(), adminHandler.RevokeServiceToken)
+		admin.GET("/service-audit-logs", adminHandler.ListServiceAuditLogs)
+		admin.GET("/generation-tasks", adminHandler.ListGenerationTasks)
+		admin.GET("/ai-response-archives/:id", responseArchiveHandler.GetArchive)
+		admin.GET("/abuse-flags", adminHandler.ListAbuseFlags)
+		admin.POST("/abuse-flags/:id/review", adminHandler.ReviewAbuseFlag)
+		admin.GET("/ai/parse-failures", adminHandler.GetParseFailureStats)
+		admin.GET("/prompt-experiments", promptTemplateHandler.GetExperiment)
+		admin.POST("/account-merges", adminHandler.MergeAccounts)
+		admin.GET("/account-merges", adminHandler.ListAccountMerges)
+		admin.POST("/account-merges/:id/revert", adminHandler.RevertAccountMerge)
+		admin.GET("/integrity/report", integrityHandler.GetReport)
+		admin.POST("/integrity/check", integrityHandler.RunCheck)
+
+		// Generation task queue
+		admin.GET("/queue/stuck-tasks", taskOpsHandler.ListStuckTasks)
+		admin.POST("/queue/tasks/:id/requeue", taskOpsHandler.RequeueTask)
+		admin.POST("/queue/tasks/:id/fail", taskOpsHandler.FailTask)
+		admin.GET("/queue/depth", taskOpsHandler.GetQueueDepth)
+
+		// Organization provisioning
+		admin.POST("/organizations", organizationHandler.CreateOrganization)
+		admin.GET("/organizations", organizationHandler.ListOrganizations)
+		admin.POST("/organizations/:id/users", organizationHandler.ProvisionUsers)
+		admin.POST("/organizations/:id/users/deactivate", organizationHandler.DeactivateUsers)
 	}
 }
@@ -0,0 +1,265 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/config"
+	"github.com/ai-fitness-planner/backend/internal/middleware"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/jwt"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/pkg/session"
+	"github.com/ai-fitness-planner/backend/internal/service"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func init() {
+	if logger.Logger == nil {
+		logger.Logger = zap.NewNop()
+	}
+}
+
+// newReleaseRouter builds the full router in release mode with the given
+// system endpoint guards
+func newReleaseRouter(t *testing.T, systemEndpoints config.SystemEndpointsConfig) *gin.Engine {
+	t.Helper()
+	previous := config.GlobalConfig
+	t.Cleanup(func() {
+		config.GlobalConfig = previous
+		gin.SetMode(gin.TestMode)
+	})
+
+	config.GlobalConfig = &config.Config{
+		App:             config.AppConfig{Mode: "release"},
+		SystemEndpoints: systemEndpoints,
+	}
+	return SetupRouter(&Dependencies{RateLimiter: middleware.NewRateLimiter(nil, nil)})
+}
+
+func TestSystemEndpoints_RequireBasicAuthInRelease(t *testing.T) {
+	router := newReleaseRouter(t, config.SystemEndpointsConfig{
+		BasicAuthUsername: "ops",
+		BasicAuthPassword: "secret",
+	})
+
+	for _, path := range []string{"/health", "/metrics"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code, path)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.SetBasicAuth("ops", "secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "ai_plan_parse_failures_total")
+}
+
+func TestSystemEndpoints_ThrottledInRelease(t *testing.T) {
+	router := newReleaseRouter(t, config.SystemEndpointsConfig{RequestsPerMinute: 2})
+
+	codes := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		codes = append(codes, w.Code)
+	}
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+}
+
+// fakeImpersonationAuditRepo discards audit entries
+type fakeImpersonationAuditRepo struct{}
+
+func (fakeImpersonationAuditRepo) Create(ctx context.Context, log *model.ImpersonationAuditLog) error {
+	return nil
+}
+
+func (fakeImpersonationAuditRepo) List(ctx context.Context, userID int64, limit, offset int) ([]*model.ImpersonationAuditLog, int64, error) {
+	return nil, 0, nil
+}
+
+// newGenerationRouter builds the full router with a Redis-backed rate
+// limiter allowing perDay AI generations a day
+func newGenerationRouter(t *testing.T, perDay int64, trainingService service.TrainingService) (*gin.Engine, jwt.JWTManager, *miniredis.Miniredis) {
+	t.Helper()
+	previous := config.GlobalConfig
+	t.Cleanup(func() { config.GlobalConfig = previous })
+	config.GlobalConfig = &config.Config{}
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	jwtManager := jwt.NewJWTManager("user-secret", "service-secret", time.Hour, 24*time.Hour)
+	rateLimiter := middleware.NewRateLimiter(client, &middleware.RateLimitConfig{
+		UserRequestsPerMinute: 100,
+		UserRequestsPerHour:   100,
+		IPRequestsPerMinute:   100,
+		AIGenerationPerMinute: 100,
+		AIGenerationPerDay:    perDay,
+	})
+	router := SetupRouter(&Dependencies{
+		JWTManager:             jwtManager,
+		SessionManager:         session.NewStatelessSessionManager(24 * time.Hour),
+		RateLimiter:            rateLimiter,
+		ImpersonationAuditRepo: fakeImpersonationAuditRepo{},
+		TrainingService:        trainingService,
+	})
+	return router, jwtManager, mr
+}
+
+func TestSubstituteExercise_IsAGeneration(t *testing.T) {
+	const path = "/api/v1/training-plans/1/days/2026-01-05/exercises/0/substitute"
+
+	t.Run("impersonation is refused", func(t *testing.T) {
+		router, jwtManager, _ := newGenerationRouter(t, 1, nil)
+		token, err := jwtManager.GenerateImpersonationToken(1, "user", 99, time.Minute)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("exhausted quota is refused", func(t *testing.T) {
+		router, jwtManager, mr := newGenerationRouter(t, 1, nil)
+		token, err := jwtManager.GenerateAccessToken(1, "user")
+		require.NoError(t, err)
+		require.NoError(t, mr.Set(fmt.Sprintf("quota:ai:%d:day:%s", 1, time.Now().Format("20060102")), "1"))
+
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "0", w.Header().Get("X-AI-Quota-Remaining-Day"))
+	})
+}
+
+// fakePendingTrainingService starts one generation, then returns it as
+// pending to every later request
+type fakePendingTrainingService struct {
+	service.TrainingService
+	started bool
+}
+
+func (s *fakePendingTrainingService) GeneratePlan(ctx context.Context, userID int64, req *service.GeneratePlanRequest) (*service.TaskResponse, error) {
+	resp := &service.TaskResponse{TaskID: "task-1", Status: "pending", Existing: s.started}
+	s.started = true
+	return resp, nil
+}
+
+func TestGeneratePlan_PendingTaskDoesNotUseQuota(t *testing.T) {
+	router, jwtManager, _ := newGenerationRouter(t, 5, &fakePendingTrainingService{})
+	token, err := jwtManager.GenerateAccessToken(1, "user")
+	require.NoError(t, err)
+
+	generate := func() *httptest.ResponseRecorder {
+		body := `{"plan_name":"增肌","duration_weeks":4,"goal":"增肌","difficulty_level":"medium"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/training-plans/generate", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := generate()
+	require.Equal(t, http.StatusOK, first.Code, first.Body.String())
+	assert.Equal(t, "4", first.Header().Get("X-AI-Quota-Remaining-Day"))
+
+	for i := 0; i < 3; i++ {
+		again := generate()
+		require.Equal(t, http.StatusOK, again.Code)
+		assert.Contains(t, again.Body.String(), `"existing":true`)
+		assert.Equal(t, "4", again.Header().Get("X-AI-Quota-Remaining-Day"))
+	}
+}
+
+// fakeServiceAuditRepo discards audit entries
+type fakeServiceAuditRepo struct{}
+
+func (fakeServiceAuditRepo) Create(ctx context.Context, log *model.ServiceAuditLog) error {
+	return nil
+}
+
+func (fakeServiceAuditRepo) List(ctx context.Context, service string, limit, offset int) ([]*model.ServiceAuditLog, int64, error) {
+	return nil, 0, nil
+}
+
+func (fakeServiceAuditRepo) GetIssue(ctx context.Context, tokenID string) (*model.ServiceAuditLog, error) {
+	return nil, nil
+}
+
+// fakeRevocationRepo holds revoked token IDs
+type fakeRevocationRepo map[string]bool
+
+func (r fakeRevocationRepo) Create(ctx context.Context, revocation *model.ServiceTokenRevocation) error {
+	r[revocation.TokenID] = true
+	return nil
+}
+
+func (r fakeRevocationRepo) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	return r[tokenID], nil
+}
+
+func TestServiceTokens(t *testing.T) {
+	previous := config.GlobalConfig
+	t.Cleanup(func() { config.GlobalConfig = previous })
+	config.GlobalConfig = &config.Config{}
+
+	jwtManager := jwt.NewJWTManager("user-secret", "service-secret", time.Hour, 24*time.Hour)
+	revocations := fakeRevocationRepo{}
+	router := SetupRouter(&Dependencies{
+		JWTManager:                 jwtManager,
+		SessionManager:             session.NewStatelessSessionManager(24 * time.Hour),
+		RateLimiter:                middleware.NewRateLimiter(nil, nil),
+		ServiceAuditRepo:           fakeServiceAuditRepo{},
+		ServiceTokenRevocationRepo: revocations,
+	})
+
+	serve := func(method, path, token string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("admin routes are refused", func(t *testing.T) {
+		// The token acts for an admin, but never gets as far as the admin check
+		token, err := jwtManager.GenerateServiceToken("bot", 1, time.Hour)
+		require.NoError(t, err)
+
+		for _, path := range []string{"/api/v1/admin/impersonation-logs", "/api/v1/prompt-templates", "/api/v1/meta/runtime"} {
+			assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, path, token), path)
+		}
+		assert.Equal(t, http.StatusForbidden, serve(http.MethodDelete, "/api/v1/admin/service-tokens/abc", token))
+	})
+
+	t.Run("revoked tokens are refused", func(t *testing.T) {
+		token, err := jwtManager.GenerateServiceToken("bot", 1, time.Hour)
+		require.NoError(t, err)
+		claims, err := jwtManager.ValidateServiceToken(token)
+		require.NoError(t, err)
+
+		require.NoError(t, revocations.Create(context.Background(), &model.ServiceTokenRevocation{TokenID: claims.ID}))
+		assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/api/v1/prompt-templates", token))
+	})
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ai-fitness-planner/backend/internal/config"
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// knownAIHosts lists the official API hosts of the supported providers.
+// Endpoints on other hosts must be allowed via abuse.allowed_hosts.
+var knownAIHosts = []string{
+	"api.openai.com",
+	"aip.baidubce.com",
+	"qianfan.baidubce.com",
+	"dashscope.aliyuncs.com",
+	"api.anthropic.com",
+	"api.deepseek.com",
+}
+
+// minDuplicateInputRunes is the shortest normalized user input compared
+// across accounts; shorter input, like a bare "增肌", is too common to mean
+// the accounts are coordinated
+const minDuplicateInputRunes = 20
+
+// AbuseDetector inspects AI generation requests before they are sent
+type AbuseDetector interface {
+	// CheckGeneration returns an error if the API config is suspended or the
+	// request is flagged as abusive; a new flag suspends the config.
+	// userInput is the free text the user wrote for the request, such as a
+	// goal, feedback or chat message.
+	CheckGeneration(ctx context.Context, userID int64, api *model.AIAPI, userInput ...string) error
+}
+
+// abuseDetector implements AbuseDetector using Redis counters
+type abuseDetector struct {
+	client    *redis.Client
+	flagRepo  repository.AbuseFlagRepository
+	cfg       config.AbuseConfig
+	hostAllow map[string]bool
+}
+
+// NewAbuseDetector creates a new instance of AbuseDetector
+func NewAbuseDetector(client *redis.Client, flagRepo repository.AbuseFlagRepository, cfg config.AbuseConfig) AbuseDetector {
+	hostAllow := make(map[string]bool, len(knownAIHosts)+len(cfg.AllowedHosts))
+	for _, h := range knownAIHosts {
+		hostAllow[h] = true
+	}
+	for _, h := range cfg.AllowedHosts {
+		hostAllow[strings.ToLower(strings.TrimSpace(h))] = true
+	}
+	if cfg.SuspensionDuration <= 0 {
+		cfg.SuspensionDuration = 24 * time.Hour
+	}
+	if cfg.DuplicatePromptWindow <= 0 {
+		cfg.DuplicatePromptWindow = time.Hour
+	}
+
+	return &abuseDetector{
+		client:    client,
+		flagRepo:  flagRepo,
+		cfg:       cfg,
+		hostAllow: hostAllow,
+	}
+}
+
+// CheckGeneration runs the suspension check followed by each detector.
+// Redis failures are logged and allow the request (fail open), matching the
+// rate limiter.
+func (d *abuseDetector) CheckGeneration(ctx context.Context, userID int64, api *model.AIAPI, userInput ...string) error {
+	if !d.cfg.Enabled {
+		return nil
+	}
+
+	suspension, err := d.flagRepo.GetActiveSuspension(ctx, api.ID, time.Now())
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase, "检查AI API状态失败")
+	}
+	if suspension != nil {
+		return errors.New(errors.ErrAIAPISuspended, "AI API配置因异常使用已被暂停，等待管理员审核")
+	}
+
+	if host, ok := d.isNonAIHost(api.Provider, api.APIEndpoint); ok {
+		return d.flag(ctx, userID, api, model.AbuseReasonNonAIHost, model.JSONMap{"host": host})
+	}
+
+	if d.cfg.SpikeThresholdPerHour > 0 {
+		key := fmt.Sprintf("abuse:gen:%d:hour", userID)
+		count, err := d.incrWithExpire(ctx, key, time.Hour)
+		if err != nil {
+			logger.Warn("Abuse spike check failed", zap.Error(err), zap.Int64("user_id", userID))
+		} else if count > d.cfg.SpikeThresholdPerHour {
+			return d.flag(ctx, userID, api, model.AbuseReasonUsageSpike, model.JSONMap{
+				"generations_last_hour": count,
+				"threshold":             d.cfg.SpikeThresholdPerHour,
+			})
+		}
+	}
+
+	// The rendered prompt embeds each account's own profile and body data,
+	// so only what the users wrote can match across accounts
+	input := normalizeUserInput(userInput)
+	if d.cfg.DuplicatePromptAccounts > 0 && utf8.RuneCountInString(input) >= minDuplicateInputRunes {
+		sum := sha256.Sum256([]byte(input))
+		hash := hex.EncodeToString(sum[:])
+		key := "abuse:prompt:" + hash
+
+		pipe := d.client.TxPipeline()
+		pipe.SAdd(ctx, key, userID)
+		pipe.Expire(ctx, key, d.cfg.DuplicatePromptWindow)
+		card := pipe.SCard(ctx, key)
+		if _, err := pipe.Exec(ctx); err != nil {
+			logger.Warn("Abuse duplicate prompt check failed", zap.Error(err), zap.Int64("user_id", userID))
+		} else if card.Val() >= d.cfg.DuplicatePromptAccounts {
+			return d.flag(ctx, userID, api, model.AbuseReasonDuplicatePrompt, model.JSONMap{
+				"prompt_hash": hash,
+				"accounts":    card.Val(),
+				"threshold":   d.cfg.DuplicatePromptAccounts,
+			})
+		}
+	}
+
+	return nil
+}
+
+// normalizeUserInput lowercases each non-empty field and collapses its
+// whitespace, so trivially varied copies of the same text match
+func normalizeUserInput(fields []string) string {
+	normalized := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.Join(strings.Fields(strings.ToLower(field)), " "); field != "" {
+			normalized = append(normalized, field)
+		}
+	}
+	return strings.Join(normalized, "\n")
+}
+
+// isNonAIHost reports whether the endpoint host is neither a known provider
+// host nor explicitly allowed. Loopback and private addresses always count,
+// except for local LLM providers when abuse.allow_local_providers is set.
+func (d *abuseDetector) isNonAIHost(provider, endpoint string) (string, bool) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		return endpoint, true
+	}
+	host := strings.ToLower(u.Hostname())
+
+	if isLocalHost(host) {
+		return host, !(d.cfg.AllowLocalProviders && provider == "ollama")
+	}
+
+	return host, !d.hostAllow[host]
+}
+
+// isLocalHost reports whether host is localhost or a loopback, private,
+// link-local or unspecified address
+func isLocalHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified())
+}
+
+// flag records an abuse flag that suspends the API config and returns the
+// error reported to the caller
+func (d *abuseDetector) flag(ctx context.Context, userID int64, api *model.AIAPI, reason string, details model.JSONMap) error {
+	suspendedUntil := time.Now().Add(d.cfg.SuspensionDuration)
+	flag := &model.AIAbuseFlag{
+		UserID:         userID,
+		AIAPIID:        api.ID,
+		Reason:         reason,
+		Details:        details,
+		Status:         model.AbuseFlagStatusPending,
+		SuspendedUntil: &suspendedUntil,
+		CreatedAt:      time.Now(),
+	}
+	if err := d.flagRepo.Create(ctx, flag); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase, "记录异常使用失败")
+	}
+
+	logger.Warn("AI API config suspended for suspected abuse",
+		zap.Int64("user_id", userID),
+		zap.Int64("ai_api_id", api.ID),
+		zap.String("reason", reason),
+	)
+
+	return errors.New(errors.ErrAIAPISuspended, "检测到异常使用，AI API配置已被暂停，等待管理员审核")
+}
+
+// incrWithExpire increments a counter and sets its TTL on first use
+func (d *abuseDetector) incrWithExpire(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	count, err := d.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		d.client.Expire(ctx, key, ttl)
+	}
+	return count, nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+// MergeAccountsRequest names the duplicate account to merge into the
+// surviving target account
+type MergeAccountsRequest struct {
+	SourceUserID int64
+	TargetUserID int64
+	Reason       string
+	DryRun       bool
+}
+
+// MergeAccounts checks both accounts and merges them. The source account
+// is disabled and signed out, so its owner continues with the target.
+func (s *adminService) MergeAccounts(ctx context.Context, adminID int64, req *MergeAccountsRequest) (*model.AccountMerge, error) {
+	if req.SourceUserID == req.TargetUserID {
+		return nil, errors.New(errors.ErrInvalidParam, "不能将账号合并到自身")
+	}
+
+	source, err := s.mergeAccountUser(ctx, req.SourceUserID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.mergeAccountUser(ctx, req.TargetUserID)
+	if err != nil {
+		return nil, err
+	}
+	if target.Status != 1 {
+		return nil, errors.New(errors.ErrConflict, "保留的账号已停用")
+	}
+
+	merge := &model.AccountMerge{
+		AdminID:      adminID,
+		SourceUserID: source.ID,
+		TargetUserID: target.ID,
+		Reason:       req.Reason,
+		Status:       model.AccountMergeStatusMerged,
+		CreatedAt:    time.Now(),
+	}
+	if err := s.accountMergeRepo.Merge(ctx, merge, req.DryRun); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "合并账号失败")
+	}
+	if req.DryRun {
+		return merge, nil
+	}
+
+	if err := s.sessionManager.DeleteAllUserSessions(ctx, source.ID); err != nil {
+		logger.Warn("Failed to end sessions of merged account",
+			zap.Int64("user_id", source.ID),
+			zap.Error(err),
+		)
+	}
+	return merge, nil
+}
+
+// mergeAccountUser loads an account taking part in a merge. Admin accounts
+// are never merged.
+func (s *adminService) mergeAccountUser(ctx context.Context, userID int64) (*model.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取用户失败")
+	}
+	if user == nil {
+		return nil, errors.New(errors.ErrUserNotFound, "用户不存在")
+	}
+	if user.Role == model.UserRoleAdmin {
+		return nil, errors.New(errors.ErrForbidden, "不能合并管理员账号")
+	}
+	return user, nil
+}
+
+// ListAccountMerges returns the merge audit records, optionally of one user
+func (s *adminService) ListAccountMerges(ctx context.Context, userID int64, limit, offset int) ([]*model.AccountMerge, int64, error) {
+	merges, total, err := s.accountMergeRepo.List(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, errors.ErrDatabase, "获取账号合并记录失败")
+	}
+	return merges, total, nil
+}
+
+// RevertAccountMerge moves the merged data back to the source account.
+// Data the target account added since the merge stays with it.
+func (s *adminService) RevertAccountMerge(ctx context.Context, adminID, mergeID int64) (*model.AccountMerge, error) {
+	merge, err := s.accountMergeRepo.GetByID(ctx, mergeID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取账号合并记录失败")
+	}
+	if merge == nil {
+		return nil, errors.New(errors.ErrNotFound, "账号合并记录不存在")
+	}
+	if merge.Status != model.AccountMergeStatusMerged {
+		return nil, errors.New(errors.ErrConflict, "该合并已撤销")
+	}
+
+	if err := s.accountMergeRepo.Revert(ctx, merge, adminID, time.Now()); err != nil {
+		if err == repository.ErrMergeReverted {
+			return nil, errors.New(errors.ErrConflict, "该合并已撤销")
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase, "撤销账号合并失败")
+	}
+	return merge, nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/session"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMergeUserRepo holds the accounts taking part in a merge
+type fakeMergeUserRepo struct {
+	repository.UserRepository
+	users map[int64]*model.User
+}
+
+func (r *fakeMergeUserRepo) GetByID(ctx context.Context, id int64) (*model.User, error) {
+	return r.users[id], nil
+}
+
+// fakeAccountMergeRepo records the merges it is asked to save
+type fakeAccountMergeRepo struct {
+	repository.AccountMergeRepository
+	merges []*model.AccountMerge
+	err    error
+}
+
+func (r *fakeAccountMergeRepo) Merge(ctx context.Context, merge *model.AccountMerge, dryRun bool) error {
+	if r.err != nil {
+		return r.err
+	}
+	merge.Changes = model.AccountMergeChanges{Moved: map[string][]int64{"training_records": {101}}, SourceStatus: 1}
+	if !dryRun {
+		merge.ID = int64(len(r.merges) + 1)
+		r.merges = append(r.merges, merge)
+	}
+	return nil
+}
+
+// newTestMergeService returns an admin service merging account 9 into
+// account 7, whose sessions live in miniredis. Account 9 is signed in on
+// two devices and account 7 on one.
+func newTestMergeService(t *testing.T) (AdminService, *fakeAccountMergeRepo, session.SessionManager) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	sessions := session.NewSessionManager(client)
+	ctx := context.Background()
+	require.NoError(t, sessions.CreateSession(ctx, 9, "source-phone", "lifter2", time.Hour, "10.0.0.1", "iOS"))
+	require.NoError(t, sessions.CreateSession(ctx, 9, "source-web", "lifter2", time.Hour, "10.0.0.2", "Chrome"))
+	require.NoError(t, sessions.CreateSession(ctx, 7, "target-web", "lifter", time.Hour, "10.0.0.2", "Chrome"))
+
+	users := &fakeMergeUserRepo{users: map[int64]*model.User{
+		7: {ID: 7, Username: "lifter", Role: model.UserRoleUser, Status: 1},
+		9: {ID: 9, Username: "lifter2", Role: model.UserRoleUser, Status: 1},
+		1: {ID: 1, Username: "admin", Role: model.UserRoleAdmin, Status: 1},
+	}}
+	merges := &fakeAccountMergeRepo{}
+	adminService := NewAdminService(users, nil, nil, nil, nil, nil, nil, merges, nil, sessions, 0, 0, nil)
+	return adminService, merges, sessions
+}
+
+func assertSignedIn(t *testing.T, sessions session.SessionManager, sessionID string, signedIn bool) {
+	t.Helper()
+	s, err := sessions.GetSession(context.Background(), sessionID)
+	require.NoError(t, err)
+	if signedIn {
+		assert.NotNil(t, s, "session %s ended", sessionID)
+	} else {
+		assert.Nil(t, s, "session %s still active", sessionID)
+	}
+}
+
+func TestAdminService_MergeAccountsEndsSourceSessions(t *testing.T) {
+	adminService, merges, sessions := newTestMergeService(t)
+
+	merge, err := adminService.MergeAccounts(context.Background(), 1, &MergeAccountsRequest{SourceUserID: 9, TargetUserID: 7, Reason: "重复注册"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), merge.ID)
+	assert.Equal(t, model.AccountMergeStatusMerged, merge.Status)
+	require.Len(t, merges.merges, 1)
+
+	assertSignedIn(t, sessions, "source-phone", false)
+	assertSignedIn(t, sessions, "source-web", false)
+	assertSignedIn(t, sessions, "target-web", true)
+}
+
+func TestAdminService_MergeAccountsDryRunKeepsSessions(t *testing.T) {
+	adminService, merges, sessions := newTestMergeService(t)
+
+	merge, err := adminService.MergeAccounts(context.Background(), 1, &MergeAccountsRequest{SourceUserID: 9, TargetUserID: 7, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{101}, merge.Changes.Moved["training_records"])
+	assert.Empty(t, merges.merges)
+
+	assertSignedIn(t, sessions, "source-phone", true)
+	assertSignedIn(t, sessions, "source-web", true)
+}
+
+func TestAdminService_MergeAccountsFailureKeepsSessions(t *testing.T) {
+	adminService, merges, sessions := newTestMergeService(t)
+	merges.err = stderrors.New("Error 1062: Duplicate entry '7-c-2' for key 'uk_user_client_id'")
+
+	_, err := adminService.MergeAccounts(context.Background(), 1, &MergeAccountsRequest{SourceUserID: 9, TargetUserID: 7})
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrDatabase, appErr.Code)
+
+	assertSignedIn(t, sessions, "source-phone", true)
+	assertSignedIn(t, sessions, "source-web", true)
+}
+
+func TestAdminService_MergeAccountsRejected(t *testing.T) {
+	adminService, merges, sessions := newTestMergeService(t)
+
+	tests := []struct {
+		name   string
+		source int64
+		target int64
+		code   int
+	}{
+		{name: "same account", source: 9, target: 9, code: errors.ErrInvalidParam},
+		{name: "unknown source", source: 404, target: 7, code: errors.ErrUserNotFound},
+		{name: "admin account", source: 1, target: 7, code: errors.ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := adminService.MergeAccounts(context.Background(), 1, &MergeAccountsRequest{SourceUserID: tt.source, TargetUserID: tt.target})
+			var appErr *errors.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, tt.code, appErr.Code)
+		})
+	}
+	assert.Empty(t, merges.merges)
+	assertSignedIn(t, sessions, "source-phone", true)
+}
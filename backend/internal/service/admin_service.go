@@ -0,0 +1,376 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/jwt"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/pkg/session"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+// ImpersonationResult holds a time-boxed token for acting as another user
+type ImpersonationResult struct {
+	AccessToken string
+	ExpiresAt   time.Time
+	User        *model.User
+}
+
+// ServiceTokenResult holds a token for an internal service
+type ServiceTokenResult struct {
+	Token     string
+	TokenID   string
+	Service   string
+	UserID    int64
+	ExpiresAt time.Time
+}
+
+// AdminService interface defines support operations available to admins
+type AdminService interface {
+	Impersonate(ctx context.Context, adminID, targetUserID int64, reason, ipAddress, userAgent string) (*ImpersonationResult, error)
+	ListImpersonationLogs(ctx context.Context, userID int64, limit, offset int) ([]*model.ImpersonationAuditLog, int64, error)
+	// IssueServiceToken issues a token an internal service uses to act for
+	// a user without session checks or rate limits
+	IssueServiceToken(ctx context.Context, adminID int64, service string, userID int64, ttl time.Duration, ipAddress string) (*ServiceTokenResult, error)
+	// RevokeServiceToken makes a service token invalid before it expires
+	RevokeServiceToken(ctx context.Context, adminID int64, tokenID, ipAddress string) (*model.ServiceTokenRevocation, error)
+	ListServiceAuditLogs(ctx context.Context, service string, limit, offset int) ([]*model.ServiceAuditLog, int64, error)
+	ListAbuseFlags(ctx context.Context, status string, limit, offset int) ([]*model.AIAbuseFlag, int64, error)
+	ReviewAbuseFlag(ctx context.Context, adminID, flagID int64, action, note string) (*model.AIAbuseFlag, error)
+	// ListGenerationTasks returns a page of finished generation tasks
+	ListGenerationTasks(ctx context.Context, filter repository.GenerationTaskFilter, limit, offset int) ([]*model.GenerationTask, int64, error)
+	// ParseFailureStats returns the plan parse failure counters
+	ParseFailureStats(ctx context.Context) ([]ParseFailureCount, error)
+	// MergeAccounts moves a duplicate account's data to the surviving
+	// account and disables it; a dry run only reports what would change
+	MergeAccounts(ctx context.Context, adminID int64, req *MergeAccountsRequest) (*model.AccountMerge, error)
+	ListAccountMerges(ctx context.Context, userID int64, limit, offset int) ([]*model.AccountMerge, int64, error)
+	// RevertAccountMerge undoes a merge and re-enables the merged account
+	RevertAccountMerge(ctx context.Context, adminID, mergeID int64) (*model.AccountMerge, error)
+}
+
+// Abuse flag review actions
+const (
+	AbuseReviewDismiss = "dismiss"
+	AbuseReviewConfirm = "confirm"
+)
+
+// adminService implements the AdminService interface
+type adminService struct {
+	userRepo         repository.UserRepository
+	aiAPIRepo        repository.AIAPIRepository
+	auditRepo        repository.ImpersonationAuditRepository
+	serviceAuditRepo repository.ServiceAuditRepository
+	revocationRepo   repository.ServiceTokenRevocationRepository
+	abuseFlagRepo    repository.AbuseFlagRepository
+	taskHistoryRepo  repository.GenerationTaskRepository
+	accountMergeRepo repository.AccountMergeRepository
+	jwtManager       jwt.JWTManager
+	sessionManager   session.SessionManager
+	impersonationTTL time.Duration
+	serviceTokenTTL  time.Duration
+	parseMetrics     ParseFailureMetrics
+}
+
+// NewAdminService creates a new instance of AdminService. parseMetrics may
+// be nil, in which case no parse failures are reported.
+func NewAdminService(
+	userRepo repository.UserRepository,
+	aiAPIRepo repository.AIAPIRepository,
+	auditRepo repository.ImpersonationAuditRepository,
+	serviceAuditRepo repository.ServiceAuditRepository,
+	revocationRepo repository.ServiceTokenRevocationRepository,
+	abuseFlagRepo repository.AbuseFlagRepository,
+	taskHistoryRepo repository.GenerationTaskRepository,
+	accountMergeRepo repository.AccountMergeRepository,
+	jwtManager jwt.JWTManager,
+	sessionManager session.SessionManager,
+	impersonationTTL time.Duration,
+	serviceTokenTTL time.Duration,
+	parseMetrics ParseFailureMetrics,
+) AdminService {
+	if impersonationTTL <= 0 {
+		impersonationTTL = 30 * time.Minute
+	}
+	if serviceTokenTTL <= 0 {
+		serviceTokenTTL = 24 * time.Hour
+	}
+	return &adminService{
+		userRepo:         userRepo,
+		aiAPIRepo:        aiAPIRepo,
+		auditRepo:        auditRepo,
+		serviceAuditRepo: serviceAuditRepo,
+		revocationRepo:   revocationRepo,
+		abuseFlagRepo:    abuseFlagRepo,
+		taskHistoryRepo:  taskHistoryRepo,
+		accountMergeRepo: accountMergeRepo,
+		jwtManager:       jwtManager,
+		sessionManager:   sessionManager,
+		impersonationTTL: impersonationTTL,
+		serviceTokenTTL:  serviceTokenTTL,
+		parseMetrics:     parseMetrics,
+	}
+}
+
+// Impersonate issues a short-lived access token for the target user and
+// records the start of the impersonation in the audit log
+func (s *adminService) Impersonate(ctx context.Context, adminID, targetUserID int64, reason, ipAddress, userAgent string) (*ImpersonationResult, error) {
+	if adminID == targetUserID {
+		return nil, errors.New(errors.ErrInvalidParam, "不能代登录自己的账号")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, targetUserID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取用户失败")
+	}
+	if user == nil {
+		return nil, errors.New(errors.ErrUserNotFound, "用户不存在")
+	}
+	if user.Role == model.UserRoleAdmin {
+		return nil, errors.New(errors.ErrForbidden, "不能代登录管理员账号")
+	}
+
+	token, err := s.jwtManager.GenerateImpersonationToken(user.ID, user.Username, adminID, s.impersonationTTL)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "生成代登录令牌失败")
+	}
+
+	claims, err := s.jwtManager.ValidateToken(token)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "生成代登录令牌失败")
+	}
+
+	if err := s.sessionManager.CreateSession(
+		ctx,
+		user.ID,
+		claims.SessionID,
+		user.Username,
+		s.impersonationTTL,
+		ipAddress,
+		userAgent,
+	); err != nil {
+		return nil, errors.Wrap(err, errors.ErrCache, "创建代登录会话失败")
+	}
+
+	entry := &model.ImpersonationAuditLog{
+		AdminID:    adminID,
+		UserID:     user.ID,
+		SessionID:  claims.SessionID,
+		Method:     "IMPERSONATE",
+		Path:       "",
+		StatusCode: 200,
+		IPAddress:  ipAddress,
+		CreatedAt:  time.Now(),
+	}
+	if reason != "" {
+		entry.Reason = &reason
+	}
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		// No audit trail, no impersonation
+		_ = s.sessionManager.DeleteSession(ctx, claims.SessionID)
+		return nil, errors.Wrap(err, errors.ErrDatabase, "写入审计日志失败")
+	}
+
+	user.PasswordHash = ""
+
+	return &ImpersonationResult{
+		AccessToken: token,
+		ExpiresAt:   claims.ExpiresAt.Time,
+		User:        user,
+	}, nil
+}
+
+// ListImpersonationLogs returns audit log entries, optionally filtered by user
+func (s *adminService) ListImpersonationLogs(ctx context.Context, userID int64, limit, offset int) ([]*model.ImpersonationAuditLog, int64, error) {
+	logs, total, err := s.auditRepo.List(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, errors.ErrDatabase, "获取审计日志失败")
+	}
+	return logs, total, nil
+}
+
+// IssueServiceToken issues a service token for the user, valid for ttl
+// capped at the configured maximum, and records the issue in the service
+// audit log
+func (s *adminService) IssueServiceToken(ctx context.Context, adminID int64, service string, userID int64, ttl time.Duration, ipAddress string) (*ServiceTokenResult, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取用户失败")
+	}
+	if user == nil {
+		return nil, errors.New(errors.ErrUserNotFound, "用户不存在")
+	}
+	if ttl <= 0 || ttl > s.serviceTokenTTL {
+		ttl = s.serviceTokenTTL
+	}
+
+	token, err := s.jwtManager.GenerateServiceToken(service, user.ID, ttl)
+	if err == jwt.ErrServiceTokensDisabled {
+		return nil, errors.New(errors.ErrServiceUnavailable, "未配置服务令牌密钥")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "生成服务令牌失败")
+	}
+	claims, err := s.jwtManager.ValidateServiceToken(token)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "生成服务令牌失败")
+	}
+
+	entry := &model.ServiceAuditLog{
+		Service:    service,
+		TokenID:    claims.ID,
+		UserID:     user.ID,
+		AdminID:    &adminID,
+		Method:     model.ServiceAuditMethodIssue,
+		Path:       "",
+		StatusCode: 200,
+		IPAddress:  ipAddress,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.serviceAuditRepo.Create(ctx, entry); err != nil {
+		// No audit trail, no token
+		return nil, errors.Wrap(err, errors.ErrDatabase, "写入审计日志失败")
+	}
+
+	return &ServiceTokenResult{
+		Token:     token,
+		TokenID:   claims.ID,
+		Service:   service,
+		UserID:    user.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+// RevokeServiceToken revokes a token issued earlier and records the
+// revocation in the service audit log. Revoking a revoked token succeeds.
+func (s *adminService) RevokeServiceToken(ctx context.Context, adminID int64, tokenID, ipAddress string) (*model.ServiceTokenRevocation, error) {
+	issue, err := s.serviceAuditRepo.GetIssue(ctx, tokenID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取服务令牌失败")
+	}
+	if issue == nil {
+		return nil, errors.New(errors.ErrNotFound, "服务令牌不存在")
+	}
+
+	revocation := &model.ServiceTokenRevocation{
+		TokenID:   tokenID,
+		Service:   issue.Service,
+		UserID:    issue.UserID,
+		RevokedBy: adminID,
+		RevokedAt: time.Now(),
+	}
+	if err := s.revocationRepo.Create(ctx, revocation); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "吊销服务令牌失败")
+	}
+
+	entry := &model.ServiceAuditLog{
+		Service:    issue.Service,
+		TokenID:    tokenID,
+		UserID:     issue.UserID,
+		AdminID:    &adminID,
+		Method:     model.ServiceAuditMethodRevoke,
+		Path:       "",
+		StatusCode: 200,
+		IPAddress:  ipAddress,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.serviceAuditRepo.Create(ctx, entry); err != nil {
+		// The token is revoked either way
+		logger.Warn("Failed to audit service token revocation", zap.Error(err), zap.String("token_id", tokenID))
+	}
+
+	return revocation, nil
+}
+
+// ListServiceAuditLogs returns service token audit log entries, optionally
+// filtered by service
+func (s *adminService) ListServiceAuditLogs(ctx context.Context, service string, limit, offset int) ([]*model.ServiceAuditLog, int64, error) {
+	logs, total, err := s.serviceAuditRepo.List(ctx, service, limit, offset)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, errors.ErrDatabase, "获取审计日志失败")
+	}
+	return logs, total, nil
+}
+
+// ListAbuseFlags returns the abuse review queue, optionally filtered by status
+func (s *adminService) ListAbuseFlags(ctx context.Context, status string, limit, offset int) ([]*model.AIAbuseFlag, int64, error) {
+	flags, total, err := s.abuseFlagRepo.List(ctx, status, limit, offset)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, errors.ErrDatabase, "获取异常使用记录失败")
+	}
+	return flags, total, nil
+}
+
+// ListGenerationTasks lists the generation task history for support
+func (s *adminService) ListGenerationTasks(ctx context.Context, filter repository.GenerationTaskFilter, limit, offset int) ([]*model.GenerationTask, int64, error) {
+	tasks, total, err := s.taskHistoryRepo.List(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, errors.ErrDatabase, "获取生成任务记录失败")
+	}
+	return tasks, total, nil
+}
+
+// ReviewAbuseFlag resolves a pending abuse flag. Dismissing lifts the
+// suspension; confirming disables the AI API config permanently.
+func (s *adminService) ReviewAbuseFlag(ctx context.Context, adminID, flagID int64, action, note string) (*model.AIAbuseFlag, error) {
+	flag, err := s.abuseFlagRepo.GetByID(ctx, flagID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取异常使用记录失败")
+	}
+	if flag == nil {
+		return nil, errors.New(errors.ErrNotFound, "异常使用记录不存在")
+	}
+	if flag.Status != model.AbuseFlagStatusPending {
+		return nil, errors.New(errors.ErrConflict, "该记录已审核")
+	}
+
+	now := time.Now()
+	switch action {
+	case AbuseReviewDismiss:
+		flag.Status = model.AbuseFlagStatusDismissed
+		flag.SuspendedUntil = nil
+	case AbuseReviewConfirm:
+		api, err := s.aiAPIRepo.GetByID(ctx, flag.AIAPIID)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "获取AI API失败")
+		}
+		if api != nil {
+			api.Status = 0
+			api.IsDefault = false
+			if err := s.aiAPIRepo.Update(ctx, api); err != nil {
+				return nil, errors.Wrap(err, errors.ErrDatabase, "禁用AI API失败")
+			}
+		}
+		flag.Status = model.AbuseFlagStatusConfirmed
+	default:
+		return nil, errors.New(errors.ErrInvalidParam, "无效的审核操作")
+	}
+
+	flag.ReviewedBy = &adminID
+	flag.ReviewedAt = &now
+	if note != "" {
+		flag.ReviewNote = &note
+	}
+
+	if err := s.abuseFlagRepo.Update(ctx, flag); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "更新异常使用记录失败")
+	}
+
+	return flag, nil
+}
+
+// ParseFailureStats returns the plan parse failure counters
+func (s *adminService) ParseFailureStats(ctx context.Context) ([]ParseFailureCount, error) {
+	if s.parseMetrics == nil {
+		return []ParseFailureCount{}, nil
+	}
+	counts, err := s.parseMetrics.Counts(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCache, "获取解析失败统计失败")
+	}
+	return counts, nil
+}
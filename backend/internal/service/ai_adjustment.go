@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// TrainingAdjustmentParams holds the execution data and feedback used to
+// adjust an existing training plan
+type TrainingAdjustmentParams struct {
+	UserID  int64
+	AIAPIID int64
+	Plan    *model.TrainingPlan
+	// StartDate is the first day of the adjusted plan
+	StartDate time.Time
+	// CompletionRate is the percentage of scheduled workouts logged so far
+	CompletionRate float64
+	// DifficultyRating is 1-5, or 0 when the user did not rate the plan
+	DifficultyRating int
+	InjuryReport     string
+	Feedback         string
+	RecentRecords    []string
+	LatestCheckIn    *model.WeeklyCheckIn
+	Constraints      []*model.TrainingConstraint
+	BusySchedule     *BusySchedule
+	// Progressions are the user's recently logged exercises with suggested
+	// next loads
+	Progressions []*ExerciseProgression
+	OnCooldown   func(cooldown *ProviderCooldown)
+	// OnResponse receives the raw completion the plan was parsed from
+	OnResponse func(response string)
+}
+
+// NutritionAdjustmentParams holds the execution data and feedback used to
+// adjust an existing nutrition plan
+type NutritionAdjustmentParams struct {
+	UserID    int64
+	AIAPIID   int64
+	Plan      *model.NutritionPlan
+	StartDate time.Time
+	// CompletionRate is the percentage of elapsed plan days with meals logged
+	CompletionRate float64
+	// SatisfactionRating is 1-5, or 0 when the user did not rate the plan
+	SatisfactionRating int
+	// WeightChange is in kg since the plan started, nil if unknown
+	WeightChange  *float64
+	Feedback      string
+	RecentRecords []string
+	LatestCheckIn *model.WeeklyCheckIn
+	OnCooldown    func(cooldown *ProviderCooldown)
+	// OnResponse receives the raw completion the plan was parsed from
+	OnResponse func(response string)
+}
+
+// NutritionDayParams holds the day of a nutrition plan to regenerate. The
+// plan's calorie and macro targets, restrictions and preferences carry over.
+type NutritionDayParams struct {
+	UserID  int64
+	AIAPIID int64
+	Plan    *model.NutritionPlan
+	Date    time.Time
+	// DayNumber is the day's 1-based position in the plan
+	DayNumber int
+	// CurrentDay is the plan data of the day being replaced
+	CurrentDay map[string]interface{}
+	Feedback   string
+}
+
+// AdjustTrainingPlan asks the AI to revise params.Plan and returns the new
+// version, unsaved. It keeps the original's length and settings, starts on
+// params.StartDate and links back to the original through ParentPlanID.
+func (s *aiService) AdjustTrainingPlan(ctx context.Context, params *TrainingAdjustmentParams) (*model.TrainingPlan, error) {
+	aiAPI, err := s.aiAPIRepo.GetByID(ctx, params.AIAPIID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI API: %w", err)
+	}
+	if aiAPI == nil {
+		return nil, fmt.Errorf("AI API not found")
+	}
+
+	prompt, templateID, err := s.buildTrainingAdjustmentPrompt(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	original := params.Plan
+	genParams := &TrainingPlanParams{
+		UserID:          params.UserID,
+		PlanName:        original.PlanName,
+		DurationWeeks:   original.TotalWeeks,
+		DifficultyLevel: original.DifficultyLevel,
+		AIAPIID:         aiAPI.ID,
+		Constraints:     params.Constraints,
+		Periodization:   planPeriodization(original.PlanData),
+		UserInput:       []string{params.Feedback, params.InjuryReport},
+		OnCooldown:      params.OnCooldown,
+		OnResponse:      params.OnResponse,
+	}
+	planData, usedAPI, err := s.generateTrainingPlanWithFallback(ctx, aiAPI, prompt, genParams)
+	if err != nil {
+		return nil, err
+	}
+	if len(params.Constraints) > 0 {
+		planData["applied_constraints"] = appliedConstraints(params.Constraints)
+	}
+	if genParams.Periodization != nil {
+		genParams.Periodization.record(planData)
+	}
+
+	provider := usedAPI.Provider
+	parentID := original.ID
+	return &model.TrainingPlan{
+		UserID:           params.UserID,
+		PlanName:         original.PlanName,
+		StartDate:        params.StartDate,
+		EndDate:          params.StartDate.AddDate(0, 0, original.TotalWeeks*7),
+		TotalWeeks:       original.TotalWeeks,
+		DifficultyLevel:  original.DifficultyLevel,
+		TrainingPurpose:  original.TrainingPurpose,
+		AIAPIID:          &usedAPI.ID,
+		AIProvider:       &provider,
+		ParentPlanID:     &parentID,
+		PromptTemplateID: templateID,
+		MacrocycleID:     original.MacrocycleID,
+		BlockNumber:      original.BlockNumber,
+		BlockPhase:       original.BlockPhase,
+		PlanData:         planData,
+		Status:           "active",
+	}, nil
+}
+
+// AdjustNutritionPlan asks the AI to revise params.Plan and returns the new
+// version, unsaved, linked back to the original through ParentPlanID
+func (s *aiService) AdjustNutritionPlan(ctx context.Context, params *NutritionAdjustmentParams) (*model.NutritionPlan, error) {
+	aiAPI, err := s.aiAPIRepo.GetByID(ctx, params.AIAPIID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI API: %w", err)
+	}
+	if aiAPI == nil {
+		return nil, fmt.Errorf("AI API not found")
+	}
+
+	prompt, templateID, err := s.buildNutritionAdjustmentPrompt(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	original := params.Plan
+	parse := s.parseNutritionPlanResponse
+	if original.LeftoverLunch {
+		parse = withLeftoverLunches(parse)
+	}
+	planData, err := s.generateNutritionWith(ctx, aiAPI, prompt, params.UserID, []string{params.Feedback}, params.OnCooldown, params.OnResponse, nutritionPlanSchema, parse)
+	if err != nil {
+		return nil, err
+	}
+
+	parentID := original.ID
+	return &model.NutritionPlan{
+		UserID:              params.UserID,
+		PlanName:            original.PlanName,
+		StartDate:           params.StartDate,
+		EndDate:             params.StartDate.AddDate(0, 0, planDurationDays(original)),
+		DailyCalories:       original.DailyCalories,
+		CalorieBasis:        original.CalorieBasis,
+		ProteinRatio:        original.ProteinRatio,
+		CarbRatio:           original.CarbRatio,
+		FatRatio:            original.FatRatio,
+		DietaryRestrictions: original.DietaryRestrictions,
+		Preferences:         original.Preferences,
+		LeftoverLunch:       original.LeftoverLunch,
+		BudgetLevel:         original.BudgetLevel,
+		WeeklyBudget:        original.WeeklyBudget,
+		PlanData:            planData,
+		AIAPIID:             aiAPI.ID,
+		ParentPlanID:        &parentID,
+		PromptTemplateID:    templateID,
+		Status:              "active",
+	}, nil
+}
+
+// planDurationDays returns the number of days a nutrition plan covers
+func planDurationDays(plan *model.NutritionPlan) int {
+	days := int(plan.EndDate.Sub(plan.StartDate).Hours()/24 + 0.5)
+	if days < 1 {
+		return 1
+	}
+	return days
+}
+
+// RegenerateNutritionDay asks the AI for new meals for params.Date and
+// returns the day's plan data, labelled with its original day number and date
+func (s *aiService) RegenerateNutritionDay(ctx context.Context, params *NutritionDayParams) (model.JSONMap, error) {
+	aiAPI, err := s.aiAPIRepo.GetByID(ctx, params.AIAPIID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI API: %w", err)
+	}
+	if aiAPI == nil {
+		return nil, fmt.Errorf("AI API not found")
+	}
+
+	prompt, err := s.buildNutritionDayPrompt(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := s.generateNutritionWith(ctx, aiAPI, prompt, params.UserID, []string{params.Feedback}, nil, nil, nutritionDayResponseSchema, s.parseNutritionDayResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	// The day keeps its place in the plan whatever the AI labelled it
+	day["day"] = params.DayNumber
+	day["date"] = params.Date.Format("2006-01-02")
+	return day, nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/ai-fitness-planner/backend/internal/api/request"
 	"github.com/ai-fitness-planner/backend/internal/api/response"
+	"github.com/ai-fitness-planner/backend/internal/config"
 	"github.com/ai-fitness-planner/backend/internal/errors"
 	"github.com/ai-fitness-planner/backend/internal/model"
 	"github.com/ai-fitness-planner/backend/internal/pkg/crypto"
@@ -30,28 +31,59 @@ type AIAPIService interface {
 	SetDefault(ctx context.Context, userID int64, apiID int64) error
 	// DeleteAPI deletes an AI API configuration
 	DeleteAPI(ctx context.Context, userID int64, apiID int64) error
+	// SetFallbackOrder sets which APIs plan generation falls back to, in order
+	SetFallbackOrder(ctx context.Context, userID int64, apiIDs []int64) (*response.AIAPIListResponse, error)
+	// GetUsage aggregates an API's token usage over the last days and estimates its cost
+	GetUsage(ctx context.Context, userID int64, apiID int64, days int) (*response.AIUsageResponse, error)
+	// ListCallLogs returns a page of the user's AI calls, newest first, and the total
+	ListCallLogs(ctx context.Context, userID int64, filter repository.AICallLogFilter, limit, offset int) ([]*model.AICallLog, int64, error)
 }
 
 // aiAPIService implements AIAPIService interface
 type aiAPIService struct {
 	aiAPIRepo repository.AIAPIRepository
 	encryptor crypto.Encryptor
+	breaker   *ProviderCircuitBreaker
+	usageRepo repository.AIUsageRepository
+	callLogs  repository.AICallLogRepository
+	timeout   time.Duration
+	pricing   []config.AIModelPrice
+	policy    *ProviderPolicy
 }
 
-// NewAIAPIService creates a new instance of AIAPIService
+// NewAIAPIService creates a new instance of AIAPIService. breaker is the one
+// shared with AIService so TestAPI reports and updates the same circuits.
+// pricing is used to estimate the cost of recorded usage. policy, if set,
+// limits the providers APIs can be added, tested and chosen for.
 func NewAIAPIService(
 	aiAPIRepo repository.AIAPIRepository,
 	encryptor crypto.Encryptor,
+	breaker *ProviderCircuitBreaker,
+	usageRepo repository.AIUsageRepository,
+	callLogs repository.AICallLogRepository,
+	timeout time.Duration,
+	pricing []config.AIModelPrice,
+	policy *ProviderPolicy,
 ) AIAPIService {
 	return &aiAPIService{
 		aiAPIRepo: aiAPIRepo,
 		encryptor: encryptor,
+		breaker:   breaker,
+		usageRepo: usageRepo,
+		callLogs:  callLogs,
+		timeout:   timeout,
+		pricing:   pricing,
+		policy:    policy,
 	}
 }
 
 // AddAPI adds a new AI API configuration with encrypted API key
 // Requirements: 3.1 - Encrypt API key using AES-256 before storage
 func (s *aiAPIService) AddAPI(ctx context.Context, userID int64, req *request.AddAIAPIRequest) (*response.AIAPIInfo, error) {
+	if err := s.policy.Check(req.Provider); err != nil {
+		return nil, err
+	}
+
 	// Encrypt the API key before storage
 	encryptedKey, err := s.encryptor.Encrypt(req.APIKey)
 	if err != nil {
@@ -79,6 +111,9 @@ func (s *aiAPIService) AddAPI(ctx context.Context, userID int64, req *request.Ad
 		temp := float32(*req.Temperature)
 		api.Temperature = &temp
 	}
+	if req.TimeoutSeconds != nil {
+		api.TimeoutSeconds = req.TimeoutSeconds
+	}
 
 	// Handle is_default flag
 	if req.IsDefault != nil && *req.IsDefault {
@@ -152,6 +187,11 @@ func (s *aiAPIService) UpdateAPI(ctx context.Context, userID int64, apiID int64,
 	if api.UserID != userID {
 		return nil, errors.New(errors.ErrForbidden, "unauthorized access to AI API")
 	}
+	if req.IsDefault != nil && *req.IsDefault {
+		if err := s.policy.Check(api.Provider); err != nil {
+			return nil, err
+		}
+	}
 
 	// Update fields if provided
 	if req.Name != "" {
@@ -178,6 +218,9 @@ func (s *aiAPIService) UpdateAPI(ctx context.Context, userID int64, apiID int64,
 		temp := float32(*req.Temperature)
 		api.Temperature = &temp
 	}
+	if req.TimeoutSeconds != nil {
+		api.TimeoutSeconds = req.TimeoutSeconds
+	}
 	if req.Status != nil {
 		if *req.Status {
 			api.Status = 1
@@ -218,6 +261,9 @@ func (s *aiAPIService) TestAPI(ctx context.Context, userID int64, apiID int64) (
 	if api.UserID != userID {
 		return nil, errors.New(errors.ErrForbidden, "unauthorized access to AI API")
 	}
+	if err := s.policy.Check(api.Provider); err != nil {
+		return nil, err
+	}
 
 	// Decrypt API key for testing
 	// Requirements: 3.6 - Decrypts API key for the request
@@ -245,9 +291,11 @@ func (s *aiAPIService) TestAPI(ctx context.Context, userID int64, apiID int64) (
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrInternalServer, fmt.Sprintf("unsupported provider: %s", api.Provider))
 	}
+	circuitKey := CircuitKey(api)
+	client = s.breaker.Wrap(client, circuitKey)
 
 	// Create client config
-	config := NewAIClientFromModel(api, apiKey)
+	config := NewAIClientFromModel(api, apiKey, s.timeout)
 
 	// Test the connection and measure response time
 	startTime := time.Now()
@@ -281,6 +329,19 @@ func (s *aiAPIService) TestAPI(ctx context.Context, userID int64, apiID int64) (
 		testResult.Message = "Connection successful"
 	}
 
+	// Report the circuit after recording this test, so a successful test
+	// shows the circuit closed again
+	circuit := s.breaker.Status(circuitKey)
+	testResult.Circuit = &response.CircuitInfo{
+		State:               circuit.State,
+		ConsecutiveFailures: circuit.ConsecutiveFailures,
+		LastError:           circuit.LastError,
+	}
+	if circuit.OpenUntil != nil {
+		openUntil := response.FormatTime(*circuit.OpenUntil)
+		testResult.Circuit.OpenUntil = &openUntil
+	}
+
 	return &response.TestAPIResponse{
 		TestResult: testResult,
 	}, nil
@@ -302,6 +363,9 @@ func (s *aiAPIService) SetDefault(ctx context.Context, userID int64, apiID int64
 	if api.UserID != userID {
 		return errors.New(errors.ErrForbidden, "unauthorized access to AI API")
 	}
+	if err := s.policy.Check(api.Provider); err != nil {
+		return err
+	}
 
 	// Set as default (repository handles unsetting other defaults in a transaction)
 	if err := s.aiAPIRepo.SetDefault(ctx, userID, apiID); err != nil {
@@ -336,17 +400,109 @@ func (s *aiAPIService) DeleteAPI(ctx context.Context, userID int64, apiID int64)
 	return nil
 }
 
+// SetFallbackOrder replaces the user's fallback chain. Every API must belong to
+// the user; the default API may be listed and is then skipped when it is the
+// one that failed.
+func (s *aiAPIService) SetFallbackOrder(ctx context.Context, userID int64, apiIDs []int64) (*response.AIAPIListResponse, error) {
+	seen := make(map[int64]bool, len(apiIDs))
+	for _, apiID := range apiIDs {
+		if seen[apiID] {
+			return nil, errors.New(errors.ErrInvalidParam, "备用链中存在重复的API")
+		}
+		seen[apiID] = true
+
+		api, err := s.aiAPIRepo.GetByID(ctx, apiID)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "failed to get AI API")
+		}
+		if api == nil || api.UserID != userID {
+			return nil, errors.New(errors.ErrNotFound, "AI API not found")
+		}
+		if err := s.policy.Check(api.Provider); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.aiAPIRepo.SetFallbackOrder(ctx, userID, apiIDs); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "failed to set fallback order")
+	}
+
+	return s.ListAPIs(ctx, userID)
+}
+
 // modelToAPIInfo converts a model.AIAPI to response.AIAPIInfo
 // This function ensures encrypted keys are never exposed in responses
+// GetUsage aggregates an API's token usage per model since days ago. Cost is
+// an estimate from the configured prices; tokens of unpriced models are
+// reported separately instead of being counted as free.
+func (s *aiAPIService) GetUsage(ctx context.Context, userID int64, apiID int64, days int) (*response.AIUsageResponse, error) {
+	api, err := s.aiAPIRepo.GetByID(ctx, apiID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "failed to get AI API")
+	}
+	if api == nil {
+		return nil, errors.New(errors.ErrNotFound, "AI API not found")
+	}
+
+	// Verify ownership
+	if api.UserID != userID {
+		return nil, errors.New(errors.ErrForbidden, "unauthorized access to AI API")
+	}
+
+	if days <= 0 {
+		days = 30
+	}
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -(days - 1))
+
+	summaries, err := s.usageRepo.SummarizeByAPI(ctx, apiID, since)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "failed to get AI API usage")
+	}
+
+	resp := &response.AIUsageResponse{
+		APIID:    api.ID,
+		Provider: api.Provider,
+		Since:    response.FormatDate(since),
+		ByModel:  make([]response.AIUsageModelInfo, 0, len(summaries)),
+	}
+	for _, summary := range summaries {
+		info := response.AIUsageModelInfo{
+			Model:            summary.Model,
+			Calls:            summary.Calls,
+			PromptTokens:     summary.PromptTokens,
+			CompletionTokens: summary.CompletionTokens,
+			TotalTokens:      summary.TotalTokens,
+		}
+		if cost, ok := estimateCost(s.pricing, summary.Model, summary.PromptTokens, summary.CompletionTokens); ok {
+			info.EstimatedCostUSD = &cost
+			resp.EstimatedCostUSD += cost
+		} else {
+			resp.UnpricedTokens += summary.TotalTokens
+		}
+
+		resp.Calls += summary.Calls
+		resp.PromptTokens += summary.PromptTokens
+		resp.CompletionTokens += summary.CompletionTokens
+		resp.TotalTokens += summary.TotalTokens
+		resp.ByModel = append(resp.ByModel, info)
+	}
+
+	return resp, nil
+}
+
 func (s *aiAPIService) modelToAPIInfo(api *model.AIAPI) *response.AIAPIInfo {
 	info := &response.AIAPIInfo{
-		ID:          api.ID,
-		Provider:    api.Provider,
-		Name:        api.Name,
-		APIEndpoint: api.APIEndpoint,
-		IsDefault:   api.IsDefault,
-		Status:      api.Status == 1,
-		CreatedAt:   api.CreatedAt.Format(time.RFC3339),
+		ID:               api.ID,
+		Provider:         api.Provider,
+		Name:             api.Name,
+		APIEndpoint:      api.APIEndpoint,
+		IsDefault:        api.IsDefault,
+		FallbackPriority: api.FallbackPriority,
+		TimeoutSeconds:   api.TimeoutSeconds,
+		Status:           api.Status == 1,
+		BlockedByPolicy:  !s.policy.Allows(api.Provider),
+		CreatedAt:        response.FormatTime(api.CreatedAt),
 	}
 
 	if api.Model != nil {
@@ -361,3 +517,13 @@ func (s *aiAPIService) modelToAPIInfo(api *model.AIAPI) *response.AIAPIInfo {
 
 	return info
 }
+
+// ListCallLogs returns the user's AI call history. Filtering by an API the
+// user does not own simply matches nothing.
+func (s *aiAPIService) ListCallLogs(ctx context.Context, userID int64, filter repository.AICallLogFilter, limit, offset int) ([]*model.AICallLog, int64, error) {
+	logs, total, err := s.callLogs.ListByUser(ctx, userID, filter, limit, offset)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, errors.ErrDatabase, "failed to get AI call logs")
+	}
+	return logs, total, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// maxCallLogErrorLength bounds the stored error message, in runes
+const maxCallLogErrorLength = 500
+
+// callRecorder stores the outcome of one AI call
+type callRecorder func(prompt string, usage Usage, latency time.Duration, err error)
+
+// loggingClient implements AIClient around another client, recording every
+// Call and CallStream. TestConnection is not recorded.
+type loggingClient struct {
+	client AIClient
+	record callRecorder
+}
+
+// logCalls wraps client with record; a nil record means no logging
+func logCalls(client AIClient, record callRecorder) AIClient {
+	if record == nil {
+		return client
+	}
+	return &loggingClient{client: client, record: record}
+}
+
+// Call sends the request and records its outcome
+func (c *loggingClient) Call(ctx context.Context, prompt string, config *AIClientConfig) (string, error) {
+	cfg, usage := captureUsage(config)
+	start := time.Now()
+	response, err := c.client.Call(ctx, prompt, cfg)
+	c.record(prompt, *usage, time.Since(start), err)
+	return response, err
+}
+
+// CallStream streams the completion and records its outcome once it ends
+func (c *loggingClient) CallStream(ctx context.Context, prompt string, config *AIClientConfig, onChunk func(chunk string)) (string, error) {
+	cfg, usage := captureUsage(config)
+	start := time.Now()
+	response, err := c.client.CallStream(ctx, prompt, cfg, onChunk)
+	c.record(prompt, *usage, time.Since(start), err)
+	return response, err
+}
+
+// TestConnection tests the connection
+func (c *loggingClient) TestConnection(ctx context.Context, config *AIClientConfig) error {
+	return c.client.TestConnection(ctx, config)
+}
+
+// captureUsage returns a copy of config whose OnUsage also stores the
+// reported usage in the returned Usage
+func captureUsage(config *AIClientConfig) (*AIClientConfig, *Usage) {
+	usage := &Usage{}
+	cfg := *config
+	onUsage := config.OnUsage
+	cfg.OnUsage = func(u Usage) {
+		*usage = u
+		if onUsage != nil {
+			onUsage(u)
+		}
+	}
+	return &cfg, usage
+}
+
+// callLogger returns a callRecorder that writes an ai_call_logs row per
+// call, or nil when calls are not logged. Write failures are only logged.
+func (s *aiService) callLogger(ctx context.Context, userID int64, aiAPI *model.AIAPI, purpose string) callRecorder {
+	if s.callLogRepo == nil {
+		return nil
+	}
+	// Record calls that finish after the caller has gone away
+	ctx = context.WithoutCancel(ctx)
+
+	var modelName string
+	if aiAPI.Model != nil {
+		modelName = *aiAPI.Model
+	}
+
+	return func(prompt string, usage Usage, latency time.Duration, err error) {
+		sum := sha256.Sum256([]byte(prompt))
+		entry := &model.AICallLog{
+			UserID:           userID,
+			AIAPIID:          aiAPI.ID,
+			Provider:         aiAPI.Provider,
+			Model:            modelName,
+			Purpose:          purpose,
+			Status:           model.AICallStatusSuccess,
+			LatencyMs:        latency.Milliseconds(),
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
+			PromptHash:       hex.EncodeToString(sum[:])[:16],
+		}
+		if err != nil {
+			msg := err.Error()
+			if runes := []rune(msg); len(runes) > maxCallLogErrorLength {
+				msg = string(runes[:maxCallLogErrorLength])
+			}
+			entry.Status = model.AICallStatusError
+			entry.Error = &msg
+		}
+
+		if err := s.callLogRepo.Create(ctx, entry); err != nil {
+			logger.Warn("Failed to record AI call log",
+				zap.Int64("user_id", userID),
+				zap.Int64("ai_api_id", aiAPI.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package service
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,10 +15,21 @@ import (
 	"github.com/ai-fitness-planner/backend/internal/model"
 )
 
+// ErrProviderRateLimited is wrapped into the AIError returned by Call when the
+// provider throttles the request (HTTP 429 or an equivalent error code)
+var ErrProviderRateLimited = errors.New("provider rate limited")
+
+// ErrProviderUnavailable is wrapped into the AIError returned by Call when the
+// provider answers with a 5xx status
+var ErrProviderUnavailable = errors.New("provider unavailable")
+
 // AIClient defines the interface for AI service providers
 type AIClient interface {
 	// Call sends a prompt to the AI service and returns the response
 	Call(ctx context.Context, prompt string, config *AIClientConfig) (string, error)
+	// CallStream is Call with onChunk invoked for each piece of the completion
+	// as it arrives. Providers without streaming deliver it as one chunk.
+	CallStream(ctx context.Context, prompt string, config *AIClientConfig, onChunk func(chunk string)) (string, error)
 	// TestConnection tests the connectivity to the AI service
 	TestConnection(ctx context.Context, config *AIClientConfig) error
 }
@@ -28,13 +41,36 @@ type AIClientConfig struct {
 	Model       string
 	MaxTokens   int
 	Temperature float32
+	// Timeout bounds each call; for streaming calls it bounds the wait for
+	// the response to start. 0 means no limit beyond ctx.
+	Timeout time.Duration
+	// ResponseSchema, when set, asks providers that support it to return JSON
+	// matching the schema instead of relying on the prompt alone
+	ResponseSchema *ResponseSchema
+	// OnUsage, when set, receives the token usage of each successful call for
+	// providers that report it
+	OnUsage func(usage Usage)
 }
 
-// NewAIClientFromModel creates an AIClientConfig from a model.AIAPI
-func NewAIClientFromModel(api *model.AIAPI, decryptedKey string) *AIClientConfig {
+// reportUsage passes usage to OnUsage, filling in the total if the provider
+// left it out
+func (c *AIClientConfig) reportUsage(usage Usage) {
+	if c.OnUsage == nil || usage.PromptTokens+usage.CompletionTokens+usage.TotalTokens == 0 {
+		return
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	c.OnUsage(usage)
+}
+
+// NewAIClientFromModel creates an AIClientConfig from a model.AIAPI. The
+// API's own timeout, if set, overrides defaultTimeout.
+func NewAIClientFromModel(api *model.AIAPI, decryptedKey string, defaultTimeout time.Duration) *AIClientConfig {
 	config := &AIClientConfig{
 		APIEndpoint: api.APIEndpoint,
 		APIKey:      decryptedKey,
+		Timeout:     defaultTimeout,
 	}
 
 	if api.Model != nil {
@@ -46,10 +82,24 @@ func NewAIClientFromModel(api *model.AIAPI, decryptedKey string) *AIClientConfig
 	if api.Temperature != nil {
 		config.Temperature = *api.Temperature
 	}
+	if api.TimeoutSeconds != nil {
+		config.Timeout = time.Duration(*api.TimeoutSeconds) * time.Second
+	}
 
 	return config
 }
 
+// withCallTimeout bounds ctx by config.Timeout, if set
+func withCallTimeout(ctx context.Context, config *AIClientConfig) (context.Context, context.CancelFunc) {
+	if config.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, config.Timeout)
+}
+
+// SupportedAIProviders lists the providers GetAIClient accepts
+var SupportedAIProviders = []string{"openai", "wenxin", "tongyi", "anthropic", "deepseek", "ollama"}
+
 // GetAIClient returns the appropriate AI client based on the provider
 func GetAIClient(provider string) (AIClient, error) {
 	switch provider {
@@ -59,20 +109,51 @@ func GetAIClient(provider string) (AIClient, error) {
 		return &WenxinClient{}, nil
 	case "tongyi":
 		return &TongyiClient{}, nil
+	case "anthropic":
+		return &AnthropicClient{}, nil
+	case "deepseek":
+		return &DeepSeekClient{openAI: OpenAIClient{jsonObjectMode: true}}, nil
+	case "ollama":
+		return &OllamaClient{}, nil
 	default:
 		return nil, fmt.Errorf("unsupported AI provider: %s", provider)
 	}
 }
 
 // OpenAIClient implements AIClient for OpenAI API
-type OpenAIClient struct{}
+type OpenAIClient struct {
+	// jsonObjectMode requests plain JSON mode instead of a JSON schema, for
+	// compatible APIs that do not support structured outputs
+	jsonObjectMode bool
+}
 
 // OpenAIRequest represents the request structure for OpenAI API
 type OpenAIRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	MaxTokens   int       `json:"max_tokens,omitempty"`
-	Temperature float32   `json:"temperature,omitempty"`
+	Model          string                `json:"model"`
+	Messages       []Message             `json:"messages"`
+	MaxTokens      int                   `json:"max_tokens,omitempty"`
+	Temperature    float32               `json:"temperature,omitempty"`
+	Stream         bool                  `json:"stream,omitempty"`
+	StreamOptions  *OpenAIStreamOptions  `json:"stream_options,omitempty"`
+	ResponseFormat *OpenAIResponseFormat `json:"response_format,omitempty"`
+}
+
+// OpenAIStreamOptions asks for a final chunk carrying the token usage
+type OpenAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// OpenAIResponseFormat selects JSON mode or structured outputs
+type OpenAIResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *OpenAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+// OpenAIJSONSchema is the schema for a json_schema response format
+type OpenAIJSONSchema struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
+	Strict bool                   `json:"strict"`
 }
 
 // Message represents a chat message
@@ -113,8 +194,136 @@ type APIError struct {
 	Code    string `json:"code"`
 }
 
+// OpenAIStreamChunk represents one server-sent event of a streamed completion
+type OpenAIStreamChunk struct {
+	Choices []struct {
+		Delta        Message `json:"delta"`
+		FinishReason string  `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *Usage    `json:"usage,omitempty"`
+	Error *APIError `json:"error,omitempty"`
+}
+
 // Call sends a request to OpenAI API
 func (c *OpenAIClient) Call(ctx context.Context, prompt string, config *AIClientConfig) (string, error) {
+	ctx, cancel := withCallTimeout(ctx, config)
+	defer cancel()
+
+	req, err := c.newRequest(ctx, prompt, config, false)
+	if err != nil {
+		return "", err
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newAIError("OpenAI", resp.StatusCode, openAIErrorMessage(body)).withRetryAfter(resp.Header)
+	}
+
+	var openAIResp OpenAIResponse
+	if err := json.Unmarshal(body, &openAIResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if openAIResp.Error != nil {
+		return "", newAIError("OpenAI", resp.StatusCode, openAIResp.Error.Message)
+	}
+
+	if len(openAIResp.Choices) == 0 {
+		return "", fmt.Errorf("no response from OpenAI")
+	}
+
+	config.reportUsage(openAIResp.Usage)
+	return openAIResp.Choices[0].Message.Content, nil
+}
+
+// CallStream sends a streaming request to OpenAI API and reads the
+// server-sent events until [DONE]
+func (c *OpenAIClient) CallStream(ctx context.Context, prompt string, config *AIClientConfig, onChunk func(chunk string)) (string, error) {
+	req, err := c.newRequest(ctx, prompt, config, true)
+	if err != nil {
+		return "", err
+	}
+
+	// No overall timeout: the stream lasts as long as the generation, and
+	// ctx still cancels it
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = config.Timeout
+	client := &http.Client{Transport: transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", newAIError("OpenAI", resp.StatusCode, openAIErrorMessage(body)).withRetryAfter(resp.Header)
+	}
+
+	var content strings.Builder
+	var usage Usage
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk OpenAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return "", fmt.Errorf("OpenAI API error: %s", chunk.Error.Message)
+		}
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		onChunk(chunk.Choices[0].Delta.Content)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	if content.Len() == 0 {
+		return "", fmt.Errorf("no response from OpenAI")
+	}
+	config.reportUsage(usage)
+	return content.String(), nil
+}
+
+// openAIErrorMessage returns the message of an OpenAI error body, or "" if
+// body is not one
+func openAIErrorMessage(body []byte) string {
+	var resp OpenAIResponse
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Error != nil {
+		return resp.Error.Message
+	}
+	return ""
+}
+
+// newRequest builds a chat completions request
+func (c *OpenAIClient) newRequest(ctx context.Context, prompt string, config *AIClientConfig, stream bool) (*http.Request, error) {
 	// Set defaults
 	model := config.Model
 	if model == "" {
@@ -136,11 +345,29 @@ func (c *OpenAIClient) Call(ctx context.Context, prompt string, config *AIClient
 		},
 		MaxTokens:   maxTokens,
 		Temperature: temperature,
+		Stream:      stream,
+	}
+	if stream && config.OnUsage != nil {
+		reqBody.StreamOptions = &OpenAIStreamOptions{IncludeUsage: true}
+	}
+	if schema := config.ResponseSchema; schema != nil {
+		if c.jsonObjectMode {
+			reqBody.ResponseFormat = &OpenAIResponseFormat{Type: "json_object"}
+		} else {
+			reqBody.ResponseFormat = &OpenAIResponseFormat{
+				Type: "json_schema",
+				JSONSchema: &OpenAIJSONSchema{
+					Name:   schema.Name,
+					Schema: schema.Schema,
+					Strict: true,
+				},
+			}
+		}
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	endpoint := config.APIEndpoint
@@ -151,38 +378,15 @@ func (c *OpenAIClient) Call(ctx context.Context, prompt string, config *AIClient
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", config.APIKey))
-
-	client := &http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	var openAIResp OpenAIResponse
-	if err := json.Unmarshal(body, &openAIResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-
-	if openAIResp.Error != nil {
-		return "", fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
-	}
-
-	if len(openAIResp.Choices) == 0 {
-		return "", fmt.Errorf("no response from OpenAI")
+	// OpenAI-compatible local servers run without an API key
+	if config.APIKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", config.APIKey))
 	}
-
-	return openAIResp.Choices[0].Message.Content, nil
+	return req, nil
 }
 
 // TestConnection tests the connection to OpenAI API
@@ -216,6 +420,9 @@ type WenxinResponse struct {
 
 // Call sends a request to Wenxin API
 func (c *WenxinClient) Call(ctx context.Context, prompt string, config *AIClientConfig) (string, error) {
+	ctx, cancel := withCallTimeout(ctx, config)
+	defer cancel()
+
 	temperature := config.Temperature
 	if temperature == 0 {
 		temperature = 0.7
@@ -244,7 +451,7 @@ func (c *WenxinClient) Call(ctx context.Context, prompt string, config *AIClient
 
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 60 * time.Second}
+	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %w", err)
@@ -256,18 +463,48 @@ func (c *WenxinClient) Call(ctx context.Context, prompt string, config *AIClient
 		return "", fmt.Errorf("failed to read response: %w", err)
 	}
 
+	if resp.StatusCode != http.StatusOK {
+		return "", newAIError("Wenxin", resp.StatusCode, "").withRetryAfter(resp.Header)
+	}
+
 	var wenxinResp WenxinResponse
 	if err := json.Unmarshal(body, &wenxinResp); err != nil {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	if wenxinResp.ErrorCode != 0 {
-		return "", fmt.Errorf("Wenxin API error: %s", wenxinResp.ErrorMsg)
+		return "", wenxinError(&wenxinResp)
 	}
 
+	config.reportUsage(wenxinResp.Usage)
 	return wenxinResp.Result, nil
 }
 
+// CallStream calls the Wenxin API without streaming and delivers the
+// completion as a single chunk
+func (c *WenxinClient) CallStream(ctx context.Context, prompt string, config *AIClientConfig, onChunk func(chunk string)) (string, error) {
+	response, err := c.Call(ctx, prompt, config)
+	if err != nil {
+		return "", err
+	}
+	onChunk(response)
+	return response, nil
+}
+
+// wenxinError classifies an error code returned in a Wenxin response body
+func wenxinError(resp *WenxinResponse) *AIError {
+	e := newAIError("Wenxin", http.StatusOK, fmt.Sprintf("%s (code: %d)", resp.ErrorMsg, resp.ErrorCode))
+	switch resp.ErrorCode {
+	case 4, 18, 336501, 336502: // cluster, QPS, RPM or TPM limit reached
+		e.kind = ErrProviderRateLimited
+	case 17, 19: // daily or total request quota used up, which a retry will not restore
+		e.Retryable = false
+	case 6, 110, 111, 336003: // no permission, invalid or expired access token, invalid parameter
+		e.Retryable = false
+	}
+	return e
+}
+
 // TestConnection tests the connection to Wenxin API
 func (c *WenxinClient) TestConnection(ctx context.Context, config *AIClientConfig) error {
 	_, err := c.Call(ctx, "你好，这是一条测试消息。", config)
@@ -304,15 +541,33 @@ type TongyiResponse struct {
 		CompletionTokens int `json:"completion_tokens"`
 		TotalTokens      int `json:"total_tokens"`
 	} `json:"usage"`
-	Error *struct {
-		Message string `json:"message"`
-		Type    string `json:"type"`
-		Code    string `json:"code"`
-	} `json:"error,omitempty"`
+	Error *TongyiError `json:"error,omitempty"`
+}
+
+// TongyiError is the error object of a Tongyi response
+type TongyiError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+}
+
+// tongyiError classifies a Tongyi error. DashScope reports throttling with a
+// Throttling* code, sometimes without a 429 status.
+func tongyiError(statusCode int, header http.Header, apiErr *TongyiError) *AIError {
+	e := newAIError("Tongyi", statusCode, fmt.Sprintf("%s (type: %s, code: %s)",
+		apiErr.Message, apiErr.Type, apiErr.Code)).withRetryAfter(header)
+	if strings.HasPrefix(apiErr.Code, "Throttling") {
+		e.kind = ErrProviderRateLimited
+		e.Retryable = true
+	}
+	return e
 }
 
 // Call sends a request to Tongyi API using OpenAI-compatible format
 func (c *TongyiClient) Call(ctx context.Context, prompt string, config *AIClientConfig) (string, error) {
+	ctx, cancel := withCallTimeout(ctx, config)
+	defer cancel()
+
 	model := config.Model
 	if model == "" {
 		model = "qwen-turbo"
@@ -367,7 +622,7 @@ func (c *TongyiClient) Call(ctx context.Context, prompt string, config *AIClient
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", config.APIKey))
 
-	client := &http.Client{Timeout: 60 * time.Second}
+	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %w", err)
@@ -386,15 +641,11 @@ func (c *TongyiClient) Call(ctx context.Context, prompt string, config *AIClient
 
 	statusCode := resp.StatusCode
 	if statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices {
-		if len(body) == 0 {
-			return "", fmt.Errorf("Tongyi API error: status %d, empty body", statusCode)
-		}
 		var tongyiResp TongyiResponse
 		if err := json.Unmarshal(body, &tongyiResp); err == nil && tongyiResp.Error != nil {
-			return "", fmt.Errorf("Tongyi API error: %s (type: %s, code: %s)",
-				tongyiResp.Error.Message, tongyiResp.Error.Type, tongyiResp.Error.Code)
+			return "", tongyiError(statusCode, resp.Header, tongyiResp.Error)
 		}
-		return "", fmt.Errorf("Tongyi API error: status %d, body: %s", statusCode, string(body))
+		return "", newAIError("Tongyi", statusCode, string(body)).withRetryAfter(resp.Header)
 	}
 
 	// Check if response is empty
@@ -409,8 +660,7 @@ func (c *TongyiClient) Call(ctx context.Context, prompt string, config *AIClient
 
 	// Check for API errors
 	if tongyiResp.Error != nil {
-		return "", fmt.Errorf("Tongyi API error: %s (type: %s, code: %s)", 
-			tongyiResp.Error.Message, tongyiResp.Error.Type, tongyiResp.Error.Code)
+		return "", tongyiError(statusCode, resp.Header, tongyiResp.Error)
 	}
 
 	// Check if we have choices
@@ -418,11 +668,272 @@ func (c *TongyiClient) Call(ctx context.Context, prompt string, config *AIClient
 		return "", fmt.Errorf("no choices in Tongyi API response")
 	}
 
+	config.reportUsage(Usage(tongyiResp.Usage))
 	return tongyiResp.Choices[0].Message.Content, nil
 }
 
+// CallStream calls the Tongyi API without streaming and delivers the
+// completion as a single chunk
+func (c *TongyiClient) CallStream(ctx context.Context, prompt string, config *AIClientConfig, onChunk func(chunk string)) (string, error) {
+	response, err := c.Call(ctx, prompt, config)
+	if err != nil {
+		return "", err
+	}
+	onChunk(response)
+	return response, nil
+}
+
 // TestConnection tests the connection to Tongyi API
 func (c *TongyiClient) TestConnection(ctx context.Context, config *AIClientConfig) error {
 	_, err := c.Call(ctx, "你好，这是一条测试消息。", config)
 	return err
 }
+
+// AnthropicClient implements AIClient for the Anthropic Messages API
+type AnthropicClient struct{}
+
+// anthropicAPIVersion is sent as the anthropic-version header
+const anthropicAPIVersion = "2023-06-01"
+
+// AnthropicRequest represents the request structure for the Messages API
+type AnthropicRequest struct {
+	Model       string               `json:"model"`
+	Messages    []Message            `json:"messages"`
+	MaxTokens   int                  `json:"max_tokens"`
+	Temperature float32              `json:"temperature,omitempty"`
+	Tools       []AnthropicTool      `json:"tools,omitempty"`
+	ToolChoice  *AnthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+// AnthropicTool declares a tool the model may call
+type AnthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// AnthropicToolChoice forces the model to call a specific tool
+type AnthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+// AnthropicContentBlock represents a content block in a Messages API response
+type AnthropicContentBlock struct {
+	Type  string          `json:"type"`
+	Text  string          `json:"text"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+// AnthropicResponse represents the response structure from the Messages API
+type AnthropicResponse struct {
+	ID         string                  `json:"id"`
+	Type       string                  `json:"type"`
+	Model      string                  `json:"model"`
+	Content    []AnthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Call sends a request to the Anthropic Messages API
+func (c *AnthropicClient) Call(ctx context.Context, prompt string, config *AIClientConfig) (string, error) {
+	ctx, cancel := withCallTimeout(ctx, config)
+	defer cancel()
+
+	// Set defaults; max_tokens is required by the Messages API
+	model := config.Model
+	if model == "" {
+		model = "claude-sonnet-4-5"
+	}
+	maxTokens := config.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = 4096
+	}
+	temperature := config.Temperature
+	if temperature == 0 {
+		temperature = 0.7
+	}
+
+	reqBody := AnthropicRequest{
+		Model: model,
+		Messages: []Message{
+			{Role: "user", Content: prompt},
+		},
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+	}
+	// The Messages API has no JSON mode; forcing a tool call whose input schema
+	// is the response schema gets the same guarantee
+	if schema := config.ResponseSchema; schema != nil {
+		reqBody.Tools = []AnthropicTool{{
+			Name:        schema.Name,
+			Description: "Return the result as structured data",
+			InputSchema: schema.Schema,
+		}}
+		reqBody.ToolChoice = &AnthropicToolChoice{Type: "tool", Name: schema.Name}
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := strings.TrimRight(config.APIEndpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://api.anthropic.com"
+	}
+	url := endpoint + "/v1/messages"
+	if strings.HasSuffix(endpoint, "/v1") {
+		url = endpoint + "/messages"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", config.APIKey)
+	req.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var anthropicResp AnthropicResponse
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		var message string
+		if err := json.Unmarshal(body, &anthropicResp); err == nil && anthropicResp.Error != nil {
+			message = fmt.Sprintf("%s (type: %s)", anthropicResp.Error.Message, anthropicResp.Error.Type)
+		}
+		return "", newAIError("Anthropic", resp.StatusCode, message).withRetryAfter(resp.Header)
+	}
+
+	if err := json.Unmarshal(body, &anthropicResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if anthropicResp.Error != nil {
+		return "", newAIError("Anthropic", resp.StatusCode, fmt.Sprintf("%s (type: %s)", anthropicResp.Error.Message, anthropicResp.Error.Type))
+	}
+
+	config.reportUsage(Usage{
+		PromptTokens:     anthropicResp.Usage.InputTokens,
+		CompletionTokens: anthropicResp.Usage.OutputTokens,
+	})
+
+	var text strings.Builder
+	for _, block := range anthropicResp.Content {
+		if block.Type == "tool_use" && config.ResponseSchema != nil && block.Name == config.ResponseSchema.Name {
+			return string(block.Input), nil
+		}
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no response from Anthropic")
+	}
+
+	return text.String(), nil
+}
+
+// CallStream calls the Anthropic API without streaming and delivers the
+// completion as a single chunk
+func (c *AnthropicClient) CallStream(ctx context.Context, prompt string, config *AIClientConfig, onChunk func(chunk string)) (string, error) {
+	response, err := c.Call(ctx, prompt, config)
+	if err != nil {
+		return "", err
+	}
+	onChunk(response)
+	return response, nil
+}
+
+// TestConnection tests the connection to the Anthropic API
+func (c *AnthropicClient) TestConnection(ctx context.Context, config *AIClientConfig) error {
+	_, err := c.Call(ctx, "Hello, this is a test message.", config)
+	return err
+}
+
+// DeepSeekClient implements AIClient for DeepSeek. The API is OpenAI-compatible,
+// so requests go through OpenAIClient with DeepSeek's endpoint and model defaults.
+type DeepSeekClient struct {
+	openAI OpenAIClient
+}
+
+// Call sends a request to the DeepSeek chat completions API
+func (c *DeepSeekClient) Call(ctx context.Context, prompt string, config *AIClientConfig) (string, error) {
+	return c.openAI.Call(ctx, prompt, c.withDefaults(config))
+}
+
+// CallStream streams a completion from the DeepSeek chat completions API
+func (c *DeepSeekClient) CallStream(ctx context.Context, prompt string, config *AIClientConfig, onChunk func(chunk string)) (string, error) {
+	return c.openAI.CallStream(ctx, prompt, c.withDefaults(config), onChunk)
+}
+
+// withDefaults fills in DeepSeek's endpoint and model
+func (c *DeepSeekClient) withDefaults(config *AIClientConfig) *AIClientConfig {
+	cfg := *config
+	if cfg.APIEndpoint == "" {
+		cfg.APIEndpoint = "https://api.deepseek.com/v1"
+	}
+	if cfg.Model == "" {
+		cfg.Model = "deepseek-chat"
+	}
+	return &cfg
+}
+
+// TestConnection tests the connection to DeepSeek API
+func (c *DeepSeekClient) TestConnection(ctx context.Context, config *AIClientConfig) error {
+	_, err := c.Call(ctx, "你好，这是一条测试消息。", config)
+	return err
+}
+
+// OllamaClient implements AIClient for local LLM servers exposing the
+// OpenAI-compatible API without an API key, such as Ollama or LM Studio
+type OllamaClient struct {
+	openAI OpenAIClient
+}
+
+// Call sends a request to the local chat completions endpoint
+func (c *OllamaClient) Call(ctx context.Context, prompt string, config *AIClientConfig) (string, error) {
+	return c.openAI.Call(ctx, prompt, c.withDefaults(config))
+}
+
+// CallStream streams a completion from the local chat completions endpoint
+func (c *OllamaClient) CallStream(ctx context.Context, prompt string, config *AIClientConfig, onChunk func(chunk string)) (string, error) {
+	return c.openAI.CallStream(ctx, prompt, c.withDefaults(config), onChunk)
+}
+
+// withDefaults fills in the default local endpoint and model
+func (c *OllamaClient) withDefaults(config *AIClientConfig) *AIClientConfig {
+	cfg := *config
+	if cfg.APIEndpoint == "" {
+		cfg.APIEndpoint = "http://localhost:11434/v1"
+	}
+	if cfg.Model == "" {
+		cfg.Model = "llama3.1"
+	}
+	return &cfg
+}
+
+// TestConnection tests the connection to the local LLM server
+func (c *OllamaClient) TestConnection(ctx context.Context, config *AIClientConfig) error {
+	_, err := c.Call(ctx, "Hello, this is a test message.", config)
+	return err
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// CoachChatParams holds a user's question to the AI coach and the context
+// it is answered in. Plans, body data and the day plans may be nil.
+type CoachChatParams struct {
+	UserID  int64
+	AIAPIID int64
+	Message string
+	// History is the earlier conversation, oldest first
+	History []*model.CoachMessage
+	Now     time.Time
+
+	TrainingPlan     *model.TrainingPlan
+	TodayTraining    *model.DayPlan
+	TomorrowTraining *model.DayPlan
+	NutritionPlan    *model.NutritionPlan
+	TodayMeals       []model.NutritionPlanMeal
+	BodyData         *model.UserBodyData
+	FitnessGoals     []*model.FitnessGoal
+	Constraints      []*model.TrainingConstraint
+}
+
+// CoachReply answers a user's question with the AI API in params and
+// returns the reply text. Calls are retried like plan generation.
+func (s *aiService) CoachReply(ctx context.Context, params *CoachChatParams) (string, error) {
+	aiAPI, err := s.aiAPIRepo.GetByID(ctx, params.AIAPIID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get AI API: %w", err)
+	}
+	if aiAPI == nil {
+		return "", fmt.Errorf("AI API not found")
+	}
+
+	prompt, err := s.buildCoachPrompt(ctx, params)
+	if err != nil {
+		return "", err
+	}
+
+	apiKey, err := s.encryptor.Decrypt(aiAPI.APIKeyEncrypted)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt API key: %w", err)
+	}
+
+	client, err := s.newClient(aiAPI, s.callLogger(ctx, params.UserID, aiAPI, model.AIUsagePurposeCoachChat))
+	if err != nil {
+		return "", err
+	}
+
+	if s.abuseDetector != nil {
+		if err := s.abuseDetector.CheckGeneration(ctx, params.UserID, aiAPI, params.Message); err != nil {
+			return "", err
+		}
+	}
+
+	// No response schema: the coach answers in plain text
+	config := NewAIClientFromModel(aiAPI, apiKey, s.timeout)
+	config.OnUsage = s.usageRecorder(ctx, params.UserID, aiAPI, model.AIUsagePurposeCoachChat)
+
+	var lastErr error
+	for attempt := 0; attempt <= s.maxRetries; attempt++ {
+		if err := s.waitForAttempt(ctx, aiAPI, attempt, nil); err != nil {
+			return "", err
+		}
+
+		callStart := time.Now()
+		response, err := client.Call(ctx, prompt, config)
+		if IsCircuitOpen(err) {
+			return "", err
+		}
+		s.retryTuner.Observe(aiAPI.Provider, time.Since(callStart), err)
+		if err != nil {
+			if !IsRetryableAIError(err) {
+				return "", fmt.Errorf("AI API rejected the request, not retrying: %w", err)
+			}
+			if cooldownErr := s.startCooldown(aiAPI, attempt, err); cooldownErr != nil {
+				return "", cooldownErr
+			}
+			lastErr = err
+			continue
+		}
+
+		reply := strings.TrimSpace(response)
+		if reply == "" {
+			lastErr = fmt.Errorf("AI API returned an empty reply")
+			continue
+		}
+		return reply, nil
+	}
+
+	return "", fmt.Errorf("failed to get coach reply after %d attempts: %w", s.maxRetries+1, lastErr)
+}
+
+// coachDayPlanSummary describes a scheduled workout in one line, or returns
+// "" when there is none
+func coachDayPlanSummary(day *model.DayPlan) string {
+	if day == nil {
+		return ""
+	}
+	if day.Type == "rest" {
+		return "休息日"
+	}
+
+	summary := day.Type
+	if day.FocusArea != "" {
+		summary += "（" + day.FocusArea + "）"
+	}
+	if day.Duration > 0 {
+		summary += fmt.Sprintf("，约%d分钟", day.Duration)
+	}
+
+	exercises := make([]string, 0, len(day.Exercises))
+	for _, ex := range day.Exercises {
+		line := fmt.Sprintf("%s %d组×%s", ex.Name, ex.Sets, ex.Reps)
+		if ex.Weight != "" {
+			line += " " + ex.Weight
+		}
+		exercises = append(exercises, line)
+	}
+	if len(exercises) > 0 {
+		summary += "：" + strings.Join(exercises, "；")
+	}
+	return summary
+}
+
+// coachMealLines describes each planned meal as "时间: 食物, 热量"
+func coachMealLines(meals []model.NutritionPlanMeal) []string {
+	lines := make([]string, 0, len(meals))
+	for _, meal := range meals {
+		foods := make([]string, 0, len(meal.Foods))
+		for _, food := range meal.Foods {
+			foods = append(foods, food.Name+" "+food.Amount)
+		}
+		lines = append(lines, fmt.Sprintf("%s：%s，约%.0f千卡", meal.Time, strings.Join(foods, "、"), meal.TotalCalories))
+	}
+	return lines
+}
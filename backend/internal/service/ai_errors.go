@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AIError is returned by AIClient calls that the provider answered with an
+// error. Retryable is false for errors that would fail the same way again,
+// such as an invalid API key or a malformed request.
+type AIError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+	Retryable  bool
+	// RetryAfter is the wait the provider asked for in a Retry-After
+	// header, or 0 if it gave none
+	RetryAfter time.Duration
+	// kind is ErrProviderRateLimited or ErrProviderUnavailable, if either applies
+	kind error
+}
+
+// newAIError classifies a provider error by its HTTP status. Throttling,
+// timeouts and 5xx responses are retryable; other 4xx responses are not.
+func newAIError(provider string, statusCode int, message string) *AIError {
+	e := &AIError{
+		Provider:   provider,
+		StatusCode: statusCode,
+		Message:    message,
+		Retryable:  true,
+	}
+	switch {
+	// 529 is Anthropic's overloaded status and should back off like a 429
+	case statusCode == http.StatusTooManyRequests || statusCode == 529:
+		e.kind = ErrProviderRateLimited
+	case statusCode >= http.StatusInternalServerError:
+		e.kind = ErrProviderUnavailable
+	case statusCode == http.StatusRequestTimeout:
+	case statusCode >= http.StatusBadRequest:
+		e.Retryable = false
+	}
+	return e
+}
+
+// withRetryAfter records the Retry-After header of the response e was built
+// from. It returns e so it can wrap newAIError.
+func (e *AIError) withRetryAfter(header http.Header) *AIError {
+	e.RetryAfter = parseRetryAfter(header.Get("Retry-After"), time.Now())
+	return e
+}
+
+// parseRetryAfter reads a Retry-After value, either delay seconds or an HTTP
+// date. Missing, malformed and past values give 0.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// Error returns the provider, status and message
+func (e *AIError) Error() string {
+	msg := fmt.Sprintf("%s API error: status %d", e.Provider, e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.kind != nil {
+		msg += ": " + e.kind.Error()
+	}
+	return msg
+}
+
+// Unwrap exposes ErrProviderRateLimited and ErrProviderUnavailable to errors.Is
+func (e *AIError) Unwrap() error {
+	return e.kind
+}
+
+// IsRetryableAIError reports whether a failed AI call may succeed if made
+// again. Errors that are not an AIError, such as network failures or
+// unparsable responses, are retryable.
+func IsRetryableAIError(err error) bool {
+	var aiErr *AIError
+	if errors.As(err, &aiErr) {
+		return aiErr.Retryable
+	}
+	return true
+}
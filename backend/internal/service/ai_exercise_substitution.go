@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// ExerciseSubstitutionParams holds the exercise of a training plan day to
+// replace and what its substitute must avoid. Equipment and joints are
+// exercise library codes.
+type ExerciseSubstitutionParams struct {
+	UserID  int64
+	AIAPIID int64
+	Plan    *model.TrainingPlan
+	Date    time.Time
+	// CurrentDay is the plan data of the day, Exercise the entry replaced
+	CurrentDay           map[string]interface{}
+	Exercise             map[string]interface{}
+	UnavailableEquipment []string
+	AvoidJoints          []string
+	Notes                string
+	Constraints          []*model.TrainingConstraint
+}
+
+// SubstituteExercise asks the AI for an exercise to replace params.Exercise
+// and returns it as plan data. Substitutes naming a restricted movement or
+// repeating the original are rejected and retried like parse failures.
+func (s *aiService) SubstituteExercise(ctx context.Context, params *ExerciseSubstitutionParams) (model.JSONMap, error) {
+	aiAPI, err := s.aiAPIRepo.GetByID(ctx, params.AIAPIID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI API: %w", err)
+	}
+	if aiAPI == nil {
+		return nil, fmt.Errorf("AI API not found")
+	}
+
+	prompt, err := s.buildExerciseSubstitutionPrompt(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, err := s.encryptor.Decrypt(aiAPI.APIKeyEncrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt API key: %w", err)
+	}
+
+	client, err := s.newClient(aiAPI, s.callLogger(ctx, params.UserID, aiAPI, model.AIUsagePurposeTrainingPlan))
+	if err != nil {
+		return nil, err
+	}
+
+	if s.abuseDetector != nil {
+		if err := s.abuseDetector.CheckGeneration(ctx, params.UserID, aiAPI, params.Notes); err != nil {
+			return nil, err
+		}
+	}
+
+	config := NewAIClientFromModel(aiAPI, apiKey, s.timeout)
+	config.ResponseSchema = exerciseResponseSchema
+	config.OnUsage = s.usageRecorder(ctx, params.UserID, aiAPI, model.AIUsagePurposeTrainingPlan)
+
+	original, _ := params.Exercise["name"].(string)
+	var lastErr error
+	for attempt := 0; attempt <= s.maxRetries; attempt++ {
+		if err := s.waitForAttempt(ctx, aiAPI, attempt, nil); err != nil {
+			return nil, err
+		}
+
+		callStart := time.Now()
+		response, err := client.Call(ctx, prompt, config)
+		if IsCircuitOpen(err) {
+			return nil, err
+		}
+		s.retryTuner.Observe(aiAPI.Provider, time.Since(callStart), err)
+		if err != nil {
+			if !IsRetryableAIError(err) {
+				return nil, fmt.Errorf("AI API rejected the request, not retrying: %w", err)
+			}
+			if cooldownErr := s.startCooldown(aiAPI, attempt, err); cooldownErr != nil {
+				return nil, cooldownErr
+			}
+			lastErr = err
+			continue
+		}
+
+		exercise, err := s.parseExerciseResponse(response)
+		if err != nil {
+			s.recordParseFailure(ctx, aiAPI, model.AIUsagePurposeTrainingPlan, err)
+			lastErr = err
+			continue
+		}
+		name, _ := exercise["name"].(string)
+		if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(original)) {
+			lastErr = fmt.Errorf("AI returned the original exercise %q", name)
+			continue
+		}
+		if movement := restrictedMovementIn(name, params.Constraints); movement != "" {
+			lastErr = fmt.Errorf("substitute %q includes restricted movement %q", name, movement)
+			continue
+		}
+		return exercise, nil
+	}
+
+	return nil, fmt.Errorf("failed to substitute exercise after %d attempts: %w", s.maxRetries+1, lastErr)
+}
+
+// parseExerciseResponse parses the AI response for a single exercise. An
+// exercise wrapped in an object or list of one is unwrapped.
+func (s *aiService) parseExerciseResponse(response string) (model.JSONMap, error) {
+	jsonStr := planJSON(response)
+	if jsonStr == "" {
+		return nil, missingJSONFailure(response)
+	}
+
+	var exercise model.JSONMap
+	if err := json.Unmarshal([]byte(jsonStr), &exercise); err != nil {
+		return nil, unmarshalFailure(jsonStr, err)
+	}
+	if inner, ok := exercise["exercise"].(map[string]interface{}); ok {
+		exercise = inner
+	} else if list, ok := exercise["exercises"].([]interface{}); ok && len(list) == 1 {
+		if inner, ok := list[0].(map[string]interface{}); ok {
+			exercise = inner
+		}
+	}
+
+	// Validate structure
+	name, ok := exercise["name"].(string)
+	if !ok || strings.TrimSpace(name) == "" {
+		return nil, parseFailure(ParseFailureWrongSchema, "invalid exercise structure: missing 'name' field")
+	}
+	if _, ok := exercise["sets"].(float64); !ok {
+		return nil, parseFailure(ParseFailureWrongSchema, "invalid exercise structure: 'sets' is not a number")
+	}
+
+	return exercise, nil
+}
+
+// restrictedMovementIn returns the first movement of constraints that name
+// contains, or ""
+func restrictedMovementIn(name string, constraints []*model.TrainingConstraint) string {
+	lower := strings.ToLower(name)
+	for _, c := range constraints {
+		for _, m := range c.Movements() {
+			if strings.Contains(lower, strings.ToLower(m)) {
+				return m
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// concurrencyLimitedClient implements AIClient around another client, holding
+// one slot of a semaphore shared by all clients for the duration of each call.
+// Callers beyond the limit wait in FIFO order until a slot frees up or their
+// context ends.
+type concurrencyLimitedClient struct {
+	client AIClient
+	sem    *semaphore.Weighted
+}
+
+// limitConcurrency wraps client with sem; a nil sem means no limit
+func limitConcurrency(client AIClient, sem *semaphore.Weighted) AIClient {
+	if sem == nil {
+		return client
+	}
+	return &concurrencyLimitedClient{client: client, sem: sem}
+}
+
+// Call sends the request once a slot is free
+func (c *concurrencyLimitedClient) Call(ctx context.Context, prompt string, config *AIClientConfig) (string, error) {
+	if err := c.sem.Acquire(ctx, 1); err != nil {
+		return "", err
+	}
+	defer c.sem.Release(1)
+	return c.client.Call(ctx, prompt, config)
+}
+
+// CallStream streams the completion once a slot is free, holding it until the
+// stream ends
+func (c *concurrencyLimitedClient) CallStream(ctx context.Context, prompt string, config *AIClientConfig, onChunk func(chunk string)) (string, error) {
+	if err := c.sem.Acquire(ctx, 1); err != nil {
+		return "", err
+	}
+	defer c.sem.Release(1)
+	return c.client.CallStream(ctx, prompt, config, onChunk)
+}
+
+// TestConnection tests the connection once a slot is free
+func (c *concurrencyLimitedClient) TestConnection(ctx context.Context, config *AIClientConfig) error {
+	if err := c.sem.Acquire(ctx, 1); err != nil {
+		return err
+	}
+	defer c.sem.Release(1)
+	return c.client.TestConnection(ctx, config)
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Limits of the archive
+const (
+	// maxArchivedResponseBytes caps a stored response; plans are far smaller,
+	// so only a runaway completion is cut short
+	maxArchivedResponseBytes = 1 << 20
+	// archivePurgeBatchSize is how many archives a purge deletes per query
+	archivePurgeBatchSize = 500
+)
+
+// archiveRedactions mask personal data and credentials that a prompt may
+// have carried into the AI's response. Credentials go first so a key is not
+// half matched as a phone number.
+var archiveRedactions = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9._~+/=-]{8,}`), "[REDACTED_TOKEN]"},
+	{regexp.MustCompile(`\b(?:sk|pk|rk)-[A-Za-z0-9_-]{16,}`), "[REDACTED_KEY]"},
+	{regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`), "[REDACTED_KEY]"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{32,}\b`), "[REDACTED_KEY]"},
+	{regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}\b`), "[REDACTED_EMAIL]"},
+	{regexp.MustCompile(`(?:\+?86[- ]?)?\b1[3-9]\d{9}\b`), "[REDACTED_PHONE]"},
+	{regexp.MustCompile(`\+\d{1,3}[- ]?\d{3,4}[- ]?\d{3,4}[- ]?\d{3,4}\b`), "[REDACTED_PHONE]"},
+}
+
+// redactResponse masks personal data and credentials in an AI response and
+// cuts it to maxArchivedResponseBytes on a character boundary
+func redactResponse(response string) string {
+	for _, r := range archiveRedactions {
+		response = r.pattern.ReplaceAllString(response, r.replacement)
+	}
+	if len(response) > maxArchivedResponseBytes {
+		cut := maxArchivedResponseBytes
+		for cut > 0 && !utf8.RuneStart(response[cut]) {
+			cut--
+		}
+		response = response[:cut]
+	}
+	return response
+}
+
+// ResponseArchiveService keeps the redacted AI responses generated plans
+// were parsed from for a limited time, so support can see what the AI
+// actually advised when a user disputes a plan
+type ResponseArchiveService interface {
+	// Archive stores the response of a generation task's plan, unless
+	// archiving is disabled or the user opted out. Failures are logged; the
+	// archive must not fail the task.
+	Archive(taskID, taskType string, userID, planID, aiAPIID int64, response string)
+	// Get returns an archived response for an admin, logging the access
+	Get(ctx context.Context, adminID, id int64) (*model.AIResponseArchive, error)
+	// Purge deletes expired archives and those of users who opted out, and
+	// returns how many it deleted
+	Purge(ctx context.Context) (int, error)
+}
+
+// responseArchiveService implements ResponseArchiveService interface
+type responseArchiveService struct {
+	archiveRepo repository.AIResponseArchiveRepository
+	userRepo    repository.UserRepository
+	aiAPIRepo   repository.AIAPIRepository
+	enabled     bool
+	retention   time.Duration
+}
+
+// NewResponseArchiveService creates a new instance of ResponseArchiveService.
+// Archives are kept for retentionDays; while disabled nothing new is
+// archived, but existing archives can still be read and purged.
+func NewResponseArchiveService(
+	archiveRepo repository.AIResponseArchiveRepository,
+	userRepo repository.UserRepository,
+	aiAPIRepo repository.AIAPIRepository,
+	enabled bool,
+	retentionDays int,
+) ResponseArchiveService {
+	return &responseArchiveService{
+		archiveRepo: archiveRepo,
+		userRepo:    userRepo,
+		aiAPIRepo:   aiAPIRepo,
+		enabled:     enabled && retentionDays > 0,
+		retention:   time.Duration(retentionDays) * 24 * time.Hour,
+	}
+}
+
+// Archive runs after the plan is saved, with its own timeout so a task
+// whose context is ending still gets its response archived
+func (s *responseArchiveService) Archive(taskID, taskType string, userID, planID, aiAPIID int64, response string) {
+	if !s.enabled || response == "" || aiAPIID == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), taskHistoryTimeout)
+	defer cancel()
+
+	fields := []zap.Field{
+		zap.String("task_id", taskID),
+		zap.Int64("user_id", userID),
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		logger.Warn("Failed to archive AI response", append(fields, zap.Error(err))...)
+		return
+	}
+	if user == nil || user.ResponseArchiveOptOut {
+		return
+	}
+
+	archive := &model.AIResponseArchive{
+		TaskID:   taskID,
+		UserID:   userID,
+		TaskType: taskType,
+		PlanID:   planID,
+		AIAPIID:  aiAPIID,
+		Provider: queueProviderUnknown,
+		Response: redactResponse(response),
+	}
+	// The archive outlives changes to the AI API, so it keeps its own copy
+	// of the provider and model
+	if aiAPI, err := s.aiAPIRepo.GetByID(ctx, aiAPIID); err == nil && aiAPI != nil {
+		archive.Provider = aiAPI.Provider
+		if aiAPI.Model != nil {
+			archive.Model = *aiAPI.Model
+		}
+	}
+	archive.CreatedAt = time.Now()
+	archive.ExpiresAt = archive.CreatedAt.Add(s.retention)
+
+	if err := s.archiveRepo.Create(ctx, archive); err != nil {
+		logger.Warn("Failed to archive AI response", append(fields, zap.Error(err))...)
+	}
+}
+
+// Get logs who read the archive, as it holds what the user asked the AI
+func (s *responseArchiveService) Get(ctx context.Context, adminID, id int64) (*model.AIResponseArchive, error) {
+	archive, err := s.archiveRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取AI响应存档失败")
+	}
+	if archive == nil {
+		return nil, errors.New(errors.ErrNotFound, "AI响应存档不存在或已过期")
+	}
+
+	logger.Info("Admin viewed AI response archive",
+		zap.Int64("admin_id", adminID),
+		zap.Int64("archive_id", id),
+		zap.Int64("user_id", archive.UserID),
+		zap.String("task_id", archive.TaskID),
+	)
+	return archive, nil
+}
+
+// Purge deletes in batches until nothing is left to delete
+func (s *responseArchiveService) Purge(ctx context.Context) (int, error) {
+	total := 0
+	for _, deleteBatch := range []func(ctx context.Context) (int64, error){
+		func(ctx context.Context) (int64, error) {
+			return s.archiveRepo.DeleteExpired(ctx, time.Now(), archivePurgeBatchSize)
+		},
+		func(ctx context.Context) (int64, error) {
+			return s.archiveRepo.DeleteOptedOut(ctx, archivePurgeBatchSize)
+		},
+	} {
+		for {
+			deleted, err := deleteBatch(ctx)
+			if err != nil {
+				return total, errors.Wrap(err, errors.ErrDatabase, "清除AI响应存档失败")
+			}
+			total += int(deleted)
+			if deleted < archivePurgeBatchSize {
+				break
+			}
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// AIResponseCache keeps recent AI completions so regenerating a plan with
+// identical parameters does not pay for another completion
+type AIResponseCache interface {
+	// Get returns the cached completion for key, if any
+	Get(ctx context.Context, key string) (string, bool)
+	// Set caches a completion that parsed into a valid plan
+	Set(ctx context.Context, key, response string)
+}
+
+// redisAIResponseCache implements AIResponseCache using Redis
+type redisAIResponseCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewAIResponseCache creates a Redis-backed AIResponseCache. It returns nil,
+// which disables caching, when ttl is 0 or less.
+func NewAIResponseCache(client *redis.Client, ttl time.Duration) AIResponseCache {
+	if client == nil || ttl <= 0 {
+		return nil
+	}
+	return &redisAIResponseCache{client: client, ttl: ttl}
+}
+
+// AIResponseCacheKey derives the cache key for a prompt sent to an API. The
+// user, API config and model are part of the key so a completion is only
+// reused for the same user asking the same model the same thing.
+func AIResponseCacheKey(userID int64, api *model.AIAPI, purpose, prompt string) string {
+	modelName := ""
+	if api.Model != nil {
+		modelName = *api.Model
+	}
+	sum := sha256.Sum256([]byte(prompt))
+	return fmt.Sprintf("ai:response:%d:%d:%s:%s:%s", userID, api.ID, purpose, modelName, hex.EncodeToString(sum[:]))
+}
+
+// aiResponseCacheUserPattern matches every cached response of a user
+func aiResponseCacheUserPattern(userID int64) string {
+	return fmt.Sprintf("ai:response:%d:*", userID)
+}
+
+// Get looks up key. Redis failures are logged and treated as a miss.
+func (c *redisAIResponseCache) Get(ctx context.Context, key string) (string, bool) {
+	response, err := c.client.Get(ctx, key).Result()
+	if err != nil {
+		if err != redis.Nil {
+			logger.Warn("AI response cache lookup failed", zap.Error(err))
+		}
+		return "", false
+	}
+	return response, true
+}
+
+// Set stores response under key for the cache TTL
+func (c *redisAIResponseCache) Set(ctx context.Context, key, response string) {
+	if err := c.client.Set(ctx, key, response, c.ttl).Err(); err != nil {
+		logger.Warn("AI response cache store failed", zap.Error(err))
+	}
+}
+
+// cachedPlan returns the plan parsed from the cached completion for key, and
+// the completion.
+// Streaming callers receive the cached text as a single chunk.
+func (s *aiService) cachedPlan(ctx context.Context, key string, parse func(string) (model.JSONMap, error), onChunk func(chunk string)) (model.JSONMap, string, bool) {
+	if s.responseCache == nil {
+		return nil, "", false
+	}
+	response, ok := s.responseCache.Get(ctx, key)
+	if !ok {
+		return nil, "", false
+	}
+	planData, err := parse(response)
+	if err != nil {
+		return nil, "", false
+	}
+
+	if onChunk != nil {
+		onChunk(response)
+	}
+	logger.Info("Serving AI plan from response cache", zap.String("cache_key", key))
+	return planData, response, true
+}
+
+// cacheResponse caches a completion that parsed into a valid plan
+func (s *aiService) cacheResponse(ctx context.Context, key, response string) {
+	if s.responseCache != nil {
+		s.responseCache.Set(ctx, key, response)
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"math"
+	"strings"
 	"time"
 
+	"github.com/ai-fitness-planner/backend/internal/errors"
 	"github.com/ai-fitness-planner/backend/internal/model"
 	"github.com/ai-fitness-planner/backend/internal/pkg/crypto"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
 	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
 )
 
 // AIService defines the interface for AI integration operations
@@ -18,30 +22,93 @@ type AIService interface {
 	GenerateTrainingPlan(ctx context.Context, params *TrainingPlanParams) (*model.TrainingPlan, error)
 	// GenerateNutritionPlan generates a nutrition plan using AI
 	GenerateNutritionPlan(ctx context.Context, params *NutritionPlanParams) (*model.NutritionPlan, error)
+	// AdjustTrainingPlan generates a revised version of a training plan from
+	// the user's execution data and feedback
+	AdjustTrainingPlan(ctx context.Context, params *TrainingAdjustmentParams) (*model.TrainingPlan, error)
+	// AdjustNutritionPlan generates a revised version of a nutrition plan
+	AdjustNutritionPlan(ctx context.Context, params *NutritionAdjustmentParams) (*model.NutritionPlan, error)
+	// RegenerateNutritionDay generates new meals for one day of a nutrition plan
+	RegenerateNutritionDay(ctx context.Context, params *NutritionDayParams) (model.JSONMap, error)
+	// RegenerateTrainingWeek generates a new version of one week of a
+	// training plan
+	RegenerateTrainingWeek(ctx context.Context, params *TrainingWeekParams) (model.JSONMap, error)
+	// SubstituteExercise suggests a replacement for one exercise of a
+	// training plan day
+	SubstituteExercise(ctx context.Context, params *ExerciseSubstitutionParams) (model.JSONMap, error)
+	// CoachReply answers a user's question to the AI coach
+	CoachReply(ctx context.Context, params *CoachChatParams) (string, error)
 	// TestConnection tests the connection to an AI API
 	TestConnection(ctx context.Context, apiID int64, userID int64) error
 }
 
 // aiService implements AIService interface
 type aiService struct {
-	aiAPIRepo  repository.AIAPIRepository
-	encryptor  crypto.Encryptor
-	maxRetries int
-	retryDelay time.Duration
+	aiAPIRepo     repository.AIAPIRepository
+	encryptor     crypto.Encryptor
+	abuseDetector AbuseDetector
+	maxRetries    int
+	timeout       time.Duration
+	retryTuner    *ProviderRetryTuner
+	breaker       *ProviderCircuitBreaker
+	cooldowns     *providerCooldowns
+	callSlots     *semaphore.Weighted
+	usageRepo     repository.AIUsageRepository
+	responseCache AIResponseCache
+	templateRepo  repository.PromptTemplateRepository
+	callLogRepo   repository.AICallLogRepository
+	parseMetrics  ParseFailureMetrics
+	policy        *ProviderPolicy
 }
 
-// NewAIService creates a new instance of AIService
+// NewAIService creates a new instance of AIService.
+// abuseDetector may be nil to disable abuse checks.
+// timeout bounds each AI call unless the API sets its own.
+// retryTuner supplies the per-provider retry backoff; breaker may be nil to
+// call providers unguarded.
+// maxConcurrentRequests caps outbound AI calls in flight across all plan
+// generation goroutines; further calls queue. 0 or less means no cap.
+// usageRepo may be nil to skip recording token usage.
+// responseCache may be nil to always call the provider.
+// templateRepo may be nil to always use the built-in prompt templates.
+// callLogRepo may be nil to skip the per-call audit log.
+// parseMetrics may be nil to skip counting plan parse failures.
+// policy may be nil to allow every provider.
 func NewAIService(
 	aiAPIRepo repository.AIAPIRepository,
 	encryptor crypto.Encryptor,
+	abuseDetector AbuseDetector,
 	maxRetries int,
-	retryDelay time.Duration,
+	timeout time.Duration,
+	retryTuner *ProviderRetryTuner,
+	breaker *ProviderCircuitBreaker,
+	maxConcurrentRequests int,
+	usageRepo repository.AIUsageRepository,
+	responseCache AIResponseCache,
+	templateRepo repository.PromptTemplateRepository,
+	callLogRepo repository.AICallLogRepository,
+	parseMetrics ParseFailureMetrics,
+	policy *ProviderPolicy,
 ) AIService {
+	var callSlots *semaphore.Weighted
+	if maxConcurrentRequests > 0 {
+		callSlots = semaphore.NewWeighted(int64(maxConcurrentRequests))
+	}
 	return &aiService{
-		aiAPIRepo:  aiAPIRepo,
-		encryptor:  encryptor,
-		maxRetries: maxRetries,
-		retryDelay: retryDelay,
+		aiAPIRepo:     aiAPIRepo,
+		encryptor:     encryptor,
+		abuseDetector: abuseDetector,
+		maxRetries:    maxRetries,
+		timeout:       timeout,
+		retryTuner:    retryTuner,
+		breaker:       breaker,
+		cooldowns:     newProviderCooldowns(),
+		callSlots:     callSlots,
+		usageRepo:     usageRepo,
+		responseCache: responseCache,
+		templateRepo:  templateRepo,
+		callLogRepo:   callLogRepo,
+		parseMetrics:  parseMetrics,
+		policy:        policy,
 	}
 }
 
@@ -56,6 +123,32 @@ type TrainingPlanParams struct {
 	Assessment      *model.FitnessAssessment
 	BodyData        *model.UserBodyData
 	FitnessGoals    []*model.FitnessGoal
+	LatestCheckIn   *model.WeeklyCheckIn
+	StrengthProfile []*model.StrengthProfileEntry
+	// EquipmentProfiles replace Assessment.EquipmentAvailable when present
+	EquipmentProfiles []*model.EquipmentProfile
+	// Constraints are medical restrictions; a plan that programs one of
+	// their restricted movements is rejected and regenerated
+	Constraints []*model.TrainingConstraint
+	// BusySchedule, when set, lists days the plan must leave for rest
+	BusySchedule *BusySchedule
+	// Periodization, when set, sets the plan's style and deload weeks; a
+	// plan that misses a deload week is rejected and regenerated
+	Periodization *Periodization
+	// Block, when set, generates the plan as the next block of a macrocycle
+	Block *MacrocycleBlock
+	// UserInput is the free text the user wrote for the request, checked by
+	// the abuse detector; PlanName and Goal are used when it is nil
+	UserInput []string
+	// OnChunk, when set, receives the completion text as it streams in.
+	// OnRetry is called before each retry, whose text replaces what was
+	// streamed so far. OnCooldown is called when generation pauses for a
+	// throttling provider, and with nil when it resumes. OnResponse receives
+	// the raw completion the plan was parsed from.
+	OnChunk    func(chunk string)
+	OnRetry    func()
+	OnCooldown func(cooldown *ProviderCooldown)
+	OnResponse func(response string)
 }
 
 // NutritionPlanParams holds parameters for nutrition plan generation
@@ -64,17 +157,29 @@ type NutritionPlanParams struct {
 	PlanName            string
 	DurationDays        int
 	DailyCalories       float64
+	CalorieBasis        string // how DailyCalories was arrived at, a model.CalorieBasis* value
 	ProteinRatio        float64
 	CarbRatio           float64
 	FatRatio            float64
 	DietaryRestrictions []string
 	Preferences         []string
+	LeftoverLunch       bool // each day's lunch is the previous day's dinner
+	BudgetLevel         string
+	WeeklyBudget        *float64 // CNY; takes precedence over BudgetLevel
 	AIAPIID             int64
 	BodyData            *model.UserBodyData
 	FitnessGoals        []*model.FitnessGoal
+	LatestCheckIn       *model.WeeklyCheckIn
+	// OnCooldown is called when generation pauses for a throttling
+	// provider, and with nil when it resumes. OnResponse receives the raw
+	// completion the plan was parsed from.
+	OnCooldown func(cooldown *ProviderCooldown)
+	OnResponse func(response string)
 }
 
-// GenerateTrainingPlan generates a training plan using AI with retry logic
+// GenerateTrainingPlan generates a training plan using AI with retry logic.
+// If the requested API still fails after its retries, the user's fallback
+// chain is tried in order; the plan records the API that produced it.
 func (s *aiService) GenerateTrainingPlan(ctx context.Context, params *TrainingPlanParams) (*model.TrainingPlan, error) {
 	// Get AI API configuration
 	aiAPI, err := s.aiAPIRepo.GetByID(ctx, params.AIAPIID)
@@ -85,6 +190,111 @@ func (s *aiService) GenerateTrainingPlan(ctx context.Context, params *TrainingPl
 		return nil, fmt.Errorf("AI API not found")
 	}
 
+	// Build prompt
+	prompt, templateID, err := s.buildTrainingPlanPrompt(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	planData, usedAPI, err := s.generateTrainingPlanWithFallback(ctx, aiAPI, prompt, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create training plan model
+	startDate := time.Now()
+	endDate := startDate.AddDate(0, 0, params.DurationWeeks*7)
+	provider := usedAPI.Provider
+
+	trainingPlan := &model.TrainingPlan{
+		UserID:           params.UserID,
+		PlanName:         params.PlanName,
+		StartDate:        startDate,
+		EndDate:          endDate,
+		TotalWeeks:       params.DurationWeeks,
+		DifficultyLevel:  params.DifficultyLevel,
+		TrainingPurpose:  &params.Goal,
+		AIAPIID:          &usedAPI.ID,
+		AIProvider:       &provider,
+		PromptTemplateID: templateID,
+		PlanData:         planData,
+		Status:           "active",
+	}
+	if len(params.Constraints) > 0 {
+		planData["applied_constraints"] = appliedConstraints(params.Constraints)
+	}
+	if params.Periodization != nil {
+		params.Periodization.record(planData)
+	}
+	if block := params.Block; block != nil {
+		trainingPlan.MacrocycleID = &block.Macrocycle.ID
+		trainingPlan.BlockNumber = &block.Number
+		trainingPlan.BlockPhase = &block.Phase
+	}
+
+	return trainingPlan, nil
+}
+
+// generateTrainingPlanWithFallback generates plan data with aiAPI and, if it
+// still fails after its retries, with the user's fallback chain in order. It
+// returns the API that produced the plan.
+func (s *aiService) generateTrainingPlanWithFallback(ctx context.Context, aiAPI *model.AIAPI, prompt string, params *TrainingPlanParams) (model.JSONMap, *model.AIAPI, error) {
+	usedAPI := aiAPI
+	planData, err := s.generateTrainingPlanWith(ctx, aiAPI, prompt, params)
+	if err != nil && canFallBack(ctx, err) {
+		chain, chainErr := s.aiAPIRepo.ListFallbackChain(ctx, params.UserID)
+		if chainErr != nil {
+			logger.Warn("Failed to load AI API fallback chain",
+				zap.Int64("user_id", params.UserID),
+				zap.Error(chainErr),
+			)
+		}
+
+		for _, fallback := range chain {
+			if fallback.ID == aiAPI.ID || !s.policy.Allows(fallback.Provider) {
+				continue
+			}
+			logger.Warn("AI API failed, falling back to next API in chain",
+				zap.Int64("user_id", params.UserID),
+				zap.Int64("failed_api_id", usedAPI.ID),
+				zap.Int64("fallback_api_id", fallback.ID),
+				zap.String("fallback_provider", fallback.Provider),
+				zap.Error(err),
+			)
+			if params.OnRetry != nil {
+				params.OnRetry()
+			}
+
+			usedAPI = fallback
+			planData, err = s.generateTrainingPlanWith(ctx, fallback, prompt, params)
+			if err == nil || !canFallBack(ctx, err) {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return planData, usedAPI, nil
+}
+
+// generateTrainingPlanWith calls a single AI API, retrying call and parse
+// failures, and returns the parsed plan data
+func (s *aiService) generateTrainingPlanWith(ctx context.Context, aiAPI *model.AIAPI, prompt string, params *TrainingPlanParams) (model.JSONMap, error) {
+	// A provider the deployment disallows is not served from the cache either
+	if err := s.policy.Check(aiAPI.Provider); err != nil {
+		return nil, err
+	}
+
+	// Serve a regeneration with identical parameters from the cache
+	cacheKey := AIResponseCacheKey(params.UserID, aiAPI, model.AIUsagePurposeTrainingPlan, prompt)
+	if planData, response, ok := s.cachedPlan(ctx, cacheKey, s.parseTrainingPlanResponse, params.OnChunk); ok {
+		if params.OnResponse != nil {
+			params.OnResponse(response)
+		}
+		return planData, nil
+	}
+
 	// Decrypt API key
 	apiKey, err := s.encryptor.Decrypt(aiAPI.APIKeyEncrypted)
 	if err != nil {
@@ -92,65 +302,124 @@ func (s *aiService) GenerateTrainingPlan(ctx context.Context, params *TrainingPl
 	}
 
 	// Get AI client
-	client, err := GetAIClient(aiAPI.Provider)
+	client, err := s.newClient(aiAPI, s.callLogger(ctx, params.UserID, aiAPI, model.AIUsagePurposeTrainingPlan))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get AI client: %w", err)
+		return nil, err
 	}
 
-	// Build prompt
-	prompt := s.buildTrainingPlanPrompt(params)
+	// Reject suspended configs and flag abusive usage before spending the user's quota
+	if s.abuseDetector != nil {
+		userInput := params.UserInput
+		if userInput == nil {
+			userInput = []string{params.PlanName, params.Goal}
+		}
+		if err := s.abuseDetector.CheckGeneration(ctx, params.UserID, aiAPI, userInput...); err != nil {
+			return nil, err
+		}
+	}
 
 	// Create client config
-	config := NewAIClientFromModel(aiAPI, apiKey)
+	config := NewAIClientFromModel(aiAPI, apiKey, s.timeout)
+	config.ResponseSchema = trainingPlanSchema
+	config.OnUsage = s.usageRecorder(ctx, params.UserID, aiAPI, model.AIUsagePurposeTrainingPlan)
 
 	// Call AI with retry logic (including parse errors)
 	var lastErr error
 	for attempt := 0; attempt <= s.maxRetries; attempt++ {
-		if attempt > 0 {
-			// Exponential backoff
-			backoff := time.Duration(math.Pow(2, float64(attempt-1))) * s.retryDelay
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(backoff):
-			}
+		// Exponential backoff from the provider's tuned base delay, or
+		// longer while the provider is throttling us
+		if err := s.waitForAttempt(ctx, aiAPI, attempt, params.OnCooldown); err != nil {
+			return nil, err
 		}
 
-		response, err := client.Call(ctx, prompt, config)
+		callStart := time.Now()
+		var response string
+		if params.OnChunk != nil {
+			if attempt > 0 && params.OnRetry != nil {
+				params.OnRetry()
+			}
+			response, err = client.CallStream(ctx, prompt, config, params.OnChunk)
+		} else {
+			response, err = client.Call(ctx, prompt, config)
+		}
+		if IsCircuitOpen(err) {
+			// Retrying within the cooldown would only be skipped again
+			return nil, err
+		}
+		s.retryTuner.Observe(aiAPI.Provider, time.Since(callStart), err)
 		if err != nil {
+			if !IsRetryableAIError(err) {
+				// An invalid key or malformed request fails the same way every time
+				return nil, fmt.Errorf("AI API rejected the request, not retrying: %w", err)
+			}
+			if cooldownErr := s.startCooldown(aiAPI, attempt, err); cooldownErr != nil {
+				return nil, cooldownErr
+			}
 			lastErr = err
 			continue
 		}
 
 		planData, err := s.parseTrainingPlanResponse(response)
 		if err != nil {
+			s.recordParseFailure(ctx, aiAPI, model.AIUsagePurposeTrainingPlan, err)
 			lastErr = err
 			continue
 		}
-
-		// Create training plan model
-		startDate := time.Now()
-		endDate := startDate.AddDate(0, 0, params.DurationWeeks*7)
-
-		trainingPlan := &model.TrainingPlan{
-			UserID:          params.UserID,
-			PlanName:        params.PlanName,
-			StartDate:       startDate,
-			EndDate:         endDate,
-			TotalWeeks:      params.DurationWeeks,
-			DifficultyLevel: params.DifficultyLevel,
-			TrainingPurpose: &params.Goal,
-			AIAPIID:         params.AIAPIID,
-			PlanData:        planData,
-			Status:          "active",
+		raw := response
+		// Translate stray English fields rather than regenerating the plan;
+		// the repaired plan is what gets cached
+		if s.repairPlanLanguage(ctx, client, config, params.UserID, planData) {
+			if repaired, err := json.Marshal(planData); err == nil {
+				response = string(repaired)
+			}
+		}
+		if violations := constraintViolations(planData, params.Constraints); len(violations) > 0 {
+			lastErr = constraintViolationError(violations)
+			continue
+		}
+		if violations := deloadViolations(planData, params.Periodization, params.DurationWeeks); len(violations) > 0 {
+			lastErr = deloadViolationError(violations)
+			continue
 		}
 
-		return trainingPlan, nil
+		s.cacheResponse(ctx, cacheKey, response)
+		if params.OnResponse != nil {
+			params.OnResponse(raw)
+		}
+		return planData, nil
 	}
 
 	return nil, fmt.Errorf("failed to generate training plan after %d attempts: %w", s.maxRetries+1, lastErr)
 }
 
+// newClient returns the provider's client behind the circuit breaker and the
+// concurrency limit. The breaker sits inside the limiter so time spent queueing
+// for a slot is never counted as a provider timeout. Calls are recorded with
+// record, if set, between the two: logged latency excludes queueing, and
+// calls skipped by an open circuit are logged as failures. Providers the
+// deployment disallows get the policy error as it is, so it reaches the user.
+func (s *aiService) newClient(aiAPI *model.AIAPI, record callRecorder) (AIClient, error) {
+	if err := s.policy.Check(aiAPI.Provider); err != nil {
+		return nil, err
+	}
+	client, err := GetAIClient(aiAPI.Provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI client: %w", err)
+	}
+	return limitConcurrency(logCalls(s.breaker.Wrap(client, CircuitKey(aiAPI)), record), s.callSlots), nil
+}
+
+// canFallBack reports whether a failed generation may move on to the next API.
+// Application errors such as a suspension or abuse flag apply to the user, and
+// a cancelled context means nobody is waiting for the plan.
+func canFallBack(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	_, isAppErr := err.(*errors.AppError)
+	return !isAppErr
+}
+
 // GenerateNutritionPlan generates a nutrition plan using AI with retry logic
 func (s *aiService) GenerateNutritionPlan(ctx context.Context, params *NutritionPlanParams) (*model.NutritionPlan, error) {
 	// Get AI API configuration
@@ -162,6 +431,44 @@ func (s *aiService) GenerateNutritionPlan(ctx context.Context, params *Nutrition
 		return nil, fmt.Errorf("AI API not found")
 	}
 
+	// Build prompt
+	prompt, templateID, err := s.buildNutritionPlanPrompt(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	parse := s.parseNutritionPlanResponse
+	if params.LeftoverLunch {
+		parse = withLeftoverLunches(parse)
+	}
+	userInput := append(append([]string{params.PlanName}, params.DietaryRestrictions...), params.Preferences...)
+	planData, err := s.generateNutritionWith(ctx, aiAPI, prompt, params.UserID, userInput, params.OnCooldown, params.OnResponse, nutritionPlanSchema, parse)
+	if err != nil {
+		return nil, err
+	}
+	plan := newNutritionPlan(params, planData)
+	plan.PromptTemplateID = templateID
+	return plan, nil
+}
+
+// generateNutritionWith calls a single AI API with schema, retrying call
+// failures and responses parse rejects, and returns the parsed plan data.
+// userInput is what the user wrote, for the abuse detector. onCooldown and
+// onResponse may be nil.
+func (s *aiService) generateNutritionWith(ctx context.Context, aiAPI *model.AIAPI, prompt string, userID int64, userInput []string, onCooldown func(*ProviderCooldown), onResponse func(string), schema *ResponseSchema, parse func(string) (model.JSONMap, error)) (model.JSONMap, error) {
+	if err := s.policy.Check(aiAPI.Provider); err != nil {
+		return nil, err
+	}
+
+	// Serve a regeneration with identical parameters from the cache
+	cacheKey := AIResponseCacheKey(userID, aiAPI, model.AIUsagePurposeNutritionPlan, prompt)
+	if planData, response, ok := s.cachedPlan(ctx, cacheKey, parse, nil); ok {
+		if onResponse != nil {
+			onResponse(response)
+		}
+		return planData, nil
+	}
+
 	// Decrypt API key
 	apiKey, err := s.encryptor.Decrypt(aiAPI.APIKeyEncrypted)
 	if err != nil {
@@ -169,68 +476,97 @@ func (s *aiService) GenerateNutritionPlan(ctx context.Context, params *Nutrition
 	}
 
 	// Get AI client
-	client, err := GetAIClient(aiAPI.Provider)
+	client, err := s.newClient(aiAPI, s.callLogger(ctx, userID, aiAPI, model.AIUsagePurposeNutritionPlan))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get AI client: %w", err)
+		return nil, err
 	}
 
-	// Build prompt
-	prompt := s.buildNutritionPlanPrompt(params)
+	// Reject suspended configs and flag abusive usage before spending the user's quota
+	if s.abuseDetector != nil {
+		if err := s.abuseDetector.CheckGeneration(ctx, userID, aiAPI, userInput...); err != nil {
+			return nil, err
+		}
+	}
 
 	// Create client config
-	config := NewAIClientFromModel(aiAPI, apiKey)
+	config := NewAIClientFromModel(aiAPI, apiKey, s.timeout)
+	config.ResponseSchema = schema
+	config.OnUsage = s.usageRecorder(ctx, userID, aiAPI, model.AIUsagePurposeNutritionPlan)
 
 	// Call AI with retry logic (including parse errors)
 	var lastErr error
 	for attempt := 0; attempt <= s.maxRetries; attempt++ {
-		if attempt > 0 {
-			// Exponential backoff
-			backoff := time.Duration(math.Pow(2, float64(attempt-1))) * s.retryDelay
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(backoff):
-			}
+		// Exponential backoff from the provider's tuned base delay, or
+		// longer while the provider is throttling us
+		if err := s.waitForAttempt(ctx, aiAPI, attempt, onCooldown); err != nil {
+			return nil, err
 		}
 
+		callStart := time.Now()
 		response, err := client.Call(ctx, prompt, config)
+		if IsCircuitOpen(err) {
+			// Retrying within the cooldown would only be skipped again
+			return nil, err
+		}
+		s.retryTuner.Observe(aiAPI.Provider, time.Since(callStart), err)
 		if err != nil {
+			if !IsRetryableAIError(err) {
+				// An invalid key or malformed request fails the same way every time
+				return nil, fmt.Errorf("AI API rejected the request, not retrying: %w", err)
+			}
+			if cooldownErr := s.startCooldown(aiAPI, attempt, err); cooldownErr != nil {
+				return nil, cooldownErr
+			}
 			lastErr = err
 			continue
 		}
 
-		planData, err := s.parseNutritionPlanResponse(response)
+		planData, err := parse(response)
 		if err != nil {
+			s.recordParseFailure(ctx, aiAPI, model.AIUsagePurposeNutritionPlan, err)
 			lastErr = err
 			continue
 		}
 
-		// Create nutrition plan model
-		startDate := time.Now()
-		endDate := startDate.AddDate(0, 0, params.DurationDays)
-
-		nutritionPlan := &model.NutritionPlan{
-			UserID:              params.UserID,
-			PlanName:            params.PlanName,
-			StartDate:           startDate,
-			EndDate:             endDate,
-			DailyCalories:       params.DailyCalories,
-			ProteinRatio:        params.ProteinRatio,
-			CarbRatio:           params.CarbRatio,
-			FatRatio:            params.FatRatio,
-			DietaryRestrictions: model.JSONSlice(interfaceSlice(params.DietaryRestrictions)),
-			Preferences:         model.JSONSlice(interfaceSlice(params.Preferences)),
-			PlanData:            planData,
-			AIAPIID:             params.AIAPIID,
-			Status:              "active",
+		s.cacheResponse(ctx, cacheKey, response)
+		if onResponse != nil {
+			onResponse(response)
 		}
-
-		return nutritionPlan, nil
+		return planData, nil
 	}
 
 	return nil, fmt.Errorf("failed to generate nutrition plan after %d attempts: %w", s.maxRetries+1, lastErr)
 }
 
+// newNutritionPlan creates the nutrition plan model for generated plan data
+func newNutritionPlan(params *NutritionPlanParams, planData model.JSONMap) *model.NutritionPlan {
+	startDate := time.Now()
+	endDate := startDate.AddDate(0, 0, params.DurationDays)
+
+	plan := &model.NutritionPlan{
+		UserID:              params.UserID,
+		PlanName:            params.PlanName,
+		StartDate:           startDate,
+		EndDate:             endDate,
+		DailyCalories:       params.DailyCalories,
+		CalorieBasis:        &params.CalorieBasis,
+		ProteinRatio:        params.ProteinRatio,
+		CarbRatio:           params.CarbRatio,
+		FatRatio:            params.FatRatio,
+		DietaryRestrictions: model.JSONSlice(interfaceSlice(params.DietaryRestrictions)),
+		Preferences:         model.JSONSlice(interfaceSlice(params.Preferences)),
+		LeftoverLunch:       params.LeftoverLunch,
+		WeeklyBudget:        params.WeeklyBudget,
+		PlanData:            planData,
+		AIAPIID:             params.AIAPIID,
+		Status:              "active",
+	}
+	if params.BudgetLevel != "" {
+		plan.BudgetLevel = &params.BudgetLevel
+	}
+	return plan
+}
+
 // TestConnection tests the connection to an AI API
 func (s *aiService) TestConnection(ctx context.Context, apiID int64, userID int64) error {
 	// Get AI API configuration
@@ -254,226 +590,23 @@ func (s *aiService) TestConnection(ctx context.Context, apiID int64, userID int6
 	}
 
 	// Get AI client
-	client, err := GetAIClient(aiAPI.Provider)
+	client, err := s.newClient(aiAPI, nil)
 	if err != nil {
-		return fmt.Errorf("failed to get AI client: %w", err)
+		return err
 	}
 
 	// Create client config
-	config := NewAIClientFromModel(aiAPI, apiKey)
+	config := NewAIClientFromModel(aiAPI, apiKey, s.timeout)
 
 	// Test connection
 	return client.TestConnection(ctx, config)
 }
 
-// buildTrainingPlanPrompt builds the prompt for training plan generation
-func (s *aiService) buildTrainingPlanPrompt(params *TrainingPlanParams) string {
-	prompt := fmt.Sprintf(`Generate a detailed %d-week training plan with the following specifications:
-
-Goal: %s
-Difficulty Level: %s
-Plan Name: %s
-
-`, params.DurationWeeks, params.Goal, params.DifficultyLevel, params.PlanName)
-
-	// Add assessment information
-	if params.Assessment != nil {
-		prompt += fmt.Sprintf(`User Assessment:
-- Experience Level: %s
-- Weekly Available Days: %d
-- Daily Available Minutes: %d
-`, params.Assessment.ExperienceLevel, params.Assessment.WeeklyAvailableDays, params.Assessment.DailyAvailableMinutes)
-
-		if params.Assessment.InjuryHistory != nil && *params.Assessment.InjuryHistory != "" {
-			prompt += fmt.Sprintf("- Injury History: %s\n", *params.Assessment.InjuryHistory)
-		}
-		if params.Assessment.HealthConditions != nil && *params.Assessment.HealthConditions != "" {
-			prompt += fmt.Sprintf("- Health Conditions: %s\n", *params.Assessment.HealthConditions)
-		}
-		if len(params.Assessment.EquipmentAvailable) > 0 {
-			prompt += fmt.Sprintf("- Equipment Available: %v\n", params.Assessment.EquipmentAvailable)
-		}
-	}
-
-	// Add body data
-	if params.BodyData != nil {
-		prompt += fmt.Sprintf(`
-User Body Data:
-- Age: %d
-- Gender: %s
-- Height: %.2f cm
-- Weight: %.2f kg
-`, params.BodyData.Age, params.BodyData.Gender, params.BodyData.Height, params.BodyData.Weight)
-
-		if params.BodyData.BodyFatPercentage != nil {
-			prompt += fmt.Sprintf("- Body Fat: %.2f%%\n", *params.BodyData.BodyFatPercentage)
-		}
-	}
-
-	// Add fitness goals
-	if len(params.FitnessGoals) > 0 {
-		prompt += "\nFitness Goals:\n"
-		for _, goal := range params.FitnessGoals {
-			prompt += fmt.Sprintf("- %s", goal.GoalType)
-			if goal.GoalDescription != nil {
-				prompt += fmt.Sprintf(": %s", *goal.GoalDescription)
-			}
-			prompt += "\n"
-		}
-	}
-
-	prompt += `
-Please generate a comprehensive training plan in JSON format with the following structure:
-{
-  "weeks": [
-    {
-      "week": 1,
-      "days": [
-        {
-          "day": 1,
-          "date": "YYYY-MM-DD",
-          "type": "strength|cardio|rest",
-          "focus_area": "upper_body|lower_body|full_body|cardio",
-          "exercises": [
-            {
-              "name": "中文动作名称",
-              "sets": 4,
-              "reps": "8-10",
-              "weight": "70kg or bodyweight",
-              "rest": "90s",
-              "difficulty": "easy|medium|hard",
-              "safety_notes": "标准姿势与注意事项（中文，简洁）"
-            }
-          ],
-          "duration": 60,
-          "estimated_calories": 350
-        }
-      ]
-    }
-  ]
-}
-
-Ensure the plan:
-1. Progressively increases in difficulty
-2. Includes proper rest days
-3. Balances different muscle groups
-4. Considers any injuries or health conditions
-5. Fits within the user's available time
-6. Includes safety notes for complex exercises
-7. Uses Chinese exercise names and Chinese safety notes
-
-Return ONLY the JSON object, no additional text.
-The response must start with "{" and end with "}".
-If you cannot generate the full plan, return {"weeks": []}.`
-
-	return prompt
-}
-
-// buildNutritionPlanPrompt builds the prompt for nutrition plan generation
-func (s *aiService) buildNutritionPlanPrompt(params *NutritionPlanParams) string {
-	prompt := fmt.Sprintf(`Generate a detailed %d-day nutrition plan with the following specifications:
-
-Plan Name: %s
-Daily Calories: %.0f kcal
-Macronutrient Ratios:
-- Protein: %.0f%%
-- Carbohydrates: %.0f%%
-- Fat: %.0f%%
-
-`, params.DurationDays, params.PlanName, params.DailyCalories,
-		params.ProteinRatio*100, params.CarbRatio*100, params.FatRatio*100)
-
-	// Add dietary restrictions
-	if len(params.DietaryRestrictions) > 0 {
-		prompt += fmt.Sprintf("Dietary Restrictions: %v\n", params.DietaryRestrictions)
-	}
-
-	// Add preferences
-	if len(params.Preferences) > 0 {
-		prompt += fmt.Sprintf("Preferences: %v\n", params.Preferences)
-	}
-
-	// Add body data
-	if params.BodyData != nil {
-		prompt += fmt.Sprintf(`
-User Body Data:
-- Age: %d
-- Gender: %s
-- Height: %.2f cm
-- Weight: %.2f kg
-`, params.BodyData.Age, params.BodyData.Gender, params.BodyData.Height, params.BodyData.Weight)
-	}
-
-	// Add fitness goals
-	if len(params.FitnessGoals) > 0 {
-		prompt += "\nFitness Goals:\n"
-		for _, goal := range params.FitnessGoals {
-			prompt += fmt.Sprintf("- %s", goal.GoalType)
-			if goal.GoalDescription != nil {
-				prompt += fmt.Sprintf(": %s", *goal.GoalDescription)
-			}
-			prompt += "\n"
-		}
-	}
-
-	prompt += `
-Please generate a comprehensive nutrition plan in JSON format with the following structure:
-{
-  "days": [
-    {
-      "day": 1,
-      "date": "YYYY-MM-DD",
-      "meals": {
-        "breakfast": {
-          "time": "07:00-08:00",
-          "foods": [
-            {
-              "name": "Food name",
-              "amount": "100g",
-              "calories": 200,
-              "protein": 10,
-              "carbs": 25,
-              "fat": 5,
-              "fiber": 3
-            }
-          ],
-          "total_calories": 450
-        },
-        "lunch": { ... },
-        "dinner": { ... },
-        "snacks": { ... }
-      },
-      "daily_totals": {
-        "calories": 2000,
-        "protein": 150,
-        "carbs": 200,
-        "fat": 67
-      }
-    }
-  ]
-}
-
-Ensure the plan:
-1. Meets the specified calorie and macro targets
-2. Respects all dietary restrictions
-3. Includes variety across days
-4. Provides balanced nutrition
-5. Includes meal timing suggestions
-6. Lists specific portion sizes
-
-Return ONLY the JSON object, no additional text.
-The response must start with "{" and end with "}".
-If you cannot generate the full plan, return {"days": []}.`
-
-	return prompt
-}
-
 // parseTrainingPlanResponse parses the AI response for training plan
 func (s *aiService) parseTrainingPlanResponse(response string) (model.JSONMap, error) {
-	// Try to extract JSON from response (AI might add extra text)
-	jsonStr := extractJSON(response)
+	jsonStr := planJSON(response)
 	if jsonStr == "" {
-		return nil, fmt.Errorf("no valid JSON found in response")
+		return nil, missingJSONFailure(response)
 	}
 
 	var planData model.JSONMap
@@ -484,13 +617,21 @@ func (s *aiService) parseTrainingPlanResponse(response string) (model.JSONMap, e
 				"weeks": weeks,
 			}
 		} else {
-			return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+			return nil, unmarshalFailure(jsonStr, err)
 		}
 	}
 
 	// Validate structure
-	if _, ok := planData["weeks"]; !ok {
-		return nil, fmt.Errorf("invalid plan structure: missing 'weeks' field")
+	raw, ok := planData["weeks"]
+	if !ok {
+		return nil, parseFailure(ParseFailureWrongSchema, "invalid plan structure: missing 'weeks' field")
+	}
+	weeks, ok := raw.([]interface{})
+	if !ok {
+		return nil, parseFailure(ParseFailureWrongSchema, "invalid plan structure: 'weeks' is not a list")
+	}
+	if len(weeks) == 0 {
+		return nil, parseFailure(ParseFailureEmptyPlan, "invalid plan structure: no weeks")
 	}
 
 	return planData, nil
@@ -498,10 +639,9 @@ func (s *aiService) parseTrainingPlanResponse(response string) (model.JSONMap, e
 
 // parseNutritionPlanResponse parses the AI response for nutrition plan
 func (s *aiService) parseNutritionPlanResponse(response string) (model.JSONMap, error) {
-	// Try to extract JSON from response (AI might add extra text)
-	jsonStr := extractJSON(response)
+	jsonStr := planJSON(response)
 	if jsonStr == "" {
-		return nil, fmt.Errorf("no valid JSON found in response")
+		return nil, missingJSONFailure(response)
 	}
 
 	var planData model.JSONMap
@@ -512,18 +652,71 @@ func (s *aiService) parseNutritionPlanResponse(response string) (model.JSONMap,
 				"days": days,
 			}
 		} else {
-			return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+			return nil, unmarshalFailure(jsonStr, err)
 		}
 	}
 
 	// Validate structure
-	if _, ok := planData["days"]; !ok {
-		return nil, fmt.Errorf("invalid plan structure: missing 'days' field")
+	raw, ok := planData["days"]
+	if !ok {
+		return nil, parseFailure(ParseFailureWrongSchema, "invalid plan structure: missing 'days' field")
+	}
+	days, ok := raw.([]interface{})
+	if !ok {
+		return nil, parseFailure(ParseFailureWrongSchema, "invalid plan structure: 'days' is not a list")
+	}
+	if len(days) == 0 {
+		return nil, parseFailure(ParseFailureEmptyPlan, "invalid plan structure: no days")
 	}
 
 	return planData, nil
 }
 
+// parseNutritionDayResponse parses the AI response for a single nutrition
+// plan day. A day wrapped in a one-day plan is unwrapped.
+func (s *aiService) parseNutritionDayResponse(response string) (model.JSONMap, error) {
+	jsonStr := planJSON(response)
+	if jsonStr == "" {
+		return nil, missingJSONFailure(response)
+	}
+
+	var day model.JSONMap
+	if err := json.Unmarshal([]byte(jsonStr), &day); err != nil {
+		return nil, unmarshalFailure(jsonStr, err)
+	}
+	if days, ok := day["days"].([]interface{}); ok && len(days) == 1 {
+		if inner, ok := days[0].(map[string]interface{}); ok {
+			day = inner
+		}
+	}
+
+	// Validate structure
+	raw, ok := day["meals"]
+	if !ok {
+		return nil, parseFailure(ParseFailureWrongSchema, "invalid day structure: missing 'meals' field")
+	}
+	meals, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, parseFailure(ParseFailureWrongSchema, "invalid day structure: 'meals' is not an object")
+	}
+	if len(meals) == 0 {
+		return nil, parseFailure(ParseFailureEmptyPlan, "invalid day structure: no meals")
+	}
+
+	return day, nil
+}
+
+// planJSON returns the response as-is when it is already valid JSON, as it is
+// with structured outputs, and otherwise extracts the JSON from the surrounding
+// text for providers that only follow the prompt
+func planJSON(response string) string {
+	trimmed := strings.TrimSpace(response)
+	if json.Valid([]byte(trimmed)) {
+		return trimmed
+	}
+	return extractJSON(response)
+}
+
 // extractJSON extracts JSON object from a string that might contain additional text
 func extractJSON(s string) string {
 	// Find first { and last }
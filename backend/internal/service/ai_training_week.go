@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// TrainingWeekParams holds the week of a training plan to regenerate and
+// the feedback and limits the new week must respect
+type TrainingWeekParams struct {
+	UserID  int64
+	AIAPIID int64
+	Plan    *model.TrainingPlan
+	// Week is the 1-based week number and StartDate its first day
+	Week      int
+	StartDate time.Time
+	// CurrentWeek is the plan data of the week being replaced; the weeks
+	// around it are nil at either end of the plan
+	CurrentWeek  map[string]interface{}
+	PreviousWeek map[string]interface{}
+	NextWeek     map[string]interface{}
+	// Deload is set when the plan's periodization makes the week a deload
+	Deload        bool
+	InjuryReport  string
+	Feedback      string
+	RecentRecords []string
+	Constraints   []*model.TrainingConstraint
+	BusySchedule  *BusySchedule
+	Progressions  []*ExerciseProgression
+}
+
+// RegenerateTrainingWeek asks the AI for a new version of one week of a
+// training plan and returns the week's plan data, labelled with its week
+// number and deload flag. Weeks naming a restricted movement are rejected
+// and retried like parse failures.
+func (s *aiService) RegenerateTrainingWeek(ctx context.Context, params *TrainingWeekParams) (model.JSONMap, error) {
+	aiAPI, err := s.aiAPIRepo.GetByID(ctx, params.AIAPIID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI API: %w", err)
+	}
+	if aiAPI == nil {
+		return nil, fmt.Errorf("AI API not found")
+	}
+
+	prompt, err := s.buildTrainingWeekPrompt(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, err := s.encryptor.Decrypt(aiAPI.APIKeyEncrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt API key: %w", err)
+	}
+
+	client, err := s.newClient(aiAPI, s.callLogger(ctx, params.UserID, aiAPI, model.AIUsagePurposeTrainingPlan))
+	if err != nil {
+		return nil, err
+	}
+
+	if s.abuseDetector != nil {
+		if err := s.abuseDetector.CheckGeneration(ctx, params.UserID, aiAPI, params.Feedback, params.InjuryReport); err != nil {
+			return nil, err
+		}
+	}
+
+	config := NewAIClientFromModel(aiAPI, apiKey, s.timeout)
+	config.ResponseSchema = trainingWeekResponseSchema
+	config.OnUsage = s.usageRecorder(ctx, params.UserID, aiAPI, model.AIUsagePurposeTrainingPlan)
+
+	var lastErr error
+	for attempt := 0; attempt <= s.maxRetries; attempt++ {
+		if err := s.waitForAttempt(ctx, aiAPI, attempt, nil); err != nil {
+			return nil, err
+		}
+
+		callStart := time.Now()
+		response, err := client.Call(ctx, prompt, config)
+		if IsCircuitOpen(err) {
+			return nil, err
+		}
+		s.retryTuner.Observe(aiAPI.Provider, time.Since(callStart), err)
+		if err != nil {
+			if !IsRetryableAIError(err) {
+				return nil, fmt.Errorf("AI API rejected the request, not retrying: %w", err)
+			}
+			if cooldownErr := s.startCooldown(aiAPI, attempt, err); cooldownErr != nil {
+				return nil, cooldownErr
+			}
+			lastErr = err
+			continue
+		}
+
+		week, err := s.parseTrainingWeekResponse(response)
+		if err != nil {
+			s.recordParseFailure(ctx, aiAPI, model.AIUsagePurposeTrainingPlan, err)
+			lastErr = err
+			continue
+		}
+		if violations := constraintViolations(model.JSONMap{"weeks": []interface{}{map[string]interface{}(week)}}, params.Constraints); len(violations) > 0 {
+			lastErr = constraintViolationError(violations)
+			continue
+		}
+
+		// The week keeps its place in the plan whatever the AI labelled it,
+		// numbered as a decoded JSON number like the weeks around it
+		week["week"] = float64(params.Week)
+		week["deload"] = params.Deload
+		return week, nil
+	}
+
+	return nil, fmt.Errorf("failed to regenerate training week after %d attempts: %w", s.maxRetries+1, lastErr)
+}
+
+// parseTrainingWeekResponse parses the AI response for a single week. A
+// week wrapped in a plan of one week is unwrapped.
+func (s *aiService) parseTrainingWeekResponse(response string) (model.JSONMap, error) {
+	jsonStr := planJSON(response)
+	if jsonStr == "" {
+		return nil, missingJSONFailure(response)
+	}
+
+	var week model.JSONMap
+	if err := json.Unmarshal([]byte(jsonStr), &week); err != nil {
+		return nil, unmarshalFailure(jsonStr, err)
+	}
+	if list, ok := week["weeks"].([]interface{}); ok && len(list) == 1 {
+		if inner, ok := list[0].(map[string]interface{}); ok {
+			week = inner
+		}
+	}
+
+	// Validate structure
+	raw, ok := week["days"]
+	if !ok {
+		return nil, parseFailure(ParseFailureWrongSchema, "invalid week structure: missing 'days' field")
+	}
+	days, ok := raw.([]interface{})
+	if !ok {
+		return nil, parseFailure(ParseFailureWrongSchema, "invalid week structure: 'days' is not a list")
+	}
+	if len(days) == 0 {
+		return nil, parseFailure(ParseFailureEmptyPlan, "invalid week structure: no days")
+	}
+
+	return week, nil
+}
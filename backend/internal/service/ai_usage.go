@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ai-fitness-planner/backend/internal/config"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// usageRecorder returns an AIClientConfig.OnUsage callback that stores each
+// call's token usage, or nil when usage is not tracked. Every attempt is
+// recorded, including retries whose response failed to parse, since the
+// provider bills for them too.
+func (s *aiService) usageRecorder(ctx context.Context, userID int64, aiAPI *model.AIAPI, purpose string) func(Usage) {
+	if s.usageRepo == nil {
+		return nil
+	}
+	// The tokens are spent even if the caller goes away mid-response
+	ctx = context.WithoutCancel(ctx)
+
+	var modelName string
+	if aiAPI.Model != nil {
+		modelName = *aiAPI.Model
+	}
+
+	return func(usage Usage) {
+		record := &model.AIUsage{
+			UserID:           userID,
+			AIAPIID:          aiAPI.ID,
+			Provider:         aiAPI.Provider,
+			Model:            modelName,
+			Purpose:          purpose,
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
+		}
+		if err := s.usageRepo.Create(ctx, record); err != nil {
+			logger.Warn("Failed to record AI token usage",
+				zap.Int64("user_id", userID),
+				zap.Int64("ai_api_id", aiAPI.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// estimateCost prices token usage for a model in USD. The longest configured
+// model name that the model starts with wins, so "gpt-4o-mini-2024-07-18" is
+// priced as gpt-4o-mini rather than gpt-4o. ok is false for unpriced models.
+func estimateCost(prices []config.AIModelPrice, modelName string, promptTokens, completionTokens int64) (cost float64, ok bool) {
+	name := strings.ToLower(strings.TrimSpace(modelName))
+	if name == "" {
+		return 0, false
+	}
+
+	var best *config.AIModelPrice
+	for i := range prices {
+		priced := strings.ToLower(prices[i].Model)
+		if priced == "" || !strings.HasPrefix(name, priced) {
+			continue
+		}
+		if best == nil || len(priced) > len(best.Model) {
+			best = &prices[i]
+		}
+	}
+	if best == nil {
+		return 0, false
+	}
+
+	return (float64(promptTokens)*best.PromptPerMillion + float64(completionTokens)*best.CompletionPerMillion) / 1e6, true
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/analyticssink"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Analytics event types
+const (
+	AnalyticsEventWorkout = "workout"
+	AnalyticsEventWeight  = "weight"
+)
+
+// analyticsUnknown stands in for demographics of users who never entered
+// body data
+const analyticsUnknown = "unknown"
+
+// AnalyticsEvent is one exported event. Subject is a keyed pseudonym of the
+// user, stable across exports; every other attribute is generalized.
+type AnalyticsEvent struct {
+	Event   string `json:"event"`
+	Subject string `json:"subject"`
+	// Week is the Monday of the week the event happened in
+	Week    string `json:"week"`
+	AgeBand string `json:"age_band"`
+	Gender  string `json:"gender"`
+
+	WorkoutCategory string `json:"workout_category,omitempty"`
+	DurationMinutes *int   `json:"duration_minutes,omitempty"`
+	Rating          *int   `json:"rating,omitempty"`
+
+	HeightBand        string   `json:"height_band,omitempty"`
+	WeightKg          *float64 `json:"weight_kg,omitempty"`
+	BodyFatPercentage *float64 `json:"body_fat_percentage,omitempty"`
+}
+
+// AnalyticsExporter exports anonymized health events for offline analytics
+type AnalyticsExporter interface {
+	// ExportEvents exports the days since the last export and returns the
+	// number of events written
+	ExportEvents(ctx context.Context) (int, error)
+}
+
+// analyticsExporter implements AnalyticsExporter interface
+type analyticsExporter struct {
+	analyticsRepo repository.AnalyticsRepository
+	sink          analyticssink.Sink
+	pseudonymKey  []byte
+	k             int
+	lookbackDays  int
+}
+
+// NewAnalyticsExporter creates a new instance of AnalyticsExporter.
+// Events are only exported in groups of at least k distinct users; the
+// first export reaches back lookbackDays.
+func NewAnalyticsExporter(
+	analyticsRepo repository.AnalyticsRepository,
+	sink analyticssink.Sink,
+	pseudonymKey string,
+	k int,
+	lookbackDays int,
+) AnalyticsExporter {
+	if k < 2 {
+		k = 2
+	}
+	if lookbackDays < 1 {
+		lookbackDays = 1
+	}
+	return &analyticsExporter{
+		analyticsRepo: analyticsRepo,
+		sink:          sink,
+		pseudonymKey:  []byte(pseudonymKey),
+		k:             k,
+		lookbackDays:  lookbackDays,
+	}
+}
+
+// ExportEvents exports whole days from the end of the last export up to
+// today. The window is claimed before anything is written so that
+// concurrent runs on other instances skip it, and released again if the
+// sink fails. Records entered for days already exported are not picked up.
+func (s *analyticsExporter) ExportEvents(ctx context.Context) (int, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	start := today.AddDate(0, 0, -s.lookbackDays)
+	last, err := s.analyticsRepo.GetLastExport(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if last != nil {
+		end := last.WindowEnd
+		start = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, today.Location())
+	}
+	if !start.Before(today) {
+		return 0, nil
+	}
+
+	events, err := s.collectEvents(ctx, start, today)
+	if err != nil {
+		return 0, err
+	}
+	kept, suppressed := suppressSmallGroups(events, s.k)
+
+	export := &model.AnalyticsExport{
+		Sink:        s.sink.Type(),
+		WindowStart: start,
+		WindowEnd:   today,
+	}
+	claimed, err := s.analyticsRepo.ClaimExport(ctx, export)
+	if err != nil {
+		return 0, err
+	}
+	if !claimed {
+		return 0, nil
+	}
+
+	location := ""
+	if len(kept) > 0 {
+		var data bytes.Buffer
+		encoder := json.NewEncoder(&data)
+		for _, event := range kept {
+			if err := encoder.Encode(event); err != nil {
+				s.release(export)
+				return 0, err
+			}
+		}
+		name := fmt.Sprintf("health-events-%s_%s.jsonl", start.Format("2006-01-02"), today.AddDate(0, 0, -1).Format("2006-01-02"))
+		location, err = s.sink.Write(ctx, name, data.Bytes())
+		if err != nil {
+			s.release(export)
+			return 0, err
+		}
+	}
+
+	export.Events = len(kept)
+	export.Suppressed = suppressed
+	export.Location = location
+	if err := s.analyticsRepo.CompleteExport(ctx, export); err != nil {
+		// The events are out; the window stays claimed with zero counts
+		logger.Warn("Failed to record analytics export",
+			zap.Int64("export_id", export.ID),
+			zap.String("location", location),
+			zap.Error(err),
+		)
+	}
+	if suppressed > 0 {
+		logger.Info("Suppressed analytics events in groups below k",
+			zap.Int("suppressed", suppressed),
+			zap.Int("k", s.k),
+		)
+	}
+	return len(kept), nil
+}
+
+// release gives a claimed window back after a failed write
+func (s *analyticsExporter) release(export *model.AnalyticsExport) {
+	if err := s.analyticsRepo.ReleaseExport(context.Background(), export.ID); err != nil {
+		logger.Error("Failed to release analytics export window",
+			zap.Int64("export_id", export.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// collectEvents loads and generalizes the workouts and measurements in
+// [start, end)
+func (s *analyticsExporter) collectEvents(ctx context.Context, start, end time.Time) ([]*AnalyticsEvent, error) {
+	workouts, err := s.analyticsRepo.ListWorkouts(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	measurements, err := s.analyticsRepo.ListMeasurements(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var userIDs []int64
+	seen := make(map[int64]bool)
+	for _, w := range workouts {
+		if !seen[w.UserID] {
+			seen[w.UserID] = true
+			userIDs = append(userIDs, w.UserID)
+		}
+	}
+	demographics, err := s.analyticsRepo.LatestMeasurements(ctx, userIDs, end)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*AnalyticsEvent, 0, len(workouts)+len(measurements))
+	for _, w := range workouts {
+		event := &AnalyticsEvent{
+			Event:           AnalyticsEventWorkout,
+			Subject:         s.pseudonym(w.UserID),
+			Week:            analyticsWeek(w.WorkoutDate),
+			AgeBand:         analyticsUnknown,
+			Gender:          analyticsUnknown,
+			WorkoutCategory: workoutCategory(w.WorkoutType),
+			Rating:          w.Rating,
+		}
+		if m := demographics[w.UserID]; m != nil {
+			event.AgeBand = ageBand(m.Age)
+			event.Gender = m.Gender
+		}
+		if w.DurationMinutes != nil {
+			duration := roundTo(*w.DurationMinutes, 5)
+			event.DurationMinutes = &duration
+		}
+		events = append(events, event)
+	}
+	for _, m := range measurements {
+		weight := math.Round(m.Weight)
+		event := &AnalyticsEvent{
+			Event:      AnalyticsEventWeight,
+			Subject:    s.pseudonym(m.UserID),
+			Week:       analyticsWeek(m.MeasurementDate),
+			AgeBand:    ageBand(m.Age),
+			Gender:     m.Gender,
+			HeightBand: heightBand(m.Height),
+			WeightKg:   &weight,
+		}
+		if m.BodyFatPercentage != nil {
+			bodyFat := math.Round(*m.BodyFatPercentage)
+			event.BodyFatPercentage = &bodyFat
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// pseudonym replaces a user ID with an HMAC of it, so the export can link a
+// user's events without revealing who they are
+func (s *analyticsExporter) pseudonym(userID int64) string {
+	mac := hmac.New(sha256.New, s.pseudonymKey)
+	mac.Write([]byte(strconv.FormatInt(userID, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// suppressSmallGroups drops events whose quasi-identifiers (event type,
+// week, age band, gender and height band) are shared by fewer than k
+// distinct users, and returns the kept events and the number dropped
+func suppressSmallGroups(events []*AnalyticsEvent, k int) ([]*AnalyticsEvent, int) {
+	subjects := make(map[string]map[string]bool)
+	for _, event := range events {
+		key := quasiIdentifier(event)
+		if subjects[key] == nil {
+			subjects[key] = make(map[string]bool)
+		}
+		subjects[key][event.Subject] = true
+	}
+
+	kept := make([]*AnalyticsEvent, 0, len(events))
+	for _, event := range events {
+		if len(subjects[quasiIdentifier(event)]) >= k {
+			kept = append(kept, event)
+		}
+	}
+	return kept, len(events) - len(kept)
+}
+
+func quasiIdentifier(event *AnalyticsEvent) string {
+	return strings.Join([]string{event.Event, event.Week, event.AgeBand, event.Gender, event.HeightBand}, "|")
+}
+
+// analyticsWeek generalizes a date to the Monday of its week
+func analyticsWeek(date time.Time) string {
+	offset := (int(date.Weekday()) + 6) % 7
+	return date.AddDate(0, 0, -offset).Format("2006-01-02")
+}
+
+// ageBand generalizes an age to its decade, e.g. "30-39"
+func ageBand(age int) string {
+	if age <= 0 {
+		return analyticsUnknown
+	}
+	low := age / 10 * 10
+	return fmt.Sprintf("%d-%d", low, low+9)
+}
+
+// heightBand generalizes a height in centimetres to a 10cm band
+func heightBand(height float64) string {
+	if height <= 0 {
+		return analyticsUnknown
+	}
+	low := int(height) / 10 * 10
+	return fmt.Sprintf("%d-%d", low, low+9)
+}
+
+// roundTo rounds n to the nearest multiple of step
+func roundTo(n, step int) int {
+	return int(math.Round(float64(n)/float64(step))) * step
+}
+
+// workoutCategory maps the free-text workout type to a coarse category, so
+// that unusual descriptions cannot single a user out
+func workoutCategory(workoutType string) string {
+	t := strings.ToLower(workoutType)
+	switch {
+	case containsAny(t, "力量", "增肌", "strength", "weight", "resistance"):
+		return "strength"
+	case containsAny(t, "有氧", "跑", "骑", "游泳", "cardio", "run", "cycl", "swim", "hiit"):
+		return "cardio"
+	case containsAny(t, "拉伸", "瑜伽", "柔韧", "stretch", "yoga", "flex", "mobility"):
+		return "flexibility"
+	default:
+		return "other"
+	}
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,9 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
-	"github.com/ai-fitness-planner/backend/internal/errors"
+	apperrors "github.com/ai-fitness-planner/backend/internal/errors"
 	"github.com/ai-fitness-planner/backend/internal/model"
 	"github.com/ai-fitness-planner/backend/internal/pkg/jwt"
 	"github.com/ai-fitness-planner/backend/internal/pkg/session"
@@ -73,25 +74,25 @@ func (s *authService) Register(ctx context.Context, req *RegisterRequest) (*Auth
 	// Check if username already exists
 	existingUser, err := s.userRepo.GetByUsername(ctx, req.Username)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrDatabase, "failed to check username")
+		return nil, apperrors.Wrap(err, apperrors.ErrDatabase, "failed to check username")
 	}
 	if existingUser != nil {
-		return nil, errors.ErrUsernameExists
+		return nil, apperrors.ErrUsernameExists
 	}
 
 	// Check if email already exists
 	existingEmail, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrDatabase, "failed to check email")
+		return nil, apperrors.Wrap(err, apperrors.ErrDatabase, "failed to check email")
 	}
 	if existingEmail != nil {
-		return nil, errors.ErrEmailExists
+		return nil, apperrors.ErrEmailExists
 	}
 
 	// Hash password using bcrypt
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrInternalServer, "failed to hash password")
+		return nil, apperrors.Wrap(err, apperrors.ErrInternalServer, "failed to hash password")
 	}
 
 	// Create user
@@ -101,29 +102,30 @@ func (s *authService) Register(ctx context.Context, req *RegisterRequest) (*Auth
 		Phone:        req.Phone,
 		PasswordHash: string(passwordHash),
 		Status:       1, // Active
+		WeekStart:    model.WeekStartMonday,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
-		return nil, errors.Wrap(err, errors.ErrDatabase, "failed to create user")
+		return nil, apperrors.Wrap(err, apperrors.ErrDatabase, "failed to create user")
 	}
 
 	// Generate tokens
 	accessToken, err := s.jwtManager.GenerateAccessToken(user.ID, user.Username)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrInternalServer, "failed to generate access token")
+		return nil, apperrors.Wrap(err, apperrors.ErrInternalServer, "failed to generate access token")
 	}
 
 	refreshToken, err := s.jwtManager.GenerateRefreshToken(user.ID, user.Username)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrInternalServer, "failed to generate refresh token")
+		return nil, apperrors.Wrap(err, apperrors.ErrInternalServer, "failed to generate refresh token")
 	}
 
 	// Extract session ID from access token
 	claims, err := s.jwtManager.ValidateToken(accessToken)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrInternalServer, "failed to validate generated token")
+		return nil, apperrors.Wrap(err, apperrors.ErrInternalServer, "failed to validate generated token")
 	}
 
 	// Create session in Redis
@@ -136,7 +138,7 @@ func (s *authService) Register(ctx context.Context, req *RegisterRequest) (*Auth
 		"",             // IP address will be set by handler
 		"",             // User agent will be set by handler
 	); err != nil {
-		return nil, errors.Wrap(err, errors.ErrCache, "failed to create session")
+		return nil, apperrors.Wrap(err, apperrors.ErrCache, "failed to create session")
 	}
 
 	// Remove password hash from response
@@ -155,37 +157,37 @@ func (s *authService) Login(ctx context.Context, req *LoginRequest, ipAddress, u
 	// Get user by username
 	user, err := s.userRepo.GetByUsername(ctx, req.Username)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrDatabase, "failed to get user")
+		return nil, apperrors.Wrap(err, apperrors.ErrDatabase, "failed to get user")
 	}
 	if user == nil {
-		return nil, errors.New(errors.ErrInvalidCredentials, "invalid username or password")
+		return nil, apperrors.New(apperrors.ErrInvalidCredentials, "invalid username or password")
 	}
 
 	// Check if user is disabled
 	if user.Status != 1 {
-		return nil, errors.ErrUserDisabled
+		return nil, apperrors.ErrUserDisabled
 	}
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		return nil, errors.New(errors.ErrInvalidCredentials, "invalid username or password")
+		return nil, apperrors.New(apperrors.ErrInvalidCredentials, "invalid username or password")
 	}
 
 	// Generate tokens
 	accessToken, err := s.jwtManager.GenerateAccessToken(user.ID, user.Username)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrInternalServer, "failed to generate access token")
+		return nil, apperrors.Wrap(err, apperrors.ErrInternalServer, "failed to generate access token")
 	}
 
 	refreshToken, err := s.jwtManager.GenerateRefreshToken(user.ID, user.Username)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrInternalServer, "failed to generate refresh token")
+		return nil, apperrors.Wrap(err, apperrors.ErrInternalServer, "failed to generate refresh token")
 	}
 
 	// Extract session ID from access token
 	claims, err := s.jwtManager.ValidateToken(accessToken)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrInternalServer, "failed to validate generated token")
+		return nil, apperrors.Wrap(err, apperrors.ErrInternalServer, "failed to validate generated token")
 	}
 
 	// Create session in Redis
@@ -198,7 +200,7 @@ func (s *authService) Login(ctx context.Context, req *LoginRequest, ipAddress, u
 		ipAddress,
 		userAgent,
 	); err != nil {
-		return nil, errors.Wrap(err, errors.ErrCache, "failed to create session")
+		return nil, apperrors.Wrap(err, apperrors.ErrCache, "failed to create session")
 	}
 
 	// Remove password hash from response
@@ -215,12 +217,12 @@ func (s *authService) Login(ctx context.Context, req *LoginRequest, ipAddress, u
 // Validates: Requirements 1.5
 func (s *authService) Logout(ctx context.Context, sessionID string) error {
 	if sessionID == "" {
-		return errors.New(errors.ErrInvalidParam, "session ID is required")
+		return apperrors.New(apperrors.ErrInvalidParam, "session ID is required")
 	}
 
 	// Delete session from Redis
 	if err := s.sessionManager.DeleteSession(ctx, sessionID); err != nil {
-		return errors.Wrap(err, errors.ErrCache, "failed to delete session")
+		return apperrors.Wrap(err, apperrors.ErrCache, "failed to delete session")
 	}
 
 	return nil
@@ -232,27 +234,32 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*T
 	// Validate refresh token and extract claims
 	claims, err := s.jwtManager.ValidateToken(refreshToken)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrUnauthorized, "invalid refresh token")
+		return nil, apperrors.Wrap(err, apperrors.ErrUnauthorized, "invalid refresh token")
 	}
 
 	// Verify it's a refresh token
 	if claims.Type != "refresh" {
-		return nil, errors.New(errors.ErrUnauthorized, "token is not a refresh token")
+		return nil, apperrors.New(apperrors.ErrUnauthorized, "token is not a refresh token")
 	}
 
-	// Verify session still exists
-	session, err := s.sessionManager.GetSession(ctx, claims.SessionID)
+	// Verify session still exists; without a stored session the token's
+	// claims stand in for it, as in the auth middleware
+	sess, err := s.sessionManager.GetSession(ctx, claims.SessionID)
+	if errors.Is(err, session.ErrStatelessSession) ||
+		(errors.Is(err, session.ErrSessionUnverified) && claims.ImpersonatorID == 0) {
+		sess, err = claimsSession(s.sessionManager, claims), nil
+	}
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrCache, "failed to get session")
+		return nil, apperrors.Wrap(err, apperrors.ErrCache, "failed to get session")
 	}
-	if session == nil {
-		return nil, errors.ErrSessionNotFound
+	if sess == nil {
+		return nil, apperrors.ErrSessionNotFound
 	}
 
 	// Generate new access token
 	newAccessToken, err := s.jwtManager.GenerateAccessToken(claims.UserID, claims.Username)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrInternalServer, "failed to generate access token")
+		return nil, apperrors.Wrap(err, apperrors.ErrInternalServer, "failed to generate access token")
 	}
 
 	return &TokenResponse{
@@ -260,27 +267,39 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*T
 	}, nil
 }
 
+// claimsSession builds the session implied by a validated token
+func claimsSession(sessionManager session.SessionManager, claims *jwt.Claims) *model.Session {
+	var issuedAt, expiresAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	return session.ClaimsSession(sessionManager, claims.SessionID, claims.UserID, claims.Username, issuedAt, expiresAt)
+}
+
 // ValidateSession checks if a session is valid
 // Validates: Requirements 1.6
 func (s *authService) ValidateSession(ctx context.Context, sessionID string) (*model.Session, error) {
 	if sessionID == "" {
-		return nil, errors.New(errors.ErrInvalidParam, "session ID is required")
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "session ID is required")
 	}
 
 	session, err := s.sessionManager.GetSession(ctx, sessionID)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrCache, "failed to get session")
+		return nil, apperrors.Wrap(err, apperrors.ErrCache, "failed to get session")
 	}
 
 	if session == nil {
-		return nil, errors.ErrSessionNotFound
+		return nil, apperrors.ErrSessionNotFound
 	}
 
 	// Check if session has expired
 	if time.Now().After(session.ExpiresAt) {
 		// Delete expired session
 		_ = s.sessionManager.DeleteSession(ctx, sessionID)
-		return nil, errors.New(errors.ErrTokenExpired, "session has expired")
+		return nil, apperrors.New(apperrors.ErrTokenExpired, "session has expired")
 	}
 
 	return session, nil
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/pkg/jwt"
+	"github.com/ai-fitness-planner/backend/internal/pkg/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_RefreshTokenStateless(t *testing.T) {
+	jwtManager := jwt.NewJWTManager("user-secret", "service-secret", time.Minute, time.Hour)
+	sessionManager := session.NewStatelessSessionManager(time.Hour)
+	authService := NewAuthService(nil, jwtManager, sessionManager)
+	ctx := context.Background()
+
+	refreshToken, err := jwtManager.GenerateRefreshToken(1, "user")
+	require.NoError(t, err)
+
+	tokens, err := authService.RefreshToken(ctx, refreshToken)
+	require.NoError(t, err)
+	claims, err := jwtManager.ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), claims.UserID)
+	assert.Equal(t, "access", claims.Type)
+
+	// Revoking the user's sessions also revokes their refresh tokens
+	require.NoError(t, sessionManager.DeleteAllUserSessions(ctx, 1))
+	_, err = authService.RefreshToken(ctx, refreshToken)
+	assert.Error(t, err)
+}
+
+func TestAuthService_RefreshTokenRejectsAccessToken(t *testing.T) {
+	jwtManager := jwt.NewJWTManager("user-secret", "service-secret", time.Minute, time.Hour)
+	authService := NewAuthService(nil, jwtManager, session.NewStatelessSessionManager(time.Hour))
+
+	accessToken, err := jwtManager.GenerateAccessToken(1, "user")
+	require.NoError(t, err)
+
+	_, err = authService.RefreshToken(context.Background(), accessToken)
+	assert.Error(t, err)
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// BusySchedule is the days a user cannot train: recurring weekdays and
+// single dates
+type BusySchedule struct {
+	Weekdays []time.Weekday
+	Dates    []time.Time
+}
+
+// userBusySchedule returns the user's busy days, or nil when there are none
+func userBusySchedule(user *model.User) *BusySchedule {
+	if user == nil {
+		return nil
+	}
+	schedule := &BusySchedule{
+		Weekdays: user.BusyWeekdayList(),
+		Dates:    user.BlackoutDateList(),
+	}
+	if len(schedule.Weekdays) == 0 && len(schedule.Dates) == 0 {
+		return nil
+	}
+	return schedule
+}
+
+// busyWeekdaySlice stores busy weekdays sorted and without duplicates
+func busyWeekdaySlice(days []int) model.JSONSlice {
+	sorted := append([]int(nil), days...)
+	sort.Ints(sorted)
+	stored := model.JSONSlice{}
+	for i, d := range sorted {
+		if d < 0 || d > 6 {
+			continue
+		}
+		if i > 0 && d == sorted[i-1] {
+			continue
+		}
+		stored = append(stored, d)
+	}
+	return stored
+}
+
+// blackoutDateSlice stores blackout dates sorted and without duplicates.
+// Dates before today are dropped, since no plan can be scheduled on them.
+func blackoutDateSlice(dates []string, now time.Time) (model.JSONSlice, error) {
+	today := now.Format("2006-01-02")
+	sorted := make([]string, 0, len(dates))
+	for _, d := range dates {
+		date, err := time.ParseInLocation("2006-01-02", d, time.Local)
+		if err != nil {
+			return nil, errors.New(errors.ErrInvalidParam, "日期格式应为YYYY-MM-DD")
+		}
+		if day := date.Format("2006-01-02"); day >= today {
+			sorted = append(sorted, day)
+		}
+	}
+	sort.Strings(sorted)
+	stored := model.JSONSlice{}
+	for i, d := range sorted {
+		if i > 0 && d == sorted[i-1] {
+			continue
+		}
+		stored = append(stored, d)
+	}
+	return stored, nil
+}
+
+// Busy reports whether the user cannot train on date
+func (b *BusySchedule) Busy(date time.Time) bool {
+	if b == nil {
+		return false
+	}
+	for _, d := range b.Weekdays {
+		if date.Weekday() == d {
+			return true
+		}
+	}
+	day := date.Format("2006-01-02")
+	for _, d := range b.Dates {
+		if d.Format("2006-01-02") == day {
+			return true
+		}
+	}
+	return false
+}
+
+// busySchedulePromptSection tells the AI which days of a plan starting at
+// start and running for days days must be rest days. Blackout dates outside
+// the plan are left out.
+func busySchedulePromptSection(b *BusySchedule, start time.Time, days int) string {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	end := start.AddDate(0, 0, days)
+
+	var dates []string
+	for _, d := range b.Dates {
+		if !d.Before(start) && d.Before(end) {
+			dates = append(dates, d.Format("2006-01-02"))
+		}
+	}
+	if len(b.Weekdays) == 0 && len(dates) == 0 {
+		return ""
+	}
+	sort.Strings(dates)
+
+	section := "\nBusy Days (the user cannot train on these days; make every one of them a rest day):\n"
+	if len(b.Weekdays) > 0 {
+		names := make([]string, 0, len(b.Weekdays))
+		for i := 1; i <= 7; i++ {
+			day := time.Weekday(i % 7) // Monday first
+			for _, d := range b.Weekdays {
+				if d == day {
+					names = append(names, day.String())
+					break
+				}
+			}
+		}
+		section += fmt.Sprintf("- Every %s\n", strings.Join(names, ", "))
+	}
+	if len(dates) > 0 {
+		section += fmt.Sprintf("- %s\n", strings.Join(dates, ", "))
+	}
+	section += fmt.Sprintf("Day 1 of the plan is %s, %s; work out each day's weekday from its date and keep the weekly training days on the remaining days.\n", start.Format("2006-01-02"), start.Weekday())
+	return section
+}
+
+// planDayContent lists the day fields that move with a workout when it is
+// shifted to another day; day and date stay with the calendar day
+var planDayContent = []string{"type", "focus_area", "exercises", "duration", "estimated_calories", "estimated_minutes", "time_fit"}
+
+// enforceBusyDays moves each workout that the AI put on a busy day to a
+// rest day of the same week that is free, or turns it into a rest day when
+// the week has none. Days before since have passed and are left alone. The
+// counts are stored with the plan data.
+func enforceBusyDays(planData model.JSONMap, b *BusySchedule, since time.Time) (moved, dropped int) {
+	if b == nil {
+		return 0, 0
+	}
+
+	weeks, _ := planData["weeks"].([]interface{})
+	for _, weekRaw := range weeks {
+		week, ok := weekRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		days, _ := week["days"].([]interface{})
+		for _, dayRaw := range days {
+			day, ok := dayRaw.(map[string]interface{})
+			if !ok || isRestDay(day) || !b.Busy(planDayDate(day)) || planDayDate(day).Before(since) {
+				continue
+			}
+
+			if free := freeRestDay(days, b, since); free != nil {
+				for _, key := range planDayContent {
+					day[key], free[key] = free[key], day[key]
+					if day[key] == nil {
+						delete(day, key)
+					}
+					if free[key] == nil {
+						delete(free, key)
+					}
+				}
+				moved++
+				continue
+			}
+
+			for _, key := range planDayContent {
+				delete(day, key)
+			}
+			day["type"] = "rest"
+			day["exercises"] = []interface{}{}
+			dropped++
+		}
+	}
+
+	planData["busy_days"] = map[string]interface{}{
+		"moved":   moved,
+		"dropped": dropped,
+	}
+	return moved, dropped
+}
+
+// freeRestDay returns a rest day among days, from since on, that the user
+// is free on
+func freeRestDay(days []interface{}, b *BusySchedule, since time.Time) map[string]interface{} {
+	for _, dayRaw := range days {
+		day, ok := dayRaw.(map[string]interface{})
+		if !ok || !isRestDay(day) {
+			continue
+		}
+		if date := planDayDate(day); !date.IsZero() && !date.Before(since) && !b.Busy(date) {
+			return day
+		}
+	}
+	return nil
+}
+
+// isRestDay reports whether a plan day has no workout
+func isRestDay(day map[string]interface{}) bool {
+	exercises, _ := day["exercises"].([]interface{})
+	dayType, _ := day["type"].(string)
+	return dayType == "rest" || (dayType == "" && len(exercises) == 0)
+}
+
+// planDayDate returns the date of a plan day, or the zero time when it has
+// none
+func planDayDate(day map[string]interface{}) time.Time {
+	s, _ := day["date"].(string)
+	date, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return date
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+// checkInPromptMaxAge is how old a check-in may be and still inform AI prompts
+const checkInPromptMaxAge = 14 * 24 * time.Hour
+
+// CheckInRequest holds a weekly check-in submission
+type CheckInRequest struct {
+	WeekOf          *time.Time // any day in the target week; defaults to today
+	Weight          *float64
+	AdherenceRating int
+	EnergyLevel     int
+	HungerLevel     int
+	Notes           *string
+	Photos          []string
+}
+
+// CheckInService defines the interface for weekly check-in operations
+type CheckInService interface {
+	// SubmitCheckIn creates the check-in for a week, or replaces it if one exists
+	SubmitCheckIn(ctx context.Context, userID int64, req *CheckInRequest) (*model.WeeklyCheckIn, error)
+	ListCheckIns(ctx context.Context, userID int64, limit, offset int) ([]*model.WeeklyCheckIn, int64, error)
+	GetLatestCheckIn(ctx context.Context, userID int64) (*model.WeeklyCheckIn, error)
+	// SendReminders notifies users who have not checked in this week; it only
+	// sends on the configured reminder weekday and returns the number sent
+	SendReminders(ctx context.Context, now time.Time) (int, error)
+}
+
+// checkInService implements CheckInService interface
+type checkInService struct {
+	checkInRepo      repository.CheckInRepository
+	notificationRepo repository.NotificationRepository
+	reminderWeekday  time.Weekday
+}
+
+// NewCheckInService creates a new instance of CheckInService
+func NewCheckInService(
+	checkInRepo repository.CheckInRepository,
+	notificationRepo repository.NotificationRepository,
+	reminderWeekday time.Weekday,
+) CheckInService {
+	return &checkInService{
+		checkInRepo:      checkInRepo,
+		notificationRepo: notificationRepo,
+		reminderWeekday:  reminderWeekday,
+	}
+}
+
+// SubmitCheckIn stores the check-in for the week containing req.WeekOf
+func (s *checkInService) SubmitCheckIn(ctx context.Context, userID int64, req *CheckInRequest) (*model.WeeklyCheckIn, error) {
+	now := time.Now()
+	day := now
+	if req.WeekOf != nil {
+		day = *req.WeekOf
+	}
+	week := weekStartOf(day)
+	if week.After(now) {
+		return nil, errors.New(errors.ErrInvalidParam, "不能为未来的周签到")
+	}
+
+	photos := make(model.JSONSlice, 0, len(req.Photos))
+	for _, p := range req.Photos {
+		photos = append(photos, p)
+	}
+
+	existing, err := s.checkInRepo.GetByUserAndWeek(ctx, userID, week)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取签到记录失败")
+	}
+
+	checkIn := existing
+	if checkIn == nil {
+		checkIn = &model.WeeklyCheckIn{
+			UserID:    userID,
+			WeekStart: week,
+			CreatedAt: now,
+		}
+	}
+	checkIn.Weight = req.Weight
+	checkIn.AdherenceRating = req.AdherenceRating
+	checkIn.EnergyLevel = req.EnergyLevel
+	checkIn.HungerLevel = req.HungerLevel
+	checkIn.Notes = req.Notes
+	checkIn.Photos = photos
+	checkIn.UpdatedAt = now
+
+	if existing == nil {
+		err = s.checkInRepo.Create(ctx, checkIn)
+	} else {
+		err = s.checkInRepo.Update(ctx, checkIn)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "保存签到记录失败")
+	}
+
+	return checkIn, nil
+}
+
+// ListCheckIns returns the user's check-ins, most recent week first
+func (s *checkInService) ListCheckIns(ctx context.Context, userID int64, limit, offset int) ([]*model.WeeklyCheckIn, int64, error) {
+	checkIns, total, err := s.checkInRepo.ListByUser(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, errors.ErrDatabase, "获取签到记录失败")
+	}
+	return checkIns, total, nil
+}
+
+// GetLatestCheckIn returns the user's most recent check-in
+func (s *checkInService) GetLatestCheckIn(ctx context.Context, userID int64) (*model.WeeklyCheckIn, error) {
+	checkIn, err := s.checkInRepo.GetLatestByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取签到记录失败")
+	}
+	if checkIn == nil {
+		return nil, errors.New(errors.ErrNotFound, "暂无签到记录")
+	}
+	return checkIn, nil
+}
+
+// SendReminders creates a reminder notification for each user missing this
+// week's check-in. Users already reminded this week are skipped, so running
+// it repeatedly on the reminder day is safe.
+func (s *checkInService) SendReminders(ctx context.Context, now time.Time) (int, error) {
+	if now.Weekday() != s.reminderWeekday {
+		return 0, nil
+	}
+
+	week := weekStartOf(now)
+	userIDs, err := s.checkInRepo.ListUserIDsDueReminder(ctx, week)
+	if err != nil {
+		return 0, err
+	}
+
+	content := "花一分钟记录本周的体重、执行情况、精力和饥饿感，AI 会据此调整你的计划。"
+	sent := 0
+	for _, userID := range userIDs {
+		notification := &model.UserNotification{
+			UserID:  userID,
+			Type:    model.NotificationTypeCheckInReminder,
+			Title:   "本周签到提醒",
+			Content: &content,
+			Data: model.JSONMap{
+				"week_start": week.Format("2006-01-02"),
+				"action":     "submit_check_in",
+			},
+			CreatedAt: now,
+		}
+		if err := s.notificationRepo.Create(ctx, notification); err != nil {
+			logger.Error("Failed to create check-in reminder",
+				zap.Int64("user_id", userID),
+				zap.Error(err),
+			)
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// weekStartOf returns midnight on the Monday of the week containing t.
+// Check-in weeks always start on Monday, whatever the user's week start.
+func weekStartOf(t time.Time) time.Time {
+	return calendarWeekStart(t, time.Monday)
+}
+
+// calendarWeekStart returns midnight on the first day of the calendar week
+// containing t, for weeks starting on firstDay
+func calendarWeekStart(t time.Time, firstDay time.Weekday) time.Time {
+	offset := (int(t.Weekday()) - int(firstDay) + 7) % 7
+	day := t.AddDate(0, 0, -offset)
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// recentCheckIn returns checkIn if it is fresh enough to inform a prompt
+func recentCheckIn(checkIn *model.WeeklyCheckIn, now time.Time) *model.WeeklyCheckIn {
+	if checkIn == nil || now.Sub(checkIn.WeekStart) > checkInPromptMaxAge {
+		return nil
+	}
+	return checkIn
+}
+
+// checkInPromptSection renders a check-in as a prompt section
+func checkInPromptSection(checkIn *model.WeeklyCheckIn) string {
+	section := fmt.Sprintf(`
+Latest Weekly Check-in (week of %s, self-reported, 1-5 scale):
+- Plan Adherence: %d/5
+- Energy Level: %d/5
+- Hunger Level: %d/5
+`, checkIn.WeekStart.Format("2006-01-02"), checkIn.AdherenceRating, checkIn.EnergyLevel, checkIn.HungerLevel)
+
+	if checkIn.Weight != nil {
+		section += fmt.Sprintf("- Weight: %.2f kg\n", *checkIn.Weight)
+	}
+	if checkIn.Notes != nil && *checkIn.Notes != "" {
+		section += fmt.Sprintf("- Notes: %s\n", *checkIn.Notes)
+	}
+	section += "Take this subjective feedback into account, not just the measurements above.\n"
+
+	return section
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// ErrCircuitOpen is wrapped into the error returned for calls skipped while a
+// provider's circuit is open
+var ErrCircuitOpen = errors.New("AI provider circuit open")
+
+// Circuit states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// CircuitBreakerConfig sets when a provider's circuit opens and for how long
+type CircuitBreakerConfig struct {
+	FailureThreshold int // consecutive failures that open the circuit; 0 disables
+	Cooldown         time.Duration
+}
+
+// ProviderCircuitBreaker stops calling a provider endpoint after repeated
+// 5xx responses, timeouts or connection failures. Once the cooldown passes a
+// single probe call is let through: success closes the circuit, failure
+// reopens it for another cooldown. Rate limiting and client errors do not
+// count, since retrying later or fixing the config resolves those.
+type ProviderCircuitBreaker struct {
+	cfg      CircuitBreakerConfig
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+	lastError string
+}
+
+// CircuitStatus is a point-in-time view of one circuit
+type CircuitStatus struct {
+	State               string
+	ConsecutiveFailures int
+	OpenUntil           *time.Time
+	LastError           string
+}
+
+// NewProviderCircuitBreaker creates a breaker with the given thresholds
+func NewProviderCircuitBreaker(cfg CircuitBreakerConfig) *ProviderCircuitBreaker {
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = time.Minute
+	}
+	return &ProviderCircuitBreaker{
+		cfg:      cfg,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+// CircuitKey identifies the circuit for an AI API configuration. Circuits are
+// per provider and endpoint, so one user's unreachable self-hosted server does
+// not block everyone else using the same provider.
+func CircuitKey(api *model.AIAPI) string {
+	return api.Provider + "|" + strings.TrimRight(api.APIEndpoint, "/")
+}
+
+// Allow returns an error wrapping ErrCircuitOpen if calls to key must be
+// skipped. In the half-open state only one caller at a time gets through.
+func (b *ProviderCircuitBreaker) Allow(key string) error {
+	if b == nil || b.cfg.FailureThreshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok || c.failures < b.cfg.FailureThreshold {
+		return nil
+	}
+	if time.Now().Before(c.openUntil) || c.probing {
+		return fmt.Errorf("%w until %s after %d consecutive failures (last: %s)",
+			ErrCircuitOpen, c.openUntil.Format(time.RFC3339), c.failures, c.lastError)
+	}
+	c.probing = true
+	return nil
+}
+
+// Record updates the circuit for key with the outcome of a call
+func (b *ProviderCircuitBreaker) Record(key string, err error) {
+	if b == nil || b.cfg.FailureThreshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	c.probing = false
+
+	// A call cancelled by its caller says nothing about the provider
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	// Any answer that is not a provider failure, even a 429 or 4xx, shows the
+	// provider is reachable again
+	if !isProviderFailure(err) {
+		c.failures = 0
+		return
+	}
+
+	c.failures++
+	c.lastError = err.Error()
+	if c.failures >= b.cfg.FailureThreshold {
+		c.openUntil = time.Now().Add(b.cfg.Cooldown)
+	}
+}
+
+// Status returns the current state of the circuit for key
+func (b *ProviderCircuitBreaker) Status(key string) CircuitStatus {
+	status := CircuitStatus{State: CircuitClosed}
+	if b == nil || b.cfg.FailureThreshold <= 0 {
+		return status
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok {
+		return status
+	}
+	status.ConsecutiveFailures = c.failures
+	status.LastError = c.lastError
+	if c.failures >= b.cfg.FailureThreshold {
+		openUntil := c.openUntil
+		status.OpenUntil = &openUntil
+		status.State = CircuitOpen
+		if !time.Now().Before(openUntil) {
+			status.State = CircuitHalfOpen
+		}
+	}
+	return status
+}
+
+// Wrap returns client guarded by the circuit for key. TestConnection is
+// never skipped so users can check a provider whose circuit is open; its
+// outcome is recorded like any other call.
+func (b *ProviderCircuitBreaker) Wrap(client AIClient, key string) AIClient {
+	if b == nil {
+		return client
+	}
+	return &circuitBreakingClient{client: client, breaker: b, key: key}
+}
+
+// IsCircuitOpen reports whether err comes from a call skipped by the breaker
+func IsCircuitOpen(err error) bool {
+	return errors.Is(err, ErrCircuitOpen)
+}
+
+// isProviderFailure reports whether err indicates the provider itself is
+// failing: a 5xx response, a timeout or a connection failure
+func isProviderFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrProviderUnavailable) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// circuitBreakingClient implements AIClient around another client
+type circuitBreakingClient struct {
+	client  AIClient
+	breaker *ProviderCircuitBreaker
+	key     string
+}
+
+// Call sends the request unless the circuit is open
+func (c *circuitBreakingClient) Call(ctx context.Context, prompt string, config *AIClientConfig) (string, error) {
+	if err := c.breaker.Allow(c.key); err != nil {
+		return "", err
+	}
+	response, err := c.client.Call(ctx, prompt, config)
+	c.breaker.Record(c.key, err)
+	return response, err
+}
+
+// CallStream streams the completion unless the circuit is open
+func (c *circuitBreakingClient) CallStream(ctx context.Context, prompt string, config *AIClientConfig, onChunk func(chunk string)) (string, error) {
+	if err := c.breaker.Allow(c.key); err != nil {
+		return "", err
+	}
+	response, err := c.client.CallStream(ctx, prompt, config, onChunk)
+	c.breaker.Record(c.key, err)
+	return response, err
+}
+
+// TestConnection tests the connection and records the outcome
+func (c *circuitBreakingClient) TestConnection(ctx context.Context, config *AIClientConfig) error {
+	err := c.client.TestConnection(ctx, config)
+	c.breaker.Record(c.key, err)
+	return err
+}
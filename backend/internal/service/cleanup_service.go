@@ -0,0 +1,405 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/pkg/jobqueue"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+// Cleanup kinds
+const (
+	CleanupKindNutritionRecords = "nutrition-records"
+	CleanupKindArchivedPlans    = "archived-plans"
+	CleanupKindAIHistory        = "ai-history"
+)
+
+const (
+	// cleanupTokenTTL is how long a confirmation token can be used
+	cleanupTokenTTL = 10 * time.Minute
+	// cleanupBatchSize is how many records a cleanup deletes per query
+	cleanupBatchSize = 500
+)
+
+// archivedPlanStatuses are the statuses of plans the user is done with
+var archivedPlanStatuses = []string{"inactive", "completed"}
+
+// CleanupRequest describes data to delete. Without ConfirmationToken it is
+// only previewed; the token from the preview confirms the same request.
+type CleanupRequest struct {
+	Kind              string
+	StartDate         *time.Time
+	EndDate           *time.Time
+	ConfirmationToken string
+}
+
+// CleanupPreview reports what a cleanup would delete and the token that
+// confirms it
+type CleanupPreview struct {
+	Kind              string
+	Count             int64
+	ConfirmationToken string
+	ExpiresAt         time.Time
+}
+
+// CleanupOutcome is either the preview of a cleanup or the ID of the job
+// running it
+type CleanupOutcome struct {
+	Preview *CleanupPreview
+	TaskID  string
+}
+
+// CleanupService defines the interface for bulk deletion of user data
+type CleanupService interface {
+	// Cleanup previews a cleanup, or queues it once confirmed
+	Cleanup(ctx context.Context, userID int64, req *CleanupRequest) (*CleanupOutcome, error)
+	// GetCleanupTask retrieves a queued cleanup owned by the user
+	GetCleanupTask(ctx context.Context, userID int64, taskID string) (*jobqueue.Job, error)
+}
+
+// cleanupService implements CleanupService interface
+type cleanupService struct {
+	trainingPlanRepo    repository.TrainingPlanRepository
+	nutritionPlanRepo   repository.NutritionPlanRepository
+	nutritionRecordRepo repository.NutritionRecordRepository
+	aiCallLogRepo       repository.AICallLogRepository
+	generationTaskRepo  repository.GenerationTaskRepository
+	responseArchiveRepo repository.AIResponseArchiveRepository
+	coachMessageRepo    repository.CoachMessageRepository
+	queue               *jobqueue.Queue
+	redis               *redis.Client
+}
+
+// NewCleanupService creates a new instance of CleanupService. Confirmation
+// tokens are kept in Redis.
+func NewCleanupService(
+	trainingPlanRepo repository.TrainingPlanRepository,
+	nutritionPlanRepo repository.NutritionPlanRepository,
+	nutritionRecordRepo repository.NutritionRecordRepository,
+	aiCallLogRepo repository.AICallLogRepository,
+	generationTaskRepo repository.GenerationTaskRepository,
+	responseArchiveRepo repository.AIResponseArchiveRepository,
+	coachMessageRepo repository.CoachMessageRepository,
+	queue *jobqueue.Queue,
+	redisClient *redis.Client,
+) CleanupService {
+	return &cleanupService{
+		trainingPlanRepo:    trainingPlanRepo,
+		nutritionPlanRepo:   nutritionPlanRepo,
+		nutritionRecordRepo: nutritionRecordRepo,
+		aiCallLogRepo:       aiCallLogRepo,
+		generationTaskRepo:  generationTaskRepo,
+		responseArchiveRepo: responseArchiveRepo,
+		coachMessageRepo:    coachMessageRepo,
+		queue:               queue,
+		redis:               redisClient,
+	}
+}
+
+// Cleanup counts what the request covers and hands out a confirmation
+// token, or, given a token issued for the same request, submits the
+// deletion to the low-priority job workers
+func (s *cleanupService) Cleanup(ctx context.Context, userID int64, req *CleanupRequest) (*CleanupOutcome, error) {
+	var run func(ctx context.Context) (*jobqueue.Result, error)
+	switch req.Kind {
+	case CleanupKindNutritionRecords:
+		run = func(jobCtx context.Context) (*jobqueue.Result, error) {
+			return nil, s.deleteNutritionRecords(jobCtx, userID, req)
+		}
+	case CleanupKindArchivedPlans:
+		req.StartDate, req.EndDate = nil, nil
+		run = func(jobCtx context.Context) (*jobqueue.Result, error) {
+			return nil, s.deleteArchivedPlans(jobCtx, userID)
+		}
+	case CleanupKindAIHistory:
+		run = func(jobCtx context.Context) (*jobqueue.Result, error) {
+			return nil, s.deleteAIHistory(jobCtx, userID, req)
+		}
+	default:
+		return nil, errors.New(errors.ErrInvalidParam, "不支持的清理类型")
+	}
+
+	if req.ConfirmationToken == "" {
+		preview, err := s.preview(ctx, userID, req)
+		if err != nil {
+			return nil, err
+		}
+		return &CleanupOutcome{Preview: preview}, nil
+	}
+
+	scope, err := s.redis.GetDel(ctx, cleanupTokenKey(userID, req.ConfirmationToken)).Result()
+	if err != nil && err != redis.Nil {
+		return nil, errors.Wrap(err, errors.ErrCache, "校验确认令牌失败")
+	}
+	if err == redis.Nil || scope != cleanupScope(req) {
+		return nil, errors.New(errors.ErrInvalidParam, "确认令牌无效或已过期，请重新确认")
+	}
+
+	taskID, err := s.queue.Submit(jobqueue.ClassLow, userID, "cleanup-"+req.Kind, run)
+	if err != nil {
+		if err == jobqueue.ErrQueueFull {
+			return nil, errors.New(errors.ErrServiceUnavailable, "清理任务繁忙，请稍后重试")
+		}
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "创建清理任务失败")
+	}
+	return &CleanupOutcome{TaskID: taskID}, nil
+}
+
+// GetCleanupTask retrieves a queued cleanup owned by the user. Cleanup jobs
+// share the export queue, so the kind is checked as well.
+func (s *cleanupService) GetCleanupTask(ctx context.Context, userID int64, taskID string) (*jobqueue.Job, error) {
+	job, ok := s.queue.Get(taskID)
+	if !ok || job.OwnerID != userID || !isCleanupJob(job) {
+		return nil, errors.New(errors.ErrNotFound, "清理任务不存在")
+	}
+	return job, nil
+}
+
+// isCleanupJob reports whether a job was submitted by Cleanup
+func isCleanupJob(job *jobqueue.Job) bool {
+	switch job.Kind {
+	case "cleanup-" + CleanupKindNutritionRecords, "cleanup-" + CleanupKindArchivedPlans, "cleanup-" + CleanupKindAIHistory:
+		return true
+	}
+	return false
+}
+
+// preview counts the data a cleanup covers and stores a token for it
+func (s *cleanupService) preview(ctx context.Context, userID int64, req *CleanupRequest) (*CleanupPreview, error) {
+	var count int64
+	switch req.Kind {
+	case CleanupKindNutritionRecords:
+		n, err := s.nutritionRecordRepo.CountByUser(ctx, userID, req.StartDate, req.EndDate)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "统计饮食记录失败")
+		}
+		count = n
+	case CleanupKindArchivedPlans:
+		training, nutrition, err := s.archivedPlans(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		count = int64(len(training) + len(nutrition))
+	case CleanupKindAIHistory:
+		n, err := s.countAIHistory(ctx, userID, req)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "统计AI交互记录失败")
+		}
+		count = n
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "生成确认令牌失败")
+	}
+	token := hex.EncodeToString(buf)
+	if err := s.redis.Set(ctx, cleanupTokenKey(userID, token), cleanupScope(req), cleanupTokenTTL).Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrCache, "保存确认令牌失败")
+	}
+
+	return &CleanupPreview{
+		Kind:              req.Kind,
+		Count:             count,
+		ConfirmationToken: token,
+		ExpiresAt:         time.Now().Add(cleanupTokenTTL),
+	}, nil
+}
+
+// cleanupTokenKey is the Redis key of a confirmation token
+func cleanupTokenKey(userID int64, token string) string {
+	return fmt.Sprintf("cleanup:confirm:%d:%s", userID, token)
+}
+
+// cleanupScope identifies what a cleanup request deletes, so a token only
+// confirms the request it was issued for
+func cleanupScope(req *CleanupRequest) string {
+	date := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	}
+	return fmt.Sprintf("%s|%s|%s", req.Kind, date(req.StartDate), date(req.EndDate))
+}
+
+// deleteNutritionRecords deletes the records in the request's range batch
+// by batch, reporting progress against the count taken when it started
+func (s *cleanupService) deleteNutritionRecords(ctx context.Context, userID int64, req *CleanupRequest) error {
+	total, err := s.nutritionRecordRepo.CountByUser(ctx, userID, req.StartDate, req.EndDate)
+	if err != nil {
+		return err
+	}
+	jobqueue.ReportProgress(ctx, 0, total)
+
+	var done int64
+	for {
+		n, err := s.nutritionRecordRepo.DeleteBatch(ctx, userID, req.StartDate, req.EndDate, cleanupBatchSize)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		done += n
+		if done > total {
+			total = done
+		}
+		jobqueue.ReportProgress(ctx, done, total)
+	}
+}
+
+// deleteArchivedPlans deletes the user's archived plans one by one. Training
+// records of deleted training plans are kept and detached from the plan.
+func (s *cleanupService) deleteArchivedPlans(ctx context.Context, userID int64) error {
+	training, nutrition, err := s.archivedPlans(ctx, userID)
+	if err != nil {
+		return err
+	}
+	total := int64(len(training) + len(nutrition))
+	jobqueue.ReportProgress(ctx, 0, total)
+
+	var done int64
+	for _, plan := range training {
+		if err := s.trainingPlanRepo.Delete(ctx, plan, false); err != nil {
+			return err
+		}
+		done++
+		jobqueue.ReportProgress(ctx, done, total)
+	}
+	for _, plan := range nutrition {
+		if err := s.nutritionPlanRepo.Delete(ctx, plan); err != nil {
+			return err
+		}
+		done++
+		jobqueue.ReportProgress(ctx, done, total)
+	}
+	return nil
+}
+
+// aiHistoryRange turns the request's inclusive date range into the instants
+// creation times are compared with
+func aiHistoryRange(req *CleanupRequest) (since, before *time.Time) {
+	if req.StartDate != nil {
+		start := dayStart(*req.StartDate)
+		since = &start
+	}
+	if req.EndDate != nil {
+		end := dayStart(*req.EndDate).AddDate(0, 0, 1)
+		before = &end
+	}
+	return since, before
+}
+
+// countAIHistory counts the AI call logs, generation tasks, archived AI
+// responses and coach messages a cleanup covers
+func (s *cleanupService) countAIHistory(ctx context.Context, userID int64, req *CleanupRequest) (int64, error) {
+	since, before := aiHistoryRange(req)
+	var total int64
+	for _, count := range []func(context.Context, int64, *time.Time, *time.Time) (int64, error){
+		s.aiCallLogRepo.CountByUser,
+		s.generationTaskRepo.CountByUser,
+		s.responseArchiveRepo.CountByUser,
+		s.coachMessageRepo.CountByUser,
+	} {
+		n, err := count(ctx, userID, since, before)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// deleteAIHistory permanently deletes what the user sent to and got back
+// from AI providers: the AI call logs, the generation tasks with their
+// parameters and archived responses, and the coach conversation, within the
+// request's range. The cached AI responses and coach history are dropped
+// whatever the range, as they may hold deleted content. Token usage kept for
+// quotas is not deleted, as it holds no content.
+func (s *cleanupService) deleteAIHistory(ctx context.Context, userID int64, req *CleanupRequest) error {
+	total, err := s.countAIHistory(ctx, userID, req)
+	if err != nil {
+		return err
+	}
+	jobqueue.ReportProgress(ctx, 0, total)
+
+	since, before := aiHistoryRange(req)
+	var done int64
+	for _, deleteBatch := range []func(context.Context, int64, *time.Time, *time.Time, int) (int64, error){
+		s.aiCallLogRepo.DeleteBatch,
+		s.generationTaskRepo.DeleteBatch,
+		s.responseArchiveRepo.DeleteBatch,
+		s.coachMessageRepo.DeleteBatch,
+	} {
+		for {
+			n, err := deleteBatch(ctx, userID, since, before, cleanupBatchSize)
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				break
+			}
+			done += n
+			if done > total {
+				total = done
+			}
+			jobqueue.ReportProgress(ctx, done, total)
+		}
+	}
+
+	return s.clearAICaches(ctx, userID)
+}
+
+// clearAICaches deletes the user's cached AI responses and coach history
+func (s *cleanupService) clearAICaches(ctx context.Context, userID int64) error {
+	keys := []string{coachHistoryKey(userID)}
+	iter := s.redis.Scan(ctx, 0, aiResponseCacheUserPattern(userID), cleanupBatchSize).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	for start := 0; start < len(keys); start += cleanupBatchSize {
+		end := start + cleanupBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		if err := s.redis.Del(ctx, keys[start:end]...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archivedPlans returns the IDs of the user's archived training and
+// nutrition plans. Macrocycle blocks are left to their macrocycle.
+func (s *cleanupService) archivedPlans(ctx context.Context, userID int64) ([]int64, []int64, error) {
+	var training, nutrition []int64
+	for _, status := range archivedPlanStatuses {
+		plans, err := s.trainingPlanRepo.ListSummariesByUser(ctx, userID, status)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, errors.ErrDatabase, "获取训练计划失败")
+		}
+		for _, plan := range plans {
+			if plan.MacrocycleID == nil {
+				training = append(training, plan.ID)
+			}
+		}
+
+		nutritionPlans, err := s.nutritionPlanRepo.ListSummariesByUser(ctx, userID, status)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, errors.ErrDatabase, "获取营养计划失败")
+		}
+		for _, plan := range nutritionPlans {
+			nutrition = append(nutrition, plan.ID)
+		}
+	}
+	return training, nutrition, nil
+}
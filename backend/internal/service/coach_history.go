@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// CoachHistoryCache keeps the tail of each user's coach conversation so a
+// chat turn does not read the history back from the database
+type CoachHistoryCache interface {
+	// Get returns the cached history, oldest first, and whether there was one
+	Get(ctx context.Context, userID int64) ([]*model.CoachMessage, bool)
+	// Set replaces the cached history
+	Set(ctx context.Context, userID int64, messages []*model.CoachMessage)
+	// Append adds messages to a cached history. It does nothing when the
+	// history is not cached, so a partial history is never served.
+	Append(ctx context.Context, userID int64, messages ...*model.CoachMessage)
+	// Clear drops the cached history
+	Clear(ctx context.Context, userID int64)
+}
+
+// redisCoachHistoryCache implements CoachHistoryCache with a Redis list per
+// user holding JSON-encoded messages
+type redisCoachHistoryCache struct {
+	client      *redis.Client
+	maxMessages int
+	ttl         time.Duration
+}
+
+// NewCoachHistoryCache creates a Redis-backed CoachHistoryCache keeping at
+// most maxMessages per user for ttl after the last message. It returns nil,
+// which makes every turn read the database, when client is nil or either
+// limit is 0 or less.
+func NewCoachHistoryCache(client *redis.Client, maxMessages int, ttl time.Duration) CoachHistoryCache {
+	if client == nil || maxMessages <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &redisCoachHistoryCache{client: client, maxMessages: maxMessages, ttl: ttl}
+}
+
+func coachHistoryKey(userID int64) string {
+	return fmt.Sprintf("coach:history:%d", userID)
+}
+
+// Get reads the cached history. Redis failures and undecodable entries are
+// logged and treated as a miss.
+func (c *redisCoachHistoryCache) Get(ctx context.Context, userID int64) ([]*model.CoachMessage, bool) {
+	entries, err := c.client.LRange(ctx, coachHistoryKey(userID), 0, -1).Result()
+	if err != nil {
+		logger.Warn("Coach history cache lookup failed", zap.Int64("user_id", userID), zap.Error(err))
+		return nil, false
+	}
+	if len(entries) == 0 {
+		return nil, false
+	}
+
+	messages := make([]*model.CoachMessage, 0, len(entries))
+	for _, entry := range entries {
+		var message model.CoachMessage
+		if err := json.Unmarshal([]byte(entry), &message); err != nil {
+			logger.Warn("Discarding undecodable coach history cache", zap.Int64("user_id", userID), zap.Error(err))
+			c.Clear(ctx, userID)
+			return nil, false
+		}
+		messages = append(messages, &message)
+	}
+	return messages, true
+}
+
+// Set replaces the cached history with messages
+func (c *redisCoachHistoryCache) Set(ctx context.Context, userID int64, messages []*model.CoachMessage) {
+	entries, ok := c.encode(userID, messages)
+	if !ok {
+		return
+	}
+
+	key := coachHistoryKey(userID)
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		if len(entries) > 0 {
+			pipe.RPush(ctx, key, entries...)
+			pipe.LTrim(ctx, key, int64(-c.maxMessages), -1)
+			pipe.Expire(ctx, key, c.ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Warn("Coach history cache store failed", zap.Int64("user_id", userID), zap.Error(err))
+	}
+}
+
+// Append pushes messages onto an existing cached history and trims it
+func (c *redisCoachHistoryCache) Append(ctx context.Context, userID int64, messages ...*model.CoachMessage) {
+	entries, ok := c.encode(userID, messages)
+	if !ok || len(entries) == 0 {
+		return
+	}
+
+	key := coachHistoryKey(userID)
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPushX(ctx, key, entries...)
+		pipe.LTrim(ctx, key, int64(-c.maxMessages), -1)
+		pipe.Expire(ctx, key, c.ttl)
+		return nil
+	})
+	if err != nil {
+		logger.Warn("Coach history cache append failed", zap.Int64("user_id", userID), zap.Error(err))
+	}
+}
+
+// Clear deletes the cached history
+func (c *redisCoachHistoryCache) Clear(ctx context.Context, userID int64) {
+	if err := c.client.Del(ctx, coachHistoryKey(userID)).Err(); err != nil {
+		logger.Warn("Coach history cache clear failed", zap.Int64("user_id", userID), zap.Error(err))
+	}
+}
+
+// encode JSON-encodes messages for the Redis list
+func (c *redisCoachHistoryCache) encode(userID int64, messages []*model.CoachMessage) ([]interface{}, bool) {
+	entries := make([]interface{}, 0, len(messages))
+	for _, message := range messages {
+		entry, err := json.Marshal(message)
+		if err != nil {
+			logger.Warn("Failed to encode coach message for cache", zap.Int64("user_id", userID), zap.Error(err))
+			return nil, false
+		}
+		entries = append(entries, string(entry))
+	}
+	return entries, true
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+// maxCoachHistoryPage caps the messages returned by one history request
+const maxCoachHistoryPage = 200
+
+// CoachService defines the interface for the AI coach chat
+type CoachService interface {
+	// Chat answers a question in the context of the user's current plans,
+	// body data and goals, and stores both sides of the exchange
+	Chat(ctx context.Context, userID int64, req *CoachChatRequest) (*CoachChatResult, error)
+	// History returns the user's last limit messages, oldest first
+	History(ctx context.Context, userID int64, limit int) ([]*model.CoachMessage, error)
+	// ClearHistory deletes the user's conversation
+	ClearHistory(ctx context.Context, userID int64) error
+}
+
+// CoachChatRequest represents a question to the AI coach
+type CoachChatRequest struct {
+	Message string `json:"message" validate:"required,max=2000"`
+	AIAPIID *int64 `json:"ai_api_id"`
+}
+
+// CoachChatResult holds the stored question and the coach's reply
+type CoachChatResult struct {
+	Question *model.CoachMessage
+	Reply    *model.CoachMessage
+}
+
+// coachService implements CoachService interface
+type coachService struct {
+	messageRepo       repository.CoachMessageRepository
+	historyCache      CoachHistoryCache
+	aiAPIRepo         repository.AIAPIRepository
+	trainingPlanRepo  repository.TrainingPlanRepository
+	nutritionPlanRepo repository.NutritionPlanRepository
+	bodyDataRepo      repository.BodyDataRepository
+	fitnessGoalRepo   repository.FitnessGoalRepository
+	constraintRepo    repository.TrainingConstraintRepository
+	aiService         AIService
+	historyMessages   int
+}
+
+// NewCoachService creates a new instance of CoachService.
+// historyCache may be nil to read the history from the database each turn.
+// historyMessages is how many earlier messages are sent with a question.
+func NewCoachService(
+	messageRepo repository.CoachMessageRepository,
+	historyCache CoachHistoryCache,
+	aiAPIRepo repository.AIAPIRepository,
+	trainingPlanRepo repository.TrainingPlanRepository,
+	nutritionPlanRepo repository.NutritionPlanRepository,
+	bodyDataRepo repository.BodyDataRepository,
+	fitnessGoalRepo repository.FitnessGoalRepository,
+	constraintRepo repository.TrainingConstraintRepository,
+	aiService AIService,
+	historyMessages int,
+) CoachService {
+	return &coachService{
+		messageRepo:       messageRepo,
+		historyCache:      historyCache,
+		aiAPIRepo:         aiAPIRepo,
+		trainingPlanRepo:  trainingPlanRepo,
+		nutritionPlanRepo: nutritionPlanRepo,
+		bodyDataRepo:      bodyDataRepo,
+		fitnessGoalRepo:   fitnessGoalRepo,
+		constraintRepo:    constraintRepo,
+		aiService:         aiService,
+		historyMessages:   historyMessages,
+	}
+}
+
+// Chat answers the user's question with their configured AI API. Nothing is
+// stored when the AI call fails, so the user can simply ask again.
+func (s *coachService) Chat(ctx context.Context, userID int64, req *CoachChatRequest) (*CoachChatResult, error) {
+	aiAPIID, err := resolveAIAPIID(ctx, s.aiAPIRepo, userID, req.AIAPIID)
+	if err != nil {
+		return nil, err
+	}
+
+	history, cached, err := s.recentHistory(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := s.chatContext(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	params.AIAPIID = aiAPIID
+	params.Message = req.Message
+	params.History = history
+
+	reply, err := s.aiService.CoachReply(ctx, params)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return nil, appErr
+		}
+		logger.Error("AI coach reply failed", zap.Int64("user_id", userID), zap.Error(err))
+		return nil, errors.Wrap(err, errors.ErrExternalService, "AI教练回复失败，请稍后重试")
+	}
+
+	question := &model.CoachMessage{
+		UserID:  userID,
+		Role:    model.CoachRoleUser,
+		Content: req.Message,
+	}
+	answer := &model.CoachMessage{
+		UserID:  userID,
+		Role:    model.CoachRoleAssistant,
+		Content: reply,
+		AIAPIID: &aiAPIID,
+	}
+	if err := s.messageRepo.CreateBatch(ctx, []*model.CoachMessage{question, answer}); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "保存对话记录失败")
+	}
+
+	if s.historyCache != nil {
+		if cached {
+			s.historyCache.Append(ctx, userID, question, answer)
+		} else {
+			s.historyCache.Set(ctx, userID, append(history, question, answer))
+		}
+	}
+
+	return &CoachChatResult{Question: question, Reply: answer}, nil
+}
+
+// History returns the user's most recent messages from the database
+func (s *coachService) History(ctx context.Context, userID int64, limit int) ([]*model.CoachMessage, error) {
+	if limit <= 0 || limit > maxCoachHistoryPage {
+		limit = maxCoachHistoryPage
+	}
+
+	messages, err := s.messageRepo.ListRecent(ctx, userID, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取对话记录失败")
+	}
+	return messages, nil
+}
+
+// ClearHistory deletes the user's conversation from the database and cache
+func (s *coachService) ClearHistory(ctx context.Context, userID int64) error {
+	if err := s.messageRepo.DeleteByUser(ctx, userID); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase, "清空对话记录失败")
+	}
+	if s.historyCache != nil {
+		s.historyCache.Clear(ctx, userID)
+	}
+	return nil
+}
+
+// recentHistory returns the conversation sent with the next question and
+// whether it came from the cache
+func (s *coachService) recentHistory(ctx context.Context, userID int64) ([]*model.CoachMessage, bool, error) {
+	if s.historyMessages <= 0 {
+		return nil, false, nil
+	}
+
+	if s.historyCache != nil {
+		if history, ok := s.historyCache.Get(ctx, userID); ok {
+			return history, true, nil
+		}
+	}
+
+	history, err := s.messageRepo.ListRecent(ctx, userID, s.historyMessages)
+	if err != nil {
+		return nil, false, errors.Wrap(err, errors.ErrDatabase, "获取对话记录失败")
+	}
+	return history, false, nil
+}
+
+// chatContext gathers the plans, body data, goals and constraints the coach
+// answers in
+func (s *coachService) chatContext(ctx context.Context, userID int64, now time.Time) (*CoachChatParams, error) {
+	params := &CoachChatParams{UserID: userID, Now: now}
+
+	trainingPlans, err := s.trainingPlanRepo.ListSummariesByUser(ctx, userID, "active")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取训练计划失败")
+	}
+	if len(trainingPlans) > 0 {
+		params.TrainingPlan = trainingPlans[0]
+		if params.TodayTraining, err = s.trainingPlanRepo.GetTodaySchedule(ctx, userID, now); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "获取今日训练失败")
+		}
+		if params.TomorrowTraining, err = s.trainingPlanRepo.GetTodaySchedule(ctx, userID, now.AddDate(0, 0, 1)); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "获取明日训练失败")
+		}
+	}
+
+	nutritionPlans, err := s.nutritionPlanRepo.ListSummariesByUser(ctx, userID, "active")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取饮食计划失败")
+	}
+	if len(nutritionPlans) > 0 {
+		params.NutritionPlan = nutritionPlans[0]
+		if params.TodayMeals, err = s.nutritionPlanRepo.GetTodayMeals(ctx, userID, now); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "获取今日饮食失败")
+		}
+	}
+
+	if params.BodyData, err = s.bodyDataRepo.GetLatestByUserID(ctx, userID); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取身体数据失败")
+	}
+	if params.FitnessGoals, err = s.fitnessGoalRepo.GetByUserID(ctx, userID, "active"); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取健身目标失败")
+	}
+
+	constraints, err := s.constraintRepo.ListByUser(ctx, userID, &now)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取训练限制失败")
+	}
+	params.Constraints = constraintsForPlan(constraints, now, now.AddDate(0, 0, 1))
+
+	return params, nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// DietTerm is an entry of the controlled vocabularies for nutrition plan
+// preferences and dietary restrictions. Plans store Code; requests may also
+// use the names or a common alias.
+type DietTerm struct {
+	Code        string
+	Name        string
+	EnglishName string
+	aliases     []string
+}
+
+// label is how a term is shown to the AI
+func (t DietTerm) label() string {
+	return fmt.Sprintf("%s (%s)", t.Name, t.EnglishName)
+}
+
+var cuisineTerms = []DietTerm{
+	{Code: "chinese_home", Name: "家常菜", EnglishName: "Chinese home cooking", aliases: []string{"chinese", "中餐", "中式"}},
+	{Code: "sichuan", Name: "川菜", EnglishName: "Sichuan", aliases: []string{"szechuan", "四川菜"}},
+	{Code: "cantonese", Name: "粤菜", EnglishName: "Cantonese", aliases: []string{"广东菜"}},
+	{Code: "hunan", Name: "湘菜", EnglishName: "Hunan", aliases: []string{"湖南菜"}},
+	{Code: "shandong", Name: "鲁菜", EnglishName: "Shandong", aliases: []string{"山东菜"}},
+	{Code: "jiangsu", Name: "苏菜", EnglishName: "Jiangsu", aliases: []string{"淮扬菜", "huaiyang"}},
+	{Code: "zhejiang", Name: "浙菜", EnglishName: "Zhejiang"},
+	{Code: "fujian", Name: "闽菜", EnglishName: "Fujian"},
+	{Code: "anhui", Name: "徽菜", EnglishName: "Anhui"},
+	{Code: "northeastern", Name: "东北菜", EnglishName: "Northeastern Chinese"},
+	{Code: "northwestern", Name: "西北菜", EnglishName: "Northwestern Chinese"},
+	{Code: "japanese", Name: "日本料理", EnglishName: "Japanese", aliases: []string{"日料", "日式"}},
+	{Code: "korean", Name: "韩国料理", EnglishName: "Korean", aliases: []string{"韩餐", "韩式"}},
+	{Code: "southeast_asian", Name: "东南亚菜", EnglishName: "Southeast Asian"},
+	{Code: "indian", Name: "印度菜", EnglishName: "Indian"},
+	{Code: "mediterranean", Name: "地中海饮食", EnglishName: "Mediterranean", aliases: []string{"地中海", "地中海菜"}},
+	{Code: "western", Name: "西餐", EnglishName: "Western", aliases: []string{"西式"}},
+	{Code: "mexican", Name: "墨西哥菜", EnglishName: "Mexican"},
+}
+
+var dietaryRestrictionTerms = []DietTerm{
+	{Code: "vegetarian", Name: "素食", EnglishName: "Vegetarian", aliases: []string{"蛋奶素"}},
+	{Code: "vegan", Name: "纯素", EnglishName: "Vegan", aliases: []string{"全素"}},
+	{Code: "halal", Name: "清真", EnglishName: "Halal"},
+	{Code: "kosher", Name: "犹太洁食", EnglishName: "Kosher"},
+	{Code: "lactose_free", Name: "无乳糖", EnglishName: "Lactose-free", aliases: []string{"乳糖不耐", "乳糖不耐受"}},
+	{Code: "dairy_free", Name: "无乳制品", EnglishName: "Dairy-free"},
+	{Code: "gluten_free", Name: "无麸质", EnglishName: "Gluten-free", aliases: []string{"麸质过敏"}},
+	{Code: "nut_free", Name: "无坚果", EnglishName: "Nut-free", aliases: []string{"坚果过敏"}},
+	{Code: "seafood_free", Name: "无海鲜", EnglishName: "Seafood-free", aliases: []string{"海鲜过敏"}},
+	{Code: "egg_free", Name: "无蛋", EnglishName: "Egg-free", aliases: []string{"鸡蛋过敏"}},
+	{Code: "soy_free", Name: "无大豆", EnglishName: "Soy-free", aliases: []string{"大豆过敏"}},
+	{Code: "no_pork", Name: "不吃猪肉", EnglishName: "No pork"},
+	{Code: "no_beef", Name: "不吃牛肉", EnglishName: "No beef"},
+	{Code: "low_sodium", Name: "低钠", EnglishName: "Low sodium", aliases: []string{"低盐"}},
+	{Code: "low_carb", Name: "低碳水", EnglishName: "Low carb", aliases: []string{"低碳"}},
+	{Code: "keto", Name: "生酮", EnglishName: "Ketogenic", aliases: []string{"生酮饮食"}},
+	{Code: "low_sugar", Name: "低糖", EnglishName: "Low sugar", aliases: []string{"控糖"}},
+	{Code: "no_spicy", Name: "不吃辣", EnglishName: "No spicy food", aliases: []string{"不辣", "忌辣"}},
+}
+
+// CuisineTerms returns the cuisines a nutrition plan can prefer
+func CuisineTerms() []DietTerm {
+	return append([]DietTerm(nil), cuisineTerms...)
+}
+
+// DietaryRestrictionTerms returns the dietary restrictions a nutrition plan
+// can respect
+func DietaryRestrictionTerms() []DietTerm {
+	return append([]DietTerm(nil), dietaryRestrictionTerms...)
+}
+
+// findDietTerm looks a value up by code, name or alias, ignoring case and
+// surrounding space
+func findDietTerm(terms []DietTerm, value string) (DietTerm, bool) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	for _, t := range terms {
+		if v == t.Code || v == t.Name || v == strings.ToLower(t.EnglishName) {
+			return t, true
+		}
+		for _, alias := range t.aliases {
+			if v == alias {
+				return t, true
+			}
+		}
+	}
+	return DietTerm{}, false
+}
+
+// normalizeDietTerms maps request values to their codes, dropping
+// duplicates. kind names the list in the error for an unknown value.
+func normalizeDietTerms(terms []DietTerm, values []string, kind string) ([]string, error) {
+	codes := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		term, ok := findDietTerm(terms, value)
+		if !ok {
+			return nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("不支持的%s：%s", kind, value))
+		}
+		if !seen[term.Code] {
+			seen[term.Code] = true
+			codes = append(codes, term.Code)
+		}
+	}
+	return codes, nil
+}
+
+// dietTermList formats stored codes for a prompt the way the templates show
+// lists, or returns "" when there are none. Values that are not codes, as
+// stored by plans created before the vocabularies, are shown as they are.
+func dietTermList(terms []DietTerm, values []interface{}) string {
+	if len(values) == 0 {
+		return ""
+	}
+	labels := make([]string, 0, len(values))
+	for _, v := range values {
+		s := fmt.Sprint(v)
+		if term, ok := findDietTerm(terms, s); ok && term.Code == s {
+			s = term.label()
+		}
+		labels = append(labels, s)
+	}
+	return fmt.Sprintf("%v", labels)
+}
+
+// planDietaryRestrictions formats a plan's dietary restrictions for a prompt
+func planDietaryRestrictions(plan *model.NutritionPlan) string {
+	return dietTermList(dietaryRestrictionTerms, plan.DietaryRestrictions)
+}
+
+// planPreferences formats a plan's cuisine preferences for a prompt
+func planPreferences(plan *model.NutritionPlan) string {
+	return dietTermList(cuisineTerms, plan.Preferences)
+}
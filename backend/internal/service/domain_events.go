@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/events"
+)
+
+// Sources of a created plan
+const (
+	planSourceGenerated = "generated"
+	planSourceAdjusted  = "adjusted"
+	planSourceCloned    = "cloned"
+)
+
+// publishTrainingPlanCreated announces a saved training plan. parentID is
+// the plan it was adjusted or cloned from, if any.
+func publishTrainingPlanCreated(ctx context.Context, plan *model.TrainingPlan, source string, parentID *int64) {
+	events.Publish(ctx, events.TrainingPlanCreated, plan.UserID, events.PlanPayload{
+		PlanID:       plan.ID,
+		Name:         plan.PlanName,
+		Source:       source,
+		ParentPlanID: parentID,
+		Status:       plan.Status,
+		StartDate:    plan.StartDate.Format("2006-01-02"),
+		EndDate:      plan.EndDate.Format("2006-01-02"),
+	})
+}
+
+// publishTrainingPlanStatusChanged announces a saved status change; it
+// does nothing when the status stayed prevStatus
+func publishTrainingPlanStatusChanged(ctx context.Context, plan *model.TrainingPlan, prevStatus string) {
+	if plan.Status == prevStatus {
+		return
+	}
+	events.Publish(ctx, events.TrainingPlanStatusChanged, plan.UserID, events.PlanPayload{
+		PlanID:     plan.ID,
+		Status:     plan.Status,
+		PrevStatus: prevStatus,
+	})
+}
+
+func publishTrainingPlanDeleted(ctx context.Context, plan *model.TrainingPlan) {
+	events.Publish(ctx, events.TrainingPlanDeleted, plan.UserID, events.PlanPayload{
+		PlanID: plan.ID,
+		Status: plan.Status,
+	})
+}
+
+func publishNutritionPlanCreated(ctx context.Context, plan *model.NutritionPlan, source string, parentID *int64) {
+	events.Publish(ctx, events.NutritionPlanCreated, plan.UserID, events.PlanPayload{
+		PlanID:       plan.ID,
+		Name:         plan.PlanName,
+		Source:       source,
+		ParentPlanID: parentID,
+		Status:       plan.Status,
+		StartDate:    plan.StartDate.Format("2006-01-02"),
+		EndDate:      plan.EndDate.Format("2006-01-02"),
+	})
+}
+
+func publishTrainingRecordCreated(ctx context.Context, record *model.TrainingRecord) {
+	events.Publish(ctx, events.TrainingRecordCreated, record.UserID, events.RecordPayload{
+		RecordID:        record.ID,
+		PlanID:          record.PlanID,
+		Date:            record.WorkoutDate.Format("2006-01-02"),
+		Kind:            record.WorkoutType,
+		DurationMinutes: record.DurationMinutes,
+	})
+}
+
+func publishNutritionRecordCreated(ctx context.Context, record *model.NutritionRecord) {
+	calories := record.Calories
+	events.Publish(ctx, events.NutritionRecordCreated, record.UserID, events.RecordPayload{
+		RecordID: record.ID,
+		Date:     record.MealDate.Format("2006-01-02"),
+		Kind:     record.MealTime,
+		Calories: &calories,
+	})
+}
+
+func publishGoalSet(ctx context.Context, goal *model.FitnessGoal) {
+	publishGoalEvent(ctx, events.GoalSet, goal)
+}
+
+func publishGoalAchieved(ctx context.Context, goal *model.FitnessGoal) {
+	publishGoalEvent(ctx, events.GoalAchieved, goal)
+}
+
+func publishGoalEvent(ctx context.Context, eventType string, goal *model.FitnessGoal) {
+	payload := events.GoalPayload{
+		GoalID:        goal.ID,
+		GoalType:      goal.GoalType,
+		TargetWeight:  goal.TargetWeight,
+		TargetBodyFat: goal.TargetBodyFat,
+	}
+	if goal.Deadline != nil {
+		payload.Deadline = goal.Deadline.Format("2006-01-02")
+	}
+	events.Publish(ctx, eventType, goal.UserID, payload)
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+)
+
+// maxEquipmentProfiles caps how many equipment profiles a user can keep
+const maxEquipmentProfiles = 10
+
+// EquipmentProfileRequest holds equipment profile fields; nil fields are left
+// unchanged on update
+type EquipmentProfileRequest struct {
+	Name         *string
+	LocationType *string
+	Equipment    []string
+	Weekdays     []int // 0 = Sunday; a weekday belongs to at most one profile
+	IsDefault    *bool
+}
+
+// EquipmentService defines the interface for equipment inventory operations
+type EquipmentService interface {
+	ListProfiles(ctx context.Context, userID int64) ([]*model.EquipmentProfile, error)
+	// CreateProfile and UpdateProfile move any weekdays they assign away from
+	// the user's other profiles
+	CreateProfile(ctx context.Context, userID int64, req *EquipmentProfileRequest) (*model.EquipmentProfile, error)
+	UpdateProfile(ctx context.Context, userID, profileID int64, req *EquipmentProfileRequest) (*model.EquipmentProfile, error)
+	// DeleteProfile removes a profile; if it was the default, the oldest
+	// remaining profile becomes the default
+	DeleteProfile(ctx context.Context, userID, profileID int64) error
+}
+
+// equipmentService implements EquipmentService interface
+type equipmentService struct {
+	equipmentRepo repository.EquipmentProfileRepository
+}
+
+// NewEquipmentService creates a new instance of EquipmentService
+func NewEquipmentService(equipmentRepo repository.EquipmentProfileRepository) EquipmentService {
+	return &equipmentService{equipmentRepo: equipmentRepo}
+}
+
+// ListProfiles returns the user's equipment profiles, default first
+func (s *equipmentService) ListProfiles(ctx context.Context, userID int64) ([]*model.EquipmentProfile, error) {
+	profiles, err := s.equipmentRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取器材清单失败")
+	}
+	return profiles, nil
+}
+
+// CreateProfile adds an equipment profile. The user's first profile is
+// always the default.
+func (s *equipmentService) CreateProfile(ctx context.Context, userID int64, req *EquipmentProfileRequest) (*model.EquipmentProfile, error) {
+	if req.Name == nil || strings.TrimSpace(*req.Name) == "" {
+		return nil, errors.New(errors.ErrInvalidParam, "器材清单名称不能为空")
+	}
+	name := strings.TrimSpace(*req.Name)
+
+	count, err := s.equipmentRepo.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取器材清单失败")
+	}
+	if count >= maxEquipmentProfiles {
+		return nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("最多只能创建%d个器材清单", maxEquipmentProfiles))
+	}
+	if err := s.checkNameAvailable(ctx, userID, name, 0); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	profile := &model.EquipmentProfile{
+		UserID:       userID,
+		Name:         name,
+		LocationType: model.LocationTypeOther,
+		Equipment:    toEquipmentSlice(req.Equipment),
+		Weekdays:     toWeekdaySlice(req.Weekdays),
+		IsDefault:    count == 0 || (req.IsDefault != nil && *req.IsDefault),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if req.LocationType != nil {
+		profile.LocationType = *req.LocationType
+	}
+
+	if err := s.equipmentRepo.Create(ctx, profile); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "创建器材清单失败")
+	}
+	if err := s.releaseWeekdays(ctx, profile); err != nil {
+		return nil, err
+	}
+	if profile.IsDefault && count > 0 {
+		if err := s.equipmentRepo.SetDefault(ctx, userID, profile.ID); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "设置默认器材清单失败")
+		}
+	}
+
+	return profile, nil
+}
+
+// UpdateProfile edits an equipment profile owned by the user
+func (s *equipmentService) UpdateProfile(ctx context.Context, userID, profileID int64, req *EquipmentProfileRequest) (*model.EquipmentProfile, error) {
+	profile, err := s.getOwnedProfile(ctx, userID, profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errors.New(errors.ErrInvalidParam, "器材清单名称不能为空")
+		}
+		if name != profile.Name {
+			if err := s.checkNameAvailable(ctx, userID, name, profile.ID); err != nil {
+				return nil, err
+			}
+			profile.Name = name
+		}
+	}
+	if req.LocationType != nil {
+		profile.LocationType = *req.LocationType
+	}
+	if req.Equipment != nil {
+		profile.Equipment = toEquipmentSlice(req.Equipment)
+	}
+	if req.Weekdays != nil {
+		profile.Weekdays = toWeekdaySlice(req.Weekdays)
+	}
+	profile.UpdatedAt = time.Now()
+
+	if err := s.equipmentRepo.Update(ctx, profile); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "更新器材清单失败")
+	}
+	if req.Weekdays != nil {
+		if err := s.releaseWeekdays(ctx, profile); err != nil {
+			return nil, err
+		}
+	}
+
+	// Clearing the default is not allowed; pick another profile as default instead
+	if req.IsDefault != nil && *req.IsDefault && !profile.IsDefault {
+		if err := s.equipmentRepo.SetDefault(ctx, userID, profile.ID); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "设置默认器材清单失败")
+		}
+		profile.IsDefault = true
+	}
+
+	return profile, nil
+}
+
+// DeleteProfile deletes an equipment profile owned by the user
+func (s *equipmentService) DeleteProfile(ctx context.Context, userID, profileID int64) error {
+	profile, err := s.getOwnedProfile(ctx, userID, profileID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.equipmentRepo.Delete(ctx, profile.ID); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase, "删除器材清单失败")
+	}
+
+	if profile.IsDefault {
+		remaining, err := s.equipmentRepo.ListByUser(ctx, userID)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrDatabase, "获取器材清单失败")
+		}
+		if len(remaining) > 0 {
+			if err := s.equipmentRepo.SetDefault(ctx, userID, remaining[0].ID); err != nil {
+				return errors.Wrap(err, errors.ErrDatabase, "设置默认器材清单失败")
+			}
+		}
+	}
+
+	return nil
+}
+
+// getOwnedProfile loads a profile and verifies it belongs to the user
+func (s *equipmentService) getOwnedProfile(ctx context.Context, userID, profileID int64) (*model.EquipmentProfile, error) {
+	profile, err := s.equipmentRepo.GetByID(ctx, profileID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取器材清单失败")
+	}
+	if profile == nil || profile.UserID != userID {
+		return nil, errors.New(errors.ErrNotFound, "器材清单不存在")
+	}
+	return profile, nil
+}
+
+// checkNameAvailable rejects a name already used by another of the user's profiles
+func (s *equipmentService) checkNameAvailable(ctx context.Context, userID int64, name string, selfID int64) error {
+	existing, err := s.equipmentRepo.GetByUserAndName(ctx, userID, name)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase, "获取器材清单失败")
+	}
+	if existing != nil && existing.ID != selfID {
+		return errors.New(errors.ErrConflict, "器材清单名称已存在")
+	}
+	return nil
+}
+
+// releaseWeekdays removes the profile's weekdays from the user's other profiles
+func (s *equipmentService) releaseWeekdays(ctx context.Context, profile *model.EquipmentProfile) error {
+	claimed := profile.WeekdayList()
+	if len(claimed) == 0 {
+		return nil
+	}
+
+	profiles, err := s.equipmentRepo.ListByUser(ctx, profile.UserID)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase, "获取器材清单失败")
+	}
+
+	for _, other := range profiles {
+		if other.ID == profile.ID {
+			continue
+		}
+		var kept []int
+		for _, day := range other.WeekdayList() {
+			if !containsWeekday(claimed, day) {
+				kept = append(kept, int(day))
+			}
+		}
+		if len(kept) == len(other.Weekdays) {
+			continue
+		}
+		other.Weekdays = toWeekdaySlice(kept)
+		other.UpdatedAt = time.Now()
+		if err := s.equipmentRepo.Update(ctx, other); err != nil {
+			return errors.Wrap(err, errors.ErrDatabase, "更新器材清单失败")
+		}
+	}
+	return nil
+}
+
+// toEquipmentSlice trims and de-duplicates equipment names
+func toEquipmentSlice(items []string) model.JSONSlice {
+	seen := make(map[string]bool, len(items))
+	slice := make(model.JSONSlice, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		slice = append(slice, item)
+	}
+	return slice
+}
+
+// toWeekdaySlice de-duplicates and sorts weekdays, dropping invalid values
+func toWeekdaySlice(days []int) model.JSONSlice {
+	var seen [7]bool
+	for _, d := range days {
+		if d >= 0 && d <= 6 {
+			seen[d] = true
+		}
+	}
+
+	slice := make(model.JSONSlice, 0, len(days))
+	for d, ok := range seen {
+		if ok {
+			slice = append(slice, d)
+		}
+	}
+	return slice
+}
+
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// equipmentPromptSection renders the user's equipment profiles as a prompt
+// section. When profiles are assigned to weekdays it adds a per-weekday
+// location schedule anchored on the plan's start date.
+func equipmentPromptSection(profiles []*model.EquipmentProfile, start time.Time) string {
+	section := "\nEquipment Profiles (only program exercises the user can do with this equipment):\n"
+	for _, p := range profiles {
+		label := p.Name
+		if p.IsDefault {
+			label += ", default"
+		}
+		equipment := "bodyweight only"
+		if len(p.Equipment) > 0 {
+			names := make([]string, 0, len(p.Equipment))
+			for _, e := range p.Equipment {
+				names = append(names, fmt.Sprint(e))
+			}
+			equipment = strings.Join(names, ", ")
+		}
+		section += fmt.Sprintf("- %s (%s): %s\n", label, p.LocationType, equipment)
+	}
+
+	schedule := make(map[time.Weekday]*model.EquipmentProfile)
+	for _, p := range profiles {
+		for _, day := range p.WeekdayList() {
+			schedule[day] = p
+		}
+	}
+	if len(schedule) == 0 {
+		if len(profiles) > 1 {
+			section += "Unless told otherwise, plan around the default profile.\n"
+		}
+		return section
+	}
+
+	section += fmt.Sprintf("\nWeekly Training Locations (day 1 of the plan is %s, %s):\n", start.Format("2006-01-02"), start.Weekday())
+	for i := 1; i <= 7; i++ {
+		day := time.Weekday(i % 7) // Monday first
+		if p, ok := schedule[day]; ok {
+			section += fmt.Sprintf("- %s: %s (%s)\n", day, p.Name, p.LocationType)
+		}
+	}
+	section += `Work out each day's weekday from its date. On each day, only use exercises possible with that day's location equipment, e.g. bodyweight or dumbbell work at home and barbell work only where a barbell is available. Days not listed use the default profile. Arrange the weekly split so each location gets the sessions its equipment suits best.
+`
+	return section
+}
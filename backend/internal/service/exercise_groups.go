@@ -0,0 +1,74 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// normalizeExerciseGroups tidies the grouping fields of a plan's days: empty
+// superset IDs and tempos are dropped, as are superset IDs that only one
+// exercise of the day carries and circuit rounds below 2. Providers using
+// structured outputs fill every field, so this keeps the stored plan free of
+// placeholders. It returns the number of fields removed.
+func normalizeExerciseGroups(planData model.JSONMap) int {
+	removed := 0
+	weeks, _ := planData["weeks"].([]interface{})
+	for _, weekRaw := range weeks {
+		week, _ := weekRaw.(map[string]interface{})
+		days, _ := week["days"].([]interface{})
+		for _, dayRaw := range days {
+			day, ok := dayRaw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if _, ok := day["circuit_rounds"]; ok && jsonInt(day["circuit_rounds"]) < 2 {
+				delete(day, "circuit_rounds")
+				removed++
+			}
+			exercises, _ := day["exercises"].([]interface{})
+			removed += normalizeDayGroups(exercises)
+		}
+	}
+	return removed
+}
+
+// normalizeDayGroups tidies the grouping fields of one day's exercises
+func normalizeDayGroups(exercises []interface{}) int {
+	removed := 0
+	members := make(map[string]int)
+	for _, exerciseRaw := range exercises {
+		exercise, ok := exerciseRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"superset_id", "tempo"} {
+			if _, ok := exercise[key]; !ok {
+				continue
+			}
+			value, _ := exercise[key].(string)
+			value = strings.TrimSpace(value)
+			if value == "" {
+				delete(exercise, key)
+				removed++
+				continue
+			}
+			exercise[key] = value
+		}
+		if superset, ok := exercise["superset_id"].(string); ok {
+			members[superset]++
+		}
+	}
+
+	for _, exerciseRaw := range exercises {
+		exercise, ok := exerciseRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if superset, ok := exercise["superset_id"].(string); ok && members[superset] < 2 {
+			delete(exercise, "superset_id")
+			removed++
+		}
+	}
+	return removed
+}
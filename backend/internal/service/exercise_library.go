@@ -0,0 +1,257 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+)
+
+// ExerciseTerm is an entry of the equipment and joint vocabularies of the
+// exercise library. Requests may use the code, the names or an alias.
+type ExerciseTerm struct {
+	Code        string
+	Name        string
+	EnglishName string
+	aliases     []string
+}
+
+// label is how a term is shown to the AI
+func (t ExerciseTerm) label() string {
+	return fmt.Sprintf("%s (%s)", t.Name, t.EnglishName)
+}
+
+var equipmentTerms = []ExerciseTerm{
+	{Code: "bodyweight", Name: "自重", EnglishName: "Bodyweight", aliases: []string{"徒手", "无器械"}},
+	{Code: "barbell", Name: "杠铃", EnglishName: "Barbell"},
+	{Code: "dumbbell", Name: "哑铃", EnglishName: "Dumbbell"},
+	{Code: "kettlebell", Name: "壶铃", EnglishName: "Kettlebell"},
+	{Code: "cable", Name: "龙门架", EnglishName: "Cable machine", aliases: []string{"绳索", "拉力器"}},
+	{Code: "machine", Name: "固定器械", EnglishName: "Weight machine", aliases: []string{"器械"}},
+	{Code: "resistance_band", Name: "弹力带", EnglishName: "Resistance band", aliases: []string{"band", "阻力带"}},
+	{Code: "pull_up_bar", Name: "单杠", EnglishName: "Pull-up bar", aliases: []string{"引体向上杆"}},
+}
+
+var jointTerms = []ExerciseTerm{
+	{Code: "shoulder", Name: "肩", EnglishName: "Shoulder", aliases: []string{"肩部", "肩膀"}},
+	{Code: "elbow", Name: "肘", EnglishName: "Elbow", aliases: []string{"肘部"}},
+	{Code: "wrist", Name: "腕", EnglishName: "Wrist", aliases: []string{"手腕", "腕部"}},
+	{Code: "lower_back", Name: "腰", EnglishName: "Lower back", aliases: []string{"腰部", "下背"}},
+	{Code: "hip", Name: "髋", EnglishName: "Hip", aliases: []string{"髋部"}},
+	{Code: "knee", Name: "膝", EnglishName: "Knee", aliases: []string{"膝盖", "膝部"}},
+}
+
+// LibraryExercise is an exercise of the built-in library used to suggest
+// substitutes. Exercises with the same MuscleGroup train the same muscles;
+// Joints are the joints it loads heavily.
+type LibraryExercise struct {
+	Name        string
+	EnglishName string
+	MuscleGroup string
+	Equipment   string
+	Joints      []string
+}
+
+// exerciseLibrary is ordered within each muscle group from the most to the
+// least demanding variation, so substitutes stay close to the original
+var exerciseLibrary = []LibraryExercise{
+	{Name: "杠铃卧推", EnglishName: "Barbell Bench Press", MuscleGroup: "chest", Equipment: "barbell", Joints: []string{"shoulder", "elbow"}},
+	{Name: "哑铃卧推", EnglishName: "Dumbbell Bench Press", MuscleGroup: "chest", Equipment: "dumbbell", Joints: []string{"shoulder"}},
+	{Name: "上斜哑铃卧推", EnglishName: "Incline Dumbbell Press", MuscleGroup: "chest", Equipment: "dumbbell", Joints: []string{"shoulder"}},
+	{Name: "器械推胸", EnglishName: "Machine Chest Press", MuscleGroup: "chest", Equipment: "machine"},
+	{Name: "绳索夹胸", EnglishName: "Cable Fly", MuscleGroup: "chest", Equipment: "cable", Joints: []string{"shoulder"}},
+	{Name: "俯卧撑", EnglishName: "Push-up", MuscleGroup: "chest", Equipment: "bodyweight", Joints: []string{"shoulder", "wrist"}},
+	{Name: "弹力带推胸", EnglishName: "Band Chest Press", MuscleGroup: "chest", Equipment: "resistance_band"},
+
+	{Name: "引体向上", EnglishName: "Pull-up", MuscleGroup: "back", Equipment: "pull_up_bar", Joints: []string{"shoulder", "elbow"}},
+	{Name: "杠铃划船", EnglishName: "Barbell Row", MuscleGroup: "back", Equipment: "barbell", Joints: []string{"lower_back"}},
+	{Name: "高位下拉", EnglishName: "Lat Pulldown", MuscleGroup: "back", Equipment: "cable", Joints: []string{"shoulder"}},
+	{Name: "哑铃划船", EnglishName: "Dumbbell Row", MuscleGroup: "back", Equipment: "dumbbell"},
+	{Name: "坐姿绳索划船", EnglishName: "Seated Cable Row", MuscleGroup: "back", Equipment: "cable"},
+	{Name: "器械划船", EnglishName: "Machine Row", MuscleGroup: "back", Equipment: "machine"},
+	{Name: "弹力带划船", EnglishName: "Band Row", MuscleGroup: "back", Equipment: "resistance_band"},
+
+	{Name: "杠铃推举", EnglishName: "Overhead Press", MuscleGroup: "shoulders", Equipment: "barbell", Joints: []string{"shoulder", "lower_back"}},
+	{Name: "哑铃推举", EnglishName: "Dumbbell Shoulder Press", MuscleGroup: "shoulders", Equipment: "dumbbell", Joints: []string{"shoulder"}},
+	{Name: "器械推肩", EnglishName: "Machine Shoulder Press", MuscleGroup: "shoulders", Equipment: "machine", Joints: []string{"shoulder"}},
+	{Name: "派克俯卧撑", EnglishName: "Pike Push-up", MuscleGroup: "shoulders", Equipment: "bodyweight", Joints: []string{"shoulder", "wrist"}},
+	{Name: "哑铃侧平举", EnglishName: "Dumbbell Lateral Raise", MuscleGroup: "shoulders", Equipment: "dumbbell"},
+	{Name: "弹力带侧平举", EnglishName: "Band Lateral Raise", MuscleGroup: "shoulders", Equipment: "resistance_band"},
+
+	{Name: "杠铃深蹲", EnglishName: "Barbell Back Squat", MuscleGroup: "quads", Equipment: "barbell", Joints: []string{"knee", "hip", "lower_back"}},
+	{Name: "腿举", EnglishName: "Leg Press", MuscleGroup: "quads", Equipment: "machine", Joints: []string{"knee"}},
+	{Name: "保加利亚分腿蹲", EnglishName: "Bulgarian Split Squat", MuscleGroup: "quads", Equipment: "dumbbell", Joints: []string{"knee", "hip"}},
+	{Name: "高脚杯深蹲", EnglishName: "Goblet Squat", MuscleGroup: "quads", Equipment: "dumbbell", Joints: []string{"knee"}},
+	{Name: "壶铃高脚杯深蹲", EnglishName: "Kettlebell Goblet Squat", MuscleGroup: "quads", Equipment: "kettlebell", Joints: []string{"knee"}},
+	{Name: "自重深蹲", EnglishName: "Bodyweight Squat", MuscleGroup: "quads", Equipment: "bodyweight", Joints: []string{"knee"}},
+	{Name: "弹力带深蹲", EnglishName: "Band Squat", MuscleGroup: "quads", Equipment: "resistance_band", Joints: []string{"knee"}},
+
+	{Name: "杠铃硬拉", EnglishName: "Deadlift", MuscleGroup: "posterior_chain", Equipment: "barbell", Joints: []string{"lower_back", "hip"}},
+	{Name: "罗马尼亚硬拉", EnglishName: "Romanian Deadlift", MuscleGroup: "posterior_chain", Equipment: "barbell", Joints: []string{"lower_back"}},
+	{Name: "杠铃臀推", EnglishName: "Barbell Hip Thrust", MuscleGroup: "posterior_chain", Equipment: "barbell", Joints: []string{"hip"}},
+	{Name: "哑铃罗马尼亚硬拉", EnglishName: "Dumbbell Romanian Deadlift", MuscleGroup: "posterior_chain", Equipment: "dumbbell", Joints: []string{"lower_back"}},
+	{Name: "壶铃摇摆", EnglishName: "Kettlebell Swing", MuscleGroup: "posterior_chain", Equipment: "kettlebell", Joints: []string{"lower_back", "hip"}},
+	{Name: "器械腿弯举", EnglishName: "Machine Leg Curl", MuscleGroup: "posterior_chain", Equipment: "machine", Joints: []string{"knee"}},
+	{Name: "臀桥", EnglishName: "Glute Bridge", MuscleGroup: "posterior_chain", Equipment: "bodyweight"},
+
+	{Name: "杠铃弯举", EnglishName: "Barbell Curl", MuscleGroup: "biceps", Equipment: "barbell", Joints: []string{"elbow", "wrist"}},
+	{Name: "哑铃弯举", EnglishName: "Dumbbell Curl", MuscleGroup: "biceps", Equipment: "dumbbell", Joints: []string{"elbow"}},
+	{Name: "绳索弯举", EnglishName: "Cable Curl", MuscleGroup: "biceps", Equipment: "cable", Joints: []string{"elbow"}},
+	{Name: "弹力带弯举", EnglishName: "Band Curl", MuscleGroup: "biceps", Equipment: "resistance_band", Joints: []string{"elbow"}},
+
+	{Name: "窄距卧推", EnglishName: "Close-grip Bench Press", MuscleGroup: "triceps", Equipment: "barbell", Joints: []string{"elbow", "shoulder"}},
+	{Name: "哑铃颈后臂屈伸", EnglishName: "Overhead Dumbbell Extension", MuscleGroup: "triceps", Equipment: "dumbbell", Joints: []string{"elbow", "shoulder"}},
+	{Name: "绳索下压", EnglishName: "Triceps Pushdown", MuscleGroup: "triceps", Equipment: "cable", Joints: []string{"elbow"}},
+	{Name: "钻石俯卧撑", EnglishName: "Diamond Push-up", MuscleGroup: "triceps", Equipment: "bodyweight", Joints: []string{"elbow", "wrist"}},
+	{Name: "弹力带下压", EnglishName: "Band Pushdown", MuscleGroup: "triceps", Equipment: "resistance_band", Joints: []string{"elbow"}},
+
+	{Name: "悬垂举腿", EnglishName: "Hanging Leg Raise", MuscleGroup: "core", Equipment: "pull_up_bar", Joints: []string{"shoulder"}},
+	{Name: "绳索卷腹", EnglishName: "Cable Crunch", MuscleGroup: "core", Equipment: "cable"},
+	{Name: "俄罗斯转体", EnglishName: "Russian Twist", MuscleGroup: "core", Equipment: "bodyweight", Joints: []string{"lower_back"}},
+	{Name: "平板支撑", EnglishName: "Plank", MuscleGroup: "core", Equipment: "bodyweight"},
+	{Name: "卷腹", EnglishName: "Crunch", MuscleGroup: "core", Equipment: "bodyweight"},
+	{Name: "死虫", EnglishName: "Dead Bug", MuscleGroup: "core", Equipment: "bodyweight"},
+}
+
+// EquipmentTerms returns the equipment the exercise library knows
+func EquipmentTerms() []ExerciseTerm {
+	return append([]ExerciseTerm(nil), equipmentTerms...)
+}
+
+// JointTerms returns the joints an exercise substitution can spare
+func JointTerms() []ExerciseTerm {
+	return append([]ExerciseTerm(nil), jointTerms...)
+}
+
+// normalizeExerciseTerms maps each value to the code of its term, matching
+// codes, names and aliases case-insensitively. Unknown values are rejected
+// with what, the vocabulary's name.
+func normalizeExerciseTerms(values []string, terms []ExerciseTerm, what string) ([]string, error) {
+	codes := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		term := findExerciseTerm(value, terms)
+		if term == nil {
+			return nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("不支持的%s: %s", what, value))
+		}
+		if !seen[term.Code] {
+			seen[term.Code] = true
+			codes = append(codes, term.Code)
+		}
+	}
+	return codes, nil
+}
+
+// findExerciseTerm returns the term value names, or nil
+func findExerciseTerm(value string, terms []ExerciseTerm) *ExerciseTerm {
+	key := strings.ToLower(strings.TrimSpace(value))
+	for i, term := range terms {
+		if key == term.Code || key == term.Name || key == strings.ToLower(term.EnglishName) {
+			return &terms[i]
+		}
+		for _, alias := range term.aliases {
+			if key == strings.ToLower(alias) {
+				return &terms[i]
+			}
+		}
+	}
+	return nil
+}
+
+// exerciseTermLabels returns the labels of codes for prompts
+func exerciseTermLabels(codes []string, terms []ExerciseTerm) []string {
+	labels := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if term := findExerciseTerm(code, terms); term != nil {
+			labels = append(labels, term.label())
+		}
+	}
+	return labels
+}
+
+// findLibraryExercise returns the library exercise a plan's exercise name
+// refers to, or nil. Plans often qualify a name ("平板杠铃卧推"), so
+// without an exact match the longest library name it contains is used.
+func findLibraryExercise(name string) *LibraryExercise {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "" {
+		return nil
+	}
+
+	var best *LibraryExercise
+	bestLen := 0
+	for i, ex := range exerciseLibrary {
+		for _, candidate := range []string{ex.Name, strings.ToLower(ex.EnglishName)} {
+			if key == candidate {
+				return &exerciseLibrary[i]
+			}
+			if strings.Contains(key, candidate) && len(candidate) > bestLen {
+				best, bestLen = &exerciseLibrary[i], len(candidate)
+			}
+		}
+	}
+	return best
+}
+
+// exerciseFilter is what a substitute from the library must avoid
+type exerciseFilter struct {
+	unavailableEquipment []string
+	avoidJoints          []string
+	// restrictedMovements are the lower-cased movements of the user's
+	// training constraints, matched against exercise names
+	restrictedMovements []string
+	// exclude are the names of exercises already on the day
+	exclude []string
+}
+
+// allows reports whether ex passes the filter
+func (f exerciseFilter) allows(ex LibraryExercise) bool {
+	if containsString(f.unavailableEquipment, ex.Equipment) {
+		return false
+	}
+	for _, joint := range ex.Joints {
+		if containsString(f.avoidJoints, joint) {
+			return false
+		}
+	}
+	for _, name := range f.exclude {
+		if strings.EqualFold(strings.TrimSpace(name), ex.Name) || strings.EqualFold(strings.TrimSpace(name), ex.EnglishName) {
+			return false
+		}
+	}
+	lower := strings.ToLower(ex.Name + " " + ex.EnglishName)
+	for _, m := range f.restrictedMovements {
+		if strings.Contains(lower, m) {
+			return false
+		}
+	}
+	return true
+}
+
+// librarySubstitute picks a substitute for original from the library: an
+// exercise for the same muscle group that passes filter, preferring one with
+// the same equipment. It returns nil when the library has none.
+func librarySubstitute(original *LibraryExercise, filter exerciseFilter) *LibraryExercise {
+	var fallback *LibraryExercise
+	for i, ex := range exerciseLibrary {
+		if ex.MuscleGroup != original.MuscleGroup || ex.Name == original.Name || !filter.allows(ex) {
+			continue
+		}
+		if ex.Equipment == original.Equipment {
+			return &exerciseLibrary[i]
+		}
+		if fallback == nil {
+			fallback = &exerciseLibrary[i]
+		}
+	}
+	return fallback
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
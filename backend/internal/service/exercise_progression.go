@@ -0,0 +1,439 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+const (
+	// defaultProgressionDays is how far back progression looks by default
+	defaultProgressionDays = 90
+	// progressionStallSessions is the number of sessions in a row without a
+	// higher estimated 1RM or volume after which a deload is suggested
+	progressionStallSessions = 3
+	// progressionDeloadRatio is the share of the load kept on a deload
+	progressionDeloadRatio = 0.9
+	// maxPromptProgressions caps the exercises listed in the adjustment prompt
+	maxPromptProgressions = 10
+)
+
+// Load suggestion actions
+const (
+	ProgressionIncreaseLoad = "increase_load"
+	ProgressionAddReps      = "add_reps"
+	ProgressionDeload       = "deload"
+)
+
+// progressionRepBands are the rep ranges progression works within; a
+// session's range is the band holding the reps of its heaviest set
+var progressionRepBands = [][2]int{{1, 5}, {6, 8}, {9, 12}, {13, 20}}
+
+// ExerciseProgression is the logged history of one exercise with its trend
+// and a suggested load for the next session
+type ExerciseProgression struct {
+	Exercise string `json:"exercise"`
+	Days     int    `json:"days"`
+	// Sessions are oldest first
+	Sessions []ProgressionSession `json:"sessions"`
+	// Trend is nil with fewer than two sessions that give a 1RM estimate
+	Trend      *ProgressionTrend `json:"trend,omitempty"`
+	Suggestion *LoadSuggestion   `json:"suggestion,omitempty"`
+
+	HasSufficientData bool   `json:"has_sufficient_data"`
+	Message           string `json:"message,omitempty"`
+}
+
+// ProgressionSession sums up the sets of an exercise logged on one day
+type ProgressionSession struct {
+	Date     time.Time `json:"date"`
+	RecordID int64     `json:"record_id"`
+	Sets     int       `json:"sets"`
+	// RepsPerSet and WeightUsed are the logged sets in order
+	RepsPerSet []int     `json:"reps_per_set"`
+	WeightUsed []float64 `json:"weight_used"`
+	// TopWeight is the heaviest load, TopReps the most reps done with it
+	TopWeight float64 `json:"top_weight"`
+	TopReps   int     `json:"top_reps"`
+	TotalReps int     `json:"total_reps"`
+	// Volume is reps × weight over all sets, in kg
+	Volume float64 `json:"volume"`
+	// EstimatedOneRepMax is the best Epley estimate of the sets, 0 when no
+	// set gives one
+	EstimatedOneRepMax float64 `json:"estimated_one_rep_max"`
+}
+
+// ProgressionTrend describes how the estimated 1RM moved over the sessions
+type ProgressionTrend struct {
+	StartOneRepMax float64 `json:"start_one_rep_max"`
+	EndOneRepMax   float64 `json:"end_one_rep_max"`
+	Change         float64 `json:"change"`
+	ChangePercent  float64 `json:"change_percent"`
+	// WeeklyChange is the slope of a least-squares fit, in kg per week
+	WeeklyChange float64 `json:"weekly_change"`
+	// Direction is "up", "down" or "flat"
+	Direction string `json:"direction"`
+}
+
+// LoadSuggestion is the load and reps to aim for next session, following
+// double progression: add reps within the range, then add load once every
+// working set reaches its top
+type LoadSuggestion struct {
+	Action     string  `json:"action"`
+	Weight     float64 `json:"weight"`
+	Sets       int     `json:"sets"`
+	RepsLow    int     `json:"reps_low"`
+	RepsHigh   int     `json:"reps_high"`
+	TargetReps int     `json:"target_reps"`
+	Reason     string  `json:"reason"`
+}
+
+// GetExerciseProgression returns the user's history of an exercise over
+// the last days days, matching exercise names case- and space-insensitively
+func (s *statisticsService) GetExerciseProgression(ctx context.Context, userID int64, exercise string, days int) (*ExerciseProgression, error) {
+	exercise = strings.TrimSpace(exercise)
+	if exercise == "" {
+		return nil, errors.New(errors.ErrInvalidParam, "动作名称不能为空")
+	}
+	if days <= 0 {
+		days = defaultProgressionDays
+	}
+
+	endDate := time.Now()
+	startDate := dayStart(endDate).AddDate(0, 0, -days)
+	records, err := s.trainingRecordRepo.ListByUser(ctx, userID, &startDate, &endDate)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取训练记录失败")
+	}
+
+	key := normalizeExerciseName(exercise)
+	history := progressionHistories(records)[key]
+	progression := &ExerciseProgression{Exercise: exercise, Days: days, Sessions: []ProgressionSession{}}
+	if history == nil {
+		progression.Message = "该时间段内没有该动作的训练记录"
+		return progression, nil
+	}
+	history.analyse(progression)
+	return progression, nil
+}
+
+// progressionHistory is the sessions of one exercise, oldest first
+type progressionHistory struct {
+	// name is the exercise name as last logged
+	name     string
+	sessions []ProgressionSession
+}
+
+// progressionHistories groups the logged sets of records by exercise, one
+// session per exercise and day
+func progressionHistories(records []*model.TrainingRecord) map[string]*progressionHistory {
+	sorted := append([]*model.TrainingRecord(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].WorkoutDate.Before(sorted[j].WorkoutDate) })
+
+	histories := make(map[string]*progressionHistory)
+	for _, record := range sorted {
+		day := dayStart(record.WorkoutDate)
+		for _, ex := range exerciseRecords(record.Exercises) {
+			key := normalizeExerciseName(ex.ExerciseName)
+			if key == "" {
+				continue
+			}
+			h := histories[key]
+			if h == nil {
+				h = &progressionHistory{}
+				histories[key] = h
+			}
+			h.name = strings.Join(strings.Fields(ex.ExerciseName), " ")
+
+			// The same exercise twice on a day, in one record or two, is one session
+			if n := len(h.sessions); n == 0 || !h.sessions[n-1].Date.Equal(day) {
+				h.sessions = append(h.sessions, ProgressionSession{Date: day, RecordID: record.ID})
+			}
+			h.sessions[len(h.sessions)-1].addSets(ex)
+		}
+	}
+
+	for key, h := range histories {
+		kept := h.sessions[:0]
+		for _, session := range h.sessions {
+			if session.Sets > 0 {
+				kept = append(kept, session)
+			}
+		}
+		if len(kept) == 0 {
+			delete(histories, key)
+			continue
+		}
+		h.sessions = kept
+	}
+	return histories
+}
+
+// addSets adds the sets of an exercise log that have reps
+func (p *ProgressionSession) addSets(ex model.ExerciseRecord) {
+	for i, reps := range ex.RepsPerSet {
+		if reps <= 0 {
+			continue
+		}
+		weight := 0.0
+		if i < len(ex.WeightUsed) && ex.WeightUsed[i] > 0 {
+			weight = ex.WeightUsed[i]
+		}
+
+		p.Sets++
+		p.RepsPerSet = append(p.RepsPerSet, reps)
+		p.WeightUsed = append(p.WeightUsed, weight)
+		p.TotalReps += reps
+		p.Volume = math.Round((p.Volume+float64(reps)*weight)*10) / 10
+		if weight > p.TopWeight || (weight == p.TopWeight && reps > p.TopReps) {
+			p.TopWeight, p.TopReps = weight, reps
+		}
+		if estimate := estimateOneRepMax(weight, reps); estimate > p.EstimatedOneRepMax {
+			p.EstimatedOneRepMax = estimate
+		}
+	}
+}
+
+// analyse fills in the sessions, trend and suggestion of a progression
+func (h *progressionHistory) analyse(progression *ExerciseProgression) {
+	progression.Sessions = h.sessions
+	progression.Trend = progressionTrend(h.sessions)
+	progression.Suggestion = suggestNextLoad(h.name, h.sessions)
+	progression.HasSufficientData = progression.Trend != nil
+	if !progression.HasSufficientData {
+		progression.Message = "记录的训练次数不足，暂时无法计算趋势"
+	}
+}
+
+// progressionTrend fits the estimated 1RMs of the sessions over time
+func progressionTrend(sessions []ProgressionSession) *ProgressionTrend {
+	var estimated []ProgressionSession
+	for _, s := range sessions {
+		if s.EstimatedOneRepMax > 0 {
+			estimated = append(estimated, s)
+		}
+	}
+	if len(estimated) < 2 {
+		return nil
+	}
+
+	first, last := estimated[0], estimated[len(estimated)-1]
+	trend := &ProgressionTrend{
+		StartOneRepMax: first.EstimatedOneRepMax,
+		EndOneRepMax:   last.EstimatedOneRepMax,
+		Change:         roundLoad(last.EstimatedOneRepMax - first.EstimatedOneRepMax),
+	}
+	trend.ChangePercent = math.Round(trend.Change/trend.StartOneRepMax*1000) / 10
+
+	// Least-squares slope of the estimate against days since the first session
+	var sumX, sumY, sumXY, sumXX float64
+	n := float64(len(estimated))
+	for _, s := range estimated {
+		x := s.Date.Sub(first.Date).Hours() / 24
+		sumX += x
+		sumY += s.EstimatedOneRepMax
+		sumXY += x * s.EstimatedOneRepMax
+		sumXX += x * x
+	}
+	if denom := n*sumXX - sumX*sumX; denom > 0 {
+		trend.WeeklyChange = math.Round((n*sumXY-sumX*sumY)/denom*7*10) / 10
+	}
+
+	// Less than 0.5% a week is noise in logged sets
+	switch threshold := trend.StartOneRepMax * 0.005; {
+	case trend.WeeklyChange > threshold:
+		trend.Direction = "up"
+	case trend.WeeklyChange < -threshold:
+		trend.Direction = "down"
+	default:
+		trend.Direction = "flat"
+	}
+	return trend
+}
+
+// suggestNextLoad applies double progression to the last session: a load
+// increase once every working set reached the top of the rep range, another
+// rep otherwise, and a deload after progressionStallSessions sessions
+// without progress
+func suggestNextLoad(name string, sessions []ProgressionSession) *LoadSuggestion {
+	if len(sessions) == 0 {
+		return nil
+	}
+	last := sessions[len(sessions)-1]
+
+	// Working sets are those at the top weight
+	working, minReps := 0, 0
+	for i, reps := range last.RepsPerSet {
+		if last.WeightUsed[i] != last.TopWeight {
+			continue
+		}
+		if working == 0 || reps < minReps {
+			minReps = reps
+		}
+		working++
+	}
+
+	low, high := progressionRepRangeFor(last.TopReps)
+	suggestion := &LoadSuggestion{
+		Weight:   last.TopWeight,
+		Sets:     working,
+		RepsLow:  low,
+		RepsHigh: high,
+	}
+
+	switch {
+	case last.TopWeight > 0 && progressionStalled(sessions):
+		suggestion.Action = ProgressionDeload
+		suggestion.Weight = roundLoad(last.TopWeight * progressionDeloadRatio)
+		suggestion.TargetReps = low
+		suggestion.Reason = fmt.Sprintf("连续%d次训练估算1RM和训练量都没有提升，建议减重约10%%恢复后再逐步加回", progressionStallSessions)
+	case last.TopWeight > 0 && minReps >= high:
+		suggestion.Action = ProgressionIncreaseLoad
+		suggestion.Weight = roundLoad(last.TopWeight + loadIncrement(name, last.TopWeight))
+		suggestion.TargetReps = low
+		suggestion.Reason = fmt.Sprintf("上次所有工作组都完成了%d次，可以增加重量并从%d次开始", high, low)
+	default:
+		suggestion.Action = ProgressionAddReps
+		suggestion.TargetReps = minReps + 1
+		if suggestion.TargetReps < low {
+			suggestion.TargetReps = low
+		}
+		if suggestion.TargetReps > high && last.TopWeight > 0 {
+			suggestion.TargetReps = high
+		}
+		suggestion.Reason = fmt.Sprintf("保持重量，争取每组完成%d次，所有组达到%d次后再加重", suggestion.TargetReps, high)
+	}
+	return suggestion
+}
+
+// progressionRepRangeFor returns the rep band holding reps
+func progressionRepRangeFor(reps int) (int, int) {
+	for _, band := range progressionRepBands {
+		if reps <= band[1] {
+			return band[0], band[1]
+		}
+	}
+	last := progressionRepBands[len(progressionRepBands)-1]
+	return last[0], last[1]
+}
+
+// progressionStalled reports whether none of the last
+// progressionStallSessions sessions beat the best estimated 1RM or volume
+// of the sessions before them; more reps at the same load still counts as
+// progress
+func progressionStalled(sessions []ProgressionSession) bool {
+	if len(sessions) <= progressionStallSessions {
+		return false
+	}
+	bestEstimate, bestVolume := 0.0, 0.0
+	for _, s := range sessions[:len(sessions)-progressionStallSessions] {
+		if s.EstimatedOneRepMax > bestEstimate {
+			bestEstimate = s.EstimatedOneRepMax
+		}
+		if s.Volume > bestVolume {
+			bestVolume = s.Volume
+		}
+	}
+	if bestEstimate == 0 {
+		return false
+	}
+	for _, s := range sessions[len(sessions)-progressionStallSessions:] {
+		if s.EstimatedOneRepMax == 0 || s.EstimatedOneRepMax > bestEstimate || s.Volume > bestVolume {
+			return false
+		}
+	}
+	return true
+}
+
+// loadIncrement is the step up from weight: 5% for squats and deadlifts,
+// which progress faster, 2.5% for everything else, and never below 1 kg
+func loadIncrement(name string, weight float64) float64 {
+	ratio := 0.025
+	if lift, ok := matchMainLift(name); ok && (lift == model.LiftSquat || lift == model.LiftDeadlift) {
+		ratio = 0.05
+	}
+	if step := weight * ratio; step > 1 {
+		return step
+	}
+	return 1
+}
+
+// exerciseProgressions analyses every exercise with at least two sessions in
+// records, most recently trained first
+func exerciseProgressions(records []*model.TrainingRecord) []*ExerciseProgression {
+	histories := progressionHistories(records)
+	progressions := make([]*ExerciseProgression, 0, len(histories))
+	latest := make(map[*ExerciseProgression]time.Time, len(histories))
+	for _, h := range histories {
+		if len(h.sessions) < 2 {
+			continue
+		}
+		p := &ExerciseProgression{Exercise: h.name}
+		h.analyse(p)
+		progressions = append(progressions, p)
+		latest[p] = h.sessions[len(h.sessions)-1].Date
+	}
+	sort.Slice(progressions, func(i, j int) bool {
+		a, b := latest[progressions[i]], latest[progressions[j]]
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return progressions[i].Exercise < progressions[j].Exercise
+	})
+	return progressions
+}
+
+// progressionPromptSection lists the logged performance and suggested next
+// load of the exercises the user has been training
+func progressionPromptSection(progressions []*ExerciseProgression) string {
+	if len(progressions) == 0 {
+		return ""
+	}
+	if len(progressions) > maxPromptProgressions {
+		progressions = progressions[:maxPromptProgressions]
+	}
+
+	section := "\nExercise Progression (from logged sets; suggested next loads use double progression):\n"
+	for _, p := range progressions {
+		last := p.Sessions[len(p.Sessions)-1]
+		line := fmt.Sprintf("- %s: last %s %s", p.Exercise, last.Date.Format("2006-01-02"), setsLine(last))
+		if p.Trend != nil {
+			line += fmt.Sprintf(", e1RM %.1f→%.1f kg (%s)", p.Trend.StartOneRepMax, p.Trend.EndOneRepMax, p.Trend.Direction)
+		}
+		if s := p.Suggestion; s != nil {
+			if s.Weight > 0 {
+				line += fmt.Sprintf("; next: %g kg × %d-%d reps (%s)", s.Weight, s.RepsLow, s.RepsHigh, s.Action)
+			} else {
+				line += fmt.Sprintf("; next: %d-%d reps (%s)", s.RepsLow, s.RepsHigh, s.Action)
+			}
+		}
+		section += line + "\n"
+	}
+	section += "Base the loads of these exercises in the adjusted plan on the suggested next loads rather than repeating the current plan's loads.\n"
+	return section
+}
+
+// setsLine writes a session's sets compactly, e.g. "60kg×8,8,7" or
+// "60kg×8, 55kg×10"; bodyweight sets show reps only
+func setsLine(session ProgressionSession) string {
+	var groups []string
+	for i := 0; i < len(session.RepsPerSet); {
+		weight := session.WeightUsed[i]
+		reps := []string{}
+		for ; i < len(session.RepsPerSet) && session.WeightUsed[i] == weight; i++ {
+			reps = append(reps, fmt.Sprintf("%d", session.RepsPerSet[i]))
+		}
+		group := strings.Join(reps, ",") + " reps"
+		if weight > 0 {
+			group = fmt.Sprintf("%gkg×%s", weight, strings.Join(reps, ","))
+		}
+		groups = append(groups, group)
+	}
+	return strings.Join(groups, ", ")
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// ExerciseSafetyNote is the vetted safety guidance for a library exercise
+type ExerciseSafetyNote struct {
+	Chinese string
+	English string
+}
+
+// exerciseSafetyNotes holds the curated notes, keyed by the Chinese name of
+// the library exercise they belong to
+var exerciseSafetyNotes = map[string]ExerciseSafetyNote{
+	"杠铃卧推":   {Chinese: "肩胛骨后收下沉，双脚踩实；杠铃落于胸骨下缘，不要弹胸；大重量时安排保护者。", English: "Retract and depress the shoulder blades, keep the feet planted; lower the bar to the lower chest without bouncing; use a spotter for heavy sets."},
+	"哑铃卧推":   {Chinese: "肩胛骨收紧，哑铃下放至胸部两侧，肘部与躯干约成45度；结束时先将哑铃放回大腿再起身。", English: "Keep the shoulder blades tight, lower the dumbbells beside the chest with elbows about 45 degrees from the torso; bring them back to the thighs before sitting up."},
+	"上斜哑铃卧推": {Chinese: "凳面角度30至45度，肩胛骨贴紧凳面；下放时控制速度，避免肩部过度外展。", English: "Set the bench to 30-45 degrees and keep the shoulder blades against it; lower under control without flaring the shoulders."},
+	"器械推胸":   {Chinese: "调整座椅使把手与胸部中段平齐；推起时不要锁死肘关节，回程保持控制。", English: "Adjust the seat so the handles are level with the mid chest; do not lock the elbows and control the return."},
+	"绳索夹胸":   {Chinese: "肘部保持微屈固定，动作幅度以肩部无不适为限；躯干稳定，不要借助身体前后摆动。", English: "Keep a fixed slight bend in the elbows and stay within a pain-free shoulder range; keep the torso still instead of swinging."},
+	"俯卧撑":    {Chinese: "身体从头到脚保持一条直线，收紧核心和臀部；双手略宽于肩，手腕不适时可改用俯卧撑架。", English: "Keep a straight line from head to heels with the core and glutes braced; hands slightly wider than the shoulders, use push-up handles if the wrists hurt."},
+	"弹力带推胸":  {Chinese: "训练前检查弹力带有无裂口，固定点牢靠；推出与回收都保持控制，避免弹力带回弹。", English: "Check the band for tears and anchor it securely; control both the press and the return so the band does not snap back."},
+
+	"引体向上":   {Chinese: "从肩胛下沉开始发力，避免身体摆动借力；下放至手臂接近伸直但保持肩部紧张。", English: "Start each rep by pulling the shoulder blades down and avoid kipping; lower until the arms are nearly straight while keeping the shoulders engaged."},
+	"杠铃划船":   {Chinese: "髋部折叠，背部保持中立不弓背；杠铃拉向下腹部，腰部不适时改用有支撑的划船。", English: "Hinge at the hips with a neutral back; row the bar to the lower abdomen and switch to a supported row if the lower back aches."},
+	"高位下拉":   {Chinese: "横杆拉至锁骨上方，不要拉到颈后；躯干稍后仰即可，避免用身体后倒借力。", English: "Pull the bar to the upper chest, never behind the neck; lean back only slightly instead of throwing the torso back."},
+	"哑铃划船":   {Chinese: "支撑手和膝稳定，背部平直；拉起时肘部贴近身体，不要扭转躯干。", English: "Keep the supporting hand and knee stable and the back flat; row with the elbow close to the body without twisting the torso."},
+	"坐姿绳索划船": {Chinese: "挺胸坐直，拉到腹部时肩胛骨后收；回程时不要弯腰前探。", English: "Sit tall and squeeze the shoulder blades together at the abdomen; do not round forward on the return."},
+	"器械划船":   {Chinese: "胸垫贴紧，调整座椅使把手与胸部下缘平齐；拉至肩胛骨完全后收，回程控制速度。", English: "Keep the chest against the pad with the handles at lower-chest height; pull until the shoulder blades are fully retracted and control the return."},
+	"弹力带划船":  {Chinese: "固定点与胸部同高并检查牢固；站姿稳定，回程保持张力不要让弹力带突然松开。", English: "Anchor the band at chest height and check it is secure; stand stable and keep tension on the return."},
+
+	"杠铃推举":   {Chinese: "收紧臀部和核心，避免腰部过度后仰；杠铃沿面部前方直线上推，头部在杠铃过脸后前移。", English: "Brace the glutes and core to avoid arching the lower back; press in a straight line past the face and move the head through once the bar clears it."},
+	"哑铃推举":   {Chinese: "坐姿时背部贴紧靠背，哑铃下放至耳朵高度；推起时不要互相撞击或锁死肘部。", English: "When seated keep the back against the pad and lower the dumbbells to ear height; do not clash them or lock the elbows at the top."},
+	"器械推肩":   {Chinese: "调整座椅使把手起始位置与肩同高；推起时肩部不要耸起，回程控制速度。", English: "Adjust the seat so the handles start at shoulder height; do not shrug while pressing and control the return."},
+	"派克俯卧撑":  {Chinese: "臀部抬高使身体呈倒V字形，头部落在双手前方；肩部不适或手腕疼痛时降低幅度。", English: "Raise the hips into an inverted V and lower the head in front of the hands; shorten the range if the shoulders or wrists hurt."},
+	"哑铃侧平举":  {Chinese: "选择较轻重量，手臂微屈抬至与肩同高即可；不要耸肩或借助身体摆动。", English: "Use a light weight and raise the slightly bent arms only to shoulder height; do not shrug or swing."},
+	"弹力带侧平举": {Chinese: "双脚踩稳弹力带防止滑脱，抬至与肩同高；回程慢放，保持肩部放松不耸起。", English: "Stand firmly on the band so it cannot slip and raise to shoulder height; lower slowly without shrugging."},
+
+	"杠铃深蹲":    {Chinese: "下蹲前吸气收紧核心，膝盖与脚尖方向一致；背部保持中立，大重量时使用安全杠或保护者。", English: "Breathe in and brace before descending, track the knees over the toes; keep a neutral back and use safety bars or a spotter for heavy sets."},
+	"腿举":      {Chinese: "下背部全程贴紧靠垫，下放时膝盖不要内扣；推起时不要锁死膝关节。", English: "Keep the lower back against the pad throughout and the knees from caving in; do not lock the knees at the top."},
+	"保加利亚分腿蹲": {Chinese: "前脚距离凳子足够远，下蹲时前膝与脚尖方向一致；先徒手掌握平衡再加重量。", English: "Place the front foot far enough from the bench and track the front knee over the toes; master the balance without weight first."},
+	"高脚杯深蹲":   {Chinese: "哑铃贴近胸前，挺胸下蹲；膝盖随脚尖方向打开，脚跟不要离地。", English: "Hold the dumbbell close to the chest and squat with the chest up; let the knees follow the toes and keep the heels down."},
+	"壶铃高脚杯深蹲": {Chinese: "双手握住壶铃把手贴近胸前，挺胸下蹲；脚跟不要离地，膝盖不要内扣。", English: "Hold the kettlebell by the horns close to the chest and squat with the chest up; keep the heels down and the knees from caving in."},
+	"自重深蹲":    {Chinese: "双脚与肩同宽，臀部向后向下坐；膝盖与脚尖方向一致，脚跟始终着地。", English: "Stand shoulder-width apart and sit back and down; track the knees over the toes and keep the heels on the floor."},
+	"弹力带深蹲":   {Chinese: "检查弹力带无破损并踩稳；下蹲时背部挺直，起身时不要让弹力带把身体拉向前。", English: "Check the band for damage and stand on it securely; keep the back straight and do not let the band pull you forward as you stand."},
+
+	"杠铃硬拉":     {Chinese: "杠铃贴近小腿，背部保持中立，起拉前收紧背阔肌；用腿和髋发力，不要弓背或猛拉。", English: "Keep the bar against the shins and the back neutral, tighten the lats before pulling; drive with the legs and hips without rounding or jerking."},
+	"罗马尼亚硬拉":   {Chinese: "膝盖微屈固定，以髋为轴向后推臀；杠铃贴腿下放至腘绳肌有拉伸感即可，背部不弓。", English: "Keep a fixed slight knee bend and push the hips back; slide the bar down the legs until the hamstrings stretch, without rounding the back."},
+	"杠铃臀推":     {Chinese: "杠铃处垫上护垫，上背靠在凳子边缘；顶峰时收紧臀部，避免腰部过度后仰。", English: "Pad the bar and rest the upper back on the bench edge; squeeze the glutes at the top without overarching the lower back."},
+	"哑铃罗马尼亚硬拉": {Chinese: "哑铃贴近大腿下放，以髋为轴向后推臀；背部保持平直，腰部不适时减小幅度。", English: "Lower the dumbbells close to the thighs while pushing the hips back; keep the back flat and shorten the range if the lower back aches."},
+	"壶铃摇摆":     {Chinese: "这是髋部铰链动作而非深蹲，用臀部爆发力把壶铃荡起；背部保持中立，壶铃最高到胸前。", English: "Hinge rather than squat and swing the kettlebell with a powerful hip drive; keep the back neutral and the bell no higher than the chest."},
+	"器械腿弯举":    {Chinese: "调整器械使膝关节与转轴对齐；弯举和回放都保持控制，不要抬起臀部借力。", English: "Line the knees up with the machine's pivot; control both the curl and the return without lifting the hips."},
+	"臀桥":       {Chinese: "双脚踩实，靠臀部发力抬起髋部；顶峰时身体从肩到膝成一条直线，不要过度挺腰。", English: "Plant the feet and lift the hips with the glutes; finish with a straight line from shoulders to knees without overarching."},
+
+	"杠铃弯举":  {Chinese: "上臂贴紧身体保持不动，不要摆动身体借力；手腕保持中立，腕部不适时改用曲杆。", English: "Keep the upper arms still at the sides and do not swing; keep the wrists neutral and switch to an EZ bar if they hurt."},
+	"哑铃弯举":  {Chinese: "上臂固定，弯举时不要耸肩或晃动身体；下放至手臂接近伸直并保持控制。", English: "Keep the upper arms fixed without shrugging or swaying; lower under control until the arms are nearly straight."},
+	"绳索弯举":  {Chinese: "站稳后上臂贴紧身体，只活动肘关节；回程慢放，不要被绳索拉动身体。", English: "Stand firm with the upper arms at the sides and move only at the elbows; return slowly without being pulled by the cable."},
+	"弹力带弯举": {Chinese: "踩稳弹力带并检查有无破损；上臂保持固定，回程控制速度。", English: "Stand securely on the band and check it for damage; keep the upper arms fixed and control the return."},
+
+	"窄距卧推":    {Chinese: "握距约与肩同宽，不宜过窄；肘部贴近身体下放，手腕或肘部不适时减轻重量。", English: "Grip about shoulder-width, not narrower; lower with the elbows close to the body and reduce the load if the wrists or elbows hurt."},
+	"哑铃颈后臂屈伸": {Chinese: "核心收紧避免腰部后仰，上臂保持靠近头部；下放幅度以肘部和肩部无不适为限。", English: "Brace the core to avoid arching, keep the upper arms close to the head; lower only as far as the elbows and shoulders stay comfortable."},
+	"绳索下压":    {Chinese: "上臂固定在身体两侧，只活动肘关节；身体不要前倾压重量。", English: "Pin the upper arms to the sides and move only at the elbows; do not lean over the weight."},
+	"钻石俯卧撑":   {Chinese: "双手在胸下形成菱形，身体保持一条直线；手腕或肘部不适时将双手分开或改为跪姿。", English: "Form a diamond under the chest and keep the body straight; widen the hands or drop to the knees if the wrists or elbows hurt."},
+	"弹力带下压":   {Chinese: "固定点高于头部并检查牢固；上臂贴紧身体，回程保持张力。", English: "Anchor the band above head height and check it is secure; keep the upper arms at the sides and keep tension on the return."},
+	"悬垂举腿":    {Chinese: "肩部保持主动发力不要完全放松悬挂；用腹部卷起骨盆抬腿，避免身体摆动。", English: "Keep the shoulders active instead of hanging loosely; curl the pelvis up with the abs and avoid swinging."},
+	"绳索卷腹":    {Chinese: "髋部保持固定，用腹部卷曲脊柱向下；不要用手臂拉动重量。", English: "Keep the hips still and curl the spine down with the abs; do not pull the weight with the arms."},
+	"俄罗斯转体":   {Chinese: "背部挺直，转动来自胸椎而非腰部；腰部不适时双脚着地并去掉负重。", English: "Keep the back straight and rotate from the upper back rather than the lower back; keep the feet down and drop the weight if the lower back hurts."},
+	"平板支撑":    {Chinese: "肘部位于肩部正下方，收紧腹部和臀部；塌腰或臀部抬高时结束本组。", English: "Keep the elbows under the shoulders and brace the abs and glutes; end the set when the hips sag or rise."},
+	"卷腹":      {Chinese: "双手轻扶头部不要拉扯颈部；只需将肩胛骨卷离地面，下背部保持贴地。", English: "Support the head lightly without pulling the neck; lift only the shoulder blades off the floor and keep the lower back down."},
+	"死虫":      {Chinese: "下背部全程贴紧地面，对侧手脚缓慢伸展；腰部离地时减小伸展幅度。", English: "Keep the lower back pressed to the floor and extend the opposite arm and leg slowly; shorten the reach if the back lifts."},
+}
+
+// librarySafetyNote returns the curated safety note of the library exercise
+// a plan's exercise name refers to
+func librarySafetyNote(name string) (ExerciseSafetyNote, bool) {
+	ex := findLibraryExercise(name)
+	if ex == nil {
+		return ExerciseSafetyNote{}, false
+	}
+	note, ok := exerciseSafetyNotes[ex.Name]
+	return note, ok
+}
+
+// overlaySafetyNotes replaces the safety notes of the plan's exercises that
+// the library has curated notes for, adding the English version under
+// safety_notes_en. It returns the number of exercises changed.
+func overlaySafetyNotes(planData model.JSONMap) int {
+	overlaid := 0
+	weeks, _ := planData["weeks"].([]interface{})
+	for _, weekRaw := range weeks {
+		week, _ := weekRaw.(map[string]interface{})
+		days, _ := week["days"].([]interface{})
+		for _, dayRaw := range days {
+			day, _ := dayRaw.(map[string]interface{})
+			exercises, _ := day["exercises"].([]interface{})
+			for _, exerciseRaw := range exercises {
+				if exercise, ok := exerciseRaw.(map[string]interface{}); ok && overlaySafetyNote(exercise) {
+					overlaid++
+				}
+			}
+		}
+	}
+	return overlaid
+}
+
+// overlaySafetyNote sets the curated safety notes on one exercise entry and
+// reports whether the library had any
+func overlaySafetyNote(exercise map[string]interface{}) bool {
+	name, _ := exercise["name"].(string)
+	note, ok := librarySafetyNote(name)
+	if !ok {
+		return false
+	}
+	exercise["safety_notes"] = note.Chinese
+	exercise["safety_notes_en"] = note.English
+	return true
+}
+
+// safetyNotesPromptSection lists the exercises whose safety notes come from
+// the library, so the AI can leave them out instead of writing its own
+func safetyNotesPromptSection() string {
+	names := make([]string, 0, len(exerciseSafetyNotes))
+	for _, ex := range exerciseLibrary {
+		if _, ok := exerciseSafetyNotes[ex.Name]; ok {
+			names = append(names, ex.Name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return "\nCurated Safety Notes (vetted notes are added to these exercises after generation; use these names and leave their safety_notes empty):\n" +
+		strings.Join(names, "、") + "\n"
+}
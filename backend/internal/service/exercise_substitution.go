@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Where a substitute exercise came from
+const (
+	SubstitutionSourceLibrary = "library"
+	SubstitutionSourceAI      = "ai"
+)
+
+// SubstituteExerciseRequest says what the substitute for an exercise must
+// avoid. Equipment and joints may be codes, names or aliases of the
+// exercise library's terms. Notes can only be taken into account by the AI,
+// so a request with notes skips the library.
+type SubstituteExerciseRequest struct {
+	UnavailableEquipment []string
+	AvoidJoints          []string
+	Notes                string
+	AIAPIID              *int64 // Optional, uses default if not provided
+}
+
+// ExerciseSubstitution is an exercise of a plan day and the substitute
+// saved in its place
+type ExerciseSubstitution struct {
+	PlanID   int64
+	Date     time.Time
+	Index    int
+	Original map[string]interface{}
+	Exercise map[string]interface{}
+	Source   string
+}
+
+// SubstituteExercise replaces the exercise at index (0-based) of date's day
+// in one of the user's plans. The exercise library suggests an exercise for
+// the same muscle group, preferably with the same equipment; when it has
+// none the AI is asked. The substitute keeps the original's name under
+// substituted_for, so repeated substitutions still point at the planned
+// exercise.
+func (s *trainingService) SubstituteExercise(ctx context.Context, userID, planID int64, date time.Time, index int, req *SubstituteExerciseRequest) (*ExerciseSubstitution, error) {
+	plan, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	date = dayStart(date)
+	day := planDayOn(plan, date)
+	if day == nil {
+		return nil, errors.New(errors.ErrNotFound, "计划中没有该日期的训练安排")
+	}
+	exercises, _ := day["exercises"].([]interface{})
+	if index < 0 || index >= len(exercises) {
+		return nil, errors.New(errors.ErrNotFound, "该训练日没有这个动作")
+	}
+	original, ok := exercises[index].(map[string]interface{})
+	if !ok {
+		return nil, errors.New(errors.ErrNotFound, "该训练日没有这个动作")
+	}
+
+	unavailable, err := normalizeExerciseTerms(req.UnavailableEquipment, equipmentTerms, "器材")
+	if err != nil {
+		return nil, err
+	}
+	avoidJoints, err := normalizeExerciseTerms(req.AvoidJoints, jointTerms, "关节")
+	if err != nil {
+		return nil, err
+	}
+
+	constraints, err := s.constraintRepo.ListByUser(ctx, userID, &date)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取训练限制失败")
+	}
+	constraints = constraintsForPlan(constraints, date, date)
+
+	name, _ := original["name"].(string)
+	var exercise map[string]interface{}
+	source := SubstitutionSourceLibrary
+	if req.Notes == "" {
+		exercise = librarySubstitution(original, exercises, index, unavailable, avoidJoints, constraints)
+	}
+
+	if exercise == nil {
+		source = SubstitutionSourceAI
+		aiAPIID, err := resolveAIAPIID(ctx, s.aiAPIRepo, userID, req.AIAPIID)
+		if err != nil {
+			if err == errors.ErrNoDefaultAIAPI && req.Notes == "" {
+				return nil, errors.New(errors.ErrAiApiNotConfigured, "动作库中没有合适的替代动作，设置默认AI API后可由AI推荐")
+			}
+			return nil, err
+		}
+
+		suggested, err := s.aiService.SubstituteExercise(ctx, &ExerciseSubstitutionParams{
+			UserID:               userID,
+			AIAPIID:              aiAPIID,
+			Plan:                 plan,
+			Date:                 date,
+			CurrentDay:           day,
+			Exercise:             original,
+			UnavailableEquipment: unavailable,
+			AvoidJoints:          avoidJoints,
+			Notes:                req.Notes,
+			Constraints:          constraints,
+		})
+		if err != nil {
+			if appErr, ok := err.(*errors.AppError); ok {
+				return nil, appErr
+			}
+			logger.Error("Exercise substitution failed",
+				zap.Int64("plan_id", plan.ID),
+				zap.String("date", date.Format("2006-01-02")),
+				zap.Int("index", index),
+				zap.Error(err),
+			)
+			return nil, errors.Wrap(err, errors.ErrExternalService, "AI推荐替代动作失败，请稍后重试")
+		}
+		exercise = map[string]interface{}(suggested)
+		overlaySafetyNote(exercise)
+	}
+
+	// The substitute takes the original's place in its superset
+	if superset, ok := original["superset_id"].(string); ok && superset != "" {
+		exercise["superset_id"] = superset
+	} else {
+		delete(exercise, "superset_id")
+	}
+	if tempo, _ := exercise["tempo"].(string); tempo == "" {
+		delete(exercise, "tempo")
+	}
+	if planned, ok := original["substituted_for"].(string); ok && planned != "" {
+		exercise["substituted_for"] = planned
+	} else {
+		exercise["substituted_for"] = name
+	}
+	exercises[index] = exercise
+	day["exercises"] = exercises
+	if err := s.planRepo.Update(ctx, plan); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "保存训练计划失败")
+	}
+
+	return &ExerciseSubstitution{
+		PlanID:   plan.ID,
+		Date:     date,
+		Index:    index,
+		Original: original,
+		Exercise: exercise,
+		Source:   source,
+	}, nil
+}
+
+// librarySubstitution returns the plan entry of a library substitute for
+// the exercise at index of a day's exercises, or nil when the exercise is
+// not in the library or the library has no suitable substitute
+func librarySubstitution(original map[string]interface{}, exercises []interface{}, index int, unavailable, avoidJoints []string, constraints []*model.TrainingConstraint) map[string]interface{} {
+	name, _ := original["name"].(string)
+	from := findLibraryExercise(name)
+	if from == nil {
+		return nil
+	}
+
+	filter := exerciseFilter{
+		unavailableEquipment: unavailable,
+		avoidJoints:          avoidJoints,
+	}
+	for _, c := range constraints {
+		for _, m := range c.Movements() {
+			filter.restrictedMovements = append(filter.restrictedMovements, strings.ToLower(m))
+		}
+	}
+	for i, raw := range exercises {
+		if other, ok := raw.(map[string]interface{}); ok && i != index {
+			otherName, _ := other["name"].(string)
+			filter.exclude = append(filter.exclude, otherName)
+		}
+	}
+
+	to := librarySubstitute(from, filter)
+	if to == nil {
+		return nil
+	}
+
+	// The volume carries over; a weight only means something with the same
+	// equipment
+	entry := make(map[string]interface{}, len(original))
+	for k, v := range original {
+		entry[k] = v
+	}
+	entry["name"] = to.Name
+	if !overlaySafetyNote(entry) {
+		entry["safety_notes"] = ""
+		delete(entry, "safety_notes_en")
+	}
+	switch {
+	case to.Equipment == "bodyweight":
+		entry["weight"] = "自重"
+	case to.Equipment != from.Equipment:
+		entry["weight"] = ""
+	}
+	return entry
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/pkg/crypto"
+	"github.com/ai-fitness-planner/backend/internal/pkg/jobqueue"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+)
+
+// Export kinds
+const (
+	ExportKindTrainingRecords  = "training-records"
+	ExportKindNutritionRecords = "nutrition-records"
+)
+
+// ExportRequest holds parameters for an export. A non-empty Passphrase opts
+// the export into encryption; it is never stored.
+type ExportRequest struct {
+	Kind       string
+	StartDate  *time.Time
+	EndDate    *time.Time
+	Passphrase string
+}
+
+// ExportOutcome is either an inline file or the ID of a queued export job
+type ExportOutcome struct {
+	File   *jobqueue.Result
+	TaskID string
+}
+
+// ExportService defines the interface for data export operations
+type ExportService interface {
+	// Export returns the file inline for small datasets and queues a job otherwise
+	Export(ctx context.Context, userID int64, req *ExportRequest) (*ExportOutcome, error)
+	// GetExportTask retrieves a queued export owned by the user
+	GetExportTask(ctx context.Context, userID int64, taskID string) (*jobqueue.Job, error)
+}
+
+// exportService implements ExportService interface
+type exportService struct {
+	trainingRecordRepo  repository.TrainingRecordRepository
+	nutritionRecordRepo repository.NutritionRecordRepository
+	queue               *jobqueue.Queue
+	syncRowLimit        int64
+}
+
+// NewExportService creates a new instance of ExportService
+func NewExportService(
+	trainingRecordRepo repository.TrainingRecordRepository,
+	nutritionRecordRepo repository.NutritionRecordRepository,
+	queue *jobqueue.Queue,
+	syncRowLimit int64,
+) ExportService {
+	return &exportService{
+		trainingRecordRepo:  trainingRecordRepo,
+		nutritionRecordRepo: nutritionRecordRepo,
+		queue:               queue,
+		syncRowLimit:        syncRowLimit,
+	}
+}
+
+// Export builds the export inline when the row count is within the sync limit,
+// otherwise submits it to the low-priority export workers
+func (s *exportService) Export(ctx context.Context, userID int64, req *ExportRequest) (*ExportOutcome, error) {
+	var count int64
+	var err error
+	switch req.Kind {
+	case ExportKindTrainingRecords:
+		count, err = s.trainingRecordRepo.CountByUser(ctx, userID, req.StartDate, req.EndDate)
+	case ExportKindNutritionRecords:
+		count, err = s.nutritionRecordRepo.CountByUser(ctx, userID, req.StartDate, req.EndDate)
+	default:
+		return nil, errors.New(errors.ErrInvalidParam, "不支持的导出类型")
+	}
+	if req.Passphrase != "" && len(req.Passphrase) < crypto.MinPassphraseLength {
+		return nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("加密口令至少%d个字符", crypto.MinPassphraseLength))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "统计导出数据失败")
+	}
+
+	if count <= s.syncRowLimit {
+		file, err := s.build(ctx, userID, req)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrInternalServer, "生成导出文件失败")
+		}
+		return &ExportOutcome{File: file}, nil
+	}
+
+	taskID, err := s.queue.Submit(jobqueue.ClassLow, userID, req.Kind, func(jobCtx context.Context) (*jobqueue.Result, error) {
+		return s.build(jobCtx, userID, req)
+	})
+	if err != nil {
+		if err == jobqueue.ErrQueueFull {
+			return nil, errors.New(errors.ErrServiceUnavailable, "导出任务繁忙，请稍后重试")
+		}
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "创建导出任务失败")
+	}
+
+	return &ExportOutcome{TaskID: taskID}, nil
+}
+
+// GetExportTask retrieves a queued export owned by the user
+func (s *exportService) GetExportTask(ctx context.Context, userID int64, taskID string) (*jobqueue.Job, error) {
+	job, ok := s.queue.Get(taskID)
+	if !ok || job.OwnerID != userID {
+		return nil, errors.New(errors.ErrNotFound, "导出任务不存在")
+	}
+	return job, nil
+}
+
+// build renders the requested export as CSV, encrypted with the request's
+// passphrase when one is given
+func (s *exportService) build(ctx context.Context, userID int64, req *ExportRequest) (*jobqueue.Result, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	switch req.Kind {
+	case ExportKindTrainingRecords:
+		records, err := s.trainingRecordRepo.ListByUser(ctx, userID, req.StartDate, req.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		w.Write([]string{"id", "workout_date", "workout_type", "duration_minutes", "rating", "notes", "exercises"})
+		for _, r := range records {
+			exercises, _ := json.Marshal(r.Exercises)
+			w.Write([]string{
+				strconv.FormatInt(r.ID, 10),
+				r.WorkoutDate.Format("2006-01-02"),
+				r.WorkoutType,
+				optionalInt(r.DurationMinutes),
+				optionalInt(r.Rating),
+				optionalString(r.Notes),
+				string(exercises),
+			})
+		}
+	case ExportKindNutritionRecords:
+		records, err := s.nutritionRecordRepo.ListByUser(ctx, userID, req.StartDate, req.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		w.Write([]string{"id", "meal_date", "meal_time", "calories", "protein", "carbs", "fat", "fiber", "foods"})
+		for _, r := range records {
+			foods, _ := json.Marshal(r.Foods)
+			w.Write([]string{
+				strconv.FormatInt(r.ID, 10),
+				r.MealDate.Format("2006-01-02"),
+				r.MealTime,
+				strconv.FormatFloat(r.Calories, 'f', 2, 64),
+				strconv.FormatFloat(r.Protein, 'f', 2, 64),
+				strconv.FormatFloat(r.Carbs, 'f', 2, 64),
+				strconv.FormatFloat(r.Fat, 'f', 2, 64),
+				strconv.FormatFloat(r.Fiber, 'f', 2, 64),
+				string(foods),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported export kind: %s", req.Kind)
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	result := &jobqueue.Result{
+		Filename:    fmt.Sprintf("%s-%s.csv", req.Kind, time.Now().Format("20060102")),
+		ContentType: "text/csv; charset=utf-8",
+		Data:        buf.Bytes(),
+	}
+	if req.Passphrase != "" {
+		encrypted, err := crypto.EncryptWithPassphrase(result.Data, req.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		result.Filename += crypto.PassphraseFileExt
+		result.ContentType = "application/octet-stream"
+		result.Data = encrypted
+	}
+	return result, nil
+}
+
+func optionalInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func optionalString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Kinds of plan a generation lock covers. Training and nutrition tasks are
+// polled on different endpoints, so each kind has its own lock.
+const (
+	generationLockTraining  = "training"
+	generationLockNutrition = "nutrition"
+)
+
+// generationLockTimeout bounds releasing a lock once its task has finished
+const generationLockTimeout = 5 * time.Second
+
+// releaseGenerationLock deletes the lock only while it is still held by the
+// task releasing it
+var releaseGenerationLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// GenerationLock allows each user one pending generation of each kind, so
+// repeated requests do not start parallel AI jobs
+type GenerationLock interface {
+	// Acquire takes the user's lock for taskID and returns taskID, or
+	// returns the ID of the task already holding it
+	Acquire(ctx context.Context, kind string, userID int64, taskID string) (string, error)
+	// Release frees the user's lock if taskID holds it
+	Release(ctx context.Context, kind string, userID int64, taskID string)
+}
+
+// redisGenerationLock implements GenerationLock using Redis
+type redisGenerationLock struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewGenerationLock creates a Redis-backed GenerationLock. A lock expires
+// after ttl in case its task is lost; it returns nil, which disables the
+// lock, when ttl is 0 or less.
+func NewGenerationLock(client *redis.Client, ttl time.Duration) GenerationLock {
+	if client == nil || ttl <= 0 {
+		return nil
+	}
+	return &redisGenerationLock{client: client, ttl: ttl}
+}
+
+// generationLockKey is the Redis key of a user's lock
+func generationLockKey(kind string, userID int64) string {
+	return fmt.Sprintf("generation:lock:%s:%d", kind, userID)
+}
+
+// Acquire sets the lock with SETNX. When it is taken, the holder is read
+// back; should the lock expire in between, taking it is tried once more.
+func (l *redisGenerationLock) Acquire(ctx context.Context, kind string, userID int64, taskID string) (string, error) {
+	key := generationLockKey(kind, userID)
+	for attempt := 0; attempt < 2; attempt++ {
+		ok, err := l.client.SetNX(ctx, key, taskID, l.ttl).Result()
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return taskID, nil
+		}
+		holder, err := l.client.Get(ctx, key).Result()
+		if err == nil {
+			return holder, nil
+		}
+		if err != redis.Nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("generation lock %s kept changing hands", key)
+}
+
+// Release deletes the lock held by taskID. Failures are logged; the lock
+// then expires on its own.
+func (l *redisGenerationLock) Release(ctx context.Context, kind string, userID int64, taskID string) {
+	key := generationLockKey(kind, userID)
+	if err := releaseGenerationLock.Run(ctx, l.client, []string{key}, taskID).Err(); err != nil {
+		logger.Warn("Failed to release generation lock",
+			zap.String("key", key),
+			zap.String("task_id", taskID),
+			zap.Error(err),
+		)
+	}
+}
+
+// releaseFinishedTask frees the user's lock once their task has finished
+func releaseFinishedTask(lock GenerationLock, kind string, userID int64, taskID string) {
+	if lock == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), generationLockTimeout)
+	defer cancel()
+	lock.Release(ctx, kind, userID, taskID)
+}
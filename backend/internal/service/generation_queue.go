@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	apperrors "github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/pkg/taskqueue"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Task types of the plan generations run on the task queue
+const (
+	taskTypeGenerateTrainingPlan  = "training:generate"
+	taskTypeAdjustTrainingPlan    = "training:adjust"
+	taskTypeGenerateNutritionPlan = "nutrition:generate"
+	taskTypeAdjustNutritionPlan   = "nutrition:adjust"
+)
+
+// queuedTask is a generation task as it was queued, kept with the task's
+// status so that the task can be queued again once it has failed
+type queuedTask struct {
+	taskType string
+	payload  json.RawMessage
+}
+
+// queuedFrom returns how a task claimed from the queue was queued
+func queuedFrom(task *taskqueue.Task) *queuedTask {
+	return &queuedTask{taskType: task.Type, payload: task.Payload}
+}
+
+// taskHistoryTimeout bounds writing a finished task to the history table
+const taskHistoryTimeout = 5 * time.Second
+
+// taskHistoryLimit is how many finished tasks a user's history lists
+const taskHistoryLimit = 10
+
+// Task types listed in each service's task history
+var (
+	trainingTaskTypes  = []string{taskTypeGenerateTrainingPlan, taskTypeAdjustTrainingPlan}
+	nutritionTaskTypes = []string{taskTypeGenerateNutritionPlan, taskTypeAdjustNutritionPlan}
+)
+
+// newTaskHistory builds the history record of a task that reached status
+// at finishedAt, or returns nil while the task may still change
+func newTaskHistory(taskID string, userID int64, queued *queuedTask, status, errMsg string, planID *int64, createdAt, finishedAt time.Time) *model.GenerationTask {
+	if status != TaskStatusCompleted && status != TaskStatusFailed {
+		return nil
+	}
+
+	record := &model.GenerationTask{
+		TaskID:     taskID,
+		UserID:     userID,
+		Status:     status,
+		PlanID:     planID,
+		DurationMs: finishedAt.Sub(createdAt).Milliseconds(),
+		CreatedAt:  createdAt,
+		FinishedAt: finishedAt,
+	}
+	if queued != nil {
+		record.TaskType = queued.taskType
+		var params model.JSONMap
+		if err := json.Unmarshal(queued.payload, &params); err == nil {
+			record.Params = params
+		}
+	}
+	if errMsg != "" {
+		record.Error = &errMsg
+	}
+	return record
+}
+
+// saveTaskHistory writes a finished task to the history table. Failures
+// are logged; the history is for support and must not fail the task.
+func saveTaskHistory(repo repository.GenerationTaskRepository, record *model.GenerationTask) {
+	ctx, cancel := context.WithTimeout(context.Background(), taskHistoryTimeout)
+	defer cancel()
+
+	if err := repo.Save(ctx, record); err != nil {
+		logger.Warn("Failed to save generation task history",
+			zap.String("task_id", record.TaskID),
+			zap.Int64("user_id", record.UserID),
+			zap.Error(err),
+		)
+	}
+}
+
+// queuedTaskHeader holds the fields every generation payload carries
+type queuedTaskHeader struct {
+	TaskID  string `json:"task_id"`
+	UserID  int64  `json:"user_id"`
+	AIAPIID int64  `json:"ai_api_id"`
+}
+
+// decodeTaskHeader reads the common fields of a queued generation task. The
+// queue ID stands in for a payload without a task ID.
+func decodeTaskHeader(task *taskqueue.Task) queuedTaskHeader {
+	var header queuedTaskHeader
+	_ = task.Decode(&header)
+	if header.TaskID == "" {
+		header.TaskID = task.ID
+	}
+	return header
+}
+
+// generateTrainingTask is the queued payload of a training plan generation
+type generateTrainingTask struct {
+	TaskID  string               `json:"task_id"`
+	UserID  int64                `json:"user_id"`
+	AIAPIID int64                `json:"ai_api_id"`
+	Request *GeneratePlanRequest `json:"request"`
+	Block   *MacrocycleBlock     `json:"block,omitempty"`
+}
+
+// adjustTrainingTask is the queued payload of a training plan adjustment.
+// The plan is loaded again when the task runs.
+type adjustTrainingTask struct {
+	TaskID  string             `json:"task_id"`
+	UserID  int64              `json:"user_id"`
+	AIAPIID int64              `json:"ai_api_id"`
+	PlanID  int64              `json:"plan_id"`
+	Request *AdjustPlanRequest `json:"request"`
+}
+
+// generateNutritionTask is the queued payload of a nutrition plan generation
+type generateNutritionTask struct {
+	TaskID  string                        `json:"task_id"`
+	UserID  int64                         `json:"user_id"`
+	AIAPIID int64                         `json:"ai_api_id"`
+	Request *GenerateNutritionPlanRequest `json:"request"`
+}
+
+// adjustNutritionTask is the queued payload of a nutrition plan adjustment
+type adjustNutritionTask struct {
+	TaskID  string                      `json:"task_id"`
+	UserID  int64                       `json:"user_id"`
+	AIAPIID int64                       `json:"ai_api_id"`
+	PlanID  int64                       `json:"plan_id"`
+	Request *AdjustNutritionPlanRequest `json:"request"`
+}
+
+// attemptOutcome decides what a failed generation attempt means for its
+// task. It returns the error to hand back to the queue and, unless the
+// attempt was interrupted, the status to show: failed once no retry is
+// left, otherwise pending with a note that generation will be retried.
+func attemptOutcome(ctx context.Context, task *taskqueue.Task, err error) (status, message string, queueErr error) {
+	if ctx.Err() != nil {
+		// Interrupted by shutdown; the queue runs the task again
+		return "", "", err
+	}
+	if task.FinalAttempt() || isPermanentGenerationError(err) {
+		return TaskStatusFailed, "", fmt.Errorf("%w: %w", err, taskqueue.ErrSkipRetry)
+	}
+	return TaskStatusPending, fmt.Sprintf("生成失败，稍后自动重试（第%d/%d次）", task.Attempt+1, task.MaxAttempts), err
+}
+
+// isPermanentGenerationError reports whether a failed generation would fail
+// the same way if retried, such as when the provider rejected the API key or
+// the plan being adjusted was deleted
+func isPermanentGenerationError(err error) bool {
+	var aiErr *AIError
+	if errors.As(err, &aiErr) {
+		return !aiErr.Retryable
+	}
+	// Only server-side application errors (5xxx) may clear up on their own
+	var appErr *apperrors.AppError
+	return errors.As(err, &appErr) && appErr.Code/1000 != apperrors.ErrInternalServer/1000
+}
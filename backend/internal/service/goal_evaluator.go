@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Tolerances used when a goal's target equals its starting value, i.e. the
+// goal is to hold steady rather than move in a direction
+const (
+	goalWeightTolerance  = 0.5 // kg
+	goalBodyFatTolerance = 0.5 // percentage points
+)
+
+// GoalEvaluator completes body composition goals from body data history
+type GoalEvaluator interface {
+	// EvaluateActiveGoals completes every active goal whose target has been
+	// met and sustained, and returns how many goals were completed
+	EvaluateActiveGoals(ctx context.Context) (int, error)
+}
+
+// goalEvaluator implements GoalEvaluator interface
+type goalEvaluator struct {
+	fitnessGoalRepo  repository.FitnessGoalRepository
+	bodyDataRepo     repository.BodyDataRepository
+	notificationRepo repository.NotificationRepository
+	sustainDays      int
+}
+
+// NewGoalEvaluator creates a new instance of GoalEvaluator.
+// A goal completes once the target has held for sustainDays of measurements.
+func NewGoalEvaluator(
+	fitnessGoalRepo repository.FitnessGoalRepository,
+	bodyDataRepo repository.BodyDataRepository,
+	notificationRepo repository.NotificationRepository,
+	sustainDays int,
+) GoalEvaluator {
+	if sustainDays < 0 {
+		sustainDays = 0
+	}
+	return &goalEvaluator{
+		fitnessGoalRepo:  fitnessGoalRepo,
+		bodyDataRepo:     bodyDataRepo,
+		notificationRepo: notificationRepo,
+		sustainDays:      sustainDays,
+	}
+}
+
+// EvaluateActiveGoals checks each active goal with a weight or body fat target.
+// Maintenance goals are open-ended and never auto-complete.
+func (e *goalEvaluator) EvaluateActiveGoals(ctx context.Context) (int, error) {
+	goals, err := e.fitnessGoalRepo.ListActiveWithTargets(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	completed := 0
+	for _, goal := range goals {
+		if ctx.Err() != nil {
+			return completed, ctx.Err()
+		}
+		if goal.GoalType == model.GoalTypeMaintenance {
+			continue
+		}
+
+		ok, err := e.evaluateGoal(ctx, goal)
+		if err != nil {
+			logger.Error("Failed to evaluate fitness goal",
+				zap.Int64("goal_id", goal.ID),
+				zap.Int64("user_id", goal.UserID),
+				zap.Error(err),
+			)
+			continue
+		}
+		if ok {
+			completed++
+		}
+	}
+
+	return completed, nil
+}
+
+// evaluateGoal completes a single goal if its history qualifies
+func (e *goalEvaluator) evaluateGoal(ctx context.Context, goal *model.FitnessGoal) (bool, error) {
+	since := time.Date(goal.CreatedAt.Year(), goal.CreatedAt.Month(), goal.CreatedAt.Day(), 0, 0, 0, 0, goal.CreatedAt.Location())
+	history, err := e.bodyDataRepo.GetByUserIDSince(ctx, goal.UserID, since)
+	if err != nil {
+		return false, err
+	}
+
+	latest, ok := sustainedTarget(goal, history, e.sustainDays)
+	if !ok {
+		return false, nil
+	}
+
+	now := time.Now()
+	updated, err := e.fitnessGoalRepo.MarkCompleted(ctx, goal.ID, now)
+	if err != nil {
+		return false, err
+	}
+	if !updated {
+		// Completed or cancelled concurrently; the other writer owns the notification
+		return false, nil
+	}
+	publishGoalAchieved(ctx, goal)
+
+	if err := e.notificationRepo.Create(ctx, goalAchievedNotification(goal, latest, e.sustainDays, now)); err != nil {
+		logger.Error("Failed to create goal achieved notification",
+			zap.Int64("goal_id", goal.ID),
+			zap.Error(err),
+		)
+	}
+
+	logger.Info("Fitness goal completed",
+		zap.Int64("goal_id", goal.ID),
+		zap.Int64("user_id", goal.UserID),
+	)
+	return true, nil
+}
+
+// sustainedTarget reports whether the trailing run of measurements that meet
+// every target of the goal spans at least sustainDays, and returns the latest
+// of those measurements. history must be ordered oldest first.
+func sustainedTarget(goal *model.FitnessGoal, history []*model.UserBodyData, sustainDays int) (*model.UserBodyData, bool) {
+	if goal.TargetWeight == nil && goal.TargetBodyFat == nil {
+		return nil, false
+	}
+
+	// Only measurements that include every targeted metric can be judged
+	relevant := make([]*model.UserBodyData, 0, len(history))
+	for _, bd := range history {
+		if goal.TargetBodyFat != nil && bd.BodyFatPercentage == nil {
+			continue
+		}
+		relevant = append(relevant, bd)
+	}
+	if len(relevant) == 0 {
+		return nil, false
+	}
+
+	// Fall back to the first measurement when the goal was set without a baseline
+	initialWeight := goal.InitialWeight
+	if initialWeight == nil {
+		initialWeight = &relevant[0].Weight
+	}
+	initialBodyFat := goal.InitialBodyFat
+	if initialBodyFat == nil {
+		initialBodyFat = relevant[0].BodyFatPercentage
+	}
+
+	meets := func(bd *model.UserBodyData) bool {
+		if goal.TargetWeight != nil && !reachedTarget(*initialWeight, *goal.TargetWeight, bd.Weight, goalWeightTolerance) {
+			return false
+		}
+		if goal.TargetBodyFat != nil && !reachedTarget(*initialBodyFat, *goal.TargetBodyFat, *bd.BodyFatPercentage, goalBodyFatTolerance) {
+			return false
+		}
+		return true
+	}
+
+	latest := relevant[len(relevant)-1]
+	if !meets(latest) {
+		return nil, false
+	}
+
+	streakStart := latest.MeasurementDate
+	for i := len(relevant) - 2; i >= 0 && meets(relevant[i]); i-- {
+		streakStart = relevant[i].MeasurementDate
+	}
+
+	sustained := latest.MeasurementDate.Sub(streakStart) >= time.Duration(sustainDays)*24*time.Hour
+	return latest, sustained
+}
+
+// reachedTarget reports whether value has reached target moving away from
+// initial; with no direction to move it must stay within tolerance
+func reachedTarget(initial, target, value, tolerance float64) bool {
+	switch {
+	case target < initial:
+		return value <= target
+	case target > initial:
+		return value >= target
+	default:
+		return math.Abs(value-target) <= tolerance
+	}
+}
+
+// goalAchievedNotification builds the notification prompting the user to set
+// a maintenance goal at their current measurements
+func goalAchievedNotification(goal *model.FitnessGoal, latest *model.UserBodyData, sustainDays int, now time.Time) *model.UserNotification {
+	content := fmt.Sprintf("恭喜你达成了目标「%s」，并已保持%d天。建议设置一个维持目标来巩固当前成果。", goal.GoalType, sustainDays)
+
+	suggested := map[string]interface{}{
+		"goal_type":     model.GoalTypeMaintenance,
+		"target_weight": latest.Weight,
+	}
+	if goal.TargetBodyFat != nil && latest.BodyFatPercentage != nil {
+		suggested["target_body_fat"] = *latest.BodyFatPercentage
+	}
+
+	return &model.UserNotification{
+		UserID:  goal.UserID,
+		Type:    model.NotificationTypeGoalAchieved,
+		Title:   "目标达成",
+		Content: &content,
+		Data: model.JSONMap{
+			"goal_id":        goal.ID,
+			"action":         "set_maintenance_goal",
+			"suggested_goal": suggested,
+		},
+		CreatedAt: now,
+	}
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+)
+
+// Integrity checks
+const (
+	IntegrityCheckOrphanedRecord   = "orphaned_training_record"
+	IntegrityCheckInvalidPlanDates = "invalid_plan_dates"
+	IntegrityCheckZeroMacroRecord  = "zero_macro_nutrition_record"
+)
+
+// integrityIssueLimit caps the issues reported per check in one run
+const integrityIssueLimit = 500
+
+// IntegrityIssue is one anomaly found by a consistency check
+type IntegrityIssue struct {
+	Check    string
+	Table    string
+	RecordID int64
+	UserID   int64
+	Detail   string
+	// Repairable is set when the check knows a safe fix; Repaired once applied
+	Repairable  bool
+	Repaired    bool
+	RepairError string
+}
+
+// IntegrityReport is the result of one consistency check run
+type IntegrityReport struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Repair     bool
+	Issues     []IntegrityIssue
+	// Counts holds the number of issues per check
+	Counts   map[string]int
+	Repaired int
+	// Truncated lists checks that stopped at integrityIssueLimit
+	Truncated []string
+}
+
+// IntegrityService detects and optionally repairs inconsistent data
+type IntegrityService interface {
+	// Check runs every consistency check; with repair, safe fixes are applied
+	Check(ctx context.Context, repair bool) (*IntegrityReport, error)
+	// LatestReport returns the report of the last run, or nil before the first
+	LatestReport() *IntegrityReport
+	// RunScheduled is Check for the background job, applying fixes only if
+	// auto-repair is enabled; it returns the number of issues found
+	RunScheduled(ctx context.Context) (int, error)
+}
+
+// integrityService implements IntegrityService interface
+type integrityService struct {
+	integrityRepo repository.IntegrityRepository
+	autoRepair    bool
+
+	mu     sync.Mutex
+	latest *IntegrityReport
+}
+
+// NewIntegrityService creates a new instance of IntegrityService
+func NewIntegrityService(integrityRepo repository.IntegrityRepository, autoRepair bool) IntegrityService {
+	return &integrityService{
+		integrityRepo: integrityRepo,
+		autoRepair:    autoRepair,
+	}
+}
+
+// Check runs the consistency checks and keeps the report as the latest
+func (s *integrityService) Check(ctx context.Context, repair bool) (*IntegrityReport, error) {
+	report := &IntegrityReport{
+		StartedAt: time.Now(),
+		Repair:    repair,
+		Issues:    []IntegrityIssue{},
+		Counts:    map[string]int{},
+	}
+
+	checks := []struct {
+		name string
+		run  func(ctx context.Context, repair bool) ([]IntegrityIssue, error)
+	}{
+		{IntegrityCheckOrphanedRecord, s.checkOrphanedRecords},
+		{IntegrityCheckInvalidPlanDates, s.checkPlanDates},
+		{IntegrityCheckZeroMacroRecord, s.checkZeroMacroRecords},
+	}
+	for _, check := range checks {
+		issues, err := check.run(ctx, repair)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "数据一致性检查失败")
+		}
+
+		report.Counts[check.name] = len(issues)
+		if len(issues) >= integrityIssueLimit {
+			report.Truncated = append(report.Truncated, check.name)
+		}
+		for _, issue := range issues {
+			if issue.Repaired {
+				report.Repaired++
+			}
+		}
+		report.Issues = append(report.Issues, issues...)
+	}
+	report.FinishedAt = time.Now()
+
+	s.mu.Lock()
+	s.latest = report
+	s.mu.Unlock()
+
+	return report, nil
+}
+
+// LatestReport returns the most recent report
+func (s *integrityService) LatestReport() *IntegrityReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latest
+}
+
+// RunScheduled runs the checks for the background job
+func (s *integrityService) RunScheduled(ctx context.Context) (int, error) {
+	report, err := s.Check(ctx, s.autoRepair)
+	if err != nil {
+		return 0, err
+	}
+	return len(report.Issues), nil
+}
+
+// checkOrphanedRecords finds training records pointing at a deleted plan or
+// another user's plan. The fix detaches the record; the workout itself stays.
+func (s *integrityService) checkOrphanedRecords(ctx context.Context, repair bool) ([]IntegrityIssue, error) {
+	records, err := s.integrityRepo.ListOrphanedTrainingRecords(ctx, integrityIssueLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]IntegrityIssue, 0, len(records))
+	for _, record := range records {
+		issue := IntegrityIssue{
+			Check:      IntegrityCheckOrphanedRecord,
+			Table:      model.TrainingRecord{}.TableName(),
+			RecordID:   record.ID,
+			UserID:     record.UserID,
+			Detail:     fmt.Sprintf("训练记录引用的计划%d不存在或不属于该用户", *record.PlanID),
+			Repairable: true,
+		}
+		if repair {
+			applyRepair(&issue, s.integrityRepo.ClearTrainingRecordPlan(ctx, record.ID))
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// checkPlanDates finds plans that end before they start. The end date is
+// recomputed from the plan's length the same way generation sets it.
+func (s *integrityService) checkPlanDates(ctx context.Context, repair bool) ([]IntegrityIssue, error) {
+	trainingPlans, err := s.integrityRepo.ListTrainingPlansWithInvalidDates(ctx, integrityIssueLimit)
+	if err != nil {
+		return nil, err
+	}
+	nutritionPlans, err := s.integrityRepo.ListNutritionPlansWithInvalidDates(ctx, integrityIssueLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]IntegrityIssue, 0, len(trainingPlans)+len(nutritionPlans))
+	for _, plan := range trainingPlans {
+		issue := IntegrityIssue{
+			Check:      IntegrityCheckInvalidPlanDates,
+			Table:      model.TrainingPlan{}.TableName(),
+			RecordID:   plan.ID,
+			UserID:     plan.UserID,
+			Detail:     invalidDatesDetail(plan.StartDate, plan.EndDate),
+			Repairable: plan.TotalWeeks > 0,
+		}
+		if repair && issue.Repairable {
+			endDate := plan.StartDate.AddDate(0, 0, plan.TotalWeeks*7)
+			applyRepair(&issue, s.integrityRepo.SetTrainingPlanEndDate(ctx, plan.ID, endDate))
+		}
+		issues = append(issues, issue)
+	}
+
+	for _, plan := range nutritionPlans {
+		days, _ := plan.PlanData["days"].([]interface{})
+		issue := IntegrityIssue{
+			Check:      IntegrityCheckInvalidPlanDates,
+			Table:      model.NutritionPlan{}.TableName(),
+			RecordID:   plan.ID,
+			UserID:     plan.UserID,
+			Detail:     invalidDatesDetail(plan.StartDate, plan.EndDate),
+			Repairable: len(days) > 0,
+		}
+		if repair && issue.Repairable {
+			endDate := plan.StartDate.AddDate(0, 0, len(days))
+			applyRepair(&issue, s.integrityRepo.SetNutritionPlanEndDate(ctx, plan.ID, endDate))
+		}
+		issues = append(issues, issue)
+	}
+
+	if len(issues) > integrityIssueLimit {
+		issues = issues[:integrityIssueLimit]
+	}
+	return issues, nil
+}
+
+// checkZeroMacroRecords finds nutrition records listing foods but recording
+// no calories or macros. When the foods carry nutrition values the totals are
+// recalculated from them, as RecordMeal does on creation.
+func (s *integrityService) checkZeroMacroRecords(ctx context.Context, repair bool) ([]IntegrityIssue, error) {
+	var issues []IntegrityIssue
+	var afterID int64
+	for len(issues) < integrityIssueLimit {
+		records, err := s.integrityRepo.ListZeroMacroNutritionRecords(ctx, afterID, integrityIssueLimit)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			break
+		}
+		afterID = records[len(records)-1].ID
+
+		for _, record := range records {
+			foods := foodItems(record.Foods)
+			if len(foods) == 0 {
+				continue
+			}
+
+			calories, protein, carbs, fat, fiber := calculateNutritionFromFoods(record.Foods)
+			issue := IntegrityIssue{
+				Check:      IntegrityCheckZeroMacroRecord,
+				Table:      model.NutritionRecord{}.TableName(),
+				RecordID:   record.ID,
+				UserID:     record.UserID,
+				Detail:     fmt.Sprintf("饮食记录包含%d种食物但热量和营养素均为0", len(foods)),
+				Repairable: calories > 0 || protein > 0 || carbs > 0 || fat > 0,
+			}
+			if repair && issue.Repairable {
+				record.Calories, record.Protein, record.Carbs, record.Fat, record.Fiber = calories, protein, carbs, fat, fiber
+				applyRepair(&issue, s.integrityRepo.SetNutritionRecordMacros(ctx, record))
+			}
+			issues = append(issues, issue)
+			if len(issues) >= integrityIssueLimit {
+				break
+			}
+		}
+	}
+	return issues, nil
+}
+
+// applyRepair records the outcome of a fix on its issue. A failed fix is
+// reported rather than aborting the run, so one bad row does not hide the rest.
+func applyRepair(issue *IntegrityIssue, err error) {
+	if err != nil {
+		issue.RepairError = err.Error()
+		return
+	}
+	issue.Repaired = true
+}
+
+func invalidDatesDetail(startDate, endDate time.Time) string {
+	return fmt.Sprintf("结束日期%s早于开始日期%s", endDate.Format("2006-01-02"), startDate.Format("2006-01-02"))
+}
@@ -0,0 +1,437 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+// MacrocycleService defines the interface for long-term periodization
+type MacrocycleService interface {
+	// CreateMacrocycle creates a macrocycle, optionally taking over an
+	// existing plan as its first block
+	CreateMacrocycle(ctx context.Context, userID int64, req *CreateMacrocycleRequest) (*MacrocycleDetail, error)
+	// ListMacrocycles retrieves the user's macrocycles, newest first
+	ListMacrocycles(ctx context.Context, userID int64) ([]*model.Macrocycle, error)
+	// GetMacrocycle retrieves a macrocycle with its blocks
+	GetMacrocycle(ctx context.Context, userID, macrocycleID int64) (*MacrocycleDetail, error)
+	// GenerateNextBlock generates the macrocycle's next block from the
+	// previous block's adherence and PRs asynchronously and returns a task ID
+	GenerateNextBlock(ctx context.Context, userID, macrocycleID int64, req *NextBlockRequest) (*TaskResponse, error)
+}
+
+// CreateMacrocycleRequest holds parameters for creating a macrocycle
+type CreateMacrocycleRequest struct {
+	Name   string   `json:"name" validate:"required,min=1,max=100"`
+	Goal   string   `json:"goal" validate:"required,max=100"`
+	Phases []string `json:"phases" validate:"required,min=1,max=12"`
+	// InitialPlanID is an existing plan to use as block 1
+	InitialPlanID *int64 `json:"initial_plan_id"`
+}
+
+// NextBlockRequest holds parameters for generating a macrocycle's next block
+type NextBlockRequest struct {
+	PlanName        string `json:"plan_name" validate:"omitempty,max=200"` // defaults to the macrocycle name and block
+	DurationWeeks   int    `json:"duration_weeks" validate:"required,min=1,max=52"`
+	DifficultyLevel string `json:"difficulty_level" validate:"required,oneof=easy medium hard extreme"`
+	Phase           string `json:"phase"` // defaults to the next planned phase
+	AIAPIID         *int64 `json:"ai_api_id"`
+}
+
+// MacrocycleDetail is a macrocycle with the current plan of each block.
+// NextPhase is the planned phase of the next block, "" once all planned
+// phases have a block.
+type MacrocycleDetail struct {
+	Macrocycle *model.Macrocycle
+	Blocks     []*MacrocycleBlockSummary
+	NextPhase  string
+}
+
+// MacrocycleBlockSummary is one block of a macrocycle. CompletionRate is
+// the percentage of its workouts up to now that were logged.
+type MacrocycleBlockSummary struct {
+	Plan           *model.TrainingPlan
+	CompletionRate float64
+}
+
+// MacrocycleBlock describes the block a plan is generated as. Previous is
+// nil for the first block.
+type MacrocycleBlock struct {
+	Macrocycle *model.Macrocycle
+	Number     int
+	Phase      string
+	Previous   *PreviousBlock
+}
+
+// PreviousBlock summarises how the block before a new one went
+type PreviousBlock struct {
+	Plan           *model.TrainingPlan
+	CompletionRate float64
+	Lifts          []LiftOutcome
+}
+
+// LiftOutcome is a main lift's result over a block. BestEstimated is the
+// highest 1RM estimated from the block's logged sets, 0 if none. PR is set
+// when the strength profile's 1RM was raised by a record in the block.
+type LiftOutcome struct {
+	Lift             string
+	BestEstimated    float64
+	CurrentOneRepMax float64
+	PR               bool
+	PRDate           time.Time
+}
+
+// validPhases lists the block phases a macrocycle can plan
+var validPhases = map[string]bool{
+	model.PhaseHypertrophy: true,
+	model.PhaseStrength:    true,
+	model.PhasePower:       true,
+	model.PhasePeak:        true,
+	model.PhaseEndurance:   true,
+}
+
+// macrocycleService implements MacrocycleService interface
+type macrocycleService struct {
+	macrocycleRepo  repository.MacrocycleRepository
+	planRepo        repository.TrainingPlanRepository
+	recordRepo      repository.TrainingRecordRepository
+	strengthService StrengthProfileService
+	trainingService TrainingService
+}
+
+// NewMacrocycleService creates a new instance of MacrocycleService
+func NewMacrocycleService(
+	macrocycleRepo repository.MacrocycleRepository,
+	planRepo repository.TrainingPlanRepository,
+	recordRepo repository.TrainingRecordRepository,
+	strengthService StrengthProfileService,
+	trainingService TrainingService,
+) MacrocycleService {
+	return &macrocycleService{
+		macrocycleRepo:  macrocycleRepo,
+		planRepo:        planRepo,
+		recordRepo:      recordRepo,
+		strengthService: strengthService,
+		trainingService: trainingService,
+	}
+}
+
+// CreateMacrocycle creates a macrocycle with the planned phases in order
+func (s *macrocycleService) CreateMacrocycle(ctx context.Context, userID int64, req *CreateMacrocycleRequest) (*MacrocycleDetail, error) {
+	for _, phase := range req.Phases {
+		if !validPhases[phase] {
+			return nil, errors.New(errors.ErrInvalidParam, "无效的训练阶段: "+phase)
+		}
+	}
+
+	var initialPlan *model.TrainingPlan
+	if req.InitialPlanID != nil {
+		plan, err := s.trainingService.GetPlanDetail(ctx, *req.InitialPlanID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if plan.MacrocycleID != nil {
+			return nil, errors.New(errors.ErrConflict, "该训练计划已属于其他宏周期")
+		}
+		initialPlan = plan
+	}
+
+	macrocycle := &model.Macrocycle{
+		UserID: userID,
+		Name:   req.Name,
+		Goal:   req.Goal,
+		Phases: model.JSONSlice(interfaceSlice(req.Phases)),
+	}
+	if err := s.macrocycleRepo.Create(ctx, macrocycle); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "创建宏周期失败")
+	}
+
+	if initialPlan != nil {
+		number := 1
+		phase := req.Phases[0]
+		initialPlan.MacrocycleID = &macrocycle.ID
+		initialPlan.BlockNumber = &number
+		initialPlan.BlockPhase = &phase
+		if err := s.planRepo.Update(ctx, initialPlan); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "关联训练计划失败")
+		}
+	}
+
+	return s.detail(ctx, userID, macrocycle)
+}
+
+// ListMacrocycles retrieves the user's macrocycles
+func (s *macrocycleService) ListMacrocycles(ctx context.Context, userID int64) ([]*model.Macrocycle, error) {
+	macrocycles, err := s.macrocycleRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取宏周期列表失败")
+	}
+	return macrocycles, nil
+}
+
+// GetMacrocycle retrieves one of the user's macrocycles with its blocks
+func (s *macrocycleService) GetMacrocycle(ctx context.Context, userID, macrocycleID int64) (*MacrocycleDetail, error) {
+	macrocycle, err := s.getOwned(ctx, userID, macrocycleID)
+	if err != nil {
+		return nil, err
+	}
+	return s.detail(ctx, userID, macrocycle)
+}
+
+// GenerateNextBlock starts generating the block after the macrocycle's last
+// one. The previous block is marked completed once the new one is saved.
+func (s *macrocycleService) GenerateNextBlock(ctx context.Context, userID, macrocycleID int64, req *NextBlockRequest) (*TaskResponse, error) {
+	macrocycle, err := s.getOwned(ctx, userID, macrocycleID)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := s.planRepo.ListBlocks(ctx, macrocycle.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取宏周期训练块失败")
+	}
+	number := nextBlockNumber(blocks)
+
+	phase := req.Phase
+	if phase == "" {
+		phase = plannedPhase(macrocycle, number)
+		if phase == "" {
+			return nil, errors.New(errors.ErrInvalidParam, "宏周期的计划阶段已全部生成，请指定下一训练块的阶段")
+		}
+	} else if !validPhases[phase] {
+		return nil, errors.New(errors.ErrInvalidParam, "无效的训练阶段: "+phase)
+	}
+
+	block := &MacrocycleBlock{Macrocycle: macrocycle, Number: number, Phase: phase}
+	if len(blocks) > 0 {
+		block.Previous, err = s.summarizeBlock(ctx, userID, blocks[len(blocks)-1], time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	planName := req.PlanName
+	if planName == "" {
+		planName = fmt.Sprintf("%s 第%d阶段", macrocycle.Name, number)
+	}
+
+	return s.trainingService.GenerateBlock(ctx, userID, &GeneratePlanRequest{
+		PlanName:        planName,
+		DurationWeeks:   req.DurationWeeks,
+		Goal:            macrocycle.Goal,
+		DifficultyLevel: req.DifficultyLevel,
+		AIAPIID:         req.AIAPIID,
+	}, block)
+}
+
+// getOwned retrieves a macrocycle and checks it belongs to the user
+func (s *macrocycleService) getOwned(ctx context.Context, userID, macrocycleID int64) (*model.Macrocycle, error) {
+	macrocycle, err := s.macrocycleRepo.GetByID(ctx, macrocycleID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取宏周期失败")
+	}
+	if macrocycle == nil {
+		return nil, errors.New(errors.ErrNotFound, "宏周期不存在")
+	}
+	if macrocycle.UserID != userID {
+		return nil, errors.New(errors.ErrForbidden, "无权访问此宏周期")
+	}
+	return macrocycle, nil
+}
+
+// detail loads a macrocycle's blocks and their completion rates
+func (s *macrocycleService) detail(ctx context.Context, userID int64, macrocycle *model.Macrocycle) (*MacrocycleDetail, error) {
+	plans, err := s.planRepo.ListBlocks(ctx, macrocycle.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取宏周期训练块失败")
+	}
+
+	now := time.Now()
+	blocks := make([]*MacrocycleBlockSummary, 0, len(plans))
+	for _, plan := range plans {
+		records, err := s.blockRecords(ctx, userID, plan, now)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, &MacrocycleBlockSummary{
+			Plan:           plan,
+			CompletionRate: trainingCompletionRate(plan, records, now),
+		})
+	}
+
+	return &MacrocycleDetail{
+		Macrocycle: macrocycle,
+		Blocks:     blocks,
+		NextPhase:  plannedPhase(macrocycle, nextBlockNumber(plans)),
+	}, nil
+}
+
+// summarizeBlock measures adherence and lift outcomes over a block
+func (s *macrocycleService) summarizeBlock(ctx context.Context, userID int64, plan *model.TrainingPlan, now time.Time) (*PreviousBlock, error) {
+	records, err := s.blockRecords(ctx, userID, plan, now)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := s.strengthService.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PreviousBlock{
+		Plan:           plan,
+		CompletionRate: trainingCompletionRate(plan, records, now),
+		Lifts:          liftOutcomes(plan, records, profile, now),
+	}, nil
+}
+
+// blockRecords retrieves the training records logged during a block
+func (s *macrocycleService) blockRecords(ctx context.Context, userID int64, plan *model.TrainingPlan, now time.Time) ([]*model.TrainingRecord, error) {
+	start, end := blockWindow(plan, now)
+	records, err := s.recordRepo.ListByUser(ctx, userID, &start, &end)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取训练记录失败")
+	}
+	return records, nil
+}
+
+// GenerateBlock generates a plan as a macrocycle block asynchronously
+func (s *trainingService) GenerateBlock(ctx context.Context, userID int64, req *GeneratePlanRequest, block *MacrocycleBlock) (*TaskResponse, error) {
+	aiAPIID, err := resolveAIAPIID(ctx, s.aiAPIRepo, userID, req.AIAPIID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.enqueueTask(ctx, userID, taskTypeGenerateTrainingPlan, fmt.Sprintf("宏周期第%d训练块生成任务已创建", block.Number), func(taskID string) interface{} {
+		return &generateTrainingTask{TaskID: taskID, UserID: userID, AIAPIID: aiAPIID, Request: req, Block: block}
+	})
+}
+
+// completeBlock marks the block a new one follows as completed. Failing to
+// do so leaves it active but does not fail the generation.
+func (s *trainingService) completeBlock(ctx context.Context, plan *model.TrainingPlan) {
+	if plan.Status != "active" {
+		return
+	}
+	now := time.Now()
+	plan.Status = "completed"
+	plan.CompletedAt = &now
+	if err := s.planRepo.Update(ctx, plan); err != nil {
+		logger.Warn("Failed to complete previous macrocycle block",
+			zap.Int64("plan_id", plan.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// nextBlockNumber returns the number of the block after blocks
+func nextBlockNumber(blocks []*model.TrainingPlan) int {
+	number := 1
+	for _, b := range blocks {
+		if b.BlockNumber != nil && *b.BlockNumber >= number {
+			number = *b.BlockNumber + 1
+		}
+	}
+	return number
+}
+
+// plannedPhase returns the planned phase of block number, or "" past the
+// end of the plan
+func plannedPhase(macrocycle *model.Macrocycle, number int) string {
+	phases := macrocycle.PhaseNames()
+	if number < 1 || number > len(phases) {
+		return ""
+	}
+	return phases[number-1]
+}
+
+// blockWindow returns the dates a block covers so far
+func blockWindow(plan *model.TrainingPlan, now time.Time) (time.Time, time.Time) {
+	end := plan.EndDate
+	if now.Before(end) {
+		end = now
+	}
+	return plan.StartDate, end
+}
+
+// liftOutcomes reports each main lift trained or tested during a block
+func liftOutcomes(plan *model.TrainingPlan, records []*model.TrainingRecord, profile []*model.StrengthProfileEntry, now time.Time) []LiftOutcome {
+	best := make(map[string]float64)
+	for _, r := range records {
+		for lift, estimate := range bestEstimatedOneRepMaxes(r.Exercises) {
+			if estimate > best[lift] {
+				best[lift] = estimate
+			}
+		}
+	}
+
+	current := make(map[string]*model.StrengthProfileEntry, len(profile))
+	for _, entry := range profile {
+		current[entry.Lift] = entry
+	}
+
+	start, end := blockWindow(plan, now)
+	var outcomes []LiftOutcome
+	for _, lift := range model.MainLifts {
+		outcome := LiftOutcome{Lift: lift, BestEstimated: best[lift]}
+		if entry := current[lift]; entry != nil {
+			outcome.CurrentOneRepMax = entry.OneRepMax
+			if entry.Source == model.StrengthSourcePR &&
+				!entry.AchievedAt.Before(dayStart(start)) && !entry.AchievedAt.After(end) {
+				outcome.PR = true
+				outcome.PRDate = entry.AchievedAt
+			}
+		}
+		if outcome.BestEstimated == 0 && outcome.CurrentOneRepMax == 0 {
+			continue
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes
+}
+
+// macrocyclePromptSection renders a block's place in its macrocycle and the
+// previous block's results as a prompt section
+func macrocyclePromptSection(block *MacrocycleBlock) string {
+	m := block.Macrocycle
+	section := fmt.Sprintf("\nPeriodization (macrocycle %q, long-term goal: %s):\n", m.Name, m.Goal)
+	if phases := m.PhaseNames(); len(phases) > 0 {
+		section += "- Planned phases: " + strings.Join(phases, " -> ") + "\n"
+	}
+	section += fmt.Sprintf("- This plan is block %d, a %s phase. Program the whole plan for that phase's training focus.\n", block.Number, block.Phase)
+
+	prev := block.Previous
+	if prev == nil {
+		return section
+	}
+
+	prevPhase := "unspecified"
+	if prev.Plan.BlockPhase != nil {
+		prevPhase = *prev.Plan.BlockPhase
+	}
+	section += fmt.Sprintf("Previous block (%s phase, %d weeks from %s):\n", prevPhase, prev.Plan.TotalWeeks, prev.Plan.StartDate.Format("2006-01-02"))
+	section += fmt.Sprintf("- Adherence: %.1f%% of scheduled workouts logged\n", prev.CompletionRate)
+	for _, l := range prev.Lifts {
+		line := "- " + l.Lift + ": "
+		if l.PR {
+			line += fmt.Sprintf("new PR, 1RM %.1f kg on %s", l.CurrentOneRepMax, l.PRDate.Format("2006-01-02"))
+		} else {
+			line += "no PR this block"
+			if l.CurrentOneRepMax > 0 {
+				line += fmt.Sprintf(", 1RM stays %.1f kg", l.CurrentOneRepMax)
+			}
+		}
+		if l.BestEstimated > 0 {
+			line += fmt.Sprintf(", best estimated 1RM from logged sets %.1f kg", l.BestEstimated)
+		}
+		section += line + "\n"
+	}
+	section += "Build on the previous block: progress the lifts that set PRs, rework the approach for lifts that stalled, and set volume the user can sustain given the adherence above.\n"
+	return section
+}
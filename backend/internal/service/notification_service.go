@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+)
+
+// NotificationService defines the interface for user notification operations
+type NotificationService interface {
+	List(ctx context.Context, userID int64, unreadOnly bool, limit, offset int) ([]*model.UserNotification, int64, error)
+	MarkRead(ctx context.Context, userID, notificationID int64) (*model.UserNotification, error)
+}
+
+// notificationService implements NotificationService interface
+type notificationService struct {
+	notificationRepo repository.NotificationRepository
+}
+
+// NewNotificationService creates a new instance of NotificationService
+func NewNotificationService(notificationRepo repository.NotificationRepository) NotificationService {
+	return &notificationService{notificationRepo: notificationRepo}
+}
+
+// List returns the user's notifications, newest first
+func (s *notificationService) List(ctx context.Context, userID int64, unreadOnly bool, limit, offset int) ([]*model.UserNotification, int64, error) {
+	notifications, total, err := s.notificationRepo.ListByUser(ctx, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, errors.ErrDatabase, "获取通知失败")
+	}
+	return notifications, total, nil
+}
+
+// MarkRead marks one of the user's notifications as read
+func (s *notificationService) MarkRead(ctx context.Context, userID, notificationID int64) (*model.UserNotification, error) {
+	notification, err := s.notificationRepo.GetByID(ctx, notificationID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取通知失败")
+	}
+	if notification == nil || notification.UserID != userID {
+		return nil, errors.New(errors.ErrNotFound, "通知不存在")
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if err := s.notificationRepo.MarkRead(ctx, notification.ID, now); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "更新通知失败")
+		}
+		notification.ReadAt = &now
+	}
+
+	return notification, nil
+}
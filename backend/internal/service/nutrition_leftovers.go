@@ -0,0 +1,99 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// withLeftoverLunches wraps a nutrition plan parser to also reject plans
+// that do not serve each dinner again at the next day's lunch, so the
+// response is retried like one that does not parse
+func withLeftoverLunches(parse func(string) (model.JSONMap, error)) func(string) (model.JSONMap, error) {
+	return func(response string) (model.JSONMap, error) {
+		planData, err := parse(response)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkLeftoverLunches(planData); err != nil {
+			return nil, err
+		}
+		return planData, nil
+	}
+}
+
+// checkLeftoverLunches verifies that, from the second day on, every food of
+// the previous day's dinner appears in the day's lunch. Names are compared
+// ignoring case and surrounding space; amounts may differ.
+func checkLeftoverLunches(planData model.JSONMap) error {
+	days, _ := planData["days"].([]interface{})
+	for i := 1; i < len(days); i++ {
+		dinner := mealFoodNames(days[i-1], "dinner")
+		lunch := mealFoodNames(days[i], "lunch")
+		for name := range dinner {
+			if !lunch[name] {
+				return parseFailure(ParseFailureConstraint, "day %d lunch does not reuse the previous dinner's %q", i+1, name)
+			}
+		}
+	}
+	return nil
+}
+
+// mealFoodNames returns the normalised food names of a meal of a plan day
+func mealFoodNames(day interface{}, meal string) map[string]bool {
+	names := make(map[string]bool)
+	foods, _ := planDayMeal(day, meal)["foods"].([]interface{})
+	for _, raw := range foods {
+		food, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, ok := food["name"].(string); ok && strings.TrimSpace(name) != "" {
+			names[strings.ToLower(strings.TrimSpace(name))] = true
+		}
+	}
+	return names
+}
+
+// planDayMeal returns a meal of a plan day, or nil when it has none
+func planDayMeal(day interface{}, meal string) map[string]interface{} {
+	dayMap, _ := day.(map[string]interface{})
+	meals, _ := dayMap["meals"].(map[string]interface{})
+	m, _ := meals[meal].(map[string]interface{})
+	return m
+}
+
+// applyLeftoverLunches keeps a regenerated day in a leftover plan's chain:
+// the day's lunch stays the previous day's leftovers, and the next day's
+// lunch becomes the new dinner at that lunch's usual time. days holds the
+// plan's days with the regenerated one at index.
+func applyLeftoverLunches(days []interface{}, index int, original map[string]interface{}) {
+	day, _ := days[index].(map[string]interface{})
+	meals, ok := day["meals"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if index > 0 {
+		if lunch := planDayMeal(original, "lunch"); lunch != nil {
+			meals["lunch"] = lunch
+		}
+	}
+
+	if index+1 < len(days) {
+		dinner := planDayMeal(day, "dinner")
+		next, _ := days[index+1].(map[string]interface{})
+		nextMeals, _ := next["meals"].(map[string]interface{})
+		if dinner == nil || nextMeals == nil {
+			return
+		}
+		lunch := make(map[string]interface{}, len(dinner))
+		for k, v := range dinner {
+			lunch[k] = v
+		}
+		if lunchTime, ok := planDayMeal(next, "lunch")["time"]; ok {
+			lunch["time"] = lunchTime
+		}
+		nextMeals["lunch"] = lunch
+	}
+}
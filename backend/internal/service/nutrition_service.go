@@ -2,12 +2,16 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"math"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/ai-fitness-planner/backend/internal/errors"
 	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/taskqueue"
 	"github.com/ai-fitness-planner/backend/internal/repository"
 	"github.com/google/uuid"
 )
@@ -18,10 +22,35 @@ type NutritionService interface {
 	GeneratePlan(ctx context.Context, userID int64, req *GenerateNutritionPlanRequest) (*TaskResponse, error)
 	// GetPlanStatus retrieves the status of a plan generation task
 	GetPlanStatus(ctx context.Context, taskID string) (*NutritionTaskStatus, error)
+	// WatchPlanTask returns a snapshot of the user's generation task and a
+	// channel that is closed on its next change
+	WatchPlanTask(ctx context.Context, userID int64, taskID string) (*NutritionTaskStatus, <-chan struct{}, error)
+	// ListTasks retrieves the user's most recent plan generation tasks,
+	// newest first
+	ListTasks(ctx context.Context, userID int64) ([]*NutritionTaskStatus, error)
+	// SweepExpiredTasks forgets finished tasks past their retention and
+	// returns how many were removed
+	SweepExpiredTasks(ctx context.Context) (int, error)
+	// RetryTask queues the user's failed generation task again with the
+	// parameters it was submitted with
+	RetryTask(ctx context.Context, userID int64, taskID string) (*TaskResponse, error)
+	// FailQueuedTask marks a task taken off the queue by an operator as
+	// failed with reason
+	FailQueuedTask(task *taskqueue.Task, reason string)
+	// ListTaskHistory retrieves the user's most recently finished
+	// generation tasks from the task history, newest first
+	ListTaskHistory(ctx context.Context, userID int64) ([]*model.GenerationTask, error)
 	// ListPlans retrieves nutrition plans for a user with optional status filter
 	ListPlans(ctx context.Context, userID int64, status string) ([]*model.NutritionPlan, error)
 	// GetPlanDetail retrieves a specific nutrition plan
 	GetPlanDetail(ctx context.Context, planID int64, userID int64) (*model.NutritionPlan, error)
+	// GetPlanSummary retrieves a specific nutrition plan without its plan data
+	GetPlanSummary(ctx context.Context, planID int64, userID int64) (*model.NutritionPlan, error)
+	// ExportPDF renders a plan's meals as a printable, watermarked PDF
+	ExportPDF(ctx context.Context, userID, planID int64, opts PDFExportOptions) ([]byte, error)
+	// GetShoppingList lists the foods of a week of a plan with their
+	// estimated cost
+	GetShoppingList(ctx context.Context, userID, planID int64, week int) (*ShoppingList, error)
 	// GetTodayMeals retrieves today's meal plan
 	GetTodayMeals(ctx context.Context, userID int64) ([]model.NutritionPlanMeal, error)
 	// RecordMeal records a meal with nutrition calculation
@@ -30,6 +59,12 @@ type NutritionService interface {
 	GetDailySummary(ctx context.Context, userID int64, date time.Time) (*repository.DailyNutritionSummary, error)
 	// GetNutritionHistory retrieves nutrition records for a user
 	GetNutritionHistory(ctx context.Context, userID int64, startDate, endDate *time.Time) ([]*model.NutritionRecord, error)
+	// AdjustPlan generates a revised version of a plan from the user's
+	// records and feedback asynchronously and returns a task ID
+	AdjustPlan(ctx context.Context, userID, planID int64, req *AdjustNutritionPlanRequest) (*TaskResponse, error)
+	// RegenerateDay replaces one date of a plan with newly generated meals
+	// that keep the plan's targets and restrictions, and returns that day
+	RegenerateDay(ctx context.Context, userID, planID int64, date time.Time, req *RegenerateNutritionDayRequest) (model.JSONMap, error)
 }
 
 // GenerateNutritionPlanRequest holds parameters for nutrition plan generation request
@@ -40,21 +75,61 @@ type GenerateNutritionPlanRequest struct {
 	ProteinRatio        float64  `json:"protein_ratio" validate:"required,min=0,max=1"`
 	CarbRatio           float64  `json:"carb_ratio" validate:"required,min=0,max=1"`
 	FatRatio            float64  `json:"fat_ratio" validate:"required,min=0,max=1"`
-	DietaryRestrictions []string `json:"dietary_restrictions"`
-	Preferences         []string `json:"preferences"`
-	AIAPIID             *int64   `json:"ai_api_id"` // Optional, uses default if not provided
+	DietaryRestrictions []string `json:"dietary_restrictions"` // DietaryRestrictionTerms codes or names
+	Preferences         []string `json:"preferences"`          // CuisineTerms codes or names
+	LeftoverLunch       bool     `json:"leftover_lunch"`       // Dinner leftovers become the next day's lunch
+	BudgetLevel         string   `json:"budget_level"`         // Optional model.NutritionBudget* value
+	WeeklyBudget        *float64 `json:"weekly_budget"`        // Optional weekly food budget in CNY
+	AIAPIID             *int64   `json:"ai_api_id"`            // Optional, uses default if not provided
 }
 
 // NutritionTaskStatus represents the status of an async nutrition task
 type NutritionTaskStatus struct {
-	TaskID    string               `json:"task_id"`
-	Status    string               `json:"status"` // pending, processing, completed, failed
-	Progress  int                  `json:"progress"`
-	Message   string               `json:"message,omitempty"`
-	Error     string               `json:"error,omitempty"`
-	Result    *model.NutritionPlan `json:"result,omitempty"`
-	CreatedAt time.Time            `json:"created_at"`
-	UpdatedAt time.Time            `json:"updated_at"`
+	TaskID   string               `json:"task_id"`
+	Status   string               `json:"status"` // pending, processing, completed, failed
+	Progress int                  `json:"progress"`
+	Message  string               `json:"message,omitempty"`
+	Error    string               `json:"error,omitempty"`
+	Result   *model.NutritionPlan `json:"result,omitempty"`
+	// Warnings apply to Result once the task has completed
+	Warnings []PlanWarning `json:"warnings,omitempty"`
+	// Cooldown is set while generation waits for a throttling provider
+	Cooldown  *ProviderCooldown `json:"cooldown,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	// ExpiresAt is when a finished task is forgotten; nil while it runs
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// UserID owns the task
+	UserID  int64         `json:"-"`
+	changed chan struct{} // closed and replaced on every update
+	queued  *queuedTask
+}
+
+// defaultDailyCalories is the calorie target used when the user has no body
+// data to calculate one from
+const defaultDailyCalories = 2000.0
+
+// PlanWarning points out something the user can fix to get a better plan
+type PlanWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// WarningMissingBodyData is the warning code for a nutrition plan whose
+// calorie target is the default because the user has no body data
+const WarningMissingBodyData = "missing_body_data"
+
+// NutritionPlanWarnings returns the warnings that apply to a nutrition plan
+func NutritionPlanWarnings(plan *model.NutritionPlan) []PlanWarning {
+	var warnings []PlanWarning
+	if plan.CalorieBasis != nil && *plan.CalorieBasis == model.CalorieBasisDefault {
+		warnings = append(warnings, PlanWarning{
+			Code:    WarningMissingBodyData,
+			Message: fmt.Sprintf("未找到身体数据，每日热量按默认%.0f千卡估算。补充身高、体重、年龄和性别后重新生成可获得个性化热量目标", defaultDailyCalories),
+		})
+	}
+	return warnings
 }
 
 // nutritionService implements NutritionService interface
@@ -64,11 +139,19 @@ type nutritionService struct {
 	aiAPIRepo       repository.AIAPIRepository
 	bodyDataRepo    repository.BodyDataRepository
 	fitnessGoalRepo repository.FitnessGoalRepository
+	checkInRepo     repository.CheckInRepository
 	aiService       AIService
-
-	// In-memory task storage (in production, use Redis)
-	tasks      map[string]*NutritionTaskStatus
-	tasksMutex sync.RWMutex
+	queue           *taskqueue.Queue
+	generationLock  GenerationLock
+	taskHistoryRepo repository.GenerationTaskRepository
+	todayCache      TodayCache
+	responseArchive ResponseArchiveService
+
+	// In-memory task storage (in production, use Redis); finished tasks
+	// are kept for taskRetention and in the task history
+	tasks         map[string]*NutritionTaskStatus
+	tasksMutex    sync.RWMutex
+	taskRetention time.Duration
 }
 
 // NewNutritionService creates a new instance of NutritionService
@@ -78,17 +161,34 @@ func NewNutritionService(
 	aiAPIRepo repository.AIAPIRepository,
 	bodyDataRepo repository.BodyDataRepository,
 	fitnessGoalRepo repository.FitnessGoalRepository,
+	checkInRepo repository.CheckInRepository,
 	aiService AIService,
+	queue *taskqueue.Queue,
+	generationLock GenerationLock,
+	taskHistoryRepo repository.GenerationTaskRepository,
+	taskRetention time.Duration,
+	todayCache TodayCache,
+	responseArchive ResponseArchiveService,
 ) NutritionService {
-	return &nutritionService{
+	s := &nutritionService{
 		planRepo:        planRepo,
 		recordRepo:      recordRepo,
 		aiAPIRepo:       aiAPIRepo,
 		bodyDataRepo:    bodyDataRepo,
 		fitnessGoalRepo: fitnessGoalRepo,
+		checkInRepo:     checkInRepo,
 		aiService:       aiService,
+		queue:           queue,
+		generationLock:  generationLock,
+		taskHistoryRepo: taskHistoryRepo,
+		todayCache:      todayCache,
+		responseArchive: responseArchive,
 		tasks:           make(map[string]*NutritionTaskStatus),
+		taskRetention:   taskRetention,
 	}
+	queue.Handle(taskTypeGenerateNutritionPlan, s.runGeneratePlan)
+	queue.Handle(taskTypeAdjustNutritionPlan, s.runAdjustPlan)
+	return s
 }
 
 // GeneratePlan generates a nutrition plan asynchronously
@@ -101,61 +201,133 @@ func (s *nutritionService) GeneratePlan(ctx context.Context, userID int64, req *
 		return nil, errors.New(errors.ErrInvalidParam, "宏量营养素比例之和必须等于100%")
 	}
 
-	// Determine which AI API to use
-	var aiAPIID int64
-	if req.AIAPIID != nil {
-		aiAPIID = *req.AIAPIID
-		// Verify the API exists and belongs to the user
-		api, err := s.aiAPIRepo.GetByID(ctx, aiAPIID)
-		if err != nil {
-			return nil, errors.Wrap(err, errors.ErrDatabase, "获取AI API失败")
-		}
-		if api == nil || api.UserID != userID {
-			return nil, errors.New(errors.ErrNotFound, "AI API不存在")
-		}
-	} else {
-		// Use default AI API
-		defaultAPI, err := s.aiAPIRepo.GetDefaultByUser(ctx, userID)
+	// Plans store the vocabulary codes so prompts and clients agree on them
+	restrictions, err := normalizeDietTerms(dietaryRestrictionTerms, req.DietaryRestrictions, "饮食限制")
+	if err != nil {
+		return nil, err
+	}
+	preferences, err := normalizeDietTerms(cuisineTerms, req.Preferences, "菜系偏好")
+	if err != nil {
+		return nil, err
+	}
+	req.DietaryRestrictions, req.Preferences = restrictions, preferences
+
+	aiAPIID, err := resolveAIAPIID(ctx, s.aiAPIRepo, userID, req.AIAPIID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.enqueueTask(ctx, userID, taskTypeGenerateNutritionPlan, "饮食计划生成任务已创建", func(taskID string) interface{} {
+		return &generateNutritionTask{TaskID: taskID, UserID: userID, AIAPIID: aiAPIID, Request: req}
+	})
+}
+
+// enqueueTask registers a pending generation task and queues the payload
+// built for its ID, responding with message. While the user has another
+// task of the same kind pending, that task is returned instead.
+func (s *nutritionService) enqueueTask(ctx context.Context, userID int64, taskType, message string, payload func(taskID string) interface{}) (*TaskResponse, error) {
+	taskID := uuid.New().String()
+	raw, err := json.Marshal(payload(taskID))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "创建生成任务失败")
+	}
+
+	if s.generationLock != nil {
+		holder, err := s.generationLock.Acquire(ctx, generationLockNutrition, userID, taskID)
 		if err != nil {
-			return nil, errors.Wrap(err, errors.ErrDatabase, "获取默认AI API失败")
+			return nil, errors.Wrap(err, errors.ErrCache, "创建生成任务失败")
 		}
-		if defaultAPI == nil {
-			return nil, errors.ErrNoDefaultAIAPI
+		if holder != taskID {
+			return s.existingTaskResponse(holder), nil
 		}
-		aiAPIID = defaultAPI.ID
 	}
+	s.registerTask(taskID, userID, time.Now(), &queuedTask{taskType: taskType, payload: raw})
+
+	if err := s.queue.Enqueue(ctx, taskType, taskID, raw); err != nil {
+		s.tasksMutex.Lock()
+		delete(s.tasks, taskID)
+		s.tasksMutex.Unlock()
+		releaseFinishedTask(s.generationLock, generationLockNutrition, userID, taskID)
+		return nil, errors.Wrap(err, errors.ErrCache, "创建生成任务失败")
+	}
+	return &TaskResponse{
+		TaskID:  taskID,
+		Status:  TaskStatusPending,
+		Message: message,
+	}, nil
+}
 
-	// Create task ID
-	taskID := uuid.New().String()
+// existingTaskResponse describes the pending task a new request of the
+// user's was turned down for. The task may run on another instance, where
+// its progress is not known here.
+func (s *nutritionService) existingTaskResponse(taskID string) *TaskResponse {
+	status := TaskStatusPending
+	s.tasksMutex.RLock()
+	if task, exists := s.tasks[taskID]; exists {
+		status = task.Status
+	}
+	s.tasksMutex.RUnlock()
 
-	// Initialize task status
-	now := time.Now()
-	task := &NutritionTaskStatus{
+	return &TaskResponse{
+		TaskID:   taskID,
+		Status:   status,
+		Message:  "已有生成任务正在进行，请等待其完成",
+		Existing: true,
+	}
+}
+
+// registerTask records a pending generation task unless it is already
+// registered
+func (s *nutritionService) registerTask(taskID string, userID int64, createdAt time.Time, queued *queuedTask) {
+	s.tasksMutex.Lock()
+	defer s.tasksMutex.Unlock()
+
+	if _, exists := s.tasks[taskID]; exists {
+		return
+	}
+	s.tasks[taskID] = &NutritionTaskStatus{
 		TaskID:    taskID,
 		Status:    TaskStatusPending,
 		Progress:  0,
 		Message:   "任务已创建，等待处理",
-		CreatedAt: now,
-		UpdatedAt: now,
+		CreatedAt: createdAt,
+		UpdatedAt: time.Now(),
+		UserID:    userID,
+		changed:   make(chan struct{}),
+		queued:    queued,
 	}
+}
 
-	s.tasksMutex.Lock()
-	s.tasks[taskID] = task
-	s.tasksMutex.Unlock()
+// runGeneratePlan runs a queued plan generation
+func (s *nutritionService) runGeneratePlan(ctx context.Context, task *taskqueue.Task) error {
+	var payload generateNutritionTask
+	if err := task.Decode(&payload); err != nil {
+		return fmt.Errorf("invalid nutrition generation task: %v: %w", err, taskqueue.ErrSkipRetry)
+	}
+	s.registerTask(payload.TaskID, payload.UserID, task.EnqueuedAt, queuedFrom(task))
 
-	// Start async generation
-	go s.processGeneratePlan(userID, req, aiAPIID, taskID)
+	err := s.processGeneratePlan(ctx, payload.UserID, payload.Request, payload.AIAPIID, payload.TaskID)
+	return s.finishAttempt(ctx, task, payload.TaskID, err)
+}
 
-	return &TaskResponse{
-		TaskID:  taskID,
-		Status:  TaskStatusPending,
-		Message: "饮食计划生成任务已创建",
-	}, nil
+// finishAttempt records a failed attempt on its task and returns the error
+// for the queue
+func (s *nutritionService) finishAttempt(ctx context.Context, task *taskqueue.Task, taskID string, err error) error {
+	if err == nil {
+		return nil
+	}
+	status, message, queueErr := attemptOutcome(ctx, task, err)
+	switch status {
+	case TaskStatusFailed:
+		s.updateTaskStatus(taskID, TaskStatusFailed, 0, "", err.Error(), nil)
+	case TaskStatusPending:
+		s.updateTaskStatus(taskID, TaskStatusPending, 0, message, err.Error(), nil)
+	}
+	return queueErr
 }
 
-// processGeneratePlan handles the async plan generation
-func (s *nutritionService) processGeneratePlan(userID int64, req *GenerateNutritionPlanRequest, aiAPIID int64, taskID string) {
-	ctx := context.Background()
+// processGeneratePlan generates and saves a plan, updating the task as it goes
+func (s *nutritionService) processGeneratePlan(ctx context.Context, userID int64, req *GenerateNutritionPlanRequest, aiAPIID int64, taskID string) error {
 
 	// Update task status to processing
 	s.updateTaskStatus(taskID, TaskStatusProcessing, 10, "正在收集用户数据...", "", nil)
@@ -163,8 +335,7 @@ func (s *nutritionService) processGeneratePlan(userID int64, req *GenerateNutrit
 	// Get user's latest body data
 	bodyData, err := s.bodyDataRepo.GetLatestByUserID(ctx, userID)
 	if err != nil {
-		s.updateTaskStatus(taskID, TaskStatusFailed, 0, "", "获取身体数据失败: "+err.Error(), nil)
-		return
+		return fmt.Errorf("获取身体数据失败: %w", err)
 	}
 
 	s.updateTaskStatus(taskID, TaskStatusProcessing, 20, "正在获取健身目标...", "", nil)
@@ -172,8 +343,13 @@ func (s *nutritionService) processGeneratePlan(userID int64, req *GenerateNutrit
 	// Get user's fitness goals
 	fitnessGoals, err := s.fitnessGoalRepo.GetByUserID(ctx, userID, "active")
 	if err != nil {
-		s.updateTaskStatus(taskID, TaskStatusFailed, 0, "", "获取健身目标失败: "+err.Error(), nil)
-		return
+		return fmt.Errorf("获取健身目标失败: %w", err)
+	}
+
+	// Get user's latest weekly check-in; only a recent one informs the plan
+	latestCheckIn, err := s.checkInRepo.GetLatestByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("获取每周签到失败: %w", err)
 	}
 
 	s.updateTaskStatus(taskID, TaskStatusProcessing, 30, "正在计算每日热量需求...", "", nil)
@@ -181,55 +357,70 @@ func (s *nutritionService) processGeneratePlan(userID int64, req *GenerateNutrit
 	// Calculate daily calories if not provided
 	// Requirements: 6.1 - Calculate daily calorie needs based on body data
 	dailyCalories := req.DailyCalories
+	calorieBasis := model.CalorieBasisProvided
 	if dailyCalories == nil || *dailyCalories <= 0 {
-		calculatedCalories := s.calculateDailyCalories(bodyData, fitnessGoals)
+		calculatedCalories, basis := s.calculateDailyCalories(bodyData, fitnessGoals)
 		dailyCalories = &calculatedCalories
+		calorieBasis = basis
 	}
 
 	s.updateTaskStatus(taskID, TaskStatusProcessing, 50, "正在调用AI生成饮食计划...", "", nil)
 
 	// Build AI params
+	var aiResponse string
 	params := &NutritionPlanParams{
 		UserID:              userID,
 		PlanName:            req.PlanName,
 		DurationDays:        req.DurationDays,
 		DailyCalories:       *dailyCalories,
+		CalorieBasis:        calorieBasis,
 		ProteinRatio:        req.ProteinRatio,
 		CarbRatio:           req.CarbRatio,
 		FatRatio:            req.FatRatio,
 		DietaryRestrictions: req.DietaryRestrictions,
 		Preferences:         req.Preferences,
+		LeftoverLunch:       req.LeftoverLunch,
+		BudgetLevel:         req.BudgetLevel,
+		WeeklyBudget:        req.WeeklyBudget,
 		AIAPIID:             aiAPIID,
 		BodyData:            bodyData,
 		FitnessGoals:        fitnessGoals,
+		LatestCheckIn:       recentCheckIn(latestCheckIn, time.Now()),
+		OnCooldown: func(cooldown *ProviderCooldown) {
+			s.setTaskCooldown(taskID, cooldown)
+		},
+		OnResponse: func(response string) {
+			aiResponse = response
+		},
 	}
 
 	// Generate plan using AI service
 	plan, err := s.aiService.GenerateNutritionPlan(ctx, params)
 	if err != nil {
-		s.updateTaskStatus(taskID, TaskStatusFailed, 0, "", "AI生成计划失败: "+err.Error(), nil)
-		return
+		return fmt.Errorf("AI生成计划失败: %w", err)
 	}
 
 	s.updateTaskStatus(taskID, TaskStatusProcessing, 80, "正在保存饮食计划...", "", nil)
 
 	// Save the plan to database
 	if err := s.planRepo.Create(ctx, plan); err != nil {
-		s.updateTaskStatus(taskID, TaskStatusFailed, 0, "", "保存计划失败: "+err.Error(), nil)
-		return
+		return fmt.Errorf("保存计划失败: %w", err)
 	}
+	publishNutritionPlanCreated(ctx, plan, planSourceGenerated, nil)
+	s.archiveResponse(taskID, taskTypeGenerateNutritionPlan, plan, aiResponse)
 
 	// Update task status to completed
 	s.updateTaskStatus(taskID, TaskStatusCompleted, 100, "饮食计划生成完成", "", plan)
+	return nil
 }
 
 // calculateDailyCalories calculates daily calorie needs based on body data and goals
-// Uses Mifflin-St Jeor equation for BMR calculation
+// Uses Mifflin-St Jeor equation for BMR calculation. The returned basis says
+// whether the result was calculated or is the default used without body data.
 // Requirements: 6.1
-func (s *nutritionService) calculateDailyCalories(bodyData *model.UserBodyData, goals []*model.FitnessGoal) float64 {
+func (s *nutritionService) calculateDailyCalories(bodyData *model.UserBodyData, goals []*model.FitnessGoal) (float64, string) {
 	if bodyData == nil {
-		// Default to 2000 calories if no body data available
-		return 2000.0
+		return defaultDailyCalories, model.CalorieBasisDefault
 	}
 
 	// Calculate BMR using Mifflin-St Jeor equation
@@ -263,24 +454,72 @@ func (s *nutritionService) calculateDailyCalories(bodyData *model.UserBodyData,
 	}
 
 	// Round to nearest 50
-	return math.Round(tdee/50) * 50
+	return math.Round(tdee/50) * 50, model.CalorieBasisCalculated
+}
+
+// archiveResponse archives the AI response a saved plan was parsed from
+func (s *nutritionService) archiveResponse(taskID, taskType string, plan *model.NutritionPlan, response string) {
+	if s.responseArchive == nil {
+		return
+	}
+	s.responseArchive.Archive(taskID, taskType, plan.UserID, plan.ID, plan.AIAPIID, response)
 }
 
-// updateTaskStatus updates the status of a task
+// updateTaskStatus updates the status of a task, recording it in the task
+// history once it has finished
 func (s *nutritionService) updateTaskStatus(taskID, status string, progress int, message, errMsg string, result *model.NutritionPlan) {
+	s.tasksMutex.Lock()
+	task, exists := s.tasks[taskID]
+	if !exists {
+		s.tasksMutex.Unlock()
+		return
+	}
+	task.Status = status
+	task.Progress = progress
+	task.Message = message
+	task.Error = errMsg
+	task.Result = result
+	task.Warnings = nil
+	var planID *int64
+	if result != nil {
+		task.Warnings = NutritionPlanWarnings(result)
+		planID = &result.ID
+	}
+	task.Cooldown = nil
+	task.UpdatedAt = time.Now()
+	task.ExpiresAt = taskExpiry(status, task.UpdatedAt, s.taskRetention)
+	notifyNutritionTaskChanged(task)
+
+	record := newTaskHistory(taskID, task.UserID, task.queued, status, errMsg, planID, task.CreatedAt, task.UpdatedAt)
+	s.tasksMutex.Unlock()
+
+	if record != nil {
+		saveTaskHistory(s.taskHistoryRepo, record)
+		releaseFinishedTask(s.generationLock, generationLockNutrition, record.UserID, taskID)
+	}
+}
+
+// setTaskCooldown records that a task is waiting for a throttling provider,
+// or with nil that it has resumed
+func (s *nutritionService) setTaskCooldown(taskID string, cooldown *ProviderCooldown) {
 	s.tasksMutex.Lock()
 	defer s.tasksMutex.Unlock()
 
 	if task, exists := s.tasks[taskID]; exists {
-		task.Status = status
-		task.Progress = progress
-		task.Message = message
-		task.Error = errMsg
-		task.Result = result
+		task.Cooldown = cooldown
+		task.Message = cooldownMessage(cooldown)
 		task.UpdatedAt = time.Now()
+		notifyNutritionTaskChanged(task)
 	}
 }
 
+// notifyNutritionTaskChanged wakes watchers of a task; callers hold
+// tasksMutex
+func notifyNutritionTaskChanged(task *NutritionTaskStatus) {
+	close(task.changed)
+	task.changed = make(chan struct{})
+}
+
 // GetPlanStatus retrieves the status of a plan generation task
 func (s *nutritionService) GetPlanStatus(ctx context.Context, taskID string) (*NutritionTaskStatus, error) {
 	s.tasksMutex.RLock()
@@ -294,10 +533,131 @@ func (s *nutritionService) GetPlanStatus(ctx context.Context, taskID string) (*N
 	return task, nil
 }
 
+// WatchPlanTask returns a copy of the task so callers can read it without
+// holding the lock, along with the channel for its next change
+func (s *nutritionService) WatchPlanTask(ctx context.Context, userID int64, taskID string) (*NutritionTaskStatus, <-chan struct{}, error) {
+	s.tasksMutex.RLock()
+	defer s.tasksMutex.RUnlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists || task.UserID != userID {
+		return nil, nil, errors.New(errors.ErrNotFound, "任务不存在")
+	}
+
+	snapshot := *task
+	return &snapshot, task.changed, nil
+}
+
+// ListTasks returns copies of the user's tasks, capped at recentTaskLimit
+func (s *nutritionService) ListTasks(ctx context.Context, userID int64) ([]*NutritionTaskStatus, error) {
+	s.tasksMutex.RLock()
+	defer s.tasksMutex.RUnlock()
+
+	tasks := make([]*NutritionTaskStatus, 0)
+	for _, task := range s.tasks {
+		if task.UserID != userID {
+			continue
+		}
+		snapshot := *task
+		tasks = append(tasks, &snapshot)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
+	})
+	if len(tasks) > recentTaskLimit {
+		tasks = tasks[:recentTaskLimit]
+	}
+	return tasks, nil
+}
+
+// RetryTask queues a failed task again under the same ID, so clients
+// watching the task see it start over. Attempts are counted afresh.
+func (s *nutritionService) RetryTask(ctx context.Context, userID int64, taskID string) (*TaskResponse, error) {
+	s.tasksMutex.Lock()
+	task, exists := s.tasks[taskID]
+	if !exists || task.UserID != userID {
+		s.tasksMutex.Unlock()
+		return nil, errors.New(errors.ErrNotFound, "任务不存在")
+	}
+	if task.Status != TaskStatusFailed {
+		s.tasksMutex.Unlock()
+		return nil, errors.New(errors.ErrConflict, "只能重试失败的任务")
+	}
+	// Claim the task so that a concurrent retry is refused
+	queued, lastErr := task.queued, task.Error
+	task.Status = TaskStatusPending
+	s.tasksMutex.Unlock()
+
+	// The retry waits its turn like a new request would
+	if s.generationLock != nil {
+		holder, err := s.generationLock.Acquire(ctx, generationLockNutrition, userID, taskID)
+		if err != nil || holder != taskID {
+			s.tasksMutex.Lock()
+			task.Status = TaskStatusFailed
+			s.tasksMutex.Unlock()
+			if err != nil {
+				return nil, errors.Wrap(err, errors.ErrCache, "重新提交任务失败")
+			}
+			return s.existingTaskResponse(holder), nil
+		}
+	}
+
+	s.updateTaskStatus(taskID, TaskStatusPending, 0, "任务已重新提交，等待处理", "", nil)
+	if err := s.queue.Enqueue(ctx, queued.taskType, taskID, queued.payload); err != nil {
+		s.updateTaskStatus(taskID, TaskStatusFailed, 0, "", lastErr, nil)
+		return nil, errors.Wrap(err, errors.ErrCache, "重新提交任务失败")
+	}
+
+	return &TaskResponse{
+		TaskID:  taskID,
+		Status:  TaskStatusPending,
+		Message: "任务已重新提交",
+	}, nil
+}
+
+// FailQueuedTask records a removed task as failed the way a last failed
+// attempt would: its history is written and the user's generation lock is
+// released. The task is registered first, as it may never have run on this
+// instance.
+func (s *nutritionService) FailQueuedTask(task *taskqueue.Task, reason string) {
+	header := decodeTaskHeader(task)
+	s.registerTask(header.TaskID, header.UserID, task.EnqueuedAt, queuedFrom(task))
+	s.updateTaskStatus(header.TaskID, TaskStatusFailed, 0, "", reason, nil)
+}
+
+// ListTaskHistory lists finished tasks, including those no longer in memory
+func (s *nutritionService) ListTaskHistory(ctx context.Context, userID int64) ([]*model.GenerationTask, error) {
+	tasks, _, err := s.taskHistoryRepo.List(ctx, repository.GenerationTaskFilter{
+		UserID:    userID,
+		TaskTypes: nutritionTaskTypes,
+	}, taskHistoryLimit, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取任务历史失败")
+	}
+	return tasks, nil
+}
+
+// SweepExpiredTasks removes finished tasks whose retention has run out
+func (s *nutritionService) SweepExpiredTasks(ctx context.Context) (int, error) {
+	s.tasksMutex.Lock()
+	defer s.tasksMutex.Unlock()
+
+	now := time.Now()
+	removed := 0
+	for taskID, task := range s.tasks {
+		if taskExpired(task.ExpiresAt, now) {
+			delete(s.tasks, taskID)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
 // ListPlans retrieves nutrition plans for a user with optional status filter
 // Requirements: 6.3
 func (s *nutritionService) ListPlans(ctx context.Context, userID int64, status string) ([]*model.NutritionPlan, error) {
-	plans, err := s.planRepo.ListByUser(ctx, userID, status)
+	plans, err := s.planRepo.ListSummariesByUser(ctx, userID, status)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrDatabase, "获取饮食计划列表失败")
 	}
@@ -308,6 +668,18 @@ func (s *nutritionService) ListPlans(ctx context.Context, userID int64, status s
 // Requirements: 6.3
 func (s *nutritionService) GetPlanDetail(ctx context.Context, planID int64, userID int64) (*model.NutritionPlan, error) {
 	plan, err := s.planRepo.GetByID(ctx, planID)
+	return ownedNutritionPlan(plan, err, userID)
+}
+
+// GetPlanSummary retrieves a specific nutrition plan without its plan data
+func (s *nutritionService) GetPlanSummary(ctx context.Context, planID int64, userID int64) (*model.NutritionPlan, error) {
+	plan, err := s.planRepo.GetSummaryByID(ctx, planID)
+	return ownedNutritionPlan(plan, err, userID)
+}
+
+// ownedNutritionPlan checks the result of reading a plan for userID: that the
+// read succeeded and found a plan the user owns
+func ownedNutritionPlan(plan *model.NutritionPlan, err error, userID int64) (*model.NutritionPlan, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrDatabase, "获取饮食计划失败")
 	}
@@ -323,12 +695,12 @@ func (s *nutritionService) GetPlanDetail(ctx context.Context, planID int64, user
 	return plan, nil
 }
 
-// GetTodayMeals retrieves today's meal plan
+// GetTodayMeals retrieves today's meal plan through the today cache
 // Requirements: 6.4
 func (s *nutritionService) GetTodayMeals(ctx context.Context, userID int64) ([]model.NutritionPlanMeal, error) {
 	today := time.Now()
 
-	meals, err := s.planRepo.GetTodayMeals(ctx, userID, today)
+	meals, err := cachedTodayMeals(ctx, s.todayCache, s.planRepo, userID, today)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrDatabase, "获取今日餐食失败")
 	}
@@ -350,7 +722,7 @@ func (s *nutritionService) RecordMeal(ctx context.Context, userID int64, record
 	// Calculate total nutrition from foods if not already set
 	// Requirements: 8.1 - Calculate total calories and macronutrients
 	if record.Foods != nil {
-		totalCalories, totalProtein, totalCarbs, totalFat, totalFiber := s.calculateNutritionFromFoods(record.Foods)
+		totalCalories, totalProtein, totalCarbs, totalFat, totalFiber := calculateNutritionFromFoods(record.Foods)
 
 		// Only override if not explicitly set
 		if record.Calories == 0 {
@@ -374,28 +746,25 @@ func (s *nutritionService) RecordMeal(ctx context.Context, userID int64, record
 	if err := s.recordRepo.Create(ctx, record); err != nil {
 		return errors.Wrap(err, errors.ErrDatabase, "保存饮食记录失败")
 	}
+	publishNutritionRecordCreated(ctx, record)
 
 	return nil
 }
 
-// calculateNutritionFromFoods calculates total nutrition values from foods JSON
-func (s *nutritionService) calculateNutritionFromFoods(foods model.JSONMap) (calories, protein, carbs, fat, fiber float64) {
-	// Try to extract foods array from the JSON map
+// foodItems returns the food entries of a record's foods JSON, stored under
+// "items" or the alternative "foods" key
+func foodItems(foods model.JSONMap) []interface{} {
 	foodsInterface, ok := foods["items"]
 	if !ok {
-		// Try alternative key
-		foodsInterface, ok = foods["foods"]
-		if !ok {
-			return 0, 0, 0, 0, 0
-		}
-	}
-
-	foodsArray, ok := foodsInterface.([]interface{})
-	if !ok {
-		return 0, 0, 0, 0, 0
+		foodsInterface = foods["foods"]
 	}
+	foodsArray, _ := foodsInterface.([]interface{})
+	return foodsArray
+}
 
-	for _, foodInterface := range foodsArray {
+// calculateNutritionFromFoods calculates total nutrition values from foods JSON
+func calculateNutritionFromFoods(foods model.JSONMap) (calories, protein, carbs, fat, fiber float64) {
+	for _, foodInterface := range foodItems(foods) {
 		foodMap, ok := foodInterface.(map[string]interface{})
 		if !ok {
 			continue
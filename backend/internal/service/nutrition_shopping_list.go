@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+)
+
+// ShoppingListItem is a food to buy for a week of a nutrition plan
+type ShoppingListItem struct {
+	Name string
+	// Quantity is the amounts summed when they share a unit, e.g. "350g",
+	// and listed otherwise, e.g. "2个 + 100g"
+	Quantity string
+	// Servings is how many times the food is served in the week
+	Servings int
+	// EstimatedCost sums the prices of the priced servings; nil when none
+	// was priced
+	EstimatedCost *float64
+	// Unpriced counts the servings without a price
+	Unpriced int
+}
+
+// ShoppingList lists the foods of a week of a nutrition plan with their
+// estimated cost against the plan's budget
+type ShoppingList struct {
+	PlanID     int64
+	Week       int
+	TotalWeeks int
+	StartDate  string
+	EndDate    string
+	Items      []ShoppingListItem
+	// EstimatedCost sums the priced servings; nil when nothing was priced.
+	// It underestimates the bill while Unpriced is above zero.
+	EstimatedCost *float64
+	Unpriced      int
+	WeeklyBudget  *float64
+	BudgetLevel   *string
+	// OverBudget is set when the estimate exceeds the weekly budget
+	OverBudget bool
+}
+
+// GetShoppingList adds up the foods of one week of the user's plan, week 1
+// starting on the plan's first day. Prices come from the plan's foods, which
+// the AI is asked to price when the plan has a budget.
+func (s *nutritionService) GetShoppingList(ctx context.Context, userID, planID int64, week int) (*ShoppingList, error) {
+	plan, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	days := datedNutritionDays(plan)
+	weeks := (len(days) + 6) / 7
+	if week < 1 || week > weeks {
+		return nil, errors.New(errors.ErrNotFound, "计划中没有该周")
+	}
+	days = days[(week-1)*7:]
+	if len(days) > 7 {
+		days = days[:7]
+	}
+
+	list := buildShoppingList(days)
+	list.PlanID = plan.ID
+	list.Week = week
+	list.TotalWeeks = weeks
+	list.StartDate = days[0].date
+	list.EndDate = days[len(days)-1].date
+	list.WeeklyBudget = plan.WeeklyBudget
+	list.BudgetLevel = plan.BudgetLevel
+	if list.WeeklyBudget != nil && list.EstimatedCost != nil {
+		list.OverBudget = *list.EstimatedCost > *list.WeeklyBudget
+	}
+	return list, nil
+}
+
+// shoppingAmount is a food amount split into its number and unit
+type shoppingAmount struct {
+	raw    string
+	value  float64
+	unit   string
+	parsed bool
+}
+
+// amountPattern splits amounts like "150g", "2 个" or "0.5杯"
+var amountPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(\S*)$`)
+
+// parseShoppingAmount splits an amount, leaving it unparsed when it does not
+// start with a number
+func parseShoppingAmount(amount string) shoppingAmount {
+	amount = strings.TrimSpace(amount)
+	m := amountPattern.FindStringSubmatch(amount)
+	if m == nil {
+		return shoppingAmount{raw: amount}
+	}
+	value, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return shoppingAmount{raw: amount}
+	}
+	return shoppingAmount{raw: amount, value: value, unit: strings.ToLower(m[2]), parsed: true}
+}
+
+// buildShoppingList groups the foods of days by name, ignoring case and
+// surrounding space, in the order they are first served
+func buildShoppingList(days []datedNutritionDay) *ShoppingList {
+	type entry struct {
+		item    ShoppingListItem
+		amounts []shoppingAmount
+	}
+	var order []string
+	entries := make(map[string]*entry)
+	list := &ShoppingList{Items: []ShoppingListItem{}}
+
+	for _, day := range days {
+		meals, _ := day.data["meals"].(map[string]interface{})
+		for _, m := range nutritionMealOrder {
+			meal, _ := meals[m.key].(map[string]interface{})
+			foods, _ := meal["foods"].([]interface{})
+			for _, raw := range foods {
+				food, ok := raw.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _ := food["name"].(string)
+				key := strings.ToLower(strings.TrimSpace(name))
+				if key == "" {
+					continue
+				}
+				e, ok := entries[key]
+				if !ok {
+					e = &entry{item: ShoppingListItem{Name: strings.TrimSpace(name)}}
+					entries[key] = e
+					order = append(order, key)
+				}
+				e.item.Servings++
+				if amount, _ := food["amount"].(string); strings.TrimSpace(amount) != "" {
+					e.amounts = append(e.amounts, parseShoppingAmount(amount))
+				}
+				if price, ok := food["price"].(float64); ok && price >= 0 {
+					if e.item.EstimatedCost == nil {
+						e.item.EstimatedCost = new(float64)
+					}
+					*e.item.EstimatedCost += price
+				} else {
+					e.item.Unpriced++
+				}
+			}
+		}
+	}
+
+	for _, key := range order {
+		e := entries[key]
+		e.item.Quantity = sumShoppingAmounts(e.amounts)
+		if e.item.EstimatedCost != nil {
+			if list.EstimatedCost == nil {
+				list.EstimatedCost = new(float64)
+			}
+			*list.EstimatedCost += *e.item.EstimatedCost
+		}
+		list.Unpriced += e.item.Unpriced
+		list.Items = append(list.Items, e.item)
+	}
+	return list
+}
+
+// sumShoppingAmounts adds up amounts of the same unit; amounts in different
+// units, or that could not be parsed, are listed instead
+func sumShoppingAmounts(amounts []shoppingAmount) string {
+	if len(amounts) == 0 {
+		return ""
+	}
+	total := 0.0
+	for _, a := range amounts {
+		if !a.parsed || a.unit != amounts[0].unit {
+			raws := make([]string, 0, len(amounts))
+			for _, a := range amounts {
+				raws = append(raws, a.raw)
+			}
+			return strings.Join(raws, " + ")
+		}
+		total += a.value
+	}
+	return strconv.FormatFloat(math.Round(total*100)/100, 'f', -1, 64) + amounts[0].unit
+}
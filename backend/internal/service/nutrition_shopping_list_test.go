@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildShoppingList(t *testing.T) {
+	food := func(name, amount string, price interface{}) map[string]interface{} {
+		f := map[string]interface{}{"name": name, "amount": amount}
+		if price != nil {
+			f["price"] = price
+		}
+		return f
+	}
+	day := func(n int, breakfast, dinner []interface{}) interface{} {
+		return map[string]interface{}{
+			"day": float64(n),
+			"meals": map[string]interface{}{
+				"breakfast": map[string]interface{}{"foods": breakfast},
+				"dinner":    map[string]interface{}{"foods": dinner},
+			},
+		}
+	}
+	plan := &model.NutritionPlan{
+		StartDate: time.Date(2026, 1, 5, 0, 0, 0, 0, time.Local),
+		PlanData: model.JSONMap{"days": []interface{}{
+			day(1, []interface{}{food("鸡蛋", "2个", 3.0), food("燕麦", "50g", 1.5)}, []interface{}{food("鸡胸肉", "150g", 9.0)}),
+			day(2, []interface{}{food("鸡蛋", "2个", 3.0), food("燕麦", "1杯", nil)}, []interface{}{food(" 鸡胸肉", "200g", 12.0)}),
+		}},
+	}
+
+	list := buildShoppingList(datedNutritionDays(plan))
+	require.Len(t, list.Items, 3)
+
+	assert.Equal(t, "鸡蛋", list.Items[0].Name)
+	assert.Equal(t, "4个", list.Items[0].Quantity)
+	assert.Equal(t, 2, list.Items[0].Servings)
+	assert.InDelta(t, 6.0, *list.Items[0].EstimatedCost, 0.001)
+
+	// Different units are listed, and the unpriced serving is counted
+	assert.Equal(t, "50g + 1杯", list.Items[1].Quantity)
+	assert.InDelta(t, 1.5, *list.Items[1].EstimatedCost, 0.001)
+	assert.Equal(t, 1, list.Items[1].Unpriced)
+
+	assert.Equal(t, "鸡胸肉", list.Items[2].Name)
+	assert.Equal(t, "350g", list.Items[2].Quantity)
+
+	assert.InDelta(t, 28.5, *list.EstimatedCost, 0.001)
+	assert.Equal(t, 1, list.Unpriced)
+}
+
+func TestBuildShoppingList_WithoutPrices(t *testing.T) {
+	plan := &model.NutritionPlan{PlanData: model.JSONMap{"days": []interface{}{
+		map[string]interface{}{"meals": map[string]interface{}{
+			"lunch": map[string]interface{}{"foods": []interface{}{
+				map[string]interface{}{"name": "米饭", "amount": "150g"},
+			}},
+		}},
+	}}}
+
+	list := buildShoppingList(datedNutritionDays(plan))
+	require.Len(t, list.Items, 1)
+	assert.Nil(t, list.EstimatedCost)
+	assert.Nil(t, list.Items[0].EstimatedCost)
+	assert.Equal(t, 1, list.Unpriced)
+}
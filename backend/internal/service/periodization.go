@@ -0,0 +1,179 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// Periodization styles of a training plan
+const (
+	// PeriodizationLinear raises the load a little every week
+	PeriodizationLinear = "linear"
+	// PeriodizationUndulating varies reps and intensity between the
+	// sessions of each week
+	PeriodizationUndulating = "undulating"
+	// PeriodizationBlock moves from a volume phase through an intensity
+	// phase to a peaking phase
+	PeriodizationBlock = "block"
+)
+
+// defaultDeloadEvery is the deload interval used when deload weeks are asked
+// for without one; rule-based plans use it too
+const defaultDeloadEvery = 4
+
+// deloadMinDrop is how much lighter than the week before a deload week must
+// be, measured like the progression audit
+const deloadMinDrop = 0.1
+
+// periodizationStyleInstructions tells the AI how to sequence the weeks of
+// each style
+var periodizationStyleInstructions = map[string]string{
+	PeriodizationLinear:     "Linear: keep the exercises and rep ranges stable and raise the load or reps a little every week.",
+	PeriodizationUndulating: "Undulating: alternate heavy low-rep, moderate and light high-rep sessions within each week, and progress each kind of session from week to week.",
+	PeriodizationBlock:      "Block: spend the first third of the plan on higher-volume accumulation, the second third on heavier intensification with fewer reps, and the last third on low-volume peaking at the heaviest loads.",
+}
+
+// Periodization is how the weeks of a plan are sequenced. It is stored with
+// the plan data, so adjusted versions of the plan keep it.
+type Periodization struct {
+	Style string `json:"style,omitempty"`
+	// DeloadEvery makes every DeloadEvery-th week a lighter deload week;
+	// 0 for none
+	DeloadEvery int `json:"deload_every,omitempty"`
+}
+
+// newPeriodization reads the periodization of a generation request, or nil
+// when none was asked for
+func newPeriodization(req *GeneratePlanRequest) *Periodization {
+	p := &Periodization{Style: req.PeriodizationStyle}
+	if req.IncludeDeload {
+		p.DeloadEvery = req.DeloadEvery
+		if p.DeloadEvery == 0 {
+			p.DeloadEvery = defaultDeloadEvery
+		}
+	}
+	if p.Style == "" && p.DeloadEvery == 0 {
+		return nil
+	}
+	return p
+}
+
+// planPeriodization reads the periodization stored with plan data, or nil
+func planPeriodization(planData model.JSONMap) *Periodization {
+	stored, ok := planData["periodization"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	p := &Periodization{}
+	p.Style, _ = stored["style"].(string)
+	p.DeloadEvery = jsonInt(stored["deload_every"])
+	if p.Style == "" && p.DeloadEvery <= 0 {
+		return nil
+	}
+	return p
+}
+
+// record stores the periodization with plan data
+func (p *Periodization) record(planData model.JSONMap) {
+	stored := map[string]interface{}{}
+	if p.Style != "" {
+		stored["style"] = p.Style
+	}
+	if p.DeloadEvery > 0 {
+		stored["deload_every"] = p.DeloadEvery
+	}
+	planData["periodization"] = stored
+}
+
+// DeloadWeeks lists the 1-based deload weeks of a plan of totalWeeks weeks.
+// The last week is never a deload, so a plan ends on its hardest week.
+func (p *Periodization) DeloadWeeks(totalWeeks int) []int {
+	if p == nil || p.DeloadEvery <= 0 {
+		return nil
+	}
+	var weeks []int
+	for w := p.DeloadEvery; w < totalWeeks; w += p.DeloadEvery {
+		weeks = append(weeks, w)
+	}
+	return weeks
+}
+
+// periodizationPromptSection renders a plan's periodization as a prompt
+// section, or "" when it asks for nothing the plan can honour
+func periodizationPromptSection(p *Periodization, totalWeeks int) string {
+	if p == nil {
+		return ""
+	}
+	instruction := periodizationStyleInstructions[p.Style]
+	deloads := p.DeloadWeeks(totalWeeks)
+	if instruction == "" && len(deloads) == 0 {
+		return ""
+	}
+
+	section := "\nPeriodization:\n"
+	if instruction != "" {
+		section += "- " + instruction + "\n"
+	}
+	if len(deloads) > 0 {
+		weeks := make([]string, 0, len(deloads))
+		for _, w := range deloads {
+			weeks = append(weeks, fmt.Sprint(w))
+		}
+		section += fmt.Sprintf("- Deload weeks: %s. In each, keep the same exercises but cut the sets by about a third and the loads by about 10%% from the week before, then resume progressing from the week before the deload. Set \"deload\": true on these weeks and false on every other week.\n", strings.Join(weeks, ", "))
+	}
+	return section
+}
+
+// deloadViolations lists how plan data departs from the deload schedule:
+// deload weeks that are not marked or not clearly lighter than the week
+// before, and other weeks marked as deloads. Weeks the plan does not have are
+// not reported.
+func deloadViolations(planData model.JSONMap, p *Periodization, totalWeeks int) []string {
+	deloads := p.DeloadWeeks(totalWeeks)
+	if len(deloads) == 0 {
+		return nil
+	}
+	isDeload := make(map[int]bool, len(deloads))
+	for _, w := range deloads {
+		isDeload[w] = true
+	}
+
+	var violations []string
+	weeks, _ := planData["weeks"].([]interface{})
+	loads := planWeekLoads(planData)
+	for i, w := range weeks {
+		week, ok := w.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		number := i + 1
+		marked, _ := week["deload"].(bool)
+		switch {
+		case isDeload[number] && !marked:
+			violations = append(violations, fmt.Sprintf("week %d is not marked as a deload", number))
+		case !isDeload[number] && marked:
+			violations = append(violations, fmt.Sprintf("week %d is marked as a deload but is not one", number))
+		}
+		if isDeload[number] && i > 0 && i < len(loads) {
+			change := weekLoadChange(loads[i-1], loads[i])
+			switch {
+			case change >= 0:
+				violations = append(violations, fmt.Sprintf("deload week %d is not lighter than week %d", number, number-1))
+			case change > -deloadMinDrop:
+				violations = append(violations, fmt.Sprintf("deload week %d is only %.0f%% lighter than week %d", number, -change*100, number-1))
+			}
+		}
+	}
+	return violations
+}
+
+// deloadViolationError summarises deload violations for a retry
+func deloadViolationError(violations []string) error {
+	shown := violations
+	if len(shown) > maxReportedViolations {
+		shown = shown[:maxReportedViolations]
+	}
+	return fmt.Errorf("plan does not follow the deload schedule: %s", strings.Join(shown, "; "))
+}
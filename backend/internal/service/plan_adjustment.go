@@ -0,0 +1,546 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/pkg/taskqueue"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+// adjustmentRecordWindowDays is how far back records are summarised for an
+// adjustment prompt
+const adjustmentRecordWindowDays = 14
+
+// AdjustPlanRequest holds the user's feedback for adjusting a training plan
+type AdjustPlanRequest struct {
+	Feedback         string `json:"feedback" validate:"max=1000"`
+	DifficultyRating *int   `json:"difficulty_rating" validate:"omitempty,min=1,max=5"`
+	InjuryReport     string `json:"injury_report" validate:"max=1000"`
+	AIAPIID          *int64 `json:"ai_api_id"` // Optional, uses default if not provided
+}
+
+// AdjustNutritionPlanRequest holds the user's feedback for adjusting a
+// nutrition plan
+type AdjustNutritionPlanRequest struct {
+	Feedback           string   `json:"feedback" validate:"max=1000"`
+	SatisfactionRating *int     `json:"satisfaction_rating" validate:"omitempty,min=1,max=5"`
+	WeightChange       *float64 `json:"weight_change"` // kg, derived from body data if not provided
+	AIAPIID            *int64   `json:"ai_api_id"`
+}
+
+// RegenerateNutritionDayRequest holds the user's feedback on the day of a
+// nutrition plan being regenerated
+type RegenerateNutritionDayRequest struct {
+	Feedback string `json:"feedback" validate:"max=1000"`
+	AIAPIID  *int64 `json:"ai_api_id"` // Optional, uses default if not provided
+}
+
+// resolveAIAPIID returns the requested AI API after checking it belongs to
+// the user, or the user's default API when none is requested
+func resolveAIAPIID(ctx context.Context, aiAPIRepo repository.AIAPIRepository, userID int64, requested *int64) (int64, error) {
+	if requested != nil {
+		api, err := aiAPIRepo.GetByID(ctx, *requested)
+		if err != nil {
+			return 0, errors.Wrap(err, errors.ErrDatabase, "获取AI API失败")
+		}
+		if api == nil || api.UserID != userID {
+			return 0, errors.New(errors.ErrNotFound, "AI API不存在")
+		}
+		return api.ID, nil
+	}
+
+	defaultAPI, err := aiAPIRepo.GetDefaultByUser(ctx, userID)
+	if err != nil {
+		return 0, errors.Wrap(err, errors.ErrDatabase, "获取默认AI API失败")
+	}
+	if defaultAPI == nil {
+		return 0, errors.ErrNoDefaultAIAPI
+	}
+	return defaultAPI.ID, nil
+}
+
+// AdjustPlan generates a revised version of one of the user's training plans
+// asynchronously. The new plan links to the original, which is deactivated
+// once the new one is saved.
+func (s *trainingService) AdjustPlan(ctx context.Context, userID, planID int64, req *AdjustPlanRequest) (*TaskResponse, error) {
+	plan, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	aiAPIID, err := resolveAIAPIID(ctx, s.aiAPIRepo, userID, req.AIAPIID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.enqueueTask(ctx, userID, taskTypeAdjustTrainingPlan, "训练计划调整任务已创建", func(taskID string) interface{} {
+		return &adjustTrainingTask{TaskID: taskID, UserID: userID, AIAPIID: aiAPIID, PlanID: plan.ID, Request: req}
+	})
+}
+
+// runAdjustPlan runs a queued plan adjustment
+func (s *trainingService) runAdjustPlan(ctx context.Context, task *taskqueue.Task) error {
+	var payload adjustTrainingTask
+	if err := task.Decode(&payload); err != nil {
+		return fmt.Errorf("invalid training adjustment task: %v: %w", err, taskqueue.ErrSkipRetry)
+	}
+	s.registerTask(payload.TaskID, payload.UserID, task.EnqueuedAt, queuedFrom(task))
+
+	plan, err := s.GetPlanDetail(ctx, payload.PlanID, payload.UserID)
+	if err != nil {
+		return s.finishAttempt(ctx, task, payload.TaskID, fmt.Errorf("获取训练计划失败: %w", err))
+	}
+	err = s.processAdjustPlan(ctx, payload.UserID, plan, payload.Request, payload.AIAPIID, payload.TaskID)
+	return s.finishAttempt(ctx, task, payload.TaskID, err)
+}
+
+// processAdjustPlan generates and saves the adjusted plan, updating the task
+// as it goes
+func (s *trainingService) processAdjustPlan(ctx context.Context, userID int64, plan *model.TrainingPlan, req *AdjustPlanRequest, aiAPIID int64, taskID string) error {
+	now := time.Now()
+
+	s.updateTaskStatus(taskID, TaskStatusProcessing, 10, "正在收集训练记录...", "", nil)
+
+	since := now.AddDate(0, 0, -adjustmentRecordWindowDays)
+	if plan.StartDate.Before(since) {
+		since = plan.StartDate
+	}
+	records, err := s.recordRepo.ListByUser(ctx, userID, &since, &now)
+	if err != nil {
+		return fmt.Errorf("获取训练记录失败: %w", err)
+	}
+
+	latestCheckIn, err := s.checkInRepo.GetLatestByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("获取每周签到失败: %w", err)
+	}
+
+	constraints, err := s.constraintRepo.ListByUser(ctx, userID, &now)
+	if err != nil {
+		return fmt.Errorf("获取训练限制失败: %w", err)
+	}
+	constraints = constraintsForPlan(constraints, now, now.AddDate(0, 0, plan.TotalWeeks*7))
+
+	// The adjusted days must still fit the time the user has
+	assessment, err := s.assessmentRepo.GetLatest(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("获取用户评估数据失败: %w", err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("获取用户信息失败: %w", err)
+	}
+	busy := userBusySchedule(user)
+
+	s.updateTaskStatus(taskID, TaskStatusProcessing, 50, "正在调用AI调整训练计划...", "", nil)
+
+	var aiResponse string
+	params := &TrainingAdjustmentParams{
+		UserID:         userID,
+		AIAPIID:        aiAPIID,
+		Plan:           plan,
+		StartDate:      now,
+		CompletionRate: trainingCompletionRate(plan, records, now),
+		InjuryReport:   req.InjuryReport,
+		Feedback:       req.Feedback,
+		RecentRecords:  trainingRecordLines(records, now.AddDate(0, 0, -adjustmentRecordWindowDays)),
+		LatestCheckIn:  recentCheckIn(latestCheckIn, now),
+		Constraints:    constraints,
+		BusySchedule:   busy,
+		Progressions:   exerciseProgressions(records),
+		OnCooldown: func(cooldown *ProviderCooldown) {
+			s.setTaskCooldown(taskID, cooldown)
+		},
+		OnResponse: func(response string) {
+			aiResponse = response
+		},
+	}
+	if req.DifficultyRating != nil {
+		params.DifficultyRating = *req.DifficultyRating
+	}
+
+	adjusted, err := s.aiService.AdjustTrainingPlan(ctx, params)
+	if err != nil {
+		return fmt.Errorf("AI调整计划失败: %w", err)
+	}
+
+	s.updateTaskStatus(taskID, TaskStatusProcessing, 80, "正在保存调整后的训练计划...", "", nil)
+	enforceBusyDays(adjusted.PlanData, busy, dayStart(now))
+	overlaySafetyNotes(adjusted.PlanData)
+	normalizeExerciseGroups(adjusted.PlanData)
+	if assessment != nil {
+		fitPlanToTime(adjusted.PlanData, assessment.DailyAvailableMinutes)
+	}
+	message := recordProgressionAudit(adjusted, "训练计划调整完成")
+
+	if err := s.planRepo.Create(ctx, adjusted); err != nil {
+		return fmt.Errorf("保存计划失败: %w", err)
+	}
+	publishTrainingPlanCreated(ctx, adjusted, planSourceAdjusted, &plan.ID)
+	s.archiveResponse(taskID, taskTypeAdjustTrainingPlan, adjusted, aiResponse)
+
+	// The adjusted plan replaces the original on the user's schedule
+	plan.Status = "inactive"
+	if err := s.planRepo.Update(ctx, plan); err != nil {
+		logger.Warn("Failed to deactivate adjusted training plan",
+			zap.Int64("plan_id", plan.ID),
+			zap.Int64("adjusted_plan_id", adjusted.ID),
+			zap.Error(err),
+		)
+	}
+
+	s.updateTaskStatus(taskID, TaskStatusCompleted, 100, message, "", adjusted)
+	return nil
+}
+
+// trainingCompletionRate returns the percentage of the plan's workout days
+// up to now that have a training record on their date. Today counts only
+// once it has been logged. A plan with no elapsed workout days scores 0.
+func trainingCompletionRate(plan *model.TrainingPlan, records []*model.TrainingRecord, now time.Time) float64 {
+	logged := make(map[string]bool, len(records))
+	for _, r := range records {
+		logged[r.WorkoutDate.Format("2006-01-02")] = true
+	}
+
+	today := now.Format("2006-01-02")
+	scheduled, completed := 0, 0
+	weeks, _ := plan.PlanData["weeks"].([]interface{})
+	for _, w := range weeks {
+		week, _ := w.(map[string]interface{})
+		days, _ := week["days"].([]interface{})
+		for _, d := range days {
+			day, _ := d.(map[string]interface{})
+			date, _ := day["date"].(string)
+			if date == "" || date > today || day["type"] == "rest" {
+				continue
+			}
+			if date == today && !logged[date] {
+				continue
+			}
+			scheduled++
+			if logged[date] {
+				completed++
+			}
+		}
+	}
+
+	if scheduled == 0 {
+		return 0
+	}
+	return float64(completed) * 100 / float64(scheduled)
+}
+
+// trainingRecordLines summarises the records on or after since, oldest first
+func trainingRecordLines(records []*model.TrainingRecord, since time.Time) []string {
+	sorted := make([]*model.TrainingRecord, 0, len(records))
+	for _, r := range records {
+		if !r.WorkoutDate.Before(dayStart(since)) {
+			sorted = append(sorted, r)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].WorkoutDate.Before(sorted[j].WorkoutDate) })
+
+	lines := make([]string, 0, len(sorted))
+	for _, r := range sorted {
+		parts := []string{r.WorkoutDate.Format("2006-01-02"), r.WorkoutType}
+		if r.DurationMinutes != nil {
+			parts = append(parts, fmt.Sprintf("%d分钟", *r.DurationMinutes))
+		}
+		if r.Rating != nil {
+			parts = append(parts, fmt.Sprintf("评分%d/5", *r.Rating))
+		}
+		if r.InjuryReport != nil && *r.InjuryReport != "" {
+			parts = append(parts, "伤病: "+*r.InjuryReport)
+		}
+		if r.Notes != nil && *r.Notes != "" {
+			parts = append(parts, "备注: "+*r.Notes)
+		}
+		lines = append(lines, strings.Join(parts, " "))
+	}
+	return lines
+}
+
+// AdjustPlan generates a revised version of one of the user's nutrition
+// plans asynchronously. The new plan links to the original, which is
+// deactivated once the new one is saved.
+func (s *nutritionService) AdjustPlan(ctx context.Context, userID, planID int64, req *AdjustNutritionPlanRequest) (*TaskResponse, error) {
+	plan, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	aiAPIID, err := resolveAIAPIID(ctx, s.aiAPIRepo, userID, req.AIAPIID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.enqueueTask(ctx, userID, taskTypeAdjustNutritionPlan, "饮食计划调整任务已创建", func(taskID string) interface{} {
+		return &adjustNutritionTask{TaskID: taskID, UserID: userID, AIAPIID: aiAPIID, PlanID: plan.ID, Request: req}
+	})
+}
+
+// runAdjustPlan runs a queued plan adjustment
+func (s *nutritionService) runAdjustPlan(ctx context.Context, task *taskqueue.Task) error {
+	var payload adjustNutritionTask
+	if err := task.Decode(&payload); err != nil {
+		return fmt.Errorf("invalid nutrition adjustment task: %v: %w", err, taskqueue.ErrSkipRetry)
+	}
+	s.registerTask(payload.TaskID, payload.UserID, task.EnqueuedAt, queuedFrom(task))
+
+	plan, err := s.GetPlanDetail(ctx, payload.PlanID, payload.UserID)
+	if err != nil {
+		return s.finishAttempt(ctx, task, payload.TaskID, fmt.Errorf("获取饮食计划失败: %w", err))
+	}
+	err = s.processAdjustPlan(ctx, payload.UserID, plan, payload.Request, payload.AIAPIID, payload.TaskID)
+	return s.finishAttempt(ctx, task, payload.TaskID, err)
+}
+
+// processAdjustPlan generates and saves the adjusted plan, updating the task
+// as it goes
+func (s *nutritionService) processAdjustPlan(ctx context.Context, userID int64, plan *model.NutritionPlan, req *AdjustNutritionPlanRequest, aiAPIID int64, taskID string) error {
+	now := time.Now()
+
+	s.updateTaskStatus(taskID, TaskStatusProcessing, 10, "正在收集饮食记录...", "", nil)
+
+	since := now.AddDate(0, 0, -adjustmentRecordWindowDays)
+	if plan.StartDate.Before(since) {
+		since = plan.StartDate
+	}
+	records, err := s.recordRepo.ListByUser(ctx, userID, &since, &now)
+	if err != nil {
+		return fmt.Errorf("获取饮食记录失败: %w", err)
+	}
+
+	latestCheckIn, err := s.checkInRepo.GetLatestByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("获取每周签到失败: %w", err)
+	}
+
+	weightChange := req.WeightChange
+	if weightChange == nil {
+		measurements, err := s.bodyDataRepo.GetByUserIDSince(ctx, userID, plan.StartDate)
+		if err != nil {
+			return fmt.Errorf("获取身体数据失败: %w", err)
+		}
+		if n := len(measurements); n >= 2 {
+			change := measurements[n-1].Weight - measurements[0].Weight
+			weightChange = &change
+		}
+	}
+
+	s.updateTaskStatus(taskID, TaskStatusProcessing, 50, "正在调用AI调整饮食计划...", "", nil)
+
+	var aiResponse string
+	params := &NutritionAdjustmentParams{
+		UserID:         userID,
+		AIAPIID:        aiAPIID,
+		Plan:           plan,
+		StartDate:      now,
+		CompletionRate: nutritionCompletionRate(plan, records, now),
+		WeightChange:   weightChange,
+		Feedback:       req.Feedback,
+		RecentRecords:  nutritionRecordLines(records, now.AddDate(0, 0, -adjustmentRecordWindowDays)),
+		LatestCheckIn:  recentCheckIn(latestCheckIn, now),
+		OnCooldown: func(cooldown *ProviderCooldown) {
+			s.setTaskCooldown(taskID, cooldown)
+		},
+		OnResponse: func(response string) {
+			aiResponse = response
+		},
+	}
+	if req.SatisfactionRating != nil {
+		params.SatisfactionRating = *req.SatisfactionRating
+	}
+
+	adjusted, err := s.aiService.AdjustNutritionPlan(ctx, params)
+	if err != nil {
+		return fmt.Errorf("AI调整计划失败: %w", err)
+	}
+
+	s.updateTaskStatus(taskID, TaskStatusProcessing, 80, "正在保存调整后的饮食计划...", "", nil)
+
+	if err := s.planRepo.Create(ctx, adjusted); err != nil {
+		return fmt.Errorf("保存计划失败: %w", err)
+	}
+	publishNutritionPlanCreated(ctx, adjusted, planSourceAdjusted, &plan.ID)
+	s.archiveResponse(taskID, taskTypeAdjustNutritionPlan, adjusted, aiResponse)
+
+	plan.Status = "inactive"
+	if err := s.planRepo.Update(ctx, plan); err != nil {
+		logger.Warn("Failed to deactivate adjusted nutrition plan",
+			zap.Int64("plan_id", plan.ID),
+			zap.Int64("adjusted_plan_id", adjusted.ID),
+			zap.Error(err),
+		)
+	}
+
+	s.updateTaskStatus(taskID, TaskStatusCompleted, 100, "饮食计划调整完成", "", adjusted)
+	return nil
+}
+
+// RegenerateDay asks the AI for new meals for one date of the user's plan
+// and saves them in place of that day. The rest of the plan is unchanged.
+func (s *nutritionService) RegenerateDay(ctx context.Context, userID, planID int64, date time.Time, req *RegenerateNutritionDayRequest) (model.JSONMap, error) {
+	plan, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	days, index, dayNumber, err := nutritionPlanDay(plan, date)
+	if err != nil {
+		return nil, err
+	}
+
+	aiAPIID, err := resolveAIAPIID(ctx, s.aiAPIRepo, userID, req.AIAPIID)
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := s.aiService.RegenerateNutritionDay(ctx, &NutritionDayParams{
+		UserID:     userID,
+		AIAPIID:    aiAPIID,
+		Plan:       plan,
+		Date:       date,
+		DayNumber:  dayNumber,
+		CurrentDay: days[index].(map[string]interface{}),
+		Feedback:   req.Feedback,
+	})
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return nil, appErr
+		}
+		logger.Error("Nutrition day regeneration failed",
+			zap.Int64("plan_id", plan.ID),
+			zap.String("date", date.Format("2006-01-02")),
+			zap.Error(err),
+		)
+		return nil, errors.Wrap(err, errors.ErrExternalService, "AI重新生成当日饮食失败，请稍后重试")
+	}
+
+	original := days[index].(map[string]interface{})
+	days[index] = map[string]interface{}(day)
+	if plan.LeftoverLunch {
+		applyLeftoverLunches(days, index, original)
+	}
+	plan.PlanData["days"] = days
+	if err := s.planRepo.Update(ctx, plan); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "保存饮食计划失败")
+	}
+	return day, nil
+}
+
+// nutritionPlanDay finds date in the plan data. It returns the plan's days,
+// the index of date's day among them and its 1-based number in the plan.
+// Days are matched on the date the AI wrote, falling back to the day number
+// for plans whose dates are missing or wrong.
+func nutritionPlanDay(plan *model.NutritionPlan, date time.Time) ([]interface{}, int, int, error) {
+	start := dayStart(plan.StartDate)
+	// Compare calendar dates in the location the plan's dates were read in
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, start.Location())
+	if date.Before(start) || !date.Before(dayStart(plan.EndDate)) {
+		return nil, 0, 0, errors.New(errors.ErrInvalidParam, "日期不在计划范围内")
+	}
+	dayNumber := int(date.Sub(start).Hours()/24+0.5) + 1
+
+	days, _ := plan.PlanData["days"].([]interface{})
+	dateStr := date.Format("2006-01-02")
+	byNumber := -1
+	for i, raw := range days {
+		day, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if d, _ := day["date"].(string); d == dateStr {
+			return days, i, dayNumber, nil
+		}
+		if n, _ := day["day"].(float64); int(n) == dayNumber && byNumber < 0 {
+			byNumber = i
+		}
+	}
+	if byNumber < 0 {
+		return nil, 0, 0, errors.New(errors.ErrNotFound, "计划中没有该日期的饮食安排")
+	}
+	return days, byNumber, dayNumber, nil
+}
+
+// nutritionCompletionRate returns the percentage of the plan's elapsed days
+// with at least one meal logged. Today counts only once a meal is logged.
+func nutritionCompletionRate(plan *model.NutritionPlan, records []*model.NutritionRecord, now time.Time) float64 {
+	logged := make(map[string]bool, len(records))
+	for _, r := range records {
+		logged[r.MealDate.Format("2006-01-02")] = true
+	}
+
+	today := dayStart(now)
+	end := dayStart(plan.EndDate)
+	elapsed, completed := 0, 0
+	for day := dayStart(plan.StartDate); day.Before(end) && !day.After(today); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		if day.Equal(today) && !logged[date] {
+			continue
+		}
+		elapsed++
+		if logged[date] {
+			completed++
+		}
+	}
+
+	if elapsed == 0 {
+		return 0
+	}
+	return float64(completed) * 100 / float64(elapsed)
+}
+
+// nutritionRecordLines summarises the records on or after since as one
+// line of daily totals per day, oldest first
+func nutritionRecordLines(records []*model.NutritionRecord, since time.Time) []string {
+	type dayTotals struct {
+		meals                         int
+		calories, protein, carbs, fat float64
+	}
+	totals := make(map[string]*dayTotals)
+	for _, r := range records {
+		if r.MealDate.Before(dayStart(since)) {
+			continue
+		}
+		date := r.MealDate.Format("2006-01-02")
+		t, ok := totals[date]
+		if !ok {
+			t = &dayTotals{}
+			totals[date] = t
+		}
+		t.meals++
+		t.calories += r.Calories
+		t.protein += r.Protein
+		t.carbs += r.Carbs
+		t.fat += r.Fat
+	}
+
+	dates := make([]string, 0, len(totals))
+	for date := range totals {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	lines := make([]string, 0, len(dates))
+	for _, date := range dates {
+		t := totals[date]
+		lines = append(lines, fmt.Sprintf("%s %d餐 %.0f千卡 蛋白质%.0fg 碳水%.0fg 脂肪%.0fg",
+			date, t.meals, t.calories, t.protein, t.carbs, t.fat))
+	}
+	return lines
+}
+
+// dayStart returns midnight at the start of t's day
+func dayStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
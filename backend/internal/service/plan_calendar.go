@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// icsLineLimit is the longest content line RFC 5545 allows, in octets,
+// before it has to be folded
+const icsLineLimit = 75
+
+// calendarDayTypes names training day types in event summaries
+var calendarDayTypes = map[string]string{
+	"strength": "力量训练",
+	"cardio":   "有氧训练",
+}
+
+// ExportCalendar renders the training days of one of the user's plans as
+// an iCalendar file with one all-day event per day, which calendar apps can
+// import. Rest days are left out.
+func (s *trainingService) ExportCalendar(ctx context.Context, userID, planID int64) ([]byte, error) {
+	plan, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stamp := plan.UpdatedAt
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	dtstamp := stamp.UTC().Format("20060102T150405Z")
+
+	var b strings.Builder
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//AI Fitness Planner//Training Plan//ZH")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "METHOD:PUBLISH")
+	writeICSLine(&b, "X-WR-CALNAME:"+escapeICSText(plan.PlanName))
+	for _, day := range datedPlanDays(plan) {
+		if day.Type == "rest" || (day.Type == "" && len(day.Exercises) == 0) {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", day.Date, time.Local)
+		if err != nil {
+			continue
+		}
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, fmt.Sprintf("UID:training-plan-%d-%s@ai-fitness-planner", plan.ID, date.Format("20060102")))
+		writeICSLine(&b, "DTSTAMP:"+dtstamp)
+		writeICSLine(&b, "DTSTART;VALUE=DATE:"+date.Format("20060102"))
+		writeICSLine(&b, "DTEND;VALUE=DATE:"+date.AddDate(0, 0, 1).Format("20060102"))
+		writeICSLine(&b, "SUMMARY:"+escapeICSText(calendarSummary(day)))
+		if description := calendarDescription(day); description != "" {
+			writeICSLine(&b, "DESCRIPTION:"+escapeICSText(description))
+		}
+		writeICSLine(&b, "END:VEVENT")
+	}
+	writeICSLine(&b, "END:VCALENDAR")
+	return []byte(b.String()), nil
+}
+
+// calendarSummary titles a training day, e.g. "力量训练 · upper_body（60分钟）"
+func calendarSummary(day *model.DayPlan) string {
+	summary := calendarDayTypes[day.Type]
+	if summary == "" {
+		summary = "训练"
+	}
+	if day.FocusArea != "" {
+		summary += " · " + day.FocusArea
+	}
+	if day.Duration > 0 {
+		summary += fmt.Sprintf("（%d分钟）", day.Duration)
+	}
+	return summary
+}
+
+// calendarDescription lists a training day's exercises, one per line
+func calendarDescription(day *model.DayPlan) string {
+	lines := make([]string, 0, len(day.Exercises))
+	for _, ex := range day.Exercises {
+		lines = append(lines, exerciseLine(ex))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exerciseLine describes an exercise in one line, e.g.
+// "杠铃卧推 4×8-10 60kg，休息90s"
+func exerciseLine(ex model.Exercise) string {
+	line := ex.Name
+	if ex.Sets > 0 && ex.Reps != "" {
+		line += fmt.Sprintf(" %d×%s", ex.Sets, ex.Reps)
+	}
+	if ex.Weight != "" {
+		line += " " + ex.Weight
+	}
+	if ex.Rest != "" {
+		line += "，休息" + ex.Rest
+	}
+	return line
+}
+
+// escapeICSText escapes a TEXT property value
+func escapeICSText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// writeICSLine writes a content line, folding it into continuation lines
+// of at most icsLineLimit octets without splitting a UTF-8 character
+func writeICSLine(b *strings.Builder, line string) {
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space that counts toward the limit
+		limit = icsLineLimit - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// ClonePlanRequest says when a cloned plan starts and, optionally, what it
+// is called; by default it keeps the original's name
+type ClonePlanRequest struct {
+	StartDate time.Time
+	PlanName  *string
+}
+
+// ClonePlan copies one of the user's plans to start on req.StartDate. Every
+// dated day keeps its distance from the start, so the copy runs as long as
+// the original, and is then moved off the user's current busy days. The copy
+// is a new active plan; nothing is generated by the AI.
+func (s *trainingService) ClonePlan(ctx context.Context, userID, planID int64, req *ClonePlanRequest) (*model.TrainingPlan, error) {
+	original, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := dayStart(req.StartDate)
+	if start.Before(dayStart(time.Now())) {
+		return nil, errors.New(errors.ErrInvalidParam, "开始日期不能早于今天")
+	}
+	name := original.PlanName
+	if req.PlanName != nil {
+		name = strings.TrimSpace(*req.PlanName)
+		if name == "" {
+			return nil, errors.New(errors.ErrInvalidParam, "计划名称不能为空")
+		}
+	}
+
+	planData, err := copyPlanData(original.PlanData)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "复制训练计划失败")
+	}
+	days := int(math.Round(start.Sub(dayStart(original.StartDate)).Hours() / 24))
+	shiftPlanDays(planData, time.Time{}, days)
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取用户信息失败")
+	}
+	enforceBusyDays(planData, userBusySchedule(user), start)
+
+	now := time.Now()
+	plan := &model.TrainingPlan{
+		UserID:          userID,
+		PlanName:        name,
+		StartDate:       start,
+		EndDate:         dayStart(original.EndDate).AddDate(0, 0, days),
+		TotalWeeks:      original.TotalWeeks,
+		DifficultyLevel: original.DifficultyLevel,
+		TrainingPurpose: original.TrainingPurpose,
+		AIAPIID:         original.AIAPIID,
+		AIProvider:      original.AIProvider,
+		PlanData:        planData,
+		Status:          "active",
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := s.planRepo.Create(ctx, plan); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "保存训练计划失败")
+	}
+	publishTrainingPlanCreated(ctx, plan, planSourceCloned, &original.ID)
+	return plan, nil
+}
+
+// copyPlanData returns a deep copy of plan data, so the copy's days can be
+// changed without touching the original
+func copyPlanData(planData model.JSONMap) (model.JSONMap, error) {
+	raw, err := json.Marshal(planData)
+	if err != nil {
+		return nil, err
+	}
+	var copied model.JSONMap
+	if err := json.Unmarshal(raw, &copied); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
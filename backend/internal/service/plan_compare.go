@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// PlanWeekStats summarizes one week of a training plan
+type PlanWeekStats struct {
+	Week              int
+	TrainingDays      int
+	RestDays          int
+	Exercises         int
+	TotalSets         int
+	DurationMinutes   int
+	EstimatedCalories int
+	// Intensity counts exercises per difficulty (easy, medium, hard)
+	Intensity map[string]int
+}
+
+// PlanWeekComparison pairs the same week of two plans; either side is nil
+// when that plan has no such week
+type PlanWeekComparison struct {
+	Week int
+	A    *PlanWeekStats
+	B    *PlanWeekStats
+}
+
+// PlanComparison describes how two training plans differ
+type PlanComparison struct {
+	PlanA *model.TrainingPlan
+	PlanB *model.TrainingPlan
+	Weeks []PlanWeekComparison
+
+	SharedExercises []string
+	OnlyInA         []string
+	OnlyInB         []string
+	// ExerciseOverlap is the Jaccard index of the two plans' exercise sets
+	ExerciseOverlap float64
+}
+
+// ComparePlans compares two of the user's training plans week by week
+func (s *trainingService) ComparePlans(ctx context.Context, userID, planAID, planBID int64) (*PlanComparison, error) {
+	planA, err := s.GetPlanDetail(ctx, planAID, userID)
+	if err != nil {
+		return nil, err
+	}
+	planB, err := s.GetPlanDetail(ctx, planBID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	weeksA, exercisesA := summarizePlanData(planA.PlanData)
+	weeksB, exercisesB := summarizePlanData(planB.PlanData)
+
+	comparison := &PlanComparison{
+		PlanA: planA,
+		PlanB: planB,
+		Weeks: pairPlanWeeks(weeksA, weeksB),
+	}
+
+	for name := range exercisesA {
+		if exercisesB[name] {
+			comparison.SharedExercises = append(comparison.SharedExercises, name)
+		} else {
+			comparison.OnlyInA = append(comparison.OnlyInA, name)
+		}
+	}
+	for name := range exercisesB {
+		if !exercisesA[name] {
+			comparison.OnlyInB = append(comparison.OnlyInB, name)
+		}
+	}
+	sort.Strings(comparison.SharedExercises)
+	sort.Strings(comparison.OnlyInA)
+	sort.Strings(comparison.OnlyInB)
+
+	if union := len(comparison.SharedExercises) + len(comparison.OnlyInA) + len(comparison.OnlyInB); union > 0 {
+		comparison.ExerciseOverlap = float64(len(comparison.SharedExercises)) / float64(union)
+	}
+
+	return comparison, nil
+}
+
+// summarizePlanData computes per-week stats and the set of exercise names
+// from AI-generated plan data. Malformed entries are skipped rather than
+// failing the comparison, since the data has not been strictly validated.
+func summarizePlanData(planData model.JSONMap) ([]*PlanWeekStats, map[string]bool) {
+	exercises := make(map[string]bool)
+	weeksRaw, _ := planData["weeks"].([]interface{})
+
+	weeks := make([]*PlanWeekStats, 0, len(weeksRaw))
+	for i, weekRaw := range weeksRaw {
+		week, ok := weekRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		stats := &PlanWeekStats{
+			Week:      i + 1,
+			Intensity: map[string]int{},
+		}
+		if n := jsonInt(week["week"]); n > 0 {
+			stats.Week = n
+		}
+
+		days, _ := week["days"].([]interface{})
+		for _, dayRaw := range days {
+			day, ok := dayRaw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			dayExercises, _ := day["exercises"].([]interface{})
+			dayType, _ := day["type"].(string)
+			if dayType == "rest" || (dayType == "" && len(dayExercises) == 0) {
+				stats.RestDays++
+				continue
+			}
+			stats.TrainingDays++
+			stats.DurationMinutes += jsonInt(day["duration"])
+			stats.EstimatedCalories += jsonInt(day["estimated_calories"])
+
+			for _, exerciseRaw := range dayExercises {
+				exercise, ok := exerciseRaw.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				stats.Exercises++
+				stats.TotalSets += jsonInt(exercise["sets"])
+
+				if difficulty, _ := exercise["difficulty"].(string); difficulty != "" {
+					stats.Intensity[strings.ToLower(difficulty)]++
+				}
+				if name, _ := exercise["name"].(string); strings.TrimSpace(name) != "" {
+					exercises[normalizeExerciseName(name)] = true
+				}
+			}
+		}
+
+		weeks = append(weeks, stats)
+	}
+
+	return weeks, exercises
+}
+
+// pairPlanWeeks lines up two plans' weeks by week number
+func pairPlanWeeks(weeksA, weeksB []*PlanWeekStats) []PlanWeekComparison {
+	byWeek := make(map[int]*PlanWeekComparison)
+	var order []int
+	get := func(week int) *PlanWeekComparison {
+		pair, ok := byWeek[week]
+		if !ok {
+			pair = &PlanWeekComparison{Week: week}
+			byWeek[week] = pair
+			order = append(order, week)
+		}
+		return pair
+	}
+
+	for _, w := range weeksA {
+		get(w.Week).A = w
+	}
+	for _, w := range weeksB {
+		get(w.Week).B = w
+	}
+
+	sort.Ints(order)
+	pairs := make([]PlanWeekComparison, 0, len(order))
+	for _, week := range order {
+		pairs = append(pairs, *byWeek[week])
+	}
+	return pairs
+}
+
+// normalizeExerciseName makes exercise names comparable across plans
+func normalizeExerciseName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// jsonInt reads a JSON number that may have been decoded as float64, or
+// given by the AI as a numeric string
+func jsonInt(v interface{}) int {
+	switch n := v.(type) {
+	case float64:
+		return int(n)
+	case int:
+		return n
+	case string:
+		var i int
+		for _, r := range strings.TrimSpace(n) {
+			if r < '0' || r > '9' {
+				break
+			}
+			i = i*10 + int(r-'0')
+		}
+		return i
+	}
+	return 0
+}
+
+// jsonFloat reads a JSON number, or a numeric string such as "320" the AI
+// sometimes gives instead
+func jsonFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f
+	}
+	return 0
+}
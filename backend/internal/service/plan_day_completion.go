@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// CompletePlanDay marks date of one of the user's plans as completed,
+// linking recordID when given. Marking a day again replaces the link.
+func (s *trainingService) CompletePlanDay(ctx context.Context, userID, planID int64, date time.Time, recordID *int64) (*model.PlanDayCompletion, error) {
+	plan, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	date = dayStart(date)
+	if date.After(dayStart(time.Now())) {
+		return nil, errors.New(errors.ErrInvalidParam, "不能标记未来的训练日")
+	}
+	day := planDayOn(plan, date)
+	if day == nil {
+		return nil, errors.New(errors.ErrNotFound, "计划中没有该日期的训练安排")
+	}
+	if isRestDay(day) {
+		return nil, errors.New(errors.ErrInvalidParam, "休息日无需标记完成")
+	}
+
+	if recordID != nil {
+		record, err := s.recordRepo.GetByID(ctx, *recordID)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "获取训练记录失败")
+		}
+		if record == nil || record.UserID != userID {
+			return nil, errors.New(errors.ErrNotFound, "训练记录不存在")
+		}
+		if record.WorkoutDate.Format("2006-01-02") != date.Format("2006-01-02") {
+			return nil, errors.New(errors.ErrInvalidParam, "训练记录的日期与计划日不一致")
+		}
+	}
+
+	completion := &model.PlanDayCompletion{
+		PlanID:      plan.ID,
+		UserID:      userID,
+		PlanDate:    date,
+		RecordID:    recordID,
+		CompletedAt: time.Now(),
+	}
+	if err := s.planRepo.SaveDayCompletion(ctx, completion); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "保存完成状态失败")
+	}
+	return completion, nil
+}
+
+// planDayOn returns the day of a plan dated date, or nil if it has none
+func planDayOn(plan *model.TrainingPlan, date time.Time) map[string]interface{} {
+	dateStr := date.Format("2006-01-02")
+	weeks, _ := plan.PlanData["weeks"].([]interface{})
+	for week := 1; week <= len(weeks); week++ {
+		for i, day := range planWeekDays(plan, week) {
+			if weekDayDate(plan, week, i, day) == dateStr {
+				return day
+			}
+		}
+	}
+	return nil
+}
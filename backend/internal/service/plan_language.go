@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// translationPrompt asks for Chinese versions of plan fields, keyed as sent
+const translationPrompt = `Translate the values of the following JSON object into concise Simplified Chinese fitness terminology.
+Keep every key unchanged. Return ONLY a JSON object with the same keys, no additional text.
+
+%s`
+
+// untranslatedField is a plan text field that should be Chinese but is not
+type untranslatedField struct {
+	parent map[string]interface{}
+	key    string
+	text   string
+}
+
+// untranslatedFields returns the exercise names and safety notes of a
+// training plan that contain Latin letters and no Chinese characters
+func untranslatedFields(planData model.JSONMap) []untranslatedField {
+	var fields []untranslatedField
+	weeks, _ := planData["weeks"].([]interface{})
+	for _, w := range weeks {
+		week, _ := w.(map[string]interface{})
+		days, _ := week["days"].([]interface{})
+		for _, d := range days {
+			day, _ := d.(map[string]interface{})
+			exercises, _ := day["exercises"].([]interface{})
+			for _, e := range exercises {
+				exercise, ok := e.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				for _, key := range []string{"name", "safety_notes"} {
+					if text, _ := exercise[key].(string); needsTranslation(text) {
+						fields = append(fields, untranslatedField{parent: exercise, key: key, text: text})
+					}
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// needsTranslation reports whether text has a Latin word but no Chinese
+// characters. Mixed text such as "TRX 划船" and notation such as "3x10" are
+// left alone.
+func needsTranslation(text string) bool {
+	hasWord := false
+	run := 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			return false
+		}
+		if r <= unicode.MaxASCII && unicode.IsLetter(r) {
+			run++
+			if run >= 2 {
+				hasWord = true
+			}
+		} else {
+			run = 0
+		}
+	}
+	return hasWord
+}
+
+// containsHan reports whether text has at least one Chinese character
+func containsHan(text string) bool {
+	return strings.IndexFunc(text, func(r rune) bool { return unicode.Is(unicode.Han, r) }) >= 0
+}
+
+// repairPlanLanguage translates English exercise names and safety notes in
+// planData with one extra call to client, instead of regenerating the plan.
+// It reports whether any field was replaced. A failed repair is logged and
+// leaves the plan as it was.
+func (s *aiService) repairPlanLanguage(ctx context.Context, client AIClient, config *AIClientConfig, userID int64, planData model.JSONMap) bool {
+	fields := untranslatedFields(planData)
+	if len(fields) == 0 {
+		return false
+	}
+
+	source := make(map[string]string, len(fields))
+	for i, f := range fields {
+		source[strconv.Itoa(i)] = f.text
+	}
+	body, err := json.Marshal(source)
+	if err != nil {
+		return false
+	}
+
+	// The plan schema does not apply to the translation
+	cfg := *config
+	cfg.ResponseSchema = nil
+	response, err := client.Call(ctx, fmt.Sprintf(translationPrompt, body), &cfg)
+	if err != nil {
+		logger.Warn("Failed to translate plan fields",
+			zap.Int64("user_id", userID),
+			zap.Int("fields", len(fields)),
+			zap.Error(err),
+		)
+		return false
+	}
+
+	var translated map[string]string
+	if err := json.Unmarshal([]byte(planJSON(response)), &translated); err != nil {
+		logger.Warn("Failed to parse plan field translation",
+			zap.Int64("user_id", userID),
+			zap.Error(err),
+		)
+		return false
+	}
+
+	repaired := 0
+	for i, f := range fields {
+		if text := strings.TrimSpace(translated[strconv.Itoa(i)]); containsHan(text) {
+			f.parent[f.key] = text
+			repaired++
+		}
+	}
+	logger.Info("Translated English plan fields",
+		zap.Int64("user_id", userID),
+		zap.Int("fields", len(fields)),
+		zap.Int("repaired", repaired),
+	)
+	return repaired > 0
+}
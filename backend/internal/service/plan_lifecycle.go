@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// planTransitions lists the statuses each training plan status may change
+// to. Completed is final; an inactive plan, one replaced by an adjustment,
+// may be taken up again.
+var planTransitions = map[string][]string{
+	"active":   {"paused", "completed", "inactive"},
+	"paused":   {"active", "inactive"},
+	"inactive": {"active"},
+}
+
+// planStatusNames are the Chinese names of plan statuses for messages
+var planStatusNames = map[string]string{
+	"active":    "进行中",
+	"paused":    "已暂停",
+	"inactive":  "已停用",
+	"completed": "已完成",
+}
+
+// PausePlan pauses an active plan; it has no scheduled days until resumed
+func (s *trainingService) PausePlan(ctx context.Context, userID, planID int64) (*model.TrainingPlan, error) {
+	return s.changePlanStatus(ctx, userID, planID, "paused")
+}
+
+// ResumePlan resumes a paused plan, moving the days it missed while paused
+// to after the pause
+func (s *trainingService) ResumePlan(ctx context.Context, userID, planID int64) (*model.TrainingPlan, error) {
+	return s.changePlanStatus(ctx, userID, planID, "active")
+}
+
+// CompletePlan marks an active plan as completed
+func (s *trainingService) CompletePlan(ctx context.Context, userID, planID int64) (*model.TrainingPlan, error) {
+	return s.changePlanStatus(ctx, userID, planID, "completed")
+}
+
+// changePlanStatus moves one of the user's plans to status and saves it
+func (s *trainingService) changePlanStatus(ctx context.Context, userID, planID int64, status string) (*model.TrainingPlan, error) {
+	plan, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+	prevStatus := plan.Status
+	if err := s.transitionPlan(ctx, plan, status, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.planRepo.Update(ctx, plan); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "更新训练计划失败")
+	}
+	publishTrainingPlanStatusChanged(ctx, plan, prevStatus)
+	return plan, nil
+}
+
+// transitionPlan validates a status change and applies its side effects to
+// plan: pausing records when, resuming shifts the rest of the plan by the
+// whole days it was paused, and completing records the completion time.
+// Changing to the current status is a no-op.
+func (s *trainingService) transitionPlan(ctx context.Context, plan *model.TrainingPlan, status string, now time.Time) error {
+	if plan.Status == status {
+		return nil
+	}
+	allowed := false
+	for _, to := range planTransitions[plan.Status] {
+		if to == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return errors.New(errors.ErrConflict, fmt.Sprintf("%s的计划不能变更为%s", planStatusNames[plan.Status], planStatusNames[status]))
+	}
+
+	switch status {
+	case "paused":
+		plan.PausedAt = &now
+	case "completed":
+		plan.CompletedAt = &now
+	}
+	if plan.Status == "paused" && plan.PausedAt != nil {
+		if err := s.shiftPausedPlan(ctx, plan, now); err != nil {
+			return err
+		}
+	}
+	if status != "paused" {
+		plan.PausedAt = nil
+	}
+
+	plan.Status = status
+	plan.UpdatedAt = now
+	return nil
+}
+
+// shiftPausedPlan moves the days from the pause on by the number of days
+// the plan was paused, and then off the user's busy days again
+func (s *trainingService) shiftPausedPlan(ctx context.Context, plan *model.TrainingPlan, now time.Time) error {
+	from := dayStart(*plan.PausedAt)
+	days := int(dayStart(now).Sub(from).Hours()/24 + 0.5)
+	if days <= 0 {
+		return nil
+	}
+
+	user, err := s.userRepo.GetByID(ctx, plan.UserID)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase, "获取用户信息失败")
+	}
+
+	shiftPlanDays(plan.PlanData, from, days)
+	plan.EndDate = plan.EndDate.AddDate(0, 0, days)
+	enforceBusyDays(plan.PlanData, userBusySchedule(user), dayStart(now))
+	return nil
+}
+
+// shiftPlanDays moves every plan day dated on or after from by days
+func shiftPlanDays(planData model.JSONMap, from time.Time, days int) {
+	weeks, _ := planData["weeks"].([]interface{})
+	for _, weekRaw := range weeks {
+		week, ok := weekRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		planDays, _ := week["days"].([]interface{})
+		for _, dayRaw := range planDays {
+			day, ok := dayRaw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			date := planDayDate(day)
+			if date.IsZero() || date.Before(from) {
+				continue
+			}
+			day["date"] = date.AddDate(0, 0, days).Format("2006-01-02")
+		}
+	}
+}
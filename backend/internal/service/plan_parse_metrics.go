@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// ParseFailureKind classifies why an AI response could not be parsed into a
+// plan
+type ParseFailureKind string
+
+const (
+	// ParseFailureNonJSON is a response of prose with no JSON in it
+	ParseFailureNonJSON ParseFailureKind = "non_json"
+	// ParseFailureTruncated is JSON that was cut off before it closed,
+	// usually by the completion's token limit
+	ParseFailureTruncated ParseFailureKind = "truncated_json"
+	// ParseFailureMalformed is complete but syntactically invalid JSON
+	ParseFailureMalformed ParseFailureKind = "malformed_json"
+	// ParseFailureWrongSchema is valid JSON without the plan's structure
+	ParseFailureWrongSchema ParseFailureKind = "wrong_schema"
+	// ParseFailureEmptyPlan is a plan with no weeks or days
+	ParseFailureEmptyPlan ParseFailureKind = "empty_plan"
+	// ParseFailureConstraint is a plan that breaks a pattern the prompt
+	// required, such as dinner leftovers for the next day's lunch
+	ParseFailureConstraint ParseFailureKind = "constraint_violation"
+)
+
+// planParseError is returned by the plan response parsers
+type planParseError struct {
+	Kind ParseFailureKind
+	err  error
+}
+
+func (e *planParseError) Error() string {
+	return e.err.Error()
+}
+
+func (e *planParseError) Unwrap() error {
+	return e.err
+}
+
+// parseFailure returns a planParseError of kind with a formatted message
+func parseFailure(kind ParseFailureKind, format string, args ...interface{}) error {
+	return &planParseError{Kind: kind, err: fmt.Errorf(format, args...)}
+}
+
+// missingJSONFailure explains a response no JSON could be found in: an
+// opening brace that is never closed means the output was cut off
+func missingJSONFailure(response string) error {
+	if strings.Contains(response, "{") {
+		return parseFailure(ParseFailureTruncated, "no complete JSON found in response")
+	}
+	return parseFailure(ParseFailureNonJSON, "no valid JSON found in response")
+}
+
+// unmarshalFailure classifies a JSON decoding error for input
+func unmarshalFailure(input string, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) && syntaxErr.Offset >= int64(len(input)) {
+		return &planParseError{Kind: ParseFailureTruncated, err: fmt.Errorf("failed to unmarshal JSON: %w", err)}
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &planParseError{Kind: ParseFailureWrongSchema, err: fmt.Errorf("failed to unmarshal JSON: %w", err)}
+	}
+	return &planParseError{Kind: ParseFailureMalformed, err: fmt.Errorf("failed to unmarshal JSON: %w", err)}
+}
+
+// ParseFailureCount is the number of parse failures of one kind for a
+// provider, model and generation purpose
+type ParseFailureCount struct {
+	Provider string
+	Model    string
+	Purpose  string
+	Kind     ParseFailureKind
+	Count    int64
+}
+
+// ParseFailureMetrics counts plan parse failures so the repair strategies
+// worth investing in can be told apart
+type ParseFailureMetrics interface {
+	// Record counts one failure of kind for a response from api
+	Record(ctx context.Context, api *model.AIAPI, purpose string, kind ParseFailureKind)
+	// Counts returns every counter, ordered by provider, model, purpose and kind
+	Counts(ctx context.Context) ([]ParseFailureCount, error)
+}
+
+// parseFailureMetricsKey is the Redis hash of the counters, shared by every
+// API instance
+const parseFailureMetricsKey = "metrics:plan_parse_failures"
+
+// parseFailureFieldSeparator joins the labels of a counter's hash field
+const parseFailureFieldSeparator = "|"
+
+// redisParseFailureMetrics implements ParseFailureMetrics using Redis
+type redisParseFailureMetrics struct {
+	client *redis.Client
+}
+
+// NewParseFailureMetrics creates Redis-backed ParseFailureMetrics, or nil
+// without a client
+func NewParseFailureMetrics(client *redis.Client) ParseFailureMetrics {
+	if client == nil {
+		return nil
+	}
+	return &redisParseFailureMetrics{client: client}
+}
+
+// Record increments the counter. Redis failures are only logged.
+func (m *redisParseFailureMetrics) Record(ctx context.Context, api *model.AIAPI, purpose string, kind ParseFailureKind) {
+	modelName := ""
+	if api.Model != nil {
+		modelName = *api.Model
+	}
+	field := strings.Join([]string{api.Provider, modelName, purpose, string(kind)}, parseFailureFieldSeparator)
+	if err := m.client.HIncrBy(ctx, parseFailureMetricsKey, field, 1).Err(); err != nil {
+		logger.Warn("Failed to record plan parse failure",
+			zap.String("provider", api.Provider),
+			zap.String("kind", string(kind)),
+			zap.Error(err),
+		)
+	}
+}
+
+// Counts reads all counters
+func (m *redisParseFailureMetrics) Counts(ctx context.Context) ([]ParseFailureCount, error) {
+	fields, err := m.client.HGetAll(ctx, parseFailureMetricsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseFailureCounts(fields), nil
+}
+
+// parseFailureCounts decodes the counter hash, skipping malformed fields
+func parseFailureCounts(fields map[string]string) []ParseFailureCount {
+	counts := make([]ParseFailureCount, 0, len(fields))
+	for field, value := range fields {
+		labels := strings.SplitN(field, parseFailureFieldSeparator, 4)
+		if len(labels) != 4 {
+			continue
+		}
+		var count int64
+		if _, err := fmt.Sscan(value, &count); err != nil {
+			continue
+		}
+		counts = append(counts, ParseFailureCount{
+			Provider: labels[0],
+			Model:    labels[1],
+			Purpose:  labels[2],
+			Kind:     ParseFailureKind(labels[3]),
+			Count:    count,
+		})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		a, b := counts[i], counts[j]
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		if a.Purpose != b.Purpose {
+			return a.Purpose < b.Purpose
+		}
+		return a.Kind < b.Kind
+	})
+	return counts
+}
+
+// recordParseFailure counts a failed parse of a response from aiAPI
+func (s *aiService) recordParseFailure(ctx context.Context, aiAPI *model.AIAPI, purpose string, err error) {
+	var parseErr *planParseError
+	if s.parseMetrics == nil || !errors.As(err, &parseErr) {
+		return
+	}
+	s.parseMetrics.Record(context.WithoutCancel(ctx), aiAPI, purpose, parseErr.Kind)
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/crypto"
+	"github.com/ai-fitness-planner/backend/internal/pkg/pdf"
+)
+
+// PDFExportOptions personalises an exported plan
+type PDFExportOptions struct {
+	// Username is watermarked on every page with the export time, so a
+	// shared printout can be traced back to the account it came from
+	Username string
+	// Password, when set, encrypts the PDF so it opens only with it
+	Password string
+}
+
+// pdfFoodStyle sets the foods under a meal, between items and notes
+var pdfFoodStyle = pdf.Style{Size: 9.5, Indent: 28}
+
+var weekdayLabels = [...]string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"}
+
+var difficultyLabels = map[string]string{
+	"easy":    "简单",
+	"medium":  "中等",
+	"hard":    "困难",
+	"extreme": "极限",
+}
+
+// nutritionMealOrder lists the meals of a nutrition plan day in print order
+var nutritionMealOrder = []struct {
+	key   string
+	label string
+}{
+	{"breakfast", "早餐"},
+	{"lunch", "午餐"},
+	{"dinner", "晚餐"},
+	{"snacks", "加餐"},
+}
+
+// ExportPDF renders one of the user's training plans as a printable PDF:
+// each week's days with their exercises, loads and safety notes
+func (s *trainingService) ExportPDF(ctx context.Context, userID, planID int64, opts PDFExportOptions) ([]byte, error) {
+	if err := checkPDFPassword(opts.Password); err != nil {
+		return nil, err
+	}
+	plan, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := pdf.New(plan.PlanName)
+	doc.Write(pdf.Title, plan.PlanName)
+	doc.Write(pdf.Body, fmt.Sprintf("周期：%s 至 %s，共%d周",
+		plan.StartDate.Format("2006-01-02"), plan.EndDate.Format("2006-01-02"), plan.TotalWeeks))
+	summary := "难度：" + labelOr(difficultyLabels, plan.DifficultyLevel)
+	if plan.TrainingPurpose != nil && *plan.TrainingPurpose != "" {
+		summary += "　训练目的：" + *plan.TrainingPurpose
+	}
+	doc.Write(pdf.Body, summary)
+
+	start := dayStart(plan.StartDate)
+	week := 0
+	for _, day := range datedPlanDays(plan) {
+		date, err := time.ParseInLocation("2006-01-02", day.Date, time.Local)
+		if err != nil {
+			continue
+		}
+		if w := int(date.Sub(start).Hours()/24)/7 + 1; w != week {
+			week = w
+			doc.Write(pdf.Heading, fmt.Sprintf("第%d周", week))
+		}
+
+		heading := day.Date + " " + weekdayLabels[date.Weekday()]
+		if day.Type == "rest" || (day.Type == "" && len(day.Exercises) == 0) {
+			doc.Write(pdf.Body, heading+"　休息日")
+			continue
+		}
+		doc.Write(pdf.Subheading, heading+"　"+calendarSummary(day))
+		for i, ex := range day.Exercises {
+			doc.Write(pdf.Item, fmt.Sprintf("%d. %s", i+1, exerciseLine(ex)))
+			if notes := strings.TrimSpace(ex.SafetyNotes); notes != "" {
+				doc.Write(pdf.Note, "安全提示："+notes)
+			}
+		}
+	}
+	return renderPlanPDF(doc, userID, opts)
+}
+
+// ExportPDF renders one of the user's nutrition plans as a printable PDF:
+// each day's meals with their foods, portions and calories
+func (s *nutritionService) ExportPDF(ctx context.Context, userID, planID int64, opts PDFExportOptions) ([]byte, error) {
+	if err := checkPDFPassword(opts.Password); err != nil {
+		return nil, err
+	}
+	plan, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := pdf.New(plan.PlanName)
+	doc.Write(pdf.Title, plan.PlanName)
+	doc.Write(pdf.Body, fmt.Sprintf("周期：%s 至 %s",
+		plan.StartDate.Format("2006-01-02"), plan.EndDate.Format("2006-01-02")))
+	doc.Write(pdf.Body, fmt.Sprintf("每日目标：%.0f千卡（蛋白质%.0f%%，碳水%.0f%%，脂肪%.0f%%）",
+		plan.DailyCalories, plan.ProteinRatio*100, plan.CarbRatio*100, plan.FatRatio*100))
+
+	for _, day := range datedNutritionDays(plan) {
+		heading := day.date
+		if date, err := time.ParseInLocation("2006-01-02", day.date, time.Local); err == nil {
+			heading += " " + weekdayLabels[date.Weekday()]
+		}
+		if totals, ok := day.data["daily_totals"].(map[string]interface{}); ok {
+			if calories := jsonFloat(totals["calories"]); calories > 0 {
+				heading += fmt.Sprintf("　%.0f千卡", calories)
+			}
+		}
+		doc.Write(pdf.Heading, heading)
+
+		meals, _ := day.data["meals"].(map[string]interface{})
+		for _, m := range nutritionMealOrder {
+			meal, ok := meals[m.key].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			foods, _ := meal["foods"].([]interface{})
+			if len(foods) == 0 {
+				continue
+			}
+			title := m.label
+			if t, _ := meal["time"].(string); t != "" {
+				title += "（" + t + "）"
+			}
+			if calories := jsonFloat(meal["total_calories"]); calories > 0 {
+				title += fmt.Sprintf("　%.0f千卡", calories)
+			}
+			doc.Write(pdf.Subheading, title)
+			for _, raw := range foods {
+				if food, ok := raw.(map[string]interface{}); ok {
+					doc.Write(pdfFoodStyle, foodLine(food))
+				}
+			}
+		}
+	}
+	return renderPlanPDF(doc, userID, opts)
+}
+
+// checkPDFPassword accepts no password, or one long enough to protect the
+// plan that every PDF viewer can type in
+func checkPDFPassword(password string) error {
+	if password == "" {
+		return nil
+	}
+	if len(password) < crypto.MinPassphraseLength || pdf.CheckPassword(password) != nil {
+		return errors.New(errors.ErrInvalidParam, fmt.Sprintf("PDF密码须为%d-%d个英文字母、数字或符号",
+			crypto.MinPassphraseLength, pdf.MaxPasswordLength))
+	}
+	return nil
+}
+
+// renderPlanPDF watermarks every page with who exported the plan and when,
+// then renders it, encrypted when a password was given
+func renderPlanPDF(doc *pdf.Document, userID int64, opts PDFExportOptions) ([]byte, error) {
+	who := opts.Username
+	if who == "" {
+		who = fmt.Sprintf("用户%d", userID)
+	}
+	doc.Watermark(fmt.Sprintf("%s 导出于 %s", who, time.Now().Format("2006-01-02 15:04")))
+
+	if opts.Password == "" {
+		return doc.Bytes(), nil
+	}
+	data, err := doc.Encrypt(opts.Password)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "生成PDF失败")
+	}
+	return data, nil
+}
+
+// datedNutritionDay is a day of a nutrition plan's data with its date
+type datedNutritionDay struct {
+	date string
+	data map[string]interface{}
+}
+
+// datedNutritionDays returns the days of a nutrition plan in date order.
+// Days without a date are dated from the plan start by their day number.
+func datedNutritionDays(plan *model.NutritionPlan) []datedNutritionDay {
+	rawDays, _ := plan.PlanData["days"].([]interface{})
+	days := make([]datedNutritionDay, 0, len(rawDays))
+	for i, raw := range rawDays {
+		day, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		date, _ := day["date"].(string)
+		if date == "" {
+			offset := i
+			if n := jsonInt(day["day"]); n >= 1 {
+				offset = n - 1
+			}
+			date = plan.StartDate.AddDate(0, 0, offset).Format("2006-01-02")
+		}
+		days = append(days, datedNutritionDay{date: date, data: day})
+	}
+	sort.SliceStable(days, func(a, b int) bool {
+		return days[a].date < days[b].date
+	})
+	return days
+}
+
+// foodLine describes a food with its portion and macros, e.g.
+// "燕麦 50g　190千卡　蛋白质6.5g 碳水33g 脂肪3.5g"
+func foodLine(food map[string]interface{}) string {
+	line, _ := food["name"].(string)
+	if amount, _ := food["amount"].(string); amount != "" {
+		line += " " + amount
+	}
+	if calories := jsonFloat(food["calories"]); calories > 0 {
+		line += fmt.Sprintf("　%.0f千卡", calories)
+	}
+	var macros []string
+	for _, macro := range []struct{ key, label string }{{"protein", "蛋白质"}, {"carbs", "碳水"}, {"fat", "脂肪"}} {
+		if grams := jsonFloat(food[macro.key]); grams > 0 {
+			macros = append(macros, fmt.Sprintf("%s%sg", macro.label, formatGrams(grams)))
+		}
+	}
+	if len(macros) > 0 {
+		line += "　" + strings.Join(macros, " ")
+	}
+	return line
+}
+
+// formatGrams prints grams with at most one decimal
+func formatGrams(grams float64) string {
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", grams), ".0")
+}
+
+// labelOr returns the label of value, or value itself when it has none
+func labelOr(labels map[string]string, value string) string {
+	if label, ok := labels[value]; ok {
+		return label
+	}
+	return value
+}
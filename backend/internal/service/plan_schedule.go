@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// maxScheduleDays bounds the date range of one schedule request
+const maxScheduleDays = 92
+
+// GetSchedule returns the days of one of the user's plans dated from start
+// to end inclusive, in date order, each marked with whether the user
+// completed it. Dates the plan does not cover are left out.
+func (s *trainingService) GetSchedule(ctx context.Context, userID, planID int64, start, end time.Time) ([]*model.DayPlan, error) {
+	start, end = dayStart(start), dayStart(end)
+	if end.Before(start) {
+		return nil, errors.New(errors.ErrInvalidParam, "结束日期不能早于开始日期")
+	}
+	if end.Sub(start) >= maxScheduleDays*24*time.Hour {
+		return nil, errors.New(errors.ErrInvalidParam, "查询范围不能超过92天")
+	}
+
+	plan, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := start.Format("2006-01-02"), end.Format("2006-01-02")
+	var schedule []*model.DayPlan
+	for _, day := range datedPlanDays(plan) {
+		if day.Date >= from && day.Date <= to {
+			schedule = append(schedule, day)
+		}
+	}
+	if len(schedule) == 0 {
+		return schedule, nil
+	}
+
+	completions, err := s.planRepo.ListDayCompletions(ctx, plan.ID, start, end)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取完成记录失败")
+	}
+	completed := make(map[string]bool, len(completions))
+	for _, c := range completions {
+		completed[c.PlanDate.Format("2006-01-02")] = true
+	}
+	for _, day := range schedule {
+		day.IsCompleted = completed[day.Date]
+	}
+	return schedule, nil
+}
+
+// datedPlanDays returns every day of the plan with its date filled in, in
+// date order
+func datedPlanDays(plan *model.TrainingPlan) []*model.DayPlan {
+	var planDays []*model.DayPlan
+	weeks, _ := plan.PlanData["weeks"].([]interface{})
+	for i, weekRaw := range weeks {
+		week, ok := weekRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		number := jsonInt(week["week"])
+		if number < 1 {
+			number = i + 1
+		}
+		days, _ := week["days"].([]interface{})
+		for j, dayRaw := range days {
+			day, ok := dayRaw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			dayPlan := model.DayPlanFromData(day)
+			dayPlan.PlanID = plan.ID
+			dayPlan.Date = weekDayDate(plan, number, j, day)
+			planDays = append(planDays, dayPlan)
+		}
+	}
+	sort.SliceStable(planDays, func(a, b int) bool {
+		return planDays[a].Date < planDays[b].Date
+	})
+	return planDays
+}
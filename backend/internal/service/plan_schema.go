@@ -0,0 +1,139 @@
+package service
+
+import "sort"
+
+// ResponseSchema is a JSON Schema the provider is asked to constrain its
+// output to, via structured outputs or forced tool calling
+type ResponseSchema struct {
+	Name   string
+	Schema map[string]interface{}
+}
+
+// exerciseSchema is one exercise of a training plan day
+var exerciseSchema = strictObject(map[string]interface{}{
+	"name":         schemaType("string"),
+	"sets":         schemaType("integer"),
+	"reps":         schemaType("string"),
+	"weight":       schemaType("string"),
+	"rest":         schemaType("string"),
+	"difficulty":   schemaEnum("easy", "medium", "hard"),
+	"safety_notes": schemaType("string"),
+	"superset_id":  schemaType("string"),
+	"tempo":        schemaType("string"),
+})
+
+// trainingWeekSchema is one week of a training plan
+var trainingWeekSchema = strictObject(map[string]interface{}{
+	"week":   schemaType("integer"),
+	"deload": schemaType("boolean"),
+	"days": arrayOf(strictObject(map[string]interface{}{
+		"day":                schemaType("integer"),
+		"date":               schemaType("string"),
+		"type":               schemaEnum("strength", "cardio", "rest"),
+		"focus_area":         schemaEnum("upper_body", "lower_body", "full_body", "cardio"),
+		"exercises":          arrayOf(exerciseSchema),
+		"duration":           schemaType("integer"),
+		"estimated_calories": schemaType("number"),
+		"circuit_rounds":     schemaType("integer"),
+	})),
+})
+
+// trainingPlanSchema mirrors the structure described in the training plan prompt
+var trainingPlanSchema = &ResponseSchema{
+	Name: "training_plan",
+	Schema: strictObject(map[string]interface{}{
+		"weeks": arrayOf(trainingWeekSchema),
+	}),
+}
+
+// nutritionDaySchema is one day of a nutrition plan
+var nutritionDaySchema = func() map[string]interface{} {
+	meal := strictObject(map[string]interface{}{
+		"time": schemaType("string"),
+		"foods": arrayOf(strictObject(map[string]interface{}{
+			"name":     schemaType("string"),
+			"amount":   schemaType("string"),
+			"calories": schemaType("number"),
+			"protein":  schemaType("number"),
+			"carbs":    schemaType("number"),
+			"fat":      schemaType("number"),
+			"fiber":    schemaType("number"),
+		})),
+		"total_calories": schemaType("number"),
+	})
+
+	return strictObject(map[string]interface{}{
+		"day":  schemaType("integer"),
+		"date": schemaType("string"),
+		"meals": strictObject(map[string]interface{}{
+			"breakfast": meal,
+			"lunch":     meal,
+			"dinner":    meal,
+			"snacks":    meal,
+		}),
+		"daily_totals": strictObject(map[string]interface{}{
+			"calories": schemaType("number"),
+			"protein":  schemaType("number"),
+			"carbs":    schemaType("number"),
+			"fat":      schemaType("number"),
+		}),
+	})
+}()
+
+// nutritionPlanSchema mirrors the structure described in the nutrition plan prompt
+var nutritionPlanSchema = &ResponseSchema{
+	Name: "nutrition_plan",
+	Schema: strictObject(map[string]interface{}{
+		"days": arrayOf(nutritionDaySchema),
+	}),
+}
+
+// nutritionDayResponseSchema constrains the single day returned when one day
+// of a nutrition plan is regenerated
+var nutritionDayResponseSchema = &ResponseSchema{
+	Name:   "nutrition_day",
+	Schema: nutritionDaySchema,
+}
+
+// trainingWeekResponseSchema constrains the single week returned when one
+// week of a training plan is regenerated
+var trainingWeekResponseSchema = &ResponseSchema{
+	Name:   "training_week",
+	Schema: trainingWeekSchema,
+}
+
+// exerciseResponseSchema constrains the exercise returned when one exercise
+// of a training plan day is substituted
+var exerciseResponseSchema = &ResponseSchema{
+	Name:   "exercise",
+	Schema: exerciseSchema,
+}
+
+// strictObject builds an object schema in the form OpenAI strict mode requires:
+// every property required and no additional properties
+func strictObject(properties map[string]interface{}) map[string]interface{} {
+	required := make([]string, 0, len(properties))
+	for name := range properties {
+		required = append(required, name)
+	}
+	sort.Strings(required)
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+func arrayOf(items map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "array", "items": items}
+}
+
+func schemaType(t string) map[string]interface{} {
+	return map[string]interface{}{"type": t}
+}
+
+func schemaEnum(values ...string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "enum": values}
+}
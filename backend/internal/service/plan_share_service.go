@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/jwt"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+)
+
+// PlanShareService defines the interface for sharing training plans through
+// public read-only links
+type PlanShareService interface {
+	// SharePlan makes a signed link token for one of the user's plans,
+	// valid for ttl or the configured maximum if ttl is zero or longer
+	SharePlan(ctx context.Context, userID, planID int64, ttl time.Duration) (*PlanShare, error)
+	// GetSharedPlan returns the plan a share token grants access to
+	GetSharedPlan(ctx context.Context, token string) (*SharedPlan, error)
+}
+
+// PlanShare is a public link token for a training plan
+type PlanShare struct {
+	PlanID    int64
+	Token     string
+	ExpiresAt time.Time
+}
+
+// SharedPlan is a plan opened through a share link. Only the plan itself is
+// meant for the public; the handler leaves out its owner's data.
+type SharedPlan struct {
+	Plan      *model.TrainingPlan
+	ExpiresAt time.Time
+}
+
+// planShareService implements PlanShareService interface
+type planShareService struct {
+	planRepo   repository.TrainingPlanRepository
+	jwtManager jwt.JWTManager
+	maxTTL     time.Duration
+}
+
+// NewPlanShareService creates a new instance of PlanShareService
+func NewPlanShareService(planRepo repository.TrainingPlanRepository, jwtManager jwt.JWTManager, maxTTL time.Duration) PlanShareService {
+	return &planShareService{
+		planRepo:   planRepo,
+		jwtManager: jwtManager,
+		maxTTL:     maxTTL,
+	}
+}
+
+// SharePlan signs a link token naming the plan and its owner. Tokens are not
+// stored: a link works until it expires, the plan is deleted or it changes
+// hands.
+func (s *planShareService) SharePlan(ctx context.Context, userID, planID int64, ttl time.Duration) (*PlanShare, error) {
+	plan, err := s.planRepo.GetSummaryByID(ctx, planID)
+	if _, err := ownedTrainingPlan(plan, err, userID); err != nil {
+		return nil, err
+	}
+
+	if ttl <= 0 || ttl > s.maxTTL {
+		ttl = s.maxTTL
+	}
+	token, err := s.jwtManager.GenerateShareToken(planID, userID, ttl)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "生成分享链接失败")
+	}
+	claims, err := s.jwtManager.ValidateShareToken(token)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "生成分享链接失败")
+	}
+
+	return &PlanShare{
+		PlanID:    planID,
+		Token:     token,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+// GetSharedPlan checks the token's signature and expiry and loads its plan.
+// A bad token and a plan that is gone look the same, so a link reveals
+// nothing about plans it does not grant.
+func (s *planShareService) GetSharedPlan(ctx context.Context, token string) (*SharedPlan, error) {
+	claims, err := s.jwtManager.ValidateShareToken(token)
+	if err != nil {
+		return nil, errors.New(errors.ErrNotFound, "分享链接无效或已过期")
+	}
+
+	plan, err := s.planRepo.GetByID(ctx, claims.PlanID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取训练计划失败")
+	}
+	if plan == nil || plan.UserID != claims.UserID {
+		return nil, errors.New(errors.ErrNotFound, "分享链接无效或已过期")
+	}
+
+	return &SharedPlan{
+		Plan:      plan,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
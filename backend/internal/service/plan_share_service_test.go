@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/jwt"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shareMaxTTL is the longest a share link of the tests stays valid
+const shareMaxTTL = 30 * 24 * time.Hour
+
+// fakeSharePlanRepo keeps training plans in memory
+type fakeSharePlanRepo struct {
+	repository.TrainingPlanRepository
+	plans map[int64]*model.TrainingPlan
+}
+
+func (r *fakeSharePlanRepo) GetByID(ctx context.Context, id int64) (*model.TrainingPlan, error) {
+	return r.plans[id], nil
+}
+
+func (r *fakeSharePlanRepo) GetSummaryByID(ctx context.Context, id int64) (*model.TrainingPlan, error) {
+	return r.plans[id], nil
+}
+
+// newTestPlanShareService returns a share service whose only plan is plan 3
+// of user 42
+func newTestPlanShareService(t *testing.T) (PlanShareService, *fakeSharePlanRepo, jwt.JWTManager) {
+	t.Helper()
+	plans := &fakeSharePlanRepo{plans: map[int64]*model.TrainingPlan{
+		3: {ID: 3, UserID: 42, PlanName: "四周力量计划", Status: "active"},
+	}}
+	jwtManager := jwt.NewJWTManager("user-secret", "service-secret", time.Hour, 24*time.Hour)
+	return NewPlanShareService(plans, jwtManager, shareMaxTTL), plans, jwtManager
+}
+
+func assertAppError(t *testing.T, err error, code int, message string) {
+	t.Helper()
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, code, appErr.Code)
+	assert.Equal(t, message, appErr.Message)
+}
+
+func TestPlanShareService_SharePlan(t *testing.T) {
+	shareService, _, _ := newTestPlanShareService(t)
+	ctx := context.Background()
+
+	share, err := shareService.SharePlan(ctx, 42, 3, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), share.PlanID)
+	assert.NotEmpty(t, share.Token)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), share.ExpiresAt, 2*time.Second)
+
+	shared, err := shareService.GetSharedPlan(ctx, share.Token)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), shared.Plan.ID)
+	assert.Equal(t, share.ExpiresAt, shared.ExpiresAt)
+}
+
+func TestPlanShareService_SharePlanCapsTTL(t *testing.T) {
+	shareService, _, _ := newTestPlanShareService(t)
+
+	for _, ttl := range []time.Duration{0, -time.Hour, shareMaxTTL + time.Hour, 365 * 24 * time.Hour} {
+		share, err := shareService.SharePlan(context.Background(), 42, 3, ttl)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(shareMaxTTL), share.ExpiresAt, 2*time.Second, "ttl %s", ttl)
+	}
+}
+
+func TestPlanShareService_ExpiredLink(t *testing.T) {
+	shareService, _, jwtManager := newTestPlanShareService(t)
+
+	token, err := jwtManager.GenerateShareToken(3, 42, -time.Minute)
+	require.NoError(t, err)
+
+	_, err = shareService.GetSharedPlan(context.Background(), token)
+	assertAppError(t, err, errors.ErrNotFound, "分享链接无效或已过期")
+}
+
+func TestPlanShareService_RevokedLink(t *testing.T) {
+	// Tokens are not stored, so a link stops working when its plan is
+	// deleted or moves to another account, e.g. by an account merge
+	tests := []struct {
+		name   string
+		revoke func(plans *fakeSharePlanRepo)
+	}{
+		{name: "plan deleted", revoke: func(plans *fakeSharePlanRepo) { delete(plans.plans, 3) }},
+		{name: "plan changed hands", revoke: func(plans *fakeSharePlanRepo) { plans.plans[3].UserID = 7 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shareService, plans, _ := newTestPlanShareService(t)
+			ctx := context.Background()
+
+			share, err := shareService.SharePlan(ctx, 42, 3, time.Hour)
+			require.NoError(t, err)
+			tt.revoke(plans)
+
+			_, err = shareService.GetSharedPlan(ctx, share.Token)
+			assertAppError(t, err, errors.ErrNotFound, "分享链接无效或已过期")
+		})
+	}
+}
+
+func TestPlanShareService_InvalidLink(t *testing.T) {
+	shareService, _, jwtManager := newTestPlanShareService(t)
+
+	accessToken, err := jwtManager.GenerateAccessToken(42, "lifter")
+	require.NoError(t, err)
+	other := jwt.NewJWTManager("other-secret", "service-secret", time.Hour, 24*time.Hour)
+	forged, err := other.GenerateShareToken(3, 42, time.Hour)
+	require.NoError(t, err)
+
+	for _, token := range []string{"", "not-a-token", accessToken, forged} {
+		_, err := shareService.GetSharedPlan(context.Background(), token)
+		assertAppError(t, err, errors.ErrNotFound, "分享链接无效或已过期")
+	}
+}
+
+func TestPlanShareService_AnotherUsersPlan(t *testing.T) {
+	shareService, _, jwtManager := newTestPlanShareService(t)
+	ctx := context.Background()
+
+	_, err := shareService.SharePlan(ctx, 43, 3, time.Hour)
+	assertAppError(t, err, errors.ErrForbidden, "无权访问此训练计划")
+
+	_, err = shareService.SharePlan(ctx, 42, 404, time.Hour)
+	assertAppError(t, err, errors.ErrPlanNotFound, "训练计划不存在")
+
+	// A validly signed link naming someone else as the owner shows the plan
+	// no more than a link to a plan that does not exist
+	token, err := jwtManager.GenerateShareToken(3, 43, time.Hour)
+	require.NoError(t, err)
+	_, err = shareService.GetSharedPlan(ctx, token)
+	assertAppError(t, err, errors.ErrNotFound, "分享链接无效或已过期")
+}
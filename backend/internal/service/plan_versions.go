@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+// RegenerateWeekRequest holds the user's feedback on the week of a training
+// plan being regenerated
+type RegenerateWeekRequest struct {
+	Feedback     string `json:"feedback" validate:"max=1000"`
+	InjuryReport string `json:"injury_report" validate:"max=1000"`
+	AIAPIID      *int64 `json:"ai_api_id"` // Optional, uses default if not provided
+}
+
+// RegenerateWeek asks the AI for a new version of week n of one of the
+// user's plans, from their feedback, injuries and recent records, and saves
+// it in place of that week. The other weeks are unchanged; the plan data
+// replaced is kept as the plan's previous version.
+func (s *trainingService) RegenerateWeek(ctx context.Context, userID, planID int64, week int, req *RegenerateWeekRequest) (*model.TrainingPlan, error) {
+	plan, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	weeks, _ := plan.PlanData["weeks"].([]interface{})
+	index := planWeekIndex(weeks, week)
+	if index < 0 {
+		return nil, errors.New(errors.ErrNotFound, "计划中没有该周")
+	}
+
+	aiAPIID, err := resolveAIAPIID(ctx, s.aiAPIRepo, userID, req.AIAPIID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	start := dayStart(plan.StartDate).AddDate(0, 0, (week-1)*7)
+	since := now.AddDate(0, 0, -adjustmentRecordWindowDays)
+	records, err := s.recordRepo.ListByUser(ctx, userID, &since, &now)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取训练记录失败")
+	}
+	constraints, err := s.constraintRepo.ListByUser(ctx, userID, &start)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取训练限制失败")
+	}
+	constraints = constraintsForPlan(constraints, start, start.AddDate(0, 0, 6))
+	assessment, err := s.assessmentRepo.GetLatest(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取用户评估数据失败")
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取用户信息失败")
+	}
+	busy := userBusySchedule(user)
+
+	params := &TrainingWeekParams{
+		UserID:        userID,
+		AIAPIID:       aiAPIID,
+		Plan:          plan,
+		Week:          week,
+		StartDate:     start,
+		CurrentWeek:   weeks[index].(map[string]interface{}),
+		InjuryReport:  req.InjuryReport,
+		Feedback:      req.Feedback,
+		RecentRecords: trainingRecordLines(records, since),
+		Constraints:   constraints,
+		BusySchedule:  busy,
+		Progressions:  exerciseProgressions(records),
+	}
+	if index > 0 {
+		params.PreviousWeek, _ = weeks[index-1].(map[string]interface{})
+	}
+	if index+1 < len(weeks) {
+		params.NextWeek, _ = weeks[index+1].(map[string]interface{})
+	}
+	for _, w := range planPeriodization(plan.PlanData).DeloadWeeks(plan.TotalWeeks) {
+		if w == week {
+			params.Deload = true
+		}
+	}
+
+	regenerated, err := s.aiService.RegenerateTrainingWeek(ctx, params)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return nil, appErr
+		}
+		logger.Error("Training week regeneration failed",
+			zap.Int64("plan_id", plan.ID),
+			zap.Int("week", week),
+			zap.Error(err),
+		)
+		return nil, errors.Wrap(err, errors.ErrExternalService, "AI重新生成本周训练失败，请稍后重试")
+	}
+
+	// The week goes through the same fixes as a whole adjusted plan
+	weekData := model.JSONMap{"weeks": []interface{}{map[string]interface{}(regenerated)}}
+	enforceBusyDays(weekData, busy, dayStart(now))
+	overlaySafetyNotes(weekData)
+	normalizeExerciseGroups(weekData)
+	if assessment != nil {
+		fitPlanToTime(weekData, assessment.DailyAvailableMinutes)
+	}
+
+	previous := &model.TrainingPlanVersion{
+		PlanID:   plan.ID,
+		Version:  plan.Version,
+		PlanData: plan.PlanData,
+		Week:     &week,
+	}
+	if req.Feedback != "" {
+		previous.Feedback = &req.Feedback
+	}
+
+	// Copy rather than modify the plan data, which is kept as the previous
+	// version
+	planData := make(model.JSONMap, len(plan.PlanData))
+	for k, v := range plan.PlanData {
+		planData[k] = v
+	}
+	replaced := make([]interface{}, len(weeks))
+	copy(replaced, weeks)
+	replaced[index] = weekData["weeks"].([]interface{})[0]
+	planData["weeks"] = replaced
+	plan.PlanData = planData
+	plan.Version = previous.Version + 1
+
+	if err := s.planRepo.SaveVersion(ctx, plan, previous); err != nil {
+		if err == repository.ErrPlanVersionChanged {
+			return nil, errors.New(errors.ErrConflict, "训练计划已被修改，请刷新后重试")
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase, "保存训练计划失败")
+	}
+	return plan, nil
+}
+
+// ListPlanVersions returns the earlier versions of one of the user's plans,
+// newest first, without their plan data
+func (s *trainingService) ListPlanVersions(ctx context.Context, userID, planID int64) ([]*model.TrainingPlanVersion, error) {
+	if _, err := s.GetPlanSummary(ctx, planID, userID); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.planRepo.ListVersions(ctx, planID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取计划历史版本失败")
+	}
+	return versions, nil
+}
+
+// GetPlanVersion returns an earlier version of one of the user's plans
+func (s *trainingService) GetPlanVersion(ctx context.Context, userID, planID int64, version int) (*model.TrainingPlanVersion, error) {
+	if _, err := s.GetPlanSummary(ctx, planID, userID); err != nil {
+		return nil, err
+	}
+
+	v, err := s.planRepo.GetVersion(ctx, planID, version)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取计划历史版本失败")
+	}
+	if v == nil {
+		return nil, errors.New(errors.ErrNotFound, "计划历史版本不存在")
+	}
+	return v, nil
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Thresholds of the progression audit. Week-over-week changes within
+// progressionTolerance count as holding steady; a plan is flat when its
+// score is below progressionFlatScore or its last week is less than
+// progressionMinGain harder than its first.
+const (
+	progressionTolerance = 0.01
+	progressionMinGain   = 0.05
+	progressionFlatScore = 50
+	// progressionMaxRatio bounds one exercise's week-over-week ratio, so a
+	// switch between bodyweight and a loaded variant does not dominate
+	progressionMaxRatio = 2.0
+)
+
+// difficultyLoadFactor weights an exercise by difficulty when it has no load
+var difficultyLoadFactor = map[string]float64{
+	"easy":   1.0,
+	"medium": 1.25,
+	"hard":   1.5,
+}
+
+var progressionNumber = regexp.MustCompile(`\d+(?:\.\d+)?`)
+var progressionRepRange = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(?:-|~|～|到|至)\s*(\d+(?:\.\d+)?)`)
+
+// ProgressionWeek is one week of a progression audit. Change is the
+// week's load relative to the previous week, e.g. 0.05 for 5% harder, and
+// is zero for the first week.
+type ProgressionWeek struct {
+	Week      int
+	Exercises int
+	Change    float64
+}
+
+// ProgressionAudit rates whether a training plan gets harder week by week,
+// measured per exercise as sets × reps × load
+type ProgressionAudit struct {
+	Weeks []ProgressionWeek
+	// Score is the share of week transitions that progress, 0-100. A single
+	// lighter week followed by a harder one counts as a planned deload.
+	Score int
+	// OverallChange is the last week's load relative to the first week's
+	OverallChange float64
+	// Flat marks plans that should be regenerated
+	Flat   bool
+	Issues []string
+}
+
+// exerciseLoad is the weekly volume of one exercise
+type exerciseLoad struct {
+	load       float64
+	difficulty float64
+}
+
+// AuditPlanProgression audits the progression of one of the user's plans
+func (s *trainingService) AuditPlanProgression(ctx context.Context, userID, planID int64) (*ProgressionAudit, error) {
+	plan, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return auditPlanProgression(plan.PlanData, plan.TotalWeeks), nil
+}
+
+// auditPlanProgression scores the week-over-week progression of plan data.
+// Each exercise is compared only with itself in the previous week, so loads
+// given in different units by different exercises never mix; when two weeks
+// share no exercise, their total volume weighted by difficulty is compared.
+func auditPlanProgression(planData model.JSONMap, totalWeeks int) *ProgressionAudit {
+	weekLoads := planWeekLoads(planData)
+	audit := &ProgressionAudit{Weeks: make([]ProgressionWeek, 0, len(weekLoads))}
+
+	if totalWeeks > 1 && len(weekLoads) < totalWeeks {
+		audit.Issues = append(audit.Issues, "计划未列出全部周次，无法体现逐周递增")
+	}
+	if len(weekLoads) < 2 {
+		audit.Flat = totalWeeks > 1
+		for i, loads := range weekLoads {
+			audit.Weeks = append(audit.Weeks, ProgressionWeek{Week: i + 1, Exercises: len(loads)})
+		}
+		return audit
+	}
+
+	changes := make([]float64, len(weekLoads))
+	cumulative := 1.0
+	for i, loads := range weekLoads {
+		week := ProgressionWeek{Week: i + 1, Exercises: len(loads)}
+		if i > 0 {
+			changes[i] = weekLoadChange(weekLoads[i-1], loads)
+			week.Change = roundRatio(changes[i])
+			cumulative *= 1 + changes[i]
+		}
+		audit.Weeks = append(audit.Weeks, week)
+	}
+	audit.OverallChange = roundRatio(cumulative - 1)
+
+	progressed, held, regressed := 0, 0, 0
+	for i := 1; i < len(changes); i++ {
+		switch {
+		case changes[i] > progressionTolerance:
+			progressed++
+		case changes[i] < -progressionTolerance:
+			// A single lighter week followed by a harder one is a deload
+			if i+1 < len(changes) && changes[i+1] > progressionTolerance && (i == 1 || changes[i-1] >= -progressionTolerance) {
+				progressed++
+			} else {
+				regressed++
+			}
+		default:
+			held++
+		}
+	}
+	audit.Score = int(math.Round(100 * float64(progressed) / float64(len(changes)-1)))
+
+	if held > 0 {
+		audit.Issues = append(audit.Issues, strconv.Itoa(held)+"周与上周难度持平")
+	}
+	if regressed > 0 {
+		audit.Issues = append(audit.Issues, strconv.Itoa(regressed)+"周难度低于上周且不属于减载周")
+	}
+	if audit.OverallChange < progressionMinGain {
+		audit.Issues = append(audit.Issues, "最后一周整体难度未高于第一周")
+	}
+	audit.Flat = audit.Score < progressionFlatScore || audit.OverallChange < progressionMinGain
+
+	return audit
+}
+
+// planWeekLoads computes each week's load per exercise. Rest days and
+// malformed entries are skipped.
+func planWeekLoads(planData model.JSONMap) []map[string]exerciseLoad {
+	weeksRaw, _ := planData["weeks"].([]interface{})
+
+	weeks := make([]map[string]exerciseLoad, 0, len(weeksRaw))
+	for _, weekRaw := range weeksRaw {
+		week, ok := weekRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		loads := make(map[string]exerciseLoad)
+		days, _ := week["days"].([]interface{})
+		for _, dayRaw := range days {
+			day, ok := dayRaw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if dayType, _ := day["type"].(string); dayType == "rest" {
+				continue
+			}
+
+			exercises, _ := day["exercises"].([]interface{})
+			for _, exerciseRaw := range exercises {
+				exercise, ok := exerciseRaw.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _ := exercise["name"].(string)
+				if strings.TrimSpace(name) == "" {
+					continue
+				}
+
+				volume := float64(jsonInt(exercise["sets"])) * repCount(exercise["reps"])
+				if volume <= 0 {
+					continue
+				}
+				difficulty, _ := exercise["difficulty"].(string)
+				factor, ok := difficultyLoadFactor[strings.ToLower(difficulty)]
+				if !ok {
+					factor = difficultyLoadFactor["medium"]
+				}
+				load := exerciseWeight(exercise["weight"])
+				if load <= 0 {
+					load = factor
+				}
+
+				key := normalizeExerciseName(name)
+				entry := loads[key]
+				entry.load += volume * load
+				entry.difficulty += volume * factor
+				loads[key] = entry
+			}
+		}
+		weeks = append(weeks, loads)
+	}
+	return weeks
+}
+
+// weekLoadChange is the geometric mean of the per-exercise load ratios of
+// two consecutive weeks, minus one
+func weekLoadChange(prev, cur map[string]exerciseLoad) float64 {
+	logSum, shared := 0.0, 0
+	for name, c := range cur {
+		p, ok := prev[name]
+		if !ok || p.load <= 0 {
+			continue
+		}
+		ratio := math.Min(math.Max(c.load/p.load, 1/progressionMaxRatio), progressionMaxRatio)
+		logSum += math.Log(ratio)
+		shared++
+	}
+	if shared > 0 {
+		return math.Exp(logSum/float64(shared)) - 1
+	}
+
+	var prevTotal, curTotal float64
+	for _, p := range prev {
+		prevTotal += p.difficulty
+	}
+	for _, c := range cur {
+		curTotal += c.difficulty
+	}
+	if prevTotal <= 0 {
+		return 0
+	}
+	return curTotal/prevTotal - 1
+}
+
+// repCount reads reps such as 10, "8-12" or "30秒", using the middle of a
+// range. Timed sets count their seconds, which is consistent within an
+// exercise.
+func repCount(v interface{}) float64 {
+	if n, ok := v.(float64); ok {
+		return n
+	}
+	s, _ := v.(string)
+	if m := progressionRepRange.FindStringSubmatch(s); m != nil {
+		low, _ := strconv.ParseFloat(m[1], 64)
+		high, _ := strconv.ParseFloat(m[2], 64)
+		return (low + high) / 2
+	}
+	if m := progressionNumber.FindString(s); m != "" {
+		n, _ := strconv.ParseFloat(m, 64)
+		return n
+	}
+	return 0
+}
+
+// exerciseWeight reads the load of an exercise such as "60kg" or "70%1RM",
+// or returns 0 for bodyweight and unspecified loads
+func exerciseWeight(v interface{}) float64 {
+	if n, ok := v.(float64); ok {
+		return n
+	}
+	s, _ := v.(string)
+	if m := progressionNumber.FindString(s); m != "" {
+		n, _ := strconv.ParseFloat(m, 64)
+		return n
+	}
+	return 0
+}
+
+// roundRatio rounds a ratio to three decimals for reporting
+func roundRatio(r float64) float64 {
+	return math.Round(r*1000) / 1000
+}
+
+// recordProgressionAudit audits a newly generated plan and stores the
+// result with its plan data, so flat plans can be spotted and regenerated.
+// It returns the completion message for the task, which warns about a flat
+// plan.
+func recordProgressionAudit(plan *model.TrainingPlan, message string) string {
+	audit := auditPlanProgression(plan.PlanData, plan.TotalWeeks)
+	plan.PlanData["progression_audit"] = map[string]interface{}{
+		"score":          audit.Score,
+		"overall_change": audit.OverallChange,
+		"flat":           audit.Flat,
+	}
+	if !audit.Flat {
+		return message
+	}
+
+	logger.Warn("Generated training plan does not progress",
+		zap.Int64("user_id", plan.UserID),
+		zap.Int("score", audit.Score),
+		zap.Float64("overall_change", audit.OverallChange),
+	)
+	return message + "，但各周难度未逐周递增，建议重新生成"
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// notProvided is rendered in place of a placeholder variable with no value
+const notProvided = "未提供"
+
+// Roles of prompt variables, set with a `prompt` tag on the fields of the
+// prompt data structs. Untagged fields are passed through as they are.
+const (
+	// promptRequired fields must be set; a prompt is never built without them
+	promptRequired = "required"
+	// promptPlaceholder fields are rendered as notProvided when unset. Only
+	// tag fields that templates print directly: the placeholder is a
+	// string, so it would pass {{if}} checks and break printf verbs.
+	promptPlaceholder = "placeholder"
+	// promptOptional fields carry context the user may not have; leaving
+	// one unset is logged with the generation
+	promptOptional = "optional"
+)
+
+// fmtArtifact matches the markers fmt writes for a bad verb or argument
+// count, such as %!d(MISSING) or %!f(string=x), and text/template's output
+// for a nil value
+var fmtArtifact = regexp.MustCompile(`%!\w?\((MISSING|EXTRA |NOVERB|BAD\w+|[\w.*\[\]]+=)|<no value>`)
+
+// promptVariables are the variables of one prompt, ready to render
+type promptVariables struct {
+	vars interface{}
+	// omitted lists the optional variables that were left unset
+	omitted []string
+}
+
+// assemblePromptVariables checks the required variables of a prompt data
+// struct and fills in placeholders. It returns the variables as a map keyed
+// by field name, so templates address them as before. Data that is not a
+// struct, such as preview samples for templates without one, is returned
+// unchanged.
+func assemblePromptVariables(data interface{}) (*promptVariables, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return &promptVariables{vars: data}, nil
+	}
+
+	t := v.Type()
+	vars := make(map[string]interface{}, t.NumField())
+	var missing, omitted []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := v.Field(i)
+		vars[field.Name] = value.Interface()
+
+		if !isUnsetPromptValue(value) {
+			continue
+		}
+		switch field.Tag.Get("prompt") {
+		case promptRequired:
+			missing = append(missing, field.Name)
+		case promptPlaceholder:
+			vars[field.Name] = notProvided
+		case promptOptional:
+			omitted = append(omitted, field.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required prompt variables: %s", strings.Join(missing, ", "))
+	}
+	return &promptVariables{vars: vars, omitted: omitted}, nil
+}
+
+// isUnsetPromptValue reports whether a prompt variable has no value. Empty
+// strings and lists count as unset, as does whitespace-only text.
+func isUnsetPromptValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// checkRenderedPrompt rejects a rendered prompt that contains formatting
+// artifacts, which mean a variable was missing or of the wrong type
+func checkRenderedPrompt(name, prompt string) error {
+	if artifact := fmtArtifact.FindString(prompt); artifact != "" {
+		return fmt.Errorf("prompt template %s rendered %q; check its variables and printf verbs", name, artifact)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// servedTemplates returns the stored templates to try for userID's prompt
+// of category and subcategory, in order: the rollout variant the user is
+// assigned to, if any, then the default. Lookup failures are logged and
+// leave the built-in template.
+func (s *aiService) servedTemplates(ctx context.Context, userID int64, category model.PromptCategory, subcategory string) []*model.PromptTemplate {
+	if s.templateRepo == nil {
+		return nil
+	}
+
+	var served []*model.PromptTemplate
+	variants, err := s.templateRepo.ListVariants(ctx, string(category), subcategory)
+	if err != nil {
+		logger.Warn("Failed to load prompt template variants, serving the default",
+			zap.String("category", string(category)),
+			zap.String("subcategory", subcategory),
+			zap.Error(err),
+		)
+	} else if variant := assignedVariant(variants, promptRolloutBucket(userID, category, subcategory)); variant != nil {
+		served = append(served, variant)
+	}
+
+	stored, err := s.templateRepo.GetDefault(ctx, string(category), subcategory)
+	if err != nil {
+		logger.Warn("Failed to load prompt template, using built-in",
+			zap.String("category", string(category)),
+			zap.String("subcategory", subcategory),
+			zap.Error(err),
+		)
+	} else if stored != nil {
+		served = append(served, stored)
+	}
+	return served
+}
+
+// promptRolloutBucket places a user in one of 100 buckets for the templates
+// of category and subcategory. The bucket only depends on its inputs, so a
+// user keeps getting the same variant, and differs per subcategory, so the
+// same users are not in every experiment.
+func promptRolloutBucket(userID int64, category model.PromptCategory, subcategory string) int {
+	h := fnv.New32a()
+	h.Write([]byte(strconv.FormatInt(userID, 10) + "|" + string(category) + "|" + subcategory))
+	return int(h.Sum32() % 100)
+}
+
+// assignedVariant returns the variant whose share of the buckets contains
+// bucket, or nil for the default. Variants take consecutive ranges from
+// bucket 0 in the order given; the buckets left over get the default.
+func assignedVariant(variants []*model.PromptTemplate, bucket int) *model.PromptTemplate {
+	upper := 0
+	for _, v := range variants {
+		upper += v.RolloutPercent
+		if bucket < upper {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,507 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+)
+
+// PromptTemplateUpdate holds the editable fields of a prompt template; nil
+// fields are left unchanged
+type PromptTemplateUpdate struct {
+	Name        *string
+	Template    *string
+	Variables   []string
+	Description *string
+}
+
+// PromptVariantResult is a template taking part in a prompt experiment with
+// the plans generated from it
+type PromptVariantResult struct {
+	Template *model.PromptTemplate
+	Stats    *repository.PromptVariantStats
+}
+
+// PromptExperiment compares the default template of a plan category and
+// subcategory with the variants rolled out beside it
+type PromptExperiment struct {
+	Category    string
+	Subcategory string
+	Since       time.Time
+	// Variants lists the default first, then the variants oldest first
+	Variants []*PromptVariantResult
+}
+
+// PromptTemplateService manages the prompt templates used for AI generation
+type PromptTemplateService interface {
+	List(ctx context.Context, category, subcategory string) ([]*model.PromptTemplate, error)
+	Get(ctx context.Context, id int64) (*model.PromptTemplate, error)
+	// Create adds a new version of a template; it is not the default until
+	// SetDefault is called
+	Create(ctx context.Context, template *model.PromptTemplate) (*model.PromptTemplate, error)
+	// Update edits a template and marks it customized, so seeding no longer
+	// overwrites it
+	Update(ctx context.Context, id int64, update *PromptTemplateUpdate) (*model.PromptTemplate, error)
+	SetDefault(ctx context.Context, id int64) (*model.PromptTemplate, error)
+	// SetRollout serves a non-default template to percent of the users of
+	// its category and subcategory; 0 ends its rollout
+	SetRollout(ctx context.Context, id int64, percent int) (*model.PromptTemplate, error)
+	// Promote makes a variant the default and ends every rollout beside it
+	Promote(ctx context.Context, id int64) (*model.PromptTemplate, error)
+	// Experiment compares the plans generated in the last days days from
+	// the default and each rolled out variant
+	Experiment(ctx context.Context, category, subcategory string, days int) (*PromptExperiment, error)
+	// Preview renders the template, or text in its place if given, with
+	// sample variables overlaid by variables
+	Preview(ctx context.Context, id int64, text *string, variables map[string]interface{}) (string, error)
+}
+
+// promptTemplateService implements PromptTemplateService
+type promptTemplateService struct {
+	templateRepo repository.PromptTemplateRepository
+}
+
+// NewPromptTemplateService creates a new PromptTemplateService instance
+func NewPromptTemplateService(templateRepo repository.PromptTemplateRepository) PromptTemplateService {
+	return &promptTemplateService{templateRepo: templateRepo}
+}
+
+// List returns templates, optionally filtered by category and subcategory
+func (s *promptTemplateService) List(ctx context.Context, category, subcategory string) ([]*model.PromptTemplate, error) {
+	templates, err := s.templateRepo.List(ctx, category, subcategory)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取提示词模板失败")
+	}
+	return templates, nil
+}
+
+// Get returns a template by ID
+func (s *promptTemplateService) Get(ctx context.Context, id int64) (*model.PromptTemplate, error) {
+	template, err := s.templateRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取提示词模板失败")
+	}
+	if template == nil {
+		return nil, errors.New(errors.ErrNotFound, "提示词模板不存在")
+	}
+	return template, nil
+}
+
+// Create validates and stores a new template
+func (s *promptTemplateService) Create(ctx context.Context, template *model.PromptTemplate) (*model.PromptTemplate, error) {
+	if _, err := renderWithSample(template, nil); err != nil {
+		return nil, err
+	}
+
+	template.IsDefault = false
+	template.IsCustomized = true
+	if err := s.templateRepo.Create(ctx, template); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "创建提示词模板失败")
+	}
+	return template, nil
+}
+
+// Update validates and saves the edited template
+func (s *promptTemplateService) Update(ctx context.Context, id int64, update *PromptTemplateUpdate) (*model.PromptTemplate, error) {
+	template, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		template.Name = *update.Name
+	}
+	if update.Template != nil {
+		template.Template = *update.Template
+	}
+	if update.Variables != nil {
+		variables := make(model.JSONSlice, 0, len(update.Variables))
+		for _, v := range update.Variables {
+			variables = append(variables, v)
+		}
+		template.Variables = variables
+	}
+	if update.Description != nil {
+		template.Description = update.Description
+	}
+
+	if _, err := renderWithSample(template, nil); err != nil {
+		return nil, err
+	}
+
+	template.IsCustomized = true
+	if err := s.templateRepo.Update(ctx, template); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "更新提示词模板失败")
+	}
+	return template, nil
+}
+
+// SetDefault makes the template the default of its category and subcategory
+func (s *promptTemplateService) SetDefault(ctx context.Context, id int64) (*model.PromptTemplate, error) {
+	template, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.templateRepo.SetDefault(ctx, template); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "设置默认提示词模板失败")
+	}
+	template.IsDefault = true
+	return template, nil
+}
+
+// SetRollout checks that the template is a variant and that the shares of
+// its category and subcategory still add up to at most 100%
+func (s *promptTemplateService) SetRollout(ctx context.Context, id int64, percent int) (*model.PromptTemplate, error) {
+	template, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if template.IsDefault {
+		return nil, errors.New(errors.ErrConflict, "默认模板服务于其余用户，不能设置灰度比例")
+	}
+
+	if percent > 0 {
+		variants, err := s.templateRepo.ListVariants(ctx, template.Category, templateSubcategory(template))
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "获取提示词模板失败")
+		}
+		total := percent
+		for _, v := range variants {
+			if v.ID != template.ID {
+				total += v.RolloutPercent
+			}
+		}
+		if total > 100 {
+			return nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("同一分类的灰度比例合计不能超过100%%，当前将达到%d%%", total))
+		}
+	}
+
+	if err := s.templateRepo.SetRollout(ctx, template, percent); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "设置提示词模板灰度比例失败")
+	}
+	template.RolloutPercent = percent
+	return template, nil
+}
+
+// Promote makes the template the default of its category and subcategory
+// for every user
+func (s *promptTemplateService) Promote(ctx context.Context, id int64) (*model.PromptTemplate, error) {
+	template, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.templateRepo.Promote(ctx, template); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "推广提示词模板失败")
+	}
+	template.IsDefault = true
+	template.RolloutPercent = 0
+	return template, nil
+}
+
+// Experiment collects the default and the variants of a training or
+// nutrition subcategory with statistics of their plans
+func (s *promptTemplateService) Experiment(ctx context.Context, category, subcategory string, days int) (*PromptExperiment, error) {
+	switch model.PromptCategory(category) {
+	case model.PromptCategoryTraining, model.PromptCategoryNutrition:
+	default:
+		return nil, errors.New(errors.ErrInvalidParam, "只能比较训练和营养计划的提示词模板")
+	}
+	if days <= 0 {
+		days = 30
+	}
+
+	experiment := &PromptExperiment{
+		Category:    category,
+		Subcategory: subcategory,
+		Since:       time.Now().AddDate(0, 0, -days),
+	}
+	current, err := s.templateRepo.GetDefault(ctx, category, subcategory)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取提示词模板失败")
+	}
+	variants, err := s.templateRepo.ListVariants(ctx, category, subcategory)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取提示词模板失败")
+	}
+	if current != nil {
+		variants = append([]*model.PromptTemplate{current}, variants...)
+	}
+
+	ids := make([]int64, 0, len(variants))
+	for _, v := range variants {
+		ids = append(ids, v.ID)
+	}
+	stats, err := s.templateRepo.VariantStats(ctx, category, ids, experiment.Since)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "统计提示词模板效果失败")
+	}
+
+	for _, v := range variants {
+		result := &PromptVariantResult{
+			Template: v,
+			Stats:    &repository.PromptVariantStats{TemplateID: v.ID},
+		}
+		for _, st := range stats {
+			if st.TemplateID == v.ID {
+				result.Stats = st
+			}
+		}
+		experiment.Variants = append(experiment.Variants, result)
+	}
+	return experiment, nil
+}
+
+// templateSubcategory returns the subcategory of a template, "" if it has
+// none
+func templateSubcategory(template *model.PromptTemplate) string {
+	if template.Subcategory == nil {
+		return ""
+	}
+	return *template.Subcategory
+}
+
+// Preview renders the template with sample data
+func (s *promptTemplateService) Preview(ctx context.Context, id int64, text *string, variables map[string]interface{}) (string, error) {
+	template, err := s.Get(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if text != nil {
+		template.Template = *text
+	}
+	return renderWithSample(template, variables)
+}
+
+// renderWithSample renders the template against sample data for its category,
+// overlaid by overrides. A template that fails to render is reported as an
+// invalid parameter, since generation would silently fall back to the
+// built-in prompt.
+func renderWithSample(template *model.PromptTemplate, overrides map[string]interface{}) (string, error) {
+	data, err := samplePromptData(template, overrides)
+	if err != nil {
+		return "", errors.New(errors.ErrInvalidParam, fmt.Sprintf("示例变量无效: %v", err))
+	}
+	assembled, err := assemblePromptVariables(data)
+	if err != nil {
+		return "", errors.New(errors.ErrInvalidParam, fmt.Sprintf("示例变量无效: %v", err))
+	}
+
+	prompt, err := renderTemplate(template.Name, template.Template, assembled.vars)
+	if err != nil {
+		return "", errors.New(errors.ErrInvalidParam, fmt.Sprintf("提示词模板无法渲染: %v", err))
+	}
+	return prompt, nil
+}
+
+// samplePromptData returns representative variables for a template. Plan
+// generation and adjustment templates get the struct the AI service passes,
+// so values keep their real types; other templates get a placeholder for
+// each declared variable.
+func samplePromptData(template *model.PromptTemplate, overrides map[string]interface{}) (interface{}, error) {
+	var sample interface{}
+	if template.Subcategory != nil {
+		category := model.PromptCategory(template.Category)
+		switch *template.Subcategory {
+		case PromptSubcategoryPlanGeneration:
+			switch category {
+			case model.PromptCategoryTraining:
+				sample = sampleTrainingPromptData()
+			case model.PromptCategoryNutrition:
+				sample = sampleNutritionPromptData()
+			}
+		case PromptSubcategoryAdjustment:
+			switch category {
+			case model.PromptCategoryTraining:
+				sample = sampleTrainingAdjustmentPromptData()
+			case model.PromptCategoryNutrition:
+				sample = sampleNutritionAdjustmentPromptData()
+			}
+		case PromptSubcategoryDayRegeneration:
+			if category == model.PromptCategoryNutrition {
+				sample = sampleNutritionDayPromptData()
+			}
+		case PromptSubcategoryWeekRegeneration:
+			if category == model.PromptCategoryTraining {
+				sample = sampleTrainingWeekPromptData()
+			}
+		case PromptSubcategoryExerciseSubstitution:
+			if category == model.PromptCategoryTraining {
+				sample = sampleExerciseSubstitutionPromptData()
+			}
+		case PromptSubcategoryChat:
+			if category == model.PromptCategoryCoach {
+				sample = sampleCoachPromptData()
+			}
+		}
+	}
+
+	if sample == nil {
+		data := make(map[string]interface{})
+		for _, v := range template.Variables {
+			if name, ok := v.(string); ok {
+				data[name] = "<" + name + ">"
+			}
+		}
+		for name, value := range overrides {
+			data[name] = value
+		}
+		return data, nil
+	}
+
+	if len(overrides) > 0 {
+		// The prompt data structs have no JSON tags, so override keys match
+		// field names
+		raw, err := json.Marshal(overrides)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, sample); err != nil {
+			return nil, err
+		}
+	}
+	return sample, nil
+}
+
+func sampleTrainingPromptData() *TrainingPromptData {
+	return &TrainingPromptData{
+		PlanName:              "增肌计划",
+		Goal:                  "muscle_gain",
+		DifficultyLevel:       "intermediate",
+		TotalWeeks:            8,
+		HasAssessment:         true,
+		ExperienceLevel:       "intermediate",
+		WeeklyAvailableDays:   4,
+		DailyAvailableMinutes: 60,
+		HasBodyData:           true,
+		Age:                   28,
+		Gender:                "male",
+		Height:                175,
+		Weight:                70,
+		BodyFatPercentage:     18,
+		FitnessGoals:          []string{"muscle_gain: 三个月增重3公斤"},
+	}
+}
+
+func sampleNutritionPromptData() *NutritionPromptData {
+	return &NutritionPromptData{
+		PlanName:      "增肌饮食计划",
+		TotalDays:     7,
+		DailyCalories: 2500,
+		ProteinRatio:  30,
+		CarbRatio:     45,
+		FatRatio:      25,
+		Preferences:   "[家常菜 (Chinese home cooking)]",
+		BudgetLevel:   model.NutritionBudgetMedium,
+		HasBodyData:   true,
+		Age:           28,
+		Gender:        "male",
+		Height:        175,
+		Weight:        70,
+		FitnessGoals:  []string{"muscle_gain: 三个月增重3公斤"},
+	}
+}
+
+func sampleTrainingAdjustmentPromptData() *TrainingAdjustmentPromptData {
+	return &TrainingAdjustmentPromptData{
+		CurrentPlan:      `{"weeks":[{"week":1,"days":[{"day":1,"date":"2024-01-01","type":"strength","exercises":[{"name":"杠铃深蹲","sets":4,"reps":"8-10"}]}]}]}`,
+		TotalWeeks:       4,
+		StartDate:        "2024-01-15",
+		CompletionRate:   75,
+		DifficultyRating: 4,
+		Feedback:         "深蹲后膝盖有些酸",
+		RecentRecords:    []string{"2024-01-10 力量训练 60分钟 评分4/5"},
+		HasCheckIn:       true,
+		CheckInAdherence: 4,
+		CheckInEnergy:    3,
+		CheckInHunger:    3,
+	}
+}
+
+func sampleNutritionAdjustmentPromptData() *NutritionAdjustmentPromptData {
+	return &NutritionAdjustmentPromptData{
+		CurrentPlan:        `{"days":[{"day":1,"date":"2024-01-01","meals":{"breakfast":{"foods":[{"name":"燕麦","amount":"80g","calories":300}]}}}]}`,
+		TotalDays:          7,
+		StartDate:          "2024-01-15",
+		CompletionRate:     80,
+		SatisfactionRating: 4,
+		HasWeightChange:    true,
+		WeightChange:       -0.8,
+		RecentRecords:      []string{"2024-01-10 3餐 2350千卡 蛋白质140g 碳水260g 脂肪70g"},
+		HasCheckIn:         true,
+		CheckInAdherence:   4,
+		CheckInEnergy:      4,
+		CheckInHunger:      2,
+	}
+}
+
+func sampleNutritionDayPromptData() *NutritionDayPromptData {
+	return &NutritionDayPromptData{
+		PlanName:            "减脂饮食计划",
+		Date:                "2024-01-17",
+		DayNumber:           3,
+		CurrentDay:          `{"day":3,"date":"2024-01-17","meals":{"breakfast":{"foods":[{"name":"燕麦","amount":"80g","calories":300}]}}}`,
+		DailyCalories:       1800,
+		ProteinRatio:        30,
+		CarbRatio:           40,
+		FatRatio:            30,
+		DietaryRestrictions: "[无乳糖 (Lactose-free)]",
+		Feedback:            "不喜欢燕麦",
+	}
+}
+
+func sampleTrainingWeekPromptData() *TrainingWeekPromptData {
+	return &TrainingWeekPromptData{
+		PlanName:      "增肌计划",
+		Week:          2,
+		TotalWeeks:    4,
+		StartDate:     "2024-01-22",
+		EndDate:       "2024-01-28",
+		CurrentWeek:   `{"week":2,"deload":false,"days":[{"day":1,"date":"2024-01-22","type":"strength","focus_area":"lower_body","exercises":[{"name":"杠铃深蹲","sets":4,"reps":"8-10","weight":"80kg","rest":"120s"}]}]}`,
+		PreviousWeek:  `{"week":1,"deload":false,"days":[{"day":1,"date":"2024-01-15","type":"strength","focus_area":"lower_body","exercises":[{"name":"杠铃深蹲","sets":4,"reps":"8-10","weight":"75kg","rest":"120s"}]}]}`,
+		InjuryReport:  "左膝下蹲时疼痛",
+		Feedback:      "这周出差，只能用酒店健身房",
+		RecentRecords: []string{"2024-01-15 力量训练 60分钟 评分3/5"},
+	}
+}
+
+func sampleExerciseSubstitutionPromptData() *ExerciseSubstitutionPromptData {
+	return &ExerciseSubstitutionPromptData{
+		PlanName:             "增肌计划",
+		Date:                 "2024-01-17",
+		CurrentDay:           `{"day":3,"date":"2024-01-17","type":"strength","focus_area":"upper_body","exercises":[{"name":"杠铃卧推","sets":4,"reps":"8-10","weight":"60kg","rest":"90s"},{"name":"哑铃划船","sets":3,"reps":"10-12","weight":"20kg","rest":"60s"}]}`,
+		Exercise:             `{"name":"杠铃卧推","sets":4,"reps":"8-10","weight":"60kg","rest":"90s"}`,
+		UnavailableEquipment: "杠铃 (Barbell)",
+		AvoidJoints:          "肩 (Shoulder)",
+		Notes:                "最近右肩有些不适",
+	}
+}
+
+func sampleCoachPromptData() *CoachPromptData {
+	return &CoachPromptData{
+		Date:              "2024-01-15",
+		HasTrainingPlan:   true,
+		TrainingPlanName:  "增肌计划",
+		TrainingPlanWeeks: 8,
+		TodayTraining:     "休息日",
+		TomorrowTraining:  "strength（腿部），约60分钟：杠铃深蹲 4组×8-10 60kg",
+		HasNutritionPlan:  true,
+		NutritionPlanName: "增肌饮食计划",
+		DailyCalories:     2500,
+		TodayMeals:        []string{"07:30：燕麦 80g、鸡蛋 2个，约450千卡"},
+		HasBodyData:       true,
+		Age:               28,
+		Gender:            "male",
+		Height:            175,
+		Weight:            70,
+		FitnessGoals:      []string{"muscle_gain: 三个月增重3公斤"},
+		History:           []string{"学员：练完腿第二天很酸正常吗？", "教练：轻度酸痛是正常的，注意拉伸和休息。"},
+		Question:          "明天的深蹲可以换成别的动作吗？",
+	}
+}
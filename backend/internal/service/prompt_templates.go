@@ -0,0 +1,675 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/migration"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Prompt template subcategories
+const (
+	PromptSubcategoryPlanGeneration = "plan_generation"
+	PromptSubcategoryAdjustment     = "adjustment"
+	PromptSubcategoryChat           = "chat"
+	// PromptSubcategoryDayRegeneration replaces a single day of a plan
+	PromptSubcategoryDayRegeneration = "day_regeneration"
+	// PromptSubcategoryWeekRegeneration replaces a single week of a
+	// training plan
+	PromptSubcategoryWeekRegeneration = "week_regeneration"
+	// PromptSubcategoryExerciseSubstitution replaces one exercise of a
+	// training plan day
+	PromptSubcategoryExerciseSubstitution = "exercise_substitution"
+)
+
+// Built-in templates, used when the database has no usable default
+const (
+	builtinTrainingPlanTemplate         = "training_plan_generation.tmpl"
+	builtinNutritionPlanTemplate        = "nutrition_plan_generation.tmpl"
+	builtinTrainingAdjustmentTemplate   = "training_adjustment.tmpl"
+	builtinNutritionAdjustmentTemplate  = "nutrition_adjustment.tmpl"
+	builtinNutritionDayTemplate         = "nutrition_day_regeneration.tmpl"
+	builtinTrainingWeekTemplate         = "training_week_regeneration.tmpl"
+	builtinExerciseSubstitutionTemplate = "exercise_substitution.tmpl"
+	builtinCoachChatTemplate            = "coach_chat.tmpl"
+)
+
+// TrainingPromptData holds the variables available to training plan
+// generation templates. The *Section fields are pre-rendered blocks that are
+// empty when the user has no such data.
+type TrainingPromptData struct {
+	PlanName        string `prompt:"required"`
+	Goal            string `prompt:"required"`
+	DifficultyLevel string `prompt:"required"`
+	TotalWeeks      int    `prompt:"required"`
+
+	HasAssessment         bool   `prompt:"optional"`
+	ExperienceLevel       string `prompt:"placeholder"`
+	WeeklyAvailableDays   int    `prompt:"placeholder"`
+	DailyAvailableMinutes int    `prompt:"placeholder"`
+	InjuryHistory         string
+	HealthConditions      string
+	// EquipmentAvailable is the assessment's equipment list, set only when
+	// the user has no equipment profiles
+	EquipmentAvailable string
+
+	HasBodyData       bool   `prompt:"optional"`
+	Age               int    `prompt:"placeholder"`
+	Gender            string `prompt:"placeholder"`
+	Height            float64
+	Weight            float64
+	BodyFatPercentage float64
+
+	// FitnessGoals lists goals as "type" or "type: description"
+	FitnessGoals []string `prompt:"optional"`
+
+	ConstraintSection string `prompt:"optional"`
+	EquipmentSection  string `prompt:"optional"`
+	StrengthSection   string `prompt:"optional"`
+	CheckInSection    string `prompt:"optional"`
+	// MacrocycleSection places the plan in its macrocycle and reports how
+	// the previous block went
+	MacrocycleSection string `prompt:"optional"`
+	// ScheduleSection lists the days the user is busy and cannot train
+	ScheduleSection string `prompt:"optional"`
+	// SafetyNotesSection lists the exercises with curated safety notes
+	SafetyNotesSection string `prompt:"optional"`
+	// PeriodizationSection describes the periodization style and the deload
+	// weeks asked for
+	PeriodizationSection string `prompt:"optional"`
+}
+
+// NutritionPromptData holds the variables available to nutrition plan
+// generation templates. Ratios are percentages.
+type NutritionPromptData struct {
+	PlanName      string  `prompt:"required"`
+	TotalDays     int     `prompt:"required"`
+	DailyCalories float64 `prompt:"required"`
+	ProteinRatio  float64
+	CarbRatio     float64
+	FatRatio      float64
+	// DietaryRestrictions and Preferences are formatted lists, empty if none
+	DietaryRestrictions string
+	Preferences         string
+	// LeftoverLunch asks for each dinner to be served again at the next
+	// day's lunch
+	LeftoverLunch bool
+	// WeeklyBudget is the weekly food budget in CNY, 0 if none; BudgetLevel
+	// is low, medium or high and only set without an amount
+	WeeklyBudget float64
+	BudgetLevel  string
+
+	HasBodyData bool   `prompt:"optional"`
+	Age         int    `prompt:"placeholder"`
+	Gender      string `prompt:"placeholder"`
+	Height      float64
+	Weight      float64
+
+	FitnessGoals []string `prompt:"optional"`
+
+	CheckInSection string `prompt:"optional"`
+}
+
+// TrainingAdjustmentPromptData holds the variables available to training
+// plan adjustment templates. CurrentPlan is the plan data as JSON and
+// CompletionRate a percentage; DifficultyRating is 0 when not given.
+type TrainingAdjustmentPromptData struct {
+	CurrentPlan      string `prompt:"required"`
+	TotalWeeks       int    `prompt:"required"`
+	StartDate        string `prompt:"required"`
+	CompletionRate   float64
+	DifficultyRating int
+	InjuryReport     string
+	Feedback         string
+	// RecentRecords summarises one logged workout per line
+	RecentRecords []string `prompt:"optional"`
+
+	HasCheckIn       bool `prompt:"optional"`
+	CheckInAdherence int
+	CheckInEnergy    int
+	CheckInHunger    int
+	CheckInNotes     string
+
+	ConstraintSection  string `prompt:"optional"`
+	ScheduleSection    string `prompt:"optional"`
+	SafetyNotesSection string `prompt:"optional"`
+	// ProgressionSection suggests next loads for the exercises in the logs
+	ProgressionSection string `prompt:"optional"`
+	// PeriodizationSection carries over the current plan's periodization
+	PeriodizationSection string `prompt:"optional"`
+}
+
+// NutritionAdjustmentPromptData holds the variables available to nutrition
+// plan adjustment templates. WeightChange is in kg and only meaningful when
+// HasWeightChange is set.
+type NutritionAdjustmentPromptData struct {
+	CurrentPlan        string `prompt:"required"`
+	TotalDays          int    `prompt:"required"`
+	StartDate          string `prompt:"required"`
+	CompletionRate     float64
+	SatisfactionRating int
+	LeftoverLunch      bool
+	WeeklyBudget       float64
+	BudgetLevel        string
+	HasWeightChange    bool `prompt:"optional"`
+	WeightChange       float64
+	Feedback           string
+	// RecentRecords summarises one logged day per line
+	RecentRecords []string `prompt:"optional"`
+
+	HasCheckIn       bool `prompt:"optional"`
+	CheckInAdherence int
+	CheckInEnergy    int
+	CheckInHunger    int
+	CheckInNotes     string
+}
+
+// NutritionDayPromptData holds the variables available to templates that
+// regenerate one day of a nutrition plan. CurrentDay is the day being
+// replaced as JSON; ratios are percentages.
+type NutritionDayPromptData struct {
+	PlanName      string  `prompt:"required"`
+	Date          string  `prompt:"required"`
+	DayNumber     int     `prompt:"required"`
+	CurrentDay    string  `prompt:"required"`
+	DailyCalories float64 `prompt:"required"`
+	ProteinRatio  float64
+	CarbRatio     float64
+	FatRatio      float64
+	// DietaryRestrictions and Preferences are formatted lists, empty if none
+	DietaryRestrictions string
+	Preferences         string
+	LeftoverLunch       bool
+	WeeklyBudget        float64
+	BudgetLevel         string
+	Feedback            string
+}
+
+// TrainingWeekPromptData holds the variables available to templates that
+// regenerate one week of a training plan. CurrentWeek and its neighbours are
+// JSON; a neighbour is empty at either end of the plan.
+type TrainingWeekPromptData struct {
+	PlanName     string `prompt:"required"`
+	Week         int    `prompt:"required"`
+	TotalWeeks   int    `prompt:"required"`
+	StartDate    string `prompt:"required"`
+	EndDate      string `prompt:"required"`
+	CurrentWeek  string `prompt:"required"`
+	PreviousWeek string `prompt:"optional"`
+	NextWeek     string `prompt:"optional"`
+	// Deload is set when the plan's periodization makes this a deload week
+	Deload       bool
+	InjuryReport string
+	Feedback     string
+	// RecentRecords summarises one logged workout per line
+	RecentRecords []string `prompt:"optional"`
+
+	ConstraintSection  string `prompt:"optional"`
+	ScheduleSection    string `prompt:"optional"`
+	SafetyNotesSection string `prompt:"optional"`
+	ProgressionSection string `prompt:"optional"`
+}
+
+// ExerciseSubstitutionPromptData holds the variables available to templates
+// that replace one exercise of a training plan day. CurrentDay and Exercise
+// are JSON; the equipment and joint lists are formatted, empty if none.
+type ExerciseSubstitutionPromptData struct {
+	PlanName             string `prompt:"required"`
+	Date                 string `prompt:"required"`
+	CurrentDay           string `prompt:"required"`
+	Exercise             string `prompt:"required"`
+	UnavailableEquipment string
+	AvoidJoints          string
+	Notes                string
+	ConstraintSection    string
+}
+
+// CoachPromptData holds the variables available to coach chat templates.
+// TodayTraining and TomorrowTraining summarise the scheduled workout and are
+// empty when none is scheduled. History holds earlier turns as "角色：内容".
+type CoachPromptData struct {
+	Date string `prompt:"required"`
+
+	HasTrainingPlan   bool `prompt:"optional"`
+	TrainingPlanName  string
+	TrainingPlanWeeks int
+	TodayTraining     string
+	TomorrowTraining  string
+
+	HasNutritionPlan  bool `prompt:"optional"`
+	NutritionPlanName string
+	DailyCalories     float64
+	// TodayMeals summarises one planned meal per line
+	TodayMeals []string
+
+	HasBodyData bool `prompt:"optional"`
+	Age         int
+	Gender      string `prompt:"placeholder"`
+	Height      float64
+	Weight      float64
+
+	FitnessGoals      []string `prompt:"optional"`
+	ConstraintSection string   `prompt:"optional"`
+
+	History  []string `prompt:"optional"`
+	Question string   `prompt:"required"`
+}
+
+// buildTrainingPlanPrompt builds the prompt for training plan generation and
+// returns the ID of the stored template it came from, nil for the built-in
+func (s *aiService) buildTrainingPlanPrompt(ctx context.Context, params *TrainingPlanParams) (string, *int64, error) {
+	data := TrainingPromptData{
+		PlanName:        params.PlanName,
+		Goal:            params.Goal,
+		DifficultyLevel: params.DifficultyLevel,
+		TotalWeeks:      params.DurationWeeks,
+		FitnessGoals:    fitnessGoalLines(params.FitnessGoals),
+	}
+
+	if a := params.Assessment; a != nil {
+		data.HasAssessment = true
+		data.ExperienceLevel = a.ExperienceLevel
+		data.WeeklyAvailableDays = a.WeeklyAvailableDays
+		data.DailyAvailableMinutes = a.DailyAvailableMinutes
+		if a.InjuryHistory != nil {
+			data.InjuryHistory = *a.InjuryHistory
+		}
+		if a.HealthConditions != nil {
+			data.HealthConditions = *a.HealthConditions
+		}
+		if len(params.EquipmentProfiles) == 0 && len(a.EquipmentAvailable) > 0 {
+			data.EquipmentAvailable = fmt.Sprintf("%v", a.EquipmentAvailable)
+		}
+	}
+
+	if b := params.BodyData; b != nil {
+		data.HasBodyData = true
+		data.Age = b.Age
+		data.Gender = b.Gender
+		data.Height = b.Height
+		data.Weight = b.Weight
+		if b.BodyFatPercentage != nil {
+			data.BodyFatPercentage = *b.BodyFatPercentage
+		}
+	}
+
+	if len(params.Constraints) > 0 {
+		data.ConstraintSection = constraintPromptSection(params.Constraints)
+	}
+	if len(params.EquipmentProfiles) > 0 {
+		data.EquipmentSection = equipmentPromptSection(params.EquipmentProfiles, time.Now())
+	}
+	if len(params.StrengthProfile) > 0 {
+		data.StrengthSection = strengthProfilePromptSection(params.StrengthProfile)
+	}
+	if params.LatestCheckIn != nil {
+		data.CheckInSection = checkInPromptSection(params.LatestCheckIn)
+	}
+	if params.Block != nil {
+		data.MacrocycleSection = macrocyclePromptSection(params.Block)
+	}
+	if params.BusySchedule != nil {
+		data.ScheduleSection = busySchedulePromptSection(params.BusySchedule, time.Now(), params.DurationWeeks*7)
+	}
+	data.SafetyNotesSection = safetyNotesPromptSection()
+	data.PeriodizationSection = periodizationPromptSection(params.Periodization, params.DurationWeeks)
+
+	return s.renderPrompt(ctx, params.UserID, model.PromptCategoryTraining, PromptSubcategoryPlanGeneration, builtinTrainingPlanTemplate, data)
+}
+
+// buildNutritionPlanPrompt builds the prompt for nutrition plan generation
+// and returns the ID of the stored template used
+func (s *aiService) buildNutritionPlanPrompt(ctx context.Context, params *NutritionPlanParams) (string, *int64, error) {
+	data := NutritionPromptData{
+		PlanName:      params.PlanName,
+		TotalDays:     params.DurationDays,
+		DailyCalories: params.DailyCalories,
+		ProteinRatio:  params.ProteinRatio * 100,
+		CarbRatio:     params.CarbRatio * 100,
+		FatRatio:      params.FatRatio * 100,
+		LeftoverLunch: params.LeftoverLunch,
+		FitnessGoals:  fitnessGoalLines(params.FitnessGoals),
+	}
+	if params.WeeklyBudget != nil {
+		data.WeeklyBudget = *params.WeeklyBudget
+	} else {
+		data.BudgetLevel = params.BudgetLevel
+	}
+	data.DietaryRestrictions = dietTermList(dietaryRestrictionTerms, interfaceSlice(params.DietaryRestrictions))
+	data.Preferences = dietTermList(cuisineTerms, interfaceSlice(params.Preferences))
+
+	if b := params.BodyData; b != nil {
+		data.HasBodyData = true
+		data.Age = b.Age
+		data.Gender = b.Gender
+		data.Height = b.Height
+		data.Weight = b.Weight
+	}
+
+	if params.LatestCheckIn != nil {
+		data.CheckInSection = checkInPromptSection(params.LatestCheckIn)
+	}
+
+	return s.renderPrompt(ctx, params.UserID, model.PromptCategoryNutrition, PromptSubcategoryPlanGeneration, builtinNutritionPlanTemplate, data)
+}
+
+// buildTrainingAdjustmentPrompt builds the prompt for adjusting a training
+// plan and returns the ID of the stored template used
+func (s *aiService) buildTrainingAdjustmentPrompt(ctx context.Context, params *TrainingAdjustmentParams) (string, *int64, error) {
+	currentPlan, err := json.Marshal(params.Plan.PlanData)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode current plan: %w", err)
+	}
+
+	data := TrainingAdjustmentPromptData{
+		CurrentPlan:      string(currentPlan),
+		TotalWeeks:       params.Plan.TotalWeeks,
+		StartDate:        params.StartDate.Format("2006-01-02"),
+		CompletionRate:   math.Round(params.CompletionRate*10) / 10,
+		DifficultyRating: params.DifficultyRating,
+		InjuryReport:     params.InjuryReport,
+		Feedback:         params.Feedback,
+		RecentRecords:    params.RecentRecords,
+	}
+	if c := params.LatestCheckIn; c != nil {
+		data.HasCheckIn = true
+		data.CheckInAdherence = c.AdherenceRating
+		data.CheckInEnergy = c.EnergyLevel
+		data.CheckInHunger = c.HungerLevel
+		if c.Notes != nil {
+			data.CheckInNotes = *c.Notes
+		}
+	}
+	if len(params.Constraints) > 0 {
+		data.ConstraintSection = constraintPromptSection(params.Constraints)
+	}
+	if params.BusySchedule != nil {
+		data.ScheduleSection = busySchedulePromptSection(params.BusySchedule, params.StartDate, params.Plan.TotalWeeks*7)
+	}
+	data.SafetyNotesSection = safetyNotesPromptSection()
+	data.ProgressionSection = progressionPromptSection(params.Progressions)
+	data.PeriodizationSection = periodizationPromptSection(planPeriodization(params.Plan.PlanData), params.Plan.TotalWeeks)
+
+	return s.renderPrompt(ctx, params.UserID, model.PromptCategoryTraining, PromptSubcategoryAdjustment, builtinTrainingAdjustmentTemplate, data)
+}
+
+// buildNutritionAdjustmentPrompt builds the prompt for adjusting a
+// nutrition plan and returns the ID of the stored template used
+func (s *aiService) buildNutritionAdjustmentPrompt(ctx context.Context, params *NutritionAdjustmentParams) (string, *int64, error) {
+	currentPlan, err := json.Marshal(params.Plan.PlanData)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode current plan: %w", err)
+	}
+
+	data := NutritionAdjustmentPromptData{
+		CurrentPlan:        string(currentPlan),
+		TotalDays:          planDurationDays(params.Plan),
+		StartDate:          params.StartDate.Format("2006-01-02"),
+		CompletionRate:     math.Round(params.CompletionRate*10) / 10,
+		SatisfactionRating: params.SatisfactionRating,
+		LeftoverLunch:      params.Plan.LeftoverLunch,
+		Feedback:           params.Feedback,
+		RecentRecords:      params.RecentRecords,
+	}
+	data.WeeklyBudget, data.BudgetLevel = planBudget(params.Plan)
+	if params.WeightChange != nil {
+		data.HasWeightChange = true
+		data.WeightChange = *params.WeightChange
+	}
+	if c := params.LatestCheckIn; c != nil {
+		data.HasCheckIn = true
+		data.CheckInAdherence = c.AdherenceRating
+		data.CheckInEnergy = c.EnergyLevel
+		data.CheckInHunger = c.HungerLevel
+		if c.Notes != nil {
+			data.CheckInNotes = *c.Notes
+		}
+	}
+
+	return s.renderPrompt(ctx, params.UserID, model.PromptCategoryNutrition, PromptSubcategoryAdjustment, builtinNutritionAdjustmentTemplate, data)
+}
+
+// buildNutritionDayPrompt builds the prompt for regenerating one day of a
+// nutrition plan
+func (s *aiService) buildNutritionDayPrompt(ctx context.Context, params *NutritionDayParams) (string, error) {
+	currentDay, err := json.Marshal(params.CurrentDay)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode current day: %w", err)
+	}
+
+	plan := params.Plan
+	data := NutritionDayPromptData{
+		PlanName:            plan.PlanName,
+		Date:                params.Date.Format("2006-01-02"),
+		DayNumber:           params.DayNumber,
+		CurrentDay:          string(currentDay),
+		DailyCalories:       plan.DailyCalories,
+		ProteinRatio:        plan.ProteinRatio * 100,
+		CarbRatio:           plan.CarbRatio * 100,
+		FatRatio:            plan.FatRatio * 100,
+		DietaryRestrictions: planDietaryRestrictions(plan),
+		Preferences:         planPreferences(plan),
+		LeftoverLunch:       plan.LeftoverLunch,
+		Feedback:            params.Feedback,
+	}
+	data.WeeklyBudget, data.BudgetLevel = planBudget(plan)
+
+	prompt, _, err := s.renderPrompt(ctx, params.UserID, model.PromptCategoryNutrition, PromptSubcategoryDayRegeneration, builtinNutritionDayTemplate, data)
+	return prompt, err
+}
+
+// buildTrainingWeekPrompt builds the prompt for regenerating one week of a
+// training plan
+func (s *aiService) buildTrainingWeekPrompt(ctx context.Context, params *TrainingWeekParams) (string, error) {
+	encode := func(week map[string]interface{}) (string, error) {
+		if week == nil {
+			return "", nil
+		}
+		encoded, err := json.Marshal(week)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode week: %w", err)
+		}
+		return string(encoded), nil
+	}
+	currentWeek, err := encode(params.CurrentWeek)
+	if err != nil {
+		return "", err
+	}
+	previousWeek, err := encode(params.PreviousWeek)
+	if err != nil {
+		return "", err
+	}
+	nextWeek, err := encode(params.NextWeek)
+	if err != nil {
+		return "", err
+	}
+
+	data := TrainingWeekPromptData{
+		PlanName:      params.Plan.PlanName,
+		Week:          params.Week,
+		TotalWeeks:    params.Plan.TotalWeeks,
+		StartDate:     params.StartDate.Format("2006-01-02"),
+		EndDate:       params.StartDate.AddDate(0, 0, 6).Format("2006-01-02"),
+		CurrentWeek:   currentWeek,
+		PreviousWeek:  previousWeek,
+		NextWeek:      nextWeek,
+		Deload:        params.Deload,
+		InjuryReport:  params.InjuryReport,
+		Feedback:      params.Feedback,
+		RecentRecords: params.RecentRecords,
+	}
+	if len(params.Constraints) > 0 {
+		data.ConstraintSection = constraintPromptSection(params.Constraints)
+	}
+	if params.BusySchedule != nil {
+		data.ScheduleSection = busySchedulePromptSection(params.BusySchedule, params.StartDate, 7)
+	}
+	data.SafetyNotesSection = safetyNotesPromptSection()
+	data.ProgressionSection = progressionPromptSection(params.Progressions)
+
+	prompt, _, err := s.renderPrompt(ctx, params.UserID, model.PromptCategoryTraining, PromptSubcategoryWeekRegeneration, builtinTrainingWeekTemplate, data)
+	return prompt, err
+}
+
+// buildExerciseSubstitutionPrompt builds the prompt for replacing one
+// exercise of a training plan day
+func (s *aiService) buildExerciseSubstitutionPrompt(ctx context.Context, params *ExerciseSubstitutionParams) (string, error) {
+	currentDay, err := json.Marshal(params.CurrentDay)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode current day: %w", err)
+	}
+	exercise, err := json.Marshal(params.Exercise)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode exercise: %w", err)
+	}
+
+	data := ExerciseSubstitutionPromptData{
+		PlanName:             params.Plan.PlanName,
+		Date:                 params.Date.Format("2006-01-02"),
+		CurrentDay:           string(currentDay),
+		Exercise:             string(exercise),
+		UnavailableEquipment: strings.Join(exerciseTermLabels(params.UnavailableEquipment, equipmentTerms), ", "),
+		AvoidJoints:          strings.Join(exerciseTermLabels(params.AvoidJoints, jointTerms), ", "),
+		Notes:                params.Notes,
+	}
+	if len(params.Constraints) > 0 {
+		data.ConstraintSection = constraintPromptSection(params.Constraints)
+	}
+
+	prompt, _, err := s.renderPrompt(ctx, params.UserID, model.PromptCategoryTraining, PromptSubcategoryExerciseSubstitution, builtinExerciseSubstitutionTemplate, data)
+	return prompt, err
+}
+
+// buildCoachPrompt builds the prompt for a coach chat reply
+func (s *aiService) buildCoachPrompt(ctx context.Context, params *CoachChatParams) (string, error) {
+	data := CoachPromptData{
+		Date:         params.Now.Format("2006-01-02"),
+		FitnessGoals: fitnessGoalLines(params.FitnessGoals),
+		Question:     params.Message,
+	}
+	if plan := params.TrainingPlan; plan != nil {
+		data.HasTrainingPlan = true
+		data.TrainingPlanName = plan.PlanName
+		data.TrainingPlanWeeks = plan.TotalWeeks
+		data.TodayTraining = coachDayPlanSummary(params.TodayTraining)
+		data.TomorrowTraining = coachDayPlanSummary(params.TomorrowTraining)
+	}
+	if plan := params.NutritionPlan; plan != nil {
+		data.HasNutritionPlan = true
+		data.NutritionPlanName = plan.PlanName
+		data.DailyCalories = plan.DailyCalories
+		data.TodayMeals = coachMealLines(params.TodayMeals)
+	}
+	if b := params.BodyData; b != nil {
+		data.HasBodyData = true
+		data.Age = b.Age
+		data.Gender = b.Gender
+		data.Height = b.Height
+		data.Weight = b.Weight
+	}
+	if len(params.Constraints) > 0 {
+		data.ConstraintSection = constraintPromptSection(params.Constraints)
+	}
+	for _, message := range params.History {
+		speaker := "学员"
+		if message.Role == model.CoachRoleAssistant {
+			speaker = "教练"
+		}
+		data.History = append(data.History, speaker+"："+message.Content)
+	}
+
+	prompt, _, err := s.renderPrompt(ctx, params.UserID, model.PromptCategoryCoach, PromptSubcategoryChat, builtinCoachChatTemplate, data)
+	return prompt, err
+}
+
+// renderPrompt renders the stored template for category and subcategory
+// that userID is served: the rollout variant the user is assigned to, or
+// else the default. It returns the ID of the template rendered. A missing
+// template, a lookup failure or a template that does not render falls back
+// to the next candidate and finally to the built-in template, with a nil ID,
+// so a bad edit in the database never blocks generation. Data missing a
+// required variable fails before any template is tried.
+func (s *aiService) renderPrompt(ctx context.Context, userID int64, category model.PromptCategory, subcategory, builtin string, data interface{}) (string, *int64, error) {
+	assembled, err := assemblePromptVariables(data)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(assembled.omitted) > 0 {
+		logger.Info("Building prompt without optional context",
+			zap.String("category", string(category)),
+			zap.String("subcategory", subcategory),
+			zap.Strings("omitted", assembled.omitted),
+		)
+	}
+
+	for _, stored := range s.servedTemplates(ctx, userID, category, subcategory) {
+		prompt, err := renderTemplate(stored.Name, stored.Template, assembled.vars)
+		if err == nil {
+			id := stored.ID
+			return prompt, &id, nil
+		}
+		logger.Warn("Stored prompt template failed to render, trying the next",
+			zap.Int64("template_id", stored.ID),
+			zap.String("template_name", stored.Name),
+			zap.Error(err),
+		)
+	}
+
+	text, err := migration.TemplateText(builtin)
+	if err != nil {
+		return "", nil, err
+	}
+	prompt, err := renderTemplate(builtin, text, assembled.vars)
+	return prompt, nil, err
+}
+
+// renderTemplate parses and executes a text/template prompt
+func renderTemplate(name, text string, data interface{}) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse prompt template %s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", name, err)
+	}
+	if err := checkRenderedPrompt(name, buf.String()); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// fitnessGoalLines formats goals for the prompt templates
+func fitnessGoalLines(goals []*model.FitnessGoal) []string {
+	lines := make([]string, 0, len(goals))
+	for _, goal := range goals {
+		line := goal.GoalType
+		if goal.GoalDescription != nil {
+			line += ": " + *goal.GoalDescription
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// planBudget returns a nutrition plan's food budget as prompt variables: the
+// weekly amount when one was given, otherwise the level
+func planBudget(plan *model.NutritionPlan) (weeklyBudget float64, level string) {
+	if plan.WeeklyBudget != nil {
+		return *plan.WeeklyBudget, ""
+	}
+	if plan.BudgetLevel != nil {
+		return 0, *plan.BudgetLevel
+	}
+	return 0, ""
+}
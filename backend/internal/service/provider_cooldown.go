@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// maxCooldownWait is the longest Retry-After a generation waits out. A
+// provider asking for more, typically because a quota is used up, fails the
+// generation instead of leaving it pending.
+const maxCooldownWait = 2 * time.Minute
+
+// ProviderCooldown describes a pause in calls to an AI provider that
+// throttled a request
+type ProviderCooldown struct {
+	Provider string    `json:"provider"`
+	Until    time.Time `json:"until"`
+}
+
+// providerCooldowns tracks, per API endpoint, until when a throttled
+// provider should be left alone. It is shared by all generations so a 429
+// seen by one holds back the others instead of each hitting the limit.
+type providerCooldowns struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+func newProviderCooldowns() *providerCooldowns {
+	return &providerCooldowns{until: make(map[string]time.Time)}
+}
+
+// extend moves the cooldown for key to until, unless it already ends later
+func (c *providerCooldowns) extend(key string, until time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if until.After(c.until[key]) {
+		c.until[key] = until
+	}
+}
+
+// get returns when the cooldown for key ends, or the zero time if there is
+// none in force at now
+func (c *providerCooldowns) get(key string, now time.Time) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	until, ok := c.until[key]
+	if !ok {
+		return time.Time{}
+	}
+	if !until.After(now) {
+		delete(c.until, key)
+		return time.Time{}
+	}
+	return until
+}
+
+// waitForAttempt sleeps before a call to aiAPI. Retries wait the provider's
+// tuned backoff; any attempt also waits out a cooldown in force for the API,
+// which is reported through onCooldown while it lasts.
+func (s *aiService) waitForAttempt(ctx context.Context, aiAPI *model.AIAPI, attempt int, onCooldown func(*ProviderCooldown)) error {
+	now := time.Now()
+	var wait time.Duration
+	if attempt > 0 {
+		wait = s.retryTuner.Backoff(aiAPI.Provider, attempt)
+	}
+
+	if until := s.cooldowns.get(CircuitKey(aiAPI), now); !until.IsZero() {
+		if remaining := until.Sub(now); remaining > wait {
+			wait = remaining
+		}
+		if onCooldown != nil {
+			onCooldown(&ProviderCooldown{Provider: aiAPI.Provider, Until: now.Add(wait)})
+			defer onCooldown(nil)
+		}
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// startCooldown puts aiAPI in cooldown after a throttled call. The pause is
+// the provider's Retry-After, or the backoff for the next attempt when it
+// sent none. It returns an error if the provider asks for longer than
+// maxCooldownWait.
+func (s *aiService) startCooldown(aiAPI *model.AIAPI, attempt int, err error) error {
+	var aiErr *AIError
+	if !errors.As(err, &aiErr) {
+		return nil
+	}
+	wait := aiErr.RetryAfter
+	if wait == 0 {
+		if !errors.Is(err, ErrProviderRateLimited) {
+			return nil
+		}
+		wait = s.retryTuner.Backoff(aiAPI.Provider, attempt+1)
+	}
+	if wait > maxCooldownWait {
+		return fmt.Errorf("AI API asked to retry after %s, not waiting: %w", wait.Round(time.Second), err)
+	}
+
+	s.cooldowns.extend(CircuitKey(aiAPI), time.Now().Add(wait))
+	return nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+)
+
+// ProviderPolicy is the deployment's allowlist of AI providers, for
+// organizations whose data must not leave a region or their own servers. A
+// nil policy allows every supported provider.
+type ProviderPolicy struct {
+	allowed map[string]bool
+	// names keeps the configured order for reporting
+	names []string
+}
+
+// NewProviderPolicy builds the policy allowing only the listed providers.
+// It returns nil, allowing all, when the list is empty, and an error when it
+// names a provider GetAIClient does not support, so a typo cannot silently
+// block every provider.
+func NewProviderPolicy(allowed []string) (*ProviderPolicy, error) {
+	if len(allowed) == 0 {
+		return nil, nil
+	}
+
+	supported := make(map[string]bool, len(SupportedAIProviders))
+	for _, provider := range SupportedAIProviders {
+		supported[provider] = true
+	}
+
+	p := &ProviderPolicy{allowed: make(map[string]bool, len(allowed))}
+	for _, provider := range allowed {
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if !supported[provider] {
+			return nil, fmt.Errorf("unsupported AI provider %q in ai.allowed_providers, expected one of %s", provider, strings.Join(SupportedAIProviders, ", "))
+		}
+		if !p.allowed[provider] {
+			p.allowed[provider] = true
+			p.names = append(p.names, provider)
+		}
+	}
+	return p, nil
+}
+
+// Allows reports whether AI APIs of the provider may be configured and called
+func (p *ProviderPolicy) Allows(provider string) bool {
+	return p == nil || p.allowed[provider]
+}
+
+// AllowedProviders lists the providers the policy allows
+func (p *ProviderPolicy) AllowedProviders() []string {
+	if p == nil {
+		return SupportedAIProviders
+	}
+	return p.names
+}
+
+// Check returns the policy error for a provider the deployment does not
+// allow, or nil
+func (p *ProviderPolicy) Check(provider string) error {
+	if p.Allows(provider) {
+		return nil
+	}
+	return errors.New(errors.ErrAIProviderNotAllowed,
+		fmt.Sprintf("本站点的数据合规策略不允许使用AI服务商 %s，可用的服务商：%s", provider, strings.Join(p.names, ", ")))
+}
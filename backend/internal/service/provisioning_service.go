@@ -0,0 +1,420 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/pkg/mailer"
+	"github.com/ai-fitness-planner/backend/internal/pkg/session"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxProvisionBatch caps the users handled by one provisioning request
+const maxProvisionBatch = 500
+
+var (
+	provisionEmailRegex    = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
+	provisionUsernameRegex = regexp.MustCompile(`^[a-zA-Z0-9]{3,20}$`)
+)
+
+// ProvisionUser is one user an admin provisions for an organization. An empty
+// Username is derived from the email's local part.
+type ProvisionUser struct {
+	Email    string
+	Username string
+	Nickname string
+}
+
+// ProvisionedUser reports a user activated by provisioning
+type ProvisionedUser struct {
+	Email          string
+	UserID         int64
+	Reactivated    bool
+	InvitationSent bool
+}
+
+// ProvisionIssue reports a user that was skipped or rejected, and why
+type ProvisionIssue struct {
+	Email  string
+	Reason string
+}
+
+// ProvisionResult summarizes a provisioning batch
+type ProvisionResult struct {
+	Provisioned []ProvisionedUser
+	Skipped     []ProvisionIssue
+	Rejected    []ProvisionIssue
+	SeatsUsed   int64
+	SeatLimit   int
+}
+
+// DeactivationResult summarizes a deactivation batch
+type DeactivationResult struct {
+	Deactivated []string
+	Rejected    []ProvisionIssue
+	SeatsUsed   int64
+}
+
+// OrganizationSummary is an organization with its seat usage
+type OrganizationSummary struct {
+	Organization  *model.Organization
+	ActiveMembers int64
+}
+
+// ProvisioningService defines bulk user provisioning for enterprise and gym
+// organizations
+type ProvisioningService interface {
+	CreateOrganization(ctx context.Context, name string, seatLimit int) (*model.Organization, error)
+	ListOrganizations(ctx context.Context) ([]*OrganizationSummary, error)
+	// ProvisionUsers creates or reactivates users in the organization and
+	// emails each an invitation to set a password. Users beyond the seat
+	// limit are rejected; the rest of the batch still goes through.
+	ProvisionUsers(ctx context.Context, adminID, orgID int64, users []ProvisionUser) (*ProvisionResult, error)
+	// DeactivateUsers disables members by email and ends their sessions
+	DeactivateUsers(ctx context.Context, orgID int64, emails []string) (*DeactivationResult, error)
+	// AcceptInvitation sets the invited user's password
+	AcceptInvitation(ctx context.Context, token, password string) error
+}
+
+// provisioningService implements ProvisioningService interface
+type provisioningService struct {
+	orgRepo        repository.OrganizationRepository
+	userRepo       repository.UserRepository
+	sessionManager session.SessionManager
+	mailer         mailer.Mailer
+	inviteTTL      time.Duration
+	acceptURL      string
+}
+
+// NewProvisioningService creates a new instance of ProvisioningService
+func NewProvisioningService(
+	orgRepo repository.OrganizationRepository,
+	userRepo repository.UserRepository,
+	sessionManager session.SessionManager,
+	mailer mailer.Mailer,
+	inviteTTL time.Duration,
+	acceptURL string,
+) ProvisioningService {
+	if inviteTTL <= 0 {
+		inviteTTL = 7 * 24 * time.Hour
+	}
+	return &provisioningService{
+		orgRepo:        orgRepo,
+		userRepo:       userRepo,
+		sessionManager: sessionManager,
+		mailer:         mailer,
+		inviteTTL:      inviteTTL,
+		acceptURL:      acceptURL,
+	}
+}
+
+// CreateOrganization creates an organization with a seat limit
+func (s *provisioningService) CreateOrganization(ctx context.Context, name string, seatLimit int) (*model.Organization, error) {
+	existing, err := s.orgRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取组织失败")
+	}
+	if existing != nil {
+		return nil, errors.New(errors.ErrConflict, "组织名称已存在")
+	}
+
+	org := &model.Organization{Name: name, SeatLimit: seatLimit}
+	if err := s.orgRepo.Create(ctx, org); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "创建组织失败")
+	}
+	return org, nil
+}
+
+// ListOrganizations returns all organizations with their active member counts
+func (s *provisioningService) ListOrganizations(ctx context.Context) ([]*OrganizationSummary, error) {
+	orgs, err := s.orgRepo.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取组织列表失败")
+	}
+
+	summaries := make([]*OrganizationSummary, 0, len(orgs))
+	for _, org := range orgs {
+		active, err := s.orgRepo.CountActiveMembers(ctx, org.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "统计组织席位失败")
+		}
+		summaries = append(summaries, &OrganizationSummary{Organization: org, ActiveMembers: active})
+	}
+	return summaries, nil
+}
+
+// ProvisionUsers activates each user in turn so a full organization rejects
+// only the users past the limit
+func (s *provisioningService) ProvisionUsers(ctx context.Context, adminID, orgID int64, users []ProvisionUser) (*ProvisionResult, error) {
+	if len(users) == 0 {
+		return nil, errors.New(errors.ErrInvalidParam, "用户列表不能为空")
+	}
+	if len(users) > maxProvisionBatch {
+		return nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("单次最多导入%d个用户", maxProvisionBatch))
+	}
+
+	org, err := s.getOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ProvisionResult{SeatLimit: org.SeatLimit}
+	seen := make(map[string]bool, len(users))
+	for _, u := range users {
+		email := strings.ToLower(strings.TrimSpace(u.Email))
+		if seen[email] {
+			result.Skipped = append(result.Skipped, ProvisionIssue{Email: email, Reason: "重复的邮箱"})
+			continue
+		}
+		seen[email] = true
+
+		provisioned, issue, err := s.provisionUser(ctx, adminID, org, email, u)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case provisioned != nil:
+			result.Provisioned = append(result.Provisioned, *provisioned)
+		case issue.skipped:
+			result.Skipped = append(result.Skipped, issue.ProvisionIssue)
+		default:
+			result.Rejected = append(result.Rejected, issue.ProvisionIssue)
+		}
+	}
+
+	result.SeatsUsed, err = s.orgRepo.CountActiveMembers(ctx, orgID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "统计组织席位失败")
+	}
+	return result, nil
+}
+
+// provisionIssue is a ProvisionIssue plus whether it is a harmless skip
+type provisionIssue struct {
+	ProvisionIssue
+	skipped bool
+}
+
+// provisionUser provisions one user. Only storage errors are returned; a user
+// that cannot be provisioned is reported through the issue.
+func (s *provisioningService) provisionUser(ctx context.Context, adminID int64, org *model.Organization, email string, u ProvisionUser) (*ProvisionedUser, provisionIssue, error) {
+	reject := func(reason string) (*ProvisionedUser, provisionIssue, error) {
+		return nil, provisionIssue{ProvisionIssue: ProvisionIssue{Email: email, Reason: reason}}, nil
+	}
+
+	if !provisionEmailRegex.MatchString(email) {
+		return reject("邮箱格式不正确")
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, provisionIssue{}, errors.Wrap(err, errors.ErrDatabase, "获取用户失败")
+	}
+	reactivated := user != nil
+	if user != nil {
+		if user.OrganizationID == nil || *user.OrganizationID != org.ID {
+			return reject("邮箱已被其他账号使用")
+		}
+		if user.Status == 1 {
+			return nil, provisionIssue{ProvisionIssue: ProvisionIssue{Email: email, Reason: "用户已启用"}, skipped: true}, nil
+		}
+	} else {
+		username := strings.TrimSpace(u.Username)
+		if username == "" {
+			username = strings.SplitN(email, "@", 2)[0]
+		}
+		if !provisionUsernameRegex.MatchString(username) {
+			return reject("用户名需为3-20位字母或数字")
+		}
+		existing, err := s.userRepo.GetByUsername(ctx, username)
+		if err != nil {
+			return nil, provisionIssue{}, errors.Wrap(err, errors.ErrDatabase, "获取用户失败")
+		}
+		if existing != nil {
+			return reject("用户名已存在")
+		}
+
+		// Unusable until the invitation is accepted
+		passwordHash, err := randomPasswordHash()
+		if err != nil {
+			return nil, provisionIssue{}, errors.Wrap(err, errors.ErrInternalServer, "生成初始密码失败")
+		}
+		user = &model.User{
+			Username:     username,
+			Email:        email,
+			PasswordHash: passwordHash,
+			Role:         model.UserRoleUser,
+			WeekStart:    model.WeekStartMonday,
+		}
+		if nickname := strings.TrimSpace(u.Nickname); nickname != "" {
+			user.Nickname = &nickname
+		}
+	}
+
+	token, tokenHash, err := newInvitationToken()
+	if err != nil {
+		return nil, provisionIssue{}, errors.Wrap(err, errors.ErrInternalServer, "生成邀请令牌失败")
+	}
+	invitation := &model.UserInvitation{
+		TokenHash: tokenHash,
+		InvitedBy: adminID,
+		ExpiresAt: time.Now().Add(s.inviteTTL),
+	}
+
+	if err := s.orgRepo.AddMember(ctx, org.ID, user, invitation); err != nil {
+		if err == repository.ErrSeatLimitReached {
+			return reject("组织席位已满")
+		}
+		return nil, provisionIssue{}, errors.Wrap(err, errors.ErrDatabase, "创建组织用户失败")
+	}
+
+	return &ProvisionedUser{
+		Email:          email,
+		UserID:         user.ID,
+		Reactivated:    reactivated,
+		InvitationSent: s.sendInvitation(ctx, org, user, token),
+	}, provisionIssue{}, nil
+}
+
+// sendInvitation emails the invitation link. A failed send does not undo the
+// provisioning; the admin sees InvitationSent false and can provision again.
+func (s *provisioningService) sendInvitation(ctx context.Context, org *model.Organization, user *model.User, token string) bool {
+	subject := fmt.Sprintf("%s 邀请您加入 AI 健身计划", org.Name)
+	body := fmt.Sprintf(
+		"%s，您好：\n\n%s 已为您开通 AI 健身计划账号（用户名：%s）。\n请在 %s 前打开以下链接设置密码：\n\n%s?token=%s\n",
+		user.Username, org.Name, user.Username,
+		time.Now().Add(s.inviteTTL).Format("2006-01-02 15:04"),
+		s.acceptURL, token,
+	)
+	if err := s.mailer.Send(ctx, user.Email, subject, body); err != nil {
+		logger.Warn("Failed to send organization invitation",
+			zap.Int64("organization_id", org.ID),
+			zap.Int64("user_id", user.ID),
+			zap.Error(err),
+		)
+		return false
+	}
+	return true
+}
+
+// DeactivateUsers disables each listed member of the organization
+func (s *provisioningService) DeactivateUsers(ctx context.Context, orgID int64, emails []string) (*DeactivationResult, error) {
+	if len(emails) == 0 {
+		return nil, errors.New(errors.ErrInvalidParam, "用户列表不能为空")
+	}
+	if len(emails) > maxProvisionBatch {
+		return nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("单次最多停用%d个用户", maxProvisionBatch))
+	}
+	if _, err := s.getOrganization(ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	result := &DeactivationResult{}
+	for _, raw := range emails {
+		email := strings.ToLower(strings.TrimSpace(raw))
+		user, err := s.userRepo.GetByEmail(ctx, email)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "获取用户失败")
+		}
+		if user == nil || user.OrganizationID == nil || *user.OrganizationID != orgID {
+			result.Rejected = append(result.Rejected, ProvisionIssue{Email: email, Reason: "用户不属于该组织"})
+			continue
+		}
+
+		if err := s.orgRepo.DeactivateMember(ctx, orgID, user.ID); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "停用用户失败")
+		}
+		if err := s.sessionManager.DeleteAllUserSessions(ctx, user.ID); err != nil {
+			logger.Warn("Failed to end sessions of deactivated user",
+				zap.Int64("user_id", user.ID),
+				zap.Error(err),
+			)
+		}
+		result.Deactivated = append(result.Deactivated, email)
+	}
+
+	var err error
+	result.SeatsUsed, err = s.orgRepo.CountActiveMembers(ctx, orgID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "统计组织席位失败")
+	}
+	return result, nil
+}
+
+// AcceptInvitation checks the token and sets the user's password
+func (s *provisioningService) AcceptInvitation(ctx context.Context, token, password string) error {
+	sum := sha256.Sum256([]byte(token))
+	invitation, err := s.orgRepo.GetInvitationByTokenHash(ctx, hex.EncodeToString(sum[:]))
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase, "获取邀请失败")
+	}
+	if invitation == nil || invitation.AcceptedAt != nil || time.Now().After(invitation.ExpiresAt) {
+		return errors.New(errors.ErrInvalidParam, "邀请链接无效或已过期")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, invitation.UserID)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase, "获取用户失败")
+	}
+	if user == nil || user.Status != 1 {
+		return errors.ErrUserDisabled
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer, "failed to hash password")
+	}
+	if err := s.orgRepo.AcceptInvitation(ctx, invitation, string(passwordHash)); err != nil {
+		if err == repository.ErrInvitationUsed {
+			return errors.New(errors.ErrInvalidParam, "邀请链接无效或已过期")
+		}
+		return errors.Wrap(err, errors.ErrDatabase, "设置密码失败")
+	}
+	return nil
+}
+
+func (s *provisioningService) getOrganization(ctx context.Context, orgID int64) (*model.Organization, error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取组织失败")
+	}
+	if org == nil {
+		return nil, errors.New(errors.ErrNotFound, "组织不存在")
+	}
+	return org, nil
+}
+
+// newInvitationToken returns a random token and the SHA-256 hex stored for it
+func newInvitationToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(buf)
+	sum := sha256.Sum256([]byte(token))
+	return token, hex.EncodeToString(sum[:]), nil
+}
+
+// randomPasswordHash hashes a random secret nobody knows
+func randomPasswordHash() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(buf)), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
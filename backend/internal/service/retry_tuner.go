@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RetryTunerConfig bounds the per-provider retry backoff base
+type RetryTunerConfig struct {
+	BaseDelay time.Duration // starting point before any observations
+	MinDelay  time.Duration
+	MaxDelay  time.Duration
+	Window    int // number of recent calls considered per provider
+}
+
+// ProviderRetryTuner tracks recent call latency and 429 frequency per
+// provider and derives a retry backoff base from them, so retries back off
+// harder while a provider is slow or throttling and recover once it is healthy.
+type ProviderRetryTuner struct {
+	cfg       RetryTunerConfig
+	mu        sync.Mutex
+	providers map[string]*providerWindow
+}
+
+// providerWindow is a fixed-size ring of recent call observations
+type providerWindow struct {
+	latencies   []time.Duration
+	rateLimited []bool
+	next        int
+	filled      bool
+}
+
+// ProviderRetryStats is a point-in-time view of a provider's tuning inputs
+type ProviderRetryStats struct {
+	Samples       int           `json:"samples"`
+	P95Latency    time.Duration `json:"p95_latency"`
+	RateLimitRate float64       `json:"rate_limit_rate"`
+	RetryDelay    time.Duration `json:"retry_delay"`
+}
+
+// NewProviderRetryTuner creates a tuner with the given bounds
+func NewProviderRetryTuner(cfg RetryTunerConfig) *ProviderRetryTuner {
+	if cfg.Window <= 0 {
+		cfg.Window = 50
+	}
+	if cfg.MinDelay <= 0 {
+		cfg.MinDelay = cfg.BaseDelay
+	}
+	if cfg.MaxDelay < cfg.MinDelay {
+		cfg.MaxDelay = cfg.MinDelay
+	}
+	return &ProviderRetryTuner{
+		cfg:       cfg,
+		providers: make(map[string]*providerWindow),
+	}
+}
+
+// Observe records the outcome of a single provider call
+func (t *ProviderRetryTuner) Observe(provider string, latency time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w, ok := t.providers[provider]
+	if !ok {
+		w = &providerWindow{
+			latencies:   make([]time.Duration, t.cfg.Window),
+			rateLimited: make([]bool, t.cfg.Window),
+		}
+		t.providers[provider] = w
+	}
+
+	w.latencies[w.next] = latency
+	w.rateLimited[w.next] = errors.Is(err, ErrProviderRateLimited)
+	w.next = (w.next + 1) % t.cfg.Window
+	if w.next == 0 {
+		w.filled = true
+	}
+}
+
+// Backoff returns the delay before the given retry attempt (1-based)
+func (t *ProviderRetryTuner) Backoff(provider string, attempt int) time.Duration {
+	base := t.Stats(provider).RetryDelay
+	return time.Duration(math.Pow(2, float64(attempt-1))) * base
+}
+
+// Stats returns the current tuning inputs and resulting delay for a provider
+func (t *ProviderRetryTuner) Stats(provider string) ProviderRetryStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := ProviderRetryStats{RetryDelay: t.clamp(t.cfg.BaseDelay)}
+
+	w, ok := t.providers[provider]
+	if !ok {
+		return stats
+	}
+
+	n := w.next
+	if w.filled {
+		n = t.cfg.Window
+	}
+	if n == 0 {
+		return stats
+	}
+
+	latencies := make([]time.Duration, n)
+	copy(latencies, w.latencies[:n])
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	limited := 0
+	for _, rl := range w.rateLimited[:n] {
+		if rl {
+			limited++
+		}
+	}
+
+	stats.Samples = n
+	stats.P95Latency = latencies[int(math.Ceil(0.95*float64(n)))-1]
+	stats.RateLimitRate = float64(limited) / float64(n)
+
+	// Throttling scales the base up to 5x; slow responses set a floor of a
+	// quarter of p95 so retries do not pile onto a provider that is already lagging.
+	delay := time.Duration(float64(t.cfg.BaseDelay) * (1 + 4*stats.RateLimitRate))
+	if floor := stats.P95Latency / 4; delay < floor {
+		delay = floor
+	}
+	stats.RetryDelay = t.clamp(delay)
+
+	return stats
+}
+
+func (t *ProviderRetryTuner) clamp(d time.Duration) time.Duration {
+	if d < t.cfg.MinDelay {
+		return t.cfg.MinDelay
+	}
+	if d > t.cfg.MaxDelay {
+		return t.cfg.MaxDelay
+	}
+	return d
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Feedback sent with an automatic rollover, in place of what a user would
+// write when adjusting a plan by hand
+const (
+	trainingRolloverFeedback  = "上一周期训练计划已结束，请参考该计划和近期训练记录安排下一周期，在完成情况良好的动作上适当推进负荷。"
+	nutritionRolloverFeedback = "上一周期饮食计划已结束，请参考该计划和近期饮食记录安排下一周期，保持原有目标并调整执行困难的餐次。"
+)
+
+// RolloverService generates the next block of plans that have ended, for
+// users who opted in to automatic rollover
+type RolloverService interface {
+	// RollOverEndedPlans enqueues a successor for every training and
+	// nutrition plan that ended recently, and returns how many were queued
+	RollOverEndedPlans(ctx context.Context) (int, error)
+}
+
+// rolloverService implements RolloverService interface
+type rolloverService struct {
+	trainingPlanRepo  repository.TrainingPlanRepository
+	nutritionPlanRepo repository.NutritionPlanRepository
+	trainingService   TrainingService
+	nutritionService  NutritionService
+	lookbackDays      int
+}
+
+// NewRolloverService creates a new instance of RolloverService.
+// Plans that ended more than lookbackDays ago are not rolled over, so turning
+// the option on does not regenerate a user's whole history.
+func NewRolloverService(
+	trainingPlanRepo repository.TrainingPlanRepository,
+	nutritionPlanRepo repository.NutritionPlanRepository,
+	trainingService TrainingService,
+	nutritionService NutritionService,
+	lookbackDays int,
+) RolloverService {
+	if lookbackDays < 1 {
+		lookbackDays = 1
+	}
+	return &rolloverService{
+		trainingPlanRepo:  trainingPlanRepo,
+		nutritionPlanRepo: nutritionPlanRepo,
+		trainingService:   trainingService,
+		nutritionService:  nutritionService,
+		lookbackDays:      lookbackDays,
+	}
+}
+
+// RollOverEndedPlans adjusts each due plan with the user's default AI API.
+// A plan is marked before its task is queued so that concurrent runs on
+// other instances skip it; a plan whose task could not be queued, or whose
+// user already had a generation pending, stays marked and is left for the
+// user to continue by hand.
+func (s *rolloverService) RollOverEndedPlans(ctx context.Context) (int, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	since := today.AddDate(0, 0, -s.lookbackDays)
+
+	queued := 0
+
+	trainingPlans, err := s.trainingPlanRepo.ListRolloverDue(ctx, since, today)
+	if err != nil {
+		return 0, err
+	}
+	for _, plan := range trainingPlans {
+		if ctx.Err() != nil {
+			return queued, ctx.Err()
+		}
+		marked, err := s.trainingPlanRepo.MarkRolledOver(ctx, plan.ID, now)
+		if err != nil {
+			return queued, err
+		}
+		if !marked {
+			continue
+		}
+		resp, err := s.trainingService.AdjustPlan(ctx, plan.UserID, plan.ID, &AdjustPlanRequest{
+			Feedback: trainingRolloverFeedback,
+		})
+		if err != nil {
+			logger.Warn("Failed to roll over training plan",
+				zap.Int64("plan_id", plan.ID),
+				zap.Int64("user_id", plan.UserID),
+				zap.Error(err),
+			)
+			continue
+		}
+		if resp.Existing {
+			logger.Info("Skipped training plan rollover, user has a generation pending",
+				zap.Int64("plan_id", plan.ID),
+				zap.Int64("user_id", plan.UserID),
+				zap.String("task_id", resp.TaskID),
+			)
+			continue
+		}
+		queued++
+	}
+
+	nutritionPlans, err := s.nutritionPlanRepo.ListRolloverDue(ctx, since, today)
+	if err != nil {
+		return queued, err
+	}
+	for _, plan := range nutritionPlans {
+		if ctx.Err() != nil {
+			return queued, ctx.Err()
+		}
+		marked, err := s.nutritionPlanRepo.MarkRolledOver(ctx, plan.ID, now)
+		if err != nil {
+			return queued, err
+		}
+		if !marked {
+			continue
+		}
+		resp, err := s.nutritionService.AdjustPlan(ctx, plan.UserID, plan.ID, &AdjustNutritionPlanRequest{
+			Feedback: nutritionRolloverFeedback,
+		})
+		if err != nil {
+			logger.Warn("Failed to roll over nutrition plan",
+				zap.Int64("plan_id", plan.ID),
+				zap.Int64("user_id", plan.UserID),
+				zap.Error(err),
+			)
+			continue
+		}
+		if resp.Existing {
+			logger.Info("Skipped nutrition plan rollover, user has a generation pending",
+				zap.Int64("plan_id", plan.ID),
+				zap.Int64("user_id", plan.UserID),
+				zap.String("task_id", resp.TaskID),
+			)
+			continue
+		}
+		queued++
+	}
+
+	return queued, nil
+}
@@ -0,0 +1,423 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// RuleBasedProvider is recorded as the provider of plans built from
+// training templates, for users who have not configured an AI API
+const RuleBasedProvider = "rule_based"
+
+// Rule-based progression: loads rise by ruleIntensityStep %1RM a week, up
+// to ruleMaxSteps steps, and every fourth week, or every deload interval the
+// user asked for, is a lighter deload week
+const (
+	ruleIntensityStep = 2.5
+	ruleMaxSteps      = 6
+)
+
+// ruleScheme is the prescription of a training goal. Intensity is the
+// %1RM of loaded exercises in the first week.
+type ruleScheme struct {
+	sets      int
+	reps      int
+	intensity float64
+	rest      string
+	// cardio adds a cardio day to weeks with at least three training days
+	cardio bool
+}
+
+var ruleSchemes = map[string]ruleScheme{
+	"strength":  {sets: 5, reps: 5, intensity: 75, rest: "180秒"},
+	"muscle":    {sets: 4, reps: 10, intensity: 65, rest: "90秒"},
+	"fat_loss":  {sets: 3, reps: 15, intensity: 55, rest: "45秒", cardio: true},
+	"endurance": {sets: 3, reps: 15, intensity: 55, rest: "45秒", cardio: true},
+	"general":   {sets: 3, reps: 12, intensity: 60, rest: "60秒"},
+}
+
+// ruleSession is one kind of training day of a split, listing the muscle
+// groups of the exercise library it trains in order
+type ruleSession struct {
+	focus  string
+	groups []string
+}
+
+// ruleSplit is a weekly training split
+type ruleSplit struct {
+	name     string
+	sessions []ruleSession
+}
+
+var (
+	fullBodySplit = ruleSplit{name: "full_body", sessions: []ruleSession{
+		{focus: "full_body", groups: []string{"quads", "chest", "back", "shoulders", "core"}},
+		{focus: "full_body", groups: []string{"posterior_chain", "back", "chest", "biceps", "triceps"}},
+	}}
+	upperLowerSplit = ruleSplit{name: "upper_lower", sessions: []ruleSession{
+		{focus: "upper_body", groups: []string{"chest", "back", "shoulders", "biceps", "triceps"}},
+		{focus: "lower_body", groups: []string{"quads", "posterior_chain", "core"}},
+	}}
+	pushPullLegsSplit = ruleSplit{name: "push_pull_legs", sessions: []ruleSession{
+		{focus: "upper_body", groups: []string{"chest", "shoulders", "triceps"}},
+		{focus: "upper_body", groups: []string{"back", "biceps", "core"}},
+		{focus: "lower_body", groups: []string{"quads", "posterior_chain", "core"}},
+	}}
+)
+
+// ruleHoldExercises are held for time rather than counted in reps
+var ruleHoldExercises = map[string]bool{"平板支撑": true}
+
+var ruleWeekdays = map[string]time.Weekday{
+	"monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday, "sunday": time.Sunday,
+}
+
+// generateRuleBasedPlan builds a training plan without AI from the user's
+// assessment: the split follows the weekly training days, exercises come
+// from the exercise library filtered by equipment, injuries and training
+// constraints, and sets, reps and loads follow the goal. The same input
+// always gives the same plan.
+func generateRuleBasedPlan(params *TrainingPlanParams, start time.Time) *model.TrainingPlan {
+	start = dayStart(start)
+	scheme := ruleSchemes[ruleGoal(params.Goal)]
+	experience := "intermediate"
+	if params.Assessment != nil {
+		experience = params.Assessment.ExperienceLevel
+	}
+	if experience == "beginner" {
+		scheme.sets--
+		scheme.intensity -= 5
+	}
+
+	trainingDays := ruleTrainingWeekdays(params.Assessment)
+	strengthDays := len(trainingDays)
+	if scheme.cardio && strengthDays >= 3 {
+		strengthDays--
+	}
+	split := fullBodySplit
+	switch {
+	case strengthDays >= 5:
+		split = pushPullLegsSplit
+	case strengthDays == 4:
+		split = upperLowerSplit
+	}
+
+	filter := ruleExerciseFilter(params)
+	exercises := make(map[string]*LibraryExercise)
+	for _, session := range split.sessions {
+		for _, group := range session.groups {
+			if _, ok := exercises[group]; !ok {
+				exercises[group] = pickLibraryExercise(group, filter, experience)
+			}
+		}
+	}
+
+	difficulty := "medium"
+	switch params.DifficultyLevel {
+	case "easy":
+		difficulty = "easy"
+	case "hard", "extreme":
+		difficulty = "hard"
+	}
+
+	deloadEvery := defaultDeloadEvery
+	if p := params.Periodization; p != nil && p.DeloadEvery > 0 {
+		deloadEvery = p.DeloadEvery
+	}
+
+	weeks := make([]interface{}, 0, params.DurationWeeks)
+	for w := 0; w < params.DurationWeeks; w++ {
+		deload := w%deloadEvery == deloadEvery-1 && w < params.DurationWeeks-1
+		steps := w/deloadEvery + w%deloadEvery
+		if deload {
+			// Two steps below where the cycle started
+			steps = w/deloadEvery - 2
+		}
+		if steps > ruleMaxSteps {
+			steps = ruleMaxSteps
+		}
+
+		// Sessions restart each week, so every week trains the same way
+		session := 0
+		days := make([]interface{}, 0, 7)
+		for d := 0; d < 7; d++ {
+			date := start.AddDate(0, 0, w*7+d)
+			day := map[string]interface{}{
+				"day":  d + 1,
+				"date": date.Format("2006-01-02"),
+			}
+			index := weekdayIndex(trainingDays, date.Weekday())
+			switch {
+			case index < 0:
+				day["type"] = "rest"
+				day["exercises"] = []interface{}{}
+			case scheme.cardio && len(trainingDays) >= 3 && index == len(trainingDays)-1:
+				day["type"] = "cardio"
+				day["focus_area"] = "cardio"
+				day["exercises"] = []interface{}{ruleCardioExercise(steps)}
+			default:
+				current := split.sessions[session%len(split.sessions)]
+				session++
+				entries := make([]interface{}, 0, len(current.groups))
+				for _, group := range current.groups {
+					if ex := exercises[group]; ex != nil {
+						entries = append(entries, ruleExercise(ex, scheme, steps, deload, difficulty))
+					}
+				}
+				day["type"] = "strength"
+				day["focus_area"] = current.focus
+				day["exercises"] = entries
+			}
+			if exercisesRaw, _ := day["exercises"].([]interface{}); len(exercisesRaw) > 0 {
+				minutes := int(math.Round(daySeconds(exercisesRaw) / 60))
+				perMinute := 6
+				if day["type"] == "cardio" {
+					perMinute = 8
+				}
+				day["duration"] = minutes
+				day["estimated_calories"] = minutes * perMinute
+			}
+			days = append(days, day)
+		}
+		weeks = append(weeks, map[string]interface{}{"week": w + 1, "deload": deload, "days": days})
+	}
+
+	planData := model.JSONMap{
+		"weeks": weeks,
+		"generator": map[string]interface{}{
+			"type":  RuleBasedProvider,
+			"split": split.name,
+		},
+	}
+	// Store plain JSON types, as a plan read back from the database has
+	if normalized, err := copyPlanData(planData); err == nil {
+		planData = normalized
+	}
+	if len(params.Constraints) > 0 {
+		planData["applied_constraints"] = appliedConstraints(params.Constraints)
+	}
+	if params.Periodization != nil {
+		params.Periodization.record(planData)
+	}
+
+	provider := RuleBasedProvider
+	plan := &model.TrainingPlan{
+		UserID:          params.UserID,
+		PlanName:        params.PlanName,
+		StartDate:       start,
+		EndDate:         start.AddDate(0, 0, params.DurationWeeks*7),
+		TotalWeeks:      params.DurationWeeks,
+		DifficultyLevel: params.DifficultyLevel,
+		TrainingPurpose: &params.Goal,
+		AIProvider:      &provider,
+		PlanData:        planData,
+		Status:          "active",
+	}
+	if block := params.Block; block != nil {
+		plan.MacrocycleID = &block.Macrocycle.ID
+		plan.BlockNumber = &block.Number
+		plan.BlockPhase = &block.Phase
+	}
+	return plan
+}
+
+// ruleGoal classifies a free-text goal as one of the keys of ruleSchemes
+func ruleGoal(goal string) string {
+	g := strings.ToLower(goal)
+	switch {
+	case containsAny(g, "减脂", "减重", "减肥", "fat", "weight_loss", "lose"):
+		return "fat_loss"
+	case containsAny(g, "增肌", "肌肉", "muscle", "hypertrophy"):
+		return "muscle"
+	case containsAny(g, "力量", "strength", "power"):
+		return "strength"
+	case containsAny(g, "耐力", "有氧", "心肺", "endurance", "cardio"):
+		return "endurance"
+	default:
+		return "general"
+	}
+}
+
+// ruleTrainingWeekdays returns the weekdays to train on: the assessment's
+// preferred days, up to its weekly available days, or that many days spread
+// over the week starting on Monday. Without an assessment it is three days.
+func ruleTrainingWeekdays(assessment *model.FitnessAssessment) []time.Weekday {
+	count := 3
+	if assessment != nil && assessment.WeeklyAvailableDays > 0 {
+		count = assessment.WeeklyAvailableDays
+		if count > 6 {
+			count = 6
+		}
+	}
+
+	var preferred []time.Weekday
+	if assessment != nil {
+		for _, name := range []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"} {
+			for _, raw := range assessment.PreferredDays {
+				if s, _ := raw.(string); strings.EqualFold(s, name) {
+					preferred = append(preferred, ruleWeekdays[name])
+					break
+				}
+			}
+		}
+	}
+	if len(preferred) > 0 {
+		if len(preferred) > count {
+			preferred = preferred[:count]
+		}
+		return preferred
+	}
+
+	days := make([]time.Weekday, 0, count)
+	for i := 0; i < count; i++ {
+		days = append(days, time.Weekday((1+i*7/count)%7))
+	}
+	return days
+}
+
+func weekdayIndex(days []time.Weekday, day time.Weekday) int {
+	for i, d := range days {
+		if d == day {
+			return i
+		}
+	}
+	return -1
+}
+
+// ruleExerciseFilter excludes exercises needing equipment the user lacks,
+// loading joints named in their injury history or health conditions, or
+// matching their training constraints. Equipment profiles replace the
+// assessment's equipment list; with neither, only bodyweight is assumed.
+func ruleExerciseFilter(params *TrainingPlanParams) exerciseFilter {
+	available := map[string]bool{"bodyweight": true}
+	var listed []interface{}
+	if len(params.EquipmentProfiles) > 0 {
+		for _, profile := range params.EquipmentProfiles {
+			listed = append(listed, profile.Equipment...)
+		}
+	} else if params.Assessment != nil {
+		listed = params.Assessment.EquipmentAvailable
+	}
+	for _, raw := range listed {
+		if s, ok := raw.(string); ok {
+			if term := findExerciseTerm(s, equipmentTerms); term != nil {
+				available[term.Code] = true
+			}
+		}
+	}
+
+	var filter exerciseFilter
+	for _, term := range equipmentTerms {
+		if !available[term.Code] {
+			filter.unavailableEquipment = append(filter.unavailableEquipment, term.Code)
+		}
+	}
+
+	if a := params.Assessment; a != nil {
+		var notes []string
+		if a.InjuryHistory != nil {
+			notes = append(notes, *a.InjuryHistory)
+		}
+		if a.HealthConditions != nil {
+			notes = append(notes, *a.HealthConditions)
+		}
+		text := strings.ToLower(strings.Join(notes, " "))
+		for _, term := range jointTerms {
+			names := append([]string{term.Name, strings.ToLower(term.EnglishName)}, term.aliases...)
+			if text != "" && containsAny(text, names...) {
+				filter.avoidJoints = append(filter.avoidJoints, term.Code)
+			}
+		}
+	}
+
+	for _, c := range params.Constraints {
+		for _, m := range c.Movements() {
+			filter.restrictedMovements = append(filter.restrictedMovements, strings.ToLower(m))
+		}
+	}
+	return filter
+}
+
+// pickLibraryExercise picks the exercise for a muscle group among those the
+// filter allows. The library lists each group from the most demanding
+// variation down, so advanced users get the first and others one further
+// down the list. It returns nil when none is allowed.
+func pickLibraryExercise(group string, filter exerciseFilter, experience string) *LibraryExercise {
+	var allowed []*LibraryExercise
+	for i, ex := range exerciseLibrary {
+		if ex.MuscleGroup == group && filter.allows(ex) {
+			allowed = append(allowed, &exerciseLibrary[i])
+		}
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+	switch experience {
+	case "advanced":
+		return allowed[0]
+	case "beginner":
+		return allowed[len(allowed)/2]
+	default:
+		return allowed[len(allowed)/3]
+	}
+}
+
+// ruleExercise prescribes a library exercise for a week that is steps
+// progression steps past the first. Loaded exercises progress in %1RM,
+// bodyweight and band exercises in reps or hold time; a deload week also
+// drops a set.
+func ruleExercise(ex *LibraryExercise, scheme ruleScheme, steps int, deload bool, difficulty string) map[string]interface{} {
+	sets := scheme.sets
+	if deload {
+		sets--
+	}
+
+	reps := fmt.Sprintf("%d", scheme.reps)
+	var weight string
+	switch ex.Equipment {
+	case "bodyweight", "pull_up_bar", "resistance_band":
+		weight = "自重"
+		if ex.Equipment == "resistance_band" {
+			weight = "弹力带"
+		}
+		if ruleHoldExercises[ex.Name] {
+			reps = fmt.Sprintf("%d秒", 30+5*steps)
+		} else {
+			reps = fmt.Sprintf("%d", scheme.reps+steps)
+		}
+	default:
+		weight = fmt.Sprintf("%g%%1RM", scheme.intensity+ruleIntensityStep*float64(steps))
+	}
+
+	return map[string]interface{}{
+		"name":       ex.Name,
+		"sets":       sets,
+		"reps":       reps,
+		"weight":     weight,
+		"rest":       scheme.rest,
+		"difficulty": difficulty,
+	}
+}
+
+// ruleCardioExercise is the steady-state session of a cardio day, five
+// minutes longer per progression step up to 45 minutes
+func ruleCardioExercise(steps int) map[string]interface{} {
+	minutes := 20 + 5*steps
+	if minutes > 45 {
+		minutes = 45
+	}
+	return map[string]interface{}{
+		"name":         "快走或慢跑",
+		"sets":         1,
+		"reps":         fmt.Sprintf("%d分钟", minutes),
+		"weight":       "自重",
+		"rest":         "0秒",
+		"difficulty":   "easy",
+		"safety_notes": "保持能说话但略喘的强度，关节不适时改为快走或骑行",
+	}
+}
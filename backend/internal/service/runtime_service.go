@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/config"
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/migration"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// RuntimeInfo is the effective, non-secret configuration of a running server.
+// It deliberately leaves out credentials, hosts and secrets so it can be
+// pasted into support requests for self-hosted installs.
+type RuntimeInfo struct {
+	AppName   string
+	Version   string
+	Mode      string
+	GoVersion string
+	StartedAt time.Time
+
+	RateLimit config.RateLimitConfig
+
+	AIMaxConcurrentRequests int
+	AITimeout               time.Duration
+	AIRetryAttempts         int
+	SupportedProviders      []string
+	// AllowedProviders are the supported providers the deployment allows
+	AllowedProviders []string
+	// ConfiguredProviders counts active AI API configurations per provider
+	ConfiguredProviders map[string]int64
+
+	Features            map[string]bool
+	SyncTrainingPolicy  string
+	SyncNutritionPolicy string
+
+	Schema *migration.SchemaStatus
+}
+
+// RuntimeService reports the server's effective configuration
+type RuntimeService interface {
+	GetRuntimeInfo(ctx context.Context) (*RuntimeInfo, error)
+}
+
+// runtimeService implements RuntimeService interface
+type runtimeService struct {
+	db        *gorm.DB
+	aiAPIRepo repository.AIAPIRepository
+	cfg       *config.Config
+	policy    *ProviderPolicy
+	startedAt time.Time
+}
+
+// NewRuntimeService creates a new instance of RuntimeService
+func NewRuntimeService(db *gorm.DB, aiAPIRepo repository.AIAPIRepository, cfg *config.Config, policy *ProviderPolicy) RuntimeService {
+	return &runtimeService{
+		db:        db,
+		aiAPIRepo: aiAPIRepo,
+		cfg:       cfg,
+		policy:    policy,
+		startedAt: time.Now(),
+	}
+}
+
+// GetRuntimeInfo collects the effective configuration and schema version
+func (s *runtimeService) GetRuntimeInfo(ctx context.Context) (*RuntimeInfo, error) {
+	configured, err := s.aiAPIRepo.CountActiveByProvider(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "统计AI API配置失败")
+	}
+
+	schema, err := migration.Status(s.db.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取数据库版本失败")
+	}
+
+	cfg := s.cfg
+	return &RuntimeInfo{
+		AppName:                 cfg.App.Name,
+		Version:                 cfg.App.Version,
+		Mode:                    cfg.App.Mode,
+		GoVersion:               runtime.Version(),
+		StartedAt:               s.startedAt,
+		RateLimit:               cfg.RateLimit,
+		AIMaxConcurrentRequests: cfg.AI.MaxConcurrentRequests,
+		AITimeout:               cfg.AI.Timeout,
+		AIRetryAttempts:         cfg.AI.RetryAttempts,
+		SupportedProviders:      SupportedAIProviders,
+		AllowedProviders:        s.policy.AllowedProviders(),
+		ConfiguredProviders:     configured,
+		Features: map[string]bool{
+			"auto_migrate":               cfg.Database.MySQL.AutoMigrate,
+			"degraded_mode":              cfg.Startup.DegradedMode,
+			"abuse_detection":            cfg.Abuse.Enabled,
+			"allow_local_providers":      cfg.Abuse.AllowLocalProviders,
+			"goal_evaluation":            cfg.Goals.EvaluationEnabled,
+			"check_in_reminders":         cfg.CheckIn.ReminderEnabled,
+			"integrity_check":            cfg.Integrity.CheckEnabled,
+			"plan_rollover":              cfg.Rollover.Enabled,
+			"session_degraded_auth":      cfg.Session.DegradedAuth,
+			"session_sliding_expiration": cfg.Session.SlidingExpiration,
+			"smtp_mail":                  cfg.Mail.Host != "",
+		},
+		SyncTrainingPolicy:  normalizeSyncPolicy(cfg.Sync.TrainingRecordPolicy),
+		SyncNutritionPolicy: normalizeSyncPolicy(cfg.Sync.NutritionRecordPolicy),
+		Schema:              schema,
+	}, nil
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/ai-fitness-planner/backend/internal/errors"
@@ -24,6 +26,14 @@ type StatisticsService interface {
 	CalculateTrends(ctx context.Context, userID int64, period string, count int) (*TrendsReport, error)
 	// CalculateTrendsByRange aggregates trend data for a custom date range
 	CalculateTrendsByRange(ctx context.Context, userID int64, period string, startDate, endDate time.Time) (*TrendsReport, error)
+	// GetExerciseProgression returns the logged sessions of one exercise over
+	// the last days days (90 when 0) with the trend of its estimated 1RM and
+	// a suggested load for the next session
+	GetExerciseProgression(ctx context.Context, userID int64, exercise string, days int) (*ExerciseProgression, error)
+	// GetOneRepMaxReport estimates the 1RM of each main lift from the sets of
+	// the last days days (90 when 0) and compares it with bodyweight-relative
+	// strength standards
+	GetOneRepMaxReport(ctx context.Context, userID int64, days int) (*OneRepMaxReport, error)
 }
 
 // TrainingStats represents aggregated training statistics
@@ -38,6 +48,7 @@ type TrainingStats struct {
 	AverageRating     float64          `json:"average_rating"`
 	WorkoutsByType    map[string]int64 `json:"workouts_by_type"`
 	AverageDuration   float64          `json:"average_duration_minutes"`
+	FlaggedWorkouts   int64            `json:"flagged_workouts"`
 	HasSufficientData bool             `json:"has_sufficient_data"`
 	Message           string           `json:"message,omitempty"`
 }
@@ -86,37 +97,52 @@ type WorkoutComparison struct {
 // TrendsReport represents trend data over multiple periods
 // Requirements: 10.3
 type TrendsReport struct {
-	Period            string       `json:"period"` // "week" or "month"
+	Period            string       `json:"period"`               // "week" or "month"
+	WeekStart         string       `json:"week_start,omitempty"` // "monday" or "sunday", weekly trends only
 	DataPoints        []TrendPoint `json:"data_points"`
 	HasSufficientData bool         `json:"has_sufficient_data"`
 	Message           string       `json:"message,omitempty"`
 }
 
-// TrendPoint represents a single data point in the trend
+// TrendPoint represents a single data point in the trend. StartDate and
+// EndDate are the days covered; Partial is set when that is less than the
+// whole calendar period, at the edges of a range or for the current period.
 type TrendPoint struct {
 	PeriodLabel   string    `json:"period_label"`
 	StartDate     time.Time `json:"start_date"`
 	EndDate       time.Time `json:"end_date"`
+	Partial       bool      `json:"partial"`
 	TotalWorkouts int64     `json:"total_workouts"`
 	TotalDuration int64     `json:"total_duration_minutes"`
 	TotalCalories int64     `json:"total_calories"`
 	AverageRating float64   `json:"average_rating"`
+	// Tonnage is the total load lifted (reps × weight, kg) across logged sets
+	Tonnage float64 `json:"tonnage_kg"`
+	// RelativeIntensity is the rep-weighted average load of main-lift sets as a
+	// percentage of the user's current 1RM; nil without matching sets or 1RMs
+	RelativeIntensity *float64 `json:"relative_intensity_pct,omitempty"`
 }
 
 // statisticsService implements StatisticsService interface
 type statisticsService struct {
 	trainingRecordRepo repository.TrainingRecordRepository
 	bodyDataRepo       repository.BodyDataRepository
+	strengthRepo       repository.StrengthProfileRepository
+	userRepo           repository.UserRepository
 }
 
 // NewStatisticsService creates a new instance of StatisticsService
 func NewStatisticsService(
 	trainingRecordRepo repository.TrainingRecordRepository,
 	bodyDataRepo repository.BodyDataRepository,
+	strengthRepo repository.StrengthProfileRepository,
+	userRepo repository.UserRepository,
 ) StatisticsService {
 	return &statisticsService{
 		trainingRecordRepo: trainingRecordRepo,
 		bodyDataRepo:       bodyDataRepo,
+		strengthRepo:       strengthRepo,
+		userRepo:           userRepo,
 	}
 }
 
@@ -135,14 +161,15 @@ func (s *statisticsService) GetTrainingStatistics(ctx context.Context, userID in
 	}
 
 	result := &TrainingStats{
-		Period:         period,
-		StartDate:      startDate,
-		EndDate:        endDate,
-		TotalWorkouts:  stats.TotalWorkouts,
-		TotalDuration:  stats.TotalDuration,
-		TotalCalories:  stats.TotalCalories,
-		AverageRating:  stats.AverageRating,
-		WorkoutsByType: stats.WorkoutsByType,
+		Period:          period,
+		StartDate:       startDate,
+		EndDate:         endDate,
+		TotalWorkouts:   stats.TotalWorkouts,
+		TotalDuration:   stats.TotalDuration,
+		TotalCalories:   stats.TotalCalories,
+		AverageRating:   stats.AverageRating,
+		WorkoutsByType:  stats.WorkoutsByType,
+		FlaggedWorkouts: stats.FlaggedWorkouts,
 	}
 
 	// Calculate average duration
@@ -165,14 +192,15 @@ func (s *statisticsService) GetTrainingStatisticsByRange(ctx context.Context, us
 	}
 
 	result := &TrainingStats{
-		Period:         "custom",
-		StartDate:      startDate,
-		EndDate:        endDate,
-		TotalWorkouts:  stats.TotalWorkouts,
-		TotalDuration:  stats.TotalDuration,
-		TotalCalories:  stats.TotalCalories,
-		AverageRating:  stats.AverageRating,
-		WorkoutsByType: stats.WorkoutsByType,
+		Period:          "custom",
+		StartDate:       startDate,
+		EndDate:         endDate,
+		TotalWorkouts:   stats.TotalWorkouts,
+		TotalDuration:   stats.TotalDuration,
+		TotalCalories:   stats.TotalCalories,
+		AverageRating:   stats.AverageRating,
+		WorkoutsByType:  stats.WorkoutsByType,
+		FlaggedWorkouts: stats.FlaggedWorkouts,
 	}
 
 	if stats.TotalWorkouts > 0 {
@@ -257,7 +285,8 @@ func (s *statisticsService) GetProgressReport(ctx context.Context, userID int64)
 	return report, nil
 }
 
-// CalculateTrends aggregates data by week or month
+// CalculateTrends aggregates data by week or month. Weeks are calendar weeks
+// starting on the user's week start day; the current period ends today.
 // Requirements: 10.3
 func (s *statisticsService) CalculateTrends(ctx context.Context, userID int64, period string, count int) (*TrendsReport, error) {
 	if period != "week" && period != "month" {
@@ -268,68 +297,44 @@ func (s *statisticsService) CalculateTrends(ctx context.Context, userID int64, p
 		count = 12 // Default to 12 periods
 	}
 
+	firstDay, err := s.firstWeekday(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	oneRepMaxes, err := s.oneRepMaxes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
-	dataPoints := make([]TrendPoint, 0, count)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	currentStart := calendarWeekStart(today, firstDay)
+	if period == "month" {
+		currentStart = time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+	}
 
+	dataPoints := make([]TrendPoint, 0, count)
 	for i := count - 1; i >= 0; i-- {
-		var startDate, endDate time.Time
-		var label string
-
+		var periodStart time.Time
 		if period == "week" {
-			// Calculate week boundaries
-			endDate = now.AddDate(0, 0, -7*i)
-			startDate = endDate.AddDate(0, 0, -6)
-			label = startDate.Format("01/02") + " - " + endDate.Format("01/02")
+			periodStart = currentStart.AddDate(0, 0, -7*i)
 		} else {
-			// Calculate month boundaries
-			targetMonth := now.AddDate(0, -i, 0)
-			startDate = time.Date(targetMonth.Year(), targetMonth.Month(), 1, 0, 0, 0, 0, targetMonth.Location())
-			endDate = startDate.AddDate(0, 1, -1)
-			label = startDate.Format("2006-01")
+			periodStart = currentStart.AddDate(0, -i, 0)
 		}
 
-		stats, err := s.trainingRecordRepo.GetStatistics(ctx, userID, startDate, endDate)
+		point, err := s.trendPoint(ctx, userID, period, periodStart, periodStart, today, oneRepMaxes)
 		if err != nil {
-			return nil, errors.Wrap(err, errors.ErrDatabase, "获取趋势数据失败")
+			return nil, err
 		}
-
-		dataPoints = append(dataPoints, TrendPoint{
-			PeriodLabel:   label,
-			StartDate:     startDate,
-			EndDate:       endDate,
-			TotalWorkouts: stats.TotalWorkouts,
-			TotalDuration: stats.TotalDuration,
-			TotalCalories: stats.TotalCalories,
-			AverageRating: stats.AverageRating,
-		})
+		dataPoints = append(dataPoints, *point)
 	}
 
-	report := &TrendsReport{
-		Period:     period,
-		DataPoints: dataPoints,
-	}
-
-	// Check if we have sufficient data
-	// Requirements: 10.4 - handle insufficient data cases
-	hasData := false
-	for _, dp := range dataPoints {
-		if dp.TotalWorkouts > 0 {
-			hasData = true
-			break
-		}
-	}
-
-	if !hasData {
-		report.HasSufficientData = false
-		report.Message = "没有足够的训练数据来生成趋势报告"
-	} else {
-		report.HasSufficientData = true
-	}
-
-	return report, nil
+	return newTrendsReport(period, firstDay, dataPoints), nil
 }
 
-// CalculateTrendsByRange aggregates trend data within a custom date range
+// CalculateTrendsByRange aggregates trend data within a custom date range.
+// Periods are aligned to calendar weeks or months; the first and last are
+// cut to the range and marked partial.
 func (s *statisticsService) CalculateTrendsByRange(ctx context.Context, userID int64, period string, startDate, endDate time.Time) (*TrendsReport, error) {
 	if period != "week" && period != "month" {
 		return nil, errors.New(errors.ErrInvalidParam, "period必须是'week'或'month'")
@@ -338,68 +343,172 @@ func (s *statisticsService) CalculateTrendsByRange(ctx context.Context, userID i
 		return nil, errors.New(errors.ErrInvalidParam, "结束日期必须大于开始日期")
 	}
 
-	dataPoints := make([]TrendPoint, 0)
-	currentStart := startDate
+	firstDay, err := s.firstWeekday(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	oneRepMaxes, err := s.oneRepMaxes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
 
-	for !currentStart.After(endDate) {
-		var currentEnd time.Time
-		var label string
+	periodStart := calendarWeekStart(startDate, firstDay)
+	if period == "month" {
+		periodStart = time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, startDate.Location())
+	}
+
+	dataPoints := make([]TrendPoint, 0)
+	for !periodStart.After(endDate) {
+		point, err := s.trendPoint(ctx, userID, period, periodStart, startDate, endDate, oneRepMaxes)
+		if err != nil {
+			return nil, err
+		}
+		dataPoints = append(dataPoints, *point)
 
 		if period == "week" {
-			currentEnd = currentStart.AddDate(0, 0, 6)
-			label = currentStart.Format("01/02") + " - " + currentEnd.Format("01/02")
+			periodStart = periodStart.AddDate(0, 0, 7)
 		} else {
-			currentEnd = currentStart.AddDate(0, 1, 0).AddDate(0, 0, -1)
-			label = currentStart.Format("2006-01")
+			periodStart = periodStart.AddDate(0, 1, 0)
 		}
+	}
 
-		if currentEnd.After(endDate) {
-			currentEnd = endDate
-		}
+	return newTrendsReport(period, firstDay, dataPoints), nil
+}
 
-		stats, err := s.trainingRecordRepo.GetStatistics(ctx, userID, currentStart, currentEnd)
-		if err != nil {
-			return nil, errors.Wrap(err, errors.ErrDatabase, "获取趋势数据失败")
-		}
+// trendPoint aggregates the calendar period starting at periodStart, cut to
+// the days between notBefore and notAfter
+func (s *statisticsService) trendPoint(ctx context.Context, userID int64, period string, periodStart, notBefore, notAfter time.Time, oneRepMaxes map[string]float64) (*TrendPoint, error) {
+	var periodEnd time.Time
+	var label string
+	if period == "week" {
+		periodEnd = periodStart.AddDate(0, 0, 6)
+		label = periodStart.Format("01/02") + " - " + periodEnd.Format("01/02")
+	} else {
+		periodEnd = periodStart.AddDate(0, 1, -1)
+		label = periodStart.Format("2006-01")
+	}
 
-		dataPoints = append(dataPoints, TrendPoint{
-			PeriodLabel:   label,
-			StartDate:     currentStart,
-			EndDate:       currentEnd,
-			TotalWorkouts: stats.TotalWorkouts,
-			TotalDuration: stats.TotalDuration,
-			TotalCalories: stats.TotalCalories,
-			AverageRating: stats.AverageRating,
-		})
+	startDate, endDate := periodStart, periodEnd
+	if startDate.Before(notBefore) {
+		startDate = notBefore
+	}
+	if endDate.After(notAfter) {
+		endDate = notAfter
+	}
 
-		if period == "week" {
-			currentStart = currentStart.AddDate(0, 0, 7)
-		} else {
-			currentStart = currentStart.AddDate(0, 1, 0)
-		}
+	stats, err := s.trainingRecordRepo.GetStatistics(ctx, userID, startDate, endDate)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取趋势数据失败")
+	}
+
+	tonnage, intensity, err := s.trainingLoad(ctx, userID, startDate, endDate, oneRepMaxes)
+	if err != nil {
+		return nil, err
 	}
 
+	return &TrendPoint{
+		PeriodLabel:       label,
+		StartDate:         startDate,
+		EndDate:           endDate,
+		Partial:           !startDate.Equal(periodStart) || !endDate.Equal(periodEnd),
+		TotalWorkouts:     stats.TotalWorkouts,
+		TotalDuration:     stats.TotalDuration,
+		TotalCalories:     stats.TotalCalories,
+		AverageRating:     stats.AverageRating,
+		Tonnage:           tonnage,
+		RelativeIntensity: intensity,
+	}, nil
+}
+
+// newTrendsReport wraps data points in a report, flagging insufficient data
+// Requirements: 10.4 - handle insufficient data cases
+func newTrendsReport(period string, firstDay time.Weekday, dataPoints []TrendPoint) *TrendsReport {
 	report := &TrendsReport{
 		Period:     period,
 		DataPoints: dataPoints,
 	}
+	if period == "week" {
+		report.WeekStart = strings.ToLower(firstDay.String())
+	}
 
-	hasData := false
 	for _, dp := range dataPoints {
 		if dp.TotalWorkouts > 0 {
-			hasData = true
+			report.HasSufficientData = true
 			break
 		}
 	}
-
-	if !hasData {
-		report.HasSufficientData = false
+	if !report.HasSufficientData {
 		report.Message = "没有足够的训练数据来生成趋势报告"
-	} else {
-		report.HasSufficientData = true
 	}
 
-	return report, nil
+	return report
+}
+
+// firstWeekday returns the day the user's weeks start on
+func (s *statisticsService) firstWeekday(ctx context.Context, userID int64) (time.Weekday, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return time.Monday, errors.Wrap(err, errors.ErrDatabase, "获取用户设置失败")
+	}
+	if user == nil {
+		return time.Monday, nil
+	}
+	return user.FirstWeekday(), nil
+}
+
+// oneRepMaxes returns the user's current 1RM per main lift
+func (s *statisticsService) oneRepMaxes(ctx context.Context, userID int64) (map[string]float64, error) {
+	entries, err := s.strengthRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取力量档案失败")
+	}
+	maxes := make(map[string]float64, len(entries))
+	for _, e := range entries {
+		if e.OneRepMax > 0 {
+			maxes[e.Lift] = e.OneRepMax
+		}
+	}
+	return maxes, nil
+}
+
+// trainingLoad computes tonnage and relative intensity from the set logs of
+// the records in a date range
+func (s *statisticsService) trainingLoad(ctx context.Context, userID int64, startDate, endDate time.Time, oneRepMaxes map[string]float64) (float64, *float64, error) {
+	records, err := s.trainingRecordRepo.ListByUser(ctx, userID, &startDate, &endDate)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, errors.ErrDatabase, "获取趋势数据失败")
+	}
+
+	var tonnage, intensityWeighted, intensityReps float64
+	for _, record := range records {
+		for _, ex := range exerciseRecords(record.Exercises) {
+			oneRepMax := 0.0
+			if lift, ok := matchMainLift(ex.ExerciseName); ok {
+				oneRepMax = oneRepMaxes[lift]
+			}
+			for i, reps := range ex.RepsPerSet {
+				if i >= len(ex.WeightUsed) {
+					break
+				}
+				weight := ex.WeightUsed[i]
+				if reps <= 0 || weight <= 0 {
+					continue
+				}
+				tonnage += float64(reps) * weight
+				if oneRepMax > 0 {
+					intensityWeighted += float64(reps) * weight / oneRepMax
+					intensityReps += float64(reps)
+				}
+			}
+		}
+	}
+
+	tonnage = math.Round(tonnage*10) / 10
+	if intensityReps == 0 {
+		return tonnage, nil, nil
+	}
+	intensity := math.Round(intensityWeighted/intensityReps*1000) / 10
+	return tonnage, &intensity, nil
 }
 
 // calculateDateRange calculates start and end dates based on period string
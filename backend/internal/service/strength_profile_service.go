@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+)
+
+// maxRepsForEstimate caps the reps used for a 1RM estimate; beyond this the
+// Epley formula overestimates too much to be useful
+const maxRepsForEstimate = 12
+
+// StrengthProfileService defines the interface for strength profile operations
+type StrengthProfileService interface {
+	GetProfile(ctx context.Context, userID int64) ([]*model.StrengthProfileEntry, error)
+	// SetOneRepMax records a manually entered 1RM, replacing the current value
+	SetOneRepMax(ctx context.Context, userID int64, lift string, oneRepMax float64, achievedAt time.Time) (*model.StrengthProfileEntry, error)
+	// UpdateFromRecord raises 1RMs for main lifts where the training record
+	// contains a set that beats the current value; returns the updated entries
+	UpdateFromRecord(ctx context.Context, record *model.TrainingRecord) ([]*model.StrengthProfileEntry, error)
+}
+
+// strengthProfileService implements StrengthProfileService interface
+type strengthProfileService struct {
+	strengthRepo repository.StrengthProfileRepository
+}
+
+// NewStrengthProfileService creates a new instance of StrengthProfileService
+func NewStrengthProfileService(strengthRepo repository.StrengthProfileRepository) StrengthProfileService {
+	return &strengthProfileService{strengthRepo: strengthRepo}
+}
+
+// GetProfile returns the user's current 1RM for each recorded lift
+func (s *strengthProfileService) GetProfile(ctx context.Context, userID int64) ([]*model.StrengthProfileEntry, error) {
+	entries, err := s.strengthRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取力量档案失败")
+	}
+	return entries, nil
+}
+
+// SetOneRepMax records a manual 1RM for a main lift
+func (s *strengthProfileService) SetOneRepMax(ctx context.Context, userID int64, lift string, oneRepMax float64, achievedAt time.Time) (*model.StrengthProfileEntry, error) {
+	if !isMainLift(lift) {
+		return nil, errors.New(errors.ErrInvalidParam, "不支持的主项动作")
+	}
+
+	entry, err := s.strengthRepo.GetByUserAndLift(ctx, userID, lift)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取力量档案失败")
+	}
+
+	now := time.Now()
+	if entry == nil {
+		entry = &model.StrengthProfileEntry{UserID: userID, Lift: lift, CreatedAt: now}
+	}
+	entry.OneRepMax = roundLoad(oneRepMax)
+	entry.Source = model.StrengthSourceManual
+	entry.SourceRecordID = nil
+	entry.AchievedAt = achievedAt
+	entry.UpdatedAt = now
+
+	if err := s.strengthRepo.Save(ctx, entry); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "保存力量档案失败")
+	}
+	return entry, nil
+}
+
+// UpdateFromRecord applies any new personal records found in a training record
+func (s *strengthProfileService) UpdateFromRecord(ctx context.Context, record *model.TrainingRecord) ([]*model.StrengthProfileEntry, error) {
+	best := bestEstimatedOneRepMaxes(record.Exercises)
+	if len(best) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	var updated []*model.StrengthProfileEntry
+	for _, lift := range model.MainLifts {
+		estimate, ok := best[lift]
+		if !ok {
+			continue
+		}
+
+		entry, err := s.strengthRepo.GetByUserAndLift(ctx, record.UserID, lift)
+		if err != nil {
+			return updated, err
+		}
+		if entry != nil && entry.OneRepMax >= estimate {
+			continue
+		}
+		if entry == nil {
+			entry = &model.StrengthProfileEntry{UserID: record.UserID, Lift: lift, CreatedAt: now}
+		}
+
+		recordID := record.ID
+		entry.OneRepMax = estimate
+		entry.Source = model.StrengthSourcePR
+		entry.SourceRecordID = &recordID
+		entry.AchievedAt = record.WorkoutDate
+		entry.UpdatedAt = now
+
+		if err := s.strengthRepo.Save(ctx, entry); err != nil {
+			return updated, err
+		}
+		updated = append(updated, entry)
+	}
+
+	return updated, nil
+}
+
+// bestEstimatedOneRepMaxes returns the highest estimated 1RM per main lift in
+// a training record's exercises ({"items": [ExerciseRecord, ...]})
+func bestEstimatedOneRepMaxes(exercises model.JSONMap) map[string]float64 {
+	records := exerciseRecords(exercises)
+	if len(records) == 0 {
+		return nil
+	}
+
+	best := make(map[string]float64)
+	for _, ex := range records {
+		lift, ok := matchMainLift(ex.ExerciseName)
+		if !ok {
+			continue
+		}
+		for i, reps := range ex.RepsPerSet {
+			if i >= len(ex.WeightUsed) {
+				break
+			}
+			estimate := estimateOneRepMax(ex.WeightUsed[i], reps)
+			if estimate > best[lift] {
+				best[lift] = estimate
+			}
+		}
+	}
+	return best
+}
+
+// exerciseRecords decodes the structured set logs of a training record's
+// exercises; it returns nil when they are missing or malformed
+func exerciseRecords(exercises model.JSONMap) []model.ExerciseRecord {
+	items, ok := exercises["items"]
+	if !ok {
+		return nil
+	}
+	raw, err := json.Marshal(items)
+	if err != nil {
+		return nil
+	}
+	var records []model.ExerciseRecord
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return nil
+	}
+	return records
+}
+
+// estimateOneRepMax applies the Epley formula; it returns 0 when the set
+// cannot give a meaningful estimate
+func estimateOneRepMax(weight float64, reps int) float64 {
+	if weight <= 0 || reps <= 0 || reps > maxRepsForEstimate {
+		return 0
+	}
+	if reps == 1 {
+		return roundLoad(weight)
+	}
+	return roundLoad(weight * (1 + float64(reps)/30))
+}
+
+// roundLoad rounds a load to 0.5 kg
+func roundLoad(kg float64) float64 {
+	return math.Round(kg*2) / 2
+}
+
+// mainLiftAliases maps exercise name fragments to main lifts. Variations that
+// do not reflect the barbell lift's max are excluded by mainLiftExclusions.
+var mainLiftAliases = []struct {
+	lift    string
+	aliases []string
+}{
+	{model.LiftSquat, []string{"深蹲", "squat"}},
+	{model.LiftBenchPress, []string{"卧推", "bench press"}},
+	{model.LiftDeadlift, []string{"硬拉", "deadlift"}},
+	{model.LiftOverheadPress, []string{"推举", "肩推", "overhead press", "military press"}},
+}
+
+var mainLiftExclusions = []string{
+	"哑铃", "dumbbell", "壶铃", "kettlebell", "器械", "machine", "史密斯", "smith",
+	"上斜", "incline", "下斜", "decline", "罗马尼亚", "romanian", "直腿", "stiff",
+	"高脚杯", "goblet", "保加利亚", "bulgarian", "分腿", "split", "自重", "bodyweight",
+	"前蹲", "front", "跳", "jump",
+}
+
+// matchMainLift maps an exercise name to a main lift
+func matchMainLift(name string) (string, bool) {
+	n := strings.ToLower(strings.TrimSpace(name))
+	if n == "" {
+		return "", false
+	}
+	for _, ex := range mainLiftExclusions {
+		if strings.Contains(n, ex) {
+			return "", false
+		}
+	}
+	for _, m := range mainLiftAliases {
+		for _, alias := range m.aliases {
+			if strings.Contains(n, alias) {
+				return m.lift, true
+			}
+		}
+	}
+	return "", false
+}
+
+func isMainLift(lift string) bool {
+	for _, l := range model.MainLifts {
+		if l == lift {
+			return true
+		}
+	}
+	return false
+}
+
+// strengthProfilePromptSection renders the strength profile as a prompt section
+func strengthProfilePromptSection(entries []*model.StrengthProfileEntry) string {
+	section := "\nStrength Profile (current 1RM):\n"
+	for _, e := range entries {
+		section += fmt.Sprintf("- %s: %.1f kg (as of %s)\n", e.Lift, e.OneRepMax, e.AchievedAt.Format("2006-01-02"))
+	}
+	section += `For these lifts and their close variations, prescribe concrete loads as "%1RM (≈ X kg)", e.g. "75% 1RM (≈ 60kg)", rounded to 2.5 kg. Never use vague loads such as "根据个人能力".
+`
+	return section
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// defaultOneRepMaxDays is how far back set logs are searched by default
+const defaultOneRepMaxDays = 90
+
+// Strength levels, weakest first
+const (
+	StrengthLevelBeginner     = "beginner"
+	StrengthLevelNovice       = "novice"
+	StrengthLevelIntermediate = "intermediate"
+	StrengthLevelAdvanced     = "advanced"
+	StrengthLevelElite        = "elite"
+)
+
+// strengthLevels lists the levels in the order of the standards' thresholds
+var strengthLevels = []string{
+	StrengthLevelBeginner,
+	StrengthLevelNovice,
+	StrengthLevelIntermediate,
+	StrengthLevelAdvanced,
+	StrengthLevelElite,
+}
+
+// strengthStandards are the 1RMs, as multiples of bodyweight, at which each
+// strength level starts, per gender and lift. They follow widely used
+// bodyweight-relative tables for adult lifters.
+var strengthStandards = map[string]map[string][5]float64{
+	string(model.GenderMale): {
+		model.LiftSquat:         {0.75, 1.25, 1.5, 2.25, 2.75},
+		model.LiftBenchPress:    {0.5, 0.75, 1.25, 1.75, 2},
+		model.LiftDeadlift:      {1, 1.5, 2, 2.5, 3},
+		model.LiftOverheadPress: {0.4, 0.55, 0.8, 1.1, 1.4},
+	},
+	string(model.GenderFemale): {
+		model.LiftSquat:         {0.5, 0.75, 1.25, 1.75, 2.25},
+		model.LiftBenchPress:    {0.25, 0.5, 0.75, 1, 1.5},
+		model.LiftDeadlift:      {0.5, 1, 1.25, 1.75, 2.5},
+		model.LiftOverheadPress: {0.2, 0.35, 0.5, 0.75, 1},
+	},
+}
+
+// One-rep max sources
+const (
+	OneRepMaxSourceRecords = "records"
+	OneRepMaxSourceProfile = "profile"
+)
+
+// OneRepMaxReport is the estimated 1RM of each main lift and how it compares
+// with bodyweight-relative strength standards
+type OneRepMaxReport struct {
+	Days int `json:"days"`
+	// BodyWeight and Gender come from the latest body data; without them
+	// lifts are not classified
+	BodyWeight *float64        `json:"body_weight,omitempty"`
+	Gender     string          `json:"gender,omitempty"`
+	Lifts      []LiftOneRepMax `json:"lifts"`
+
+	HasSufficientData bool   `json:"has_sufficient_data"`
+	Message           string `json:"message,omitempty"`
+}
+
+// LiftOneRepMax is the estimated 1RM of a main lift. OneRepMax is the Epley
+// estimate of the best logged set, as used by the strength profile, or the
+// profile's value when no set was logged in the period.
+type LiftOneRepMax struct {
+	Lift      string  `json:"lift"`
+	OneRepMax float64 `json:"one_rep_max"`
+	Source    string  `json:"source"`
+	// Epley and Brzycki are the estimates of the best set; zero when the
+	// 1RM comes from the profile
+	Epley   float64    `json:"epley"`
+	Brzycki float64    `json:"brzycki"`
+	BestSet *BestSet   `json:"best_set,omitempty"`
+	Level   *LiftLevel `json:"level,omitempty"`
+}
+
+// BestSet is the logged set giving the highest 1RM estimate
+type BestSet struct {
+	RecordID int64     `json:"record_id"`
+	Date     time.Time `json:"date"`
+	Exercise string    `json:"exercise"`
+	Weight   float64   `json:"weight"`
+	Reps     int       `json:"reps"`
+}
+
+// LiftLevel classifies a 1RM against the strength standards
+type LiftLevel struct {
+	BodyweightRatio float64 `json:"bodyweight_ratio"`
+	Level           string  `json:"level"`
+	// NextLevel and NextLevelOneRepMax are empty at elite
+	NextLevel          string  `json:"next_level,omitempty"`
+	NextLevelOneRepMax float64 `json:"next_level_one_rep_max,omitempty"`
+}
+
+// GetOneRepMaxReport estimates the user's 1RM per main lift from the sets
+// logged in the last days days (90 when 0), falling back to the strength
+// profile, and classifies each against the strength standards for the
+// user's bodyweight and gender
+func (s *statisticsService) GetOneRepMaxReport(ctx context.Context, userID int64, days int) (*OneRepMaxReport, error) {
+	if days <= 0 {
+		days = defaultOneRepMaxDays
+	}
+
+	endDate := time.Now()
+	startDate := dayStart(endDate).AddDate(0, 0, -days)
+	records, err := s.trainingRecordRepo.ListByUser(ctx, userID, &startDate, &endDate)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取训练记录失败")
+	}
+	profile, err := s.oneRepMaxes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	bodyData, err := s.bodyDataRepo.GetLatestByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取身体数据失败")
+	}
+
+	report := &OneRepMaxReport{Days: days, Lifts: []LiftOneRepMax{}}
+	if bodyData != nil && bodyData.Weight > 0 {
+		weight := bodyData.Weight
+		report.BodyWeight = &weight
+		report.Gender = bodyData.Gender
+	}
+
+	best := bestLoggedSets(records)
+	for _, lift := range model.MainLifts {
+		entry := LiftOneRepMax{Lift: lift}
+		if set, ok := best[lift]; ok {
+			entry.Source = OneRepMaxSourceRecords
+			entry.BestSet = set
+			entry.Epley = estimateOneRepMax(set.Weight, set.Reps)
+			entry.Brzycki = brzyckiOneRepMax(set.Weight, set.Reps)
+			entry.OneRepMax = entry.Epley
+		} else if oneRepMax, ok := profile[lift]; ok {
+			entry.Source = OneRepMaxSourceProfile
+			entry.OneRepMax = oneRepMax
+		} else {
+			continue
+		}
+		if report.BodyWeight != nil {
+			entry.Level = classifyStrength(report.Gender, lift, entry.OneRepMax, *report.BodyWeight)
+		}
+		report.Lifts = append(report.Lifts, entry)
+	}
+
+	switch {
+	case len(report.Lifts) == 0:
+		report.Message = "没有主项动作的训练记录或力量档案，无法估算1RM"
+	case report.BodyWeight == nil:
+		report.HasSufficientData = true
+		report.Message = "缺少体重数据，无法对比力量标准"
+	default:
+		report.HasSufficientData = true
+	}
+	return report, nil
+}
+
+// bestLoggedSets finds the set with the highest Epley estimate per main lift
+func bestLoggedSets(records []*model.TrainingRecord) map[string]*BestSet {
+	best := make(map[string]*BestSet)
+	estimates := make(map[string]float64)
+	for _, record := range records {
+		for _, ex := range exerciseRecords(record.Exercises) {
+			lift, ok := matchMainLift(ex.ExerciseName)
+			if !ok {
+				continue
+			}
+			for i, reps := range ex.RepsPerSet {
+				if i >= len(ex.WeightUsed) {
+					break
+				}
+				estimate := estimateOneRepMax(ex.WeightUsed[i], reps)
+				if estimate <= estimates[lift] {
+					continue
+				}
+				estimates[lift] = estimate
+				best[lift] = &BestSet{
+					RecordID: record.ID,
+					Date:     dayStart(record.WorkoutDate),
+					Exercise: ex.ExerciseName,
+					Weight:   ex.WeightUsed[i],
+					Reps:     reps,
+				}
+			}
+		}
+	}
+	return best
+}
+
+// brzyckiOneRepMax applies the Brzycki formula, which reads lower than
+// Epley for sets of more than ten reps; it returns 0 when the set cannot
+// give a meaningful estimate
+func brzyckiOneRepMax(weight float64, reps int) float64 {
+	if weight <= 0 || reps <= 0 || reps > maxRepsForEstimate {
+		return 0
+	}
+	return roundLoad(weight * 36 / float64(37-reps))
+}
+
+// classifyStrength places a 1RM on the standards of the gender. Users who
+// gave neither male nor female are compared with the midpoint of the two.
+func classifyStrength(gender, lift string, oneRepMax, bodyWeight float64) *LiftLevel {
+	thresholds, ok := strengthStandards[gender][lift]
+	if !ok {
+		male := strengthStandards[string(model.GenderMale)][lift]
+		female := strengthStandards[string(model.GenderFemale)][lift]
+		for i := range thresholds {
+			thresholds[i] = (male[i] + female[i]) / 2
+		}
+	}
+
+	ratio := oneRepMax / bodyWeight
+	level := &LiftLevel{
+		BodyweightRatio: math.Round(ratio*100) / 100,
+		Level:           strengthLevels[0],
+	}
+	next := 1
+	for i := 1; i < len(thresholds); i++ {
+		if ratio < thresholds[i] {
+			break
+		}
+		level.Level = strengthLevels[i]
+		next = i + 1
+	}
+	if next < len(thresholds) {
+		level.NextLevel = strengthLevels[next]
+		level.NextLevelOneRepMax = roundLoad(thresholds[next] * bodyWeight)
+	}
+	return level
+}
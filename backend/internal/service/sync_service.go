@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Conflict resolution policies for offline sync
+const (
+	SyncPolicyServerWins = "server_wins"
+	SyncPolicyClientWins = "client_wins"
+)
+
+// Entity names used in sync conflicts and rejections
+const (
+	SyncEntityTrainingRecord  = "training_record"
+	SyncEntityNutritionRecord = "nutrition_record"
+)
+
+// SyncTrainingChange is a training record created, edited or deleted offline.
+// Record is ignored for deletions.
+type SyncTrainingChange struct {
+	ClientID string
+	Deleted  bool
+	Record   *model.TrainingRecord
+}
+
+// SyncNutritionChange is a nutrition record created, edited or deleted offline
+type SyncNutritionChange struct {
+	ClientID string
+	Deleted  bool
+	Record   *model.NutritionRecord
+}
+
+// SyncRequest holds the client's pending changes and its sync cursor
+type SyncRequest struct {
+	// LastSyncedAt is the ServerTime of the client's previous sync; nil for a
+	// first sync, which pulls everything
+	LastSyncedAt     *time.Time
+	TrainingRecords  []SyncTrainingChange
+	NutritionRecords []SyncNutritionChange
+}
+
+// SyncConflict reports a record changed on both sides since the last sync
+type SyncConflict struct {
+	Entity     string
+	ClientID   string
+	Resolution string
+}
+
+// SyncRejection reports a pushed change that failed validation
+type SyncRejection struct {
+	Entity   string
+	ClientID string
+	Reason   string
+}
+
+// SyncResult holds what the client must apply locally. The record lists
+// include tombstones (DeletedAt set) and the records just pushed.
+type SyncResult struct {
+	// ServerTime is the cursor to send as LastSyncedAt next time
+	ServerTime       time.Time
+	TrainingRecords  []*model.TrainingRecord
+	NutritionRecords []*model.NutritionRecord
+	Conflicts        []SyncConflict
+	Rejected         []SyncRejection
+}
+
+// SyncService defines the interface for offline sync of mobile clients
+type SyncService interface {
+	// Sync applies the client's changes, then returns every record changed
+	// since the client's cursor
+	Sync(ctx context.Context, userID int64, req *SyncRequest) (*SyncResult, error)
+}
+
+// syncService implements SyncService interface
+type syncService struct {
+	trainingRecordRepo  repository.TrainingRecordRepository
+	nutritionRecordRepo repository.NutritionRecordRepository
+	trainingPlanRepo    repository.TrainingPlanRepository
+	strengthService     StrengthProfileService
+	trainingPolicy      string
+	nutritionPolicy     string
+	maxBatchSize        int
+}
+
+// NewSyncService creates a new instance of SyncService. Unknown policies fall
+// back to server_wins.
+func NewSyncService(
+	trainingRecordRepo repository.TrainingRecordRepository,
+	nutritionRecordRepo repository.NutritionRecordRepository,
+	trainingPlanRepo repository.TrainingPlanRepository,
+	strengthService StrengthProfileService,
+	trainingPolicy, nutritionPolicy string,
+	maxBatchSize int,
+) SyncService {
+	return &syncService{
+		trainingRecordRepo:  trainingRecordRepo,
+		nutritionRecordRepo: nutritionRecordRepo,
+		trainingPlanRepo:    trainingPlanRepo,
+		strengthService:     strengthService,
+		trainingPolicy:      normalizeSyncPolicy(trainingPolicy),
+		nutritionPolicy:     normalizeSyncPolicy(nutritionPolicy),
+		maxBatchSize:        maxBatchSize,
+	}
+}
+
+// Sync pushes then pulls. The cursor is taken between the two and rounded up
+// to the next second because MySQL rounds updated_at to whole seconds: records
+// pushed here are not after the cursor and so never come back as conflicts,
+// while later writes are at or after it and get pulled. A record may be pulled
+// twice; clients match records by client ID.
+func (s *syncService) Sync(ctx context.Context, userID int64, req *SyncRequest) (*SyncResult, error) {
+	if s.maxBatchSize > 0 && len(req.TrainingRecords)+len(req.NutritionRecords) > s.maxBatchSize {
+		return nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("单次同步最多%d条记录", s.maxBatchSize))
+	}
+
+	now := time.Now()
+	result := &SyncResult{}
+
+	for _, change := range req.TrainingRecords {
+		if err := s.applyTrainingChange(ctx, userID, req.LastSyncedAt, change, now, result); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "同步训练记录失败")
+		}
+	}
+	for _, change := range req.NutritionRecords {
+		if err := s.applyNutritionChange(ctx, userID, req.LastSyncedAt, change, now, result); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase, "同步饮食记录失败")
+		}
+	}
+
+	result.ServerTime = time.Now().Truncate(time.Second).Add(time.Second)
+
+	var err error
+	result.TrainingRecords, err = s.trainingRecordRepo.ListChangedSince(ctx, userID, req.LastSyncedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取训练记录变更失败")
+	}
+	result.NutritionRecords, err = s.nutritionRecordRepo.ListChangedSince(ctx, userID, req.LastSyncedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取饮食记录变更失败")
+	}
+
+	return result, nil
+}
+
+// applyTrainingChange applies one pushed training record change. Validation
+// failures are added to result.Rejected; only storage errors are returned.
+func (s *syncService) applyTrainingChange(ctx context.Context, userID int64, since *time.Time, change SyncTrainingChange, now time.Time, result *SyncResult) error {
+	existing, err := s.trainingRecordRepo.GetByClientID(ctx, userID, change.ClientID)
+	if err != nil {
+		return err
+	}
+	if existing == nil && change.Deleted {
+		// Created and deleted offline; the server never saw it
+		return nil
+	}
+
+	if existing != nil && changedSince(existing.UpdatedAt, since) {
+		result.Conflicts = append(result.Conflicts, SyncConflict{
+			Entity:     SyncEntityTrainingRecord,
+			ClientID:   change.ClientID,
+			Resolution: s.trainingPolicy,
+		})
+		if s.trainingPolicy == SyncPolicyServerWins {
+			return nil
+		}
+	}
+
+	if change.Deleted {
+		existing.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+		return s.trainingRecordRepo.SaveSynced(ctx, existing)
+	}
+
+	record := change.Record
+	if reason := s.validateTrainingRecord(ctx, userID, record, now); reason != "" {
+		result.Rejected = append(result.Rejected, SyncRejection{
+			Entity:   SyncEntityTrainingRecord,
+			ClientID: change.ClientID,
+			Reason:   reason,
+		})
+		return nil
+	}
+
+	record.UserID = userID
+	record.ClientID = change.ClientID
+	record.CreatedAt = now
+	record.DeletedAt = gorm.DeletedAt{}
+	if existing != nil {
+		record.ID = existing.ID
+		record.CreatedAt = existing.CreatedAt
+		record.IdempotencyKey = existing.IdempotencyKey
+	}
+	if err := s.trainingRecordRepo.SaveSynced(ctx, record); err != nil {
+		return err
+	}
+
+	if _, err := s.strengthService.UpdateFromRecord(ctx, record); err != nil {
+		logger.Warn("Failed to update strength profile from synced training record",
+			zap.Int64("user_id", userID),
+			zap.Int64("record_id", record.ID),
+			zap.Error(err),
+		)
+	}
+	return nil
+}
+
+// validateTrainingRecord returns why a pushed record cannot be saved, or ""
+func (s *syncService) validateTrainingRecord(ctx context.Context, userID int64, record *model.TrainingRecord, now time.Time) string {
+	if record == nil || record.WorkoutDate.IsZero() || record.WorkoutType == "" {
+		return "缺少训练日期或训练类型"
+	}
+	if record.WorkoutDate.After(now) {
+		return "训练日期不能是未来日期"
+	}
+	if err := applySessionDuration(record, now); err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return appErr.Message
+		}
+		return err.Error()
+	}
+	if record.PlanID != nil {
+		plan, err := s.trainingPlanRepo.GetByID(ctx, *record.PlanID)
+		if err != nil || plan == nil || plan.UserID != userID {
+			return "训练计划不存在"
+		}
+	}
+	return ""
+}
+
+// applyNutritionChange applies one pushed nutrition record change
+func (s *syncService) applyNutritionChange(ctx context.Context, userID int64, since *time.Time, change SyncNutritionChange, now time.Time, result *SyncResult) error {
+	existing, err := s.nutritionRecordRepo.GetByClientID(ctx, userID, change.ClientID)
+	if err != nil {
+		return err
+	}
+	if existing == nil && change.Deleted {
+		return nil
+	}
+
+	if existing != nil && changedSince(existing.UpdatedAt, since) {
+		result.Conflicts = append(result.Conflicts, SyncConflict{
+			Entity:     SyncEntityNutritionRecord,
+			ClientID:   change.ClientID,
+			Resolution: s.nutritionPolicy,
+		})
+		if s.nutritionPolicy == SyncPolicyServerWins {
+			return nil
+		}
+	}
+
+	if change.Deleted {
+		existing.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+		return s.nutritionRecordRepo.SaveSynced(ctx, existing)
+	}
+
+	record := change.Record
+	if record == nil || record.MealDate.IsZero() || record.MealTime == "" {
+		result.Rejected = append(result.Rejected, SyncRejection{
+			Entity:   SyncEntityNutritionRecord,
+			ClientID: change.ClientID,
+			Reason:   "缺少用餐日期或餐次",
+		})
+		return nil
+	}
+	if record.MealDate.After(now) {
+		result.Rejected = append(result.Rejected, SyncRejection{
+			Entity:   SyncEntityNutritionRecord,
+			ClientID: change.ClientID,
+			Reason:   "用餐日期不能是未来日期",
+		})
+		return nil
+	}
+
+	record.UserID = userID
+	record.ClientID = change.ClientID
+	record.CreatedAt = now
+	record.DeletedAt = gorm.DeletedAt{}
+	if existing != nil {
+		record.ID = existing.ID
+		record.CreatedAt = existing.CreatedAt
+	}
+	return s.nutritionRecordRepo.SaveSynced(ctx, record)
+}
+
+// changedSince reports whether a server record changed after the client's
+// cursor. Without a cursor the client has never seen the server copy, so any
+// existing record counts as changed.
+func changedSince(updatedAt time.Time, since *time.Time) bool {
+	return since == nil || updatedAt.After(*since)
+}
+
+func normalizeSyncPolicy(policy string) string {
+	if policy == SyncPolicyClientWins {
+		return SyncPolicyClientWins
+	}
+	return SyncPolicyServerWins
+}
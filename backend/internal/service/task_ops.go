@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	apperrors "github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/pkg/taskqueue"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+// defaultStuckTaskAge is how long a task must have been processing to be
+// listed as stuck when no threshold is given
+const defaultStuckTaskAge = 10 * time.Minute
+
+// Provider names of queued tasks that do not call a configured AI API
+const (
+	queueProviderTemplate = "template"
+	queueProviderUnknown  = "unknown"
+)
+
+// QueuedGenerationTask is a generation task held by the task queue
+type QueuedGenerationTask struct {
+	TaskID   string
+	TaskType string
+	UserID   int64
+	AIAPIID  int64
+	Provider string
+	// State is active, scheduled or pending
+	State       string
+	Attempt     int
+	MaxAttempts int
+	LastError   string
+	EnqueuedAt  time.Time
+	ClaimedAt   time.Time
+	// Deadline is when an active task's lease runs out or when a scheduled
+	// retry is due
+	Deadline time.Time
+}
+
+// ProviderQueueDepth counts the queued tasks bound for one AI provider
+type ProviderQueueDepth struct {
+	Provider  string
+	Pending   int
+	Scheduled int
+	Active    int
+}
+
+// TaskOpsService lets admins deal with generation tasks that hang in the
+// queue without editing Redis or the task history by hand
+type TaskOpsService interface {
+	// ListStuckTasks lists tasks that have been processing for longer than
+	// olderThan, longest first
+	ListStuckTasks(ctx context.Context, olderThan time.Duration) ([]*QueuedGenerationTask, error)
+	// RequeueTask puts a processing or scheduled task back at the front of
+	// the queue with its attempts reset
+	RequeueTask(ctx context.Context, adminID int64, taskID string) error
+	// FailTask takes a task off the queue and records it as failed
+	FailTask(ctx context.Context, adminID int64, taskID, reason string) error
+	// QueueDepth counts the queued tasks per AI provider
+	QueueDepth(ctx context.Context) ([]ProviderQueueDepth, error)
+}
+
+// taskOpsService implements TaskOpsService interface
+type taskOpsService struct {
+	queue            *taskqueue.Queue
+	aiAPIRepo        repository.AIAPIRepository
+	trainingService  TrainingService
+	nutritionService NutritionService
+}
+
+// NewTaskOpsService creates a new instance of TaskOpsService
+func NewTaskOpsService(
+	queue *taskqueue.Queue,
+	aiAPIRepo repository.AIAPIRepository,
+	trainingService TrainingService,
+	nutritionService NutritionService,
+) TaskOpsService {
+	return &taskOpsService{
+		queue:            queue,
+		aiAPIRepo:        aiAPIRepo,
+		trainingService:  trainingService,
+		nutritionService: nutritionService,
+	}
+}
+
+// ListStuckTasks goes by when the current attempt was claimed, so a task
+// whose worker keeps renewing its lease without finishing is listed too
+func (s *taskOpsService) ListStuckTasks(ctx context.Context, olderThan time.Duration) ([]*QueuedGenerationTask, error) {
+	if olderThan <= 0 {
+		olderThan = defaultStuckTaskAge
+	}
+
+	tasks, err := s.queuedTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	stuck := make([]*QueuedGenerationTask, 0)
+	for _, task := range tasks {
+		if task.State == taskqueue.StateActive && !task.ClaimedAt.IsZero() && task.ClaimedAt.Before(cutoff) {
+			stuck = append(stuck, task)
+		}
+	}
+	sort.SliceStable(stuck, func(i, j int) bool {
+		return stuck[i].ClaimedAt.Before(stuck[j].ClaimedAt)
+	})
+	return stuck, nil
+}
+
+// RequeueTask does not stop a worker still running the task; if that worker
+// finishes after all, the user's plan may be generated twice
+func (s *taskOpsService) RequeueTask(ctx context.Context, adminID int64, taskID string) error {
+	if err := s.queue.Requeue(ctx, taskID); err != nil {
+		return queueOpError(err, "重新排队失败")
+	}
+
+	logger.Info("Admin requeued generation task",
+		zap.Int64("admin_id", adminID),
+		zap.String("task_id", taskID),
+	)
+	return nil
+}
+
+// FailTask hands the removed task to the service owning its type, which
+// writes the history and releases the user's generation lock
+func (s *taskOpsService) FailTask(ctx context.Context, adminID int64, taskID, reason string) error {
+	task, err := s.queue.Remove(ctx, taskID)
+	if err != nil {
+		return queueOpError(err, "终止任务失败")
+	}
+
+	switch {
+	case containsString(trainingTaskTypes, task.Type):
+		s.trainingService.FailQueuedTask(task, reason)
+	case containsString(nutritionTaskTypes, task.Type):
+		s.nutritionService.FailQueuedTask(task, reason)
+	default:
+		logger.Warn("Removed queued task of unknown type",
+			zap.String("task_id", taskID),
+			zap.String("task_type", task.Type),
+		)
+	}
+
+	logger.Info("Admin failed generation task",
+		zap.Int64("admin_id", adminID),
+		zap.String("task_id", taskID),
+		zap.String("task_type", task.Type),
+		zap.String("reason", reason),
+	)
+	return nil
+}
+
+// QueueDepth groups the queue's tasks by the provider of the AI API they
+// were submitted with, most tasks first
+func (s *taskOpsService) QueueDepth(ctx context.Context) ([]ProviderQueueDepth, error) {
+	tasks, err := s.queuedTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	depths := make(map[string]*ProviderQueueDepth)
+	for _, task := range tasks {
+		depth, ok := depths[task.Provider]
+		if !ok {
+			depth = &ProviderQueueDepth{Provider: task.Provider}
+			depths[task.Provider] = depth
+		}
+		switch task.State {
+		case taskqueue.StateActive:
+			depth.Active++
+		case taskqueue.StateScheduled:
+			depth.Scheduled++
+		default:
+			depth.Pending++
+		}
+	}
+
+	result := make([]ProviderQueueDepth, 0, len(depths))
+	for _, depth := range depths {
+		result = append(result, *depth)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		ti := result[i].Pending + result[i].Scheduled + result[i].Active
+		tj := result[j].Pending + result[j].Scheduled + result[j].Active
+		if ti != tj {
+			return ti > tj
+		}
+		return result[i].Provider < result[j].Provider
+	})
+	return result, nil
+}
+
+// queuedTasks reads every task in the queue and resolves its provider.
+// AI APIs are looked up once per ID.
+func (s *taskOpsService) queuedTasks(ctx context.Context) ([]*QueuedGenerationTask, error) {
+	infos, err := s.queue.Tasks(ctx)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCache, "读取任务队列失败")
+	}
+
+	providers := map[int64]string{0: queueProviderTemplate}
+	tasks := make([]*QueuedGenerationTask, 0, len(infos))
+	for _, info := range infos {
+		header := decodeTaskHeader(&info.Task)
+		provider, ok := providers[header.AIAPIID]
+		if !ok {
+			api, err := s.aiAPIRepo.GetByID(ctx, header.AIAPIID)
+			if err != nil {
+				return nil, apperrors.Wrap(err, apperrors.ErrDatabase, "获取AI配置失败")
+			}
+			provider = queueProviderUnknown
+			if api != nil {
+				provider = api.Provider
+			}
+			providers[header.AIAPIID] = provider
+		}
+
+		tasks = append(tasks, &QueuedGenerationTask{
+			TaskID:      info.ID,
+			TaskType:    info.Type,
+			UserID:      header.UserID,
+			AIAPIID:     header.AIAPIID,
+			Provider:    provider,
+			State:       info.State,
+			Attempt:     info.Attempt,
+			MaxAttempts: info.MaxAttempts,
+			LastError:   info.LastError,
+			EnqueuedAt:  info.EnqueuedAt,
+			ClaimedAt:   info.ClaimedAt,
+			Deadline:    info.Deadline,
+		})
+	}
+	return tasks, nil
+}
+
+// queueOpError maps the queue's errors for a task to app errors
+func queueOpError(err error, message string) error {
+	switch {
+	case errors.Is(err, taskqueue.ErrTaskNotFound):
+		return apperrors.New(apperrors.ErrNotFound, "任务不在队列中")
+	case errors.Is(err, taskqueue.ErrTaskPending):
+		return apperrors.New(apperrors.ErrConflict, "任务正在排队等待处理")
+	}
+	return apperrors.Wrap(err, apperrors.ErrCache, message)
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// todayCacheGrace keeps a day's entries a little past midnight, so requests
+// that started the evening before can still read them
+const todayCacheGrace = time.Hour
+
+// todayCacheSettle is how long a plan must go unchanged before its day is
+// cached. Plan timestamps have second precision, so an edit in the same
+// second as the read that cached it would otherwise go unnoticed.
+const todayCacheSettle = 5 * time.Second
+
+// TodayCache keeps the training day and meals each user has scheduled for
+// the current date. Entries are keyed by the plan and the time it was last
+// updated, so an edited, replaced or deactivated plan is never served from
+// the cache; readers only look up which plan is active, leaving its plan
+// data unparsed.
+type TodayCache interface {
+	// Get reads the value cached under key into v and reports whether it
+	// was found
+	Get(ctx context.Context, key string, v interface{}) bool
+	// Set caches v under key until shortly after the end of date
+	Set(ctx context.Context, key string, date time.Time, v interface{})
+}
+
+// redisTodayCache implements TodayCache using Redis
+type redisTodayCache struct {
+	client *redis.Client
+}
+
+// NewTodayCache creates a Redis-backed TodayCache. It returns nil, which
+// disables caching, when client is nil.
+func NewTodayCache(client *redis.Client) TodayCache {
+	if client == nil {
+		return nil
+	}
+	return &redisTodayCache{client: client}
+}
+
+// todayTrainingCacheKey is the key of the training day a plan schedules on
+// date
+func todayTrainingCacheKey(userID int64, date time.Time, plan *model.TrainingPlan) string {
+	return fmt.Sprintf("today:training:%d:%s:%d:%d", userID, date.Format("2006-01-02"), plan.ID, plan.UpdatedAt.Unix())
+}
+
+// todayMealsCacheKey is the key of the meals a plan schedules on date
+func todayMealsCacheKey(userID int64, date time.Time, plan *model.NutritionPlan) string {
+	return fmt.Sprintf("today:meals:%d:%s:%d:%d", userID, date.Format("2006-01-02"), plan.ID, plan.UpdatedAt.Unix())
+}
+
+// Get looks up key. Redis failures and unreadable entries are logged and
+// treated as a miss.
+func (c *redisTodayCache) Get(ctx context.Context, key string, v interface{}) bool {
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			logger.Warn("Today cache lookup failed", zap.Error(err))
+		}
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		logger.Warn("Today cache entry unreadable", zap.String("key", key), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// Set stores v under key until todayCacheGrace after the end of date
+func (c *redisTodayCache) Set(ctx context.Context, key string, date time.Time, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		logger.Warn("Today cache store failed", zap.String("key", key), zap.Error(err))
+		return
+	}
+	nextDay := time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, date.Location())
+	ttl := time.Until(nextDay) + todayCacheGrace
+	if ttl <= 0 {
+		return
+	}
+	if err := c.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		logger.Warn("Today cache store failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// cacheableAt reports whether a plan last updated at updatedAt has settled
+// long enough for its day to be cached
+func cacheableAt(updatedAt time.Time) bool {
+	return time.Since(updatedAt) >= todayCacheSettle
+}
+
+// cachedTodaySchedule returns the training day the user's active plan
+// schedules on date, through cache when one is configured. A day without
+// training is cached as well, as nil.
+func cachedTodaySchedule(ctx context.Context, cache TodayCache, planRepo repository.TrainingPlanRepository, userID int64, date time.Time) (*model.DayPlan, error) {
+	if cache == nil {
+		return planRepo.GetTodaySchedule(ctx, userID, date)
+	}
+
+	plan, err := planRepo.GetActiveOn(ctx, userID, date)
+	if err != nil || plan == nil {
+		return nil, err
+	}
+	key := todayTrainingCacheKey(userID, date, plan)
+	var dayPlan *model.DayPlan
+	if cache.Get(ctx, key, &dayPlan) {
+		return dayPlan, nil
+	}
+
+	dayPlan, err = planRepo.GetTodaySchedule(ctx, userID, date)
+	if err != nil {
+		return nil, err
+	}
+	// Another plan may have been activated in between; it gets its own entry
+	// on the next read
+	if (dayPlan == nil || dayPlan.PlanID == plan.ID) && cacheableAt(plan.UpdatedAt) {
+		cache.Set(ctx, key, date, dayPlan)
+	}
+	return dayPlan, nil
+}
+
+// cachedTodayMeals returns the meals the user's active nutrition plan
+// schedules on date, through cache when one is configured
+func cachedTodayMeals(ctx context.Context, cache TodayCache, planRepo repository.NutritionPlanRepository, userID int64, date time.Time) ([]model.NutritionPlanMeal, error) {
+	if cache == nil {
+		return planRepo.GetTodayMeals(ctx, userID, date)
+	}
+
+	plan, err := planRepo.GetActiveOn(ctx, userID, date)
+	if err != nil || plan == nil {
+		return nil, err
+	}
+	key := todayMealsCacheKey(userID, date, plan)
+	var meals []model.NutritionPlanMeal
+	if cache.Get(ctx, key, &meals) {
+		return meals, nil
+	}
+
+	meals, err = planRepo.GetTodayMeals(ctx, userID, date)
+	if err != nil {
+		return nil, err
+	}
+	if cacheableAt(plan.UpdatedAt) {
+		cache.Set(ctx, key, date, meals)
+	}
+	return meals, nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// todayWarmupClaimTTL outlives the day a warmup claim is for
+const todayWarmupClaimTTL = 48 * time.Hour
+
+// TodayWarmupService fills the today cache for every active user once a day,
+// shortly after midnight, so the first dashboard of the morning is served
+// from Redis rather than every user's plan data being parsed at once
+type TodayWarmupService interface {
+	// WarmToday caches today's training day and meals of every user with an
+	// active plan, unless another run already did so today, and returns how
+	// many users were warmed
+	WarmToday(ctx context.Context) (int, error)
+}
+
+// todayWarmupService implements TodayWarmupService interface
+type todayWarmupService struct {
+	client            *redis.Client
+	cache             TodayCache
+	trainingPlanRepo  repository.TrainingPlanRepository
+	nutritionPlanRepo repository.NutritionPlanRepository
+}
+
+// NewTodayWarmupService creates a new instance of TodayWarmupService.
+// Plan dates are calendar dates in the server's zone, so every user's day
+// starts at the server's midnight.
+func NewTodayWarmupService(
+	client *redis.Client,
+	cache TodayCache,
+	trainingPlanRepo repository.TrainingPlanRepository,
+	nutritionPlanRepo repository.NutritionPlanRepository,
+) TodayWarmupService {
+	return &todayWarmupService{
+		client:            client,
+		cache:             cache,
+		trainingPlanRepo:  trainingPlanRepo,
+		nutritionPlanRepo: nutritionPlanRepo,
+	}
+}
+
+// todayWarmupClaimKey marks the date whose cache a run has warmed
+func todayWarmupClaimKey(date time.Time) string {
+	return fmt.Sprintf("today:warmup:%s", date.Format("2006-01-02"))
+}
+
+// WarmToday claims the date first, so that of several instances only one
+// warms it. A run that fails part way gives the claim back for the next run
+// to finish; users it already warmed are cache hits then.
+func (s *todayWarmupService) WarmToday(ctx context.Context) (int, error) {
+	if s.cache == nil {
+		return 0, nil
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	claimKey := todayWarmupClaimKey(today)
+	claimed, err := s.client.SetNX(ctx, claimKey, now.Unix(), todayWarmupClaimTTL).Result()
+	if err != nil || !claimed {
+		return 0, err
+	}
+
+	warmed, err := s.warm(ctx, today)
+	if err != nil {
+		if delErr := s.client.Del(context.Background(), claimKey).Err(); delErr != nil {
+			logger.Warn("Failed to release today cache warmup claim", zap.String("key", claimKey), zap.Error(delErr))
+		}
+		return warmed, err
+	}
+	return warmed, nil
+}
+
+// warm caches the day of every user with an active training or nutrition
+// plan covering date
+func (s *todayWarmupService) warm(ctx context.Context, date time.Time) (int, error) {
+	trainingUsers, err := s.trainingPlanRepo.ListActiveUserIDs(ctx, date)
+	if err != nil {
+		return 0, err
+	}
+	nutritionUsers, err := s.nutritionPlanRepo.ListActiveUserIDs(ctx, date)
+	if err != nil {
+		return 0, err
+	}
+
+	warmed := make(map[int64]bool, len(trainingUsers))
+	for _, userID := range trainingUsers {
+		if ctx.Err() != nil {
+			return len(warmed), ctx.Err()
+		}
+		if _, err := cachedTodaySchedule(ctx, s.cache, s.trainingPlanRepo, userID, date); err != nil {
+			return len(warmed), err
+		}
+		warmed[userID] = true
+	}
+	for _, userID := range nutritionUsers {
+		if ctx.Err() != nil {
+			return len(warmed), ctx.Err()
+		}
+		if _, err := cachedTodayMeals(ctx, s.cache, s.nutritionPlanRepo, userID, date); err != nil {
+			return len(warmed), err
+		}
+		warmed[userID] = true
+	}
+	return len(warmed), nil
+}
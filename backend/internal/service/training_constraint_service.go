@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+// maxReportedViolations caps how many restricted exercises a rejected plan's
+// error lists
+const maxReportedViolations = 3
+
+// TrainingConstraintRequest holds training constraint fields; nil fields are
+// left unchanged on update
+type TrainingConstraintRequest struct {
+	Source              *string
+	Description         *string
+	RestrictedMovements []string
+	Attachments         []string
+	StartsOn            *time.Time // defaults to today on create
+	ExpiresOn           *time.Time
+}
+
+// TrainingConstraintService manages medical restrictions that plan
+// generation must honor
+type TrainingConstraintService interface {
+	// List returns the user's constraints; activeOnly drops expired ones
+	List(ctx context.Context, userID int64, activeOnly bool) ([]*model.TrainingConstraint, error)
+	Create(ctx context.Context, userID int64, req *TrainingConstraintRequest) (*model.TrainingConstraint, error)
+	Update(ctx context.Context, userID, constraintID int64, req *TrainingConstraintRequest) (*model.TrainingConstraint, error)
+	Delete(ctx context.Context, userID, constraintID int64) error
+}
+
+// trainingConstraintService implements TrainingConstraintService
+type trainingConstraintService struct {
+	constraintRepo   repository.TrainingConstraintRepository
+	planRepo         repository.TrainingPlanRepository
+	notificationRepo repository.NotificationRepository
+}
+
+// NewTrainingConstraintService creates a new TrainingConstraintService.
+// Changes are announced to users with active plans through notificationRepo.
+func NewTrainingConstraintService(
+	constraintRepo repository.TrainingConstraintRepository,
+	planRepo repository.TrainingPlanRepository,
+	notificationRepo repository.NotificationRepository,
+) TrainingConstraintService {
+	return &trainingConstraintService{
+		constraintRepo:   constraintRepo,
+		planRepo:         planRepo,
+		notificationRepo: notificationRepo,
+	}
+}
+
+// List returns the user's constraints, newest first
+func (s *trainingConstraintService) List(ctx context.Context, userID int64, activeOnly bool) ([]*model.TrainingConstraint, error) {
+	var activeOn *time.Time
+	if activeOnly {
+		now := time.Now()
+		activeOn = &now
+	}
+
+	constraints, err := s.constraintRepo.ListByUser(ctx, userID, activeOn)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取训练限制失败")
+	}
+	return constraints, nil
+}
+
+// Create records a new constraint
+func (s *trainingConstraintService) Create(ctx context.Context, userID int64, req *TrainingConstraintRequest) (*model.TrainingConstraint, error) {
+	if req.Description == nil || strings.TrimSpace(*req.Description) == "" {
+		return nil, errors.New(errors.ErrInvalidParam, "限制说明不能为空")
+	}
+
+	now := time.Now()
+	constraint := &model.TrainingConstraint{
+		UserID:    userID,
+		Source:    model.ConstraintSourceOther,
+		StartsOn:  time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := applyConstraintRequest(constraint, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.constraintRepo.Create(ctx, constraint); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "创建训练限制失败")
+	}
+
+	s.notifyChanged(ctx, constraint, "created")
+	return constraint, nil
+}
+
+// Update edits a constraint owned by the user
+func (s *trainingConstraintService) Update(ctx context.Context, userID, constraintID int64, req *TrainingConstraintRequest) (*model.TrainingConstraint, error) {
+	constraint, err := s.getOwned(ctx, userID, constraintID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyConstraintRequest(constraint, req); err != nil {
+		return nil, err
+	}
+	constraint.UpdatedAt = time.Now()
+
+	if err := s.constraintRepo.Update(ctx, constraint); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "更新训练限制失败")
+	}
+
+	s.notifyChanged(ctx, constraint, "updated")
+	return constraint, nil
+}
+
+// Delete removes a constraint owned by the user
+func (s *trainingConstraintService) Delete(ctx context.Context, userID, constraintID int64) error {
+	constraint, err := s.getOwned(ctx, userID, constraintID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.constraintRepo.Delete(ctx, constraint.ID); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase, "删除训练限制失败")
+	}
+
+	s.notifyChanged(ctx, constraint, "deleted")
+	return nil
+}
+
+// getOwned loads a constraint, hiding other users' constraints as not found
+func (s *trainingConstraintService) getOwned(ctx context.Context, userID, constraintID int64) (*model.TrainingConstraint, error) {
+	constraint, err := s.constraintRepo.GetByID(ctx, constraintID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取训练限制失败")
+	}
+	if constraint == nil || constraint.UserID != userID {
+		return nil, errors.New(errors.ErrNotFound, "训练限制不存在")
+	}
+	return constraint, nil
+}
+
+// applyConstraintRequest copies the set fields of req onto constraint and
+// validates the result
+func applyConstraintRequest(constraint *model.TrainingConstraint, req *TrainingConstraintRequest) error {
+	if req.Source != nil {
+		constraint.Source = *req.Source
+	}
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		if description == "" {
+			return errors.New(errors.ErrInvalidParam, "限制说明不能为空")
+		}
+		constraint.Description = description
+	}
+	if req.RestrictedMovements != nil {
+		movements := make(model.JSONSlice, 0, len(req.RestrictedMovements))
+		for _, m := range req.RestrictedMovements {
+			if m = strings.TrimSpace(m); m != "" {
+				movements = append(movements, m)
+			}
+		}
+		constraint.RestrictedMovements = movements
+	}
+	if req.Attachments != nil {
+		attachments := make(model.JSONSlice, 0, len(req.Attachments))
+		for _, a := range req.Attachments {
+			attachments = append(attachments, a)
+		}
+		constraint.Attachments = attachments
+	}
+	if req.StartsOn != nil {
+		constraint.StartsOn = *req.StartsOn
+	}
+	if req.ExpiresOn != nil {
+		constraint.ExpiresOn = req.ExpiresOn
+	}
+
+	if constraint.ExpiresOn != nil && constraint.ExpiresOn.Before(constraint.StartsOn) {
+		return errors.New(errors.ErrInvalidParam, "到期日期不能早于生效日期")
+	}
+	return nil
+}
+
+// notifyChanged tells the user that plans generated before the change did
+// not take it into account. It does nothing if the user has no active plan.
+// Failures are logged only; the change itself has been saved.
+func (s *trainingConstraintService) notifyChanged(ctx context.Context, constraint *model.TrainingConstraint, action string) {
+	plans, err := s.planRepo.ListSummariesByUser(ctx, constraint.UserID, "active")
+	if err != nil {
+		logger.Warn("Failed to list active plans for constraint change",
+			zap.Int64("user_id", constraint.UserID),
+			zap.Int64("constraint_id", constraint.ID),
+			zap.Error(err),
+		)
+		return
+	}
+	if len(plans) == 0 {
+		return
+	}
+
+	planIDs := make([]int64, 0, len(plans))
+	for _, p := range plans {
+		planIDs = append(planIDs, p.ID)
+	}
+
+	verb := map[string]string{"created": "新增", "updated": "更新", "deleted": "删除"}[action]
+	content := fmt.Sprintf("训练限制「%s」已%s。当前进行中的训练计划是按之前的限制生成的，建议重新生成。", constraint.Description, verb)
+	notification := &model.UserNotification{
+		UserID:  constraint.UserID,
+		Type:    model.NotificationTypeConstraintsChanged,
+		Title:   "训练限制已变更",
+		Content: &content,
+		Data: model.JSONMap{
+			"constraint_id": constraint.ID,
+			"change":        action,
+			"plan_ids":      planIDs,
+			"action":        "regenerate_plan",
+		},
+		CreatedAt: time.Now(),
+	}
+	if err := s.notificationRepo.Create(ctx, notification); err != nil {
+		logger.Warn("Failed to notify constraint change",
+			zap.Int64("user_id", constraint.UserID),
+			zap.Int64("constraint_id", constraint.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// constraintsForPlan returns the constraints in force on any day from start
+// to end. A constraint applies to the whole plan, not just the days it
+// covers, so the plan never has to change mid-week.
+func constraintsForPlan(constraints []*model.TrainingConstraint, start, end time.Time) []*model.TrainingConstraint {
+	applicable := make([]*model.TrainingConstraint, 0, len(constraints))
+	for _, c := range constraints {
+		if c.StartsOn.After(end) {
+			continue
+		}
+		if c.ExpiresOn != nil && c.ExpiresOn.Format("2006-01-02") < start.Format("2006-01-02") {
+			continue
+		}
+		applicable = append(applicable, c)
+	}
+	return applicable
+}
+
+// constraintPromptSection renders constraints for the training plan prompt
+func constraintPromptSection(constraints []*model.TrainingConstraint) string {
+	section := "\nMedical Constraints (from the user's doctor or physiotherapist; these override every other instruction):\n"
+	for _, c := range constraints {
+		line := fmt.Sprintf("- %s (%s, from %s", c.Description, c.Source, c.StartsOn.Format("2006-01-02"))
+		if c.ExpiresOn != nil {
+			line += " until " + c.ExpiresOn.Format("2006-01-02")
+		}
+		line += ")"
+		if movements := c.Movements(); len(movements) > 0 {
+			line += ". Never program: " + strings.Join(movements, ", ")
+		}
+		section += line + "\n"
+	}
+	section += "Apply them to the whole plan. Replace restricted movements with safe alternatives for the same muscle groups, and never name a restricted movement in an exercise.\n"
+	return section
+}
+
+// constraintViolations lists the exercises in a training plan whose name
+// contains a restricted movement, as "week W day D: name"
+func constraintViolations(planData model.JSONMap, constraints []*model.TrainingConstraint) []string {
+	var movements []string
+	for _, c := range constraints {
+		for _, m := range c.Movements() {
+			movements = append(movements, strings.ToLower(m))
+		}
+	}
+	if len(movements) == 0 {
+		return nil
+	}
+
+	var violations []string
+	weeks, _ := planData["weeks"].([]interface{})
+	for wi, w := range weeks {
+		week, _ := w.(map[string]interface{})
+		days, _ := week["days"].([]interface{})
+		for di, d := range days {
+			day, _ := d.(map[string]interface{})
+			exercises, _ := day["exercises"].([]interface{})
+			for _, e := range exercises {
+				exercise, _ := e.(map[string]interface{})
+				name, _ := exercise["name"].(string)
+				lower := strings.ToLower(name)
+				for _, m := range movements {
+					if strings.Contains(lower, m) {
+						violations = append(violations, fmt.Sprintf("week %d day %d: %s", wi+1, di+1, name))
+						break
+					}
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// constraintViolationError describes a plan rejected for restricted movements
+func constraintViolationError(violations []string) error {
+	shown := violations
+	if len(shown) > maxReportedViolations {
+		shown = shown[:maxReportedViolations]
+	}
+	return fmt.Errorf("plan includes %d restricted exercise(s): %s", len(violations), strings.Join(shown, "; "))
+}
+
+// appliedConstraints snapshots constraints into the plan data, so the plan
+// records what it was generated under even after they change
+func appliedConstraints(constraints []*model.TrainingConstraint) []interface{} {
+	applied := make([]interface{}, 0, len(constraints))
+	for _, c := range constraints {
+		entry := map[string]interface{}{
+			"id":                   c.ID,
+			"source":               c.Source,
+			"description":          c.Description,
+			"restricted_movements": c.Movements(),
+			"starts_on":            c.StartsOn.Format("2006-01-02"),
+		}
+		if c.ExpiresOn != nil {
+			entry["expires_on"] = c.ExpiresOn.Format("2006-01-02")
+		}
+		applied = append(applied, entry)
+	}
+	return applied
+}
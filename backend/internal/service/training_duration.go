@@ -0,0 +1,76 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// Bounds for training session durations. Anything outside the realistic range
+// is still stored but flagged; sessions longer than a day are rejected.
+const (
+	minRealisticSessionMinutes = 5
+	maxRealisticSessionMinutes = 240
+	maxSessionMinutes          = 1440
+	// clockSkewAllowance tolerates devices whose clocks run slightly ahead
+	clockSkewAllowance = 5 * time.Minute
+)
+
+// applySessionDuration derives DurationMinutes from the session timestamps
+// when both are present, then flags durations that look implausible
+func applySessionDuration(record *model.TrainingRecord, now time.Time) error {
+	record.DurationFlag = nil
+
+	if (record.StartedAt == nil) != (record.EndedAt == nil) {
+		return errors.New(errors.ErrInvalidParam, "开始时间和结束时间需同时提供")
+	}
+
+	if record.StartedAt != nil {
+		started, ended := *record.StartedAt, *record.EndedAt
+		if !ended.After(started) {
+			return errors.New(errors.ErrInvalidParam, "结束时间必须晚于开始时间")
+		}
+		if ended.After(now.Add(clockSkewAllowance)) {
+			return errors.New(errors.ErrInvalidParam, "结束时间不能是未来时间")
+		}
+		localStart := started.In(time.Local)
+		localWorkout := record.WorkoutDate.In(time.Local)
+		if localStart.Year() != localWorkout.Year() || localStart.YearDay() != localWorkout.YearDay() {
+			return errors.New(errors.ErrInvalidParam, "开始时间与训练日期不一致")
+		}
+
+		derived := int(math.Round(ended.Sub(started).Minutes()))
+		if derived > maxSessionMinutes {
+			return errors.New(errors.ErrInvalidParam, "训练时长不能超过24小时")
+		}
+
+		if record.DurationMinutes != nil && durationsDisagree(*record.DurationMinutes, derived) {
+			setDurationFlag(record, model.DurationFlagMismatch)
+		}
+		record.DurationMinutes = &derived
+	}
+
+	if record.DurationFlag == nil && record.DurationMinutes != nil {
+		switch d := *record.DurationMinutes; {
+		case d < minRealisticSessionMinutes:
+			setDurationFlag(record, model.DurationFlagTooShort)
+		case d > maxRealisticSessionMinutes:
+			setDurationFlag(record, model.DurationFlagTooLong)
+		}
+	}
+
+	return nil
+}
+
+// durationsDisagree reports whether an entered duration is off from the
+// measured one by more than 10 minutes and 20%
+func durationsDisagree(entered, measured int) bool {
+	diff := math.Abs(float64(entered - measured))
+	return diff > 10 && diff > 0.2*float64(measured)
+}
+
+func setDurationFlag(record *model.TrainingRecord, flag string) {
+	record.DurationFlag = &flag
+}
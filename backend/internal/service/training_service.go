@@ -2,13 +2,21 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/ai-fitness-planner/backend/internal/errors"
 	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"github.com/ai-fitness-planner/backend/internal/pkg/taskqueue"
 	"github.com/ai-fitness-planner/backend/internal/repository"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 // TrainingService defines the interface for training operations
@@ -17,14 +25,89 @@ type TrainingService interface {
 	GeneratePlan(ctx context.Context, userID int64, req *GeneratePlanRequest) (*TaskResponse, error)
 	// GetPlanStatus retrieves the status of a plan generation task
 	GetPlanStatus(ctx context.Context, taskID string) (*TaskStatus, error)
+	// WatchPlanTask returns a snapshot of the user's generation task and a
+	// channel that is closed on its next change
+	WatchPlanTask(ctx context.Context, userID int64, taskID string) (*TaskStatus, <-chan struct{}, error)
+	// ListTasks retrieves the user's most recent plan generation tasks,
+	// newest first
+	ListTasks(ctx context.Context, userID int64) ([]*TaskStatus, error)
+	// SweepExpiredTasks forgets finished tasks past their retention and
+	// returns how many were removed
+	SweepExpiredTasks(ctx context.Context) (int, error)
+	// RetryTask queues the user's failed generation task again with the
+	// parameters it was submitted with
+	RetryTask(ctx context.Context, userID int64, taskID string) (*TaskResponse, error)
+	// FailQueuedTask marks a task taken off the queue by an operator as
+	// failed with reason
+	FailQueuedTask(task *taskqueue.Task, reason string)
+	// ListTaskHistory retrieves the user's most recently finished
+	// generation tasks from the task history, newest first
+	ListTaskHistory(ctx context.Context, userID int64) ([]*model.GenerationTask, error)
 	// ListPlans retrieves training plans for a user with optional status filter
 	ListPlans(ctx context.Context, userID int64, status string) ([]*model.TrainingPlan, error)
 	// GetPlanDetail retrieves a specific training plan
 	GetPlanDetail(ctx context.Context, planID int64, userID int64) (*model.TrainingPlan, error)
+	// GetPlanSummary retrieves a specific training plan without its plan data
+	GetPlanSummary(ctx context.Context, planID int64, userID int64) (*model.TrainingPlan, error)
+	// UpdatePlan renames a plan or changes its status, following the same
+	// transitions as PausePlan, ResumePlan and CompletePlan
+	UpdatePlan(ctx context.Context, userID, planID int64, req *UpdatePlanRequest) (*model.TrainingPlan, error)
+	// ClonePlan copies a plan to a new start date, with every day moved by
+	// the same number of days, without another AI generation
+	ClonePlan(ctx context.Context, userID, planID int64, req *ClonePlanRequest) (*model.TrainingPlan, error)
+	// DeletePlan deletes a plan. Its training records are kept without the
+	// plan link, or deleted as well when deleteRecords is set.
+	DeletePlan(ctx context.Context, userID, planID int64, deleteRecords bool) error
+	// PausePlan pauses an active plan, which then has no scheduled days
+	PausePlan(ctx context.Context, userID, planID int64) (*model.TrainingPlan, error)
+	// ResumePlan resumes a paused plan, shifting its remaining days by the
+	// length of the pause
+	ResumePlan(ctx context.Context, userID, planID int64) (*model.TrainingPlan, error)
+	// CompletePlan marks an active plan as completed, recording when
+	CompletePlan(ctx context.Context, userID, planID int64) (*model.TrainingPlan, error)
 	// GetTodayTraining retrieves today's training schedule
 	GetTodayTraining(ctx context.Context, userID int64) (*model.DayPlan, error)
-	// RecordTraining records a training session with validation
-	RecordTraining(ctx context.Context, userID int64, record *model.TrainingRecord) error
+	// GetRestIntervals retrieves the user's default rests, which replace
+	// the plan's rests when its days are shown
+	GetRestIntervals(ctx context.Context, userID int64) (model.RestIntervals, error)
+	// CompletePlanDay marks a day of a plan as completed, optionally linking
+	// the training record logged for it
+	CompletePlanDay(ctx context.Context, userID, planID int64, date time.Time, recordID *int64) (*model.PlanDayCompletion, error)
+	// SubstituteExercise replaces an exercise of a plan day with one for the
+	// same muscles, from the exercise library or the AI
+	SubstituteExercise(ctx context.Context, userID, planID int64, date time.Time, index int, req *SubstituteExerciseRequest) (*ExerciseSubstitution, error)
+	// RecordTraining records a training session with validation. A record that
+	// looks like a resubmission of an existing one is rejected with a conflict
+	// unless allowDuplicate is set; a repeated idempotency key returns the
+	// original record in place.
+	RecordTraining(ctx context.Context, userID int64, record *model.TrainingRecord, allowDuplicate bool) error
+	// ComparePlans compares volume, frequency, intensity and exercises of two
+	// of the user's plans
+	ComparePlans(ctx context.Context, userID, planAID, planBID int64) (*PlanComparison, error)
+	// AuditPlanProgression checks that a plan gets harder week by week
+	AuditPlanProgression(ctx context.Context, userID, planID int64) (*ProgressionAudit, error)
+	// GetWeekSummary summarizes one week of a plan, day by day, with the
+	// completion of each day computed from the user's training records
+	GetWeekSummary(ctx context.Context, userID, planID int64, week int) (*WeekSummary, error)
+	// RegenerateWeek regenerates one week of a plan with the AI, keeping the
+	// plan data it replaces as the plan's previous version
+	RegenerateWeek(ctx context.Context, userID, planID int64, week int, req *RegenerateWeekRequest) (*model.TrainingPlan, error)
+	// ListPlanVersions lists the earlier versions of a plan, newest first
+	ListPlanVersions(ctx context.Context, userID, planID int64) ([]*model.TrainingPlanVersion, error)
+	// GetPlanVersion returns an earlier version of a plan with its data
+	GetPlanVersion(ctx context.Context, userID, planID int64, version int) (*model.TrainingPlanVersion, error)
+	// GetSchedule returns the days of a plan in a date range
+	GetSchedule(ctx context.Context, userID, planID int64, start, end time.Time) ([]*model.DayPlan, error)
+	// ExportCalendar renders a plan's training days as an iCalendar file
+	ExportCalendar(ctx context.Context, userID, planID int64) ([]byte, error)
+	// ExportPDF renders a plan as a printable, watermarked PDF
+	ExportPDF(ctx context.Context, userID, planID int64, opts PDFExportOptions) ([]byte, error)
+	// AdjustPlan generates a revised version of a plan from the user's
+	// records and feedback asynchronously and returns a task ID
+	AdjustPlan(ctx context.Context, userID, planID int64, req *AdjustPlanRequest) (*TaskResponse, error)
+	// GenerateBlock generates a plan as the next block of a macrocycle
+	// asynchronously and returns a task ID
+	GenerateBlock(ctx context.Context, userID int64, req *GeneratePlanRequest, block *MacrocycleBlock) (*TaskResponse, error)
 }
 
 // GeneratePlanRequest holds parameters for plan generation request
@@ -34,6 +117,18 @@ type GeneratePlanRequest struct {
 	Goal            string `json:"goal" validate:"required,max=100"`
 	DifficultyLevel string `json:"difficulty_level" validate:"required,oneof=easy medium hard extreme"`
 	AIAPIID         *int64 `json:"ai_api_id"` // Optional, uses default if not provided
+	// IncludeDeload makes every DeloadEvery-th week a deload week, every
+	// fourth when DeloadEvery is 0
+	IncludeDeload      bool   `json:"include_deload,omitempty"`
+	DeloadEvery        int    `json:"deload_every,omitempty" validate:"omitempty,min=2,max=12"`
+	PeriodizationStyle string `json:"periodization_style,omitempty" validate:"omitempty,oneof=linear undulating block"`
+}
+
+// UpdatePlanRequest holds the editable fields of a training plan; nil
+// fields are left unchanged
+type UpdatePlanRequest struct {
+	PlanName *string `json:"plan_name" validate:"omitempty,min=1,max=200"`
+	Status   *string `json:"status" validate:"omitempty,oneof=active paused inactive completed"`
 }
 
 // TaskResponse represents the response for async task creation
@@ -41,20 +136,37 @@ type TaskResponse struct {
 	TaskID  string `json:"task_id"`
 	Status  string `json:"status"`
 	Message string `json:"message"`
+	// Existing is set when the user already had a task pending, which is
+	// returned in place of a new one
+	Existing bool `json:"existing,omitempty"`
 }
 
 // TaskStatus represents the status of an async task
 type TaskStatus struct {
-	TaskID    string              `json:"task_id"`
-	Status    string              `json:"status"` // pending, processing, completed, failed
-	Progress  int                 `json:"progress"`
-	Message   string              `json:"message,omitempty"`
-	Error     string              `json:"error,omitempty"`
-	Result    *model.TrainingPlan `json:"result,omitempty"`
-	CreatedAt time.Time           `json:"created_at"`
-	UpdatedAt time.Time           `json:"updated_at"`
+	TaskID   string              `json:"task_id"`
+	Status   string              `json:"status"` // pending, processing, completed, failed
+	Progress int                 `json:"progress"`
+	Message  string              `json:"message,omitempty"`
+	Error    string              `json:"error,omitempty"`
+	Result   *model.TrainingPlan `json:"result,omitempty"`
+	// Cooldown is set while generation waits for a throttling provider
+	Cooldown  *ProviderCooldown `json:"cooldown,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	// ExpiresAt is when a finished task is forgotten; nil while it runs
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// UserID owns the task; Output is the plan text streamed so far
+	UserID  int64         `json:"-"`
+	Output  string        `json:"-"`
+	changed chan struct{} // closed and replaced on every update
+	queued  *queuedTask
 }
 
+// duplicateDurationToleranceMinutes is how far apart two durations may be for
+// records on the same date and type to count as a double submission
+const duplicateDurationToleranceMinutes = 5
+
 // Task status constants
 const (
 	TaskStatusPending    = "pending"
@@ -63,6 +175,24 @@ const (
 	TaskStatusFailed     = "failed"
 )
 
+// recentTaskLimit is how many of a user's generation tasks are listed
+const recentTaskLimit = 20
+
+// taskExpiry returns when a task that reached status at now is forgotten,
+// or nil while it may still change or when retention is disabled
+func taskExpiry(status string, now time.Time, retention time.Duration) *time.Time {
+	if retention <= 0 || (status != TaskStatusCompleted && status != TaskStatusFailed) {
+		return nil
+	}
+	expiresAt := now.Add(retention)
+	return &expiresAt
+}
+
+// taskExpired reports whether a task's retention has run out at now
+func taskExpired(expiresAt *time.Time, now time.Time) bool {
+	return expiresAt != nil && !now.Before(*expiresAt)
+}
+
 // trainingService implements TrainingService interface
 type trainingService struct {
 	planRepo        repository.TrainingPlanRepository
@@ -71,11 +201,23 @@ type trainingService struct {
 	assessmentRepo  repository.AssessmentRepository
 	bodyDataRepo    repository.BodyDataRepository
 	fitnessGoalRepo repository.FitnessGoalRepository
+	checkInRepo     repository.CheckInRepository
+	strengthService StrengthProfileService
+	equipmentRepo   repository.EquipmentProfileRepository
+	constraintRepo  repository.TrainingConstraintRepository
+	userRepo        repository.UserRepository
 	aiService       AIService
-
-	// In-memory task storage (in production, use Redis)
-	tasks      map[string]*TaskStatus
-	tasksMutex sync.RWMutex
+	queue           *taskqueue.Queue
+	generationLock  GenerationLock
+	taskHistoryRepo repository.GenerationTaskRepository
+	todayCache      TodayCache
+	responseArchive ResponseArchiveService
+
+	// In-memory task storage (in production, use Redis); finished tasks
+	// are kept for taskRetention and in the task history
+	tasks         map[string]*TaskStatus
+	tasksMutex    sync.RWMutex
+	taskRetention time.Duration
 }
 
 // NewTrainingService creates a new instance of TrainingService
@@ -86,87 +228,184 @@ func NewTrainingService(
 	assessmentRepo repository.AssessmentRepository,
 	bodyDataRepo repository.BodyDataRepository,
 	fitnessGoalRepo repository.FitnessGoalRepository,
+	checkInRepo repository.CheckInRepository,
+	strengthService StrengthProfileService,
+	equipmentRepo repository.EquipmentProfileRepository,
+	constraintRepo repository.TrainingConstraintRepository,
+	userRepo repository.UserRepository,
 	aiService AIService,
+	queue *taskqueue.Queue,
+	generationLock GenerationLock,
+	taskHistoryRepo repository.GenerationTaskRepository,
+	taskRetention time.Duration,
+	todayCache TodayCache,
+	responseArchive ResponseArchiveService,
 ) TrainingService {
-	return &trainingService{
+	s := &trainingService{
 		planRepo:        planRepo,
 		recordRepo:      recordRepo,
 		aiAPIRepo:       aiAPIRepo,
 		assessmentRepo:  assessmentRepo,
 		bodyDataRepo:    bodyDataRepo,
 		fitnessGoalRepo: fitnessGoalRepo,
+		checkInRepo:     checkInRepo,
+		strengthService: strengthService,
+		equipmentRepo:   equipmentRepo,
+		constraintRepo:  constraintRepo,
+		userRepo:        userRepo,
 		aiService:       aiService,
+		queue:           queue,
+		generationLock:  generationLock,
+		taskHistoryRepo: taskHistoryRepo,
+		todayCache:      todayCache,
+		responseArchive: responseArchive,
 		tasks:           make(map[string]*TaskStatus),
+		taskRetention:   taskRetention,
 	}
+	queue.Handle(taskTypeGenerateTrainingPlan, s.runGeneratePlan)
+	queue.Handle(taskTypeAdjustTrainingPlan, s.runAdjustPlan)
+	return s
 }
 
-// GeneratePlan generates a training plan asynchronously
+// GeneratePlan generates a training plan asynchronously. A user without a
+// default AI API gets a plan built from training templates, queued with no
+// AI API.
 // Requirements: 5.1, 5.2, 5.4
 func (s *trainingService) GeneratePlan(ctx context.Context, userID int64, req *GeneratePlanRequest) (*TaskResponse, error) {
-	// Determine which AI API to use
-	var aiAPIID int64
-	if req.AIAPIID != nil {
-		aiAPIID = *req.AIAPIID
-		// Verify the API exists and belongs to the user
-		api, err := s.aiAPIRepo.GetByID(ctx, aiAPIID)
-		if err != nil {
-			return nil, errors.Wrap(err, errors.ErrDatabase, "获取AI API失败")
-		}
-		if api == nil || api.UserID != userID {
-			return nil, errors.New(errors.ErrNotFound, "AI API不存在")
-		}
-	} else {
-		// Use default AI API
-		defaultAPI, err := s.aiAPIRepo.GetDefaultByUser(ctx, userID)
+	message := "训练计划生成任务已创建"
+	aiAPIID, err := resolveAIAPIID(ctx, s.aiAPIRepo, userID, req.AIAPIID)
+	if err == errors.ErrNoDefaultAIAPI {
+		aiAPIID, message = 0, "未配置AI API，将按训练模板生成计划"
+	} else if err != nil {
+		return nil, err
+	}
+
+	return s.enqueueTask(ctx, userID, taskTypeGenerateTrainingPlan, message, func(taskID string) interface{} {
+		return &generateTrainingTask{TaskID: taskID, UserID: userID, AIAPIID: aiAPIID, Request: req}
+	})
+}
+
+// enqueueTask registers a pending generation task and queues the payload
+// built for its ID, responding with message. While the user has another
+// task of the same kind pending, that task is returned instead.
+func (s *trainingService) enqueueTask(ctx context.Context, userID int64, taskType, message string, payload func(taskID string) interface{}) (*TaskResponse, error) {
+	taskID := uuid.New().String()
+	raw, err := json.Marshal(payload(taskID))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "创建生成任务失败")
+	}
+
+	if s.generationLock != nil {
+		holder, err := s.generationLock.Acquire(ctx, generationLockTraining, userID, taskID)
 		if err != nil {
-			return nil, errors.Wrap(err, errors.ErrDatabase, "获取默认AI API失败")
+			return nil, errors.Wrap(err, errors.ErrCache, "创建生成任务失败")
 		}
-		if defaultAPI == nil {
-			return nil, errors.ErrNoDefaultAIAPI
+		if holder != taskID {
+			return s.existingTaskResponse(holder), nil
 		}
-		aiAPIID = defaultAPI.ID
 	}
+	s.registerTask(taskID, userID, time.Now(), &queuedTask{taskType: taskType, payload: raw})
+
+	if err := s.queue.Enqueue(ctx, taskType, taskID, raw); err != nil {
+		s.tasksMutex.Lock()
+		delete(s.tasks, taskID)
+		s.tasksMutex.Unlock()
+		releaseFinishedTask(s.generationLock, generationLockTraining, userID, taskID)
+		return nil, errors.Wrap(err, errors.ErrCache, "创建生成任务失败")
+	}
+	return &TaskResponse{
+		TaskID:  taskID,
+		Status:  TaskStatusPending,
+		Message: message,
+	}, nil
+}
+
+// existingTaskResponse describes the pending task a new request of the
+// user's was turned down for. The task may run on another instance, where
+// its progress is not known here.
+func (s *trainingService) existingTaskResponse(taskID string) *TaskResponse {
+	status := TaskStatusPending
+	s.tasksMutex.RLock()
+	if task, exists := s.tasks[taskID]; exists {
+		status = task.Status
+	}
+	s.tasksMutex.RUnlock()
 
-	// Create task ID
-	taskID := uuid.New().String()
+	return &TaskResponse{
+		TaskID:   taskID,
+		Status:   status,
+		Message:  "已有生成任务正在进行，请等待其完成",
+		Existing: true,
+	}
+}
 
-	// Initialize task status
-	now := time.Now()
-	task := &TaskStatus{
+// registerTask records a pending generation task. A task that is already
+// registered is left as it is; one that is not, because it was queued
+// before this process started, is registered again when it runs.
+func (s *trainingService) registerTask(taskID string, userID int64, createdAt time.Time, queued *queuedTask) {
+	s.tasksMutex.Lock()
+	defer s.tasksMutex.Unlock()
+
+	if _, exists := s.tasks[taskID]; exists {
+		return
+	}
+	s.tasks[taskID] = &TaskStatus{
 		TaskID:    taskID,
 		Status:    TaskStatusPending,
 		Progress:  0,
 		Message:   "任务已创建，等待处理",
-		CreatedAt: now,
-		UpdatedAt: now,
+		CreatedAt: createdAt,
+		UpdatedAt: time.Now(),
+		UserID:    userID,
+		changed:   make(chan struct{}),
+		queued:    queued,
 	}
+}
 
-	s.tasksMutex.Lock()
-	s.tasks[taskID] = task
-	s.tasksMutex.Unlock()
-
-	// Start async generation
-	go s.processGeneratePlan(userID, req, aiAPIID, taskID)
+// runGeneratePlan runs a queued plan generation
+func (s *trainingService) runGeneratePlan(ctx context.Context, task *taskqueue.Task) error {
+	var payload generateTrainingTask
+	if err := task.Decode(&payload); err != nil {
+		return fmt.Errorf("invalid training generation task: %v: %w", err, taskqueue.ErrSkipRetry)
+	}
+	s.registerTask(payload.TaskID, payload.UserID, task.EnqueuedAt, queuedFrom(task))
 
-	return &TaskResponse{
-		TaskID:  taskID,
-		Status:  TaskStatusPending,
-		Message: "训练计划生成任务已创建",
-	}, nil
+	err := s.processGeneratePlan(ctx, payload.UserID, payload.Request, payload.AIAPIID, payload.TaskID, payload.Block)
+	return s.finishAttempt(ctx, task, payload.TaskID, err)
 }
 
-// processGeneratePlan handles the async plan generation
-func (s *trainingService) processGeneratePlan(userID int64, req *GeneratePlanRequest, aiAPIID int64, taskID string) {
-	ctx := context.Background()
+// finishAttempt records a failed attempt on its task and returns the error
+// for the queue
+func (s *trainingService) finishAttempt(ctx context.Context, task *taskqueue.Task, taskID string, err error) error {
+	if err == nil {
+		return nil
+	}
+	status, message, queueErr := attemptOutcome(ctx, task, err)
+	switch status {
+	case TaskStatusFailed:
+		s.updateTaskStatus(taskID, TaskStatusFailed, 0, "", err.Error(), nil)
+	case TaskStatusPending:
+		// The next attempt streams the plan text from the start
+		s.tasksMutex.Lock()
+		if t, exists := s.tasks[taskID]; exists {
+			t.Output = ""
+		}
+		s.tasksMutex.Unlock()
+		s.updateTaskStatus(taskID, TaskStatusPending, 0, message, err.Error(), nil)
+	}
+	return queueErr
+}
 
+// processGeneratePlan generates and saves a plan, updating the task as it
+// goes. With block set the plan is generated as that macrocycle block.
+func (s *trainingService) processGeneratePlan(ctx context.Context, userID int64, req *GeneratePlanRequest, aiAPIID int64, taskID string, block *MacrocycleBlock) error {
 	// Update task status to processing
 	s.updateTaskStatus(taskID, TaskStatusProcessing, 10, "正在收集用户数据...", "", nil)
 
 	// Get user's latest assessment
 	assessment, err := s.assessmentRepo.GetLatest(ctx, userID)
 	if err != nil {
-		s.updateTaskStatus(taskID, TaskStatusFailed, 0, "", "获取用户评估数据失败: "+err.Error(), nil)
-		return
+		return fmt.Errorf("获取用户评估数据失败: %w", err)
 	}
 
 	s.updateTaskStatus(taskID, TaskStatusProcessing, 20, "正在获取身体数据...", "", nil)
@@ -174,8 +413,7 @@ func (s *trainingService) processGeneratePlan(userID int64, req *GeneratePlanReq
 	// Get user's latest body data
 	bodyData, err := s.bodyDataRepo.GetLatestByUserID(ctx, userID)
 	if err != nil {
-		s.updateTaskStatus(taskID, TaskStatusFailed, 0, "", "获取身体数据失败: "+err.Error(), nil)
-		return
+		return fmt.Errorf("获取身体数据失败: %w", err)
 	}
 
 	s.updateTaskStatus(taskID, TaskStatusProcessing, 30, "正在获取健身目标...", "", nil)
@@ -183,59 +421,216 @@ func (s *trainingService) processGeneratePlan(userID int64, req *GeneratePlanReq
 	// Get user's fitness goals
 	fitnessGoals, err := s.fitnessGoalRepo.GetByUserID(ctx, userID, "active")
 	if err != nil {
-		s.updateTaskStatus(taskID, TaskStatusFailed, 0, "", "获取健身目标失败: "+err.Error(), nil)
-		return
+		return fmt.Errorf("获取健身目标失败: %w", err)
+	}
+
+	// Get user's latest weekly check-in; only a recent one informs the plan
+	latestCheckIn, err := s.checkInRepo.GetLatestByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("获取每周签到失败: %w", err)
+	}
+
+	// Get user's strength profile so the plan can prescribe concrete loads
+	strengthProfile, err := s.strengthService.GetProfile(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("获取力量档案失败: %w", err)
+	}
+
+	// Get user's equipment profiles; these supersede the assessment's equipment list
+	equipmentProfiles, err := s.equipmentRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("获取器材清单失败: %w", err)
+	}
+
+	// Get user's medical constraints in force at any point during the plan
+	now := time.Now()
+	constraints, err := s.constraintRepo.ListByUser(ctx, userID, &now)
+	if err != nil {
+		return fmt.Errorf("获取训练限制失败: %w", err)
 	}
+	constraints = constraintsForPlan(constraints, now, now.AddDate(0, 0, req.DurationWeeks*7))
 
-	s.updateTaskStatus(taskID, TaskStatusProcessing, 50, "正在调用AI生成训练计划...", "", nil)
+	// Get the days the user cannot train, which the plan leaves for rest
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("获取用户信息失败: %w", err)
+	}
+	busy := userBusySchedule(user)
 
 	// Build AI params
+	var aiResponse string
 	params := &TrainingPlanParams{
-		UserID:          userID,
-		PlanName:        req.PlanName,
-		DurationWeeks:   req.DurationWeeks,
-		Goal:            req.Goal,
-		DifficultyLevel: req.DifficultyLevel,
-		AIAPIID:         aiAPIID,
-		Assessment:      assessment,
-		BodyData:        bodyData,
-		FitnessGoals:    fitnessGoals,
-	}
-
-	// Generate plan using AI service
-	plan, err := s.aiService.GenerateTrainingPlan(ctx, params)
-	if err != nil {
-		s.updateTaskStatus(taskID, TaskStatusFailed, 0, "", "AI生成计划失败: "+err.Error(), nil)
-		return
+		UserID:            userID,
+		PlanName:          req.PlanName,
+		DurationWeeks:     req.DurationWeeks,
+		Goal:              req.Goal,
+		DifficultyLevel:   req.DifficultyLevel,
+		AIAPIID:           aiAPIID,
+		Assessment:        assessment,
+		BodyData:          bodyData,
+		FitnessGoals:      fitnessGoals,
+		LatestCheckIn:     recentCheckIn(latestCheckIn, time.Now()),
+		StrengthProfile:   strengthProfile,
+		EquipmentProfiles: equipmentProfiles,
+		Constraints:       constraints,
+		BusySchedule:      busy,
+		Periodization:     newPeriodization(req),
+		Block:             block,
+		OnChunk: func(chunk string) {
+			s.appendTaskOutput(taskID, chunk)
+		},
+		OnRetry: func() {
+			s.resetTaskOutput(taskID)
+		},
+		OnCooldown: func(cooldown *ProviderCooldown) {
+			s.setTaskCooldown(taskID, cooldown)
+		},
+		OnResponse: func(response string) {
+			aiResponse = response
+		},
+	}
+
+	var plan *model.TrainingPlan
+	if aiAPIID == 0 {
+		s.updateTaskStatus(taskID, TaskStatusProcessing, 50, "正在按训练模板生成训练计划...", "", nil)
+		plan = generateRuleBasedPlan(params, time.Now())
+	} else {
+		s.updateTaskStatus(taskID, TaskStatusProcessing, 50, "正在调用AI生成训练计划...", "", nil)
+
+		// Generate plan using AI service
+		plan, err = s.aiService.GenerateTrainingPlan(ctx, params)
+		if err != nil {
+			return fmt.Errorf("AI生成计划失败: %w", err)
+		}
 	}
 
 	s.updateTaskStatus(taskID, TaskStatusProcessing, 80, "正在保存训练计划...", "", nil)
+	enforceBusyDays(plan.PlanData, busy, dayStart(time.Now()))
+	overlaySafetyNotes(plan.PlanData)
+	normalizeExerciseGroups(plan.PlanData)
+	if assessment != nil {
+		fitPlanToTime(plan.PlanData, assessment.DailyAvailableMinutes)
+	}
+	message := recordProgressionAudit(plan, "训练计划生成完成")
 
 	// Save the plan to database
 	if err := s.planRepo.Create(ctx, plan); err != nil {
-		s.updateTaskStatus(taskID, TaskStatusFailed, 0, "", "保存计划失败: "+err.Error(), nil)
-		return
+		return fmt.Errorf("保存计划失败: %w", err)
+	}
+	publishTrainingPlanCreated(ctx, plan, planSourceGenerated, nil)
+	s.archiveResponse(taskID, taskTypeGenerateTrainingPlan, plan, aiResponse)
+	if block != nil && block.Previous != nil {
+		s.completeBlock(ctx, block.Previous.Plan)
 	}
 
 	// Update task status to completed
-	s.updateTaskStatus(taskID, TaskStatusCompleted, 100, "训练计划生成完成", "", plan)
+	s.updateTaskStatus(taskID, TaskStatusCompleted, 100, message, "", plan)
+	return nil
 }
 
-// updateTaskStatus updates the status of a task
+// archiveResponse archives the AI response a saved plan was parsed from;
+// template plans have none
+func (s *trainingService) archiveResponse(taskID, taskType string, plan *model.TrainingPlan, response string) {
+	if s.responseArchive == nil || plan.AIAPIID == nil {
+		return
+	}
+	s.responseArchive.Archive(taskID, taskType, plan.UserID, plan.ID, *plan.AIAPIID, response)
+}
+
+// updateTaskStatus updates the status of a task, recording it in the task
+// history once it has finished
 func (s *trainingService) updateTaskStatus(taskID, status string, progress int, message, errMsg string, result *model.TrainingPlan) {
+	s.tasksMutex.Lock()
+	task, exists := s.tasks[taskID]
+	if !exists {
+		s.tasksMutex.Unlock()
+		return
+	}
+	task.Status = status
+	task.Progress = progress
+	task.Message = message
+	task.Error = errMsg
+	task.Result = result
+	task.Cooldown = nil
+	task.UpdatedAt = time.Now()
+	task.ExpiresAt = taskExpiry(status, task.UpdatedAt, s.taskRetention)
+	notifyTaskChanged(task)
+
+	var planID *int64
+	if result != nil {
+		planID = &result.ID
+	}
+	record := newTaskHistory(taskID, task.UserID, task.queued, status, errMsg, planID, task.CreatedAt, task.UpdatedAt)
+	s.tasksMutex.Unlock()
+
+	if record != nil {
+		saveTaskHistory(s.taskHistoryRepo, record)
+		releaseFinishedTask(s.generationLock, generationLockTraining, record.UserID, taskID)
+	}
+}
+
+// setTaskCooldown records that a task is waiting for a throttling provider,
+// or with nil that it has resumed
+func (s *trainingService) setTaskCooldown(taskID string, cooldown *ProviderCooldown) {
 	s.tasksMutex.Lock()
 	defer s.tasksMutex.Unlock()
 
 	if task, exists := s.tasks[taskID]; exists {
-		task.Status = status
-		task.Progress = progress
-		task.Message = message
-		task.Error = errMsg
-		task.Result = result
+		task.Cooldown = cooldown
+		task.Message = cooldownMessage(cooldown)
 		task.UpdatedAt = time.Now()
+		notifyTaskChanged(task)
 	}
 }
 
+// cooldownMessage is the task message while waiting for a provider
+func cooldownMessage(cooldown *ProviderCooldown) string {
+	if cooldown == nil {
+		return "正在重新调用AI..."
+	}
+	seconds := int(math.Ceil(time.Until(cooldown.Until).Seconds()))
+	return fmt.Sprintf("AI服务商(%s)请求过于频繁，约%d秒后继续", cooldown.Provider, seconds)
+}
+
+// streamProgressSpan is how much progress the streamed completion may add on
+// top of the 50% reached when the AI call starts
+const streamProgressSpan = 29
+
+// appendTaskOutput adds streamed plan text to a task. The final length is
+// unknown, so progress approaches 79% asymptotically as text arrives.
+func (s *trainingService) appendTaskOutput(taskID, chunk string) {
+	s.tasksMutex.Lock()
+	defer s.tasksMutex.Unlock()
+
+	if task, exists := s.tasks[taskID]; exists {
+		task.Output += chunk
+		n := len([]rune(task.Output))
+		task.Progress = 50 + streamProgressSpan*n/(n+4000)
+		task.UpdatedAt = time.Now()
+		notifyTaskChanged(task)
+	}
+}
+
+// resetTaskOutput discards streamed text before a retry
+func (s *trainingService) resetTaskOutput(taskID string) {
+	s.tasksMutex.Lock()
+	defer s.tasksMutex.Unlock()
+
+	if task, exists := s.tasks[taskID]; exists {
+		task.Output = ""
+		task.Progress = 50
+		task.Message = "AI响应无效，正在重试..."
+		task.UpdatedAt = time.Now()
+		notifyTaskChanged(task)
+	}
+}
+
+// notifyTaskChanged wakes watchers of a task; callers hold tasksMutex
+func notifyTaskChanged(task *TaskStatus) {
+	close(task.changed)
+	task.changed = make(chan struct{})
+}
+
 // GetPlanStatus retrieves the status of a plan generation task
 func (s *trainingService) GetPlanStatus(ctx context.Context, taskID string) (*TaskStatus, error) {
 	s.tasksMutex.RLock()
@@ -249,10 +644,133 @@ func (s *trainingService) GetPlanStatus(ctx context.Context, taskID string) (*Ta
 	return task, nil
 }
 
+// WatchPlanTask returns a copy of the task so callers can read it without
+// holding the lock, along with the channel for its next change
+func (s *trainingService) WatchPlanTask(ctx context.Context, userID int64, taskID string) (*TaskStatus, <-chan struct{}, error) {
+	s.tasksMutex.RLock()
+	defer s.tasksMutex.RUnlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists || task.UserID != userID {
+		return nil, nil, errors.New(errors.ErrNotFound, "任务不存在")
+	}
+
+	snapshot := *task
+	return &snapshot, task.changed, nil
+}
+
+// ListTasks returns copies of the user's tasks so callers can read them
+// without holding the lock
+func (s *trainingService) ListTasks(ctx context.Context, userID int64) ([]*TaskStatus, error) {
+	s.tasksMutex.RLock()
+	defer s.tasksMutex.RUnlock()
+
+	tasks := make([]*TaskStatus, 0)
+	for _, task := range s.tasks {
+		if task.UserID != userID {
+			continue
+		}
+		snapshot := *task
+		tasks = append(tasks, &snapshot)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
+	})
+	if len(tasks) > recentTaskLimit {
+		tasks = tasks[:recentTaskLimit]
+	}
+	return tasks, nil
+}
+
+// RetryTask queues a failed task again under the same ID, so clients
+// watching the task see it start over. Attempts are counted afresh.
+func (s *trainingService) RetryTask(ctx context.Context, userID int64, taskID string) (*TaskResponse, error) {
+	s.tasksMutex.Lock()
+	task, exists := s.tasks[taskID]
+	if !exists || task.UserID != userID {
+		s.tasksMutex.Unlock()
+		return nil, errors.New(errors.ErrNotFound, "任务不存在")
+	}
+	if task.Status != TaskStatusFailed {
+		s.tasksMutex.Unlock()
+		return nil, errors.New(errors.ErrConflict, "只能重试失败的任务")
+	}
+	// Claim the task so that a concurrent retry is refused
+	queued, lastErr := task.queued, task.Error
+	task.Status = TaskStatusPending
+	task.Output = ""
+	s.tasksMutex.Unlock()
+
+	// The retry waits its turn like a new request would
+	if s.generationLock != nil {
+		holder, err := s.generationLock.Acquire(ctx, generationLockTraining, userID, taskID)
+		if err != nil || holder != taskID {
+			s.tasksMutex.Lock()
+			task.Status = TaskStatusFailed
+			s.tasksMutex.Unlock()
+			if err != nil {
+				return nil, errors.Wrap(err, errors.ErrCache, "重新提交任务失败")
+			}
+			return s.existingTaskResponse(holder), nil
+		}
+	}
+
+	s.updateTaskStatus(taskID, TaskStatusPending, 0, "任务已重新提交，等待处理", "", nil)
+	if err := s.queue.Enqueue(ctx, queued.taskType, taskID, queued.payload); err != nil {
+		s.updateTaskStatus(taskID, TaskStatusFailed, 0, "", lastErr, nil)
+		return nil, errors.Wrap(err, errors.ErrCache, "重新提交任务失败")
+	}
+
+	return &TaskResponse{
+		TaskID:  taskID,
+		Status:  TaskStatusPending,
+		Message: "任务已重新提交",
+	}, nil
+}
+
+// FailQueuedTask records a removed task as failed the way a last failed
+// attempt would: its history is written and the user's generation lock is
+// released. The task is registered first, as it may never have run on this
+// instance.
+func (s *trainingService) FailQueuedTask(task *taskqueue.Task, reason string) {
+	header := decodeTaskHeader(task)
+	s.registerTask(header.TaskID, header.UserID, task.EnqueuedAt, queuedFrom(task))
+	s.updateTaskStatus(header.TaskID, TaskStatusFailed, 0, "", reason, nil)
+}
+
+// ListTaskHistory lists finished tasks, including those no longer in memory
+func (s *trainingService) ListTaskHistory(ctx context.Context, userID int64) ([]*model.GenerationTask, error) {
+	tasks, _, err := s.taskHistoryRepo.List(ctx, repository.GenerationTaskFilter{
+		UserID:    userID,
+		TaskTypes: trainingTaskTypes,
+	}, taskHistoryLimit, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取任务历史失败")
+	}
+	return tasks, nil
+}
+
+// SweepExpiredTasks removes finished tasks whose retention has run out
+func (s *trainingService) SweepExpiredTasks(ctx context.Context) (int, error) {
+	s.tasksMutex.Lock()
+	defer s.tasksMutex.Unlock()
+
+	now := time.Now()
+	removed := 0
+	for taskID, task := range s.tasks {
+		if taskExpired(task.ExpiresAt, now) {
+			delete(s.tasks, taskID)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
 // ListPlans retrieves training plans for a user with optional status filter
 // Requirements: 5.5
 func (s *trainingService) ListPlans(ctx context.Context, userID int64, status string) ([]*model.TrainingPlan, error) {
-	plans, err := s.planRepo.ListByUser(ctx, userID, status)
+	plans, err := s.planRepo.ListSummariesByUser(ctx, userID, status)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrDatabase, "获取训练计划列表失败")
 	}
@@ -263,6 +781,18 @@ func (s *trainingService) ListPlans(ctx context.Context, userID int64, status st
 // Requirements: 5.4
 func (s *trainingService) GetPlanDetail(ctx context.Context, planID int64, userID int64) (*model.TrainingPlan, error) {
 	plan, err := s.planRepo.GetByID(ctx, planID)
+	return ownedTrainingPlan(plan, err, userID)
+}
+
+// GetPlanSummary retrieves a specific training plan without its plan data
+func (s *trainingService) GetPlanSummary(ctx context.Context, planID int64, userID int64) (*model.TrainingPlan, error) {
+	plan, err := s.planRepo.GetSummaryByID(ctx, planID)
+	return ownedTrainingPlan(plan, err, userID)
+}
+
+// ownedTrainingPlan checks the result of reading a plan for userID: that the
+// read succeeded and found a plan the user owns
+func ownedTrainingPlan(plan *model.TrainingPlan, err error, userID int64) (*model.TrainingPlan, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrDatabase, "获取训练计划失败")
 	}
@@ -278,23 +808,95 @@ func (s *trainingService) GetPlanDetail(ctx context.Context, planID int64, userI
 	return plan, nil
 }
 
-// GetTodayTraining retrieves today's training schedule
+// UpdatePlan edits a training plan owned by the user
+func (s *trainingService) UpdatePlan(ctx context.Context, userID, planID int64, req *UpdatePlanRequest) (*model.TrainingPlan, error) {
+	plan, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.PlanName != nil {
+		name := strings.TrimSpace(*req.PlanName)
+		if name == "" {
+			return nil, errors.New(errors.ErrInvalidParam, "计划名称不能为空")
+		}
+		plan.PlanName = name
+	}
+	prevStatus := plan.Status
+	if req.Status != nil {
+		if err := s.transitionPlan(ctx, plan, *req.Status, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	plan.UpdatedAt = time.Now()
+
+	if err := s.planRepo.Update(ctx, plan); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "更新训练计划失败")
+	}
+	publishTrainingPlanStatusChanged(ctx, plan, prevStatus)
+	return plan, nil
+}
+
+// DeletePlan deletes a training plan owned by the user. Plans adjusted from
+// it lose their parent link through the foreign key.
+func (s *trainingService) DeletePlan(ctx context.Context, userID, planID int64, deleteRecords bool) error {
+	plan, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.planRepo.Delete(ctx, plan.ID, deleteRecords); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase, "删除训练计划失败")
+	}
+	publishTrainingPlanDeleted(ctx, plan)
+	return nil
+}
+
+// GetTodayTraining retrieves today's training schedule. The day is read
+// through the today cache; whether it was completed is always looked up.
 // Requirements: 5.6
 func (s *trainingService) GetTodayTraining(ctx context.Context, userID int64) (*model.DayPlan, error) {
 	today := time.Now()
 
-	dayPlan, err := s.planRepo.GetTodaySchedule(ctx, userID, today)
+	dayPlan, err := cachedTodaySchedule(ctx, s.todayCache, s.planRepo, userID, today)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrDatabase, "获取今日训练失败")
 	}
 
 	// Return nil if no training scheduled for today (not an error)
+	if dayPlan == nil {
+		return nil, nil
+	}
+
+	completion, err := s.planRepo.GetDayCompletion(ctx, dayPlan.PlanID, today)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取今日训练失败")
+	}
+	dayPlan.IsCompleted = completion != nil
 	return dayPlan, nil
 }
 
+// GetRestIntervals reads the preferences from the user record. Stored plans
+// keep the AI's rests, so changing a preference applies to every plan.
+func (s *trainingService) GetRestIntervals(ctx context.Context, userID int64) (model.RestIntervals, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return model.RestIntervals{}, errors.Wrap(err, errors.ErrDatabase, "获取休息偏好失败")
+	}
+	if user == nil {
+		return model.RestIntervals{}, nil
+	}
+	return user.RestIntervals(), nil
+}
+
 // RecordTraining records a training session with validation
 // Requirements: 7.1, 7.2
-func (s *trainingService) RecordTraining(ctx context.Context, userID int64, record *model.TrainingRecord) error {
+func (s *trainingService) RecordTraining(ctx context.Context, userID int64, record *model.TrainingRecord, allowDuplicate bool) error {
+	// Replay the original record for a repeated idempotency key
+	if replayed, err := s.replayIdempotentRecord(ctx, userID, record); err != nil || replayed {
+		return err
+	}
+
 	// Validate that workout date is not in the future
 	// Property 12: Future Date Rejection
 	now := time.Now()
@@ -307,6 +909,11 @@ func (s *trainingService) RecordTraining(ctx context.Context, userID int64, reco
 		return errors.New(errors.ErrInvalidParam, "训练日期不能是未来日期")
 	}
 
+	// Derive the duration from session timestamps and flag implausible values
+	if err := applySessionDuration(record, now); err != nil {
+		return err
+	}
+
 	// Set user ID
 	record.UserID = userID
 
@@ -321,14 +928,59 @@ func (s *trainingService) RecordTraining(ctx context.Context, userID int64, reco
 		}
 	}
 
+	// Reject what looks like a double submission unless the client confirms it
+	if !allowDuplicate {
+		duplicate, err := s.recordRepo.FindDuplicate(ctx, userID, workoutDate, record.WorkoutType, record.DurationMinutes, duplicateDurationToleranceMinutes)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrDatabase, "检查重复训练记录失败")
+		}
+		if duplicate != nil {
+			return errors.New(errors.ErrConflict, "已存在相同日期、类型和时长的训练记录").WithData(map[string]interface{}{
+				"existing_record_id": duplicate.ID,
+			})
+		}
+	}
+
 	// Create the record
 	if err := s.recordRepo.Create(ctx, record); err != nil {
+		// A concurrent request with the same idempotency key may have won the insert
+		if replayed, replayErr := s.replayIdempotentRecord(ctx, userID, record); replayErr == nil && replayed {
+			return nil
+		}
 		return errors.Wrap(err, errors.ErrDatabase, "保存训练记录失败")
 	}
+	publishTrainingRecordCreated(ctx, record)
+
+	// New personal records on main lifts raise the strength profile; the
+	// record itself is already saved, so a failure here is only logged
+	if _, err := s.strengthService.UpdateFromRecord(ctx, record); err != nil {
+		logger.Warn("Failed to update strength profile from training record",
+			zap.Int64("user_id", userID),
+			zap.Int64("record_id", record.ID),
+			zap.Error(err),
+		)
+	}
 
 	return nil
 }
 
+// replayIdempotentRecord loads the record previously created with the same
+// idempotency key into record and reports whether one was found
+func (s *trainingService) replayIdempotentRecord(ctx context.Context, userID int64, record *model.TrainingRecord) (bool, error) {
+	if record.IdempotencyKey == nil {
+		return false, nil
+	}
+	existing, err := s.recordRepo.GetByIdempotencyKey(ctx, userID, *record.IdempotencyKey)
+	if err != nil {
+		return false, errors.Wrap(err, errors.ErrDatabase, "获取训练记录失败")
+	}
+	if existing == nil {
+		return false, nil
+	}
+	*record = *existing
+	return true, nil
+}
+
 // GetTrainingHistory retrieves training records for a user
 // Requirements: 7.4
 func (s *trainingService) GetTrainingHistory(ctx context.Context, userID int64, startDate, endDate *time.Time) ([]*model.TrainingRecord, error) {
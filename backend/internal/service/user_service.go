@@ -15,6 +15,16 @@ type UpdateProfileRequest struct {
 	Nickname *string `json:"nickname" validate:"omitempty,min=1,max=50"`
 	Phone  *string `json:"phone" validate:"omitempty,max=20"`
 	Avatar *string `json:"avatar" validate:"omitempty,avatar"`
+	WeekStart *string `json:"week_start" validate:"omitempty,oneof=monday sunday"`
+	AutoRollover *bool `json:"auto_rollover"`
+	AnalyticsOptOut *bool `json:"analytics_opt_out"`
+	ResponseArchiveOptOut *bool `json:"response_archive_opt_out"`
+	// BusyWeekdays and BlackoutDates replace the stored lists when set; an
+	// empty list clears them
+	BusyWeekdays  *[]int    `json:"busy_weekdays" validate:"omitempty,max=7,dive,min=0,max=6"`
+	BlackoutDates *[]string `json:"blackout_dates" validate:"omitempty,max=60,dive,datetime=2006-01-02"`
+	// RestIntervals replaces the stored rest preferences when set
+	RestIntervals *model.RestIntervals `json:"rest_intervals"`
 }
 
 // BodyDataRequest represents the body data submission request
@@ -33,6 +43,7 @@ type FitnessGoalRequest struct {
 	GoalType        string     `json:"goal_type" validate:"required,max=100"`
 	GoalDescription *string    `json:"goal_description"`
 	TargetWeight    *float64   `json:"target_weight" validate:"omitempty,min=20,max=500"`
+	TargetBodyFat   *float64   `json:"target_body_fat" validate:"omitempty,min=0,max=100"`
 	Deadline        *time.Time `json:"deadline"`
 	Priority        int        `json:"priority" validate:"min=1,max=10"`
 }
@@ -122,6 +133,38 @@ func (s *userService) UpdateProfile(ctx context.Context, userID int64, req *Upda
 		user.Avatar = req.Avatar
 	}
 
+	if req.WeekStart != nil {
+		user.WeekStart = *req.WeekStart
+	}
+
+	if req.AutoRollover != nil {
+		user.AutoRollover = *req.AutoRollover
+	}
+
+	if req.AnalyticsOptOut != nil {
+		user.AnalyticsOptOut = *req.AnalyticsOptOut
+	}
+
+	if req.ResponseArchiveOptOut != nil {
+		user.ResponseArchiveOptOut = *req.ResponseArchiveOptOut
+	}
+
+	if req.BusyWeekdays != nil {
+		user.BusyWeekdays = busyWeekdaySlice(*req.BusyWeekdays)
+	}
+
+	if req.BlackoutDates != nil {
+		dates, err := blackoutDateSlice(*req.BlackoutDates, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		user.BlackoutDates = dates
+	}
+
+	if req.RestIntervals != nil {
+		user.RestPreferences = req.RestIntervals.JSONMap()
+	}
+
 	user.UpdatedAt = time.Now()
 
 	// Save updated user
@@ -222,6 +265,7 @@ func (s *userService) SetFitnessGoals(ctx context.Context, userID int64, req *Fi
 		InitialBodyFat:  initialBodyFat,
 		InitialMuscle:   initialMuscle,
 		TargetWeight:    req.TargetWeight,
+		TargetBodyFat:   req.TargetBodyFat,
 		Deadline:        req.Deadline,
 		Priority:        req.Priority,
 		Status:          string(model.GoalStatusActive),
@@ -232,6 +276,7 @@ func (s *userService) SetFitnessGoals(ctx context.Context, userID int64, req *Fi
 	if err := s.fitnessGoalRepo.Create(ctx, goal); err != nil {
 		return nil, errors.Wrap(err, errors.ErrDatabase, "failed to set fitness goal")
 	}
+	publishGoalSet(ctx, goal)
 
 	return goal, nil
 }
@@ -293,6 +338,7 @@ func (s *userService) UpdateFitnessGoals(ctx context.Context, userID int64, goal
 	goalToUpdate.GoalType = req.GoalType
 	goalToUpdate.GoalDescription = req.GoalDescription
 	goalToUpdate.TargetWeight = req.TargetWeight
+	goalToUpdate.TargetBodyFat = req.TargetBodyFat
 	goalToUpdate.Deadline = req.Deadline
 	goalToUpdate.Priority = req.Priority
 	goalToUpdate.UpdatedAt = time.Now()
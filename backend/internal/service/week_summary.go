@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/ai-fitness-planner/backend/internal/errors"
+	"github.com/ai-fitness-planner/backend/internal/model"
+)
+
+// Statuses of a day in a week summary
+const (
+	DayStatusRest      = "rest"
+	DayStatusUpcoming  = "upcoming"
+	DayStatusMissed    = "missed"
+	DayStatusPartial   = "partial"
+	DayStatusCompleted = "completed"
+)
+
+// WeekDaySummary is one day of a plan week with what was logged for it.
+// Completion is the share of the day's planned sets that were logged, as a
+// percentage; a record without exercise details completes the day.
+type WeekDaySummary struct {
+	Day             int
+	Date            string
+	Type            string
+	FocusArea       string
+	DurationMinutes int
+	Exercises       int
+	PlannedSets     int
+	LoggedSets      int
+	LoggedMinutes   int
+	Completion      float64
+	Status          string
+}
+
+// WeekSummary is a week of a training plan at a glance. CompletionRate
+// averages the completion of the training days due so far, counting today
+// only once it has been logged, and is 0 before any day is due.
+type WeekSummary struct {
+	PlanID         int64
+	Week           int
+	TotalWeeks     int
+	StartDate      string
+	EndDate        string
+	Days           []WeekDaySummary
+	TrainingDays   int
+	CompletedDays  int
+	PlannedMinutes int
+	LoggedMinutes  int
+	CompletionRate float64
+}
+
+// GetWeekSummary summarizes week n of one of the user's plans
+func (s *trainingService) GetWeekSummary(ctx context.Context, userID, planID int64, week int) (*WeekSummary, error) {
+	plan, err := s.GetPlanDetail(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	days := planWeekDays(plan, week)
+	if days == nil {
+		return nil, errors.New(errors.ErrNotFound, "计划中没有该周")
+	}
+
+	start, end := weekDateRange(plan, week, days)
+	records, err := s.recordRepo.ListByUser(ctx, userID, &start, &end)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase, "获取训练记录失败")
+	}
+
+	return summarizeWeek(plan, week, days, records, time.Now()), nil
+}
+
+// planWeekIndex returns the index of week n among a plan's weeks, looked up
+// by week number and else by position, or -1 when the plan has no such week
+func planWeekIndex(weeks []interface{}, n int) int {
+	for i, weekRaw := range weeks {
+		week, ok := weekRaw.(map[string]interface{})
+		if ok && jsonInt(week["week"]) == n {
+			return i
+		}
+	}
+	if n >= 1 && n <= len(weeks) {
+		if _, ok := weeks[n-1].(map[string]interface{}); ok {
+			return n - 1
+		}
+	}
+	return -1
+}
+
+// planWeekDays returns the days of week n of a plan, or nil when the plan
+// has no such week
+func planWeekDays(plan *model.TrainingPlan, n int) []map[string]interface{} {
+	weeks, _ := plan.PlanData["weeks"].([]interface{})
+	index := planWeekIndex(weeks, n)
+	if index < 0 {
+		return nil
+	}
+	found := weeks[index].(map[string]interface{})
+
+	rawDays, _ := found["days"].([]interface{})
+	days := make([]map[string]interface{}, 0, len(rawDays))
+	for _, dayRaw := range rawDays {
+		if day, ok := dayRaw.(map[string]interface{}); ok {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// weekDayDate is the date of a plan day, falling back to its position from
+// the plan's start when the AI gave none
+func weekDayDate(plan *model.TrainingPlan, week, index int, day map[string]interface{}) string {
+	if date, _ := day["date"].(string); date != "" {
+		return date
+	}
+	offset := index
+	if n := jsonInt(day["day"]); n >= 1 {
+		offset = n - 1
+	}
+	return plan.StartDate.AddDate(0, 0, (week-1)*7+offset).Format("2006-01-02")
+}
+
+// weekDateRange is the first and last date of a plan week
+func weekDateRange(plan *model.TrainingPlan, week int, days []map[string]interface{}) (time.Time, time.Time) {
+	start := dayStart(plan.StartDate).AddDate(0, 0, (week-1)*7)
+	end := start.AddDate(0, 0, 6)
+	for i, day := range days {
+		date, err := time.ParseInLocation("2006-01-02", weekDayDate(plan, week, i, day), time.Local)
+		if err != nil {
+			continue
+		}
+		if i == 0 || date.Before(start) {
+			start = date
+		}
+		if i == 0 || date.After(end) {
+			end = date
+		}
+	}
+	return start, end
+}
+
+// summarizeWeek matches records to the days of a plan week by date.
+// Records logged against another plan do not count.
+func summarizeWeek(plan *model.TrainingPlan, week int, days []map[string]interface{}, records []*model.TrainingRecord, now time.Time) *WeekSummary {
+	byDate := make(map[string][]*model.TrainingRecord)
+	for _, r := range records {
+		if r.PlanID != nil && *r.PlanID != plan.ID {
+			continue
+		}
+		date := r.WorkoutDate.Format("2006-01-02")
+		byDate[date] = append(byDate[date], r)
+	}
+
+	start, end := weekDateRange(plan, week, days)
+	summary := &WeekSummary{
+		PlanID:     plan.ID,
+		Week:       week,
+		TotalWeeks: plan.TotalWeeks,
+		StartDate:  start.Format("2006-01-02"),
+		EndDate:    end.Format("2006-01-02"),
+		Days:       make([]WeekDaySummary, 0, len(days)),
+	}
+
+	today := now.Format("2006-01-02")
+	due, completion := 0, 0.0
+	for i, day := range days {
+		exercises, _ := day["exercises"].([]interface{})
+		d := WeekDaySummary{
+			Day:             jsonInt(day["day"]),
+			Date:            weekDayDate(plan, week, i, day),
+			DurationMinutes: jsonInt(day["duration"]),
+			Exercises:       len(exercises),
+		}
+		d.Type, _ = day["type"].(string)
+		d.FocusArea, _ = day["focus_area"].(string)
+		if d.Day == 0 {
+			d.Day = i + 1
+		}
+		for _, exerciseRaw := range exercises {
+			if exercise, ok := exerciseRaw.(map[string]interface{}); ok {
+				d.PlannedSets += jsonInt(exercise["sets"])
+			}
+		}
+
+		logged := byDate[d.Date]
+		detailed := false
+		for _, r := range logged {
+			if r.DurationMinutes != nil {
+				d.LoggedMinutes += *r.DurationMinutes
+			}
+			for _, e := range exerciseRecords(r.Exercises) {
+				d.LoggedSets += e.Sets
+				detailed = true
+			}
+		}
+		summary.LoggedMinutes += d.LoggedMinutes
+
+		if isRestDay(day) {
+			d.Status = DayStatusRest
+			summary.Days = append(summary.Days, d)
+			continue
+		}
+
+		summary.TrainingDays++
+		summary.PlannedMinutes += d.DurationMinutes
+		if len(logged) > 0 {
+			d.Completion = 100
+			if detailed && d.PlannedSets > 0 {
+				d.Completion = math.Min(100, math.Round(float64(d.LoggedSets)*1000/float64(d.PlannedSets))/10)
+			}
+		}
+
+		switch {
+		case d.Completion >= 100:
+			d.Status = DayStatusCompleted
+			summary.CompletedDays++
+		case d.Completion > 0:
+			d.Status = DayStatusPartial
+		case d.Date < today:
+			d.Status = DayStatusMissed
+		default:
+			d.Status = DayStatusUpcoming
+		}
+		if d.Date < today || (d.Date == today && len(logged) > 0) {
+			due++
+			completion += d.Completion
+		}
+		summary.Days = append(summary.Days, d)
+	}
+
+	if due > 0 {
+		summary.CompletionRate = math.Round(completion*10/float64(due)) / 10
+	}
+	return summary
+}
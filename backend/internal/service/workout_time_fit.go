@@ -0,0 +1,188 @@
+package service
+
+import (
+	"math"
+	"regexp"
+
+	"github.com/ai-fitness-planner/backend/internal/model"
+	"github.com/ai-fitness-planner/backend/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Assumptions of the workout duration estimate
+const (
+	secondsPerRep             = 3
+	defaultRestSeconds        = 60
+	exerciseTransitionSeconds = 60 // setting up and moving to the next exercise
+	// timeFitTolerance lets a day run this much over the available time
+	// before it is shrunk, since the estimate is rough
+	timeFitTolerance = 1.1
+	// minFitSets is the fewest sets shrinking leaves an exercise with
+	minFitSets = 2
+)
+
+// Values of a day's time_fit field
+const (
+	timeFitShrunk  = "shrunk"  // sets were removed so the day fits
+	timeFitExceeds = "exceeds" // the day is still too long for the user
+)
+
+var (
+	minuteUnit = regexp.MustCompile(`(?i)\d\s*(?:mins?|minutes?)\b|分钟`)
+	secondUnit = regexp.MustCompile(`(?i)\d\s*(?:s|secs?|seconds?)\b|秒`)
+)
+
+// TimeFitResult summarizes how a plan was fitted to the user's available time
+type TimeFitResult struct {
+	AvailableMinutes int
+	ShrunkDays       int
+	ExceedingDays    int
+}
+
+// fitPlanToTime estimates how long each training day takes, from sets, reps,
+// rest and transitions between exercises, and records it on the day. A day
+// that runs clearly over availableMinutes loses sets, from the exercises
+// with the most sets first, until it fits; a day that cannot be shrunk
+// enough is flagged. The summary is stored with the plan data.
+func fitPlanToTime(planData model.JSONMap, availableMinutes int) *TimeFitResult {
+	result := &TimeFitResult{AvailableMinutes: availableMinutes}
+	if availableMinutes <= 0 {
+		return result
+	}
+	limit := float64(availableMinutes) * 60
+
+	weeks, _ := planData["weeks"].([]interface{})
+	for _, weekRaw := range weeks {
+		week, ok := weekRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		days, _ := week["days"].([]interface{})
+		for _, dayRaw := range days {
+			day, ok := dayRaw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if dayType, _ := day["type"].(string); dayType == "rest" {
+				continue
+			}
+			exercises, _ := day["exercises"].([]interface{})
+			if len(exercises) == 0 {
+				continue
+			}
+
+			seconds := daySeconds(exercises)
+			if seconds > limit*timeFitTolerance {
+				seconds = shrinkDay(exercises, limit)
+				if seconds <= limit*timeFitTolerance {
+					day["time_fit"] = timeFitShrunk
+					day["duration"] = int(math.Ceil(seconds / 60))
+					result.ShrunkDays++
+				} else {
+					day["time_fit"] = timeFitExceeds
+					result.ExceedingDays++
+				}
+			}
+			day["estimated_minutes"] = int(math.Ceil(seconds / 60))
+		}
+	}
+
+	planData["time_fit"] = map[string]interface{}{
+		"available_minutes": result.AvailableMinutes,
+		"shrunk_days":       result.ShrunkDays,
+		"exceeding_days":    result.ExceedingDays,
+	}
+	if result.ExceedingDays > 0 {
+		logger.Warn("Generated training plan has days longer than the user's available time",
+			zap.Int("available_minutes", availableMinutes),
+			zap.Int("exceeding_days", result.ExceedingDays),
+		)
+	}
+	return result
+}
+
+// shrinkDay removes one set at a time from the exercise with the most sets,
+// preferring later exercises since plans put the main lifts first, until
+// the day fits in limit seconds or no exercise can lose a set. It returns
+// the day's new estimate.
+func shrinkDay(exercises []interface{}, limit float64) float64 {
+	seconds := daySeconds(exercises)
+	for seconds > limit {
+		var target map[string]interface{}
+		most := minFitSets
+		for i := len(exercises) - 1; i >= 0; i-- {
+			exercise, ok := exercises[i].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if sets := jsonInt(exercise["sets"]); sets > most {
+				target, most = exercise, sets
+			}
+		}
+		if target == nil {
+			break
+		}
+		target["sets"] = most - 1
+		seconds = daySeconds(exercises)
+	}
+	return seconds
+}
+
+// daySeconds estimates the length of a day's exercises in seconds
+func daySeconds(exercises []interface{}) float64 {
+	var total float64
+	for _, exerciseRaw := range exercises {
+		exercise, ok := exerciseRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		sets := jsonInt(exercise["sets"])
+		if sets < 1 {
+			sets = 1
+		}
+		total += float64(sets)*setSeconds(exercise["reps"]) +
+			float64(sets-1)*restSeconds(exercise["rest"]) +
+			exerciseTransitionSeconds
+	}
+	return total
+}
+
+// setSeconds estimates one set from its reps: a count such as "8-12", or a
+// hold or interval such as "30秒" or "20 min"
+func setSeconds(reps interface{}) float64 {
+	n := repCount(reps)
+	s, _ := reps.(string)
+	switch durationUnit(s) {
+	case "minute":
+		return n * 60
+	case "second":
+		return n
+	}
+	return n * secondsPerRep
+}
+
+// restSeconds reads a rest such as "90s", "90秒" or "1-2分钟", which is in
+// seconds when no unit is given
+func restSeconds(rest interface{}) float64 {
+	n := repCount(rest)
+	if n <= 0 {
+		return defaultRestSeconds
+	}
+	s, _ := rest.(string)
+	if durationUnit(s) == "minute" {
+		return n * 60
+	}
+	return n
+}
+
+// durationUnit returns "minute" or "second" for text with a time unit, or
+// "" for a plain count
+func durationUnit(s string) string {
+	switch {
+	case minuteUnit.MatchString(s):
+		return "minute"
+	case secondUnit.MatchString(s):
+		return "second"
+	}
+	return ""
+}
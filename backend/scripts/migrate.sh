@@ -21,12 +21,6 @@ if [ ! -f "configs/config.yaml" ]; then
     exit 1
 fi
 
-# Check if schema file exists
-if [ ! -f "database/schema.sql" ]; then
-    echo -e "${RED}Error: database/schema.sql not found${NC}"
-    exit 1
-fi
-
 echo -e "${YELLOW}Starting database migration...${NC}"
 echo ""
 
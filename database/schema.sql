@@ -1,3 +1,6 @@
+-- 由 internal/migration 中的迁移文件生成，请勿手动修改；修改表结构请新增迁移文件后执行 make schema
+
+-- ==== 0001_init ====
 -- 用户基础信息表
 CREATE TABLE users (
     id BIGINT PRIMARY KEY AUTO_INCREMENT,
@@ -198,3 +201,579 @@ CREATE TABLE feedback_records (
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
     INDEX idx_user_date (user_id, created_at)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户反馈表';
+
+-- ==== 0002_admin_impersonation ====
+-- 用户角色
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user' COMMENT 'user/admin' AFTER status;
+
+-- 管理员代登录审计表
+CREATE TABLE impersonation_audit_logs (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    admin_id BIGINT NOT NULL COMMENT '管理员用户ID',
+    user_id BIGINT NOT NULL COMMENT '被代登录用户ID',
+    session_id VARCHAR(64) NOT NULL COMMENT '代登录会话ID',
+    method VARCHAR(10) NOT NULL COMMENT '请求方法',
+    path VARCHAR(500) NOT NULL COMMENT '请求路径',
+    status_code INT COMMENT '响应状态码',
+    reason VARCHAR(500) COMMENT '代登录原因',
+    ip_address VARCHAR(45) COMMENT '来源IP',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_admin_date (admin_id, created_at),
+    INDEX idx_user_date (user_id, created_at),
+    INDEX idx_session (session_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='管理员代登录审计表';
+
+-- ==== 0003_ai_abuse_flags ====
+-- AI调用异常检测记录表（管理员审核队列）
+CREATE TABLE ai_abuse_flags (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    ai_api_id BIGINT NOT NULL COMMENT '被暂停的AI API配置',
+    reason VARCHAR(50) NOT NULL COMMENT 'usage_spike/duplicate_prompt/non_ai_host',
+    details JSON COMMENT '检测详情',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' COMMENT 'pending/dismissed/confirmed',
+    suspended_until TIMESTAMP NULL COMMENT '暂停截止时间',
+    reviewed_by BIGINT COMMENT '审核管理员ID',
+    review_note VARCHAR(500) COMMENT '审核备注',
+    reviewed_at TIMESTAMP NULL COMMENT '审核时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (ai_api_id) REFERENCES ai_apis(id) ON DELETE CASCADE,
+    INDEX idx_status_date (status, created_at),
+    INDEX idx_api_status (ai_api_id, status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='AI调用异常检测表';
+
+-- ==== 0004_goal_completion ====
+-- 身体成分目标：目标体脂与自动达成时间
+ALTER TABLE fitness_goals
+    ADD COLUMN target_body_fat DECIMAL(4,2) COMMENT '目标体脂' AFTER target_weight,
+    ADD COLUMN completed_at TIMESTAMP NULL COMMENT '达成时间' AFTER status;
+
+-- 用户通知表（目标达成等）
+CREATE TABLE user_notifications (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    type VARCHAR(50) NOT NULL COMMENT '通知类型',
+    title VARCHAR(200) NOT NULL COMMENT '标题',
+    content TEXT COMMENT '内容',
+    data JSON COMMENT '附加数据',
+    read_at TIMESTAMP NULL COMMENT '已读时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_user_read (user_id, read_at),
+    INDEX idx_user_date (user_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户通知表';
+
+-- ==== 0005_weekly_check_ins ====
+-- 每周签到表
+CREATE TABLE weekly_check_ins (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    week_start DATE NOT NULL COMMENT '所属周（周一）',
+    weight DECIMAL(5,2) COMMENT '体重',
+    adherence_rating TINYINT NOT NULL COMMENT '计划执行自评 1-5',
+    energy_level TINYINT NOT NULL COMMENT '精力 1-5',
+    hunger_level TINYINT NOT NULL COMMENT '饥饿感 1-5',
+    notes TEXT COMMENT '备注',
+    photos JSON COMMENT '照片URL列表',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY uk_user_week (user_id, week_start)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='每周签到表';
+
+-- ==== 0006_strength_profiles ====
+-- 力量档案表（主项当前1RM）
+CREATE TABLE strength_profiles (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    lift VARCHAR(50) NOT NULL COMMENT 'squat/bench_press/deadlift/overhead_press',
+    one_rep_max DECIMAL(6,2) NOT NULL COMMENT '1RM(kg)',
+    source VARCHAR(20) NOT NULL DEFAULT 'manual' COMMENT 'manual/pr',
+    source_record_id BIGINT COMMENT '来源训练记录ID',
+    achieved_at DATE NOT NULL COMMENT '达成日期',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY uk_user_lift (user_id, lift)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='力量档案表';
+
+-- ==== 0007_equipment_profiles ====
+-- 器材清单表（家/健身房等多套配置，可随时编辑）
+CREATE TABLE equipment_profiles (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    name VARCHAR(50) NOT NULL COMMENT '名称',
+    location_type VARCHAR(20) NOT NULL DEFAULT 'other' COMMENT 'home/gym/other',
+    equipment JSON COMMENT '器材列表',
+    is_default BOOLEAN DEFAULT FALSE COMMENT '是否默认',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY uk_user_name (user_id, name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='器材清单表';
+
+-- 从每个用户最近一次评估中迁移已有器材数据
+INSERT INTO equipment_profiles (user_id, name, location_type, equipment, is_default)
+SELECT fa.user_id, '默认', 'other', fa.equipment_available, TRUE
+FROM fitness_assessments fa
+JOIN (
+    SELECT user_id, MAX(id) AS id
+    FROM fitness_assessments
+    GROUP BY user_id
+) latest ON latest.id = fa.id
+WHERE fa.equipment_available IS NOT NULL
+  AND JSON_LENGTH(fa.equipment_available) > 0;
+
+-- ==== 0008_equipment_profile_weekdays ====
+-- 器材清单按星期分配训练地点（0=周日 ... 6=周六）
+ALTER TABLE equipment_profiles
+    ADD COLUMN weekdays JSON COMMENT '适用星期' AFTER equipment;
+
+-- ==== 0009_training_session_times ====
+-- 训练记录：开始/结束时间与时长异常标记
+ALTER TABLE training_records
+    ADD COLUMN started_at TIMESTAMP NULL COMMENT '开始时间' AFTER duration_minutes,
+    ADD COLUMN ended_at TIMESTAMP NULL COMMENT '结束时间' AFTER started_at,
+    ADD COLUMN duration_flag VARCHAR(20) NULL COMMENT '时长异常标记' AFTER ended_at;
+
+-- ==== 0010_training_record_dedup ====
+-- 训练记录幂等键，防止网络重试导致重复提交
+ALTER TABLE training_records
+    ADD COLUMN idempotency_key VARCHAR(64) NULL COMMENT '幂等键' AFTER injury_report,
+    ADD UNIQUE KEY uk_user_idempotency_key (user_id, idempotency_key);
+
+-- ==== 0011_offline_sync ====
+-- 离线同步：客户端UUID、更新时间与删除墓碑
+ALTER TABLE training_records
+    ADD COLUMN client_id CHAR(36) NULL COMMENT '客户端UUID' AFTER user_id,
+    ADD COLUMN updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP AFTER created_at,
+    ADD COLUMN deleted_at TIMESTAMP NULL COMMENT '删除时间' AFTER updated_at;
+
+UPDATE training_records SET client_id = UUID() WHERE client_id IS NULL;
+
+ALTER TABLE training_records
+    MODIFY COLUMN client_id CHAR(36) NOT NULL COMMENT '客户端UUID',
+    ADD UNIQUE KEY uk_user_client_id (user_id, client_id),
+    ADD INDEX idx_user_updated (user_id, updated_at);
+
+ALTER TABLE nutrition_records
+    ADD COLUMN client_id CHAR(36) NULL COMMENT '客户端UUID' AFTER user_id,
+    ADD COLUMN updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP AFTER created_at,
+    ADD COLUMN deleted_at TIMESTAMP NULL COMMENT '删除时间' AFTER updated_at;
+
+UPDATE nutrition_records SET client_id = UUID() WHERE client_id IS NULL;
+
+ALTER TABLE nutrition_records
+    MODIFY COLUMN client_id CHAR(36) NOT NULL COMMENT '客户端UUID',
+    ADD UNIQUE KEY uk_user_client_id (user_id, client_id),
+    ADD INDEX idx_user_updated (user_id, updated_at);
+
+-- ==== 0012_organizations ====
+-- 组织（企业/健身房）表
+CREATE TABLE organizations (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(100) UNIQUE NOT NULL COMMENT '组织名称',
+    seat_limit INT NOT NULL COMMENT '席位上限（启用用户数）',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='组织表';
+
+-- 用户所属组织
+ALTER TABLE users
+    ADD COLUMN organization_id BIGINT NULL COMMENT '所属组织ID' AFTER role,
+    ADD INDEX idx_organization_status (organization_id, status);
+
+-- 组织用户邀请表
+CREATE TABLE user_invitations (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '被邀请用户ID',
+    organization_id BIGINT NOT NULL COMMENT '组织ID',
+    token_hash CHAR(64) NOT NULL COMMENT '邀请令牌SHA-256',
+    invited_by BIGINT NOT NULL COMMENT '发起邀请的管理员ID',
+    expires_at TIMESTAMP NOT NULL COMMENT '过期时间',
+    accepted_at TIMESTAMP NULL COMMENT '接受时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    UNIQUE KEY uk_token_hash (token_hash),
+    INDEX idx_user (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='组织用户邀请表';
+
+-- ==== 0013_ai_fallback_chain ====
+-- AI API备用链：主API重试失败后按顺序尝试备用API，并记录实际生成计划的服务提供商
+ALTER TABLE ai_apis
+    ADD COLUMN fallback_priority INT NULL COMMENT '备用顺序，越小越先尝试，NULL表示不参与' AFTER is_default,
+    ADD INDEX idx_user_fallback (user_id, fallback_priority);
+
+ALTER TABLE training_plans
+    ADD COLUMN ai_provider VARCHAR(50) NULL COMMENT '实际生成计划的服务提供商' AFTER ai_api_id;
+
+-- ==== 0014_ai_usage ====
+-- AI调用Token用量表，用于按API统计用量和估算费用
+CREATE TABLE ai_usage (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    ai_api_id BIGINT NOT NULL COMMENT 'AI API配置ID',
+    provider VARCHAR(50) NOT NULL COMMENT '服务提供商',
+    model VARCHAR(100) COMMENT '调用的模型',
+    purpose VARCHAR(30) NOT NULL COMMENT 'training_plan/nutrition_plan',
+    prompt_tokens INT NOT NULL DEFAULT 0 COMMENT '输入Token数',
+    completion_tokens INT NOT NULL DEFAULT 0 COMMENT '输出Token数',
+    total_tokens INT NOT NULL DEFAULT 0 COMMENT '总Token数',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (ai_api_id) REFERENCES ai_apis(id) ON DELETE CASCADE,
+    INDEX idx_api_date (ai_api_id, created_at),
+    INDEX idx_user_date (user_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='AI调用Token用量表';
+
+-- ==== 0015_user_week_start ====
+-- 用户每周起始日设置，用于按自然周统计趋势
+ALTER TABLE users
+    ADD COLUMN week_start VARCHAR(10) NOT NULL DEFAULT 'monday' COMMENT '每周起始日 monday/sunday' AFTER organization_id;
+
+-- ==== 0016_prompt_template_customized ====
+-- 标记被运营人员修改过的提示词模板，种子数据刷新时不再覆盖
+ALTER TABLE prompt_templates
+    ADD COLUMN is_customized TINYINT NOT NULL DEFAULT 0 COMMENT '是否已被修改' AFTER is_default;
+
+-- ==== 0017_ai_call_logs ====
+-- AI调用日志表，记录每次调用的结果和耗时，用于排查生成失败
+CREATE TABLE ai_call_logs (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    ai_api_id BIGINT NOT NULL COMMENT 'AI API配置ID',
+    provider VARCHAR(50) NOT NULL COMMENT '服务提供商',
+    model VARCHAR(100) COMMENT '调用的模型',
+    purpose VARCHAR(30) NOT NULL COMMENT 'training_plan/nutrition_plan',
+    status VARCHAR(20) NOT NULL COMMENT 'success/error',
+    error VARCHAR(500) COMMENT '错误信息',
+    latency_ms BIGINT NOT NULL DEFAULT 0 COMMENT '调用耗时(毫秒)',
+    prompt_tokens INT NOT NULL DEFAULT 0 COMMENT '输入Token数',
+    completion_tokens INT NOT NULL DEFAULT 0 COMMENT '输出Token数',
+    total_tokens INT NOT NULL DEFAULT 0 COMMENT '总Token数',
+    prompt_hash VARCHAR(16) NOT NULL COMMENT '提示词SHA-256前16位',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (ai_api_id) REFERENCES ai_apis(id) ON DELETE CASCADE,
+    INDEX idx_user_date (user_id, created_at),
+    INDEX idx_api_date (ai_api_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='AI调用日志表';
+
+-- ==== 0018_training_constraints ====
+-- 训练限制表，记录医生或康复师医嘱中的动作限制，生成训练计划时必须遵守
+CREATE TABLE training_constraints (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    source VARCHAR(20) NOT NULL DEFAULT 'other' COMMENT 'doctor/physio/other',
+    description VARCHAR(500) NOT NULL COMMENT '限制说明',
+    restricted_movements JSON COMMENT '禁止的动作关键词',
+    attachments JSON COMMENT '医嘱附件URL',
+    starts_on DATE NOT NULL COMMENT '生效日期',
+    expires_on DATE COMMENT '最后生效日期，为空表示长期有效',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_user_expires (user_id, expires_on)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='训练限制表';
+
+-- ==== 0019_ai_api_timeout ====
+-- AI API单次调用超时：未设置时使用全局配置 ai.timeout
+ALTER TABLE ai_apis
+    ADD COLUMN timeout_seconds INT NULL COMMENT '单次调用超时（秒），NULL表示使用全局配置' AFTER temperature;
+
+-- ==== 0020_plan_adjustments ====
+-- 计划调整：根据执行记录和反馈生成的新版本计划指向原计划
+ALTER TABLE training_plans
+    ADD COLUMN parent_plan_id BIGINT NULL COMMENT '调整前的原计划，NULL表示新生成' AFTER ai_provider,
+    ADD CONSTRAINT fk_training_plans_parent FOREIGN KEY (parent_plan_id) REFERENCES training_plans(id) ON DELETE SET NULL;
+
+ALTER TABLE nutrition_plans
+    ADD COLUMN parent_plan_id BIGINT NULL COMMENT '调整前的原计划，NULL表示新生成' AFTER ai_api_id,
+    ADD CONSTRAINT fk_nutrition_plans_parent FOREIGN KEY (parent_plan_id) REFERENCES nutrition_plans(id) ON DELETE SET NULL;
+
+-- ==== 0021_coach_messages ====
+-- AI教练对话表，保存用户与AI教练的完整对话历史，Redis中只缓存最近的消息
+CREATE TABLE coach_messages (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    role VARCHAR(20) NOT NULL COMMENT 'user/assistant',
+    content TEXT NOT NULL COMMENT '消息内容',
+    ai_api_id BIGINT COMMENT '生成回复的AI API，用户消息为空',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (ai_api_id) REFERENCES ai_apis(id) ON DELETE SET NULL,
+    INDEX idx_user_created (user_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='AI教练对话表';
+
+-- ==== 0022_macrocycles ====
+-- 宏周期：按顺序排列的训练计划块（如增肌→力量→巅峰），下一块根据上一块的执行情况生成
+CREATE TABLE macrocycles (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    name VARCHAR(200) NOT NULL COMMENT '宏周期名称',
+    goal VARCHAR(100) NOT NULL COMMENT '长期目标',
+    phases JSON NOT NULL COMMENT '计划的阶段顺序，如["hypertrophy","strength","peak"]',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='宏周期表';
+
+ALTER TABLE training_plans
+    ADD COLUMN macrocycle_id BIGINT NULL COMMENT '所属宏周期' AFTER parent_plan_id,
+    ADD COLUMN block_number INT NULL COMMENT '在宏周期中的块序号，从1开始' AFTER macrocycle_id,
+    ADD COLUMN block_phase VARCHAR(30) NULL COMMENT '块的训练阶段' AFTER block_number,
+    ADD CONSTRAINT fk_training_plans_macrocycle FOREIGN KEY (macrocycle_id) REFERENCES macrocycles(id) ON DELETE SET NULL,
+    ADD INDEX idx_macrocycle_block (macrocycle_id, block_number);
+
+-- ==== 0023_nutrition_calorie_basis ====
+-- 记录饮食计划每日热量的来源，缺少身体数据时使用默认值需要提示用户补充
+ALTER TABLE nutrition_plans
+    ADD COLUMN calorie_basis VARCHAR(20) NULL COMMENT 'provided/calculated/default，NULL表示记录前生成' AFTER daily_calories;
+
+-- ==== 0024_nutrition_leftover_lunch ====
+-- 剩菜安排：晚餐多做一份作为次日午餐，减少单人用户的做饭次数
+ALTER TABLE nutrition_plans
+    ADD COLUMN leftover_lunch TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否将晚餐剩菜安排为次日午餐' AFTER preferences;
+
+-- ==== 0025_nutrition_budget ====
+-- 食物预算：按档位或每周金额（元）约束饮食计划的食材选择，两者最多填写一个
+ALTER TABLE nutrition_plans
+    ADD COLUMN budget_level VARCHAR(10) NULL COMMENT '食物预算档位：low/medium/high' AFTER leftover_lunch,
+    ADD COLUMN weekly_budget DECIMAL(8,2) NULL COMMENT '每周食物预算（元）' AFTER budget_level;
+
+-- ==== 0026_plan_rollover ====
+-- 计划自动续期：用户开启后，计划结束时自动生成下一周期；rolled_over_at 防止同一计划重复续期
+ALTER TABLE users
+    ADD COLUMN auto_rollover TINYINT(1) NOT NULL DEFAULT 0 COMMENT '计划结束后是否自动生成下一周期' AFTER week_start;
+
+ALTER TABLE training_plans
+    ADD COLUMN rolled_over_at DATETIME NULL COMMENT '自动续期任务创建时间，NULL表示未续期' AFTER block_phase;
+
+ALTER TABLE nutrition_plans
+    ADD COLUMN rolled_over_at DATETIME NULL COMMENT '自动续期任务创建时间，NULL表示未续期' AFTER parent_plan_id;
+
+-- ==== 0027_generation_tasks ====
+-- 生成任务历史表，任务结束后写入，内存中的任务过期后仍可查询，便于排查生成失败
+CREATE TABLE generation_tasks (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    task_id VARCHAR(36) NOT NULL COMMENT '任务ID',
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    task_type VARCHAR(30) NOT NULL COMMENT 'training:generate/training:adjust/nutrition:generate/nutrition:adjust',
+    params JSON COMMENT '任务参数',
+    status VARCHAR(20) NOT NULL COMMENT 'completed/failed',
+    error TEXT COMMENT '失败原因',
+    plan_id BIGINT COMMENT '生成的计划ID',
+    duration_ms BIGINT NOT NULL DEFAULT 0 COMMENT '从创建到结束的耗时(毫秒)',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '任务创建时间',
+    finished_at TIMESTAMP NULL COMMENT '任务结束时间',
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY uk_task_id (task_id),
+    INDEX idx_user_created (user_id, created_at),
+    INDEX idx_status_created (status, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='生成任务历史表';
+
+-- ==== 0028_user_busy_days ====
+-- 用户忙碌日：每周固定没空训练的星期和具体的不可训练日期，生成计划时安排为休息日
+ALTER TABLE users
+    ADD COLUMN busy_weekdays JSON NULL COMMENT '每周固定忙碌的星期，0=周日' AFTER auto_rollover,
+    ADD COLUMN blackout_dates JSON NULL COMMENT '不可训练的日期 YYYY-MM-DD' AFTER busy_weekdays;
+
+-- ==== 0029_plan_lifecycle ====
+-- 训练计划生命周期：暂停时间用于恢复时顺延剩余训练日，完成时间记录计划结束的日期
+ALTER TABLE training_plans
+    ADD COLUMN paused_at DATETIME NULL COMMENT '暂停时间，NULL表示未暂停' AFTER rolled_over_at,
+    ADD COLUMN completed_at DATETIME NULL COMMENT '完成时间' AFTER paused_at;
+
+-- ==== 0030_plan_day_completions ====
+-- 训练计划日完成表：记录用户标记完成的计划日及对应的训练记录，今日训练据此返回完成状态
+CREATE TABLE plan_day_completions (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    plan_id BIGINT NOT NULL COMMENT '训练计划ID',
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    plan_date DATE NOT NULL COMMENT '完成的计划日',
+    record_id BIGINT NULL COMMENT '对应的训练记录ID',
+    completed_at TIMESTAMP NOT NULL COMMENT '标记完成时间',
+    FOREIGN KEY (plan_id) REFERENCES training_plans(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (record_id) REFERENCES training_records(id) ON DELETE SET NULL,
+    UNIQUE KEY uk_plan_date (plan_id, plan_date),
+    INDEX idx_user_date (user_id, plan_date)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='训练计划日完成表';
+
+-- ==== 0031_account_merges ====
+-- 账号合并审计表：管理员将重复注册账号的计划、记录、身体数据和目标迁移到保留账号，记录迁移内容以便撤销
+CREATE TABLE account_merges (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    admin_id BIGINT NOT NULL COMMENT '执行合并的管理员ID',
+    source_user_id BIGINT NOT NULL COMMENT '被合并并停用的账号',
+    target_user_id BIGINT NOT NULL COMMENT '保留的账号',
+    reason VARCHAR(500) NOT NULL COMMENT '合并原因',
+    changes JSON NOT NULL COMMENT '迁移的记录ID与冲突处理，用于撤销',
+    status VARCHAR(20) NOT NULL DEFAULT 'merged' COMMENT 'merged/reverted',
+    reverted_by BIGINT NULL COMMENT '撤销的管理员ID',
+    reverted_at TIMESTAMP NULL COMMENT '撤销时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (source_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (target_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_source_user (source_user_id),
+    INDEX idx_target_user (target_user_id),
+    INDEX idx_admin_date (admin_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='账号合并审计表';
+
+-- ==== 0032_prompt_template_rollout ====
+-- 提示词模板灰度：非默认模板按百分比分配给用户，计划记录生成时使用的模板以便比较各版本的效果
+ALTER TABLE prompt_templates
+    ADD COLUMN rollout_percent TINYINT NOT NULL DEFAULT 0 COMMENT '灰度比例（0-100），分配到的用户使用该模板' AFTER is_customized;
+
+ALTER TABLE training_plans
+    ADD COLUMN prompt_template_id BIGINT NULL COMMENT '生成计划时使用的提示词模板，NULL表示内置模板' AFTER parent_plan_id,
+    ADD INDEX idx_prompt_template (prompt_template_id);
+
+ALTER TABLE nutrition_plans
+    ADD COLUMN prompt_template_id BIGINT NULL COMMENT '生成计划时使用的提示词模板，NULL表示内置模板' AFTER parent_plan_id,
+    ADD INDEX idx_prompt_template (prompt_template_id);
+
+-- ==== 0033_analytics_export ====
+-- 分析数据导出：用户可选择退出，导出记录保存每次导出的时间窗口，下次从上次结束处继续
+ALTER TABLE users
+    ADD COLUMN analytics_opt_out TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否退出匿名分析数据导出' AFTER auto_rollover;
+
+CREATE TABLE analytics_exports (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    sink VARCHAR(20) NOT NULL COMMENT '导出目标 file/s3/kafka',
+    window_start DATE NOT NULL COMMENT '导出数据的起始日期（含）',
+    window_end DATE NOT NULL COMMENT '导出数据的结束日期（不含）',
+    events INT NOT NULL DEFAULT 0 COMMENT '导出的事件数',
+    suppressed INT NOT NULL DEFAULT 0 COMMENT '因分组人数不足k而未导出的事件数',
+    location VARCHAR(500) NOT NULL DEFAULT '' COMMENT '导出文件路径、对象键或主题',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_window_start (window_start)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='分析数据导出记录表';
+
+-- ==== 0034_rule_based_plans ====
+-- 规则模板生成：未配置AI API的用户按训练模板生成计划，这类计划没有对应的AI API
+ALTER TABLE training_plans
+    MODIFY COLUMN ai_api_id BIGINT NULL COMMENT '使用的AI API，按训练模板生成的计划为NULL';
+
+-- ==== 0035_service_tokens ====
+-- 内部服务令牌审计表：调度器、机器人和管理工具使用服务令牌时跳过会话校验和限流，每个请求都记录在此
+CREATE TABLE service_audit_logs (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    service VARCHAR(100) NOT NULL COMMENT '服务名称',
+    token_id VARCHAR(64) NOT NULL COMMENT '服务令牌ID',
+    user_id BIGINT NOT NULL COMMENT '服务代表的用户ID',
+    admin_id BIGINT COMMENT '签发令牌的管理员，仅签发记录有值',
+    method VARCHAR(10) NOT NULL COMMENT '请求方法',
+    path VARCHAR(500) NOT NULL COMMENT '请求路径',
+    status_code INT COMMENT '响应状态码',
+    ip_address VARCHAR(45) COMMENT '来源IP',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_service_date (service, created_at),
+    INDEX idx_user_date (user_id, created_at),
+    INDEX idx_token (token_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='内部服务令牌审计表';
+
+-- ==== 0036_training_plan_versions ====
+-- 训练计划版本：单周重新生成会替换计划中的一周，替换前的计划数据保存为历史版本，可随时查看
+ALTER TABLE training_plans
+    ADD COLUMN version INT NOT NULL DEFAULT 1 COMMENT '当前计划数据的版本号，每次单周重新生成加一' AFTER plan_data;
+
+CREATE TABLE training_plan_versions (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    plan_id BIGINT NOT NULL COMMENT '训练计划ID',
+    version INT NOT NULL COMMENT '被替换的版本号',
+    plan_data JSON NOT NULL COMMENT '该版本的计划数据',
+    week INT NULL COMMENT '替换该版本时重新生成的周',
+    feedback TEXT NULL COMMENT '重新生成时用户的反馈',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '该版本被替换的时间',
+    FOREIGN KEY (plan_id) REFERENCES training_plans(id) ON DELETE CASCADE,
+    UNIQUE KEY uk_plan_version (plan_id, version)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='训练计划历史版本表';
+
+-- ==== 0037_user_rest_preferences ====
+-- 用户组间休息偏好：按力量、增肌、有氧分别设置默认休息秒数，展示计划时替换AI给出的休息时间
+ALTER TABLE users
+    ADD COLUMN rest_preferences JSON NULL COMMENT '默认组间休息秒数 {strength, hypertrophy, cardio}' AFTER blackout_dates;
+
+-- ==== 0038_ai_response_archives ====
+-- AI响应存档：可选保存生成计划的AI原始响应（已脱敏）若干天，供客服核查用户对AI建议的投诉；用户可选择不存档，到期自动清除
+ALTER TABLE users
+    ADD COLUMN response_archive_opt_out TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否不存档生成计划的AI原始响应' AFTER rest_preferences;
+
+CREATE TABLE ai_response_archives (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    task_id VARCHAR(36) NOT NULL COMMENT '生成任务ID',
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    task_type VARCHAR(30) NOT NULL COMMENT '任务类型',
+    plan_id BIGINT NOT NULL COMMENT '生成的计划ID',
+    ai_api_id BIGINT NOT NULL COMMENT 'AI API配置ID',
+    provider VARCHAR(50) NOT NULL COMMENT '服务提供商',
+    model VARCHAR(100) COMMENT '模型',
+    response MEDIUMTEXT NOT NULL COMMENT '脱敏后的AI原始响应',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL COMMENT '到期自动清除时间',
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY uk_task_id (task_id),
+    INDEX idx_user_created (user_id, created_at),
+    INDEX idx_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='AI响应存档表';
+
+-- ==== 0039_service_token_revocations ====
+-- 服务令牌吊销表：管理员吊销的服务令牌在到期前即失效，每个服务令牌请求都会检查此表
+CREATE TABLE service_token_revocations (
+    token_id VARCHAR(64) PRIMARY KEY COMMENT '服务令牌ID',
+    service VARCHAR(100) NOT NULL COMMENT '服务名称',
+    user_id BIGINT NOT NULL COMMENT '服务代表的用户ID',
+    revoked_by BIGINT NOT NULL COMMENT '吊销令牌的管理员',
+    revoked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='服务令牌吊销表';
+
+-- 已应用的迁移
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version VARCHAR(100) PRIMARY KEY,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+INSERT INTO schema_migrations (version) VALUES ('0001_init');
+INSERT INTO schema_migrations (version) VALUES ('0002_admin_impersonation');
+INSERT INTO schema_migrations (version) VALUES ('0003_ai_abuse_flags');
+INSERT INTO schema_migrations (version) VALUES ('0004_goal_completion');
+INSERT INTO schema_migrations (version) VALUES ('0005_weekly_check_ins');
+INSERT INTO schema_migrations (version) VALUES ('0006_strength_profiles');
+INSERT INTO schema_migrations (version) VALUES ('0007_equipment_profiles');
+INSERT INTO schema_migrations (version) VALUES ('0008_equipment_profile_weekdays');
+INSERT INTO schema_migrations (version) VALUES ('0009_training_session_times');
+INSERT INTO schema_migrations (version) VALUES ('0010_training_record_dedup');
+INSERT INTO schema_migrations (version) VALUES ('0011_offline_sync');
+INSERT INTO schema_migrations (version) VALUES ('0012_organizations');
+INSERT INTO schema_migrations (version) VALUES ('0013_ai_fallback_chain');
+INSERT INTO schema_migrations (version) VALUES ('0014_ai_usage');
+INSERT INTO schema_migrations (version) VALUES ('0015_user_week_start');
+INSERT INTO schema_migrations (version) VALUES ('0016_prompt_template_customized');
+INSERT INTO schema_migrations (version) VALUES ('0017_ai_call_logs');
+INSERT INTO schema_migrations (version) VALUES ('0018_training_constraints');
+INSERT INTO schema_migrations (version) VALUES ('0019_ai_api_timeout');
+INSERT INTO schema_migrations (version) VALUES ('0020_plan_adjustments');
+INSERT INTO schema_migrations (version) VALUES ('0021_coach_messages');
+INSERT INTO schema_migrations (version) VALUES ('0022_macrocycles');
+INSERT INTO schema_migrations (version) VALUES ('0023_nutrition_calorie_basis');
+INSERT INTO schema_migrations (version) VALUES ('0024_nutrition_leftover_lunch');
+INSERT INTO schema_migrations (version) VALUES ('0025_nutrition_budget');
+INSERT INTO schema_migrations (version) VALUES ('0026_plan_rollover');
+INSERT INTO schema_migrations (version) VALUES ('0027_generation_tasks');
+INSERT INTO schema_migrations (version) VALUES ('0028_user_busy_days');
+INSERT INTO schema_migrations (version) VALUES ('0029_plan_lifecycle');
+INSERT INTO schema_migrations (version) VALUES ('0030_plan_day_completions');
+INSERT INTO schema_migrations (version) VALUES ('0031_account_merges');
+INSERT INTO schema_migrations (version) VALUES ('0032_prompt_template_rollout');
+INSERT INTO schema_migrations (version) VALUES ('0033_analytics_export');
+INSERT INTO schema_migrations (version) VALUES ('0034_rule_based_plans');
+INSERT INTO schema_migrations (version) VALUES ('0035_service_tokens');
+INSERT INTO schema_migrations (version) VALUES ('0036_training_plan_versions');
+INSERT INTO schema_migrations (version) VALUES ('0037_user_rest_preferences');
+INSERT INTO schema_migrations (version) VALUES ('0038_ai_response_archives');
+INSERT INTO schema_migrations (version) VALUES ('0039_service_token_revocations');
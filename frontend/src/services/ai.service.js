@@ -16,7 +16,7 @@ export const aiService = {
   /**
    * Add a new AI API configuration
    * @param {Object} configData - AI configuration data
-   * @param {string} configData.provider - AI provider (openai, wenxin, tongyi)
+   * @param {string} configData.provider - AI provider (openai, wenxin, tongyi, anthropic, deepseek, ollama)
    * @param {string} configData.name - Configuration name
    * @param {string} configData.api_endpoint - API endpoint URL
    * @param {string} configData.api_key - API key
@@ -48,7 +48,7 @@ export const nutritionService = {
    * @returns {Promise<Object>} Response with plan details
    */
   async fetchPlan(planId) {
-    return apiClient.get(`/nutrition-plans/${planId}`)
+    return apiClient.get(`/nutrition-plans/${planId}`, { params: { include: 'plan_data' } })
   },
 
   /**
@@ -45,7 +45,7 @@ export const trainingService = {
    * @returns {Promise<Object>} Response with plan details
    */
   async fetchPlan(planId) {
-    return apiClient.get(`/training-plans/${planId}`)
+    return apiClient.get(`/training-plans/${planId}`, { params: { include: 'plan_data' } })
   },
 
   /**
@@ -84,7 +84,7 @@ export const useAIConfigStore = defineStore('aiConfig', {
     /**
      * Add new AI configuration
      * @param {Object} configData - AI configuration data
-     * @param {string} configData.provider - AI provider (openai, wenxin, tongyi)
+     * @param {string} configData.provider - AI provider (openai, wenxin, tongyi, anthropic, deepseek, ollama)
      * @param {string} configData.api_key - API key
      * @param {string} configData.model_name - Model name
      */
@@ -151,7 +151,7 @@ export const useNutritionStore = defineStore('nutrition', {
       this.error = null
 
       try {
-        const response = await apiClient.get(`/nutrition-plans/${planId}`)
+        const response = await apiClient.get(`/nutrition-plans/${planId}`, { params: { include: 'plan_data' } })
         const plan = response.data?.plan || response.data
         
         // Update plan in local state
@@ -121,7 +121,7 @@ export const useTrainingStore = defineStore('training', {
       this.error = null
 
       try {
-        const response = await apiClient.get(`/training-plans/${planId}`)
+        const response = await apiClient.get(`/training-plans/${planId}`, { params: { include: 'plan_data' } })
         const plan = response.data?.plan || response.data
         
         // Update plan in local state
@@ -39,7 +39,10 @@ export const HTTP_STATUS = {
 export const AI_PROVIDERS = {
   OPENAI: 'openai',
   WENXIN: 'wenxin',
-  TONGYI: 'tongyi'
+  TONGYI: 'tongyi',
+  ANTHROPIC: 'anthropic',
+  DEEPSEEK: 'deepseek',
+  OLLAMA: 'ollama'
 }
 
 // Meal Types